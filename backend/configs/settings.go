@@ -1,6 +1,8 @@
 package configs
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"os"
 	"log"
 	"strconv"
@@ -18,6 +20,22 @@ type Settings struct {
 	DatabaseUser string
 	DatabasePassword string
 
+	SearchIndexer string
+
+	JWTSecret string
+	JWTExpiry int64
+	AdminName string
+	AdminEmail string
+	AdminPassword string
+
+	SecretsManager string
+	SecretsKey string
+	SecretsFile string
+
+	WorkflowWorkers int
+	WorkflowTimeout int64
+	WorkflowMaxAttempts int
+
 	SystemVersion string
 	SystemRevision string
 	SystemTimestamp int
@@ -92,6 +110,112 @@ func NewSettings() *Settings {
 		settings.DatabasePassword = "admin"
 	}
 
+	// Search
+	searchIndexer, ok := os.LookupEnv("SEARCH_INDEXER")
+	if ok {
+		settings.SearchIndexer = searchIndexer
+	} else {
+		settings.SearchIndexer = "postgres"
+	}
+
+	// Authentication
+	// A random secret would revoke every session, bot token and personal access token on restart
+	jwtSecret, ok := os.LookupEnv("JWT_SECRET")
+	if ok && jwtSecret != "" {
+		settings.JWTSecret = jwtSecret
+	} else {
+		log.Fatal("JWT_SECRET is required")
+	}
+
+	jwtExpiry, ok := os.LookupEnv("JWT_EXPIRY")
+	if ok {
+		expiry, err := strconv.ParseInt(jwtExpiry, 10, 64)
+		if err != nil || expiry <= 0 {
+			log.Fatal("Invalid jwt expiry")
+		}
+		settings.JWTExpiry = expiry
+	} else {
+		settings.JWTExpiry = 3600
+	}
+
+	adminName, ok := os.LookupEnv("ADMIN_NAME")
+	if ok {
+		settings.AdminName = adminName
+	} else {
+		settings.AdminName = "admin"
+	}
+
+	adminEmail, ok := os.LookupEnv("ADMIN_EMAIL")
+	if ok {
+		settings.AdminEmail = adminEmail
+	} else {
+		settings.AdminEmail = "admin@go-metadata.org"
+	}
+
+	// The admin gets a random password, printed once, when it is not set
+	adminPassword, ok := os.LookupEnv("ADMIN_PASSWORD")
+	if ok {
+		settings.AdminPassword = adminPassword
+	}
+
+	// Secrets
+	secretsManager, ok := os.LookupEnv("SECRETS_MANAGER")
+	if ok {
+		settings.SecretsManager = secretsManager
+	} else {
+		settings.SecretsManager = "local"
+	}
+
+	secretsKey, ok := os.LookupEnv("SECRETS_KEY")
+	if ok && secretsKey != "" {
+		settings.SecretsKey = secretsKey
+	} else if settings.SecretsManager == "local" {
+		// The secrets stored with a random key cannot be read after a restart
+		log.Println("WARNING: SECRETS_KEY is not set, the secrets are encrypted with a random key and cannot be decrypted after a restart. Set SECRETS_KEY outside of development")
+		settings.SecretsKey = randomSecret()
+	}
+
+	secretsFile, ok := os.LookupEnv("SECRETS_FILE")
+	if ok {
+		settings.SecretsFile = secretsFile
+	} else {
+		settings.SecretsFile = "secrets.json"
+	}
+
+	// Workflows
+	workflowWorkers, ok := os.LookupEnv("WORKFLOW_WORKERS")
+	if ok {
+		workers, err := strconv.Atoi(workflowWorkers)
+		if err != nil || workers <= 0 {
+			log.Fatal("Invalid workflow workers")
+		}
+		settings.WorkflowWorkers = workers
+	} else {
+		settings.WorkflowWorkers = 2
+	}
+
+	workflowTimeout, ok := os.LookupEnv("WORKFLOW_TIMEOUT")
+	if ok {
+		timeout, err := strconv.ParseInt(workflowTimeout, 10, 64)
+		if err != nil || timeout <= 0 {
+			log.Fatal("Invalid workflow timeout")
+		}
+		settings.WorkflowTimeout = timeout
+	} else {
+		settings.WorkflowTimeout = 300
+	}
+
+	workflowMaxAttempts, ok := os.LookupEnv("WORKFLOW_MAX_ATTEMPTS")
+	if ok {
+		maxAttempts, err := strconv.Atoi(workflowMaxAttempts)
+		if err != nil || maxAttempts <= 0 {
+			log.Fatal("Invalid workflow max attempts")
+		}
+		settings.WorkflowMaxAttempts = maxAttempts
+	} else {
+		settings.WorkflowMaxAttempts = 3
+	}
+
 	// System
 	version, ok := os.LookupEnv("VERSION")
 	if ok {
@@ -120,3 +244,13 @@ func NewSettings() *Settings {
 
 	return settings
 }
+
+func randomSecret() string {
+	b := make([]byte, 32)
+
+	if _, err := rand.Read(b); err != nil {
+		log.Fatal("Failed to generate a random secret")
+	}
+
+	return hex.EncodeToString(b)
+}
@@ -1,21 +1,33 @@
 package handlers
 
 import (
+	"database/sql"
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/nambuitechx/go-metadata/middlewares"
 	automationsModels "github.com/nambuitechx/go-metadata/models/automations"
 	baseModels "github.com/nambuitechx/go-metadata/models/base"
 	automationsServices "github.com/nambuitechx/go-metadata/services/automations"
+	policiesModels "github.com/nambuitechx/go-metadata/models/policies"
+	policiesServices "github.com/nambuitechx/go-metadata/services/policies"
 )
 
 type WorkflowEntityHandler struct {
 	WorkflowEntityService *automationsServices.WorkflowEntityService
+	WorkflowExecutor *automationsServices.WorkflowExecutor
+	Authorizer *policiesServices.Authorizer
 }
 
-func InitWorkflowEntityHandler(e *gin.Engine, workflowEntityService *automationsServices.WorkflowEntityService) {
+func InitWorkflowEntityHandler(
+	e *gin.Engine,
+	workflowEntityService *automationsServices.WorkflowEntityService,
+	workflowExecutor *automationsServices.WorkflowExecutor,
+	authorizer *policiesServices.Authorizer,
+) {
 	// Init handler
-	h := &WorkflowEntityHandler{ WorkflowEntityService: workflowEntityService }
+	h := &WorkflowEntityHandler{ WorkflowEntityService: workflowEntityService, WorkflowExecutor: workflowExecutor, Authorizer: authorizer }
 
 	// Add routes to engine
 	g := e.Group("api/v1/automations/workflows")
@@ -23,9 +35,11 @@ func InitWorkflowEntityHandler(e *gin.Engine, workflowEntityService *automations
 		g.GET("/health", h.health)
 		g.GET("/:id", h.getWorkflowEntityById)
 		g.GET("/name/:fqn", h.getWorkflowEntityByFqn)
+		g.GET("/:id/runs", h.getWorkflowRunsById)
 		g.GET("", h.getAllWorkflowEntities)
 		g.POST("", h.createWorkflowEntity)
 		g.POST("/trigger/:id", h.triggerWorkflowById)
+		g.POST("/:id/cancel", h.cancelWorkflowById)
 		g.PUT("", h.createOrUpdateWorkflowEntity)
 		g.PATCH("/:id", h.patchWorkflowById)
 		g.PATCH("/name/:fqn", h.patchWorkflowByFqn)
@@ -51,8 +65,28 @@ func (h *WorkflowEntityHandler) getAllWorkflowEntities(ctx *gin.Context) {
 		query.Limit = 10
 	}
 
+	if query.Include == "" {
+		query.Include = "non-deleted"
+	}
+
+	if _, err := baseModels.ValidateInclude(query.Include); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid query", "error": err.Error() })
+		return
+	}
+
+	before, after, err := baseModels.ValidatePagingCursors(query.Before, query.After)
+
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid query", "error": err.Error() })
+		return
+	}
+
+	if !middlewares.Authorize(ctx, h.Authorizer, policiesModels.NewResourceContext(policiesModels.WorkflowResource, ""), policiesModels.ViewAllOperation) {
+		return
+	}
+
 	// Get workflow entites
-	workflowEntities, err := h.WorkflowEntityService.GetAllWorkflowEntities(query.Limit, query.Offset)
+	workflowEntities, paging, err := h.WorkflowEntityService.GetAllWorkflowEntities(query.Include, query.Limit, before, after)
 
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Get all workflow failed", "error": err.Error() })
@@ -66,14 +100,16 @@ func (h *WorkflowEntityHandler) getAllWorkflowEntities(ctx *gin.Context) {
 	}
 
 	// Get paging
-	total, err := h.WorkflowEntityService.GetCountTableEntities()
+	total, err := h.WorkflowEntityService.GetCountTableEntities(query.Include)
 
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Get all dbservices failed", "error": err.Error() })
 		return
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{ "message": "Get all workflow successfully", "data": jsonValues, "paging": total })
+	paging.Total = total.Total
+
+	middlewares.JSONMasked(ctx, http.StatusOK, gin.H{ "message": "Get all workflow successfully", "data": jsonValues, "paging": paging })
 }
 
 func (h *WorkflowEntityHandler) getWorkflowEntityById(ctx *gin.Context) {
@@ -85,14 +121,44 @@ func (h *WorkflowEntityHandler) getWorkflowEntityById(ctx *gin.Context) {
 		return
 	}
 
-	workflowEntity, err := h.WorkflowEntityService.GetWorkflowEntityById(param.ID)
+	query := &baseModels.GetEntityQuery{}
+
+	if err := ctx.ShouldBindQuery(query); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid query", "error": err.Error() })
+		return
+	}
+
+	if query.Include == "" {
+		query.Include = "non-deleted"
+	}
+
+	if _, err := baseModels.ValidateInclude(query.Include); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid query", "error": err.Error() })
+		return
+	}
+
+	if !middlewares.Authorize(ctx, h.Authorizer, policiesModels.NewResourceContext(policiesModels.WorkflowResource, param.ID), policiesModels.ViewAllOperation) {
+		return
+	}
+
+	workflowEntity, err := h.WorkflowEntityService.GetWorkflowEntityById(param.ID, query.Include)
 	
 	if err != nil {
 		ctx.JSON(http.StatusNotFound, gin.H{ "message": "Workflow not found", "error": err.Error() })
 		return
 	}
 
-	ctx.JSON(http.StatusOK, workflowEntity.Json)
+	if middlewares.AllowSecrets(ctx, h.Authorizer, policiesModels.NewResourceContext(policiesModels.WorkflowResource, workflowEntity.ID)) {
+		if err := h.WorkflowEntityService.DecryptWorkflowConnection(workflowEntity.Json); err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{ "message": "Get workflow failed", "error": err.Error() })
+			return
+		}
+
+		ctx.JSON(http.StatusOK, workflowEntity.Json)
+		return
+	}
+
+	middlewares.JSONMasked(ctx, http.StatusOK, workflowEntity.Json)
 }
 
 func (h *WorkflowEntityHandler) getWorkflowEntityByFqn(ctx *gin.Context) {
@@ -104,17 +170,51 @@ func (h *WorkflowEntityHandler) getWorkflowEntityByFqn(ctx *gin.Context) {
 		return
 	}
 
-	workflowEntity, err := h.WorkflowEntityService.GetWorkflowEntityByFqn(param.FQN)
+	query := &baseModels.GetEntityQuery{}
+
+	if err := ctx.ShouldBindQuery(query); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid query", "error": err.Error() })
+		return
+	}
+
+	if query.Include == "" {
+		query.Include = "non-deleted"
+	}
+
+	if _, err := baseModels.ValidateInclude(query.Include); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid query", "error": err.Error() })
+		return
+	}
+
+	workflowEntity, err := h.WorkflowEntityService.GetWorkflowEntityByFqn(param.FQN, query.Include)
 	
 	if err != nil {
 		ctx.JSON(http.StatusNotFound, gin.H{ "message": "Workflow not found", "error": err.Error() })
 		return
 	}
 
-	ctx.JSON(http.StatusOK, workflowEntity.Json)
+	if !middlewares.Authorize(ctx, h.Authorizer, policiesModels.NewResourceContext(policiesModels.WorkflowResource, workflowEntity.ID), policiesModels.ViewAllOperation) {
+		return
+	}
+
+	if middlewares.AllowSecrets(ctx, h.Authorizer, policiesModels.NewResourceContext(policiesModels.WorkflowResource, workflowEntity.ID)) {
+		if err := h.WorkflowEntityService.DecryptWorkflowConnection(workflowEntity.Json); err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{ "message": "Get workflow failed", "error": err.Error() })
+			return
+		}
+
+		ctx.JSON(http.StatusOK, workflowEntity.Json)
+		return
+	}
+
+	middlewares.JSONMasked(ctx, http.StatusOK, workflowEntity.Json)
 }
 
 func (h *WorkflowEntityHandler) createWorkflowEntity(ctx *gin.Context) {
+	if !middlewares.Authorize(ctx, h.Authorizer, policiesModels.NewResourceContext(policiesModels.WorkflowResource, ""), policiesModels.CreateOperation) {
+		return
+	}
+
 	// Get payload
 	payload := &automationsModels.CreateWorkflowRequest{}
 
@@ -124,17 +224,21 @@ func (h *WorkflowEntityHandler) createWorkflowEntity(ctx *gin.Context) {
 	}
 
 	// Create workflow entity
-	workflowEntity, err := h.WorkflowEntityService.CreateWorkflowEntity(payload);
+	workflowEntity, err := h.WorkflowEntityService.CreateWorkflowEntity(payload, middlewares.GetUserName(ctx));
 
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Create workflow failed", "error": err.Error() })
 		return
 	}
 
-	ctx.JSON(http.StatusCreated, workflowEntity.Json)
+	middlewares.JSONMasked(ctx, http.StatusCreated, workflowEntity.Json)
 }
 
 func (h *WorkflowEntityHandler) createOrUpdateWorkflowEntity(ctx *gin.Context) {
+	if !middlewares.Authorize(ctx, h.Authorizer, policiesModels.NewResourceContext(policiesModels.WorkflowResource, ""), policiesModels.CreateOperation, policiesModels.EditAllOperation) {
+		return
+	}
+
 	// Get payload
 	payload := &automationsModels.CreateWorkflowRequest{}
 
@@ -144,14 +248,19 @@ func (h *WorkflowEntityHandler) createOrUpdateWorkflowEntity(ctx *gin.Context) {
 	}
 
 	// Create or update workflow entity
-	workflowEntity, err := h.WorkflowEntityService.CreateOrUpdateWorkflowEntity(payload);
+	workflowEntity, err := h.WorkflowEntityService.CreateOrUpdateWorkflowEntity(payload, middlewares.GetUserName(ctx));
+
+	if errors.Is(err, baseModels.ErrEntityDeleted) {
+		ctx.JSON(http.StatusConflict, gin.H{ "message": "Create or update workflow failed", "error": err.Error() })
+		return
+	}
 
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Create or update workflow failed", "error": err.Error() })
 		return
 	}
 
-	ctx.JSON(http.StatusOK, workflowEntity.Json)
+	middlewares.JSONMasked(ctx, http.StatusOK, workflowEntity.Json)
 }
 
 func (h *WorkflowEntityHandler) triggerWorkflowById(ctx *gin.Context) {
@@ -163,14 +272,106 @@ func (h *WorkflowEntityHandler) triggerWorkflowById(ctx *gin.Context) {
 		return
 	}
 
-	_, err := h.WorkflowEntityService.GetWorkflowEntityById(param.ID)
+	query := &automationsModels.TriggerWorkflowQuery{}
+
+	if err := ctx.ShouldBindQuery(query); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid query", "error": err.Error() })
+		return
+	}
+
+	if !middlewares.Authorize(ctx, h.Authorizer, policiesModels.NewResourceContext(policiesModels.WorkflowResource, param.ID), policiesModels.TriggerOperation) {
+		return
+	}
+
+	workflowEntity, err := h.WorkflowEntityService.GetWorkflowEntityById(param.ID, "non-deleted")
 	
 	if err != nil {
 		ctx.JSON(http.StatusNotFound, gin.H{ "message": "Workflow not found", "error": err.Error() })
 		return
 	}
 
-	ctx.JSON(http.StatusCreated, gin.H{ "message": "Trigger workflow by id successfully" })
+	workflowRunEntity, err := h.WorkflowExecutor.EnqueueWorkflow(workflowEntity, query.Timeout, middlewares.GetUserName(ctx))
+
+	if errors.Is(err, automationsModels.ErrWorkflowRunning) {
+		ctx.JSON(http.StatusConflict, gin.H{ "message": "Trigger workflow failed", "error": err.Error() })
+		return
+	}
+
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Trigger workflow failed", "error": err.Error() })
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, gin.H{ "message": "Trigger workflow by id successfully", "data": workflowRunEntity })
+}
+
+func (h *WorkflowEntityHandler) cancelWorkflowById(ctx *gin.Context) {
+	// Get param and validate
+	param := &automationsModels.GetWorkflowEntityByIdParam{}
+
+	if err := ctx.ShouldBindUri(param); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid param", "error": err.Error() })
+		return
+	}
+
+	if !middlewares.Authorize(ctx, h.Authorizer, policiesModels.NewResourceContext(policiesModels.WorkflowResource, param.ID), policiesModels.TriggerOperation) {
+		return
+	}
+
+	workflowEntity, err := h.WorkflowEntityService.GetWorkflowEntityById(param.ID, "non-deleted")
+	
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{ "message": "Workflow not found", "error": err.Error() })
+		return
+	}
+
+	workflowRunEntity, err := h.WorkflowExecutor.CancelWorkflow(workflowEntity, middlewares.GetUserName(ctx))
+
+	if errors.Is(err, automationsModels.ErrWorkflowNotRunning) {
+		ctx.JSON(http.StatusConflict, gin.H{ "message": "Cancel workflow failed", "error": err.Error() })
+		return
+	}
+
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{ "message": "Cancel workflow failed", "error": err.Error() })
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{ "message": "Cancel workflow by id successfully", "data": workflowRunEntity })
+}
+
+func (h *WorkflowEntityHandler) getWorkflowRunsById(ctx *gin.Context) {
+	// Get param and validate
+	param := &automationsModels.GetWorkflowEntityByIdParam{}
+
+	if err := ctx.ShouldBindUri(param); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid param", "error": err.Error() })
+		return
+	}
+
+	query := &automationsModels.GetWorkflowRunsQuery{}
+
+	if err := ctx.ShouldBindQuery(query); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid query", "error": err.Error() })
+		return
+	}
+
+	if query.Limit == 0 {
+		query.Limit = 10
+	}
+
+	if !middlewares.Authorize(ctx, h.Authorizer, policiesModels.NewResourceContext(policiesModels.WorkflowResource, param.ID), policiesModels.ViewAllOperation) {
+		return
+	}
+
+	workflowRunEntities, err := h.WorkflowExecutor.GetWorkflowRuns(param.ID, query.Limit)
+
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Get workflow runs failed", "error": err.Error() })
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{ "message": "Get workflow runs successfully", "data": workflowRunEntities })
 }
 
 func (h *WorkflowEntityHandler) patchWorkflowById(ctx *gin.Context) {
@@ -182,7 +383,7 @@ func (h *WorkflowEntityHandler) patchWorkflowById(ctx *gin.Context) {
 		return
 	}
 
-	workflowEntity, err := h.WorkflowEntityService.GetWorkflowEntityById(param.ID)
+	workflowEntity, err := h.WorkflowEntityService.GetWorkflowEntityById(param.ID, "non-deleted")
 	
 	if err != nil {
 		ctx.JSON(http.StatusNotFound, gin.H{ "message": "Workflow not found", "error": err.Error() })
@@ -197,14 +398,18 @@ func (h *WorkflowEntityHandler) patchWorkflowById(ctx *gin.Context) {
 		return
 	}
 
-	updatedWorkflowEntity, updatedWorkflowEntityErr := h.WorkflowEntityService.PatchWorkflowEntity(workflowEntity, payload)
+	if !middlewares.Authorize(ctx, h.Authorizer, policiesModels.NewResourceContext(policiesModels.WorkflowResource, workflowEntity.ID), policiesModels.GetPatchOperations(payload)...) {
+		return
+	}
+
+	updatedWorkflowEntity, updatedWorkflowEntityErr := h.WorkflowEntityService.PatchWorkflowEntity(workflowEntity, payload, middlewares.GetUserName(ctx))
 
 	if updatedWorkflowEntityErr != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Failed to patch workflow by id", "error": updatedWorkflowEntityErr.Error() })
 		return
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{ "message": "Patch workflow by id successfully", "data": updatedWorkflowEntity.Json })
+	middlewares.JSONMasked(ctx, http.StatusOK, gin.H{ "message": "Patch workflow by id successfully", "data": updatedWorkflowEntity.Json })
 }
 
 func (h *WorkflowEntityHandler) patchWorkflowByFqn(ctx *gin.Context) {
@@ -216,7 +421,7 @@ func (h *WorkflowEntityHandler) patchWorkflowByFqn(ctx *gin.Context) {
 		return
 	}
 
-	workflowEntity, err := h.WorkflowEntityService.GetWorkflowEntityByFqn(param.FQN)
+	workflowEntity, err := h.WorkflowEntityService.GetWorkflowEntityByFqn(param.FQN, "non-deleted")
 	
 	if err != nil {
 		ctx.JSON(http.StatusNotFound, gin.H{ "message": "Workflow not found", "error": err.Error() })
@@ -231,14 +436,18 @@ func (h *WorkflowEntityHandler) patchWorkflowByFqn(ctx *gin.Context) {
 		return
 	}
 
-	updatedWorkflowEntity, updatedWorkflowEntityErr := h.WorkflowEntityService.PatchWorkflowEntity(workflowEntity, payload)
+	if !middlewares.Authorize(ctx, h.Authorizer, policiesModels.NewResourceContext(policiesModels.WorkflowResource, workflowEntity.ID), policiesModels.GetPatchOperations(payload)...) {
+		return
+	}
+
+	updatedWorkflowEntity, updatedWorkflowEntityErr := h.WorkflowEntityService.PatchWorkflowEntity(workflowEntity, payload, middlewares.GetUserName(ctx))
 
 	if updatedWorkflowEntityErr != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Failed to patch workflow by id", "error": updatedWorkflowEntityErr.Error() })
 		return
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{ "message": "Patch workflow by id successfully", "data": updatedWorkflowEntity.Json })
+	middlewares.JSONMasked(ctx, http.StatusOK, gin.H{ "message": "Patch workflow by id successfully", "data": updatedWorkflowEntity.Json })
 }
 
 func (h *WorkflowEntityHandler) deleteWorkflowEntityById(ctx *gin.Context) {
@@ -250,7 +459,24 @@ func (h *WorkflowEntityHandler) deleteWorkflowEntityById(ctx *gin.Context) {
 		return
 	}
 
-	err := h.WorkflowEntityService.DeleteWorkflowEntityById(param.ID)
+	// Get query and validate
+	query := &baseModels.DeleteEntityQuery{}
+
+	if err := ctx.ShouldBindQuery(query); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid query", "error": err.Error() })
+		return
+	}
+
+	if !middlewares.Authorize(ctx, h.Authorizer, policiesModels.NewResourceContext(policiesModels.WorkflowResource, param.ID), policiesModels.DeleteOperation) {
+		return
+	}
+
+	err := h.WorkflowEntityService.DeleteWorkflowEntityById(param.ID, query.HardDelete, middlewares.GetUserName(ctx))
+
+	if errors.Is(err, sql.ErrNoRows) {
+		ctx.JSON(http.StatusNotFound, gin.H{ "message": "Workflow not found", "error": err.Error() })
+		return
+	}
 
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{ "message": "Delete workflow by id failed", "error": err.Error() })
@@ -269,7 +495,31 @@ func (h *WorkflowEntityHandler) deleteWorkflowEntityByFqn(ctx *gin.Context) {
 		return
 	}
 
-	err := h.WorkflowEntityService.DeleteWorkflowEntityByFqn(param.FQN)
+	// Get query and validate
+	query := &baseModels.DeleteEntityQuery{}
+
+	if err := ctx.ShouldBindQuery(query); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid query", "error": err.Error() })
+		return
+	}
+
+	exist, err := h.WorkflowEntityService.GetWorkflowEntityByFqn(param.FQN, "all")
+
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{ "message": "Workflow not found", "error": err.Error() })
+		return
+	}
+
+	if !middlewares.Authorize(ctx, h.Authorizer, policiesModels.NewResourceContext(policiesModels.WorkflowResource, exist.ID), policiesModels.DeleteOperation) {
+		return
+	}
+
+	err = h.WorkflowEntityService.DeleteWorkflowEntityByFqn(param.FQN, query.HardDelete, middlewares.GetUserName(ctx))
+
+	if errors.Is(err, sql.ErrNoRows) {
+		ctx.JSON(http.StatusNotFound, gin.H{ "message": "Workflow not found", "error": err.Error() })
+		return
+	}
 
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{ "message": "Delete workflow by fqn failed", "error": err.Error() })
@@ -1,20 +1,28 @@
 package handlers
 
 import (
+	"database/sql"
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/nambuitechx/go-metadata/middlewares"
+	typeModels "github.com/nambuitechx/go-metadata/models/type"
 	dataModels "github.com/nambuitechx/go-metadata/models/data"
+	baseModels "github.com/nambuitechx/go-metadata/models/base"
 	dataServices "github.com/nambuitechx/go-metadata/services/data"
+	policiesModels "github.com/nambuitechx/go-metadata/models/policies"
+	policiesServices "github.com/nambuitechx/go-metadata/services/policies"
 )
 
 type DatabaseEntityHandler struct {
 	DatabaseEntityService *dataServices.DatabaseEntityService
+	Authorizer *policiesServices.Authorizer
 }
 
-func InitDatabaseEntityHandler(e *gin.Engine, databaseEntityService *dataServices.DatabaseEntityService) {
+func InitDatabaseEntityHandler(e *gin.Engine, databaseEntityService *dataServices.DatabaseEntityService, authorizer *policiesServices.Authorizer) {
 	// Init handler
-	h := &DatabaseEntityHandler{ DatabaseEntityService: databaseEntityService }
+	h := &DatabaseEntityHandler{ DatabaseEntityService: databaseEntityService, Authorizer: authorizer }
 
 	// Add routes to engine
 	g := e.Group("api/v1/databases")
@@ -22,9 +30,14 @@ func InitDatabaseEntityHandler(e *gin.Engine, databaseEntityService *dataService
 		g.GET("/health", h.health)
 		g.GET("/:id", h.getDatabaseEntityById)
 		g.GET("/name/:fqn", h.getDatabaseEntityByFqn)
+		g.GET("/:id/versions", h.getDatabaseEntityVersions)
+		g.GET("/:id/versions/:version", h.getDatabaseEntityVersion)
 		g.GET("", h.getAllDatabaseEntities)
 		g.POST("", h.createDatabaseEntity)
 		g.PUT("", h.createOrUpdateDatabaseEntity)
+		g.PATCH("/:id", h.patchDatabaseEntityById)
+		g.PATCH("/name/:fqn", h.patchDatabaseEntityByFqn)
+		g.PUT("/restore", h.restoreDatabaseEntity)
 		g.DELETE("/:id", h.deleteDatabaseEntityById)
 		g.DELETE("/name/:fqn", h.deleteDatabaseEntityByFqn)
 	}
@@ -47,8 +60,28 @@ func (h *DatabaseEntityHandler) getAllDatabaseEntities(ctx *gin.Context) {
 		query.Limit = 10
 	}
 
+	if query.Include == "" {
+		query.Include = "non-deleted"
+	}
+
+	if _, err := baseModels.ValidateInclude(query.Include); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid query", "error": err.Error() })
+		return
+	}
+
+	before, after, err := baseModels.ValidatePagingCursors(query.Before, query.After)
+
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid query", "error": err.Error() })
+		return
+	}
+
+	if !middlewares.Authorize(ctx, h.Authorizer, policiesModels.NewResourceContext(policiesModels.DatabaseResource, ""), policiesModels.ViewAllOperation) {
+		return
+	}
+
 	// Get database entites
-	databaseEntities, err := h.DatabaseEntityService.GetAllDatabaseEntities(query.Service, query.Limit, query.Offset)
+	databaseEntities, paging, err := h.DatabaseEntityService.GetAllDatabaseEntities(query.Service, query.Include, query.Limit, before, after)
 
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Get all databases failed", "error": err.Error() })
@@ -62,14 +95,16 @@ func (h *DatabaseEntityHandler) getAllDatabaseEntities(ctx *gin.Context) {
 	}
 
 	// Get paging
-	total, err := h.DatabaseEntityService.GetCountDatabaseEntities(query.Service)
+	total, err := h.DatabaseEntityService.GetCountDatabaseEntities(query.Service, query.Include)
 
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Get all dbservices failed", "error": err.Error() })
 		return
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{ "message": "Get all databases successfully", "data": jsonValues, "paging": total })
+	paging.Total = total.Total
+
+	ctx.JSON(http.StatusOK, gin.H{ "message": "Get all databases successfully", "data": jsonValues, "paging": paging })
 }
 
 func (h *DatabaseEntityHandler) getDatabaseEntityById(ctx *gin.Context) {
@@ -81,13 +116,34 @@ func (h *DatabaseEntityHandler) getDatabaseEntityById(ctx *gin.Context) {
 		return
 	}
 
-	databaseEntity, err := h.DatabaseEntityService.GetDatabaseEntityById(param.ID)
+	query := &baseModels.GetEntityQuery{}
+
+	if err := ctx.ShouldBindQuery(query); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid query", "error": err.Error() })
+		return
+	}
+
+	if query.Include == "" {
+		query.Include = "non-deleted"
+	}
+
+	if _, err := baseModels.ValidateInclude(query.Include); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid query", "error": err.Error() })
+		return
+	}
+
+	if !middlewares.Authorize(ctx, h.Authorizer, policiesModels.NewResourceContext(policiesModels.DatabaseResource, param.ID), policiesModels.ViewAllOperation) {
+		return
+	}
+
+	databaseEntity, err := h.DatabaseEntityService.GetDatabaseEntityById(param.ID, query.Include)
 	
 	if err != nil {
 		ctx.JSON(http.StatusNotFound, gin.H{ "message": "Database not found", "error": err.Error() })
 		return
 	}
 
+	ctx.Header("ETag", baseModels.EntityTag(databaseEntity.Json.Version))
 	ctx.JSON(http.StatusOK, databaseEntity.Json)
 }
 
@@ -100,17 +156,105 @@ func (h *DatabaseEntityHandler) getDatabaseEntityByFqn(ctx *gin.Context) {
 		return
 	}
 
-	databaseEntity, err := h.DatabaseEntityService.GetDatabaseEntityByFqn(param.FQN)
+	query := &baseModels.GetEntityQuery{}
+
+	if err := ctx.ShouldBindQuery(query); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid query", "error": err.Error() })
+		return
+	}
+
+	if query.Include == "" {
+		query.Include = "non-deleted"
+	}
+
+	if _, err := baseModels.ValidateInclude(query.Include); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid query", "error": err.Error() })
+		return
+	}
+
+	databaseEntity, err := h.DatabaseEntityService.GetDatabaseEntityByFqn(param.FQN, query.Include)
 	
 	if err != nil {
 		ctx.JSON(http.StatusNotFound, gin.H{ "message": "Database not found", "error": err.Error() })
 		return
 	}
 
+	if !middlewares.Authorize(ctx, h.Authorizer, policiesModels.NewResourceContext(policiesModels.DatabaseResource, databaseEntity.ID), policiesModels.ViewAllOperation) {
+		return
+	}
+
+	ctx.Header("ETag", baseModels.EntityTag(databaseEntity.Json.Version))
 	ctx.JSON(http.StatusOK, databaseEntity.Json)
 }
 
+func (h *DatabaseEntityHandler) getDatabaseEntityVersions(ctx *gin.Context) {
+	// Get param and validate
+	param := &dataModels.GetDatabaseEntityByIdParam{}
+
+	if err := ctx.ShouldBindUri(param); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid param", "error": err.Error() })
+		return
+	}
+
+	if !middlewares.Authorize(ctx, h.Authorizer, policiesModels.NewResourceContext(policiesModels.DatabaseResource, param.ID), policiesModels.ViewAllOperation) {
+		return
+	}
+
+	entityHistory, err := h.DatabaseEntityService.GetDatabaseEntityVersions(param.ID)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		ctx.JSON(http.StatusNotFound, gin.H{ "message": "Database not found", "error": err.Error() })
+		return
+	}
+
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{ "message": "Get database versions failed", "error": err.Error() })
+		return
+	}
+
+	ctx.JSON(http.StatusOK, entityHistory)
+}
+
+func (h *DatabaseEntityHandler) getDatabaseEntityVersion(ctx *gin.Context) {
+	// Get param and validate
+	param := &typeModels.GetEntityVersionParam{}
+
+	if err := ctx.ShouldBindUri(param); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid param", "error": err.Error() })
+		return
+	}
+
+	version, err := typeModels.ValidateVersion(param.Version)
+
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid param", "error": err.Error() })
+		return
+	}
+
+	if !middlewares.Authorize(ctx, h.Authorizer, policiesModels.NewResourceContext(policiesModels.DatabaseResource, param.ID), policiesModels.ViewAllOperation) {
+		return
+	}
+
+	entityVersion, err := h.DatabaseEntityService.GetDatabaseEntityVersion(param.ID, version)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		ctx.JSON(http.StatusNotFound, gin.H{ "message": "Database version not found", "error": err.Error() })
+		return
+	}
+
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{ "message": "Get database version failed", "error": err.Error() })
+		return
+	}
+
+	ctx.JSON(http.StatusOK, entityVersion)
+}
+
 func (h *DatabaseEntityHandler) createDatabaseEntity(ctx *gin.Context) {
+	if !middlewares.Authorize(ctx, h.Authorizer, policiesModels.NewResourceContext(policiesModels.DatabaseResource, ""), policiesModels.CreateOperation) {
+		return
+	}
+
 	// Get payload
 	payload := &dataModels.CreateDatabaseEntityPayload{}
 
@@ -120,17 +264,22 @@ func (h *DatabaseEntityHandler) createDatabaseEntity(ctx *gin.Context) {
 	}
 
 	// Create database entity
-	databaseEntity, err := h.DatabaseEntityService.CreateDatabaseEntity(payload);
+	databaseEntity, err := h.DatabaseEntityService.CreateDatabaseEntity(payload, middlewares.GetUserName(ctx));
 
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Create database failed", "error": err.Error() })
 		return
 	}
 
+	ctx.Header("ETag", baseModels.EntityTag(databaseEntity.Json.Version))
 	ctx.JSON(http.StatusCreated, databaseEntity.Json)
 }
 
 func (h *DatabaseEntityHandler) createOrUpdateDatabaseEntity(ctx *gin.Context) {
+	if !middlewares.Authorize(ctx, h.Authorizer, policiesModels.NewResourceContext(policiesModels.DatabaseResource, ""), policiesModels.CreateOperation, policiesModels.EditAllOperation) {
+		return
+	}
+
 	// Get payload
 	payload := &dataModels.CreateDatabaseEntityPayload{}
 
@@ -140,14 +289,115 @@ func (h *DatabaseEntityHandler) createOrUpdateDatabaseEntity(ctx *gin.Context) {
 	}
 
 	// Create or update database entity
-	databaseEntity, err := h.DatabaseEntityService.CreateOrUpdateDatabaseEntity(payload);
+	databaseEntity, err := h.DatabaseEntityService.CreateOrUpdateDatabaseEntity(payload, ctx.GetHeader("If-Match"), middlewares.GetUserName(ctx))
+
+	if errors.Is(err, baseModels.ErrPreconditionFailed) {
+		ctx.JSON(http.StatusPreconditionFailed, gin.H{ "message": "Precondition failed", "error": err.Error() })
+		return
+	}
+
+	if errors.Is(err, baseModels.ErrEntityDeleted) {
+		ctx.JSON(http.StatusConflict, gin.H{ "message": "Create or update database failed", "error": err.Error() })
+		return
+	}
 
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Create or update database failed", "error": err.Error() })
 		return
 	}
 
-	ctx.JSON(http.StatusOK,  databaseEntity.Json)
+	ctx.Header("ETag", baseModels.EntityTag(databaseEntity.Json.Version))
+	ctx.JSON(http.StatusOK, databaseEntity.Json)
+}
+
+func (h *DatabaseEntityHandler) patchDatabaseEntityById(ctx *gin.Context) {
+	// Get param and validate
+	param := &dataModels.GetDatabaseEntityByIdParam{}
+
+	if err := ctx.ShouldBindUri(param); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid param", "error": err.Error() })
+		return
+	}
+
+	// Get payload
+	var payload []baseModels.JsonPatchOperation
+
+	if err := ctx.ShouldBindJSON(&payload); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid payload", "error": err.Error() })
+		return
+	}
+
+	databaseEntity, err := h.DatabaseEntityService.GetDatabaseEntityById(param.ID, "non-deleted")
+
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{ "message": "Database not found", "error": err.Error() })
+		return
+	}
+
+	if !middlewares.Authorize(ctx, h.Authorizer, policiesModels.NewResourceContext(policiesModels.DatabaseResource, databaseEntity.ID), policiesModels.GetPatchOperations(payload)...) {
+		return
+	}
+
+	// Patch database entity
+	updatedDatabaseEntity, err := h.DatabaseEntityService.PatchDatabaseEntity(databaseEntity, payload, ctx.GetHeader("If-Match"), middlewares.GetUserName(ctx))
+
+	if errors.Is(err, baseModels.ErrPreconditionFailed) {
+		ctx.JSON(http.StatusPreconditionFailed, gin.H{ "message": "Precondition failed", "error": err.Error() })
+		return
+	}
+
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Patch database by id failed", "error": err.Error() })
+		return
+	}
+
+	ctx.Header("ETag", baseModels.EntityTag(updatedDatabaseEntity.Json.Version))
+	ctx.JSON(http.StatusOK, updatedDatabaseEntity.Json)
+}
+
+func (h *DatabaseEntityHandler) patchDatabaseEntityByFqn(ctx *gin.Context) {
+	// Get param and validate
+	param := &dataModels.GetDatabaseEntityByFqnParam{}
+
+	if err := ctx.ShouldBindUri(param); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid param", "error": err.Error() })
+		return
+	}
+
+	// Get payload
+	var payload []baseModels.JsonPatchOperation
+
+	if err := ctx.ShouldBindJSON(&payload); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid payload", "error": err.Error() })
+		return
+	}
+
+	databaseEntity, err := h.DatabaseEntityService.GetDatabaseEntityByFqn(param.FQN, "non-deleted")
+
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{ "message": "Database not found", "error": err.Error() })
+		return
+	}
+
+	if !middlewares.Authorize(ctx, h.Authorizer, policiesModels.NewResourceContext(policiesModels.DatabaseResource, databaseEntity.ID), policiesModels.GetPatchOperations(payload)...) {
+		return
+	}
+
+	// Patch database entity
+	updatedDatabaseEntity, err := h.DatabaseEntityService.PatchDatabaseEntity(databaseEntity, payload, ctx.GetHeader("If-Match"), middlewares.GetUserName(ctx))
+
+	if errors.Is(err, baseModels.ErrPreconditionFailed) {
+		ctx.JSON(http.StatusPreconditionFailed, gin.H{ "message": "Precondition failed", "error": err.Error() })
+		return
+	}
+
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Patch database by fqn failed", "error": err.Error() })
+		return
+	}
+
+	ctx.Header("ETag", baseModels.EntityTag(updatedDatabaseEntity.Json.Version))
+	ctx.JSON(http.StatusOK, updatedDatabaseEntity.Json)
 }
 
 func (h *DatabaseEntityHandler) deleteDatabaseEntityById(ctx *gin.Context) {
@@ -159,7 +409,29 @@ func (h *DatabaseEntityHandler) deleteDatabaseEntityById(ctx *gin.Context) {
 		return
 	}
 
-	err := h.DatabaseEntityService.DeleteDatabaseEntityById(param.ID)
+	// Get query and validate
+	query := &baseModels.DeleteEntityQuery{}
+
+	if err := ctx.ShouldBindQuery(query); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid query", "error": err.Error() })
+		return
+	}
+
+	if !middlewares.Authorize(ctx, h.Authorizer, policiesModels.NewResourceContext(policiesModels.DatabaseResource, param.ID), policiesModels.DeleteOperation) {
+		return
+	}
+
+	err := h.DatabaseEntityService.DeleteDatabaseEntityById(param.ID, query.HardDelete, query.Recursive, middlewares.GetUserName(ctx))
+
+	if errors.Is(err, sql.ErrNoRows) {
+		ctx.JSON(http.StatusNotFound, gin.H{ "message": "Database not found", "error": err.Error() })
+		return
+	}
+
+	if errors.Is(err, baseModels.ErrEntityHasChildren) {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Delete database by id failed", "error": err.Error() })
+		return
+	}
 
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{ "message": "Delete database by id failed", "error": err.Error() })
@@ -178,7 +450,36 @@ func (h *DatabaseEntityHandler) deleteDatabaseEntityByFqn(ctx *gin.Context) {
 		return
 	}
 
-	err := h.DatabaseEntityService.DeleteDatabaseEntityByFqn(param.FQN)
+	// Get query and validate
+	query := &baseModels.DeleteEntityQuery{}
+
+	if err := ctx.ShouldBindQuery(query); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid query", "error": err.Error() })
+		return
+	}
+
+	exist, err := h.DatabaseEntityService.GetDatabaseEntityByFqn(param.FQN, "all")
+
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{ "message": "Database not found", "error": err.Error() })
+		return
+	}
+
+	if !middlewares.Authorize(ctx, h.Authorizer, policiesModels.NewResourceContext(policiesModels.DatabaseResource, exist.ID), policiesModels.DeleteOperation) {
+		return
+	}
+
+	err = h.DatabaseEntityService.DeleteDatabaseEntityByFqn(param.FQN, query.HardDelete, query.Recursive, middlewares.GetUserName(ctx))
+
+	if errors.Is(err, sql.ErrNoRows) {
+		ctx.JSON(http.StatusNotFound, gin.H{ "message": "Database not found", "error": err.Error() })
+		return
+	}
+
+	if errors.Is(err, baseModels.ErrEntityHasChildren) {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Delete database by fqn failed", "error": err.Error() })
+		return
+	}
 
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{ "message": "Delete database by fqn failed", "error": err.Error() })
@@ -187,3 +488,33 @@ func (h *DatabaseEntityHandler) deleteDatabaseEntityByFqn(ctx *gin.Context) {
 
 	ctx.JSON(http.StatusOK, gin.H{ "message": "Delete database by fqn successfully" })
 }
+
+func (h *DatabaseEntityHandler) restoreDatabaseEntity(ctx *gin.Context) {
+	// Get payload
+	payload := &baseModels.RestoreEntityPayload{}
+
+	if err := ctx.ShouldBindJSON(payload); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid payload", "error": err.Error() })
+		return
+	}
+
+	if !middlewares.Authorize(ctx, h.Authorizer, policiesModels.NewResourceContext(policiesModels.DatabaseResource, payload.ID), policiesModels.EditAllOperation) {
+		return
+	}
+
+	// Restore database entity and its children
+	databaseEntity, err := h.DatabaseEntityService.RestoreDatabaseEntity(payload.ID, middlewares.GetUserName(ctx))
+
+	if errors.Is(err, sql.ErrNoRows) {
+		ctx.JSON(http.StatusNotFound, gin.H{ "message": "Database not found", "error": err.Error() })
+		return
+	}
+
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Restore database failed", "error": err.Error() })
+		return
+	}
+
+	ctx.Header("ETag", baseModels.EntityTag(databaseEntity.Json.Version))
+	ctx.JSON(http.StatusOK, databaseEntity.Json)
+}
@@ -1,20 +1,28 @@
 package handlers
 
 import (
+	"database/sql"
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/nambuitechx/go-metadata/middlewares"
+	typeModels "github.com/nambuitechx/go-metadata/models/type"
 	dataModels "github.com/nambuitechx/go-metadata/models/data"
+	baseModels "github.com/nambuitechx/go-metadata/models/base"
 	dataServices "github.com/nambuitechx/go-metadata/services/data"
+	policiesModels "github.com/nambuitechx/go-metadata/models/policies"
+	policiesServices "github.com/nambuitechx/go-metadata/services/policies"
 )
 
 type DatabaseSchemaEntityHandler struct {
 	DatabaseSchemaEntityService *dataServices.DatabaseSchemaEntityService
+	Authorizer *policiesServices.Authorizer
 }
 
-func InitDatabaseSchemaEntityHandler(e *gin.Engine, databaseSchemaEntityService *dataServices.DatabaseSchemaEntityService) {
+func InitDatabaseSchemaEntityHandler(e *gin.Engine, databaseSchemaEntityService *dataServices.DatabaseSchemaEntityService, authorizer *policiesServices.Authorizer) {
 	// Init handler
-	h := &DatabaseSchemaEntityHandler{ DatabaseSchemaEntityService: databaseSchemaEntityService }
+	h := &DatabaseSchemaEntityHandler{ DatabaseSchemaEntityService: databaseSchemaEntityService, Authorizer: authorizer }
 
 	// Add routes to engine
 	g := e.Group("api/v1/databaseSchemas")
@@ -22,9 +30,14 @@ func InitDatabaseSchemaEntityHandler(e *gin.Engine, databaseSchemaEntityService
 		g.GET("/health", h.health)
 		g.GET("/:id", h.getDatabaseSchemaEntityById)
 		g.GET("/name/:fqn", h.getDatabaseSchemaEntityByFqn)
+		g.GET("/:id/versions", h.getDatabaseSchemaEntityVersions)
+		g.GET("/:id/versions/:version", h.getDatabaseSchemaEntityVersion)
 		g.GET("", h.getAllDatabaseSchemaEntities)
 		g.POST("", h.createDatabaseSchemaEntity)
 		g.PUT("", h.createOrUpdateDatabaseSchemaEntity)
+		g.PATCH("/:id", h.patchDatabaseSchemaEntityById)
+		g.PATCH("/name/:fqn", h.patchDatabaseSchemaEntityByFqn)
+		g.PUT("/restore", h.restoreDatabaseSchemaEntity)
 		g.DELETE("/:id", h.deleteDatabaseSchemaEntityById)
 		g.DELETE("/name/:fqn", h.deleteDatabaseSchemaEntityByFqn)
 	}
@@ -47,8 +60,28 @@ func (h *DatabaseSchemaEntityHandler) getAllDatabaseSchemaEntities(ctx *gin.Cont
 		query.Limit = 10
 	}
 
+	if query.Include == "" {
+		query.Include = "non-deleted"
+	}
+
+	if _, err := baseModels.ValidateInclude(query.Include); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid query", "error": err.Error() })
+		return
+	}
+
+	before, after, err := baseModels.ValidatePagingCursors(query.Before, query.After)
+
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid query", "error": err.Error() })
+		return
+	}
+
+	if !middlewares.Authorize(ctx, h.Authorizer, policiesModels.NewResourceContext(policiesModels.DatabaseSchemaResource, ""), policiesModels.ViewAllOperation) {
+		return
+	}
+
 	// Get database schema entites
-	databaseSchemaEntities, err := h.DatabaseSchemaEntityService.GetAllDatabaseSchemaEntities(query.Database, query.Limit, query.Offset)
+	databaseSchemaEntities, paging, err := h.DatabaseSchemaEntityService.GetAllDatabaseSchemaEntities(query.Database, query.Include, query.Limit, before, after)
 
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Get all database schemas failed", "error": err.Error() })
@@ -62,14 +95,16 @@ func (h *DatabaseSchemaEntityHandler) getAllDatabaseSchemaEntities(ctx *gin.Cont
 	}
 
 	// Get paging
-	total, err := h.DatabaseSchemaEntityService.GetCountDatabaseSchemaEntities(query.Database)
+	total, err := h.DatabaseSchemaEntityService.GetCountDatabaseSchemaEntities(query.Database, query.Include)
 
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Get all dbservices failed", "error": err.Error() })
 		return
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{ "message": "Get all database schemas successfully", "data": jsonValues, "paging": total })
+	paging.Total = total.Total
+
+	ctx.JSON(http.StatusOK, gin.H{ "message": "Get all database schemas successfully", "data": jsonValues, "paging": paging })
 }
 
 func (h *DatabaseSchemaEntityHandler) getDatabaseSchemaEntityById(ctx *gin.Context) {
@@ -81,13 +116,34 @@ func (h *DatabaseSchemaEntityHandler) getDatabaseSchemaEntityById(ctx *gin.Conte
 		return
 	}
 
-	databaseSchemaEntity, err := h.DatabaseSchemaEntityService.GetDatabaseSchemaEntityById(param.ID)
+	query := &baseModels.GetEntityQuery{}
+
+	if err := ctx.ShouldBindQuery(query); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid query", "error": err.Error() })
+		return
+	}
+
+	if query.Include == "" {
+		query.Include = "non-deleted"
+	}
+
+	if _, err := baseModels.ValidateInclude(query.Include); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid query", "error": err.Error() })
+		return
+	}
+
+	if !middlewares.Authorize(ctx, h.Authorizer, policiesModels.NewResourceContext(policiesModels.DatabaseSchemaResource, param.ID), policiesModels.ViewAllOperation) {
+		return
+	}
+
+	databaseSchemaEntity, err := h.DatabaseSchemaEntityService.GetDatabaseSchemaEntityById(param.ID, query.Include)
 	
 	if err != nil {
 		ctx.JSON(http.StatusNotFound, gin.H{ "message": "Database schema not found", "error": err.Error() })
 		return
 	}
 
+	ctx.Header("ETag", baseModels.EntityTag(databaseSchemaEntity.Json.Version))
 	ctx.JSON(http.StatusOK, databaseSchemaEntity.Json)
 }
 
@@ -100,17 +156,105 @@ func (h *DatabaseSchemaEntityHandler) getDatabaseSchemaEntityByFqn(ctx *gin.Cont
 		return
 	}
 
-	databaseSchemaEntity, err := h.DatabaseSchemaEntityService.GetDatabaseSchemaEntityByFqn(param.FQN)
+	query := &baseModels.GetEntityQuery{}
+
+	if err := ctx.ShouldBindQuery(query); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid query", "error": err.Error() })
+		return
+	}
+
+	if query.Include == "" {
+		query.Include = "non-deleted"
+	}
+
+	if _, err := baseModels.ValidateInclude(query.Include); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid query", "error": err.Error() })
+		return
+	}
+
+	databaseSchemaEntity, err := h.DatabaseSchemaEntityService.GetDatabaseSchemaEntityByFqn(param.FQN, query.Include)
 	
 	if err != nil {
 		ctx.JSON(http.StatusNotFound, gin.H{ "message": "Database schema not found", "error": err.Error() })
 		return
 	}
 
+	if !middlewares.Authorize(ctx, h.Authorizer, policiesModels.NewResourceContext(policiesModels.DatabaseSchemaResource, databaseSchemaEntity.ID), policiesModels.ViewAllOperation) {
+		return
+	}
+
+	ctx.Header("ETag", baseModels.EntityTag(databaseSchemaEntity.Json.Version))
 	ctx.JSON(http.StatusOK, databaseSchemaEntity.Json)
 }
 
+func (h *DatabaseSchemaEntityHandler) getDatabaseSchemaEntityVersions(ctx *gin.Context) {
+	// Get param and validate
+	param := &dataModels.GetDatabaseSchemaEntityByIdParam{}
+
+	if err := ctx.ShouldBindUri(param); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid param", "error": err.Error() })
+		return
+	}
+
+	if !middlewares.Authorize(ctx, h.Authorizer, policiesModels.NewResourceContext(policiesModels.DatabaseSchemaResource, param.ID), policiesModels.ViewAllOperation) {
+		return
+	}
+
+	entityHistory, err := h.DatabaseSchemaEntityService.GetDatabaseSchemaEntityVersions(param.ID)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		ctx.JSON(http.StatusNotFound, gin.H{ "message": "Database schema not found", "error": err.Error() })
+		return
+	}
+
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{ "message": "Get database schema versions failed", "error": err.Error() })
+		return
+	}
+
+	ctx.JSON(http.StatusOK, entityHistory)
+}
+
+func (h *DatabaseSchemaEntityHandler) getDatabaseSchemaEntityVersion(ctx *gin.Context) {
+	// Get param and validate
+	param := &typeModels.GetEntityVersionParam{}
+
+	if err := ctx.ShouldBindUri(param); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid param", "error": err.Error() })
+		return
+	}
+
+	version, err := typeModels.ValidateVersion(param.Version)
+
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid param", "error": err.Error() })
+		return
+	}
+
+	if !middlewares.Authorize(ctx, h.Authorizer, policiesModels.NewResourceContext(policiesModels.DatabaseSchemaResource, param.ID), policiesModels.ViewAllOperation) {
+		return
+	}
+
+	entityVersion, err := h.DatabaseSchemaEntityService.GetDatabaseSchemaEntityVersion(param.ID, version)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		ctx.JSON(http.StatusNotFound, gin.H{ "message": "Database schema version not found", "error": err.Error() })
+		return
+	}
+
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{ "message": "Get database schema version failed", "error": err.Error() })
+		return
+	}
+
+	ctx.JSON(http.StatusOK, entityVersion)
+}
+
 func (h *DatabaseSchemaEntityHandler) createDatabaseSchemaEntity(ctx *gin.Context) {
+	if !middlewares.Authorize(ctx, h.Authorizer, policiesModels.NewResourceContext(policiesModels.DatabaseSchemaResource, ""), policiesModels.CreateOperation) {
+		return
+	}
+
 	// Get payload
 	payload := &dataModels.CreateDatabaseSchemaEntityPayload{}
 
@@ -120,17 +264,22 @@ func (h *DatabaseSchemaEntityHandler) createDatabaseSchemaEntity(ctx *gin.Contex
 	}
 
 	// Create database schema entity
-	databaseSchemaEntity, err := h.DatabaseSchemaEntityService.CreateDatabaseSchemaEntity(payload);
+	databaseSchemaEntity, err := h.DatabaseSchemaEntityService.CreateDatabaseSchemaEntity(payload, middlewares.GetUserName(ctx));
 
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Create database schema failed", "error": err.Error() })
 		return
 	}
 
+	ctx.Header("ETag", baseModels.EntityTag(databaseSchemaEntity.Json.Version))
 	ctx.JSON(http.StatusCreated, databaseSchemaEntity.Json)
 }
 
 func (h *DatabaseSchemaEntityHandler) createOrUpdateDatabaseSchemaEntity(ctx *gin.Context) {
+	if !middlewares.Authorize(ctx, h.Authorizer, policiesModels.NewResourceContext(policiesModels.DatabaseSchemaResource, ""), policiesModels.CreateOperation, policiesModels.EditAllOperation) {
+		return
+	}
+
 	// Get payload
 	payload := &dataModels.CreateDatabaseSchemaEntityPayload{}
 
@@ -140,16 +289,117 @@ func (h *DatabaseSchemaEntityHandler) createOrUpdateDatabaseSchemaEntity(ctx *gi
 	}
 
 	// Create or update database schema entity
-	databaseSchemaEntity, err := h.DatabaseSchemaEntityService.CreateOrUpdateDatabaseSchemaEntity(payload);
+	databaseSchemaEntity, err := h.DatabaseSchemaEntityService.CreateOrUpdateDatabaseSchemaEntity(payload, ctx.GetHeader("If-Match"), middlewares.GetUserName(ctx))
+
+	if errors.Is(err, baseModels.ErrPreconditionFailed) {
+		ctx.JSON(http.StatusPreconditionFailed, gin.H{ "message": "Precondition failed", "error": err.Error() })
+		return
+	}
+
+	if errors.Is(err, baseModels.ErrEntityDeleted) {
+		ctx.JSON(http.StatusConflict, gin.H{ "message": "Create or update database schema failed", "error": err.Error() })
+		return
+	}
 
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Create or update database schema failed", "error": err.Error() })
 		return
 	}
 
+	ctx.Header("ETag", baseModels.EntityTag(databaseSchemaEntity.Json.Version))
 	ctx.JSON(http.StatusOK, databaseSchemaEntity.Json)
 }
 
+func (h *DatabaseSchemaEntityHandler) patchDatabaseSchemaEntityById(ctx *gin.Context) {
+	// Get param and validate
+	param := &dataModels.GetDatabaseSchemaEntityByIdParam{}
+
+	if err := ctx.ShouldBindUri(param); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid param", "error": err.Error() })
+		return
+	}
+
+	// Get payload
+	var payload []baseModels.JsonPatchOperation
+
+	if err := ctx.ShouldBindJSON(&payload); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid payload", "error": err.Error() })
+		return
+	}
+
+	databaseSchemaEntity, err := h.DatabaseSchemaEntityService.GetDatabaseSchemaEntityById(param.ID, "non-deleted")
+
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{ "message": "Database schema not found", "error": err.Error() })
+		return
+	}
+
+	if !middlewares.Authorize(ctx, h.Authorizer, policiesModels.NewResourceContext(policiesModels.DatabaseSchemaResource, databaseSchemaEntity.ID), policiesModels.GetPatchOperations(payload)...) {
+		return
+	}
+
+	// Patch database schema entity
+	updatedDatabaseSchemaEntity, err := h.DatabaseSchemaEntityService.PatchDatabaseSchemaEntity(databaseSchemaEntity, payload, ctx.GetHeader("If-Match"), middlewares.GetUserName(ctx))
+
+	if errors.Is(err, baseModels.ErrPreconditionFailed) {
+		ctx.JSON(http.StatusPreconditionFailed, gin.H{ "message": "Precondition failed", "error": err.Error() })
+		return
+	}
+
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Patch database schema by id failed", "error": err.Error() })
+		return
+	}
+
+	ctx.Header("ETag", baseModels.EntityTag(updatedDatabaseSchemaEntity.Json.Version))
+	ctx.JSON(http.StatusOK, updatedDatabaseSchemaEntity.Json)
+}
+
+func (h *DatabaseSchemaEntityHandler) patchDatabaseSchemaEntityByFqn(ctx *gin.Context) {
+	// Get param and validate
+	param := &dataModels.GetDatabaseSchemaEntityByFqnParam{}
+
+	if err := ctx.ShouldBindUri(param); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid param", "error": err.Error() })
+		return
+	}
+
+	// Get payload
+	var payload []baseModels.JsonPatchOperation
+
+	if err := ctx.ShouldBindJSON(&payload); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid payload", "error": err.Error() })
+		return
+	}
+
+	databaseSchemaEntity, err := h.DatabaseSchemaEntityService.GetDatabaseSchemaEntityByFqn(param.FQN, "non-deleted")
+
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{ "message": "Database schema not found", "error": err.Error() })
+		return
+	}
+
+	if !middlewares.Authorize(ctx, h.Authorizer, policiesModels.NewResourceContext(policiesModels.DatabaseSchemaResource, databaseSchemaEntity.ID), policiesModels.GetPatchOperations(payload)...) {
+		return
+	}
+
+	// Patch database schema entity
+	updatedDatabaseSchemaEntity, err := h.DatabaseSchemaEntityService.PatchDatabaseSchemaEntity(databaseSchemaEntity, payload, ctx.GetHeader("If-Match"), middlewares.GetUserName(ctx))
+
+	if errors.Is(err, baseModels.ErrPreconditionFailed) {
+		ctx.JSON(http.StatusPreconditionFailed, gin.H{ "message": "Precondition failed", "error": err.Error() })
+		return
+	}
+
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Patch database schema by fqn failed", "error": err.Error() })
+		return
+	}
+
+	ctx.Header("ETag", baseModels.EntityTag(updatedDatabaseSchemaEntity.Json.Version))
+	ctx.JSON(http.StatusOK, updatedDatabaseSchemaEntity.Json)
+}
+
 func (h *DatabaseSchemaEntityHandler) deleteDatabaseSchemaEntityById(ctx *gin.Context) {
 	// Get param and validate
 	param := &dataModels.GetDatabaseSchemaEntityByIdParam{}
@@ -159,7 +409,29 @@ func (h *DatabaseSchemaEntityHandler) deleteDatabaseSchemaEntityById(ctx *gin.Co
 		return
 	}
 
-	err := h.DatabaseSchemaEntityService.DeleteDatabaseSchemaEntityById(param.ID)
+	// Get query and validate
+	query := &baseModels.DeleteEntityQuery{}
+
+	if err := ctx.ShouldBindQuery(query); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid query", "error": err.Error() })
+		return
+	}
+
+	if !middlewares.Authorize(ctx, h.Authorizer, policiesModels.NewResourceContext(policiesModels.DatabaseSchemaResource, param.ID), policiesModels.DeleteOperation) {
+		return
+	}
+
+	err := h.DatabaseSchemaEntityService.DeleteDatabaseSchemaEntityById(param.ID, query.HardDelete, query.Recursive, middlewares.GetUserName(ctx))
+
+	if errors.Is(err, sql.ErrNoRows) {
+		ctx.JSON(http.StatusNotFound, gin.H{ "message": "Database schema not found", "error": err.Error() })
+		return
+	}
+
+	if errors.Is(err, baseModels.ErrEntityHasChildren) {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Delete database schema by id failed", "error": err.Error() })
+		return
+	}
 
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{ "message": "Delete database schema by id failed", "error": err.Error() })
@@ -178,7 +450,36 @@ func (h *DatabaseSchemaEntityHandler) deleteDatabaseSchemaEntityByFqn(ctx *gin.C
 		return
 	}
 
-	err := h.DatabaseSchemaEntityService.DeleteDatabaseSchemaEntityByFqn(param.FQN)
+	// Get query and validate
+	query := &baseModels.DeleteEntityQuery{}
+
+	if err := ctx.ShouldBindQuery(query); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid query", "error": err.Error() })
+		return
+	}
+
+	exist, err := h.DatabaseSchemaEntityService.GetDatabaseSchemaEntityByFqn(param.FQN, "all")
+
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{ "message": "Database schema not found", "error": err.Error() })
+		return
+	}
+
+	if !middlewares.Authorize(ctx, h.Authorizer, policiesModels.NewResourceContext(policiesModels.DatabaseSchemaResource, exist.ID), policiesModels.DeleteOperation) {
+		return
+	}
+
+	err = h.DatabaseSchemaEntityService.DeleteDatabaseSchemaEntityByFqn(param.FQN, query.HardDelete, query.Recursive, middlewares.GetUserName(ctx))
+
+	if errors.Is(err, sql.ErrNoRows) {
+		ctx.JSON(http.StatusNotFound, gin.H{ "message": "Database schema not found", "error": err.Error() })
+		return
+	}
+
+	if errors.Is(err, baseModels.ErrEntityHasChildren) {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Delete database schema by fqn failed", "error": err.Error() })
+		return
+	}
 
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{ "message": "Delete database schema by fqn failed", "error": err.Error() })
@@ -187,3 +488,33 @@ func (h *DatabaseSchemaEntityHandler) deleteDatabaseSchemaEntityByFqn(ctx *gin.C
 
 	ctx.JSON(http.StatusOK, gin.H{ "message": "Delete database schema by fqn successfully" })
 }
+
+func (h *DatabaseSchemaEntityHandler) restoreDatabaseSchemaEntity(ctx *gin.Context) {
+	// Get payload
+	payload := &baseModels.RestoreEntityPayload{}
+
+	if err := ctx.ShouldBindJSON(payload); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid payload", "error": err.Error() })
+		return
+	}
+
+	if !middlewares.Authorize(ctx, h.Authorizer, policiesModels.NewResourceContext(policiesModels.DatabaseSchemaResource, payload.ID), policiesModels.EditAllOperation) {
+		return
+	}
+
+	// Restore database schema entity and its children
+	databaseSchemaEntity, err := h.DatabaseSchemaEntityService.RestoreDatabaseSchemaEntity(payload.ID, middlewares.GetUserName(ctx))
+
+	if errors.Is(err, sql.ErrNoRows) {
+		ctx.JSON(http.StatusNotFound, gin.H{ "message": "Database schema not found", "error": err.Error() })
+		return
+	}
+
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Restore database schema failed", "error": err.Error() })
+		return
+	}
+
+	ctx.Header("ETag", baseModels.EntityTag(databaseSchemaEntity.Json.Version))
+	ctx.JSON(http.StatusOK, databaseSchemaEntity.Json)
+}
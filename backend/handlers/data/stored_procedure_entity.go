@@ -1,20 +1,28 @@
 package handlers
 
 import (
+	"database/sql"
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/nambuitechx/go-metadata/middlewares"
+	typeModels "github.com/nambuitechx/go-metadata/models/type"
 	dataModels "github.com/nambuitechx/go-metadata/models/data"
+	baseModels "github.com/nambuitechx/go-metadata/models/base"
 	dataServices "github.com/nambuitechx/go-metadata/services/data"
+	policiesModels "github.com/nambuitechx/go-metadata/models/policies"
+	policiesServices "github.com/nambuitechx/go-metadata/services/policies"
 )
 
 type StoredProcedureEntityHandler struct {
 	StoredProcedureEntityService *dataServices.StoredProcedureEntityService
+	Authorizer *policiesServices.Authorizer
 }
 
-func InitStoreProcedureEntityHandler(e *gin.Engine, storedProcedureEntityService *dataServices.StoredProcedureEntityService) {
+func InitStoreProcedureEntityHandler(e *gin.Engine, storedProcedureEntityService *dataServices.StoredProcedureEntityService, authorizer *policiesServices.Authorizer) {
 	// Init handler
-	h := &StoredProcedureEntityHandler{ StoredProcedureEntityService: storedProcedureEntityService }
+	h := &StoredProcedureEntityHandler{ StoredProcedureEntityService: storedProcedureEntityService, Authorizer: authorizer }
 
 	// Add routes to engine
 	g := e.Group("api/v1/storedProcedures")
@@ -22,9 +30,14 @@ func InitStoreProcedureEntityHandler(e *gin.Engine, storedProcedureEntityService
 		g.GET("/health", h.health)
 		g.GET("/:id", h.getStoredProcedureEntityById)
 		g.GET("/name/:fqn", h.getStoredProcedureEntityByFqn)
+		g.GET("/:id/versions", h.getStoredProcedureEntityVersions)
+		g.GET("/:id/versions/:version", h.getStoredProcedureEntityVersion)
 		g.GET("", h.getAllStoredProcedureEntities)
 		g.POST("", h.createStoredProcedureEntity)
 		g.PUT("", h.createOrUpdateStoredProcedureEntity)
+		g.PATCH("/:id", h.patchStoredProcedureEntityById)
+		g.PATCH("/name/:fqn", h.patchStoredProcedureEntityByFqn)
+		g.PUT("/restore", h.restoreStoredProcedureEntity)
 		g.DELETE("/:id", h.deleteStoredProcedureEntityById)
 		g.DELETE("/name/:fqn", h.deleteStoredProceduredEntityByFqn)
 	}
@@ -36,7 +49,7 @@ func (h *StoredProcedureEntityHandler) health(ctx *gin.Context) {
 
 func (h *StoredProcedureEntityHandler) getAllStoredProcedureEntities(ctx *gin.Context) {
 	// Get query and validate
-	query := &dataModels.GetTableEntitiesQuery{}
+	query := &dataModels.GetStoredProcedureEntitiesQuery{}
 
 	if err := ctx.ShouldBindQuery(query); err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid query", "error": err.Error() })
@@ -47,8 +60,28 @@ func (h *StoredProcedureEntityHandler) getAllStoredProcedureEntities(ctx *gin.Co
 		query.Limit = 10
 	}
 
+	if query.Include == "" {
+		query.Include = "non-deleted"
+	}
+
+	if _, err := baseModels.ValidateInclude(query.Include); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid query", "error": err.Error() })
+		return
+	}
+
+	before, after, err := baseModels.ValidatePagingCursors(query.Before, query.After)
+
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid query", "error": err.Error() })
+		return
+	}
+
+	if !middlewares.Authorize(ctx, h.Authorizer, policiesModels.NewResourceContext(policiesModels.StoredProcedureResource, ""), policiesModels.ViewAllOperation) {
+		return
+	}
+
 	// Get stored procedure entites
-	tableEntities, err := h.StoredProcedureEntityService.GetAllStoredProcedureEntities(query.DatabaseSchema, query.Limit, query.Offset)
+	tableEntities, paging, err := h.StoredProcedureEntityService.GetAllStoredProcedureEntities(query.DatabaseSchema, query.Include, query.Limit, before, after)
 
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Get all stored procedure failed", "error": err.Error() })
@@ -62,14 +95,16 @@ func (h *StoredProcedureEntityHandler) getAllStoredProcedureEntities(ctx *gin.Co
 	}
 
 	// Get paging
-	total, err := h.StoredProcedureEntityService.GetCountStoredProcedureEntities(query.DatabaseSchema)
+	total, err := h.StoredProcedureEntityService.GetCountStoredProcedureEntities(query.DatabaseSchema, query.Include)
 
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Get all stored procedure failed", "error": err.Error() })
 		return
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{ "message": "Get all stored procedure successfully", "data": jsonValues, "paging": total })
+	paging.Total = total.Total
+
+	ctx.JSON(http.StatusOK, gin.H{ "message": "Get all stored procedure successfully", "data": jsonValues, "paging": paging })
 }
 
 func (h *StoredProcedureEntityHandler) getStoredProcedureEntityById(ctx *gin.Context) {
@@ -81,13 +116,34 @@ func (h *StoredProcedureEntityHandler) getStoredProcedureEntityById(ctx *gin.Con
 		return
 	}
 
-	storedProcedureEntity, err := h.StoredProcedureEntityService.GetStoredProcedureEntityById(param.ID)
+	query := &baseModels.GetEntityQuery{}
+
+	if err := ctx.ShouldBindQuery(query); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid query", "error": err.Error() })
+		return
+	}
+
+	if query.Include == "" {
+		query.Include = "non-deleted"
+	}
+
+	if _, err := baseModels.ValidateInclude(query.Include); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid query", "error": err.Error() })
+		return
+	}
+
+	if !middlewares.Authorize(ctx, h.Authorizer, policiesModels.NewResourceContext(policiesModels.StoredProcedureResource, param.ID), policiesModels.ViewAllOperation) {
+		return
+	}
+
+	storedProcedureEntity, err := h.StoredProcedureEntityService.GetStoredProcedureEntityById(param.ID, query.Include)
 	
 	if err != nil {
 		ctx.JSON(http.StatusNotFound, gin.H{ "message": "Stored procedure not found", "error": err.Error() })
 		return
 	}
 
+	ctx.Header("ETag", baseModels.EntityTag(storedProcedureEntity.Json.Version))
 	ctx.JSON(http.StatusOK, storedProcedureEntity.Json)
 }
 
@@ -100,17 +156,105 @@ func (h *StoredProcedureEntityHandler) getStoredProcedureEntityByFqn(ctx *gin.Co
 		return
 	}
 
-	storedProcedureEntity, err := h.StoredProcedureEntityService.GetStoredProcedureEntityByFqn(param.FQN)
+	query := &baseModels.GetEntityQuery{}
+
+	if err := ctx.ShouldBindQuery(query); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid query", "error": err.Error() })
+		return
+	}
+
+	if query.Include == "" {
+		query.Include = "non-deleted"
+	}
+
+	if _, err := baseModels.ValidateInclude(query.Include); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid query", "error": err.Error() })
+		return
+	}
+
+	storedProcedureEntity, err := h.StoredProcedureEntityService.GetStoredProcedureEntityByFqn(param.FQN, query.Include)
 	
 	if err != nil {
 		ctx.JSON(http.StatusNotFound, gin.H{ "message": "Stored procedure not found", "error": err.Error() })
 		return
 	}
 
+	if !middlewares.Authorize(ctx, h.Authorizer, policiesModels.NewResourceContext(policiesModels.StoredProcedureResource, storedProcedureEntity.ID), policiesModels.ViewAllOperation) {
+		return
+	}
+
+	ctx.Header("ETag", baseModels.EntityTag(storedProcedureEntity.Json.Version))
 	ctx.JSON(http.StatusOK, storedProcedureEntity.Json)
 }
 
+func (h *StoredProcedureEntityHandler) getStoredProcedureEntityVersions(ctx *gin.Context) {
+	// Get param and validate
+	param := &dataModels.GetStoredProcedureEntityByIdParam{}
+
+	if err := ctx.ShouldBindUri(param); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid param", "error": err.Error() })
+		return
+	}
+
+	if !middlewares.Authorize(ctx, h.Authorizer, policiesModels.NewResourceContext(policiesModels.StoredProcedureResource, param.ID), policiesModels.ViewAllOperation) {
+		return
+	}
+
+	entityHistory, err := h.StoredProcedureEntityService.GetStoredProcedureEntityVersions(param.ID)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		ctx.JSON(http.StatusNotFound, gin.H{ "message": "Stored procedure not found", "error": err.Error() })
+		return
+	}
+
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{ "message": "Get stored procedure versions failed", "error": err.Error() })
+		return
+	}
+
+	ctx.JSON(http.StatusOK, entityHistory)
+}
+
+func (h *StoredProcedureEntityHandler) getStoredProcedureEntityVersion(ctx *gin.Context) {
+	// Get param and validate
+	param := &typeModels.GetEntityVersionParam{}
+
+	if err := ctx.ShouldBindUri(param); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid param", "error": err.Error() })
+		return
+	}
+
+	version, err := typeModels.ValidateVersion(param.Version)
+
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid param", "error": err.Error() })
+		return
+	}
+
+	if !middlewares.Authorize(ctx, h.Authorizer, policiesModels.NewResourceContext(policiesModels.StoredProcedureResource, param.ID), policiesModels.ViewAllOperation) {
+		return
+	}
+
+	entityVersion, err := h.StoredProcedureEntityService.GetStoredProcedureEntityVersion(param.ID, version)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		ctx.JSON(http.StatusNotFound, gin.H{ "message": "Stored procedure version not found", "error": err.Error() })
+		return
+	}
+
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{ "message": "Get stored procedure version failed", "error": err.Error() })
+		return
+	}
+
+	ctx.JSON(http.StatusOK, entityVersion)
+}
+
 func (h *StoredProcedureEntityHandler) createStoredProcedureEntity(ctx *gin.Context) {
+	if !middlewares.Authorize(ctx, h.Authorizer, policiesModels.NewResourceContext(policiesModels.StoredProcedureResource, ""), policiesModels.CreateOperation) {
+		return
+	}
+
 	// Get payload
 	payload := &dataModels.CreateStoredProcedureEntityPayload{}
 
@@ -120,17 +264,22 @@ func (h *StoredProcedureEntityHandler) createStoredProcedureEntity(ctx *gin.Cont
 	}
 
 	// Create stored procedure entity
-	storedProcedureEntity, err := h.StoredProcedureEntityService.CreateStoredProcedureEntity(payload);
+	storedProcedureEntity, err := h.StoredProcedureEntityService.CreateStoredProcedureEntity(payload, middlewares.GetUserName(ctx));
 
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Create stored procedure failed", "error": err.Error() })
 		return
 	}
 
+	ctx.Header("ETag", baseModels.EntityTag(storedProcedureEntity.Json.Version))
 	ctx.JSON(http.StatusCreated, storedProcedureEntity.Json)
 }
 
 func (h *StoredProcedureEntityHandler) createOrUpdateStoredProcedureEntity(ctx *gin.Context) {
+	if !middlewares.Authorize(ctx, h.Authorizer, policiesModels.NewResourceContext(policiesModels.StoredProcedureResource, ""), policiesModels.CreateOperation, policiesModels.EditAllOperation) {
+		return
+	}
+
 	// Get payload
 	payload := &dataModels.CreateStoredProcedureEntityPayload{}
 
@@ -140,16 +289,117 @@ func (h *StoredProcedureEntityHandler) createOrUpdateStoredProcedureEntity(ctx *
 	}
 
 	// Create or update stored procedure entity
-	storedProcedureEntity, err := h.StoredProcedureEntityService.CreateOrUpdateStoredProcedureEntity(payload);
+	storedProcedureEntity, err := h.StoredProcedureEntityService.CreateOrUpdateStoredProcedureEntity(payload, ctx.GetHeader("If-Match"), middlewares.GetUserName(ctx))
+
+	if errors.Is(err, baseModels.ErrPreconditionFailed) {
+		ctx.JSON(http.StatusPreconditionFailed, gin.H{ "message": "Precondition failed", "error": err.Error() })
+		return
+	}
+
+	if errors.Is(err, baseModels.ErrEntityDeleted) {
+		ctx.JSON(http.StatusConflict, gin.H{ "message": "Create or update stored procedure failed", "error": err.Error() })
+		return
+	}
 
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Create or update stored procedure failed", "error": err.Error() })
 		return
 	}
 
+	ctx.Header("ETag", baseModels.EntityTag(storedProcedureEntity.Json.Version))
 	ctx.JSON(http.StatusOK, storedProcedureEntity.Json)
 }
 
+func (h *StoredProcedureEntityHandler) patchStoredProcedureEntityById(ctx *gin.Context) {
+	// Get param and validate
+	param := &dataModels.GetStoredProcedureEntityByIdParam{}
+
+	if err := ctx.ShouldBindUri(param); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid param", "error": err.Error() })
+		return
+	}
+
+	// Get payload
+	var payload []baseModels.JsonPatchOperation
+
+	if err := ctx.ShouldBindJSON(&payload); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid payload", "error": err.Error() })
+		return
+	}
+
+	storedProcedureEntity, err := h.StoredProcedureEntityService.GetStoredProcedureEntityById(param.ID, "non-deleted")
+
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{ "message": "Stored procedure not found", "error": err.Error() })
+		return
+	}
+
+	if !middlewares.Authorize(ctx, h.Authorizer, policiesModels.NewResourceContext(policiesModels.StoredProcedureResource, storedProcedureEntity.ID), policiesModels.GetPatchOperations(payload)...) {
+		return
+	}
+
+	// Patch stored procedure entity
+	updatedStoredProcedureEntity, err := h.StoredProcedureEntityService.PatchStoredProcedureEntity(storedProcedureEntity, payload, ctx.GetHeader("If-Match"), middlewares.GetUserName(ctx))
+
+	if errors.Is(err, baseModels.ErrPreconditionFailed) {
+		ctx.JSON(http.StatusPreconditionFailed, gin.H{ "message": "Precondition failed", "error": err.Error() })
+		return
+	}
+
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Patch stored procedure by id failed", "error": err.Error() })
+		return
+	}
+
+	ctx.Header("ETag", baseModels.EntityTag(updatedStoredProcedureEntity.Json.Version))
+	ctx.JSON(http.StatusOK, updatedStoredProcedureEntity.Json)
+}
+
+func (h *StoredProcedureEntityHandler) patchStoredProcedureEntityByFqn(ctx *gin.Context) {
+	// Get param and validate
+	param := &dataModels.GetStoredProcedureEntityByFqnParam{}
+
+	if err := ctx.ShouldBindUri(param); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid param", "error": err.Error() })
+		return
+	}
+
+	// Get payload
+	var payload []baseModels.JsonPatchOperation
+
+	if err := ctx.ShouldBindJSON(&payload); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid payload", "error": err.Error() })
+		return
+	}
+
+	storedProcedureEntity, err := h.StoredProcedureEntityService.GetStoredProcedureEntityByFqn(param.FQN, "non-deleted")
+
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{ "message": "Stored procedure not found", "error": err.Error() })
+		return
+	}
+
+	if !middlewares.Authorize(ctx, h.Authorizer, policiesModels.NewResourceContext(policiesModels.StoredProcedureResource, storedProcedureEntity.ID), policiesModels.GetPatchOperations(payload)...) {
+		return
+	}
+
+	// Patch stored procedure entity
+	updatedStoredProcedureEntity, err := h.StoredProcedureEntityService.PatchStoredProcedureEntity(storedProcedureEntity, payload, ctx.GetHeader("If-Match"), middlewares.GetUserName(ctx))
+
+	if errors.Is(err, baseModels.ErrPreconditionFailed) {
+		ctx.JSON(http.StatusPreconditionFailed, gin.H{ "message": "Precondition failed", "error": err.Error() })
+		return
+	}
+
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Patch stored procedure by fqn failed", "error": err.Error() })
+		return
+	}
+
+	ctx.Header("ETag", baseModels.EntityTag(updatedStoredProcedureEntity.Json.Version))
+	ctx.JSON(http.StatusOK, updatedStoredProcedureEntity.Json)
+}
+
 func (h *StoredProcedureEntityHandler) deleteStoredProcedureEntityById(ctx *gin.Context) {
 	// Get param and validate
 	param := &dataModels.GetStoredProcedureEntityByIdParam{}
@@ -159,7 +409,24 @@ func (h *StoredProcedureEntityHandler) deleteStoredProcedureEntityById(ctx *gin.
 		return
 	}
 
-	err := h.StoredProcedureEntityService.DeleteStoredProcedureEntityById(param.ID)
+	// Get query and validate
+	query := &baseModels.DeleteEntityQuery{}
+
+	if err := ctx.ShouldBindQuery(query); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid query", "error": err.Error() })
+		return
+	}
+
+	if !middlewares.Authorize(ctx, h.Authorizer, policiesModels.NewResourceContext(policiesModels.StoredProcedureResource, param.ID), policiesModels.DeleteOperation) {
+		return
+	}
+
+	err := h.StoredProcedureEntityService.DeleteStoredProcedureEntityById(param.ID, query.HardDelete, middlewares.GetUserName(ctx))
+
+	if errors.Is(err, sql.ErrNoRows) {
+		ctx.JSON(http.StatusNotFound, gin.H{ "message": "Stored procedure not found", "error": err.Error() })
+		return
+	}
 
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{ "message": "Delete stored procedure by id failed", "error": err.Error() })
@@ -178,7 +445,31 @@ func (h *StoredProcedureEntityHandler) deleteStoredProceduredEntityByFqn(ctx *gi
 		return
 	}
 
-	err := h.StoredProcedureEntityService.DeleteStoredProcedureEntityByFqn(param.FQN)
+	// Get query and validate
+	query := &baseModels.DeleteEntityQuery{}
+
+	if err := ctx.ShouldBindQuery(query); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid query", "error": err.Error() })
+		return
+	}
+
+	exist, err := h.StoredProcedureEntityService.GetStoredProcedureEntityByFqn(param.FQN, "all")
+
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{ "message": "Stored procedure not found", "error": err.Error() })
+		return
+	}
+
+	if !middlewares.Authorize(ctx, h.Authorizer, policiesModels.NewResourceContext(policiesModels.StoredProcedureResource, exist.ID), policiesModels.DeleteOperation) {
+		return
+	}
+
+	err = h.StoredProcedureEntityService.DeleteStoredProcedureEntityByFqn(param.FQN, query.HardDelete, middlewares.GetUserName(ctx))
+
+	if errors.Is(err, sql.ErrNoRows) {
+		ctx.JSON(http.StatusNotFound, gin.H{ "message": "Stored procedure not found", "error": err.Error() })
+		return
+	}
 
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{ "message": "Delete stored procedure by fqn failed", "error": err.Error() })
@@ -187,3 +478,33 @@ func (h *StoredProcedureEntityHandler) deleteStoredProceduredEntityByFqn(ctx *gi
 
 	ctx.JSON(http.StatusOK, gin.H{ "message": "Delete stored procedure by fqn successfully" })
 }
+
+func (h *StoredProcedureEntityHandler) restoreStoredProcedureEntity(ctx *gin.Context) {
+	// Get payload
+	payload := &baseModels.RestoreEntityPayload{}
+
+	if err := ctx.ShouldBindJSON(payload); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid payload", "error": err.Error() })
+		return
+	}
+
+	if !middlewares.Authorize(ctx, h.Authorizer, policiesModels.NewResourceContext(policiesModels.StoredProcedureResource, payload.ID), policiesModels.EditAllOperation) {
+		return
+	}
+
+	// Restore stored procedure entity
+	storedProcedureEntity, err := h.StoredProcedureEntityService.RestoreStoredProcedureEntity(payload.ID, middlewares.GetUserName(ctx))
+
+	if errors.Is(err, sql.ErrNoRows) {
+		ctx.JSON(http.StatusNotFound, gin.H{ "message": "Stored procedure not found", "error": err.Error() })
+		return
+	}
+
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Restore stored procedure failed", "error": err.Error() })
+		return
+	}
+
+	ctx.Header("ETag", baseModels.EntityTag(storedProcedureEntity.Json.Version))
+	ctx.JSON(http.StatusOK, storedProcedureEntity.Json)
+}
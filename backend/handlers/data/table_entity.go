@@ -1,20 +1,28 @@
 package handlers
 
 import (
+	"database/sql"
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/nambuitechx/go-metadata/middlewares"
+	typeModels "github.com/nambuitechx/go-metadata/models/type"
 	dataModels "github.com/nambuitechx/go-metadata/models/data"
+	baseModels "github.com/nambuitechx/go-metadata/models/base"
 	dataServices "github.com/nambuitechx/go-metadata/services/data"
+	policiesModels "github.com/nambuitechx/go-metadata/models/policies"
+	policiesServices "github.com/nambuitechx/go-metadata/services/policies"
 )
 
 type TableEntityHandler struct {
 	TableEntityService *dataServices.TableEntityService
+	Authorizer *policiesServices.Authorizer
 }
 
-func InitTableEntityHandler(e *gin.Engine, tableEntityService *dataServices.TableEntityService) {
+func InitTableEntityHandler(e *gin.Engine, tableEntityService *dataServices.TableEntityService, authorizer *policiesServices.Authorizer) {
 	// Init handler
-	h := &TableEntityHandler{ TableEntityService: tableEntityService }
+	h := &TableEntityHandler{ TableEntityService: tableEntityService, Authorizer: authorizer }
 
 	// Add routes to engine
 	g := e.Group("api/v1/tables")
@@ -22,9 +30,14 @@ func InitTableEntityHandler(e *gin.Engine, tableEntityService *dataServices.Tabl
 		g.GET("/health", h.health)
 		g.GET("/:id", h.getTableEntityById)
 		g.GET("/name/:fqn", h.getTableEntityByFqn)
+		g.GET("/:id/versions", h.getTableEntityVersions)
+		g.GET("/:id/versions/:version", h.getTableEntityVersion)
 		g.GET("", h.getAllTableEntities)
 		g.POST("", h.createTableEntity)
 		g.PUT("", h.createOrUpdateTableEntity)
+		g.PATCH("/:id", h.patchTableEntityById)
+		g.PATCH("/name/:fqn", h.patchTableEntityByFqn)
+		g.PUT("/restore", h.restoreTableEntity)
 		g.DELETE("/:id", h.deleteTableEntityById)
 		g.DELETE("/name/:fqn", h.deleteTableEntityByFqn)
 	}
@@ -47,8 +60,28 @@ func (h *TableEntityHandler) getAllTableEntities(ctx *gin.Context) {
 		query.Limit = 10
 	}
 
+	if query.Include == "" {
+		query.Include = "non-deleted"
+	}
+
+	if _, err := baseModels.ValidateInclude(query.Include); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid query", "error": err.Error() })
+		return
+	}
+
+	before, after, err := baseModels.ValidatePagingCursors(query.Before, query.After)
+
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid query", "error": err.Error() })
+		return
+	}
+
+	if !middlewares.Authorize(ctx, h.Authorizer, policiesModels.NewResourceContext(policiesModels.TableResource, ""), policiesModels.ViewAllOperation) {
+		return
+	}
+
 	// Get table entites
-	tableEntities, err := h.TableEntityService.GetAllTableEntities(query.DatabaseSchema, query.Limit, query.Offset)
+	tableEntities, paging, err := h.TableEntityService.GetAllTableEntities(query.DatabaseSchema, query.Owner, query.Include, query.Limit, before, after)
 
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Get all table failed", "error": err.Error() })
@@ -62,14 +95,16 @@ func (h *TableEntityHandler) getAllTableEntities(ctx *gin.Context) {
 	}
 
 	// Get paging
-	total, err := h.TableEntityService.GetCountTableEntities(query.DatabaseSchema)
+	total, err := h.TableEntityService.GetCountTableEntities(query.DatabaseSchema, query.Owner, query.Include)
 
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Get all dbservices failed", "error": err.Error() })
 		return
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{ "message": "Get all table successfully", "data": jsonValues, "paging": total })
+	paging.Total = total.Total
+
+	ctx.JSON(http.StatusOK, gin.H{ "message": "Get all table successfully", "data": jsonValues, "paging": paging })
 }
 
 func (h *TableEntityHandler) getTableEntityById(ctx *gin.Context) {
@@ -81,13 +116,34 @@ func (h *TableEntityHandler) getTableEntityById(ctx *gin.Context) {
 		return
 	}
 
-	tableEntity, err := h.TableEntityService.GetTableEntityById(param.ID)
+	query := &baseModels.GetEntityQuery{}
+
+	if err := ctx.ShouldBindQuery(query); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid query", "error": err.Error() })
+		return
+	}
+
+	if query.Include == "" {
+		query.Include = "non-deleted"
+	}
+
+	if _, err := baseModels.ValidateInclude(query.Include); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid query", "error": err.Error() })
+		return
+	}
+
+	if !middlewares.Authorize(ctx, h.Authorizer, policiesModels.NewResourceContext(policiesModels.TableResource, param.ID), policiesModels.ViewAllOperation) {
+		return
+	}
+
+	tableEntity, err := h.TableEntityService.GetTableEntityById(param.ID, query.Include)
 	
 	if err != nil {
 		ctx.JSON(http.StatusNotFound, gin.H{ "message": "Table not found", "error": err.Error() })
 		return
 	}
 
+	ctx.Header("ETag", baseModels.EntityTag(tableEntity.Json.Version))
 	ctx.JSON(http.StatusOK, tableEntity.Json)
 }
 
@@ -100,17 +156,105 @@ func (h *TableEntityHandler) getTableEntityByFqn(ctx *gin.Context) {
 		return
 	}
 
-	tableEntity, err := h.TableEntityService.GetTableEntityByFqn(param.FQN)
+	query := &baseModels.GetEntityQuery{}
+
+	if err := ctx.ShouldBindQuery(query); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid query", "error": err.Error() })
+		return
+	}
+
+	if query.Include == "" {
+		query.Include = "non-deleted"
+	}
+
+	if _, err := baseModels.ValidateInclude(query.Include); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid query", "error": err.Error() })
+		return
+	}
+
+	tableEntity, err := h.TableEntityService.GetTableEntityByFqn(param.FQN, query.Include)
 	
 	if err != nil {
 		ctx.JSON(http.StatusNotFound, gin.H{ "message": "Table not found", "error": err.Error() })
 		return
 	}
 
+	if !middlewares.Authorize(ctx, h.Authorizer, policiesModels.NewResourceContext(policiesModels.TableResource, tableEntity.ID), policiesModels.ViewAllOperation) {
+		return
+	}
+
+	ctx.Header("ETag", baseModels.EntityTag(tableEntity.Json.Version))
 	ctx.JSON(http.StatusOK, tableEntity.Json)
 }
 
+func (h *TableEntityHandler) getTableEntityVersions(ctx *gin.Context) {
+	// Get param and validate
+	param := &dataModels.GetTableEntityByIdParam{}
+
+	if err := ctx.ShouldBindUri(param); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid param", "error": err.Error() })
+		return
+	}
+
+	if !middlewares.Authorize(ctx, h.Authorizer, policiesModels.NewResourceContext(policiesModels.TableResource, param.ID), policiesModels.ViewAllOperation) {
+		return
+	}
+
+	entityHistory, err := h.TableEntityService.GetTableEntityVersions(param.ID)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		ctx.JSON(http.StatusNotFound, gin.H{ "message": "Table not found", "error": err.Error() })
+		return
+	}
+
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{ "message": "Get table versions failed", "error": err.Error() })
+		return
+	}
+
+	ctx.JSON(http.StatusOK, entityHistory)
+}
+
+func (h *TableEntityHandler) getTableEntityVersion(ctx *gin.Context) {
+	// Get param and validate
+	param := &typeModels.GetEntityVersionParam{}
+
+	if err := ctx.ShouldBindUri(param); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid param", "error": err.Error() })
+		return
+	}
+
+	version, err := typeModels.ValidateVersion(param.Version)
+
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid param", "error": err.Error() })
+		return
+	}
+
+	if !middlewares.Authorize(ctx, h.Authorizer, policiesModels.NewResourceContext(policiesModels.TableResource, param.ID), policiesModels.ViewAllOperation) {
+		return
+	}
+
+	entityVersion, err := h.TableEntityService.GetTableEntityVersion(param.ID, version)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		ctx.JSON(http.StatusNotFound, gin.H{ "message": "Table version not found", "error": err.Error() })
+		return
+	}
+
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{ "message": "Get table version failed", "error": err.Error() })
+		return
+	}
+
+	ctx.JSON(http.StatusOK, entityVersion)
+}
+
 func (h *TableEntityHandler) createTableEntity(ctx *gin.Context) {
+	if !middlewares.Authorize(ctx, h.Authorizer, policiesModels.NewResourceContext(policiesModels.TableResource, ""), policiesModels.CreateOperation) {
+		return
+	}
+
 	// Get payload
 	payload := &dataModels.CreateTableEntityPayload{}
 
@@ -126,17 +270,22 @@ func (h *TableEntityHandler) createTableEntity(ctx *gin.Context) {
 	}
 
 	// Create table entity
-	tableEntity, err := h.TableEntityService.CreateTableEntity(payload);
+	tableEntity, err := h.TableEntityService.CreateTableEntity(payload, middlewares.GetUserName(ctx));
 
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Create table failed", "error": err.Error() })
 		return
 	}
 
+	ctx.Header("ETag", baseModels.EntityTag(tableEntity.Json.Version))
 	ctx.JSON(http.StatusCreated, tableEntity.Json)
 }
 
 func (h *TableEntityHandler) createOrUpdateTableEntity(ctx *gin.Context) {
+	if !middlewares.Authorize(ctx, h.Authorizer, policiesModels.NewResourceContext(policiesModels.TableResource, ""), policiesModels.CreateOperation, policiesModels.EditAllOperation) {
+		return
+	}
+
 	// Get payload
 	payload := &dataModels.CreateTableEntityPayload{}
 
@@ -152,16 +301,117 @@ func (h *TableEntityHandler) createOrUpdateTableEntity(ctx *gin.Context) {
 	}
 
 	// Create or update table entity
-	tableEntity, err := h.TableEntityService.CreateOrUpdateTableEntity(payload);
+	tableEntity, err := h.TableEntityService.CreateOrUpdateTableEntity(payload, ctx.GetHeader("If-Match"), middlewares.GetUserName(ctx))
+
+	if errors.Is(err, baseModels.ErrPreconditionFailed) {
+		ctx.JSON(http.StatusPreconditionFailed, gin.H{ "message": "Precondition failed", "error": err.Error() })
+		return
+	}
+
+	if errors.Is(err, baseModels.ErrEntityDeleted) {
+		ctx.JSON(http.StatusConflict, gin.H{ "message": "Create or update table failed", "error": err.Error() })
+		return
+	}
 
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Create or update table failed", "error": err.Error() })
 		return
 	}
 
+	ctx.Header("ETag", baseModels.EntityTag(tableEntity.Json.Version))
 	ctx.JSON(http.StatusOK, tableEntity.Json)
 }
 
+func (h *TableEntityHandler) patchTableEntityById(ctx *gin.Context) {
+	// Get param and validate
+	param := &dataModels.GetTableEntityByIdParam{}
+
+	if err := ctx.ShouldBindUri(param); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid param", "error": err.Error() })
+		return
+	}
+
+	// Get payload
+	var payload []baseModels.JsonPatchOperation
+
+	if err := ctx.ShouldBindJSON(&payload); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid payload", "error": err.Error() })
+		return
+	}
+
+	tableEntity, err := h.TableEntityService.GetTableEntityById(param.ID, "non-deleted")
+
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{ "message": "Table not found", "error": err.Error() })
+		return
+	}
+
+	if !middlewares.Authorize(ctx, h.Authorizer, policiesModels.NewResourceContext(policiesModels.TableResource, tableEntity.ID), policiesModels.GetPatchOperations(payload)...) {
+		return
+	}
+
+	// Patch table entity
+	updatedTableEntity, err := h.TableEntityService.PatchTableEntity(tableEntity, payload, ctx.GetHeader("If-Match"), middlewares.GetUserName(ctx))
+
+	if errors.Is(err, baseModels.ErrPreconditionFailed) {
+		ctx.JSON(http.StatusPreconditionFailed, gin.H{ "message": "Precondition failed", "error": err.Error() })
+		return
+	}
+
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Patch table by id failed", "error": err.Error() })
+		return
+	}
+
+	ctx.Header("ETag", baseModels.EntityTag(updatedTableEntity.Json.Version))
+	ctx.JSON(http.StatusOK, updatedTableEntity.Json)
+}
+
+func (h *TableEntityHandler) patchTableEntityByFqn(ctx *gin.Context) {
+	// Get param and validate
+	param := &dataModels.GetTableEntityByFqnParam{}
+
+	if err := ctx.ShouldBindUri(param); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid param", "error": err.Error() })
+		return
+	}
+
+	// Get payload
+	var payload []baseModels.JsonPatchOperation
+
+	if err := ctx.ShouldBindJSON(&payload); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid payload", "error": err.Error() })
+		return
+	}
+
+	tableEntity, err := h.TableEntityService.GetTableEntityByFqn(param.FQN, "non-deleted")
+
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{ "message": "Table not found", "error": err.Error() })
+		return
+	}
+
+	if !middlewares.Authorize(ctx, h.Authorizer, policiesModels.NewResourceContext(policiesModels.TableResource, tableEntity.ID), policiesModels.GetPatchOperations(payload)...) {
+		return
+	}
+
+	// Patch table entity
+	updatedTableEntity, err := h.TableEntityService.PatchTableEntity(tableEntity, payload, ctx.GetHeader("If-Match"), middlewares.GetUserName(ctx))
+
+	if errors.Is(err, baseModels.ErrPreconditionFailed) {
+		ctx.JSON(http.StatusPreconditionFailed, gin.H{ "message": "Precondition failed", "error": err.Error() })
+		return
+	}
+
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Patch table by fqn failed", "error": err.Error() })
+		return
+	}
+
+	ctx.Header("ETag", baseModels.EntityTag(updatedTableEntity.Json.Version))
+	ctx.JSON(http.StatusOK, updatedTableEntity.Json)
+}
+
 func (h *TableEntityHandler) deleteTableEntityById(ctx *gin.Context) {
 	// Get param and validate
 	param := &dataModels.GetTableEntityByIdParam{}
@@ -171,7 +421,24 @@ func (h *TableEntityHandler) deleteTableEntityById(ctx *gin.Context) {
 		return
 	}
 
-	err := h.TableEntityService.DeleteTableEntityById(param.ID)
+	// Get query and validate
+	query := &baseModels.DeleteEntityQuery{}
+
+	if err := ctx.ShouldBindQuery(query); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid query", "error": err.Error() })
+		return
+	}
+
+	if !middlewares.Authorize(ctx, h.Authorizer, policiesModels.NewResourceContext(policiesModels.TableResource, param.ID), policiesModels.DeleteOperation) {
+		return
+	}
+
+	err := h.TableEntityService.DeleteTableEntityById(param.ID, query.HardDelete, middlewares.GetUserName(ctx))
+
+	if errors.Is(err, sql.ErrNoRows) {
+		ctx.JSON(http.StatusNotFound, gin.H{ "message": "Table not found", "error": err.Error() })
+		return
+	}
 
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{ "message": "Delete table by id failed", "error": err.Error() })
@@ -190,7 +457,31 @@ func (h *TableEntityHandler) deleteTableEntityByFqn(ctx *gin.Context) {
 		return
 	}
 
-	err := h.TableEntityService.DeleteTableEntityByFqn(param.FQN)
+	// Get query and validate
+	query := &baseModels.DeleteEntityQuery{}
+
+	if err := ctx.ShouldBindQuery(query); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid query", "error": err.Error() })
+		return
+	}
+
+	exist, err := h.TableEntityService.GetTableEntityByFqn(param.FQN, "all")
+
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{ "message": "Table not found", "error": err.Error() })
+		return
+	}
+
+	if !middlewares.Authorize(ctx, h.Authorizer, policiesModels.NewResourceContext(policiesModels.TableResource, exist.ID), policiesModels.DeleteOperation) {
+		return
+	}
+
+	err = h.TableEntityService.DeleteTableEntityByFqn(param.FQN, query.HardDelete, middlewares.GetUserName(ctx))
+
+	if errors.Is(err, sql.ErrNoRows) {
+		ctx.JSON(http.StatusNotFound, gin.H{ "message": "Table not found", "error": err.Error() })
+		return
+	}
 
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{ "message": "Delete table by fqn failed", "error": err.Error() })
@@ -199,3 +490,33 @@ func (h *TableEntityHandler) deleteTableEntityByFqn(ctx *gin.Context) {
 
 	ctx.JSON(http.StatusOK, gin.H{ "message": "Delete table by fqn successfully" })
 }
+
+func (h *TableEntityHandler) restoreTableEntity(ctx *gin.Context) {
+	// Get payload
+	payload := &baseModels.RestoreEntityPayload{}
+
+	if err := ctx.ShouldBindJSON(payload); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid payload", "error": err.Error() })
+		return
+	}
+
+	if !middlewares.Authorize(ctx, h.Authorizer, policiesModels.NewResourceContext(policiesModels.TableResource, payload.ID), policiesModels.EditAllOperation) {
+		return
+	}
+
+	// Restore table entity
+	tableEntity, err := h.TableEntityService.RestoreTableEntity(payload.ID, middlewares.GetUserName(ctx))
+
+	if errors.Is(err, sql.ErrNoRows) {
+		ctx.JSON(http.StatusNotFound, gin.H{ "message": "Table not found", "error": err.Error() })
+		return
+	}
+
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Restore table failed", "error": err.Error() })
+		return
+	}
+
+	ctx.Header("ETag", baseModels.EntityTag(tableEntity.Json.Version))
+	ctx.JSON(http.StatusOK, tableEntity.Json)
+}
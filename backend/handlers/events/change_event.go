@@ -0,0 +1,190 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-contrib/sse"
+	"github.com/gin-gonic/gin"
+
+	"github.com/nambuitechx/go-metadata/middlewares"
+	eventsModels "github.com/nambuitechx/go-metadata/models/events"
+	policiesModels "github.com/nambuitechx/go-metadata/models/policies"
+	eventsServices "github.com/nambuitechx/go-metadata/services/events"
+	policiesServices "github.com/nambuitechx/go-metadata/services/policies"
+)
+
+type ChangeEventHandler struct {
+	ChangeEventService *eventsServices.ChangeEventService
+	Authorizer *policiesServices.Authorizer
+}
+
+func InitChangeEventHandler(e *gin.Engine, changeEventService *eventsServices.ChangeEventService, authorizer *policiesServices.Authorizer) {
+	// Init handler
+	h := &ChangeEventHandler{ ChangeEventService: changeEventService, Authorizer: authorizer }
+
+	// Add routes to engine
+	g := e.Group("api/v1/events")
+	{
+		g.GET("/health", h.health)
+		g.GET("/stream", h.streamChangeEvents)
+		g.GET("", h.getChangeEvents)
+	}
+}
+
+func (h *ChangeEventHandler) health(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, gin.H{ "message": h.ChangeEventService.Health() })
+}
+
+func (h *ChangeEventHandler) getChangeEvents(ctx *gin.Context) {
+	// Get query and validate
+	query := &eventsModels.GetChangeEventsQuery{}
+
+	if err := ctx.ShouldBindQuery(query); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid query", "error": err.Error() })
+		return
+	}
+
+	filters := query.ToFilters()
+
+	if len(filters) == 0 {
+		err := errors.New("at least one of entityCreated, entityUpdated, entitySoftDeleted and entityDeleted is required")
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid query", "error": err.Error() })
+		return
+	}
+
+	// The events cover the entities of every resource
+	if !middlewares.Authorize(ctx, h.Authorizer, policiesModels.NewResourceContext(policiesModels.AllResources, ""), policiesModels.ViewAllOperation) {
+		return
+	}
+
+	// Get change events
+	changeEvents, err := h.ChangeEventService.GetChangeEvents(filters, query.Timestamp)
+
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Get all change events failed", "error": err.Error() })
+		return
+	}
+
+	jsonValues := []*eventsModels.ChangeEvent{}
+
+	for _, e := range changeEvents {
+		jsonValues = append(jsonValues, e.Json)
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{ "message": "Get all change events successfully", "data": jsonValues })
+}
+
+const (
+	streamBatchSize = 100
+	streamHeartbeatInterval = 15 * time.Second
+)
+
+func (h *ChangeEventHandler) streamChangeEvents(ctx *gin.Context) {
+	// Get query and validate
+	query := &eventsModels.StreamChangeEventsQuery{}
+
+	if err := ctx.ShouldBindQuery(query); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid query", "error": err.Error() })
+		return
+	}
+
+	filters, err := query.ToFilters()
+
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid query", "error": err.Error() })
+		return
+	}
+
+	if !middlewares.Authorize(ctx, h.Authorizer, policiesModels.NewResourceContext(policiesModels.AllResources, ""), policiesModels.ViewAllOperation) {
+		return
+	}
+
+	// Resume after the last received offset, browsers resend it in the Last-Event-ID header when reconnecting
+	lastEventID := ctx.GetHeader("Last-Event-ID")
+
+	if lastEventID == "" {
+		lastEventID = query.LastEventID
+	}
+
+	var offset int64
+
+	if lastEventID != "" {
+		offset, err = strconv.ParseInt(lastEventID, 10, 64)
+
+		if err != nil || offset < 0 {
+			ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid last event id", "error": "last event id must be a change event offset" })
+			return
+		}
+	} else {
+		offset, err = h.ChangeEventService.GetLatestOffset()
+
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{ "message": "Stream change events failed", "error": err.Error() })
+			return
+		}
+	}
+
+	// Listen before the first read so no event committed in between is missed
+	notify, stop := h.ChangeEventService.ListenChangeEvents()
+	defer stop()
+
+	heartbeat := time.NewTicker(streamHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	ctx.Header("Content-Type", "text/event-stream")
+	ctx.Header("Cache-Control", "no-cache")
+	ctx.Header("Connection", "keep-alive")
+	ctx.Header("X-Accel-Buffering", "no")
+	ctx.Status(http.StatusOK)
+
+	send := func() bool {
+		for {
+			changeEvents, err := h.ChangeEventService.GetChangeEventsAfterOffset(offset, streamBatchSize)
+
+			if err != nil {
+				ctx.Render(-1, sse.Event{ Event: "error", Data: gin.H{ "message": "Stream change events failed", "error": err.Error() } })
+				return false
+			}
+
+			for _, e := range changeEvents {
+				offset = e.Offset
+
+				if !filters.Match(e.Json) {
+					continue
+				}
+
+				ctx.Render(-1, sse.Event{ Id: strconv.FormatInt(e.Offset, 10), Event: e.EventType, Data: e.Json })
+			}
+
+			ctx.Writer.Flush()
+
+			if len(changeEvents) < streamBatchSize {
+				return true
+			}
+		}
+	}
+
+	if !send() {
+		return
+	}
+
+	for {
+		select {
+		case <-ctx.Request.Context().Done():
+			return
+		case <-notify:
+			if !send() {
+				return
+			}
+		case <-heartbeat.C:
+			if _, err := ctx.Writer.WriteString(": heartbeat\n\n"); err != nil {
+				return
+			}
+
+			ctx.Writer.Flush()
+		}
+	}
+}
@@ -0,0 +1,422 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nambuitechx/go-metadata/middlewares"
+	baseModels "github.com/nambuitechx/go-metadata/models/base"
+	eventsModels "github.com/nambuitechx/go-metadata/models/events"
+	eventsServices "github.com/nambuitechx/go-metadata/services/events"
+)
+
+type EventSubscriptionHandler struct {
+	EventSubscriptionService *eventsServices.EventSubscriptionService
+}
+
+func InitEventSubscriptionHandler(e *gin.Engine, eventSubscriptionService *eventsServices.EventSubscriptionService) {
+	// Init handler
+	h := &EventSubscriptionHandler{ EventSubscriptionService: eventSubscriptionService }
+
+	// Add routes to engine
+	g := e.Group("api/v1/events/subscriptions")
+	{
+		g.GET("/health", h.health)
+		g.GET("/:id", h.getEventSubscriptionEntityById)
+		g.GET("/name/:fqn", h.getEventSubscriptionEntityByFqn)
+		g.GET("/:id/status", h.getEventSubscriptionStatus)
+		g.GET("/:id/deadLetters", h.getEventSubscriptionDeadLetters)
+		g.GET("", h.getAllEventSubscriptionEntities)
+		g.POST("", h.createEventSubscriptionEntity)
+		g.PUT("", h.createOrUpdateEventSubscriptionEntity)
+		g.PUT("/restore", h.restoreEventSubscriptionEntity)
+		g.DELETE("/:id", h.deleteEventSubscriptionEntityById)
+		g.DELETE("/name/:fqn", h.deleteEventSubscriptionEntityByFqn)
+	}
+}
+
+func (h *EventSubscriptionHandler) health(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, gin.H{ "message": h.EventSubscriptionService.Health() })
+}
+
+func (h *EventSubscriptionHandler) getAllEventSubscriptionEntities(ctx *gin.Context) {
+	// Subscriptions receive every change event at the endpoint they name
+	if !middlewares.AuthorizeAdmin(ctx) {
+		return
+	}
+
+	// Get query and validate
+	query := &eventsModels.GetEventSubscriptionEntitiesQuery{}
+
+	if err := ctx.ShouldBindQuery(query); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid query", "error": err.Error() })
+		return
+	}
+
+	if query.Limit == 0 {
+		query.Limit = 10
+	}
+
+	if query.Include == "" {
+		query.Include = "non-deleted"
+	}
+
+	if _, err := baseModels.ValidateInclude(query.Include); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid query", "error": err.Error() })
+		return
+	}
+
+	before, after, err := baseModels.ValidatePagingCursors(query.Before, query.After)
+
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid query", "error": err.Error() })
+		return
+	}
+
+	// Get event subscription entites
+	eventSubscriptionEntities, paging, err := h.EventSubscriptionService.GetAllEventSubscriptionEntities(query.Include, query.Limit, before, after)
+
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Get all event subscriptions failed", "error": err.Error() })
+		return
+	}
+
+	jsonValues := []*eventsModels.EventSubscription{}
+
+	for _, e := range eventSubscriptionEntities {
+		jsonValues = append(jsonValues, e.Json)
+	}
+
+	// Get paging
+	total, err := h.EventSubscriptionService.GetCountEventSubscriptionEntities(query.Include)
+
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Get all event subscriptions failed", "error": err.Error() })
+		return
+	}
+
+	paging.Total = total.Total
+
+	middlewares.JSONMasked(ctx, http.StatusOK, gin.H{ "message": "Get all event subscriptions successfully", "data": jsonValues, "paging": paging })
+}
+
+func (h *EventSubscriptionHandler) getEventSubscriptionEntityById(ctx *gin.Context) {
+	if !middlewares.AuthorizeAdmin(ctx) {
+		return
+	}
+
+	// Get param and validate
+	param := &eventsModels.GetEventSubscriptionEntityByIdParam{}
+
+	if err := ctx.ShouldBindUri(param); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid param", "error": err.Error() })
+		return
+	}
+
+	query := &baseModels.GetEntityQuery{}
+
+	if err := ctx.ShouldBindQuery(query); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid query", "error": err.Error() })
+		return
+	}
+
+	if query.Include == "" {
+		query.Include = "non-deleted"
+	}
+
+	if _, err := baseModels.ValidateInclude(query.Include); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid query", "error": err.Error() })
+		return
+	}
+
+	eventSubscriptionEntity, err := h.EventSubscriptionService.GetEventSubscriptionEntityById(param.ID, query.Include)
+
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{ "message": "Event subscription not found", "error": err.Error() })
+		return
+	}
+
+	middlewares.JSONMasked(ctx, http.StatusOK, eventSubscriptionEntity.Json)
+}
+
+func (h *EventSubscriptionHandler) getEventSubscriptionEntityByFqn(ctx *gin.Context) {
+	if !middlewares.AuthorizeAdmin(ctx) {
+		return
+	}
+
+	// Get param and validate
+	param := &eventsModels.GetEventSubscriptionEntityByFqnParam{}
+
+	if err := ctx.ShouldBindUri(param); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid param", "error": err.Error() })
+		return
+	}
+
+	query := &baseModels.GetEntityQuery{}
+
+	if err := ctx.ShouldBindQuery(query); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid query", "error": err.Error() })
+		return
+	}
+
+	if query.Include == "" {
+		query.Include = "non-deleted"
+	}
+
+	if _, err := baseModels.ValidateInclude(query.Include); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid query", "error": err.Error() })
+		return
+	}
+
+	eventSubscriptionEntity, err := h.EventSubscriptionService.GetEventSubscriptionEntityByFqn(param.FQN, query.Include)
+
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{ "message": "Event subscription not found", "error": err.Error() })
+		return
+	}
+
+	middlewares.JSONMasked(ctx, http.StatusOK, eventSubscriptionEntity.Json)
+}
+
+func (h *EventSubscriptionHandler) getEventSubscriptionStatus(ctx *gin.Context) {
+	if !middlewares.AuthorizeAdmin(ctx) {
+		return
+	}
+
+	// Get param and validate
+	param := &eventsModels.GetEventSubscriptionEntityByIdParam{}
+
+	if err := ctx.ShouldBindUri(param); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid param", "error": err.Error() })
+		return
+	}
+
+	eventSubscriptionEntity, err := h.EventSubscriptionService.GetEventSubscriptionEntityById(param.ID, "non-deleted")
+
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{ "message": "Event subscription not found", "error": err.Error() })
+		return
+	}
+
+	ctx.JSON(http.StatusOK, eventSubscriptionEntity.Json.Status)
+}
+
+func (h *EventSubscriptionHandler) getEventSubscriptionDeadLetters(ctx *gin.Context) {
+	if !middlewares.AuthorizeAdmin(ctx) {
+		return
+	}
+
+	// Get param and validate
+	param := &eventsModels.GetEventSubscriptionEntityByIdParam{}
+
+	if err := ctx.ShouldBindUri(param); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid param", "error": err.Error() })
+		return
+	}
+
+	// Get query and validate
+	query := &eventsModels.GetDeadLettersQuery{}
+
+	if err := ctx.ShouldBindQuery(query); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid query", "error": err.Error() })
+		return
+	}
+
+	if query.Limit <= 0 {
+		query.Limit = 10
+	}
+
+	if _, err := h.EventSubscriptionService.GetEventSubscriptionEntityById(param.ID, "all"); err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{ "message": "Event subscription not found", "error": err.Error() })
+		return
+	}
+
+	deadLetterEntities, err := h.EventSubscriptionService.GetDeadLetters(param.ID, query.Limit)
+
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{ "message": "Get dead letters failed", "error": err.Error() })
+		return
+	}
+
+	jsonValues := []*eventsModels.DeadLetter{}
+
+	for _, e := range deadLetterEntities {
+		jsonValues = append(jsonValues, e.Json)
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{ "message": "Get dead letters successfully", "data": jsonValues })
+}
+
+func (h *EventSubscriptionHandler) createEventSubscriptionEntity(ctx *gin.Context) {
+	if !middlewares.AuthorizeAdmin(ctx) {
+		return
+	}
+
+	// Get payload
+	payload := &eventsModels.CreateEventSubscriptionPayload{}
+
+	if err := ctx.ShouldBindJSON(payload); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid payload", "error": err.Error() })
+		return
+	}
+
+	// Validate payload
+	if err := eventsModels.ValidateCreateEventSubscriptionPayload(payload); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Create event subscription failed", "error": err.Error() })
+		return
+	}
+
+	// Create event subscription entity
+	eventSubscriptionEntity, err := h.EventSubscriptionService.CreateEventSubscriptionEntity(payload, middlewares.GetUserName(ctx))
+
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Create event subscription failed", "error": err.Error() })
+		return
+	}
+
+	// The secret is returned once, for the receiver to check the signatures, and masked afterwards
+	if err := h.EventSubscriptionService.DecryptEventSubscriptionSecret(eventSubscriptionEntity.Json); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{ "message": "Create event subscription failed", "error": err.Error() })
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, eventSubscriptionEntity.Json)
+}
+
+func (h *EventSubscriptionHandler) createOrUpdateEventSubscriptionEntity(ctx *gin.Context) {
+	if !middlewares.AuthorizeAdmin(ctx) {
+		return
+	}
+
+	// Get payload
+	payload := &eventsModels.CreateEventSubscriptionPayload{}
+
+	if err := ctx.ShouldBindJSON(payload); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid payload", "error": err.Error() })
+		return
+	}
+
+	// Validate payload
+	if err := eventsModels.ValidateCreateEventSubscriptionPayload(payload); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Create or update event subscription failed", "error": err.Error() })
+		return
+	}
+
+	// Create or update event subscription entity
+	eventSubscriptionEntity, err := h.EventSubscriptionService.CreateOrUpdateEventSubscriptionEntity(payload, middlewares.GetUserName(ctx))
+
+	if errors.Is(err, baseModels.ErrEntityDeleted) {
+		ctx.JSON(http.StatusConflict, gin.H{ "message": "Create or update event subscription failed", "error": err.Error() })
+		return
+	}
+
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Create or update event subscription failed", "error": err.Error() })
+		return
+	}
+
+	middlewares.JSONMasked(ctx, http.StatusOK, eventSubscriptionEntity.Json)
+}
+
+func (h *EventSubscriptionHandler) deleteEventSubscriptionEntityById(ctx *gin.Context) {
+	if !middlewares.AuthorizeAdmin(ctx) {
+		return
+	}
+
+	// Get param and validate
+	param := &eventsModels.GetEventSubscriptionEntityByIdParam{}
+
+	if err := ctx.ShouldBindUri(param); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid param", "error": err.Error() })
+		return
+	}
+
+	// Get query and validate
+	query := &baseModels.DeleteEntityQuery{}
+
+	if err := ctx.ShouldBindQuery(query); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid query", "error": err.Error() })
+		return
+	}
+
+	err := h.EventSubscriptionService.DeleteEventSubscriptionEntityById(param.ID, query.HardDelete, middlewares.GetUserName(ctx))
+
+	if errors.Is(err, sql.ErrNoRows) {
+		ctx.JSON(http.StatusNotFound, gin.H{ "message": "Event subscription not found", "error": err.Error() })
+		return
+	}
+
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{ "message": "Delete event subscription by id failed", "error": err.Error() })
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{ "message": "Delete event subscription by id successfully" })
+}
+
+func (h *EventSubscriptionHandler) deleteEventSubscriptionEntityByFqn(ctx *gin.Context) {
+	if !middlewares.AuthorizeAdmin(ctx) {
+		return
+	}
+
+	// Get param and validate
+	param := &eventsModels.GetEventSubscriptionEntityByFqnParam{}
+
+	if err := ctx.ShouldBindUri(param); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid param", "error": err.Error() })
+		return
+	}
+
+	// Get query and validate
+	query := &baseModels.DeleteEntityQuery{}
+
+	if err := ctx.ShouldBindQuery(query); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid query", "error": err.Error() })
+		return
+	}
+
+	err := h.EventSubscriptionService.DeleteEventSubscriptionEntityByFqn(param.FQN, query.HardDelete, middlewares.GetUserName(ctx))
+
+	if errors.Is(err, sql.ErrNoRows) {
+		ctx.JSON(http.StatusNotFound, gin.H{ "message": "Event subscription not found", "error": err.Error() })
+		return
+	}
+
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{ "message": "Delete event subscription by fqn failed", "error": err.Error() })
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{ "message": "Delete event subscription by fqn successfully" })
+}
+
+func (h *EventSubscriptionHandler) restoreEventSubscriptionEntity(ctx *gin.Context) {
+	if !middlewares.AuthorizeAdmin(ctx) {
+		return
+	}
+
+	// Get payload
+	payload := &baseModels.RestoreEntityPayload{}
+
+	if err := ctx.ShouldBindJSON(payload); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid payload", "error": err.Error() })
+		return
+	}
+
+	// Restore event subscription entity
+	eventSubscriptionEntity, err := h.EventSubscriptionService.RestoreEventSubscriptionEntity(payload.ID, middlewares.GetUserName(ctx))
+
+	if errors.Is(err, sql.ErrNoRows) {
+		ctx.JSON(http.StatusNotFound, gin.H{ "message": "Event subscription not found", "error": err.Error() })
+		return
+	}
+
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Restore event subscription failed", "error": err.Error() })
+		return
+	}
+
+	middlewares.JSONMasked(ctx, http.StatusOK, eventSubscriptionEntity.Json)
+}
@@ -0,0 +1,230 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nambuitechx/go-metadata/middlewares"
+
+	lineageModels "github.com/nambuitechx/go-metadata/models/lineage"
+	policiesModels "github.com/nambuitechx/go-metadata/models/policies"
+	typeModels "github.com/nambuitechx/go-metadata/models/type"
+	lineageServices "github.com/nambuitechx/go-metadata/services/lineage"
+	policiesServices "github.com/nambuitechx/go-metadata/services/policies"
+)
+
+type LineageHandler struct {
+	LineageService *lineageServices.LineageService
+	Authorizer *policiesServices.Authorizer
+}
+
+func InitLineageHandler(e *gin.Engine, lineageService *lineageServices.LineageService, authorizer *policiesServices.Authorizer) {
+	// Init handler
+	h := &LineageHandler{ LineageService: lineageService, Authorizer: authorizer }
+
+	// Add routes to engine
+	g := e.Group("api/v1/lineage")
+	{
+		g.GET("/health", h.health)
+		g.GET("/table/name/:fqn", h.getTableLineageByFqn)
+		g.PUT("", h.addLineage)
+		g.DELETE("/:fromEntity/:fromId/:toEntity/:toId", h.deleteLineage)
+	}
+
+	e.POST("api/v1/tables/name/:fqn/impact", h.analyzeTableImpact)
+}
+
+func (h *LineageHandler) health(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, gin.H{ "message": h.LineageService.Health() })
+}
+
+func (h *LineageHandler) getTableLineageByFqn(ctx *gin.Context) {
+	// Get param and validate
+	param := &lineageModels.GetLineageByFqnParam{}
+
+	if err := ctx.ShouldBindUri(param); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid param", "error": err.Error() })
+		return
+	}
+
+	query := &lineageModels.GetLineageQuery{}
+
+	if err := ctx.ShouldBindQuery(query); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid query", "error": err.Error() })
+		return
+	}
+
+	if err := lineageModels.ValidateGetLineageQuery(query); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid query", "error": err.Error() })
+		return
+	}
+
+	if !h.authorizeTable(ctx, param.FQN) {
+		return
+	}
+
+	entityLineage, err := h.LineageService.GetTableLineageByFqn(param.FQN, query.Column, *query.UpstreamDepth, *query.DownstreamDepth)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		ctx.JSON(http.StatusNotFound, gin.H{ "message": "Table not found", "error": err.Error() })
+		return
+	}
+
+	if errors.Is(err, lineageModels.ErrColumnNotFound) {
+		ctx.JSON(http.StatusNotFound, gin.H{ "message": "Column not found", "error": err.Error() })
+		return
+	}
+
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{ "message": "Get table lineage failed", "error": err.Error() })
+		return
+	}
+
+	ctx.JSON(http.StatusOK, entityLineage)
+}
+
+func (h *LineageHandler) addLineage(ctx *gin.Context) {
+	// Get payload
+	payload := &lineageModels.AddLineagePayload{}
+
+	if err := ctx.ShouldBindJSON(payload); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid payload", "error": err.Error() })
+		return
+	}
+
+	if err := lineageModels.ValidateAddLineagePayload(payload); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid payload", "error": err.Error() })
+		return
+	}
+
+	// The lineage of a table is edited from its upstream side
+	fromId, err := h.LineageService.GetTableId(payload.Edge.FromEntity)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		ctx.JSON(http.StatusNotFound, gin.H{ "message": "Entity not found", "error": err.Error() })
+		return
+	}
+
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{ "message": "Add lineage failed", "error": err.Error() })
+		return
+	}
+
+	if !middlewares.Authorize(ctx, h.Authorizer, policiesModels.NewResourceContext(policiesModels.TableResource, fromId), policiesModels.EditLineageOperation) {
+		return
+	}
+
+	// Add lineage edge
+	edge, err := h.LineageService.AddLineage(payload, middlewares.GetUserName(ctx))
+
+	if errors.Is(err, sql.ErrNoRows) {
+		ctx.JSON(http.StatusNotFound, gin.H{ "message": "Entity not found", "error": err.Error() })
+		return
+	}
+
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Add lineage failed", "error": err.Error() })
+		return
+	}
+
+	ctx.JSON(http.StatusOK, edge)
+}
+
+func (h *LineageHandler) deleteLineage(ctx *gin.Context) {
+	// Get param and validate
+	param := &lineageModels.DeleteLineageParam{}
+
+	if err := ctx.ShouldBindUri(param); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid param", "error": err.Error() })
+		return
+	}
+
+	if err := lineageModels.ValidateDeleteLineageParam(param); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid param", "error": err.Error() })
+		return
+	}
+
+	if !middlewares.Authorize(ctx, h.Authorizer, policiesModels.NewResourceContext(policiesModels.TableResource, param.FromID), policiesModels.EditLineageOperation) {
+		return
+	}
+
+	err := h.LineageService.DeleteLineage(param.FromID, param.ToID)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		ctx.JSON(http.StatusNotFound, gin.H{ "message": "Lineage not found", "error": err.Error() })
+		return
+	}
+
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{ "message": "Delete lineage failed", "error": err.Error() })
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{ "message": "Delete lineage successfully" })
+}
+
+func (h *LineageHandler) analyzeTableImpact(ctx *gin.Context) {
+	// Get param and payload
+	param := &lineageModels.GetLineageByFqnParam{}
+
+	if err := ctx.ShouldBindUri(param); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid param", "error": err.Error() })
+		return
+	}
+
+	payload := &lineageModels.AnalyzeImpactPayload{}
+
+	if err := ctx.ShouldBindJSON(payload); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid payload", "error": err.Error() })
+		return
+	}
+
+	if err := lineageModels.ValidateAnalyzeImpactPayload(payload); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid payload", "error": err.Error() })
+		return
+	}
+
+	if !h.authorizeTable(ctx, param.FQN) {
+		return
+	}
+
+	// Analyze impact
+	report, err := h.LineageService.AnalyzeTableImpact(param.FQN, payload.Changes)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		ctx.JSON(http.StatusNotFound, gin.H{ "message": "Table not found", "error": err.Error() })
+		return
+	}
+
+	if errors.Is(err, lineageModels.ErrColumnNotFound) {
+		ctx.JSON(http.StatusNotFound, gin.H{ "message": "Column not found", "error": err.Error() })
+		return
+	}
+
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Analyze table impact failed", "error": err.Error() })
+		return
+	}
+
+	ctx.JSON(http.StatusOK, report)
+}
+
+// authorizeTable checks ViewBasic on the table read by its fully qualified name. Otherwise it writes the error
+// response and returns false
+func (h *LineageHandler) authorizeTable(ctx *gin.Context, fqn string) bool {
+	tableId, err := h.LineageService.GetTableId(&typeModels.EntityReference{ FullyQualifiedName: fqn })
+
+	if errors.Is(err, sql.ErrNoRows) {
+		ctx.JSON(http.StatusNotFound, gin.H{ "message": "Table not found", "error": err.Error() })
+		return false
+	}
+
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{ "message": "Get table failed", "error": err.Error() })
+		return false
+	}
+
+	return middlewares.Authorize(ctx, h.Authorizer, policiesModels.NewResourceContext(policiesModels.TableResource, tableId), policiesModels.ViewBasicOperation)
+}
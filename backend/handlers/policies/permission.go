@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nambuitechx/go-metadata/middlewares"
+	policiesModels "github.com/nambuitechx/go-metadata/models/policies"
+	policiesServices "github.com/nambuitechx/go-metadata/services/policies"
+)
+
+type PermissionHandler struct {
+	PermissionService *policiesServices.PermissionService
+}
+
+func InitPermissionHandler(e *gin.Engine, permissionService *policiesServices.PermissionService) {
+	// Init handler
+	h := &PermissionHandler{ PermissionService: permissionService }
+
+	// Add routes to engine
+	g := e.Group("api/v1/permissions")
+	{
+		g.GET("/health", h.health)
+		g.GET("", h.getAllResourcePermissions)
+		g.GET("/:resource", h.getResourcePermission)
+		g.GET("/:resource/:id", h.getEntityPermissionById)
+		g.GET("/:resource/name/:fqn", h.getEntityPermissionByFqn)
+	}
+}
+
+func (h *PermissionHandler) health(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, gin.H{ "message": h.PermissionService.Health() })
+}
+
+func (h *PermissionHandler) getAllResourcePermissions(ctx *gin.Context) {
+	resourcePermissions, err := h.PermissionService.GetAllResourcePermissions(middlewares.GetPrincipal(ctx))
+
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{ "message": "Get permissions failed", "error": err.Error() })
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{ "message": "Get permissions successfully", "data": resourcePermissions })
+}
+
+func (h *PermissionHandler) getResourcePermission(ctx *gin.Context) {
+	// Get param and validate
+	param := &policiesModels.GetResourcePermissionParam{}
+
+	if err := ctx.ShouldBindUri(param); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid param", "error": err.Error() })
+		return
+	}
+
+	resourcePermission, err := h.PermissionService.GetResourcePermission(middlewares.GetPrincipal(ctx), param.Resource)
+	h.respond(ctx, resourcePermission, err)
+}
+
+func (h *PermissionHandler) getEntityPermissionById(ctx *gin.Context) {
+	// Get param and validate
+	param := &policiesModels.GetEntityPermissionByIdParam{}
+
+	if err := ctx.ShouldBindUri(param); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid param", "error": err.Error() })
+		return
+	}
+
+	resourcePermission, err := h.PermissionService.GetEntityPermissionById(middlewares.GetPrincipal(ctx), param.Resource, param.ID)
+	h.respond(ctx, resourcePermission, err)
+}
+
+func (h *PermissionHandler) getEntityPermissionByFqn(ctx *gin.Context) {
+	// Get param and validate
+	param := &policiesModels.GetEntityPermissionByFqnParam{}
+
+	if err := ctx.ShouldBindUri(param); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid param", "error": err.Error() })
+		return
+	}
+
+	resourcePermission, err := h.PermissionService.GetEntityPermissionByFqn(middlewares.GetPrincipal(ctx), param.Resource, param.FQN)
+	h.respond(ctx, resourcePermission, err)
+}
+
+func (h *PermissionHandler) respond(ctx *gin.Context, resourcePermission *policiesModels.ResourcePermission, err error) {
+	if errors.Is(err, policiesModels.ErrUnknownResource) {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid param", "error": err.Error() })
+		return
+	}
+
+	if errors.Is(err, sql.ErrNoRows) {
+		ctx.JSON(http.StatusNotFound, gin.H{ "message": "Entity not found", "error": err.Error() })
+		return
+	}
+
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{ "message": "Get permissions failed", "error": err.Error() })
+		return
+	}
+
+	ctx.JSON(http.StatusOK, resourcePermission)
+}
@@ -0,0 +1,265 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nambuitechx/go-metadata/middlewares"
+	baseModels "github.com/nambuitechx/go-metadata/models/base"
+	policiesModels "github.com/nambuitechx/go-metadata/models/policies"
+	policiesServices "github.com/nambuitechx/go-metadata/services/policies"
+)
+
+type PolicyEntityHandler struct {
+	PolicyEntityService *policiesServices.PolicyEntityService
+}
+
+func InitPolicyEntityHandler(e *gin.Engine, policyEntityService *policiesServices.PolicyEntityService) {
+	// Init handler
+	h := &PolicyEntityHandler{ PolicyEntityService: policyEntityService }
+
+	// Add routes to engine
+	g := e.Group("api/v1/policies")
+	{
+		g.GET("/health", h.health)
+		g.GET("", h.getAllPolicyEntities)
+		g.GET("/:id", h.getPolicyEntityById)
+		g.GET("/name/:name", h.getPolicyEntityByName)
+		g.POST("", h.createPolicyEntity)
+		g.PUT("", h.createOrUpdatePolicyEntity)
+		g.DELETE("/name/:name", h.deletePolicyEntityByName)
+	}
+}
+
+func (h *PolicyEntityHandler) health(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, gin.H{ "message": h.PolicyEntityService.Health() })
+}
+
+func (h *PolicyEntityHandler) getAllPolicyEntities(ctx *gin.Context) {
+	// Get query and validate
+	query := &policiesModels.GetPolicyEntitiesQuery{}
+
+	if err := ctx.ShouldBindQuery(query); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid query", "error": err.Error() })
+		return
+	}
+
+	if query.Limit == 0 {
+		query.Limit = 10
+	}
+
+	if query.Include == "" {
+		query.Include = "non-deleted"
+	}
+
+	if _, err := baseModels.ValidateInclude(query.Include); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid query", "error": err.Error() })
+		return
+	}
+
+	before, after, err := baseModels.ValidatePagingCursors(query.Before, query.After)
+
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid query", "error": err.Error() })
+		return
+	}
+
+	// Get policy entities
+	policyEntities, paging, err := h.PolicyEntityService.GetAllPolicyEntities(query.Include, query.Limit, before, after)
+
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Get all policies failed", "error": err.Error() })
+		return
+	}
+
+	jsonValues := []*policiesModels.Policy{}
+
+	for _, e := range policyEntities {
+		jsonValues = append(jsonValues, e.Json)
+	}
+
+	// Get paging
+	total, err := h.PolicyEntityService.GetCountPolicyEntities(query.Include)
+
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Get all policies failed", "error": err.Error() })
+		return
+	}
+
+	paging.Total = total.Total
+
+	ctx.JSON(http.StatusOK, gin.H{ "message": "Get all policies successfully", "data": jsonValues, "paging": paging })
+}
+
+func (h *PolicyEntityHandler) getPolicyEntityById(ctx *gin.Context) {
+	// Get param and validate
+	param := &policiesModels.GetPolicyEntityByIdParam{}
+
+	if err := ctx.ShouldBindUri(param); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid param", "error": err.Error() })
+		return
+	}
+
+	query := &baseModels.GetEntityQuery{}
+
+	if err := ctx.ShouldBindQuery(query); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid query", "error": err.Error() })
+		return
+	}
+
+	if query.Include == "" {
+		query.Include = "non-deleted"
+	}
+
+	if _, err := baseModels.ValidateInclude(query.Include); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid query", "error": err.Error() })
+		return
+	}
+
+	policyEntity, err := h.PolicyEntityService.GetPolicyEntityById(param.ID, query.Include)
+
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{ "message": "Policy not found", "error": err.Error() })
+		return
+	}
+
+	ctx.JSON(http.StatusOK, policyEntity.Json)
+}
+
+func (h *PolicyEntityHandler) getPolicyEntityByName(ctx *gin.Context) {
+	// Get param and validate
+	param := &policiesModels.GetPolicyEntityByNameParam{}
+
+	if err := ctx.ShouldBindUri(param); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid param", "error": err.Error() })
+		return
+	}
+
+	query := &baseModels.GetEntityQuery{}
+
+	if err := ctx.ShouldBindQuery(query); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid query", "error": err.Error() })
+		return
+	}
+
+	if query.Include == "" {
+		query.Include = "non-deleted"
+	}
+
+	if _, err := baseModels.ValidateInclude(query.Include); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid query", "error": err.Error() })
+		return
+	}
+
+	policyEntity, err := h.PolicyEntityService.GetPolicyEntityByName(param.Name, query.Include)
+
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{ "message": "Policy not found", "error": err.Error() })
+		return
+	}
+
+	ctx.JSON(http.StatusOK, policyEntity.Json)
+}
+
+func (h *PolicyEntityHandler) createPolicyEntity(ctx *gin.Context) {
+	if !middlewares.AuthorizeAdmin(ctx) {
+		return
+	}
+
+	// Get payload
+	payload := &policiesModels.CreatePolicyPayload{}
+
+	if err := ctx.ShouldBindJSON(payload); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid payload", "error": err.Error() })
+		return
+	}
+
+	if err := policiesModels.ValidateCreatePolicyPayload(payload); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid payload", "error": err.Error() })
+		return
+	}
+
+	// Create policy entity
+	policyEntity, err := h.PolicyEntityService.CreatePolicyEntity(payload, middlewares.GetUserName(ctx))
+
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Create policy failed", "error": err.Error() })
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, policyEntity.Json)
+}
+
+func (h *PolicyEntityHandler) createOrUpdatePolicyEntity(ctx *gin.Context) {
+	if !middlewares.AuthorizeAdmin(ctx) {
+		return
+	}
+
+	// Get payload
+	payload := &policiesModels.CreatePolicyPayload{}
+
+	if err := ctx.ShouldBindJSON(payload); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid payload", "error": err.Error() })
+		return
+	}
+
+	if err := policiesModels.ValidateCreatePolicyPayload(payload); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid payload", "error": err.Error() })
+		return
+	}
+
+	// Create or update policy entity
+	policyEntity, err := h.PolicyEntityService.CreateOrUpdatePolicyEntity(payload, middlewares.GetUserName(ctx))
+
+	if errors.Is(err, baseModels.ErrPreconditionFailed) {
+		ctx.JSON(http.StatusPreconditionFailed, gin.H{ "message": "Precondition failed", "error": err.Error() })
+		return
+	}
+
+	if errors.Is(err, baseModels.ErrEntityDeleted) {
+		ctx.JSON(http.StatusConflict, gin.H{ "message": "Create or update policy failed", "error": err.Error() })
+		return
+	}
+
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Create or update policy failed", "error": err.Error() })
+		return
+	}
+
+	ctx.JSON(http.StatusOK, policyEntity.Json)
+}
+
+func (h *PolicyEntityHandler) deletePolicyEntityByName(ctx *gin.Context) {
+	if !middlewares.AuthorizeAdmin(ctx) {
+		return
+	}
+
+	// Get param and validate
+	param := &policiesModels.GetPolicyEntityByNameParam{}
+
+	if err := ctx.ShouldBindUri(param); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid param", "error": err.Error() })
+		return
+	}
+
+	exist, err := h.PolicyEntityService.GetPolicyEntityByName(param.Name, "all")
+
+	if errors.Is(err, sql.ErrNoRows) {
+		ctx.JSON(http.StatusNotFound, gin.H{ "message": "Policy not found", "error": err.Error() })
+		return
+	}
+
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{ "message": "Delete policy failed", "error": err.Error() })
+		return
+	}
+
+	if err := h.PolicyEntityService.DeletePolicyEntity(exist, middlewares.GetUserName(ctx)); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Delete policy failed", "error": err.Error() })
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{ "message": "Delete policy by name successfully" })
+}
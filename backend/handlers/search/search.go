@@ -0,0 +1,171 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nambuitechx/go-metadata/middlewares"
+
+	policiesModels "github.com/nambuitechx/go-metadata/models/policies"
+	searchModels "github.com/nambuitechx/go-metadata/models/search"
+	policiesServices "github.com/nambuitechx/go-metadata/services/policies"
+	searchServices "github.com/nambuitechx/go-metadata/services/search"
+)
+
+type SearchHandler struct {
+	SearchService *searchServices.SearchService
+	Authorizer *policiesServices.Authorizer
+}
+
+func InitSearchHandler(e *gin.Engine, searchService *searchServices.SearchService, authorizer *policiesServices.Authorizer) {
+	// Init handler
+	h := &SearchHandler{ SearchService: searchService, Authorizer: authorizer }
+
+	// Add routes to engine
+	g := e.Group("api/v1/search")
+	{
+		g.GET("/health", h.health)
+		g.GET("/query", h.search)
+		g.GET("/suggest", h.suggest)
+		g.POST("/reindex", h.startReindex)
+		g.GET("/reindex", h.getReindexStatus)
+	}
+}
+
+func (h *SearchHandler) health(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, gin.H{ "message": h.SearchService.Health() })
+}
+
+func (h *SearchHandler) search(ctx *gin.Context) {
+	// Get query and validate
+	query := &searchModels.SearchQuery{}
+
+	if err := ctx.ShouldBindQuery(query); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid query", "error": err.Error() })
+		return
+	}
+
+	indexes, err := searchModels.ValidateSearchQuery(query)
+
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid query", "error": err.Error() })
+		return
+	}
+
+	// Search entities
+	result, err := h.SearchService.Search(query.Q, indexes, query.From, query.Size)
+
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Search failed", "error": err.Error() })
+		return
+	}
+
+	// Only the hits the user may view
+	resourceContexts := []*policiesModels.ResourceContext{}
+
+	for _, hit := range result.Hits {
+		resourceContexts = append(resourceContexts, policiesModels.NewResourceContext(hit.EntityType, hit.ID))
+	}
+
+	allowed, err := h.Authorizer.AuthorizeEach(middlewares.GetPrincipal(ctx), resourceContexts, policiesModels.ViewBasicOperation)
+
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{ "message": "Authorization failed", "error": err.Error() })
+		return
+	}
+
+	result.FilterHits(allowed)
+
+	ctx.JSON(http.StatusOK, result)
+}
+
+func (h *SearchHandler) suggest(ctx *gin.Context) {
+	// Get query and validate
+	query := &searchModels.SuggestQuery{}
+
+	if err := ctx.ShouldBindQuery(query); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid query", "error": err.Error() })
+		return
+	}
+
+	types, err := searchModels.ValidateSuggestQuery(query)
+
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid query", "error": err.Error() })
+		return
+	}
+
+	// Suggest entities
+	suggestions, err := h.SearchService.Suggest(query.Q, types, query.Size)
+
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Suggest failed", "error": err.Error() })
+		return
+	}
+
+	// Only the suggestions the user may view
+	resourceContexts := []*policiesModels.ResourceContext{}
+
+	for _, suggestion := range suggestions {
+		resourceContexts = append(resourceContexts, policiesModels.NewResourceContext(suggestion.Type, suggestion.ID))
+	}
+
+	allowed, err := h.Authorizer.AuthorizeEach(middlewares.GetPrincipal(ctx), resourceContexts, policiesModels.ViewBasicOperation)
+
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{ "message": "Authorization failed", "error": err.Error() })
+		return
+	}
+
+	suggestions = searchModels.FilterSuggestions(suggestions, allowed)
+
+	ctx.JSON(http.StatusOK, gin.H{ "message": "Suggest successfully", "data": suggestions })
+}
+
+func (h *SearchHandler) startReindex(ctx *gin.Context) {
+	if !middlewares.AuthorizeAdmin(ctx) {
+		return
+	}
+
+	// Get payload, an empty body reindexes everything
+	payload := &searchModels.ReindexPayload{}
+
+	if ctx.Request.ContentLength != 0 {
+		if err := ctx.ShouldBindJSON(payload); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid payload", "error": err.Error() })
+			return
+		}
+	}
+
+	if err := searchModels.ValidateReindexPayload(payload); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid payload", "error": err.Error() })
+		return
+	}
+
+	// Start reindex
+	status, err := h.SearchService.StartReindex(payload.EntityTypes, payload.BatchSize)
+
+	if errors.Is(err, searchModels.ErrReindexRunning) {
+		ctx.JSON(http.StatusConflict, gin.H{ "message": "Reindex failed", "error": err.Error() })
+		return
+	}
+
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Reindex failed", "error": err.Error() })
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, status)
+}
+
+func (h *SearchHandler) getReindexStatus(ctx *gin.Context) {
+	status := h.SearchService.GetReindexStatus()
+
+	if status == nil {
+		ctx.JSON(http.StatusNotFound, gin.H{ "message": "Reindex not found", "error": "no reindex was started" })
+		return
+	}
+
+	ctx.JSON(http.StatusOK, status)
+}
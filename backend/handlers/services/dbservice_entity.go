@@ -1,20 +1,28 @@
 package handlers
 
 import (
+	"database/sql"
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/nambuitechx/go-metadata/middlewares"
+	typeModels "github.com/nambuitechx/go-metadata/models/type"
 	servicesModels "github.com/nambuitechx/go-metadata/models/services"
+	baseModels "github.com/nambuitechx/go-metadata/models/base"
 	servicesServices "github.com/nambuitechx/go-metadata/services/services"
+	policiesModels "github.com/nambuitechx/go-metadata/models/policies"
+	policiesServices "github.com/nambuitechx/go-metadata/services/policies"
 )
 
 type DBServiceEntityHandler struct {
 	DBServiceEntityService *servicesServices.DBServiceEntityService
+	Authorizer *policiesServices.Authorizer
 }
 
-func InitDBServiceEntityHandler(e *gin.Engine, dbserviceEntityService *servicesServices.DBServiceEntityService) {
+func InitDBServiceEntityHandler(e *gin.Engine, dbserviceEntityService *servicesServices.DBServiceEntityService, authorizer *policiesServices.Authorizer) {
 	// Init handler
-	h := &DBServiceEntityHandler{ DBServiceEntityService: dbserviceEntityService }
+	h := &DBServiceEntityHandler{ DBServiceEntityService: dbserviceEntityService, Authorizer: authorizer }
 
 	// Add routes to engine
 	g := e.Group("api/v1/services/databaseServices")
@@ -22,9 +30,14 @@ func InitDBServiceEntityHandler(e *gin.Engine, dbserviceEntityService *servicesS
 		g.GET("/health", h.health)
 		g.GET("/:id", h.getDBServiceEntityById)
 		g.GET("/name/:fqn", h.getDBServiceEntityByFqn)
+		g.GET("/:id/versions", h.getDBServiceEntityVersions)
+		g.GET("/:id/versions/:version", h.getDBServiceEntityVersion)
 		g.GET("", h.getAllDBServiceEntities)
 		g.POST("", h.createDBServiceEntity)
 		g.PUT("", h.createOrUpdateDBServiceEntity)
+		g.PATCH("/:id", h.patchDBServiceEntityById)
+		g.PATCH("/name/:fqn", h.patchDBServiceEntityByFqn)
+		g.PUT("/restore", h.restoreDBServiceEntity)
 		g.PUT("/:id/testConnectionResult", h.updateTestConnectionResult)
 		g.DELETE("/:id", h.deleteDBServiceEntityById)
 		g.DELETE("/name/:fqn", h.deleteDBServiceEntityByFqn)
@@ -48,8 +61,28 @@ func (h *DBServiceEntityHandler) getAllDBServiceEntities(ctx *gin.Context) {
 		query.Limit = 10
 	}
 
+	if query.Include == "" {
+		query.Include = "non-deleted"
+	}
+
+	if _, err := baseModels.ValidateInclude(query.Include); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid query", "error": err.Error() })
+		return
+	}
+
+	before, after, err := baseModels.ValidatePagingCursors(query.Before, query.After)
+
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid query", "error": err.Error() })
+		return
+	}
+
+	if !middlewares.Authorize(ctx, h.Authorizer, policiesModels.NewResourceContext(policiesModels.DBServiceResource, ""), policiesModels.ViewAllOperation) {
+		return
+	}
+
 	// Get dbservice entites
-	dbserviceEntities, err := h.DBServiceEntityService.GetAllDBServiceEntities(query.Limit, query.Offset)
+	dbserviceEntities, paging, err := h.DBServiceEntityService.GetAllDBServiceEntities(query.Include, query.Limit, before, after)
 
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Get all dbservices failed", "error": err.Error() })
@@ -63,14 +96,16 @@ func (h *DBServiceEntityHandler) getAllDBServiceEntities(ctx *gin.Context) {
 	}
 
 	// Get paging
-	total, err := h.DBServiceEntityService.GetCountDBServiceEntities()
+	total, err := h.DBServiceEntityService.GetCountDBServiceEntities(query.Include)
 
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Get all dbservices failed", "error": err.Error() })
 		return
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{ "message": "Get all dbservices successfully", "data": jsonValues, "paging": total })
+	paging.Total = total.Total
+
+	middlewares.JSONMasked(ctx, http.StatusOK, gin.H{ "message": "Get all dbservices successfully", "data": jsonValues, "paging": paging })
 }
 
 func (h *DBServiceEntityHandler) getDBServiceEntityById(ctx *gin.Context) {
@@ -82,14 +117,45 @@ func (h *DBServiceEntityHandler) getDBServiceEntityById(ctx *gin.Context) {
 		return
 	}
 
-	dbserviceEntity, err := h.DBServiceEntityService.GetDBServiceEntityById(param.ID)
+	query := &baseModels.GetEntityQuery{}
+
+	if err := ctx.ShouldBindQuery(query); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid query", "error": err.Error() })
+		return
+	}
+
+	if query.Include == "" {
+		query.Include = "non-deleted"
+	}
+
+	if _, err := baseModels.ValidateInclude(query.Include); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid query", "error": err.Error() })
+		return
+	}
+
+	if !middlewares.Authorize(ctx, h.Authorizer, policiesModels.NewResourceContext(policiesModels.DBServiceResource, param.ID), policiesModels.ViewAllOperation) {
+		return
+	}
+
+	dbserviceEntity, err := h.DBServiceEntityService.GetDBServiceEntityById(param.ID, query.Include)
 	
 	if err != nil {
 		ctx.JSON(http.StatusNotFound, gin.H{ "message": "DBService not found", "error": err.Error() })
 		return
 	}
 
-	ctx.JSON(http.StatusOK, dbserviceEntity.Json)
+	ctx.Header("ETag", baseModels.EntityTag(dbserviceEntity.Json.Version))
+	if middlewares.AllowSecrets(ctx, h.Authorizer, policiesModels.NewResourceContext(policiesModels.DBServiceResource, dbserviceEntity.ID)) {
+		if err := h.DBServiceEntityService.DecryptDBServiceConnection(dbserviceEntity.Json); err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{ "message": "Get dbservice failed", "error": err.Error() })
+			return
+		}
+
+		ctx.JSON(http.StatusOK, dbserviceEntity.Json)
+		return
+	}
+
+	middlewares.JSONMasked(ctx, http.StatusOK, dbserviceEntity.Json)
 }
 
 func (h *DBServiceEntityHandler) getDBServiceEntityByFqn(ctx *gin.Context) {
@@ -101,17 +167,115 @@ func (h *DBServiceEntityHandler) getDBServiceEntityByFqn(ctx *gin.Context) {
 		return
 	}
 
-	dbserviceEntity, err := h.DBServiceEntityService.GetDBServiceEntityByFqn(param.FQN)
+	query := &baseModels.GetEntityQuery{}
+
+	if err := ctx.ShouldBindQuery(query); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid query", "error": err.Error() })
+		return
+	}
+
+	if query.Include == "" {
+		query.Include = "non-deleted"
+	}
+
+	if _, err := baseModels.ValidateInclude(query.Include); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid query", "error": err.Error() })
+		return
+	}
+
+	dbserviceEntity, err := h.DBServiceEntityService.GetDBServiceEntityByFqn(param.FQN, query.Include)
 	
 	if err != nil {
 		ctx.JSON(http.StatusNotFound, gin.H{ "message": "DBService not found", "error": err.Error() })
 		return
 	}
 
-	ctx.JSON(http.StatusOK, dbserviceEntity.Json)
+	if !middlewares.Authorize(ctx, h.Authorizer, policiesModels.NewResourceContext(policiesModels.DBServiceResource, dbserviceEntity.ID), policiesModels.ViewAllOperation) {
+		return
+	}
+
+	ctx.Header("ETag", baseModels.EntityTag(dbserviceEntity.Json.Version))
+	if middlewares.AllowSecrets(ctx, h.Authorizer, policiesModels.NewResourceContext(policiesModels.DBServiceResource, dbserviceEntity.ID)) {
+		if err := h.DBServiceEntityService.DecryptDBServiceConnection(dbserviceEntity.Json); err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{ "message": "Get dbservice failed", "error": err.Error() })
+			return
+		}
+
+		ctx.JSON(http.StatusOK, dbserviceEntity.Json)
+		return
+	}
+
+	middlewares.JSONMasked(ctx, http.StatusOK, dbserviceEntity.Json)
+}
+
+func (h *DBServiceEntityHandler) getDBServiceEntityVersions(ctx *gin.Context) {
+	// Get param and validate
+	param := &servicesModels.GetDBServiceEntityByIdParam{}
+
+	if err := ctx.ShouldBindUri(param); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid param", "error": err.Error() })
+		return
+	}
+
+	if !middlewares.Authorize(ctx, h.Authorizer, policiesModels.NewResourceContext(policiesModels.DBServiceResource, param.ID), policiesModels.ViewAllOperation) {
+		return
+	}
+
+	entityHistory, err := h.DBServiceEntityService.GetDBServiceEntityVersions(param.ID)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		ctx.JSON(http.StatusNotFound, gin.H{ "message": "DBService not found", "error": err.Error() })
+		return
+	}
+
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{ "message": "Get dbservice versions failed", "error": err.Error() })
+		return
+	}
+
+	middlewares.JSONMasked(ctx, http.StatusOK, entityHistory)
+}
+
+func (h *DBServiceEntityHandler) getDBServiceEntityVersion(ctx *gin.Context) {
+	// Get param and validate
+	param := &typeModels.GetEntityVersionParam{}
+
+	if err := ctx.ShouldBindUri(param); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid param", "error": err.Error() })
+		return
+	}
+
+	version, err := typeModels.ValidateVersion(param.Version)
+
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid param", "error": err.Error() })
+		return
+	}
+
+	if !middlewares.Authorize(ctx, h.Authorizer, policiesModels.NewResourceContext(policiesModels.DBServiceResource, param.ID), policiesModels.ViewAllOperation) {
+		return
+	}
+
+	entityVersion, err := h.DBServiceEntityService.GetDBServiceEntityVersion(param.ID, version)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		ctx.JSON(http.StatusNotFound, gin.H{ "message": "DBService version not found", "error": err.Error() })
+		return
+	}
+
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{ "message": "Get dbservice version failed", "error": err.Error() })
+		return
+	}
+
+	middlewares.JSONMasked(ctx, http.StatusOK, entityVersion)
 }
 
 func (h *DBServiceEntityHandler) createDBServiceEntity(ctx *gin.Context) {
+	if !middlewares.Authorize(ctx, h.Authorizer, policiesModels.NewResourceContext(policiesModels.DBServiceResource, ""), policiesModels.CreateOperation) {
+		return
+	}
+
 	// Get payload
 	payload := &servicesModels.CreateDBServiceEntityPayload{}
 
@@ -127,17 +291,22 @@ func (h *DBServiceEntityHandler) createDBServiceEntity(ctx *gin.Context) {
 	}
 
 	// Create dbservice entity
-	dbserviceEntity, err := h.DBServiceEntityService.CreateDBServiceEntity(payload);
+	dbserviceEntity, err := h.DBServiceEntityService.CreateDBServiceEntity(payload, middlewares.GetUserName(ctx));
 
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Create dbservice failed", "error": err.Error() })
 		return
 	}
 
-	ctx.JSON(http.StatusCreated, dbserviceEntity.Json)
+	ctx.Header("ETag", baseModels.EntityTag(dbserviceEntity.Json.Version))
+	middlewares.JSONMasked(ctx, http.StatusCreated, dbserviceEntity.Json)
 }
 
 func (h *DBServiceEntityHandler) createOrUpdateDBServiceEntity(ctx *gin.Context) {
+	if !middlewares.Authorize(ctx, h.Authorizer, policiesModels.NewResourceContext(policiesModels.DBServiceResource, ""), policiesModels.CreateOperation, policiesModels.EditAllOperation) {
+		return
+	}
+
 	// Get payload
 	payload := &servicesModels.CreateDBServiceEntityPayload{}
 
@@ -153,14 +322,25 @@ func (h *DBServiceEntityHandler) createOrUpdateDBServiceEntity(ctx *gin.Context)
 	}
 
 	// Create or update dbservice entity
-	dbserviceEntity, err := h.DBServiceEntityService.CreateOrUpdateDBServiceEntity(payload);
+	dbserviceEntity, err := h.DBServiceEntityService.CreateOrUpdateDBServiceEntity(payload, ctx.GetHeader("If-Match"), middlewares.GetUserName(ctx))
+
+	if errors.Is(err, baseModels.ErrPreconditionFailed) {
+		ctx.JSON(http.StatusPreconditionFailed, gin.H{ "message": "Precondition failed", "error": err.Error() })
+		return
+	}
+
+	if errors.Is(err, baseModels.ErrEntityDeleted) {
+		ctx.JSON(http.StatusConflict, gin.H{ "message": "Create or update dbservice failed", "error": err.Error() })
+		return
+	}
 
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Create or update dbservice failed", "error": err.Error() })
 		return
 	}
 
-	ctx.JSON(http.StatusOK, dbserviceEntity.Json)
+	ctx.Header("ETag", baseModels.EntityTag(dbserviceEntity.Json.Version))
+	middlewares.JSONMasked(ctx, http.StatusOK, dbserviceEntity.Json)
 }
 
 func (h *DBServiceEntityHandler) updateTestConnectionResult(ctx *gin.Context) {
@@ -180,22 +360,116 @@ func (h *DBServiceEntityHandler) updateTestConnectionResult(ctx *gin.Context) {
 		return
 	}
 
-	dbserviceEntity, err := h.DBServiceEntityService.GetDBServiceEntityById(param.ID)
+	dbserviceEntity, err := h.DBServiceEntityService.GetDBServiceEntityById(param.ID, "non-deleted")
 
 	if err != nil {
 		ctx.JSON(http.StatusNotFound, gin.H{ "message": "DBService not found", "error": err.Error() })
 		return
 	}
 
-	dbserviceEntity.Json.TestConnectionResult = payload
-	updatedDBServiceEntity, err := h.DBServiceEntityService.DBServiceEntityRepository.UpdateDBServiceEntity(dbserviceEntity)
+	if !middlewares.Authorize(ctx, h.Authorizer, policiesModels.NewResourceContext(policiesModels.DBServiceResource, dbserviceEntity.ID), policiesModels.EditAllOperation) {
+		return
+	}
+
+	updatedDBServiceEntity, err := h.DBServiceEntityService.UpdateDBServiceTestConnectionResult(dbserviceEntity, payload, middlewares.GetUserName(ctx))
 
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{ "message": "Failed to update test connection status for dbservice", "error": err.Error() })
 		return
 	}
 
-	ctx.JSON(http.StatusOK, updatedDBServiceEntity.Json)
+	ctx.Header("ETag", baseModels.EntityTag(updatedDBServiceEntity.Json.Version))
+	middlewares.JSONMasked(ctx, http.StatusOK, updatedDBServiceEntity.Json)
+}
+
+func (h *DBServiceEntityHandler) patchDBServiceEntityById(ctx *gin.Context) {
+	// Get param and validate
+	param := &servicesModels.GetDBServiceEntityByIdParam{}
+
+	if err := ctx.ShouldBindUri(param); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid param", "error": err.Error() })
+		return
+	}
+
+	// Get payload
+	var payload []baseModels.JsonPatchOperation
+
+	if err := ctx.ShouldBindJSON(&payload); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid payload", "error": err.Error() })
+		return
+	}
+
+	dbserviceEntity, err := h.DBServiceEntityService.GetDBServiceEntityById(param.ID, "non-deleted")
+
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{ "message": "DBService not found", "error": err.Error() })
+		return
+	}
+
+	if !middlewares.Authorize(ctx, h.Authorizer, policiesModels.NewResourceContext(policiesModels.DBServiceResource, dbserviceEntity.ID), policiesModels.GetPatchOperations(payload)...) {
+		return
+	}
+
+	// Patch dbservice entity
+	updatedDBServiceEntity, err := h.DBServiceEntityService.PatchDBServiceEntity(dbserviceEntity, payload, ctx.GetHeader("If-Match"), middlewares.GetUserName(ctx))
+
+	if errors.Is(err, baseModels.ErrPreconditionFailed) {
+		ctx.JSON(http.StatusPreconditionFailed, gin.H{ "message": "Precondition failed", "error": err.Error() })
+		return
+	}
+
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Patch dbservice by id failed", "error": err.Error() })
+		return
+	}
+
+	ctx.Header("ETag", baseModels.EntityTag(updatedDBServiceEntity.Json.Version))
+	middlewares.JSONMasked(ctx, http.StatusOK, updatedDBServiceEntity.Json)
+}
+
+func (h *DBServiceEntityHandler) patchDBServiceEntityByFqn(ctx *gin.Context) {
+	// Get param and validate
+	param := &servicesModels.GetDBServiceEntityByFqnParam{}
+
+	if err := ctx.ShouldBindUri(param); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid param", "error": err.Error() })
+		return
+	}
+
+	// Get payload
+	var payload []baseModels.JsonPatchOperation
+
+	if err := ctx.ShouldBindJSON(&payload); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid payload", "error": err.Error() })
+		return
+	}
+
+	dbserviceEntity, err := h.DBServiceEntityService.GetDBServiceEntityByFqn(param.FQN, "non-deleted")
+
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{ "message": "DBService not found", "error": err.Error() })
+		return
+	}
+
+	if !middlewares.Authorize(ctx, h.Authorizer, policiesModels.NewResourceContext(policiesModels.DBServiceResource, dbserviceEntity.ID), policiesModels.GetPatchOperations(payload)...) {
+		return
+	}
+
+	// Patch dbservice entity
+	updatedDBServiceEntity, err := h.DBServiceEntityService.PatchDBServiceEntity(dbserviceEntity, payload, ctx.GetHeader("If-Match"), middlewares.GetUserName(ctx))
+
+	if errors.Is(err, baseModels.ErrPreconditionFailed) {
+		ctx.JSON(http.StatusPreconditionFailed, gin.H{ "message": "Precondition failed", "error": err.Error() })
+		return
+	}
+
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Patch dbservice by fqn failed", "error": err.Error() })
+		return
+	}
+
+	ctx.Header("ETag", baseModels.EntityTag(updatedDBServiceEntity.Json.Version))
+	middlewares.JSONMasked(ctx, http.StatusOK, updatedDBServiceEntity.Json)
 }
 
 func (h *DBServiceEntityHandler) deleteDBServiceEntityById(ctx *gin.Context) {
@@ -207,7 +481,29 @@ func (h *DBServiceEntityHandler) deleteDBServiceEntityById(ctx *gin.Context) {
 		return
 	}
 
-	err := h.DBServiceEntityService.DeleteDBServiceEntityById(param.ID)
+	// Get query and validate
+	query := &baseModels.DeleteEntityQuery{}
+
+	if err := ctx.ShouldBindQuery(query); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid query", "error": err.Error() })
+		return
+	}
+
+	if !middlewares.Authorize(ctx, h.Authorizer, policiesModels.NewResourceContext(policiesModels.DBServiceResource, param.ID), policiesModels.DeleteOperation) {
+		return
+	}
+
+	err := h.DBServiceEntityService.DeleteDBServiceEntityById(param.ID, query.HardDelete, query.Recursive, middlewares.GetUserName(ctx))
+
+	if errors.Is(err, sql.ErrNoRows) {
+		ctx.JSON(http.StatusNotFound, gin.H{ "message": "DBService not found", "error": err.Error() })
+		return
+	}
+
+	if errors.Is(err, baseModels.ErrEntityHasChildren) {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Delete dbservice by id failed", "error": err.Error() })
+		return
+	}
 
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{ "message": "Delete dbservice by id failed", "error": err.Error() })
@@ -226,7 +522,36 @@ func (h *DBServiceEntityHandler) deleteDBServiceEntityByFqn(ctx *gin.Context) {
 		return
 	}
 
-	err := h.DBServiceEntityService.DeleteDBServiceEntityByFqn(param.FQN)
+	// Get query and validate
+	query := &baseModels.DeleteEntityQuery{}
+
+	if err := ctx.ShouldBindQuery(query); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid query", "error": err.Error() })
+		return
+	}
+
+	exist, err := h.DBServiceEntityService.GetDBServiceEntityByFqn(param.FQN, "all")
+
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{ "message": "DBService not found", "error": err.Error() })
+		return
+	}
+
+	if !middlewares.Authorize(ctx, h.Authorizer, policiesModels.NewResourceContext(policiesModels.DBServiceResource, exist.ID), policiesModels.DeleteOperation) {
+		return
+	}
+
+	err = h.DBServiceEntityService.DeleteDBServiceEntityByFqn(param.FQN, query.HardDelete, query.Recursive, middlewares.GetUserName(ctx))
+
+	if errors.Is(err, sql.ErrNoRows) {
+		ctx.JSON(http.StatusNotFound, gin.H{ "message": "DBService not found", "error": err.Error() })
+		return
+	}
+
+	if errors.Is(err, baseModels.ErrEntityHasChildren) {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Delete dbservice by fqn failed", "error": err.Error() })
+		return
+	}
 
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{ "message": "Delete dbservice by fqn failed", "error": err.Error() })
@@ -235,3 +560,33 @@ func (h *DBServiceEntityHandler) deleteDBServiceEntityByFqn(ctx *gin.Context) {
 
 	ctx.JSON(http.StatusOK, gin.H{ "message": "Delete dbservice by fqn successfully" })
 }
+
+func (h *DBServiceEntityHandler) restoreDBServiceEntity(ctx *gin.Context) {
+	// Get payload
+	payload := &baseModels.RestoreEntityPayload{}
+
+	if err := ctx.ShouldBindJSON(payload); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid payload", "error": err.Error() })
+		return
+	}
+
+	if !middlewares.Authorize(ctx, h.Authorizer, policiesModels.NewResourceContext(policiesModels.DBServiceResource, payload.ID), policiesModels.EditAllOperation) {
+		return
+	}
+
+	// Restore dbservice entity and its children
+	dbserviceEntity, err := h.DBServiceEntityService.RestoreDBServiceEntity(payload.ID, middlewares.GetUserName(ctx))
+
+	if errors.Is(err, sql.ErrNoRows) {
+		ctx.JSON(http.StatusNotFound, gin.H{ "message": "DBService not found", "error": err.Error() })
+		return
+	}
+
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Restore dbservice failed", "error": err.Error() })
+		return
+	}
+
+	ctx.Header("ETag", baseModels.EntityTag(dbserviceEntity.Json.Version))
+	middlewares.JSONMasked(ctx, http.StatusOK, dbserviceEntity.Json)
+}
@@ -4,18 +4,23 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/nambuitechx/go-metadata/middlewares"
 	
+	baseModels "github.com/nambuitechx/go-metadata/models/base"
 	servicesModels "github.com/nambuitechx/go-metadata/models/services"
 	servicesServices "github.com/nambuitechx/go-metadata/services/services"
+	policiesModels "github.com/nambuitechx/go-metadata/models/policies"
+	policiesServices "github.com/nambuitechx/go-metadata/services/policies"
 )
 
 type TestConnectionDefinitionEntityHandler struct {
 	TestConnectionDefinitionEntityService *servicesServices.TestConnectionDefinitionEntityService
+	Authorizer *policiesServices.Authorizer
 }
 
-func InitTestConnectionDefinitionEntityHandler(e *gin.Engine, testConnectionDefinitionEntityService *servicesServices.TestConnectionDefinitionEntityService) {
+func InitTestConnectionDefinitionEntityHandler(e *gin.Engine, testConnectionDefinitionEntityService *servicesServices.TestConnectionDefinitionEntityService, authorizer *policiesServices.Authorizer) {
 	// Init handler
-	h := &TestConnectionDefinitionEntityHandler{ TestConnectionDefinitionEntityService: testConnectionDefinitionEntityService }
+	h := &TestConnectionDefinitionEntityHandler{ TestConnectionDefinitionEntityService: testConnectionDefinitionEntityService, Authorizer: authorizer }
 
 	// Add routes to engine
 	g := e.Group("api/v1/services/testConnectionDefinitions")
@@ -44,8 +49,28 @@ func (h *TestConnectionDefinitionEntityHandler) getAllTestConnectionDefinitionEn
 		query.Limit = 10
 	}
 
+	if query.Include == "" {
+		query.Include = "non-deleted"
+	}
+
+	if _, err := baseModels.ValidateInclude(query.Include); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid query", "error": err.Error() })
+		return
+	}
+
+	before, after, err := baseModels.ValidatePagingCursors(query.Before, query.After)
+
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid query", "error": err.Error() })
+		return
+	}
+
+	if !middlewares.Authorize(ctx, h.Authorizer, policiesModels.NewResourceContext(policiesModels.TestConnectionDefinitionResource, ""), policiesModels.ViewAllOperation) {
+		return
+	}
+
 	// Get test connection definition entites
-	testConnectionDefinitionEntities, err := h.TestConnectionDefinitionEntityService.GetAllTestConnectionDefinitionEntities(query.Limit, query.Offset)
+	testConnectionDefinitionEntities, paging, err := h.TestConnectionDefinitionEntityService.GetAllTestConnectionDefinitionEntities(query.Include, query.Limit, before, after)
 
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Get all test connection definition failed", "error": err.Error() })
@@ -59,14 +84,16 @@ func (h *TestConnectionDefinitionEntityHandler) getAllTestConnectionDefinitionEn
 	}
 
 	// Get paging
-	total, err := h.TestConnectionDefinitionEntityService.GetCountTestConnectionDefinitionEntities()
+	total, err := h.TestConnectionDefinitionEntityService.GetCountTestConnectionDefinitionEntities(query.Include)
 
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Get all test connection definition failed", "error": err.Error() })
 		return
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{ "message": "Get all test connection definition successfully", "data": jsonValues, "paging": total })
+	paging.Total = total.Total
+
+	ctx.JSON(http.StatusOK, gin.H{ "message": "Get all test connection definition successfully", "data": jsonValues, "paging": paging })
 }
 
 func (h *TestConnectionDefinitionEntityHandler) getTestConnectionDefinitionEntityById(ctx *gin.Context) {
@@ -78,13 +105,34 @@ func (h *TestConnectionDefinitionEntityHandler) getTestConnectionDefinitionEntit
 		return
 	}
 
-	testConnectionDefinitionEntity, err := h.TestConnectionDefinitionEntityService.GetTestConnectionDefinitionEntityById(param.ID)
+	query := &baseModels.GetEntityQuery{}
+
+	if err := ctx.ShouldBindQuery(query); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid query", "error": err.Error() })
+		return
+	}
+
+	if query.Include == "" {
+		query.Include = "non-deleted"
+	}
+
+	if _, err := baseModels.ValidateInclude(query.Include); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid query", "error": err.Error() })
+		return
+	}
+
+	if !middlewares.Authorize(ctx, h.Authorizer, policiesModels.NewResourceContext(policiesModels.TestConnectionDefinitionResource, param.ID), policiesModels.ViewAllOperation) {
+		return
+	}
+
+	testConnectionDefinitionEntity, err := h.TestConnectionDefinitionEntityService.GetTestConnectionDefinitionEntityById(param.ID, query.Include)
 	
 	if err != nil {
 		ctx.JSON(http.StatusNotFound, gin.H{ "message": "TestConnectionDefinition not found", "error": err.Error() })
 		return
 	}
 
+	ctx.Header("ETag", baseModels.TimestampEntityTag(testConnectionDefinitionEntity.UpdatedAt))
 	ctx.JSON(http.StatusOK, testConnectionDefinitionEntity.Json)
 }
 
@@ -97,12 +145,33 @@ func (h *TestConnectionDefinitionEntityHandler) getTestConnectionDefinitionEntit
 		return
 	}
 
-	testConnectionDefinitionEntity, err := h.TestConnectionDefinitionEntityService.GetTestConnectionDefinitionEntityByFqn(param.FQN)
+	query := &baseModels.GetEntityQuery{}
+
+	if err := ctx.ShouldBindQuery(query); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid query", "error": err.Error() })
+		return
+	}
+
+	if query.Include == "" {
+		query.Include = "non-deleted"
+	}
+
+	if _, err := baseModels.ValidateInclude(query.Include); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid query", "error": err.Error() })
+		return
+	}
+
+	testConnectionDefinitionEntity, err := h.TestConnectionDefinitionEntityService.GetTestConnectionDefinitionEntityByFqn(param.FQN, query.Include)
 	
 	if err != nil {
 		ctx.JSON(http.StatusNotFound, gin.H{ "message": "TestConnectionDefinition not found", "error": err.Error() })
 		return
 	}
 
-	ctx.JSON(http.StatusOK, testConnectionDefinitionEntity.Json )
+	if !middlewares.Authorize(ctx, h.Authorizer, policiesModels.NewResourceContext(policiesModels.TestConnectionDefinitionResource, testConnectionDefinitionEntity.ID), policiesModels.ViewAllOperation) {
+		return
+	}
+
+	ctx.Header("ETag", baseModels.TimestampEntityTag(testConnectionDefinitionEntity.UpdatedAt))
+	ctx.JSON(http.StatusOK, testConnectionDefinitionEntity.Json)
 }
@@ -0,0 +1,317 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nambuitechx/go-metadata/middlewares"
+	baseModels "github.com/nambuitechx/go-metadata/models/base"
+	teamsModels "github.com/nambuitechx/go-metadata/models/teams"
+	teamsServices "github.com/nambuitechx/go-metadata/services/teams"
+)
+
+type BotEntityHandler struct {
+	BotEntityService *teamsServices.BotEntityService
+}
+
+func InitBotEntityHandler(e *gin.Engine, botEntityService *teamsServices.BotEntityService) {
+	// Init handler
+	h := &BotEntityHandler{ BotEntityService: botEntityService }
+
+	// Add routes to engine
+	g := e.Group("api/v1/bots")
+	{
+		g.GET("/health", h.health)
+		g.GET("", h.getAllBotEntities)
+		g.GET("/:id", h.getBotEntityById)
+		g.GET("/name/:name", h.getBotEntityByName)
+		g.POST("", h.createBotEntity)
+		g.DELETE("/name/:name", h.deleteBotEntityByName)
+		g.GET("/name/:name/token", h.getBotTokenByName)
+		g.POST("/name/:name/token", h.generateBotTokenByName)
+		g.DELETE("/name/:name/token", h.revokeBotTokenByName)
+	}
+}
+
+func (h *BotEntityHandler) health(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, gin.H{ "message": h.BotEntityService.Health() })
+}
+
+func (h *BotEntityHandler) getAllBotEntities(ctx *gin.Context) {
+	// Get query and validate
+	query := &teamsModels.GetBotEntitiesQuery{}
+
+	if err := ctx.ShouldBindQuery(query); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid query", "error": err.Error() })
+		return
+	}
+
+	if query.Limit == 0 {
+		query.Limit = 10
+	}
+
+	if query.Include == "" {
+		query.Include = "non-deleted"
+	}
+
+	if _, err := baseModels.ValidateInclude(query.Include); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid query", "error": err.Error() })
+		return
+	}
+
+	before, after, err := baseModels.ValidatePagingCursors(query.Before, query.After)
+
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid query", "error": err.Error() })
+		return
+	}
+
+	// Get bot entities
+	botEntities, paging, err := h.BotEntityService.GetAllBotEntities(query.Include, query.Limit, before, after)
+
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Get all bots failed", "error": err.Error() })
+		return
+	}
+
+	jsonValues := []*teamsModels.Bot{}
+
+	for _, e := range botEntities {
+		jsonValues = append(jsonValues, e.Json)
+	}
+
+	// Get paging
+	total, err := h.BotEntityService.GetCountBotEntities(query.Include)
+
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Get all bots failed", "error": err.Error() })
+		return
+	}
+
+	paging.Total = total.Total
+
+	ctx.JSON(http.StatusOK, gin.H{ "message": "Get all bots successfully", "data": jsonValues, "paging": paging })
+}
+
+func (h *BotEntityHandler) getBotEntityById(ctx *gin.Context) {
+	// Get param and validate
+	param := &teamsModels.GetBotEntityByIdParam{}
+
+	if err := ctx.ShouldBindUri(param); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid param", "error": err.Error() })
+		return
+	}
+
+	query := &baseModels.GetEntityQuery{}
+
+	if err := ctx.ShouldBindQuery(query); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid query", "error": err.Error() })
+		return
+	}
+
+	if query.Include == "" {
+		query.Include = "non-deleted"
+	}
+
+	if _, err := baseModels.ValidateInclude(query.Include); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid query", "error": err.Error() })
+		return
+	}
+
+	botEntity, err := h.BotEntityService.GetBotEntityById(param.ID, query.Include)
+
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{ "message": "Bot not found", "error": err.Error() })
+		return
+	}
+
+	ctx.JSON(http.StatusOK, botEntity.Json)
+}
+
+func (h *BotEntityHandler) getBotEntityByName(ctx *gin.Context) {
+	// Get param and validate
+	param := &teamsModels.GetBotEntityByNameParam{}
+
+	if err := ctx.ShouldBindUri(param); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid param", "error": err.Error() })
+		return
+	}
+
+	query := &baseModels.GetEntityQuery{}
+
+	if err := ctx.ShouldBindQuery(query); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid query", "error": err.Error() })
+		return
+	}
+
+	if query.Include == "" {
+		query.Include = "non-deleted"
+	}
+
+	if _, err := baseModels.ValidateInclude(query.Include); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid query", "error": err.Error() })
+		return
+	}
+
+	botEntity, err := h.BotEntityService.GetBotEntityByName(param.Name, query.Include)
+
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{ "message": "Bot not found", "error": err.Error() })
+		return
+	}
+
+	ctx.JSON(http.StatusOK, botEntity.Json)
+}
+
+func (h *BotEntityHandler) createBotEntity(ctx *gin.Context) {
+	if !middlewares.AuthorizeAdmin(ctx) {
+		return
+	}
+
+	// Get payload
+	payload := &teamsModels.CreateBotPayload{}
+
+	if err := ctx.ShouldBindJSON(payload); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid payload", "error": err.Error() })
+		return
+	}
+
+	if err := teamsModels.ValidateCreateBotPayload(payload); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid payload", "error": err.Error() })
+		return
+	}
+
+	// Create bot entity
+	botEntity, err := h.BotEntityService.CreateBotEntity(payload, middlewares.GetUserName(ctx))
+
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Create bot failed", "error": err.Error() })
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, botEntity.Json)
+}
+
+func (h *BotEntityHandler) deleteBotEntityByName(ctx *gin.Context) {
+	if !middlewares.AuthorizeAdmin(ctx) {
+		return
+	}
+
+	exist, ok := h.getExistBotEntity(ctx, "Delete bot failed")
+
+	if !ok {
+		return
+	}
+
+	if err := h.BotEntityService.DeleteBotEntity(exist, middlewares.GetUserName(ctx)); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Delete bot failed", "error": err.Error() })
+		return
+	}
+
+	ctx.JSON(http.StatusOK, exist.Json)
+}
+
+func (h *BotEntityHandler) getBotTokenByName(ctx *gin.Context) {
+	exist, ok := h.getExistBotEntity(ctx, "Get bot token failed")
+
+	if !ok {
+		return
+	}
+
+	userToken, err := h.BotEntityService.GetBotToken(exist)
+
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{ "message": "Get bot token failed", "error": err.Error() })
+		return
+	}
+
+	if userToken == nil {
+		ctx.JSON(http.StatusNotFound, gin.H{ "message": "Bot token not found", "error": sql.ErrNoRows.Error() })
+		return
+	}
+
+	ctx.JSON(http.StatusOK, userToken)
+}
+
+func (h *BotEntityHandler) generateBotTokenByName(ctx *gin.Context) {
+	if !middlewares.AuthorizeAdmin(ctx) {
+		return
+	}
+
+	exist, ok := h.getExistBotEntity(ctx, "Generate bot token failed")
+
+	if !ok {
+		return
+	}
+
+	// Get payload, an empty body issues a token with the default expiry
+	payload := &teamsModels.GenerateBotTokenPayload{}
+
+	if ctx.Request.ContentLength != 0 {
+		if err := ctx.ShouldBindJSON(payload); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid payload", "error": err.Error() })
+			return
+		}
+	}
+
+	expiryDays, err := teamsModels.ValidateTokenExpiryDays(payload.ExpiryDays)
+
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid payload", "error": err.Error() })
+		return
+	}
+
+	userTokenResponse, err := h.BotEntityService.GenerateBotToken(exist, expiryDays, middlewares.GetUserName(ctx))
+
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Generate bot token failed", "error": err.Error() })
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, userTokenResponse)
+}
+
+func (h *BotEntityHandler) revokeBotTokenByName(ctx *gin.Context) {
+	if !middlewares.AuthorizeAdmin(ctx) {
+		return
+	}
+
+	exist, ok := h.getExistBotEntity(ctx, "Revoke bot token failed")
+
+	if !ok {
+		return
+	}
+
+	if err := h.BotEntityService.RevokeBotToken(exist); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Revoke bot token failed", "error": err.Error() })
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{ "message": "Revoke bot token successfully" })
+}
+
+// getExistBotEntity reads the bot named in the uri and writes the error response when it cannot
+func (h *BotEntityHandler) getExistBotEntity(ctx *gin.Context, failedMessage string) (*teamsModels.BotEntity, bool) {
+	param := &teamsModels.GetBotEntityByNameParam{}
+
+	if err := ctx.ShouldBindUri(param); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid param", "error": err.Error() })
+		return nil, false
+	}
+
+	exist, err := h.BotEntityService.GetBotEntityByName(param.Name, "non-deleted")
+
+	if errors.Is(err, sql.ErrNoRows) {
+		ctx.JSON(http.StatusNotFound, gin.H{ "message": "Bot not found", "error": err.Error() })
+		return nil, false
+	}
+
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{ "message": failedMessage, "error": err.Error() })
+		return nil, false
+	}
+
+	return exist, true
+}
@@ -0,0 +1,265 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nambuitechx/go-metadata/middlewares"
+	baseModels "github.com/nambuitechx/go-metadata/models/base"
+	teamsModels "github.com/nambuitechx/go-metadata/models/teams"
+	teamsServices "github.com/nambuitechx/go-metadata/services/teams"
+)
+
+type RoleEntityHandler struct {
+	RoleEntityService *teamsServices.RoleEntityService
+}
+
+func InitRoleEntityHandler(e *gin.Engine, roleEntityService *teamsServices.RoleEntityService) {
+	// Init handler
+	h := &RoleEntityHandler{ RoleEntityService: roleEntityService }
+
+	// Add routes to engine
+	g := e.Group("api/v1/roles")
+	{
+		g.GET("/health", h.health)
+		g.GET("", h.getAllRoleEntities)
+		g.GET("/:id", h.getRoleEntityById)
+		g.GET("/name/:name", h.getRoleEntityByName)
+		g.POST("", h.createRoleEntity)
+		g.PUT("", h.createOrUpdateRoleEntity)
+		g.DELETE("/name/:name", h.deleteRoleEntityByName)
+	}
+}
+
+func (h *RoleEntityHandler) health(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, gin.H{ "message": h.RoleEntityService.Health() })
+}
+
+func (h *RoleEntityHandler) getAllRoleEntities(ctx *gin.Context) {
+	// Get query and validate
+	query := &teamsModels.GetRoleEntitiesQuery{}
+
+	if err := ctx.ShouldBindQuery(query); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid query", "error": err.Error() })
+		return
+	}
+
+	if query.Limit == 0 {
+		query.Limit = 10
+	}
+
+	if query.Include == "" {
+		query.Include = "non-deleted"
+	}
+
+	if _, err := baseModels.ValidateInclude(query.Include); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid query", "error": err.Error() })
+		return
+	}
+
+	before, after, err := baseModels.ValidatePagingCursors(query.Before, query.After)
+
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid query", "error": err.Error() })
+		return
+	}
+
+	// Get role entities
+	roleEntities, paging, err := h.RoleEntityService.GetAllRoleEntities(query.Include, query.Limit, before, after)
+
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Get all roles failed", "error": err.Error() })
+		return
+	}
+
+	jsonValues := []*teamsModels.Role{}
+
+	for _, e := range roleEntities {
+		jsonValues = append(jsonValues, e.Json)
+	}
+
+	// Get paging
+	total, err := h.RoleEntityService.GetCountRoleEntities(query.Include)
+
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Get all roles failed", "error": err.Error() })
+		return
+	}
+
+	paging.Total = total.Total
+
+	ctx.JSON(http.StatusOK, gin.H{ "message": "Get all roles successfully", "data": jsonValues, "paging": paging })
+}
+
+func (h *RoleEntityHandler) getRoleEntityById(ctx *gin.Context) {
+	// Get param and validate
+	param := &teamsModels.GetRoleEntityByIdParam{}
+
+	if err := ctx.ShouldBindUri(param); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid param", "error": err.Error() })
+		return
+	}
+
+	query := &baseModels.GetEntityQuery{}
+
+	if err := ctx.ShouldBindQuery(query); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid query", "error": err.Error() })
+		return
+	}
+
+	if query.Include == "" {
+		query.Include = "non-deleted"
+	}
+
+	if _, err := baseModels.ValidateInclude(query.Include); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid query", "error": err.Error() })
+		return
+	}
+
+	roleEntity, err := h.RoleEntityService.GetRoleEntityById(param.ID, query.Include)
+
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{ "message": "Role not found", "error": err.Error() })
+		return
+	}
+
+	ctx.JSON(http.StatusOK, roleEntity.Json)
+}
+
+func (h *RoleEntityHandler) getRoleEntityByName(ctx *gin.Context) {
+	// Get param and validate
+	param := &teamsModels.GetRoleEntityByNameParam{}
+
+	if err := ctx.ShouldBindUri(param); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid param", "error": err.Error() })
+		return
+	}
+
+	query := &baseModels.GetEntityQuery{}
+
+	if err := ctx.ShouldBindQuery(query); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid query", "error": err.Error() })
+		return
+	}
+
+	if query.Include == "" {
+		query.Include = "non-deleted"
+	}
+
+	if _, err := baseModels.ValidateInclude(query.Include); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid query", "error": err.Error() })
+		return
+	}
+
+	roleEntity, err := h.RoleEntityService.GetRoleEntityByName(param.Name, query.Include)
+
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{ "message": "Role not found", "error": err.Error() })
+		return
+	}
+
+	ctx.JSON(http.StatusOK, roleEntity.Json)
+}
+
+func (h *RoleEntityHandler) createRoleEntity(ctx *gin.Context) {
+	if !middlewares.AuthorizeAdmin(ctx) {
+		return
+	}
+
+	// Get payload
+	payload := &teamsModels.CreateRolePayload{}
+
+	if err := ctx.ShouldBindJSON(payload); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid payload", "error": err.Error() })
+		return
+	}
+
+	if err := teamsModels.ValidateCreateRolePayload(payload); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid payload", "error": err.Error() })
+		return
+	}
+
+	// Create role entity
+	roleEntity, err := h.RoleEntityService.CreateRoleEntity(payload, middlewares.GetUserName(ctx))
+
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Create role failed", "error": err.Error() })
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, roleEntity.Json)
+}
+
+func (h *RoleEntityHandler) createOrUpdateRoleEntity(ctx *gin.Context) {
+	if !middlewares.AuthorizeAdmin(ctx) {
+		return
+	}
+
+	// Get payload
+	payload := &teamsModels.CreateRolePayload{}
+
+	if err := ctx.ShouldBindJSON(payload); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid payload", "error": err.Error() })
+		return
+	}
+
+	if err := teamsModels.ValidateCreateRolePayload(payload); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid payload", "error": err.Error() })
+		return
+	}
+
+	// Create or update role entity
+	roleEntity, err := h.RoleEntityService.CreateOrUpdateRoleEntity(payload, middlewares.GetUserName(ctx))
+
+	if errors.Is(err, baseModels.ErrPreconditionFailed) {
+		ctx.JSON(http.StatusPreconditionFailed, gin.H{ "message": "Precondition failed", "error": err.Error() })
+		return
+	}
+
+	if errors.Is(err, baseModels.ErrEntityDeleted) {
+		ctx.JSON(http.StatusConflict, gin.H{ "message": "Create or update role failed", "error": err.Error() })
+		return
+	}
+
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Create or update role failed", "error": err.Error() })
+		return
+	}
+
+	ctx.JSON(http.StatusOK, roleEntity.Json)
+}
+
+func (h *RoleEntityHandler) deleteRoleEntityByName(ctx *gin.Context) {
+	if !middlewares.AuthorizeAdmin(ctx) {
+		return
+	}
+
+	// Get param and validate
+	param := &teamsModels.GetRoleEntityByNameParam{}
+
+	if err := ctx.ShouldBindUri(param); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid param", "error": err.Error() })
+		return
+	}
+
+	exist, err := h.RoleEntityService.GetRoleEntityByName(param.Name, "all")
+
+	if errors.Is(err, sql.ErrNoRows) {
+		ctx.JSON(http.StatusNotFound, gin.H{ "message": "Role not found", "error": err.Error() })
+		return
+	}
+
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{ "message": "Delete role failed", "error": err.Error() })
+		return
+	}
+
+	if err := h.RoleEntityService.DeleteRoleEntity(exist, middlewares.GetUserName(ctx)); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Delete role failed", "error": err.Error() })
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{ "message": "Delete role by name successfully" })
+}
@@ -0,0 +1,265 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nambuitechx/go-metadata/middlewares"
+	baseModels "github.com/nambuitechx/go-metadata/models/base"
+	teamsModels "github.com/nambuitechx/go-metadata/models/teams"
+	teamsServices "github.com/nambuitechx/go-metadata/services/teams"
+)
+
+type TeamEntityHandler struct {
+	TeamEntityService *teamsServices.TeamEntityService
+}
+
+func InitTeamEntityHandler(e *gin.Engine, teamEntityService *teamsServices.TeamEntityService) {
+	// Init handler
+	h := &TeamEntityHandler{ TeamEntityService: teamEntityService }
+
+	// Add routes to engine
+	g := e.Group("api/v1/teams")
+	{
+		g.GET("/health", h.health)
+		g.GET("", h.getAllTeamEntities)
+		g.GET("/:id", h.getTeamEntityById)
+		g.GET("/name/:name", h.getTeamEntityByName)
+		g.POST("", h.createTeamEntity)
+		g.PUT("", h.createOrUpdateTeamEntity)
+		g.DELETE("/name/:name", h.deleteTeamEntityByName)
+	}
+}
+
+func (h *TeamEntityHandler) health(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, gin.H{ "message": h.TeamEntityService.Health() })
+}
+
+func (h *TeamEntityHandler) getAllTeamEntities(ctx *gin.Context) {
+	// Get query and validate
+	query := &teamsModels.GetTeamEntitiesQuery{}
+
+	if err := ctx.ShouldBindQuery(query); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid query", "error": err.Error() })
+		return
+	}
+
+	if query.Limit == 0 {
+		query.Limit = 10
+	}
+
+	if query.Include == "" {
+		query.Include = "non-deleted"
+	}
+
+	if _, err := baseModels.ValidateInclude(query.Include); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid query", "error": err.Error() })
+		return
+	}
+
+	before, after, err := baseModels.ValidatePagingCursors(query.Before, query.After)
+
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid query", "error": err.Error() })
+		return
+	}
+
+	// Get team entities
+	teamEntities, paging, err := h.TeamEntityService.GetAllTeamEntities(query.Include, query.Limit, before, after)
+
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Get all teams failed", "error": err.Error() })
+		return
+	}
+
+	jsonValues := []*teamsModels.Team{}
+
+	for _, e := range teamEntities {
+		jsonValues = append(jsonValues, e.Json)
+	}
+
+	// Get paging
+	total, err := h.TeamEntityService.GetCountTeamEntities(query.Include)
+
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Get all teams failed", "error": err.Error() })
+		return
+	}
+
+	paging.Total = total.Total
+
+	ctx.JSON(http.StatusOK, gin.H{ "message": "Get all teams successfully", "data": jsonValues, "paging": paging })
+}
+
+func (h *TeamEntityHandler) getTeamEntityById(ctx *gin.Context) {
+	// Get param and validate
+	param := &teamsModels.GetTeamEntityByIdParam{}
+
+	if err := ctx.ShouldBindUri(param); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid param", "error": err.Error() })
+		return
+	}
+
+	query := &baseModels.GetEntityQuery{}
+
+	if err := ctx.ShouldBindQuery(query); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid query", "error": err.Error() })
+		return
+	}
+
+	if query.Include == "" {
+		query.Include = "non-deleted"
+	}
+
+	if _, err := baseModels.ValidateInclude(query.Include); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid query", "error": err.Error() })
+		return
+	}
+
+	teamEntity, err := h.TeamEntityService.GetTeamEntityById(param.ID, query.Include)
+
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{ "message": "Team not found", "error": err.Error() })
+		return
+	}
+
+	ctx.JSON(http.StatusOK, teamEntity.Json)
+}
+
+func (h *TeamEntityHandler) getTeamEntityByName(ctx *gin.Context) {
+	// Get param and validate
+	param := &teamsModels.GetTeamEntityByNameParam{}
+
+	if err := ctx.ShouldBindUri(param); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid param", "error": err.Error() })
+		return
+	}
+
+	query := &baseModels.GetEntityQuery{}
+
+	if err := ctx.ShouldBindQuery(query); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid query", "error": err.Error() })
+		return
+	}
+
+	if query.Include == "" {
+		query.Include = "non-deleted"
+	}
+
+	if _, err := baseModels.ValidateInclude(query.Include); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid query", "error": err.Error() })
+		return
+	}
+
+	teamEntity, err := h.TeamEntityService.GetTeamEntityByName(param.Name, query.Include)
+
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{ "message": "Team not found", "error": err.Error() })
+		return
+	}
+
+	ctx.JSON(http.StatusOK, teamEntity.Json)
+}
+
+func (h *TeamEntityHandler) createTeamEntity(ctx *gin.Context) {
+	if !middlewares.AuthorizeAdmin(ctx) {
+		return
+	}
+
+	// Get payload
+	payload := &teamsModels.CreateTeamPayload{}
+
+	if err := ctx.ShouldBindJSON(payload); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid payload", "error": err.Error() })
+		return
+	}
+
+	if err := teamsModels.ValidateCreateTeamPayload(payload); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid payload", "error": err.Error() })
+		return
+	}
+
+	// Create team entity
+	teamEntity, err := h.TeamEntityService.CreateTeamEntity(payload, middlewares.GetUserName(ctx))
+
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Create team failed", "error": err.Error() })
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, teamEntity.Json)
+}
+
+func (h *TeamEntityHandler) createOrUpdateTeamEntity(ctx *gin.Context) {
+	if !middlewares.AuthorizeAdmin(ctx) {
+		return
+	}
+
+	// Get payload
+	payload := &teamsModels.CreateTeamPayload{}
+
+	if err := ctx.ShouldBindJSON(payload); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid payload", "error": err.Error() })
+		return
+	}
+
+	if err := teamsModels.ValidateCreateTeamPayload(payload); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid payload", "error": err.Error() })
+		return
+	}
+
+	// Create or update team entity
+	teamEntity, err := h.TeamEntityService.CreateOrUpdateTeamEntity(payload, middlewares.GetUserName(ctx))
+
+	if errors.Is(err, baseModels.ErrPreconditionFailed) {
+		ctx.JSON(http.StatusPreconditionFailed, gin.H{ "message": "Precondition failed", "error": err.Error() })
+		return
+	}
+
+	if errors.Is(err, baseModels.ErrEntityDeleted) {
+		ctx.JSON(http.StatusConflict, gin.H{ "message": "Create or update team failed", "error": err.Error() })
+		return
+	}
+
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Create or update team failed", "error": err.Error() })
+		return
+	}
+
+	ctx.JSON(http.StatusOK, teamEntity.Json)
+}
+
+func (h *TeamEntityHandler) deleteTeamEntityByName(ctx *gin.Context) {
+	if !middlewares.AuthorizeAdmin(ctx) {
+		return
+	}
+
+	// Get param and validate
+	param := &teamsModels.GetTeamEntityByNameParam{}
+
+	if err := ctx.ShouldBindUri(param); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid param", "error": err.Error() })
+		return
+	}
+
+	exist, err := h.TeamEntityService.GetTeamEntityByName(param.Name, "all")
+
+	if errors.Is(err, sql.ErrNoRows) {
+		ctx.JSON(http.StatusNotFound, gin.H{ "message": "Team not found", "error": err.Error() })
+		return
+	}
+
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{ "message": "Delete team failed", "error": err.Error() })
+		return
+	}
+
+	if err := h.TeamEntityService.DeleteTeamEntity(exist, middlewares.GetUserName(ctx)); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Delete team failed", "error": err.Error() })
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{ "message": "Delete team by name successfully" })
+}
@@ -0,0 +1,417 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nambuitechx/go-metadata/middlewares"
+	baseModels "github.com/nambuitechx/go-metadata/models/base"
+	teamsModels "github.com/nambuitechx/go-metadata/models/teams"
+	teamsServices "github.com/nambuitechx/go-metadata/services/teams"
+)
+
+type UserEntityHandler struct {
+	UserEntityService *teamsServices.UserEntityService
+	AuthService *teamsServices.AuthService
+}
+
+func InitUserEntityHandler(e *gin.Engine, userEntityService *teamsServices.UserEntityService, authService *teamsServices.AuthService) {
+	// Init handler
+	h := &UserEntityHandler{ UserEntityService: userEntityService, AuthService: authService }
+
+	// Add routes to engine
+	g := e.Group("api/v1/users")
+	{
+		g.GET("/health", h.health)
+		g.POST("/login", h.login)
+		g.GET("/loggedInUser", h.getLoggedInUser)
+		g.GET("/personalAccessTokens", h.getPersonalAccessTokens)
+		g.POST("/personalAccessTokens", h.createPersonalAccessToken)
+		g.DELETE("/personalAccessTokens/:id", h.revokePersonalAccessToken)
+		g.GET("/:id", h.getUserEntityById)
+		g.GET("/name/:name", h.getUserEntityByName)
+		g.GET("", h.getAllUserEntities)
+		g.POST("", h.createUserEntity)
+		g.PUT("/name/:name/deactivate", h.deactivateUserEntityByName)
+		g.PUT("/name/:name/roles", h.setUserRolesByName)
+	}
+}
+
+func (h *UserEntityHandler) health(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, gin.H{ "message": h.UserEntityService.Health() })
+}
+
+func (h *UserEntityHandler) login(ctx *gin.Context) {
+	name, password, ok := ctx.Request.BasicAuth()
+
+	if !ok {
+		ctx.Header("WWW-Authenticate", `Basic realm="go-metadata"`)
+		ctx.JSON(http.StatusUnauthorized, gin.H{ "message": "Login failed", "error": "missing basic authorization header" })
+		return
+	}
+
+	loginResponse, err := h.AuthService.Login(name, password)
+
+	if errors.Is(err, teamsModels.ErrInvalidCredentials) || errors.Is(err, teamsModels.ErrUserDeactivated) {
+		ctx.JSON(http.StatusUnauthorized, gin.H{ "message": "Login failed", "error": err.Error() })
+		return
+	}
+
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{ "message": "Login failed", "error": err.Error() })
+		return
+	}
+
+	ctx.JSON(http.StatusOK, loginResponse)
+}
+
+func (h *UserEntityHandler) getLoggedInUser(ctx *gin.Context) {
+	userEntity, err := h.UserEntityService.GetUserEntityByName(middlewares.GetUserName(ctx), "non-deleted")
+
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{ "message": "User not found", "error": err.Error() })
+		return
+	}
+
+	ctx.JSON(http.StatusOK, userEntity.Json)
+}
+
+func (h *UserEntityHandler) getAllUserEntities(ctx *gin.Context) {
+	// Get query and validate
+	query := &teamsModels.GetUserEntitiesQuery{}
+
+	if err := ctx.ShouldBindQuery(query); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid query", "error": err.Error() })
+		return
+	}
+
+	if query.Limit == 0 {
+		query.Limit = 10
+	}
+
+	if query.Include == "" {
+		query.Include = "non-deleted"
+	}
+
+	if _, err := baseModels.ValidateInclude(query.Include); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid query", "error": err.Error() })
+		return
+	}
+
+	before, after, err := baseModels.ValidatePagingCursors(query.Before, query.After)
+
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid query", "error": err.Error() })
+		return
+	}
+
+	// Get user entities
+	userEntities, paging, err := h.UserEntityService.GetAllUserEntities(query.Include, query.Limit, before, after)
+
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Get all users failed", "error": err.Error() })
+		return
+	}
+
+	jsonValues := []*teamsModels.User{}
+
+	for _, e := range userEntities {
+		jsonValues = append(jsonValues, e.Json)
+	}
+
+	// Get paging
+	total, err := h.UserEntityService.GetCountUserEntities(query.Include)
+
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Get all users failed", "error": err.Error() })
+		return
+	}
+
+	paging.Total = total.Total
+
+	ctx.JSON(http.StatusOK, gin.H{ "message": "Get all users successfully", "data": jsonValues, "paging": paging })
+}
+
+func (h *UserEntityHandler) getUserEntityById(ctx *gin.Context) {
+	// Get param and validate
+	param := &teamsModels.GetUserEntityByIdParam{}
+
+	if err := ctx.ShouldBindUri(param); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid param", "error": err.Error() })
+		return
+	}
+
+	query := &baseModels.GetEntityQuery{}
+
+	if err := ctx.ShouldBindQuery(query); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid query", "error": err.Error() })
+		return
+	}
+
+	if query.Include == "" {
+		query.Include = "non-deleted"
+	}
+
+	if _, err := baseModels.ValidateInclude(query.Include); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid query", "error": err.Error() })
+		return
+	}
+
+	userEntity, err := h.UserEntityService.GetUserEntityById(param.ID, query.Include)
+
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{ "message": "User not found", "error": err.Error() })
+		return
+	}
+
+	ctx.JSON(http.StatusOK, userEntity.Json)
+}
+
+func (h *UserEntityHandler) getUserEntityByName(ctx *gin.Context) {
+	// Get param and validate
+	param := &teamsModels.GetUserEntityByNameParam{}
+
+	if err := ctx.ShouldBindUri(param); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid param", "error": err.Error() })
+		return
+	}
+
+	query := &baseModels.GetEntityQuery{}
+
+	if err := ctx.ShouldBindQuery(query); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid query", "error": err.Error() })
+		return
+	}
+
+	if query.Include == "" {
+		query.Include = "non-deleted"
+	}
+
+	if _, err := baseModels.ValidateInclude(query.Include); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid query", "error": err.Error() })
+		return
+	}
+
+	userEntity, err := h.UserEntityService.GetUserEntityByName(param.Name, query.Include)
+
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{ "message": "User not found", "error": err.Error() })
+		return
+	}
+
+	ctx.JSON(http.StatusOK, userEntity.Json)
+}
+
+func (h *UserEntityHandler) createUserEntity(ctx *gin.Context) {
+	if !middlewares.AuthorizeAdmin(ctx) {
+		return
+	}
+
+	// Get payload
+	payload := &teamsModels.CreateUserPayload{}
+
+	if err := ctx.ShouldBindJSON(payload); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid payload", "error": err.Error() })
+		return
+	}
+
+	if err := teamsModels.ValidateCreateUserPayload(payload); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid payload", "error": err.Error() })
+		return
+	}
+
+	// Create user entity
+	userEntity, err := h.UserEntityService.CreateUserEntity(payload, middlewares.GetUserName(ctx))
+
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Create user failed", "error": err.Error() })
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, userEntity.Json)
+}
+
+func (h *UserEntityHandler) deactivateUserEntityByName(ctx *gin.Context) {
+	if !middlewares.AuthorizeAdmin(ctx) {
+		return
+	}
+
+	// Get param and validate
+	param := &teamsModels.GetUserEntityByNameParam{}
+
+	if err := ctx.ShouldBindUri(param); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid param", "error": err.Error() })
+		return
+	}
+
+	exist, err := h.UserEntityService.GetUserEntityByName(param.Name, "non-deleted")
+
+	if errors.Is(err, sql.ErrNoRows) {
+		ctx.JSON(http.StatusNotFound, gin.H{ "message": "User not found", "error": err.Error() })
+		return
+	}
+
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{ "message": "Deactivate user failed", "error": err.Error() })
+		return
+	}
+
+	userEntity, err := h.UserEntityService.DeactivateUserEntity(exist, middlewares.GetUserName(ctx))
+
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Deactivate user failed", "error": err.Error() })
+		return
+	}
+
+	ctx.JSON(http.StatusOK, userEntity.Json)
+}
+
+func (h *UserEntityHandler) setUserRolesByName(ctx *gin.Context) {
+	if !middlewares.AuthorizeAdmin(ctx) {
+		return
+	}
+
+	// Get param and validate
+	param := &teamsModels.GetUserEntityByNameParam{}
+
+	if err := ctx.ShouldBindUri(param); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid param", "error": err.Error() })
+		return
+	}
+
+	// Get payload
+	payload := &teamsModels.SetUserRolesPayload{}
+
+	if err := ctx.ShouldBindJSON(payload); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid payload", "error": err.Error() })
+		return
+	}
+
+	exist, err := h.UserEntityService.GetUserEntityByName(param.Name, "non-deleted")
+
+	if errors.Is(err, sql.ErrNoRows) {
+		ctx.JSON(http.StatusNotFound, gin.H{ "message": "User not found", "error": err.Error() })
+		return
+	}
+
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{ "message": "Set user roles failed", "error": err.Error() })
+		return
+	}
+
+	userEntity, err := h.UserEntityService.SetUserRoles(exist, payload.Roles, middlewares.GetUserName(ctx))
+
+	if errors.Is(err, baseModels.ErrPreconditionFailed) {
+		ctx.JSON(http.StatusPreconditionFailed, gin.H{ "message": "Precondition failed", "error": err.Error() })
+		return
+	}
+
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Set user roles failed", "error": err.Error() })
+		return
+	}
+
+	ctx.JSON(http.StatusOK, userEntity.Json)
+}
+
+func (h *UserEntityHandler) getPersonalAccessTokens(ctx *gin.Context) {
+	principal := middlewares.GetPrincipal(ctx)
+
+	if principal == nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{ "message": "Get personal access tokens failed", "error": "missing principal" })
+		return
+	}
+
+	userTokenEntities, err := h.AuthService.GetUserTokens(principal.ID, teamsModels.PersonalAccessToken)
+
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Get personal access tokens failed", "error": err.Error() })
+		return
+	}
+
+	jsonValues := []*teamsModels.UserToken{}
+
+	for _, e := range userTokenEntities {
+		jsonValues = append(jsonValues, e.Json)
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{ "message": "Get personal access tokens successfully", "data": jsonValues })
+}
+
+func (h *UserEntityHandler) createPersonalAccessToken(ctx *gin.Context) {
+	principal := middlewares.GetPrincipal(ctx)
+
+	if principal == nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{ "message": "Create personal access token failed", "error": "missing principal" })
+		return
+	}
+
+	if principal.IsBot {
+		ctx.JSON(http.StatusForbidden, gin.H{ "message": "Create personal access token failed", "error": teamsModels.ErrBotPersonalAccessToken.Error() })
+		return
+	}
+
+	// Get payload
+	payload := &teamsModels.CreatePersonalAccessTokenPayload{}
+
+	if err := ctx.ShouldBindJSON(payload); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid payload", "error": err.Error() })
+		return
+	}
+
+	expiryDays, err := teamsModels.ValidateTokenExpiryDays(payload.ExpiryDays)
+
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid payload", "error": err.Error() })
+		return
+	}
+
+	userEntity, err := h.UserEntityService.GetUserEntityById(principal.ID, "non-deleted")
+
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{ "message": "User not found", "error": err.Error() })
+		return
+	}
+
+	userTokenResponse, err := h.AuthService.IssueUserToken(userEntity, teamsModels.PersonalAccessToken, payload.Name, expiryDays, principal.Name)
+
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Create personal access token failed", "error": err.Error() })
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, userTokenResponse)
+}
+
+func (h *UserEntityHandler) revokePersonalAccessToken(ctx *gin.Context) {
+	principal := middlewares.GetPrincipal(ctx)
+
+	if principal == nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{ "message": "Revoke personal access token failed", "error": "missing principal" })
+		return
+	}
+
+	// Get param and validate
+	param := &teamsModels.GetUserTokenParam{}
+
+	if err := ctx.ShouldBindUri(param); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Invalid param", "error": err.Error() })
+		return
+	}
+
+	err := h.AuthService.RevokeUserToken(principal.ID, teamsModels.PersonalAccessToken, param.ID)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		ctx.JSON(http.StatusNotFound, gin.H{ "message": "Personal access token not found", "error": err.Error() })
+		return
+	}
+
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{ "message": "Revoke personal access token failed", "error": err.Error() })
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{ "message": "Revoke personal access token successfully" })
+}
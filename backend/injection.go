@@ -1,22 +1,43 @@
 package main
 
 import (
+	"log"
 	"net/http"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	"github.com/nambuitechx/go-metadata/configs"
+	"github.com/nambuitechx/go-metadata/middlewares"
 	
 	systemHandlers "github.com/nambuitechx/go-metadata/handlers/system"
 	servicesHandlers "github.com/nambuitechx/go-metadata/handlers/services"
 	dataHandlers "github.com/nambuitechx/go-metadata/handlers/data"
 	automationsHandlers "github.com/nambuitechx/go-metadata/handlers/automations"
+	eventsHandlers "github.com/nambuitechx/go-metadata/handlers/events"
+	lineageHandlers "github.com/nambuitechx/go-metadata/handlers/lineage"
+	searchHandlers "github.com/nambuitechx/go-metadata/handlers/search"
+	teamsHandlers "github.com/nambuitechx/go-metadata/handlers/teams"
+	policiesHandlers "github.com/nambuitechx/go-metadata/handlers/policies"
 	servicesServices "github.com/nambuitechx/go-metadata/services/services"
 	dataServices "github.com/nambuitechx/go-metadata/services/data"
 	automationsServices "github.com/nambuitechx/go-metadata/services/automations"
+	eventsServices "github.com/nambuitechx/go-metadata/services/events"
+	lineageServices "github.com/nambuitechx/go-metadata/services/lineage"
+	searchServices "github.com/nambuitechx/go-metadata/services/search"
+	teamsServices "github.com/nambuitechx/go-metadata/services/teams"
+	policiesServices "github.com/nambuitechx/go-metadata/services/policies"
+	securityServices "github.com/nambuitechx/go-metadata/services/security"
+	searchModels "github.com/nambuitechx/go-metadata/models/search"
 	servicesRepositories "github.com/nambuitechx/go-metadata/repositories/services"
 	dataRepositories "github.com/nambuitechx/go-metadata/repositories/data"
 	automationsRepositories "github.com/nambuitechx/go-metadata/repositories/automations"
+	baseRepositories "github.com/nambuitechx/go-metadata/repositories/base"
+	typeRepositories "github.com/nambuitechx/go-metadata/repositories/type"
+	eventsRepositories "github.com/nambuitechx/go-metadata/repositories/events"
+	lineageRepositories "github.com/nambuitechx/go-metadata/repositories/lineage"
+	searchRepositories "github.com/nambuitechx/go-metadata/repositories/search"
+	teamsRepositories "github.com/nambuitechx/go-metadata/repositories/teams"
+	policiesRepositories "github.com/nambuitechx/go-metadata/repositories/policies"
 )
 
 func getEngine() *gin.Engine {
@@ -25,6 +46,7 @@ func getEngine() *gin.Engine {
 	db := configs.NewDatabaseConnection(settings).DB
 
 	// Repositories
+	transactor := baseRepositories.NewTransactor(db)
 	testConnectionDefinitionEntityRepository := servicesRepositories.NewTestConnectionDefinitionEntityRepository(db)
 	dbserviceEntityRepository := servicesRepositories.NewDBServiceEntityRepository(db)
 	databaseEntityRepository := dataRepositories.NewDatabaseEntityRepository(db)
@@ -32,15 +54,94 @@ func getEngine() *gin.Engine {
 	tableEntityRepository := dataRepositories.NewTableEntityRepository(db)
 	storedProcedureEntityRepository := dataRepositories.NewStoredProcedureEntityRepository(db)
 	workflowEntityRepository := automationsRepositories.NewWorkflowEntityRepository(db)
+	workflowRunRepository := automationsRepositories.NewWorkflowRunRepository(db)
+	entityExtensionRepository := typeRepositories.NewEntityExtensionRepository(db)
+	cascadeDeletionRepository := typeRepositories.NewCascadeDeletionRepository(db)
+	changeEventRepository := eventsRepositories.NewChangeEventRepository(db)
+	eventSubscriptionRepository := eventsRepositories.NewEventSubscriptionRepository(db)
+	lineageRepository := lineageRepositories.NewLineageRepository(db)
+	searchRepository := searchRepositories.NewSearchRepository(db)
+	userEntityRepository := teamsRepositories.NewUserEntityRepository(db)
+	userTokenRepository := teamsRepositories.NewUserTokenRepository(db)
+	botEntityRepository := teamsRepositories.NewBotEntityRepository(db)
+	roleEntityRepository := teamsRepositories.NewRoleEntityRepository(db)
+	policyEntityRepository := policiesRepositories.NewPolicyEntityRepository(db)
+	teamEntityRepository := teamsRepositories.NewTeamEntityRepository(db)
+	ownerRepository := teamsRepositories.NewOwnerRepository(db)
 
 	// Services
+	searchIndexer := searchServices.NewSearchIndexer(settings.SearchIndexer, searchRepository)
+	ownershipService := teamsServices.NewOwnershipService(userEntityRepository, teamEntityRepository, ownerRepository)
+	secretsManager, err := securityServices.NewSecretsManager(settings.SecretsManager, settings.SecretsKey, settings.SecretsFile)
+
+	if err != nil {
+		log.Fatalf("Failed to create the secrets manager: %v\n", err)
+	}
+
 	testConnectionDefinitionEntityService := servicesServices.NewTestConnectionDefinitionEntityService(testConnectionDefinitionEntityRepository)
-	dbserviceEntityService := servicesServices.NewDBServiceEntityService(dbserviceEntityRepository)
-	databaseEntityService := dataServices.NewDatabaseEntityService(dbserviceEntityRepository, databaseEntityRepository)
-	databaseSchemaEntityService := dataServices.NewDatabaseSchemaEntityService(dbserviceEntityRepository, databaseEntityRepository, databaseSchemaEntityRepository)
-	tableEntityService := dataServices.NewTableEntityService(dbserviceEntityRepository, databaseEntityRepository, databaseSchemaEntityRepository, tableEntityRepository)
-	storedProcedureEntityService := dataServices.NewStoredProcedureEntityService(dbserviceEntityRepository, databaseEntityRepository, databaseSchemaEntityRepository, storedProcedureEntityRepository)
-	workflowEntityService := automationsServices.NewWorkflowEntityService(workflowEntityRepository)
+	dbserviceEntityService := servicesServices.NewDBServiceEntityService(transactor, dbserviceEntityRepository, databaseEntityRepository, databaseSchemaEntityRepository, tableEntityRepository, storedProcedureEntityRepository, entityExtensionRepository, cascadeDeletionRepository, changeEventRepository, searchIndexer, ownershipService, secretsManager)
+	databaseEntityService := dataServices.NewDatabaseEntityService(transactor, dbserviceEntityRepository, databaseEntityRepository, databaseSchemaEntityRepository, tableEntityRepository, storedProcedureEntityRepository, entityExtensionRepository, cascadeDeletionRepository, changeEventRepository, searchIndexer, ownershipService)
+	databaseSchemaEntityService := dataServices.NewDatabaseSchemaEntityService(transactor, dbserviceEntityRepository, databaseEntityRepository, databaseSchemaEntityRepository, tableEntityRepository, storedProcedureEntityRepository, entityExtensionRepository, cascadeDeletionRepository, changeEventRepository, searchIndexer, ownershipService)
+	tableEntityService := dataServices.NewTableEntityService(transactor, dbserviceEntityRepository, databaseEntityRepository, databaseSchemaEntityRepository, tableEntityRepository, entityExtensionRepository, changeEventRepository, lineageRepository, searchIndexer, ownershipService)
+	storedProcedureEntityService := dataServices.NewStoredProcedureEntityService(transactor, dbserviceEntityRepository, databaseEntityRepository, databaseSchemaEntityRepository, storedProcedureEntityRepository, tableEntityRepository, entityExtensionRepository, changeEventRepository, lineageRepository, searchIndexer, ownershipService)
+	workflowEntityService := automationsServices.NewWorkflowEntityService(transactor, workflowEntityRepository, changeEventRepository, secretsManager)
+	testConnectionRunner := automationsServices.NewTestConnectionRunner(workflowEntityService, testConnectionDefinitionEntityRepository, dbserviceEntityService)
+	workflowExecutor := automationsServices.NewWorkflowExecutor(workflowRunRepository, workflowEntityService, testConnectionRunner, settings.WorkflowWorkers, settings.WorkflowTimeout, settings.WorkflowMaxAttempts)
+	changeEventNotifier := eventsServices.NewChangeEventNotifier(changeEventRepository)
+	changeEventService := eventsServices.NewChangeEventService(changeEventRepository, changeEventNotifier)
+	eventSubscriptionService := eventsServices.NewEventSubscriptionService(eventSubscriptionRepository, changeEventRepository, secretsManager)
+	lineageService := lineageServices.NewLineageService(lineageRepository, tableEntityRepository, storedProcedureEntityRepository, ownershipService)
+	searchService := searchServices.NewSearchService(searchIndexer, searchRepository)
+	userEntityService := teamsServices.NewUserEntityService(transactor, userEntityRepository, roleEntityRepository, changeEventRepository)
+	authService := teamsServices.NewAuthService(transactor, userEntityRepository, userTokenRepository, teamEntityRepository, settings.JWTSecret, settings.JWTExpiry)
+	botEntityService := teamsServices.NewBotEntityService(transactor, botEntityRepository, userEntityRepository, userTokenRepository, changeEventRepository, authService)
+	policyEntityService := policiesServices.NewPolicyEntityService(transactor, policyEntityRepository, changeEventRepository)
+	roleEntityService := teamsServices.NewRoleEntityService(transactor, roleEntityRepository, policyEntityRepository, changeEventRepository)
+	teamEntityService := teamsServices.NewTeamEntityService(transactor, teamEntityRepository, userEntityRepository, ownerRepository, changeEventRepository)
+	authorizer := policiesServices.NewAuthorizer(roleEntityRepository, policyEntityRepository, ownershipService)
+	permissionService := policiesServices.NewPermissionService(authorizer, dbserviceEntityRepository, testConnectionDefinitionEntityRepository, databaseEntityRepository, databaseSchemaEntityRepository, tableEntityRepository, storedProcedureEntityRepository, workflowEntityRepository)
+
+	// The default policies and roles, which every user has
+	if err := policyEntityService.EnsureDefaultPolicies(settings.AdminName); err != nil {
+		log.Printf("Failed to create the default policies: %v\n", err)
+	}
+
+	if err := roleEntityService.EnsureDefaultRoles(settings.AdminName); err != nil {
+		log.Printf("Failed to create the default roles: %v\n", err)
+	}
+
+	// The root of the teams
+	if err := teamEntityService.EnsureOrganization(settings.AdminName); err != nil {
+		log.Printf("Failed to create the organization: %v\n", err)
+	}
+
+	// The connections stored before the secrets manager was in place
+	if err := dbserviceEntityService.EncryptStoredSecrets(); err != nil {
+		log.Printf("Failed to encrypt the dbservice secrets: %v\n", err)
+	}
+
+	if err := workflowEntityService.EncryptStoredSecrets(); err != nil {
+		log.Printf("Failed to encrypt the workflow secrets: %v\n", err)
+	}
+
+	if err := eventSubscriptionService.EncryptStoredSecrets(); err != nil {
+		log.Printf("Failed to encrypt the event subscription secrets: %v\n", err)
+	}
+
+	// The first admin, to log in and create the other users
+	if err := userEntityService.EnsureAdminUser(settings.AdminName, settings.AdminEmail, settings.AdminPassword); err != nil {
+		log.Printf("Failed to create the admin user: %v\n", err)
+	}
+
+	// Background workers
+	eventsServices.NewEventSubscriptionDispatcher(eventSubscriptionRepository, changeEventRepository, secretsManager).Start()
+	changeEventNotifier.Start()
+	workflowExecutor.Start()
+
+	// The in-memory index starts empty
+	if settings.SearchIndexer == searchModels.MemorySearchIndexer {
+		searchService.StartReindex(searchModels.DefaultSearchIndexes, searchModels.DefaultReindexBatchSize)
+	}
 
 	// Engine
 	engine := gin.Default()
@@ -48,27 +149,46 @@ func getEngine() *gin.Engine {
 	// Middlewares
 	config := cors.DefaultConfig()
     config.AllowAllOrigins = true
-    config.AllowMethods = []string{"POST", "GET", "PUT", "OPTIONS"}
-    config.AllowHeaders = []string{"Origin", "Content-Type", "Authorization", "Accept", "User-Agent", "Cache-Control", "Pragma"}
-    config.ExposeHeaders = []string{"Content-Length"}
+    config.AllowMethods = []string{"POST", "GET", "PUT", "PATCH", "DELETE", "OPTIONS"}
+    config.AllowHeaders = []string{"Origin", "Content-Type", "Authorization", "Accept", "User-Agent", "Cache-Control", "Pragma", "If-Match"}
+    config.ExposeHeaders = []string{"Content-Length", "ETag"}
     config.AllowCredentials = true
 
 	engine.Use(cors.New(config))
+	engine.Use(middlewares.NewAuthenticationMiddleware(authService, publicPaths))
 
 	// Routes
 	engine.GET("/health", checkHealth)
 	systemHandlers.InitDBServiceEntityHandler(engine, settings)
-	servicesHandlers.InitTestConnectionDefinitionEntityHandler(engine, testConnectionDefinitionEntityService)
-	servicesHandlers.InitDBServiceEntityHandler(engine, dbserviceEntityService)
-	dataHandlers.InitDatabaseEntityHandler(engine, databaseEntityService)
-	dataHandlers.InitDatabaseSchemaEntityHandler(engine, databaseSchemaEntityService)
-	dataHandlers.InitTableEntityHandler(engine, tableEntityService)
-	dataHandlers.InitStoreProcedureEntityHandler(engine, storedProcedureEntityService)
-	automationsHandlers.InitWorkflowEntityHandler(engine, workflowEntityService)
+	servicesHandlers.InitTestConnectionDefinitionEntityHandler(engine, testConnectionDefinitionEntityService, authorizer)
+	servicesHandlers.InitDBServiceEntityHandler(engine, dbserviceEntityService, authorizer)
+	dataHandlers.InitDatabaseEntityHandler(engine, databaseEntityService, authorizer)
+	dataHandlers.InitDatabaseSchemaEntityHandler(engine, databaseSchemaEntityService, authorizer)
+	dataHandlers.InitTableEntityHandler(engine, tableEntityService, authorizer)
+	dataHandlers.InitStoreProcedureEntityHandler(engine, storedProcedureEntityService, authorizer)
+	automationsHandlers.InitWorkflowEntityHandler(engine, workflowEntityService, workflowExecutor, authorizer)
+	eventsHandlers.InitChangeEventHandler(engine, changeEventService, authorizer)
+	eventsHandlers.InitEventSubscriptionHandler(engine, eventSubscriptionService)
+	lineageHandlers.InitLineageHandler(engine, lineageService, authorizer)
+	searchHandlers.InitSearchHandler(engine, searchService, authorizer)
+	teamsHandlers.InitUserEntityHandler(engine, userEntityService, authService)
+	teamsHandlers.InitBotEntityHandler(engine, botEntityService)
+	teamsHandlers.InitRoleEntityHandler(engine, roleEntityService)
+	teamsHandlers.InitTeamEntityHandler(engine, teamEntityService)
+	policiesHandlers.InitPolicyEntityHandler(engine, policyEntityService)
+	policiesHandlers.InitPermissionHandler(engine, permissionService)
 
 	return engine
 }
 
+// Routes reachable without a token
+var publicPaths = []string{
+	"/health",
+	"/api/v1/system/health",
+	"/api/v1/system/version",
+	"/api/v1/users/login",
+}
+
 func checkHealth(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, gin.H { "message": "Healthy" })
 }
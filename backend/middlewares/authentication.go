@@ -0,0 +1,74 @@
+package middlewares
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	eventsModels "github.com/nambuitechx/go-metadata/models/events"
+	teamsModels "github.com/nambuitechx/go-metadata/models/teams"
+	teamsServices "github.com/nambuitechx/go-metadata/services/teams"
+)
+
+// NewAuthenticationMiddleware rejects requests without a valid bearer token, except for the public paths, and puts
+// the authenticated principal into the context
+func NewAuthenticationMiddleware(authService *teamsServices.AuthService, publicPaths []string) gin.HandlerFunc {
+	public := map[string]bool{}
+
+	for _, path := range publicPaths {
+		public[path] = true
+	}
+
+	return func(ctx *gin.Context) {
+		if public[ctx.FullPath()] || ctx.Request.Method == http.MethodOptions {
+			ctx.Next()
+			return
+		}
+
+		token, ok := strings.CutPrefix(ctx.GetHeader("Authorization"), teamsModels.BearerTokenType + " ")
+
+		if !ok || token == "" {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{ "message": "Unauthorized", "error": "missing bearer token" })
+			return
+		}
+
+		principal, err := authService.Authenticate(token)
+
+		if errors.Is(err, teamsModels.ErrInvalidToken) || errors.Is(err, teamsModels.ErrUserDeactivated) {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{ "message": "Unauthorized", "error": err.Error() })
+			return
+		}
+
+		if err != nil {
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{ "message": "Authentication failed", "error": err.Error() })
+			return
+		}
+
+		ctx.Set(teamsModels.PrincipalKey, principal)
+		ctx.Next()
+	}
+}
+
+// GetPrincipal returns the principal of an authenticated request, nil on public paths
+func GetPrincipal(ctx *gin.Context) *teamsModels.Principal {
+	value, ok := ctx.Get(teamsModels.PrincipalKey)
+
+	if !ok {
+		return nil
+	}
+
+	principal, _ := value.(*teamsModels.Principal)
+	return principal
+}
+
+// GetUserName returns the name recorded as updatedBy for the changes made by a request
+func GetUserName(ctx *gin.Context) string {
+	principal := GetPrincipal(ctx)
+
+	if principal == nil {
+		return eventsModels.DefaultUserName
+	}
+
+	return principal.Name
+}
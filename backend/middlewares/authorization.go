@@ -0,0 +1,39 @@
+package middlewares
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	policiesModels "github.com/nambuitechx/go-metadata/models/policies"
+	policiesServices "github.com/nambuitechx/go-metadata/services/policies"
+)
+
+// Authorize checks that the principal of the request may do the operations on the resource. Otherwise it writes
+// the error response and returns false
+func Authorize(ctx *gin.Context, authorizer *policiesServices.Authorizer, resourceContext *policiesModels.ResourceContext, operations ...string) bool {
+	err := authorizer.Authorize(GetPrincipal(ctx), resourceContext, operations...)
+
+	if errors.Is(err, policiesModels.ErrPermissionDenied) {
+		ctx.JSON(http.StatusForbidden, gin.H{ "message": "Forbidden", "error": err.Error() })
+		return false
+	}
+
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{ "message": "Authorization failed", "error": err.Error() })
+		return false
+	}
+
+	return true
+}
+
+// AuthorizeAdmin checks that the principal of the request is an admin, for the operations outside of the policies.
+// Otherwise it writes the error response and returns false
+func AuthorizeAdmin(ctx *gin.Context) bool {
+	if principal := GetPrincipal(ctx); principal == nil || !principal.IsAdmin {
+		ctx.JSON(http.StatusForbidden, gin.H{ "message": "Forbidden", "error": policiesModels.ErrAdminOnly.Error() })
+		return false
+	}
+
+	return true
+}
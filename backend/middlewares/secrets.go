@@ -0,0 +1,40 @@
+package middlewares
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	policiesModels "github.com/nambuitechx/go-metadata/models/policies"
+	securityModels "github.com/nambuitechx/go-metadata/models/security"
+	policiesServices "github.com/nambuitechx/go-metadata/services/policies"
+)
+
+// AllowSecrets tells whether the request asked for the plain secrets with includeSecrets=true and is made by a bot
+// allowed the ViewSecrets operation on the resource
+func AllowSecrets(ctx *gin.Context, authorizer *policiesServices.Authorizer, resourceContext *policiesModels.ResourceContext) bool {
+	query := &securityModels.SecretsQuery{}
+
+	if err := ctx.ShouldBindQuery(query); err != nil || !query.IncludeSecrets {
+		return false
+	}
+
+	principal := GetPrincipal(ctx)
+
+	if principal == nil || !principal.IsBot {
+		return false
+	}
+
+	return authorizer.Authorize(principal, resourceContext, policiesModels.ViewSecretsOperation) == nil
+}
+
+// JSONMasked writes the value with its secrets replaced by the mask
+func JSONMasked(ctx *gin.Context, code int, value interface{}) {
+	masked, err := securityModels.MaskSecrets(value)
+
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{ "message": "Mask secrets failed", "error": err.Error() })
+		return
+	}
+
+	ctx.JSON(code, masked)
+}
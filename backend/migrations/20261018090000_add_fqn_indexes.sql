@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+CREATE INDEX IF NOT EXISTS automations_workflow_fqn_id_index ON automations_workflow ((json->>'fullyQualifiedName'), id);
+CREATE INDEX IF NOT EXISTS database_entity_fqn_id_index ON database_entity ((json->>'fullyQualifiedName'), id);
+CREATE INDEX IF NOT EXISTS database_schema_entity_fqn_id_index ON database_schema_entity ((json->>'fullyQualifiedName'), id);
+CREATE INDEX IF NOT EXISTS dbservice_entity_fqn_id_index ON dbservice_entity ((json->>'fullyQualifiedName'), id);
+CREATE INDEX IF NOT EXISTS stored_procedure_entity_fqn_id_index ON stored_procedure_entity ((json->>'fullyQualifiedName'), id);
+CREATE INDEX IF NOT EXISTS table_entity_fqn_id_index ON table_entity ((json->>'fullyQualifiedName'), id);
+CREATE INDEX IF NOT EXISTS test_connection_definition_fqn_id_index ON test_connection_definition ((json->>'fullyQualifiedName'), id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+DROP INDEX IF EXISTS automations_workflow_fqn_id_index;
+DROP INDEX IF EXISTS database_entity_fqn_id_index;
+DROP INDEX IF EXISTS database_schema_entity_fqn_id_index;
+DROP INDEX IF EXISTS dbservice_entity_fqn_id_index;
+DROP INDEX IF EXISTS stored_procedure_entity_fqn_id_index;
+DROP INDEX IF EXISTS table_entity_fqn_id_index;
+DROP INDEX IF EXISTS test_connection_definition_fqn_id_index;
+-- +goose StatementEnd
@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+CREATE TABLE IF NOT EXISTS entity_extension(
+    id VARCHAR(36) NOT NULL,
+    extension VARCHAR(256) NOT NULL,
+    jsonschema VARCHAR(256) NOT NULL,
+    json JSONB NOT NULL,
+    PRIMARY KEY (id, extension)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+DROP TABLE IF EXISTS entity_extension;
+-- +goose StatementEnd
@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+CREATE SEQUENCE IF NOT EXISTS change_event_offset_seq OWNED BY change_event."offset";
+SELECT setval('change_event_offset_seq', COALESCE((SELECT MAX("offset") FROM change_event), 0) + 1, false);
+ALTER TABLE change_event ALTER COLUMN "offset" SET DEFAULT nextval('change_event_offset_seq');
+CREATE INDEX IF NOT EXISTS change_event_eventtime_index ON change_event (eventtime);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+DROP INDEX IF EXISTS change_event_eventtime_index;
+ALTER TABLE change_event ALTER COLUMN "offset" DROP DEFAULT;
+DROP SEQUENCE IF EXISTS change_event_offset_seq;
+-- +goose StatementEnd
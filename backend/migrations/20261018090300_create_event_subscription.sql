@@ -0,0 +1,29 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+CREATE TABLE IF NOT EXISTS event_subscription_entity(
+    id VARCHAR(36) PRIMARY KEY,
+    name VARCHAR(256) UNIQUE NOT NULL,
+    json JSONB NOT NULL,
+    updatedat BIGINT NOT NULL,
+    updatedby VARCHAR(256),
+    deleted BOOLEAN NOT NULL,
+    namehash VARCHAR(256)
+);
+CREATE INDEX IF NOT EXISTS event_subscription_entity_fqn_id_index ON event_subscription_entity ((json->>'fullyQualifiedName'), id);
+CREATE TABLE IF NOT EXISTS event_subscription_dead_letter(
+    id VARCHAR(36) PRIMARY KEY,
+    subscriptionid VARCHAR(36) NOT NULL,
+    "offset" BIGINT NOT NULL,
+    failedat BIGINT NOT NULL,
+    json JSONB NOT NULL
+);
+CREATE INDEX IF NOT EXISTS event_subscription_dead_letter_subscription_index ON event_subscription_dead_letter (subscriptionid, failedat);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+DROP TABLE IF EXISTS event_subscription_dead_letter;
+DROP TABLE IF EXISTS event_subscription_entity;
+-- +goose StatementEnd
@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+CREATE INDEX IF NOT EXISTS entity_relationship_to_index ON entity_relationship (toid, relation);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+DROP INDEX IF EXISTS entity_relationship_to_index;
+-- +goose StatementEnd
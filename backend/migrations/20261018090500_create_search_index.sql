@@ -0,0 +1,100 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+CREATE TABLE IF NOT EXISTS search_index(
+    entitytype VARCHAR(64) NOT NULL,
+    id VARCHAR(36) NOT NULL,
+    searchvector TSVECTOR NOT NULL,
+    PRIMARY KEY (entitytype, id)
+);
+CREATE INDEX IF NOT EXISTS search_index_searchvector_index ON search_index USING GIN (searchvector);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+-- Names weigh the most, then descriptions, column names and column descriptions
+CREATE OR REPLACE FUNCTION entity_search_vector(entity JSONB) RETURNS TSVECTOR AS $$
+    SELECT
+        setweight(to_tsvector('english', COALESCE(entity->>'name', '') || ' ' || COALESCE(entity->>'displayName', '')), 'A') ||
+        setweight(to_tsvector('english', COALESCE(entity->>'description', '')), 'B') ||
+        setweight(to_tsvector('english', jsonb_path_query_array(entity, '$.columns[*].name')), 'C') ||
+        setweight(to_tsvector('english', jsonb_path_query_array(entity, '$.columns[*].description')), 'D')
+$$ LANGUAGE SQL IMMUTABLE;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+-- Fragments of the searched fields matching the query, keyed by field
+CREATE OR REPLACE FUNCTION entity_search_highlights(entity JSONB, query TSQUERY) RETURNS JSONB AS $$
+    SELECT COALESCE(jsonb_object_agg(field, fragments), '{}'::jsonb) FROM (
+        SELECT field, jsonb_agg(fragment) AS fragments FROM (
+            SELECT f.field, ts_headline('english', f.value, query, 'StartSel=<em>, StopSel=</em>') AS fragment FROM (
+                SELECT 'name' AS field, entity->>'name' AS value
+                UNION ALL
+                SELECT 'displayName', entity->>'displayName'
+                UNION ALL
+                SELECT 'description', entity->>'description'
+                UNION ALL
+                SELECT 'columns.name', c->>'name' FROM jsonb_array_elements(COALESCE(entity->'columns', '[]'::jsonb)) c
+                UNION ALL
+                SELECT 'columns.description', c->>'description' FROM jsonb_array_elements(COALESCE(entity->'columns', '[]'::jsonb)) c
+            ) f
+            WHERE COALESCE(f.value, '') <> ''
+        ) h
+        WHERE position('<em>' IN fragment) > 0
+        GROUP BY field
+    ) g
+$$ LANGUAGE SQL STABLE;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION update_search_index() RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        DELETE FROM search_index WHERE entitytype = TG_ARGV[0] AND id = OLD.id;
+        RETURN OLD;
+    END IF;
+
+    INSERT INTO search_index(entitytype, id, searchvector) VALUES (TG_ARGV[0], NEW.id, entity_search_vector(NEW.json))
+    ON CONFLICT (entitytype, id) DO UPDATE SET searchvector = EXCLUDED.searchvector;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER dbservice_entity_search_index AFTER INSERT OR UPDATE OF json OR DELETE ON dbservice_entity
+    FOR EACH ROW EXECUTE FUNCTION update_search_index('databaseService');
+CREATE TRIGGER database_entity_search_index AFTER INSERT OR UPDATE OF json OR DELETE ON database_entity
+    FOR EACH ROW EXECUTE FUNCTION update_search_index('database');
+CREATE TRIGGER database_schema_entity_search_index AFTER INSERT OR UPDATE OF json OR DELETE ON database_schema_entity
+    FOR EACH ROW EXECUTE FUNCTION update_search_index('databaseSchema');
+CREATE TRIGGER table_entity_search_index AFTER INSERT OR UPDATE OF json OR DELETE ON table_entity
+    FOR EACH ROW EXECUTE FUNCTION update_search_index('table');
+CREATE TRIGGER stored_procedure_entity_search_index AFTER INSERT OR UPDATE OF json OR DELETE ON stored_procedure_entity
+    FOR EACH ROW EXECUTE FUNCTION update_search_index('storedProcedure');
+
+INSERT INTO search_index(entitytype, id, searchvector)
+SELECT 'databaseService', id, entity_search_vector(json) FROM dbservice_entity
+UNION ALL
+SELECT 'database', id, entity_search_vector(json) FROM database_entity
+UNION ALL
+SELECT 'databaseSchema', id, entity_search_vector(json) FROM database_schema_entity
+UNION ALL
+SELECT 'table', id, entity_search_vector(json) FROM table_entity
+UNION ALL
+SELECT 'storedProcedure', id, entity_search_vector(json) FROM stored_procedure_entity
+ON CONFLICT (entitytype, id) DO NOTHING;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+DROP TRIGGER IF EXISTS dbservice_entity_search_index ON dbservice_entity;
+DROP TRIGGER IF EXISTS database_entity_search_index ON database_entity;
+DROP TRIGGER IF EXISTS database_schema_entity_search_index ON database_schema_entity;
+DROP TRIGGER IF EXISTS table_entity_search_index ON table_entity;
+DROP TRIGGER IF EXISTS stored_procedure_entity_search_index ON stored_procedure_entity;
+DROP FUNCTION IF EXISTS update_search_index();
+DROP FUNCTION IF EXISTS entity_search_highlights(JSONB, TSQUERY);
+DROP FUNCTION IF EXISTS entity_search_vector(JSONB);
+DROP TABLE IF EXISTS search_index;
+-- +goose StatementEnd
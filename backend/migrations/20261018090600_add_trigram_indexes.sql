@@ -0,0 +1,30 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+CREATE INDEX IF NOT EXISTS dbservice_entity_name_trgm_index ON dbservice_entity USING GIN (lower(json->>'name') gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS dbservice_entity_fqn_trgm_index ON dbservice_entity USING GIN (lower(json->>'fullyQualifiedName') gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS database_entity_name_trgm_index ON database_entity USING GIN (lower(json->>'name') gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS database_entity_fqn_trgm_index ON database_entity USING GIN (lower(json->>'fullyQualifiedName') gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS database_schema_entity_name_trgm_index ON database_schema_entity USING GIN (lower(json->>'name') gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS database_schema_entity_fqn_trgm_index ON database_schema_entity USING GIN (lower(json->>'fullyQualifiedName') gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS table_entity_name_trgm_index ON table_entity USING GIN (lower(json->>'name') gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS table_entity_fqn_trgm_index ON table_entity USING GIN (lower(json->>'fullyQualifiedName') gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS stored_procedure_entity_name_trgm_index ON stored_procedure_entity USING GIN (lower(json->>'name') gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS stored_procedure_entity_fqn_trgm_index ON stored_procedure_entity USING GIN (lower(json->>'fullyQualifiedName') gin_trgm_ops);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+DROP INDEX IF EXISTS dbservice_entity_name_trgm_index;
+DROP INDEX IF EXISTS dbservice_entity_fqn_trgm_index;
+DROP INDEX IF EXISTS database_entity_name_trgm_index;
+DROP INDEX IF EXISTS database_entity_fqn_trgm_index;
+DROP INDEX IF EXISTS database_schema_entity_name_trgm_index;
+DROP INDEX IF EXISTS database_schema_entity_fqn_trgm_index;
+DROP INDEX IF EXISTS table_entity_name_trgm_index;
+DROP INDEX IF EXISTS table_entity_fqn_trgm_index;
+DROP INDEX IF EXISTS stored_procedure_entity_name_trgm_index;
+DROP INDEX IF EXISTS stored_procedure_entity_fqn_trgm_index;
+-- +goose StatementEnd
@@ -0,0 +1,52 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+DROP TRIGGER IF EXISTS dbservice_entity_search_index ON dbservice_entity;
+DROP TRIGGER IF EXISTS database_entity_search_index ON database_entity;
+DROP TRIGGER IF EXISTS database_schema_entity_search_index ON database_schema_entity;
+DROP TRIGGER IF EXISTS table_entity_search_index ON table_entity;
+DROP TRIGGER IF EXISTS stored_procedure_entity_search_index ON stored_procedure_entity;
+DROP FUNCTION IF EXISTS update_search_index();
+ALTER TABLE search_index ADD COLUMN IF NOT EXISTS fullyqualifiedname TEXT NOT NULL DEFAULT '';
+UPDATE search_index s SET fullyqualifiedname = e.json->>'fullyQualifiedName' FROM dbservice_entity e WHERE s.entitytype = 'databaseService' AND s.id = e.id;
+UPDATE search_index s SET fullyqualifiedname = e.json->>'fullyQualifiedName' FROM database_entity e WHERE s.entitytype = 'database' AND s.id = e.id;
+UPDATE search_index s SET fullyqualifiedname = e.json->>'fullyQualifiedName' FROM database_schema_entity e WHERE s.entitytype = 'databaseSchema' AND s.id = e.id;
+UPDATE search_index s SET fullyqualifiedname = e.json->>'fullyQualifiedName' FROM table_entity e WHERE s.entitytype = 'table' AND s.id = e.id;
+UPDATE search_index s SET fullyqualifiedname = e.json->>'fullyQualifiedName' FROM stored_procedure_entity e WHERE s.entitytype = 'storedProcedure' AND s.id = e.id;
+CREATE INDEX IF NOT EXISTS search_index_fqn_index ON search_index (fullyqualifiedname text_pattern_ops);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+DROP INDEX IF EXISTS search_index_fqn_index;
+ALTER TABLE search_index DROP COLUMN IF EXISTS fullyqualifiedname;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION update_search_index() RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        DELETE FROM search_index WHERE entitytype = TG_ARGV[0] AND id = OLD.id;
+        RETURN OLD;
+    END IF;
+
+    INSERT INTO search_index(entitytype, id, searchvector) VALUES (TG_ARGV[0], NEW.id, entity_search_vector(NEW.json))
+    ON CONFLICT (entitytype, id) DO UPDATE SET searchvector = EXCLUDED.searchvector;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER dbservice_entity_search_index AFTER INSERT OR UPDATE OF json OR DELETE ON dbservice_entity
+    FOR EACH ROW EXECUTE FUNCTION update_search_index('databaseService');
+CREATE TRIGGER database_entity_search_index AFTER INSERT OR UPDATE OF json OR DELETE ON database_entity
+    FOR EACH ROW EXECUTE FUNCTION update_search_index('database');
+CREATE TRIGGER database_schema_entity_search_index AFTER INSERT OR UPDATE OF json OR DELETE ON database_schema_entity
+    FOR EACH ROW EXECUTE FUNCTION update_search_index('databaseSchema');
+CREATE TRIGGER table_entity_search_index AFTER INSERT OR UPDATE OF json OR DELETE ON table_entity
+    FOR EACH ROW EXECUTE FUNCTION update_search_index('table');
+CREATE TRIGGER stored_procedure_entity_search_index AFTER INSERT OR UPDATE OF json OR DELETE ON stored_procedure_entity
+    FOR EACH ROW EXECUTE FUNCTION update_search_index('storedProcedure');
+-- +goose StatementEnd
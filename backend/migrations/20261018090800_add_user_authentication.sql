@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+ALTER TABLE user_entity ADD COLUMN IF NOT EXISTS passwordhash VARCHAR(256) NOT NULL DEFAULT '';
+UPDATE user_entity SET deactivated = 'false' WHERE deactivated IS NULL;
+UPDATE user_entity SET updatedby = '' WHERE updatedby IS NULL;
+UPDATE user_entity SET namehash = '' WHERE namehash IS NULL;
+CREATE INDEX IF NOT EXISTS user_entity_fqn_id_index ON user_entity ((json->>'fullyQualifiedName'), id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+DROP INDEX IF EXISTS user_entity_fqn_id_index;
+ALTER TABLE user_entity DROP COLUMN IF EXISTS passwordhash;
+-- +goose StatementEnd
@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+CREATE TABLE IF NOT EXISTS user_token(
+    id VARCHAR(36) PRIMARY KEY,
+    userid VARCHAR(36) NOT NULL,
+    tokentype VARCHAR(32) NOT NULL,
+    expiresat BIGINT NOT NULL,
+    json JSONB NOT NULL
+);
+CREATE INDEX IF NOT EXISTS user_token_userid_index ON user_token (userid, tokentype);
+CREATE INDEX IF NOT EXISTS bot_entity_fqn_id_index ON bot_entity ((json->>'fullyQualifiedName'), id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+DROP INDEX IF EXISTS bot_entity_fqn_id_index;
+DROP TABLE IF EXISTS user_token;
+-- +goose StatementEnd
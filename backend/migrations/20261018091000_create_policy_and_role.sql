@@ -0,0 +1,31 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+CREATE TABLE IF NOT EXISTS policy_entity(
+    id VARCHAR(36) PRIMARY KEY,
+    name VARCHAR(256) UNIQUE NOT NULL,
+    json JSONB NOT NULL,
+    updatedat BIGINT NOT NULL,
+    updatedby VARCHAR(256),
+    deleted BOOLEAN NOT NULL,
+    namehash VARCHAR(256)
+);
+CREATE TABLE IF NOT EXISTS role_entity(
+    id VARCHAR(36) PRIMARY KEY,
+    name VARCHAR(256) UNIQUE NOT NULL,
+    json JSONB NOT NULL,
+    updatedat BIGINT NOT NULL,
+    updatedby VARCHAR(256),
+    deleted BOOLEAN NOT NULL,
+    namehash VARCHAR(256)
+);
+CREATE INDEX IF NOT EXISTS policy_entity_fqn_id_index ON policy_entity ((json->>'fullyQualifiedName'), id);
+CREATE INDEX IF NOT EXISTS role_entity_fqn_id_index ON role_entity ((json->>'fullyQualifiedName'), id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+DROP TABLE IF EXISTS role_entity;
+DROP TABLE IF EXISTS policy_entity;
+-- +goose StatementEnd
@@ -0,0 +1,29 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+CREATE TABLE IF NOT EXISTS workflow_run(
+    id VARCHAR(36) PRIMARY KEY,
+    workflowid VARCHAR(36) NOT NULL,
+    status VARCHAR(16) NOT NULL,
+    attempt INT NOT NULL,
+    maxattempts INT NOT NULL,
+    timeout BIGINT NOT NULL,
+    runafter BIGINT NOT NULL,
+    startedat BIGINT NOT NULL DEFAULT 0,
+    heartbeatat BIGINT NOT NULL DEFAULT 0,
+    finishedat BIGINT NOT NULL DEFAULT 0,
+    cancelrequested BOOLEAN NOT NULL DEFAULT FALSE,
+    error TEXT NOT NULL DEFAULT '',
+    createdat BIGINT NOT NULL,
+    createdby VARCHAR(256)
+);
+CREATE INDEX IF NOT EXISTS workflow_run_queue_index ON workflow_run (status, runafter);
+CREATE INDEX IF NOT EXISTS workflow_run_workflowid_index ON workflow_run (workflowid, createdat);
+CREATE UNIQUE INDEX IF NOT EXISTS workflow_run_active_index ON workflow_run (workflowid) WHERE status IN ('Queued', 'Running');
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+DROP TABLE IF EXISTS workflow_run;
+-- +goose StatementEnd
@@ -0,0 +1,22 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+ALTER TABLE change_event DROP CONSTRAINT IF EXISTS change_event_pkey;
+ALTER TABLE change_event ADD COLUMN IF NOT EXISTS id BIGSERIAL PRIMARY KEY;
+ALTER TABLE change_event ALTER COLUMN "offset" DROP DEFAULT;
+ALTER TABLE change_event ALTER COLUMN "offset" DROP NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS change_event_offset_index ON change_event ("offset");
+CREATE INDEX IF NOT EXISTS change_event_unpublished_index ON change_event (id) WHERE "offset" IS NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+DROP INDEX IF EXISTS change_event_unpublished_index;
+DROP INDEX IF EXISTS change_event_offset_index;
+UPDATE change_event SET "offset" = nextval('change_event_offset_seq') WHERE "offset" IS NULL;
+ALTER TABLE change_event DROP CONSTRAINT IF EXISTS change_event_pkey;
+ALTER TABLE change_event DROP COLUMN IF EXISTS id;
+ALTER TABLE change_event ALTER COLUMN "offset" SET DEFAULT nextval('change_event_offset_seq');
+ALTER TABLE change_event ADD PRIMARY KEY ("offset");
+-- +goose StatementEnd
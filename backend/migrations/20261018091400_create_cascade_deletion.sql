@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+CREATE TABLE IF NOT EXISTS cascade_deletion(
+    id VARCHAR(36) PRIMARY KEY,
+    parentid VARCHAR(36) NOT NULL,
+    version FLOAT8 NOT NULL
+);
+CREATE INDEX IF NOT EXISTS cascade_deletion_parentid_index ON cascade_deletion (parentid);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+DROP TABLE IF EXISTS cascade_deletion;
+-- +goose StatementEnd
@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+CREATE INDEX IF NOT EXISTS table_entity_fqn_lower_index ON table_entity (lower(json->>'fullyQualifiedName'));
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+DROP INDEX IF EXISTS table_entity_fqn_lower_index;
+-- +goose StatementEnd
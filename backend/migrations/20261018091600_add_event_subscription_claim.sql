@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+ALTER TABLE event_subscription_entity ADD COLUMN IF NOT EXISTS claimid VARCHAR(36) NOT NULL DEFAULT '';
+ALTER TABLE event_subscription_entity ADD COLUMN IF NOT EXISTS heartbeatat BIGINT NOT NULL DEFAULT 0;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+ALTER TABLE event_subscription_entity DROP COLUMN IF EXISTS heartbeatat;
+ALTER TABLE event_subscription_entity DROP COLUMN IF EXISTS claimid;
+-- +goose StatementEnd
@@ -6,6 +6,7 @@ import (
 	"errors"
 
 	servicesModels "github.com/nambuitechx/go-metadata/models/services"
+	typeModels "github.com/nambuitechx/go-metadata/models/type"
 )
 
 // Workflow entity
@@ -36,6 +37,8 @@ type Workflow struct {
 	Request					*TestServiceConnection					`json:"request"`
 	Response				*servicesModels.TestConnectionResult	`json:"response"`
 
+	ChangeDescription		*typeModels.ChangeDescription			`json:"changeDescription,omitempty"`
+
 	Deleted					bool									`json:"deleted"`
 }
 
@@ -53,9 +56,45 @@ func (s *Workflow) Scan(value interface{}) error {
 	return json.Unmarshal(val, &s)
 }
 
+func (s *Workflow) ToEntityReference() *typeModels.EntityReference {
+	entityRef := &typeModels.EntityReference{
+		ID: s.ID,
+		Type: "workflow",
+		Name: s.Name,
+		FullyQualifiedName: s.FullyQualifiedName,
+		DisplayName: s.DisplayName,
+		Description: s.Description,
+		Deleted: s.Deleted,
+	}
+
+	return entityRef
+}
+
 // Type and status
 var WorkflowType = map[string]int {"TEST_CONNECTION": 0}
-var WorkflowStatus = map[string]int {"Pending": 0, "Successful": 1, "Failed": 2, "Running": 3}
+var WorkflowStatus = map[string]int {"Pending": 0, "Successful": 1, "Failed": 2, "Running": 3, "Cancelled": 4}
+
+const (
+	TestConnectionWorkflow = "TEST_CONNECTION"
+	PendingStatus = "Pending"
+	RunningStatus = "Running"
+	SuccessfulStatus = "Successful"
+	FailedStatus = "Failed"
+	CancelledStatus = "Cancelled"
+)
+
+// Test connection definitions by connection type, the steps of Postgres.testConnectionDefinition test Postgres
+var TestConnectionDefinitionNames = map[string]string {
+	"Postgres": "Postgres",
+	"MySQL": "Mysql",
+	"Mysql": "Mysql",
+}
+
+var (
+	ErrWorkflowRunning = errors.New("workflow is already running")
+	ErrNotTestConnection = errors.New("workflow is not a test connection")
+	ErrUnsupportedConnection = errors.New("unsupported connection type")
+)
 
 // Test service connection request
 type TestServiceConnection struct {
@@ -69,7 +108,9 @@ type TestServiceConnection struct {
 // APIs
 type GetWorkflowEntitiesQuery struct {
 	Limit int	`form:"limit"`
-	Offset int	`form:"offset"`
+	Before string	`form:"before"`
+	After string	`form:"after"`
+	Include string	`form:"include"`
 }
 
 type GetWorkflowEntityByIdParam struct {
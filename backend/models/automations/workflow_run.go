@@ -0,0 +1,46 @@
+package models
+
+import "errors"
+
+// Workflow run, a trigger of a workflow waiting in the queue or claimed by a worker. A failed attempt is queued again
+// after runAfter until maxAttempts is reached
+type WorkflowRunEntity struct {
+	ID					string		`db:"id" json:"id"`
+	WorkflowID			string		`db:"workflowid" json:"workflowId"`
+	Status				string		`db:"status" json:"status"`
+	Attempt				int			`db:"attempt" json:"attempt"`
+	MaxAttempts			int			`db:"maxattempts" json:"maxAttempts"`
+	Timeout				int64		`db:"timeout" json:"timeout"`
+	RunAfter			int64		`db:"runafter" json:"runAfter"`
+	StartedAt			int64		`db:"startedat" json:"startedAt"`
+	HeartbeatAt			int64		`db:"heartbeatat" json:"heartbeatAt"`
+	FinishedAt			int64		`db:"finishedat" json:"finishedAt"`
+	CancelRequested		bool		`db:"cancelrequested" json:"cancelRequested"`
+	Error				string		`db:"error" json:"error"`
+	CreatedAt			int64		`db:"createdat" json:"createdAt"`
+	CreatedBy			string		`db:"createdby" json:"createdBy"`
+}
+
+// Run status, Queued and Running runs are active and a workflow has at most one of them
+const (
+	QueuedRun = "Queued"
+	RunningRun = "Running"
+	SuccessfulRun = "Successful"
+	FailedRun = "Failed"
+	CancelledRun = "Cancelled"
+)
+
+var (
+	ErrWorkflowNotRunning = errors.New("workflow has no queued or running run")
+	// The run was recovered by another server after missing its heartbeats, the other server finishes it
+	ErrWorkflowRunLost = errors.New("workflow run was recovered by another server")
+)
+
+// APIs
+type TriggerWorkflowQuery struct {
+	Timeout int64	`form:"timeout"`
+}
+
+type GetWorkflowRunsQuery struct {
+	Limit int	`form:"limit"`
+}
@@ -1,5 +1,13 @@
 package models
 
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
 type EntityTotal struct {
 	Total	int				`json:"total" db:"total"`
 }
@@ -7,5 +15,180 @@ type EntityTotal struct {
 type JsonPatchOperation struct {
 	Op   	string    		`json:"op"`
 	Path 	string      	`json:"path"`
+	From	string			`json:"from,omitempty"`
 	Value 	interface{} 	`json:"value"`
 }
+
+// Fields of data and service entities that cannot be changed by a JSON patch
+var ImmutableFields = []string {
+	"id",
+	"name",
+	"fullyQualifiedName",
+	"serviceType",
+	"service",
+	"database",
+	"databaseSchema",
+	"version",
+	"updatedAt",
+	"updatedBy",
+	"changeDescription",
+	"deleted",
+}
+
+// ValidateJsonPatch rejects operations that write to or move from one of the immutable fields
+func ValidateJsonPatch(operations []JsonPatchOperation, immutableFields []string) error {
+	if len(operations) == 0 {
+		return errors.New("empty patch")
+	}
+
+	for _, operation := range operations {
+		if operation.Op == "test" {
+			continue
+		}
+
+		paths := []string{ operation.Path }
+
+		// A move also removes its source
+		if operation.Op == "move" {
+			paths = append(paths, operation.From)
+		}
+
+		for _, path := range paths {
+			for _, field := range immutableFields {
+				if path == "/" + field || strings.HasPrefix(path, "/" + field + "/") {
+					return fmt.Errorf("field %v cannot be patched", field)
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+type DeleteEntityQuery struct {
+	HardDelete	bool	`form:"hardDelete"`
+	Recursive	bool	`form:"recursive"`
+}
+
+type RestoreEntityPayload struct {
+	ID		string	`json:"id" binding:"required"`
+}
+
+// Include
+// Filter entities by their soft deleted state. Defaults to non-deleted.
+var Include = map[string]int {"non-deleted": 0, "deleted": 1, "all": 2}
+
+func ValidateInclude(include string) (int, error) {
+	idx, ok := Include[include]
+
+	if !ok {
+		return -1, errors.New("invalid include")
+	}
+
+	return idx, nil
+}
+
+type GetEntityQuery struct {
+	Include		string	`form:"include"`
+}
+
+// Paging
+// Cursors are opaque to clients, they encode the position of an entity in a list ordered by
+// fullyQualifiedName then id, so a page stays stable while new entities are inserted
+type Paging struct {
+	Before		string	`json:"before,omitempty"`
+	After		string	`json:"after,omitempty"`
+	Total		int		`json:"total"`
+}
+
+type Cursor struct {
+	Name		string	`json:"name"`
+	ID			string	`json:"id"`
+}
+
+func EncodeCursor(cursor *Cursor) string {
+	bytes, _ := json.Marshal(cursor)
+	return base64.URLEncoding.EncodeToString(bytes)
+}
+
+func DecodeCursor(value string) (*Cursor, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	bytes, err := base64.URLEncoding.DecodeString(value)
+
+	if err != nil {
+		return nil, errors.New("invalid cursor")
+	}
+
+	cursor := &Cursor{}
+
+	if err := json.Unmarshal(bytes, cursor); err != nil || cursor.ID == "" {
+		return nil, errors.New("invalid cursor")
+	}
+
+	return cursor, nil
+}
+
+func ValidatePagingCursors(before string, after string) (*Cursor, *Cursor, error) {
+	if before != "" && after != "" {
+		return nil, nil, errors.New("only one of before and after can be set")
+	}
+
+	beforeCursor, err := DecodeCursor(before)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	afterCursor, err := DecodeCursor(after)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return beforeCursor, afterCursor, nil
+}
+
+// NewPaging trims the extra row fetched by the repository, puts rows read backward from a before
+// cursor back in ascending order and builds the cursors of the neighbouring pages
+func NewPaging[T any](entities []T, limit int, before *Cursor, after *Cursor, toCursor func(T) *Cursor) ([]T, *Paging) {
+	paging := &Paging{}
+	hasMore := limit >= 0 && len(entities) > limit
+
+	if hasMore {
+		entities = entities[:limit]
+	}
+
+	if before != nil {
+		for i, j := 0, len(entities) - 1; i < j; i, j = i + 1, j - 1 {
+			entities[i], entities[j] = entities[j], entities[i]
+		}
+	}
+
+	if len(entities) == 0 {
+		return entities, paging
+	}
+
+	first := EncodeCursor(toCursor(entities[0]))
+	last := EncodeCursor(toCursor(entities[len(entities) - 1]))
+
+	if before != nil {
+		paging.After = last
+
+		if hasMore {
+			paging.Before = first
+		}
+	} else {
+		if after != nil {
+			paging.Before = first
+		}
+
+		if hasMore {
+			paging.After = last
+		}
+	}
+
+	return entities, paging
+}
@@ -0,0 +1,252 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestValidateJsonPatch(t *testing.T) {
+	tests := []struct {
+		name string
+		operations []JsonPatchOperation
+		expectedErr bool
+	}{
+		{ name: "empty patch", operations: []JsonPatchOperation{}, expectedErr: true },
+		{ name: "mutable field", operations: []JsonPatchOperation{ { Op: "replace", Path: "/description", Value: "Orders" } } },
+		{ name: "nested mutable field", operations: []JsonPatchOperation{ { Op: "add", Path: "/columns/0/description", Value: "Id" } } },
+		{ name: "immutable field", operations: []JsonPatchOperation{ { Op: "replace", Path: "/name", Value: "orders" } }, expectedErr: true },
+		{ name: "inside an immutable field", operations: []JsonPatchOperation{ { Op: "replace", Path: "/service/id", Value: "1" } }, expectedErr: true },
+		{ name: "field sharing a prefix", operations: []JsonPatchOperation{ { Op: "replace", Path: "/versionNote", Value: "" } } },
+		{ name: "test of an immutable field", operations: []JsonPatchOperation{ { Op: "test", Path: "/version", Value: 0.1 } } },
+		{ name: "move from an immutable field", operations: []JsonPatchOperation{ { Op: "move", From: "/deleted", Path: "/tags" } }, expectedErr: true },
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := ValidateJsonPatch(test.operations, ImmutableFields); (err != nil) != test.expectedErr {
+				t.Errorf("ValidateJsonPatch() err = %v, expected an error: %v", err, test.expectedErr)
+			}
+		})
+	}
+}
+
+func TestValidateInclude(t *testing.T) {
+	tests := []struct {
+		include string
+		expected int
+		expectedErr bool
+	}{
+		{ include: "non-deleted", expected: 0 },
+		{ include: "deleted", expected: 1 },
+		{ include: "all", expected: 2 },
+		{ include: "", expected: -1, expectedErr: true },
+		{ include: "ALL", expected: -1, expectedErr: true },
+	}
+
+	for _, test := range tests {
+		t.Run(test.include, func(t *testing.T) {
+			idx, err := ValidateInclude(test.include)
+
+			if idx != test.expected || (err != nil) != test.expectedErr {
+				t.Errorf("ValidateInclude(%q) = %v, %v, expected %v", test.include, idx, err, test.expected)
+			}
+		})
+	}
+}
+
+func TestDecodeCursor(t *testing.T) {
+	tests := []struct {
+		name string
+		value string
+		expected *Cursor
+		expectedErr bool
+	}{
+		{ name: "empty", value: "", expected: nil },
+		{ name: "round trip", value: EncodeCursor(&Cursor{ Name: "pg.shop", ID: "1" }), expected: &Cursor{ Name: "pg.shop", ID: "1" } },
+		{ name: "not base64", value: "not a cursor!", expectedErr: true },
+		{ name: "not json", value: "bm90IGpzb24=", expectedErr: true },
+		{ name: "without id", value: EncodeCursor(&Cursor{ Name: "pg.shop" }), expectedErr: true },
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cursor, err := DecodeCursor(test.value)
+
+			if (err != nil) != test.expectedErr {
+				t.Fatalf("DecodeCursor() err = %v, expected an error: %v", err, test.expectedErr)
+			}
+
+			if !reflect.DeepEqual(cursor, test.expected) {
+				t.Errorf("DecodeCursor() = %+v, expected %+v", cursor, test.expected)
+			}
+		})
+	}
+}
+
+func TestValidatePagingCursors(t *testing.T) {
+	cursor := EncodeCursor(&Cursor{ Name: "pg.shop", ID: "1" })
+
+	tests := []struct {
+		name string
+		before string
+		after string
+		expectedErr bool
+	}{
+		{ name: "none", before: "", after: "" },
+		{ name: "before", before: cursor, after: "" },
+		{ name: "after", before: "", after: cursor },
+		{ name: "both", before: cursor, after: cursor, expectedErr: true },
+		{ name: "invalid after", before: "", after: "invalid", expectedErr: true },
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, _, err := ValidatePagingCursors(test.before, test.after); (err != nil) != test.expectedErr {
+				t.Errorf("ValidatePagingCursors() err = %v, expected an error: %v", err, test.expectedErr)
+			}
+		})
+	}
+}
+
+func TestNewPaging(t *testing.T) {
+	toCursor := func(id string) *Cursor {
+		return &Cursor{ Name: id, ID: id }
+	}
+
+	cursor := func(id string) string {
+		return EncodeCursor(toCursor(id))
+	}
+
+	tests := []struct {
+		name string
+		entities []string
+		limit int
+		before *Cursor
+		after *Cursor
+		expected []string
+		expectedPaging *Paging
+	}{
+		{
+			name: "first page with more",
+			entities: []string{"a", "b", "c"},
+			limit: 2,
+			expected: []string{"a", "b"},
+			expectedPaging: &Paging{ After: cursor("b") },
+		},
+		{
+			name: "only page",
+			entities: []string{"a", "b"},
+			limit: 2,
+			expected: []string{"a", "b"},
+			expectedPaging: &Paging{},
+		},
+		{
+			name: "middle page after a cursor",
+			entities: []string{"c", "d", "e"},
+			limit: 2,
+			after: toCursor("b"),
+			expected: []string{"c", "d"},
+			expectedPaging: &Paging{ Before: cursor("c"), After: cursor("d") },
+		},
+		{
+			name: "last page after a cursor",
+			entities: []string{"c"},
+			limit: 2,
+			after: toCursor("b"),
+			expected: []string{"c"},
+			expectedPaging: &Paging{ Before: cursor("c") },
+		},
+		{
+			name: "page before a cursor is read backward",
+			entities: []string{"d", "c", "b"},
+			limit: 2,
+			before: toCursor("e"),
+			expected: []string{"c", "d"},
+			expectedPaging: &Paging{ Before: cursor("c"), After: cursor("d") },
+		},
+		{
+			name: "first page before a cursor",
+			entities: []string{"b", "a"},
+			limit: 2,
+			before: toCursor("c"),
+			expected: []string{"a", "b"},
+			expectedPaging: &Paging{ After: cursor("b") },
+		},
+		{
+			name: "empty",
+			entities: []string{},
+			limit: 2,
+			expected: []string{},
+			expectedPaging: &Paging{},
+		},
+		{
+			name: "no limit",
+			entities: []string{"a", "b", "c"},
+			limit: -1,
+			expected: []string{"a", "b", "c"},
+			expectedPaging: &Paging{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			entities, paging := NewPaging(test.entities, test.limit, test.before, test.after, toCursor)
+
+			if !reflect.DeepEqual(entities, test.expected) {
+				t.Errorf("NewPaging() entities = %v, expected %v", entities, test.expected)
+			}
+
+			if *paging != *test.expectedPaging {
+				t.Errorf("NewPaging() paging = %+v, expected %+v", paging, test.expectedPaging)
+			}
+		})
+	}
+}
+
+func TestCheckIfMatch(t *testing.T) {
+	tests := []struct {
+		name string
+		ifMatch string
+		version float64
+		expectedErr bool
+	}{
+		{ name: "no header", ifMatch: "", version: 0.2 },
+		{ name: "any version", ifMatch: "*", version: 0.2 },
+		{ name: "current version", ifMatch: `"0.2"`, version: 0.2 },
+		{ name: "weak current version", ifMatch: `W/"0.2"`, version: 0.2 },
+		{ name: "one of several", ifMatch: `"0.1", "0.2"`, version: 0.2 },
+		{ name: "stale version", ifMatch: `"0.1"`, version: 0.2, expectedErr: true },
+		{ name: "unquoted version", ifMatch: "0.2", version: 0.2, expectedErr: true },
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := CheckIfMatch(test.ifMatch, test.version)
+
+			if test.expectedErr && err != ErrPreconditionFailed {
+				t.Errorf("CheckIfMatch(%q, %v) = %v, expected ErrPreconditionFailed", test.ifMatch, test.version, err)
+			}
+
+			if !test.expectedErr && err != nil {
+				t.Errorf("CheckIfMatch(%q, %v) = %v, expected no error", test.ifMatch, test.version, err)
+			}
+		})
+	}
+}
+
+func TestEntityTag(t *testing.T) {
+	tests := []struct {
+		version float64
+		expected string
+	}{
+		{ version: 0.1, expected: `"0.1"` },
+		{ version: 1, expected: `"1"` },
+		{ version: 1.3, expected: `"1.3"` },
+	}
+
+	for _, test := range tests {
+		if etag := EntityTag(test.version); etag != test.expected {
+			t.Errorf("EntityTag(%v) = %v, expected %v", test.version, etag, test.expected)
+		}
+	}
+}
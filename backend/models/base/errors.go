@@ -0,0 +1,9 @@
+package models
+
+import "errors"
+
+var ErrEntityHasChildren = errors.New("entity has children, use recursive=true to delete it")
+var ErrEntityDeleted = errors.New("entity is soft deleted, restore it first")
+var ErrEntityNotDeleted = errors.New("entity is not deleted")
+var ErrParentEntityDeleted = errors.New("parent entity is deleted, restore it first")
+var ErrPreconditionFailed = errors.New("entity has been modified, the If-Match header does not match its current ETag")
@@ -0,0 +1,35 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+// EntityTag returns the quoted ETag of an entity at the given version
+func EntityTag(version float64) string {
+	return fmt.Sprintf("\"%v\"", version)
+}
+
+// CheckIfMatch returns ErrPreconditionFailed unless the If-Match header is empty, "*" or lists the current ETag
+func CheckIfMatch(ifMatch string, version float64) error {
+	if ifMatch == "" {
+		return nil
+	}
+
+	etag := EntityTag(version)
+
+	for _, value := range strings.Split(ifMatch, ",") {
+		value = strings.TrimPrefix(strings.TrimSpace(value), "W/")
+
+		if value == "*" || value == etag {
+			return nil
+		}
+	}
+
+	return ErrPreconditionFailed
+}
+
+// TimestampEntityTag returns a weak ETag for entities that are not versioned, derived from their last update
+func TimestampEntityTag(updatedAt int64) string {
+	return fmt.Sprintf("W/\"%v\"", updatedAt)
+}
@@ -31,6 +31,13 @@ type Database struct {
 	ServiceType			string						`json:"serviceType"`
 	Service				*typeModels.EntityReference	`json:"service"`
 
+	Owners				[]*typeModels.EntityReference	`json:"owners,omitempty"`
+
+	Version				float64						`json:"version"`
+	UpdatedAt			int64						`json:"updatedAt"`
+	UpdatedBy			string						`json:"updatedBy"`
+	ChangeDescription	*typeModels.ChangeDescription	`json:"changeDescription,omitempty"`
+
 	Deleted				bool						`json:"deleted"`
 }
 
@@ -62,11 +69,24 @@ func (s *Database) ToEntityReference() *typeModels.EntityReference {
 	return entityRef
 }
 
+func (s *Database) GetVersion() float64 {
+	return s.Version
+}
+
+func (s *Database) SetVersion(version float64, changeDescription *typeModels.ChangeDescription, updatedAt int64, updatedBy string) {
+	s.Version = version
+	s.ChangeDescription = changeDescription
+	s.UpdatedAt = updatedAt
+	s.UpdatedBy = updatedBy
+}
+
 // APIs
 type GetDatabaseEntitiesQuery struct {
 	Service		string	`form:"service"`
 	Limit 		int		`form:"limit"`
-	Offset 		int		`form:"offset"`
+	Before 		string	`form:"before"`
+	After		string	`form:"after"`
+	Include		string	`form:"include"`
 }
 
 type GetDatabaseEntityByIdParam struct {
@@ -83,4 +103,6 @@ type CreateDatabaseEntityPayload struct {
 	Description		string				`json:"description"`
 
 	Service			string				`json:"service" binding:"required"`
+
+	Owners		[]*typeModels.EntityReference	`json:"owners"`
 }
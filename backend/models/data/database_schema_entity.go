@@ -32,6 +32,13 @@ type DatabaseSchema struct {
 	Service				*typeModels.EntityReference	`json:"service"`
 	Database			*typeModels.EntityReference	`json:"database"`
 
+	Owners				[]*typeModels.EntityReference	`json:"owners,omitempty"`
+
+	Version				float64						`json:"version"`
+	UpdatedAt			int64						`json:"updatedAt"`
+	UpdatedBy			string						`json:"updatedBy"`
+	ChangeDescription	*typeModels.ChangeDescription	`json:"changeDescription,omitempty"`
+
 	Deleted				bool						`json:"deleted"`
 }
 
@@ -63,11 +70,24 @@ func (s *DatabaseSchema) ToEntityReference() *typeModels.EntityReference {
 	return entityRef
 }
 
+func (s *DatabaseSchema) GetVersion() float64 {
+	return s.Version
+}
+
+func (s *DatabaseSchema) SetVersion(version float64, changeDescription *typeModels.ChangeDescription, updatedAt int64, updatedBy string) {
+	s.Version = version
+	s.ChangeDescription = changeDescription
+	s.UpdatedAt = updatedAt
+	s.UpdatedBy = updatedBy
+}
+
 // APIs
 type GetDatabaseSchemaEntitiesQuery struct {
 	Database		string	`form:"database"`
 	Limit 			int		`form:"limit"`
-	Offset 			int		`form:"offset"`
+	Before 			string	`form:"before"`
+	After			string	`form:"after"`
+	Include			string	`form:"include"`
 }
 
 type GetDatabaseSchemaEntityByIdParam struct {
@@ -84,4 +104,6 @@ type CreateDatabaseSchemaEntityPayload struct {
 	Description		string				`json:"description"`
 
 	Database		string				`json:"database" binding:"required"`
+
+	Owners		[]*typeModels.EntityReference	`json:"owners"`
 }
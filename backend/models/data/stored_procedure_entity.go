@@ -36,6 +36,13 @@ type StoredProcedure struct {
 	Database				*typeModels.EntityReference		`json:"database"`
 	DatabaseSchema			*typeModels.EntityReference		`json:"databaseSchema"`
 
+	Owners					[]*typeModels.EntityReference	`json:"owners,omitempty"`
+
+	Version					float64							`json:"version"`
+	UpdatedAt				int64							`json:"updatedAt"`
+	UpdatedBy				string							`json:"updatedBy"`
+	ChangeDescription		*typeModels.ChangeDescription	`json:"changeDescription,omitempty"`
+
 	Deleted					bool							`json:"deleted"`
 }
 
@@ -53,6 +60,31 @@ func (s *StoredProcedure) Scan(value interface{}) error {
 	return json.Unmarshal(val, &s)
 }
 
+func (s *StoredProcedure) ToEntityReference() *typeModels.EntityReference {
+	entityRef := &typeModels.EntityReference{
+		ID: s.ID,
+		Type: "storedProcedure",
+		Name: s.Name,
+		FullyQualifiedName: s.FullyQualifiedName,
+		DisplayName: s.DisplayName,
+		Description: s.Description,
+		Deleted: s.Deleted,
+	}
+
+	return entityRef
+}
+
+func (s *StoredProcedure) GetVersion() float64 {
+	return s.Version
+}
+
+func (s *StoredProcedure) SetVersion(version float64, changeDescription *typeModels.ChangeDescription, updatedAt int64, updatedBy string) {
+	s.Version = version
+	s.ChangeDescription = changeDescription
+	s.UpdatedAt = updatedAt
+	s.UpdatedBy = updatedBy
+}
+
 type StoredProcedureCode struct {
 	Language		string			`json:"language"`
 	Code			string			`json:"code"`
@@ -62,7 +94,9 @@ type StoredProcedureCode struct {
 type GetStoredProcedureEntitiesQuery struct {
 	DatabaseSchema		string	`form:"databaseSchema"`
 	Limit 				int		`form:"limit"`
-	Offset 				int		`form:"offset"`
+	Before 				string	`form:"before"`
+	After				string	`form:"after"`
+	Include				string	`form:"include"`
 }
 
 type GetStoredProcedureEntityByIdParam struct {
@@ -82,4 +116,6 @@ type CreateStoredProcedureEntityPayload struct {
 	StoredProcedureType		string							`json:"storedProcedureType"`
 
 	DatabaseSchema			string							`json:"databaseSchema" binding:"required"`
+
+	Owners				[]*typeModels.EntityReference	`json:"owners"`
 }
@@ -37,9 +37,18 @@ type Table struct {
 
 	TableType			string						`json:"tableType"`
 	TableConstraints	[]TableConstraint			`json:"tableConstraints"`
+	// Query of a view, the tables it reads are recorded as its upstream lineage
+	ViewDefinition		string						`json:"viewDefinition,omitempty"`
 
 	Columns				[]Column					`json:"columns"`
 
+	Owners				[]*typeModels.EntityReference	`json:"owners,omitempty"`
+
+	Version				float64						`json:"version"`
+	UpdatedAt			int64						`json:"updatedAt"`
+	UpdatedBy			string						`json:"updatedBy"`
+	ChangeDescription	*typeModels.ChangeDescription	`json:"changeDescription,omitempty"`
+
 	Deleted				bool						`json:"deleted"`
 }
 
@@ -71,6 +80,45 @@ func (s *Table) ToEntityReference() *typeModels.EntityReference {
 	return entityRef
 }
 
+func (s *Table) GetVersion() float64 {
+	return s.Version
+}
+
+func (s *Table) SetVersion(version float64, changeDescription *typeModels.ChangeDescription, updatedAt int64, updatedBy string) {
+	s.Version = version
+	s.ChangeDescription = changeDescription
+	s.UpdatedAt = updatedAt
+	s.UpdatedBy = updatedBy
+}
+
+// HasColumn reports whether fqn is the fullyQualifiedName of one of the columns of the table
+func (s *Table) HasColumn(fqn string) bool {
+	for _, column := range s.Columns {
+		if column.FullyQualifiedName != nil && *column.FullyQualifiedName == fqn {
+			return true
+		}
+	}
+
+	return false
+}
+
+// FindColumn returns the column with the given fullyQualifiedName or name, nil when the table has no such column
+func (s *Table) FindColumn(column string) *Column {
+	for i := range s.Columns {
+		if s.Columns[i].FullyQualifiedName != nil && *s.Columns[i].FullyQualifiedName == column {
+			return &s.Columns[i]
+		}
+	}
+
+	for i := range s.Columns {
+		if s.Columns[i].Name != nil && *s.Columns[i].Name == column {
+			return &s.Columns[i]
+		}
+	}
+
+	return nil
+}
+
 // Table type
 var TableType = map[string]int {
 	"Regular": 0,
@@ -125,7 +173,7 @@ func ValidateColumn(column *Column, tableFqn string) error {
 	}
 
 	if column.FullyQualifiedName == nil {
-		v := fmt.Sprintf("%v.%v", tableFqn, column.Name)
+		v := fmt.Sprintf("%v.%v", tableFqn, *column.Name)
 		column.FullyQualifiedName = &v
 	} else if *column.FullyQualifiedName == "" {
 		return errors.New("column fqn cannot be empty")
@@ -158,6 +206,40 @@ func ValidateColumn(column *Column, tableFqn string) error {
 	return  nil
 }
 
+// MergeColumns takes the incoming columns and keeps the display name and description of the stored
+// column with the same name when the incoming one has none
+func MergeColumns(stored []Column, incoming []Column) []Column {
+	storedByName := map[string]Column{}
+
+	for _, column := range stored {
+		if column.Name != nil {
+			storedByName[*column.Name] = column
+		}
+	}
+
+	for i := range incoming {
+		if incoming[i].Name == nil {
+			continue
+		}
+
+		column, ok := storedByName[*incoming[i].Name]
+
+		if !ok {
+			continue
+		}
+
+		if incoming[i].DisplayName == "" {
+			incoming[i].DisplayName = column.DisplayName
+		}
+
+		if incoming[i].Description == "" {
+			incoming[i].Description = column.Description
+		}
+	}
+
+	return incoming
+}
+
 // Data type
 var DataType = map[string]int {
 	"NUMBER": 0,
@@ -278,8 +360,12 @@ var RelationshipType = map[string]int {
 // APIs
 type GetTableEntitiesQuery struct {
 	DatabaseSchema		string	`form:"databaseSchema"`
+	// Name of a user or team
+	Owner				string	`form:"owner"`
 	Limit 				int		`form:"limit"`
-	Offset 				int		`form:"offset"`
+	Before 				string	`form:"before"`
+	After				string	`form:"after"`
+	Include				string	`form:"include"`
 }
 
 type GetTableEntityByIdParam struct {
@@ -299,8 +385,11 @@ type CreateTableEntityPayload struct {
 
 	TableType			string				`json:"tableType" binding:"required"`
 	TableConstraints	[]TableConstraint	`json:"tableConstraints"`
+	ViewDefinition		string				`json:"viewDefinition"`
 
 	Columns				[]Column			`json:"columns"`
+
+	Owners			[]*typeModels.EntityReference	`json:"owners"`
 }
 
 func ValidateCreateTableEntityPayload(payload *CreateTableEntityPayload) error {
@@ -312,8 +401,26 @@ func ValidateCreateTableEntityPayload(payload *CreateTableEntityPayload) error {
 
 	tableFqn := fmt.Sprintf("%v.%v", payload.DatabaseSchema, payload.Name)
 
-	for _, column := range payload.Columns {
-		err := ValidateColumn(&column, tableFqn)
+	for i := range payload.Columns {
+		err := ValidateColumn(&payload.Columns[i], tableFqn)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func ValidateTable(table *Table) error {
+	_, tableTypeErr := ValidateTableType(table.TableType)
+
+	if tableTypeErr != nil {
+		return tableTypeErr
+	}
+
+	for i := range table.Columns {
+		err := ValidateColumn(&table.Columns[i], table.FullyQualifiedName)
 
 		if err != nil {
 			return err
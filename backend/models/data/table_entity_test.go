@@ -0,0 +1,52 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMergeColumns(t *testing.T) {
+	column := func(name string, displayName string, description string) Column {
+		return Column{ Name: &name, DisplayName: displayName, Description: description }
+	}
+
+	stored := []Column{
+		column("id", "Identifier", "Primary key"),
+		column("total", "", "Amount in cents"),
+	}
+
+	tests := []struct {
+		name string
+		incoming []Column
+		expected []Column
+	}{
+		{
+			name: "documentation of the stored columns is kept",
+			incoming: []Column{ column("id", "", ""), column("total", "", "") },
+			expected: []Column{ column("id", "Identifier", "Primary key"), column("total", "", "Amount in cents") },
+		},
+		{
+			name: "incoming documentation wins",
+			incoming: []Column{ column("id", "Id", "Order id") },
+			expected: []Column{ column("id", "Id", "Order id") },
+		},
+		{
+			name: "dropped and new columns",
+			incoming: []Column{ column("status", "", "") },
+			expected: []Column{ column("status", "", "") },
+		},
+		{
+			name: "column without name",
+			incoming: []Column{ { Description: "Unnamed" } },
+			expected: []Column{ { Description: "Unnamed" } },
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if merged := MergeColumns(stored, test.incoming); !reflect.DeepEqual(merged, test.expected) {
+				t.Errorf("MergeColumns() = %+v, expected %+v", merged, test.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,257 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"github.com/google/uuid"
+	securityModels "github.com/nambuitechx/go-metadata/models/security"
+	typeModels "github.com/nambuitechx/go-metadata/models/type"
+)
+
+// Change event entity
+type ChangeEventEntity struct {
+	ID					int64				`db:"id" json:"-"`
+	// Offset is assigned when the event is published, the readers only see published events
+	Offset				int64				`db:"offset" json:"offset"`
+	EventType			string				`db:"eventtype" json:"eventType"`
+	EntityType			string				`db:"entitytype" json:"entityType"`
+	UserName			string				`db:"username" json:"userName"`
+	EventTime			int64				`db:"eventtime" json:"eventTime"`
+	Json				*ChangeEvent		`db:"json" json:"json"`
+}
+
+// Change event
+type ChangeEvent struct {
+	ID							string							`json:"id"`
+	EventType					string							`json:"eventType"`
+	EntityType					string							`json:"entityType"`
+	EntityID					string							`json:"entityId"`
+	EntityFullyQualifiedName	string							`json:"entityFullyQualifiedName"`
+
+	PreviousVersion				float64							`json:"previousVersion"`
+	CurrentVersion				float64							`json:"currentVersion"`
+	UserName					string							`json:"userName"`
+	Timestamp					int64							`json:"timestamp"`
+
+	ChangeDescription			*typeModels.ChangeDescription	`json:"changeDescription,omitempty"`
+	Entity						interface{}						`json:"entity,omitempty"`
+}
+
+func (s ChangeEvent) Value() (driver.Value, error) {
+	return json.Marshal(s)
+}
+
+func (s *ChangeEvent) Scan(value interface{}) error {
+	val, ok := value.([]byte)
+
+	if !ok {
+		return errors.New("type assertion to []byte failed")
+	}
+
+	return json.Unmarshal(val, &s)
+}
+
+// Event type
+const (
+	EntityCreated = "entityCreated"
+	EntityUpdated = "entityUpdated"
+	EntitySoftDeleted = "entitySoftDeleted"
+	EntityDeleted = "entityDeleted"
+)
+
+var EventType = map[string]int {
+	EntityCreated: 0,
+	EntityUpdated: 1,
+	EntitySoftDeleted: 2,
+	EntityDeleted: 3,
+}
+
+// Empty filters match everything, "*" in entityTypes matches every entity type
+type ChangeEventFilters struct {
+	EntityTypes				[]string		`json:"entityTypes"`
+	EventTypes				[]string		`json:"eventTypes"`
+	FqnPrefix				string			`json:"fqnPrefix"`
+}
+
+func (f *ChangeEventFilters) Match(changeEvent *ChangeEvent) bool {
+	if f == nil {
+		return true
+	}
+
+	if len(f.EventTypes) > 0 && !contains(f.EventTypes, changeEvent.EventType) {
+		return false
+	}
+
+	if len(f.EntityTypes) > 0 && !contains(f.EntityTypes, "*") && !contains(f.EntityTypes, changeEvent.EntityType) {
+		return false
+	}
+
+	return strings.HasPrefix(changeEvent.EntityFullyQualifiedName, f.FqnPrefix)
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+// MaskChangeEventSecrets returns a copy of a stored change event with its secrets masked, and whether it held any.
+// Events recorded before the secrets were masked may carry them
+func MaskChangeEventSecrets(changeEvent *ChangeEvent) (*ChangeEvent, bool, error) {
+	masked := &ChangeEvent{}
+
+	if err := securityModels.CopyMasked(changeEvent, masked); err != nil {
+		return nil, false, err
+	}
+
+	before, err := json.Marshal(changeEvent)
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	after, err := json.Marshal(masked)
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	return masked, string(before) != string(after), nil
+}
+
+// Changes made outside of an authenticated request are recorded under this user
+const DefaultUserName = "admin"
+
+func NewChangeEventEntity(
+	eventType string,
+	entityRef *typeModels.EntityReference,
+	version float64,
+	changeDescription *typeModels.ChangeDescription,
+	userName string,
+	timestamp int64,
+	entity interface{},
+) *ChangeEventEntity {
+	if userName == "" {
+		userName = DefaultUserName
+	}
+
+	previousVersion := version
+
+	if changeDescription != nil {
+		previousVersion = changeDescription.PreviousVersion
+	}
+
+	// Events are streamed and sent to the subscriptions, they never carry secrets. What cannot be masked is left out
+	entity, err := securityModels.MaskSecrets(entity)
+
+	if err != nil {
+		entity = nil
+	}
+
+	if changeDescription != nil {
+		maskedChangeDescription := &typeModels.ChangeDescription{}
+
+		if err := securityModels.CopyMasked(changeDescription, maskedChangeDescription); err != nil {
+			maskedChangeDescription = nil
+		}
+
+		changeDescription = maskedChangeDescription
+	}
+
+	changeEvent := &ChangeEvent{
+		ID: uuid.NewString(),
+		EventType: eventType,
+		EntityType: entityRef.Type,
+		EntityID: entityRef.ID,
+		EntityFullyQualifiedName: entityRef.FullyQualifiedName,
+		PreviousVersion: previousVersion,
+		CurrentVersion: version,
+		UserName: userName,
+		Timestamp: timestamp,
+		ChangeDescription: changeDescription,
+		Entity: entity,
+	}
+
+	return &ChangeEventEntity{
+		EventType: eventType,
+		EntityType: entityRef.Type,
+		UserName: userName,
+		EventTime: timestamp,
+		Json: changeEvent,
+	}
+}
+
+// APIs
+type GetChangeEventsQuery struct {
+	EntityCreated		string	`form:"entityCreated"`
+	EntityUpdated		string	`form:"entityUpdated"`
+	EntitySoftDeleted	string	`form:"entitySoftDeleted"`
+	EntityDeleted		string	`form:"entityDeleted"`
+	Timestamp			int64	`form:"timestamp" binding:"required"`
+}
+
+// ToFilters maps each requested event type to its entity types, "*" matches every entity type
+func (q *GetChangeEventsQuery) ToFilters() map[string][]string {
+	filters := map[string][]string{}
+
+	for eventType, entityTypes := range map[string]string {
+		EntityCreated: q.EntityCreated,
+		EntityUpdated: q.EntityUpdated,
+		EntitySoftDeleted: q.EntitySoftDeleted,
+		EntityDeleted: q.EntityDeleted,
+	} {
+		if entityTypes == "" {
+			continue
+		}
+
+		for _, entityType := range strings.Split(entityTypes, ",") {
+			if entityType = strings.TrimSpace(entityType); entityType != "" {
+				filters[eventType] = append(filters[eventType], entityType)
+			}
+		}
+	}
+
+	return filters
+}
+
+type StreamChangeEventsQuery struct {
+	EntityTypes			string	`form:"entityTypes"`
+	EventTypes			string	`form:"eventTypes"`
+	FqnPrefix			string	`form:"fqnPrefix"`
+	LastEventID			string	`form:"lastEventId"`
+}
+
+func (q *StreamChangeEventsQuery) ToFilters() (*ChangeEventFilters, error) {
+	filters := &ChangeEventFilters{
+		EntityTypes: splitList(q.EntityTypes),
+		EventTypes: splitList(q.EventTypes),
+		FqnPrefix: q.FqnPrefix,
+	}
+
+	for _, eventType := range filters.EventTypes {
+		if _, ok := EventType[eventType]; !ok {
+			return nil, errors.New("invalid event type")
+		}
+	}
+
+	return filters, nil
+}
+
+func splitList(value string) []string {
+	values := []string{}
+
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+
+	return values
+}
@@ -0,0 +1,206 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+
+	securityModels "github.com/nambuitechx/go-metadata/models/security"
+	typeModels "github.com/nambuitechx/go-metadata/models/type"
+)
+
+func TestChangeEventFiltersMatch(t *testing.T) {
+	changeEvent := &ChangeEvent{
+		EventType: EntityUpdated,
+		EntityType: "table",
+		EntityFullyQualifiedName: "pg.shop.sales.orders",
+	}
+
+	tests := []struct {
+		name string
+		filters *ChangeEventFilters
+		expected bool
+	}{
+		{ name: "nil filters", filters: nil, expected: true },
+		{ name: "empty filters", filters: &ChangeEventFilters{}, expected: true },
+		{ name: "event type", filters: &ChangeEventFilters{ EventTypes: []string{EntityCreated, EntityUpdated} }, expected: true },
+		{ name: "other event type", filters: &ChangeEventFilters{ EventTypes: []string{EntityDeleted} }, expected: false },
+		{ name: "entity type", filters: &ChangeEventFilters{ EntityTypes: []string{"table"} }, expected: true },
+		{ name: "other entity type", filters: &ChangeEventFilters{ EntityTypes: []string{"database"} }, expected: false },
+		{ name: "any entity type", filters: &ChangeEventFilters{ EntityTypes: []string{"database", "*"} }, expected: true },
+		{ name: "fqn prefix", filters: &ChangeEventFilters{ FqnPrefix: "pg.shop" }, expected: true },
+		{ name: "other fqn prefix", filters: &ChangeEventFilters{ FqnPrefix: "mysql" }, expected: false },
+		{
+			name: "every filter must match",
+			filters: &ChangeEventFilters{ EventTypes: []string{EntityUpdated}, EntityTypes: []string{"table"}, FqnPrefix: "mysql" },
+			expected: false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if matched := test.filters.Match(changeEvent); matched != test.expected {
+				t.Errorf("Match() = %v, expected %v", matched, test.expected)
+			}
+		})
+	}
+}
+
+func TestGetChangeEventsQueryToFilters(t *testing.T) {
+	tests := []struct {
+		name string
+		query *GetChangeEventsQuery
+		expected map[string][]string
+	}{
+		{ name: "no event type", query: &GetChangeEventsQuery{}, expected: map[string][]string{} },
+		{
+			name: "entity types per event type",
+			query: &GetChangeEventsQuery{ EntityCreated: "table, database", EntityDeleted: "*" },
+			expected: map[string][]string{ EntityCreated: {"table", "database"}, EntityDeleted: {"*"} },
+		},
+		{
+			name: "blank entity types are skipped",
+			query: &GetChangeEventsQuery{ EntityUpdated: " , table,", EntitySoftDeleted: " " },
+			expected: map[string][]string{ EntityUpdated: {"table"} },
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if filters := test.query.ToFilters(); !reflect.DeepEqual(filters, test.expected) {
+				t.Errorf("ToFilters() = %v, expected %v", filters, test.expected)
+			}
+		})
+	}
+}
+
+func TestNewChangeEventEntity(t *testing.T) {
+	entityRef := &typeModels.EntityReference{ ID: "1", Type: "table", FullyQualifiedName: "pg.shop.sales.orders" }
+
+	tests := []struct {
+		name string
+		changeDescription *typeModels.ChangeDescription
+		userName string
+		expectedPreviousVersion float64
+		expectedUserName string
+	}{
+		{
+			name: "created",
+			changeDescription: nil,
+			userName: "alice",
+			expectedPreviousVersion: 0.3,
+			expectedUserName: "alice",
+		},
+		{
+			name: "updated",
+			changeDescription: &typeModels.ChangeDescription{ PreviousVersion: 0.2 },
+			userName: "alice",
+			expectedPreviousVersion: 0.2,
+			expectedUserName: "alice",
+		},
+		{
+			name: "outside of a request",
+			changeDescription: nil,
+			userName: "",
+			expectedPreviousVersion: 0.3,
+			expectedUserName: DefaultUserName,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			changeEventEntity := NewChangeEventEntity(EntityUpdated, entityRef, 0.3, test.changeDescription, test.userName, 100, nil)
+			changeEvent := changeEventEntity.Json
+
+			if changeEvent.PreviousVersion != test.expectedPreviousVersion || changeEvent.CurrentVersion != 0.3 {
+				t.Errorf("versions = %v -> %v, expected %v -> 0.3", changeEvent.PreviousVersion, changeEvent.CurrentVersion, test.expectedPreviousVersion)
+			}
+
+			if changeEventEntity.UserName != test.expectedUserName || changeEvent.UserName != test.expectedUserName {
+				t.Errorf("userName = %v, expected %v", changeEventEntity.UserName, test.expectedUserName)
+			}
+
+			if changeEvent.EntityType != "table" || changeEvent.EntityID != "1" || changeEvent.EntityFullyQualifiedName != entityRef.FullyQualifiedName {
+				t.Errorf("unexpected entity %v %v %v", changeEvent.EntityType, changeEvent.EntityID, changeEvent.EntityFullyQualifiedName)
+			}
+		})
+	}
+}
+
+func TestStreamChangeEventsQueryToFilters(t *testing.T) {
+	tests := []struct {
+		name string
+		query *StreamChangeEventsQuery
+		expected *ChangeEventFilters
+		expectedErr bool
+	}{
+		{
+			name: "no filter",
+			query: &StreamChangeEventsQuery{},
+			expected: &ChangeEventFilters{ EntityTypes: []string{}, EventTypes: []string{} },
+		},
+		{
+			name: "lists are trimmed",
+			query: &StreamChangeEventsQuery{ EntityTypes: "table, database,", EventTypes: " entityCreated ", FqnPrefix: "pg" },
+			expected: &ChangeEventFilters{ EntityTypes: []string{"table", "database"}, EventTypes: []string{EntityCreated}, FqnPrefix: "pg" },
+		},
+		{
+			name: "unknown event type",
+			query: &StreamChangeEventsQuery{ EventTypes: "entityCreated,entityRenamed" },
+			expectedErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			filters, err := test.query.ToFilters()
+
+			if (err != nil) != test.expectedErr {
+				t.Fatalf("ToFilters() err = %v, expected an error: %v", err, test.expectedErr)
+			}
+
+			if !reflect.DeepEqual(filters, test.expected) {
+				t.Errorf("ToFilters() = %+v, expected %+v", filters, test.expected)
+			}
+		})
+	}
+}
+
+func TestNewChangeEventEntityMasksSecrets(t *testing.T) {
+	entityRef := &typeModels.EntityReference{ ID: "1", Type: "databaseService", FullyQualifiedName: "pg" }
+	entity := map[string]interface{}{ "name": "pg", "connection": map[string]interface{}{ "config": map[string]interface{}{ "password": "encrypted:abc" } } }
+
+	changeDescription := &typeModels.ChangeDescription{
+		FieldsUpdated: []typeModels.FieldChange{
+			{ Name: "connection", OldValue: map[string]interface{}{ "password": "old" }, NewValue: map[string]interface{}{ "password": "new" } },
+		},
+		PreviousVersion: 0.1,
+	}
+
+	changeEvent := NewChangeEventEntity(EntityUpdated, entityRef, 0.2, changeDescription, "alice", 100, entity).Json
+
+	tests := []struct {
+		name string
+		value interface{}
+		expected interface{}
+	}{
+		{
+			name: "entity",
+			value: changeEvent.Entity,
+			expected: map[string]interface{}{ "name": "pg", "connection": map[string]interface{}{ "config": map[string]interface{}{ "password": securityModels.SecretMask } } },
+		},
+		{ name: "old value", value: changeEvent.ChangeDescription.FieldsUpdated[0].OldValue, expected: map[string]interface{}{ "password": securityModels.SecretMask } },
+		{ name: "new value", value: changeEvent.ChangeDescription.FieldsUpdated[0].NewValue, expected: map[string]interface{}{ "password": securityModels.SecretMask } },
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if !reflect.DeepEqual(test.value, test.expected) {
+				t.Errorf("%v = %v, expected %v", test.name, test.value, test.expected)
+			}
+		})
+	}
+
+	if password := entity["connection"].(map[string]interface{})["config"].(map[string]interface{})["password"]; password != "encrypted:abc" {
+		t.Errorf("the entity was masked in place, password = %v", password)
+	}
+}
@@ -0,0 +1,191 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"net/url"
+)
+
+// Event subscription entity
+type EventSubscriptionEntity struct {
+	ID					string				`db:"id" json:"id"`
+	Name				string				`db:"name" json:"name"`
+	Json				*EventSubscription	`db:"json" json:"json"`
+	UpdatedAt			int64				`db:"updatedat" json:"updatedAt"`
+	UpdatedBy			string				`db:"updatedby" json:"updatedBy"`
+	Deleted				bool				`db:"deleted" json:"deleted"`
+	NameHash			string				`db:"namehash" json:"nameHash"`
+	// Claim of the dispatcher delivering the events, it is stale once the heartbeats stop
+	ClaimID				string				`db:"claimid" json:"-"`
+	HeartbeatAt			int64				`db:"heartbeatat" json:"-"`
+}
+
+// Event subscription
+type EventSubscription struct {
+	ID						string							`json:"id"`
+	Name					string							`json:"name"`
+	FullyQualifiedName		string							`json:"fullyQualifiedName"`
+
+	DisplayName				string							`json:"displayName"`
+	Description				string							`json:"description"`
+
+	Endpoint				string							`json:"endpoint"`
+	Secret					string							`json:"secret"`
+	Filters					*ChangeEventFilters			`json:"filters"`
+	Enabled					bool							`json:"enabled"`
+	MaxRetries				int								`json:"maxRetries"`
+	Timeout					int								`json:"timeout"`
+
+	// Offset of the last change event handled by the dispatcher
+	Offset					int64							`json:"offset"`
+	Status					*EventSubscriptionStatus		`json:"status"`
+
+	UpdatedAt				int64							`json:"updatedAt"`
+	UpdatedBy				string							`json:"updatedBy"`
+
+	Deleted					bool							`json:"deleted"`
+}
+
+func (s EventSubscription) Value() (driver.Value, error) {
+	return json.Marshal(s)
+}
+
+func (s *EventSubscription) Scan(value interface{}) error {
+	val, ok := value.([]byte)
+
+	if !ok {
+		return errors.New("type assertion to []byte failed")
+	}
+
+	return json.Unmarshal(val, &s)
+}
+
+// Subscription status
+const (
+	SubscriptionActive = "Active"
+	SubscriptionFailed = "Failed"
+)
+
+type EventSubscriptionStatus struct {
+	Status					string		`json:"status"`
+	LastSuccessfulAt		int64		`json:"lastSuccessfulAt"`
+	LastFailedAt			int64		`json:"lastFailedAt"`
+	LastFailedStatusCode	int			`json:"lastFailedStatusCode"`
+	LastFailedReason		string		`json:"lastFailedReason"`
+	FailedEvents			int64		`json:"failedEvents"`
+}
+
+func (s EventSubscriptionStatus) Value() (driver.Value, error) {
+	return json.Marshal(s)
+}
+
+// Dead letter, a change event that could not be delivered after all retries
+type DeadLetterEntity struct {
+	ID					string				`db:"id" json:"id"`
+	SubscriptionID		string				`db:"subscriptionid" json:"subscriptionId"`
+	Offset				int64				`db:"offset" json:"offset"`
+	FailedAt			int64				`db:"failedat" json:"failedAt"`
+	Json				*DeadLetter			`db:"json" json:"json"`
+}
+
+type DeadLetter struct {
+	ID						string			`json:"id"`
+	SubscriptionID			string			`json:"subscriptionId"`
+	Offset					int64			`json:"offset"`
+	Attempts				int				`json:"attempts"`
+	StatusCode				int				`json:"statusCode"`
+	Reason					string			`json:"reason"`
+	FailedAt				int64			`json:"failedAt"`
+	ChangeEvent				*ChangeEvent	`json:"changeEvent"`
+}
+
+func (s DeadLetter) Value() (driver.Value, error) {
+	return json.Marshal(s)
+}
+
+func (s *DeadLetter) Scan(value interface{}) error {
+	val, ok := value.([]byte)
+
+	if !ok {
+		return errors.New("type assertion to []byte failed")
+	}
+
+	return json.Unmarshal(val, &s)
+}
+
+// Delivery defaults
+const (
+	DefaultMaxRetries = 3
+	DefaultTimeout = 10
+)
+
+// APIs
+type GetEventSubscriptionEntitiesQuery struct {
+	Limit int	`form:"limit"`
+	Before string	`form:"before"`
+	After string	`form:"after"`
+	Include string	`form:"include"`
+}
+
+type GetEventSubscriptionEntityByIdParam struct {
+	ID string	`uri:"id" binding:"required"`
+}
+
+type GetEventSubscriptionEntityByFqnParam struct {
+	FQN string	`uri:"fqn" binding:"required"`
+}
+
+type GetDeadLettersQuery struct {
+	Limit int	`form:"limit"`
+}
+
+type CreateEventSubscriptionPayload struct {
+	Name				string						`json:"name" binding:"required"`
+	DisplayName			string						`json:"displayName"`
+	Description			string						`json:"description"`
+
+	Endpoint			string						`json:"endpoint" binding:"required"`
+	Secret				string						`json:"secret"`
+	Filters				*ChangeEventFilters		`json:"filters"`
+	Enabled				*bool						`json:"enabled"`
+	MaxRetries			*int						`json:"maxRetries"`
+	Timeout				*int						`json:"timeout"`
+}
+
+func ValidateCreateEventSubscriptionPayload(payload *CreateEventSubscriptionPayload) error {
+	endpoint, err := url.ParseRequestURI(payload.Endpoint)
+
+	if err != nil || (endpoint.Scheme != "http" && endpoint.Scheme != "https") || endpoint.Host == "" {
+		return errors.New("invalid endpoint")
+	}
+
+	if payload.Filters != nil {
+		for _, eventType := range payload.Filters.EventTypes {
+			if _, ok := EventType[eventType]; !ok {
+				return errors.New("invalid event type")
+			}
+		}
+	}
+
+	if payload.Enabled == nil {
+		v := true
+		payload.Enabled = &v
+	}
+
+	if payload.MaxRetries == nil {
+		v := DefaultMaxRetries
+		payload.MaxRetries = &v
+	} else if *payload.MaxRetries < 0 {
+		return errors.New("invalid maxRetries")
+	}
+
+	if payload.Timeout == nil {
+		v := DefaultTimeout
+		payload.Timeout = &v
+	} else if *payload.Timeout <= 0 {
+		return errors.New("invalid timeout")
+	}
+
+	return nil
+}
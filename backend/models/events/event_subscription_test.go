@@ -0,0 +1,41 @@
+package models
+
+import "testing"
+
+func TestValidateCreateEventSubscriptionPayload(t *testing.T) {
+	intPtr := func(v int) *int {
+		return &v
+	}
+
+	tests := []struct {
+		name string
+		payload *CreateEventSubscriptionPayload
+		expectedErr bool
+	}{
+		{ name: "defaults", payload: &CreateEventSubscriptionPayload{ Endpoint: "https://example.com/hook" } },
+		{ name: "relative endpoint", payload: &CreateEventSubscriptionPayload{ Endpoint: "/hook" }, expectedErr: true },
+		{ name: "not http", payload: &CreateEventSubscriptionPayload{ Endpoint: "ftp://example.com/hook" }, expectedErr: true },
+		{
+			name: "unknown event type",
+			payload: &CreateEventSubscriptionPayload{ Endpoint: "http://example.com", Filters: &ChangeEventFilters{ EventTypes: []string{"entityRenamed"} } },
+			expectedErr: true,
+		},
+		{ name: "no retries", payload: &CreateEventSubscriptionPayload{ Endpoint: "http://example.com", MaxRetries: intPtr(0) } },
+		{ name: "negative retries", payload: &CreateEventSubscriptionPayload{ Endpoint: "http://example.com", MaxRetries: intPtr(-1) }, expectedErr: true },
+		{ name: "zero timeout", payload: &CreateEventSubscriptionPayload{ Endpoint: "http://example.com", Timeout: intPtr(0) }, expectedErr: true },
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := ValidateCreateEventSubscriptionPayload(test.payload)
+
+			if (err != nil) != test.expectedErr {
+				t.Fatalf("ValidateCreateEventSubscriptionPayload() err = %v, expected an error: %v", err, test.expectedErr)
+			}
+
+			if err == nil && (test.payload.Enabled == nil || test.payload.MaxRetries == nil || test.payload.Timeout == nil) {
+				t.Errorf("defaults are not set: %+v", test.payload)
+			}
+		})
+	}
+}
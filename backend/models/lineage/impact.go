@@ -0,0 +1,95 @@
+package models
+
+import (
+	"errors"
+
+	dataModels "github.com/nambuitechx/go-metadata/models/data"
+	typeModels "github.com/nambuitechx/go-metadata/models/type"
+)
+
+// Column change type
+var ColumnChangeType = map[string]int {
+	"drop": 0,
+	"rename": 1,
+	"typeChange": 2,
+}
+
+// Reasons a stored procedure is impacted
+const (
+	ReadsTable = "readsTable"
+	WritesTable = "writesTable"
+)
+
+// Proposed change of a column, referenced by its name or fullyQualifiedName
+type ColumnChange struct {
+	ChangeType			string		`json:"changeType" binding:"required"`
+	Column				string		`json:"column" binding:"required"`
+	NewName				string		`json:"newName,omitempty"`
+	NewDataType			string		`json:"newDataType,omitempty"`
+}
+
+// Impact report
+type ImpactedColumn struct {
+	FullyQualifiedName	string			`json:"fullyQualifiedName"`
+	FromColumns			[]string		`json:"fromColumns"`
+	Function			string			`json:"function"`
+	// Changed columns of the analyzed table this column depends on
+	ImpactedBy			[]string		`json:"impactedBy"`
+}
+
+type ImpactedTable struct {
+	Table				*typeModels.EntityReference		`json:"table"`
+	TableType			string							`json:"tableType"`
+	Depth				int								`json:"depth"`
+	Columns				[]*ImpactedColumn				`json:"columns"`
+	// Set when the table is reached through edges without column lineage, so the impacted columns are unknown
+	ImpactedBy			[]string						`json:"impactedBy"`
+	Owners				[]*typeModels.EntityReference	`json:"owners"`
+}
+
+type ImpactedStoredProcedure struct {
+	StoredProcedure		*typeModels.EntityReference		`json:"storedProcedure"`
+	Reason				string							`json:"reason"`
+	Table				string							`json:"table"`
+	Owners				[]*typeModels.EntityReference	`json:"owners"`
+}
+
+type ImpactReport struct {
+	Table						*typeModels.EntityReference		`json:"table"`
+	Changes						[]*ColumnChange					`json:"changes"`
+	ImpactedTables				[]*ImpactedTable				`json:"impactedTables"`
+	ImpactedStoredProcedures	[]*ImpactedStoredProcedure		`json:"impactedStoredProcedures"`
+}
+
+// APIs
+type AnalyzeImpactPayload struct {
+	Changes				[]*ColumnChange		`json:"changes" binding:"required"`
+}
+
+func ValidateAnalyzeImpactPayload(payload *AnalyzeImpactPayload) error {
+	if len(payload.Changes) == 0 {
+		return errors.New("at least one column change is required")
+	}
+
+	for _, change := range payload.Changes {
+		if change == nil {
+			return errors.New("invalid column change")
+		}
+
+		if _, ok := ColumnChangeType[change.ChangeType]; !ok {
+			return errors.New("invalid column change type")
+		}
+
+		if change.ChangeType == "rename" && change.NewName == "" {
+			return errors.New("rename requires a newName")
+		}
+
+		if change.ChangeType == "typeChange" {
+			if _, ok := dataModels.DataType[change.NewDataType]; !ok {
+				return errors.New("typeChange requires a valid newDataType")
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,28 @@
+package models
+
+import "testing"
+
+func TestValidateAnalyzeImpactPayload(t *testing.T) {
+	tests := []struct {
+		name string
+		changes []*ColumnChange
+		expectedErr bool
+	}{
+		{ name: "no change", changes: []*ColumnChange{}, expectedErr: true },
+		{ name: "nil change", changes: []*ColumnChange{ nil }, expectedErr: true },
+		{ name: "drop", changes: []*ColumnChange{ { ChangeType: "drop", Column: "total" } } },
+		{ name: "unknown change type", changes: []*ColumnChange{ { ChangeType: "truncate", Column: "total" } }, expectedErr: true },
+		{ name: "rename", changes: []*ColumnChange{ { ChangeType: "rename", Column: "total", NewName: "amount" } } },
+		{ name: "rename without newName", changes: []*ColumnChange{ { ChangeType: "rename", Column: "total" } }, expectedErr: true },
+		{ name: "typeChange", changes: []*ColumnChange{ { ChangeType: "typeChange", Column: "total", NewDataType: "BIGINT" } } },
+		{ name: "typeChange to an unknown type", changes: []*ColumnChange{ { ChangeType: "typeChange", Column: "total", NewDataType: "MONEYBAGS" } }, expectedErr: true },
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := ValidateAnalyzeImpactPayload(&AnalyzeImpactPayload{ Changes: test.changes }); (err != nil) != test.expectedErr {
+				t.Errorf("ValidateAnalyzeImpactPayload() err = %v, expected an error: %v", err, test.expectedErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,184 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	typeModels "github.com/nambuitechx/go-metadata/models/type"
+)
+
+const (
+	LineageEntityType = "table"
+	LineagePipelineType = "storedProcedure"
+)
+
+// Depth of the lineage graph
+const (
+	DefaultLineageDepth = 1
+	MaxLineageDepth = 10
+)
+
+// Lineage source
+const (
+	ManualLineage = "Manual"
+	QueryLineage = "QueryLineage"
+	ViewLineage = "ViewLineage"
+)
+
+var ErrColumnNotFound = errors.New("column not found in table")
+
+// Lineage details, stored as the json of an upstream relationship
+type LineageDetails struct {
+	Pipeline			*typeModels.EntityReference		`json:"pipeline"`
+	SqlQuery			string							`json:"sqlQuery"`
+	Description			string							`json:"description"`
+	Source				string							`json:"source"`
+	ColumnsLineage		[]*ColumnLineage				`json:"columnsLineage"`
+
+	CreatedAt			int64							`json:"createdAt"`
+	CreatedBy			string							`json:"createdBy"`
+}
+
+func (s LineageDetails) Value() (driver.Value, error) {
+	return json.Marshal(s)
+}
+
+func (s *LineageDetails) Scan(value interface{}) error {
+	val, ok := value.([]byte)
+
+	if !ok {
+		return errors.New("type assertion to []byte failed")
+	}
+
+	return json.Unmarshal(val, &s)
+}
+
+// Column lineage, toColumn of the downstream table is computed from fromColumns of the upstream table.
+// Columns are referenced by their fullyQualifiedName
+type ColumnLineage struct {
+	FromColumns			[]string			`json:"fromColumns"`
+	ToColumn			string				`json:"toColumn"`
+	Function			string				`json:"function"`
+}
+
+// Lineage edge entity, an upstream relationship from one table to another
+type LineageEdgeEntity struct {
+	FromID				string				`db:"fromid" json:"fromId"`
+	ToID				string				`db:"toid" json:"toId"`
+	Json				*LineageDetails		`db:"json" json:"json"`
+}
+
+// Lineage graph
+type Edge struct {
+	FromEntity			string				`json:"fromEntity"`
+	ToEntity			string				`json:"toEntity"`
+	LineageDetails		*LineageDetails		`json:"lineageDetails"`
+}
+
+type EntityLineage struct {
+	Entity				*typeModels.EntityReference		`json:"entity"`
+	Nodes				[]*typeModels.EntityReference	`json:"nodes"`
+	UpstreamEdges		[]*Edge							`json:"upstreamEdges"`
+	DownstreamEdges		[]*Edge							`json:"downstreamEdges"`
+}
+
+// APIs
+type GetLineageByFqnParam struct {
+	FQN string	`uri:"fqn" binding:"required"`
+}
+
+type GetLineageQuery struct {
+	UpstreamDepth		*int	`form:"upstreamDepth"`
+	DownstreamDepth		*int	`form:"downstreamDepth"`
+	Column				string	`form:"column"`
+}
+
+func ValidateGetLineageQuery(query *GetLineageQuery) error {
+	if query.UpstreamDepth == nil {
+		v := DefaultLineageDepth
+		query.UpstreamDepth = &v
+	} else if *query.UpstreamDepth < 0 || *query.UpstreamDepth > MaxLineageDepth {
+		return errors.New("invalid upstreamDepth")
+	}
+
+	if query.DownstreamDepth == nil {
+		v := DefaultLineageDepth
+		query.DownstreamDepth = &v
+	} else if *query.DownstreamDepth < 0 || *query.DownstreamDepth > MaxLineageDepth {
+		return errors.New("invalid downstreamDepth")
+	}
+
+	return nil
+}
+
+type DeleteLineageParam struct {
+	FromEntity			string	`uri:"fromEntity" binding:"required"`
+	FromID				string	`uri:"fromId" binding:"required"`
+	ToEntity			string	`uri:"toEntity" binding:"required"`
+	ToID				string	`uri:"toId" binding:"required"`
+}
+
+func ValidateDeleteLineageParam(param *DeleteLineageParam) error {
+	if param.FromEntity != LineageEntityType || param.ToEntity != LineageEntityType {
+		return errors.New("lineage is only supported between tables")
+	}
+
+	return nil
+}
+
+// Entities are referenced by id or fullyQualifiedName
+type EntitiesEdge struct {
+	FromEntity			*typeModels.EntityReference		`json:"fromEntity" binding:"required"`
+	ToEntity			*typeModels.EntityReference		`json:"toEntity" binding:"required"`
+	LineageDetails		*LineageDetails					`json:"lineageDetails"`
+}
+
+type AddLineagePayload struct {
+	Edge				*EntitiesEdge		`json:"edge" binding:"required"`
+}
+
+func ValidateAddLineagePayload(payload *AddLineagePayload) error {
+	if err := validateEntityReference(payload.Edge.FromEntity, LineageEntityType); err != nil {
+		return err
+	}
+
+	if err := validateEntityReference(payload.Edge.ToEntity, LineageEntityType); err != nil {
+		return err
+	}
+
+	if payload.Edge.LineageDetails == nil {
+		payload.Edge.LineageDetails = &LineageDetails{}
+	}
+
+	if payload.Edge.LineageDetails.Pipeline != nil {
+		if err := validateEntityReference(payload.Edge.LineageDetails.Pipeline, LineagePipelineType); err != nil {
+			return err
+		}
+	}
+
+	for _, columnLineage := range payload.Edge.LineageDetails.ColumnsLineage {
+		if columnLineage == nil || columnLineage.ToColumn == "" {
+			return errors.New("column lineage requires a toColumn")
+		}
+
+		if len(columnLineage.FromColumns) == 0 {
+			return errors.New("column lineage requires at least one fromColumn")
+		}
+	}
+
+	return nil
+}
+
+func validateEntityReference(entityRef *typeModels.EntityReference, entityType string) error {
+	if entityRef.Type != entityType {
+		return fmt.Errorf("invalid entity type %v, expected %v", entityRef.Type, entityType)
+	}
+
+	if entityRef.ID == "" && entityRef.FullyQualifiedName == "" {
+		return errors.New("entity reference requires an id or a fullyQualifiedName")
+	}
+
+	return nil
+}
@@ -0,0 +1,114 @@
+package models
+
+import (
+	"testing"
+
+	typeModels "github.com/nambuitechx/go-metadata/models/type"
+)
+
+func TestValidateGetLineageQuery(t *testing.T) {
+	intPtr := func(v int) *int {
+		return &v
+	}
+
+	tests := []struct {
+		name string
+		query *GetLineageQuery
+		expectedUpstream int
+		expectedDownstream int
+		expectedErr bool
+	}{
+		{ name: "defaults", query: &GetLineageQuery{}, expectedUpstream: DefaultLineageDepth, expectedDownstream: DefaultLineageDepth },
+		{ name: "entity only", query: &GetLineageQuery{ UpstreamDepth: intPtr(0), DownstreamDepth: intPtr(0) }, expectedUpstream: 0, expectedDownstream: 0 },
+		{ name: "max depth", query: &GetLineageQuery{ UpstreamDepth: intPtr(MaxLineageDepth) }, expectedUpstream: MaxLineageDepth, expectedDownstream: DefaultLineageDepth },
+		{ name: "negative upstream", query: &GetLineageQuery{ UpstreamDepth: intPtr(-1) }, expectedErr: true },
+		{ name: "downstream too deep", query: &GetLineageQuery{ DownstreamDepth: intPtr(MaxLineageDepth + 1) }, expectedErr: true },
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := ValidateGetLineageQuery(test.query)
+
+			if (err != nil) != test.expectedErr {
+				t.Fatalf("ValidateGetLineageQuery() err = %v, expected an error: %v", err, test.expectedErr)
+			}
+
+			if err == nil && (*test.query.UpstreamDepth != test.expectedUpstream || *test.query.DownstreamDepth != test.expectedDownstream) {
+				t.Errorf("depths = %v, %v, expected %v, %v", *test.query.UpstreamDepth, *test.query.DownstreamDepth, test.expectedUpstream, test.expectedDownstream)
+			}
+		})
+	}
+}
+
+func TestValidateAddLineagePayload(t *testing.T) {
+	table := func(fqn string) *typeModels.EntityReference {
+		return &typeModels.EntityReference{ Type: LineageEntityType, FullyQualifiedName: fqn }
+	}
+
+	tests := []struct {
+		name string
+		edge *EntitiesEdge
+		expectedErr bool
+	}{
+		{ name: "tables by name", edge: &EntitiesEdge{ FromEntity: table("pg.shop.sales.orders"), ToEntity: &typeModels.EntityReference{ Type: LineageEntityType, ID: "1" } } },
+		{ name: "not a table", edge: &EntitiesEdge{ FromEntity: &typeModels.EntityReference{ Type: "database", ID: "1" }, ToEntity: table("pg.shop.sales.orders") }, expectedErr: true },
+		{ name: "reference without id or name", edge: &EntitiesEdge{ FromEntity: table(""), ToEntity: table("pg.shop.sales.orders") }, expectedErr: true },
+		{
+			name: "pipeline is not a stored procedure",
+			edge: &EntitiesEdge{ FromEntity: table("a"), ToEntity: table("b"), LineageDetails: &LineageDetails{ Pipeline: table("c") } },
+			expectedErr: true,
+		},
+		{
+			name: "column lineage",
+			edge: &EntitiesEdge{ FromEntity: table("a"), ToEntity: table("b"), LineageDetails: &LineageDetails{ ColumnsLineage: []*ColumnLineage{ { FromColumns: []string{"a.id"}, ToColumn: "b.id" } } } },
+		},
+		{
+			name: "column lineage without toColumn",
+			edge: &EntitiesEdge{ FromEntity: table("a"), ToEntity: table("b"), LineageDetails: &LineageDetails{ ColumnsLineage: []*ColumnLineage{ { FromColumns: []string{"a.id"} } } } },
+			expectedErr: true,
+		},
+		{
+			name: "column lineage without fromColumns",
+			edge: &EntitiesEdge{ FromEntity: table("a"), ToEntity: table("b"), LineageDetails: &LineageDetails{ ColumnsLineage: []*ColumnLineage{ { ToColumn: "b.id" } } } },
+			expectedErr: true,
+		},
+		{
+			name: "stored procedure pipeline",
+			edge: &EntitiesEdge{ FromEntity: table("a"), ToEntity: table("b"), LineageDetails: &LineageDetails{ Pipeline: &typeModels.EntityReference{ Type: LineagePipelineType, ID: "1" } } },
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			payload := &AddLineagePayload{ Edge: test.edge }
+			err := ValidateAddLineagePayload(payload)
+
+			if (err != nil) != test.expectedErr {
+				t.Fatalf("ValidateAddLineagePayload() err = %v, expected an error: %v", err, test.expectedErr)
+			}
+
+			if err == nil && payload.Edge.LineageDetails == nil {
+				t.Errorf("lineageDetails is not set")
+			}
+		})
+	}
+}
+
+func TestValidateDeleteLineageParam(t *testing.T) {
+	tests := []struct {
+		name string
+		param *DeleteLineageParam
+		expectedErr bool
+	}{
+		{ name: "tables", param: &DeleteLineageParam{ FromEntity: LineageEntityType, ToEntity: LineageEntityType } },
+		{ name: "not tables", param: &DeleteLineageParam{ FromEntity: LineageEntityType, ToEntity: "database" }, expectedErr: true },
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := ValidateDeleteLineageParam(test.param); (err != nil) != test.expectedErr {
+				t.Errorf("ValidateDeleteLineageParam() err = %v, expected an error: %v", err, test.expectedErr)
+			}
+		})
+	}
+}
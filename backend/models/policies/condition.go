@@ -0,0 +1,236 @@
+package models
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// Condition functions of a rule, combined with !, &&, || and parentheses, e.g. "isOwner() || noOwner()"
+const (
+	IsOwnerCondition = "isOwner()"
+	NoOwnerCondition = "noOwner()"
+)
+
+var ConditionFunctions = []string{"isOwner", "noOwner"}
+
+// Condition is a parsed rule condition, the functions are evaluated by the caller
+type Condition interface {
+	Evaluate(call func(function string) (bool, error)) (bool, error)
+}
+
+type orCondition struct {
+	operands []Condition
+}
+
+func (c *orCondition) Evaluate(call func(function string) (bool, error)) (bool, error) {
+	for _, operand := range c.operands {
+		ok, err := operand.Evaluate(call)
+
+		if err != nil || ok {
+			return ok, err
+		}
+	}
+
+	return false, nil
+}
+
+type andCondition struct {
+	operands []Condition
+}
+
+func (c *andCondition) Evaluate(call func(function string) (bool, error)) (bool, error) {
+	for _, operand := range c.operands {
+		ok, err := operand.Evaluate(call)
+
+		if err != nil || !ok {
+			return false, err
+		}
+	}
+
+	return true, nil
+}
+
+type notCondition struct {
+	operand Condition
+}
+
+func (c *notCondition) Evaluate(call func(function string) (bool, error)) (bool, error) {
+	ok, err := c.operand.Evaluate(call)
+	return !ok, err
+}
+
+type callCondition struct {
+	function string
+}
+
+func (c *callCondition) Evaluate(call func(function string) (bool, error)) (bool, error) {
+	return call(c.function)
+}
+
+// ParseCondition parses a rule condition, nil for an empty condition which always holds
+func ParseCondition(condition string) (Condition, error) {
+	if strings.TrimSpace(condition) == "" {
+		return nil, nil
+	}
+
+	p := &conditionParser{ tokens: tokenizeCondition(condition) }
+	c, err := p.parseOr()
+
+	if err != nil {
+		return nil, err
+	}
+
+	if p.position < len(p.tokens) {
+		return nil, fmt.Errorf("invalid condition %v: unexpected %v", condition, p.tokens[p.position])
+	}
+
+	return c, nil
+}
+
+func tokenizeCondition(condition string) []string {
+	tokens := []string{}
+	runes := []rune(condition)
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '&' || r == '|':
+			if i + 1 < len(runes) && runes[i + 1] == r {
+				tokens = append(tokens, string([]rune{r, r}))
+				i += 2
+			} else {
+				tokens = append(tokens, string(r))
+				i++
+			}
+		case unicode.IsLetter(r):
+			start := i
+
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i])) {
+				i++
+			}
+
+			tokens = append(tokens, string(runes[start:i]))
+		default:
+			tokens = append(tokens, string(r))
+			i++
+		}
+	}
+
+	return tokens
+}
+
+type conditionParser struct {
+	tokens []string
+	position int
+}
+
+func (p *conditionParser) peek() string {
+	if p.position < len(p.tokens) {
+		return p.tokens[p.position]
+	}
+
+	return ""
+}
+
+func (p *conditionParser) expect(token string) error {
+	if p.peek() != token {
+		return fmt.Errorf("invalid condition: expected %v", token)
+	}
+
+	p.position++
+	return nil
+}
+
+func (p *conditionParser) parseOr() (Condition, error) {
+	operands := []Condition{}
+
+	for {
+		c, err := p.parseAnd()
+
+		if err != nil {
+			return nil, err
+		}
+
+		operands = append(operands, c)
+
+		if p.peek() != "||" {
+			break
+		}
+
+		p.position++
+	}
+
+	if len(operands) == 1 {
+		return operands[0], nil
+	}
+
+	return &orCondition{ operands: operands }, nil
+}
+
+func (p *conditionParser) parseAnd() (Condition, error) {
+	operands := []Condition{}
+
+	for {
+		c, err := p.parseUnary()
+
+		if err != nil {
+			return nil, err
+		}
+
+		operands = append(operands, c)
+
+		if p.peek() != "&&" {
+			break
+		}
+
+		p.position++
+	}
+
+	if len(operands) == 1 {
+		return operands[0], nil
+	}
+
+	return &andCondition{ operands: operands }, nil
+}
+
+func (p *conditionParser) parseUnary() (Condition, error) {
+	token := p.peek()
+
+	switch {
+	case token == "!":
+		p.position++
+		c, err := p.parseUnary()
+
+		if err != nil {
+			return nil, err
+		}
+
+		return &notCondition{ operand: c }, nil
+	case token == "(":
+		p.position++
+		c, err := p.parseOr()
+
+		if err != nil {
+			return nil, err
+		}
+
+		return c, p.expect(")")
+	case slices.Contains(ConditionFunctions, token):
+		p.position++
+
+		if err := p.expect("("); err != nil {
+			return nil, err
+		}
+
+		return &callCondition{ function: token }, p.expect(")")
+	case token == "":
+		return nil, fmt.Errorf("invalid condition: unexpected end")
+	default:
+		return nil, fmt.Errorf("invalid condition: unknown function %v", token)
+	}
+}
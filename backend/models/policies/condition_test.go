@@ -0,0 +1,62 @@
+package models
+
+import "testing"
+
+func TestParseCondition(t *testing.T) {
+	tests := []struct {
+		name string
+		condition string
+		isOwner bool
+		noOwner bool
+		expected bool
+		expectedErr bool
+	}{
+		{ name: "empty", condition: " ", expected: true },
+		{ name: "call", condition: "isOwner()", isOwner: true, expected: true },
+		{ name: "false call", condition: "isOwner()", expected: false },
+		{ name: "not", condition: "!isOwner()", expected: true },
+		{ name: "or", condition: "isOwner() || noOwner()", noOwner: true, expected: true },
+		{ name: "and", condition: "isOwner() && noOwner()", isOwner: true, expected: false },
+		{ name: "and binds tighter than or", condition: "noOwner() || isOwner() && noOwner()", isOwner: true, expected: false },
+		{ name: "parentheses", condition: "!(isOwner() || noOwner())", expected: true },
+		{ name: "unknown function", condition: "isAdmin()", expectedErr: true },
+		{ name: "missing parenthesis", condition: "(isOwner() || noOwner()", expectedErr: true },
+		{ name: "unexpected end", condition: "isOwner() &&", expectedErr: true },
+		{ name: "trailing token", condition: "isOwner() noOwner()", expectedErr: true },
+		{ name: "single ampersand", condition: "isOwner() & noOwner()", expectedErr: true },
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			condition, err := ParseCondition(test.condition)
+
+			if (err != nil) != test.expectedErr {
+				t.Fatalf("ParseCondition(%q) err = %v, expected an error: %v", test.condition, err, test.expectedErr)
+			}
+
+			if err != nil {
+				return
+			}
+
+			ok := true
+
+			if condition != nil {
+				ok, err = condition.Evaluate(func(function string) (bool, error) {
+					if function == "isOwner" {
+						return test.isOwner, nil
+					}
+
+					return test.noOwner, nil
+				})
+
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if ok != test.expected {
+				t.Errorf("ParseCondition(%q) evaluates to %v, expected %v", test.condition, ok, test.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package models
+
+import (
+	"errors"
+	"strings"
+
+	baseModels "github.com/nambuitechx/go-metadata/models/base"
+)
+
+var ErrPermissionDenied = errors.New("permission denied")
+var ErrAdminOnly = errors.New("only admins can do this operation")
+var ErrUnknownResource = errors.New("unknown resource")
+
+// ResourceContext is what an operation is checked against, the resource type and the entity if there is one
+type ResourceContext struct {
+	Resource			string
+	EntityID			string
+}
+
+func NewResourceContext(resource string, entityId string) *ResourceContext {
+	return &ResourceContext{ Resource: resource, EntityID: entityId }
+}
+
+// Access to an operation
+const (
+	AllowAccess = "allow"
+	DenyAccess = "deny"
+	NotAllowAccess = "notAllow"
+)
+
+type Permission struct {
+	Operation			string		`json:"operation"`
+	Access				string		`json:"access"`
+	Policy				string		`json:"policy,omitempty"`
+	Rule				string		`json:"rule,omitempty"`
+}
+
+type ResourcePermission struct {
+	Resource			string			`json:"resource"`
+	EntityID			string			`json:"entityId,omitempty"`
+	Permissions			[]*Permission	`json:"permissions"`
+}
+
+// GetPatchOperations returns the operations a json patch needs, by the fields it changes
+func GetPatchOperations(patch []baseModels.JsonPatchOperation) []string {
+	operations := []string{}
+	seen := map[string]bool{}
+
+	for _, p := range patch {
+		field := strings.SplitN(strings.TrimPrefix(p.Path, "/"), "/", 2)[0]
+		operation := EditAllOperation
+
+		switch field {
+		case "description":
+			operation = EditDescriptionOperation
+		case "displayName":
+			operation = EditDisplayNameOperation
+		case "tags":
+			operation = EditTagsOperation
+		case "owners":
+			operation = EditOwnersOperation
+		case "extension":
+			operation = EditCustomFieldsOperation
+		}
+
+		if !seen[operation] {
+			seen[operation] = true
+			operations = append(operations, operation)
+		}
+	}
+
+	if len(operations) == 0 {
+		operations = append(operations, EditAllOperation)
+	}
+
+	return operations
+}
+
+// APIs
+type GetResourcePermissionParam struct {
+	Resource string	`uri:"resource" binding:"required"`
+}
+
+type GetEntityPermissionByFqnParam struct {
+	Resource string	`uri:"resource" binding:"required"`
+	FQN string	`uri:"fqn" binding:"required"`
+}
+
+type GetEntityPermissionByIdParam struct {
+	Resource string	`uri:"resource" binding:"required"`
+	ID string	`uri:"id" binding:"required"`
+}
@@ -0,0 +1,36 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+
+	baseModels "github.com/nambuitechx/go-metadata/models/base"
+)
+
+func TestGetPatchOperations(t *testing.T) {
+	tests := []struct {
+		name string
+		paths []string
+		expected []string
+	}{
+		{ name: "no operation", paths: []string{}, expected: []string{EditAllOperation} },
+		{ name: "description", paths: []string{"/description"}, expected: []string{EditDescriptionOperation} },
+		{ name: "nested path", paths: []string{"/tags/0", "/owners/1/id"}, expected: []string{EditTagsOperation, EditOwnersOperation} },
+		{ name: "each operation once", paths: []string{"/displayName", "/displayName"}, expected: []string{EditDisplayNameOperation} },
+		{ name: "other field", paths: []string{"/extension/a", "/columns/0/name"}, expected: []string{EditCustomFieldsOperation, EditAllOperation} },
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			patch := []baseModels.JsonPatchOperation{}
+
+			for _, path := range test.paths {
+				patch = append(patch, baseModels.JsonPatchOperation{ Op: "replace", Path: path })
+			}
+
+			if operations := GetPatchOperations(patch); !reflect.DeepEqual(operations, test.expected) {
+				t.Errorf("GetPatchOperations() = %v, expected %v", operations, test.expected)
+			}
+		})
+	}
+}
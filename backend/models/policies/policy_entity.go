@@ -0,0 +1,290 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+
+	typeModels "github.com/nambuitechx/go-metadata/models/type"
+)
+
+// Policy entity
+type PolicyEntity struct {
+	ID					string				`db:"id" json:"id"`
+	Name				string				`db:"name" json:"name"`
+	Json				*Policy				`db:"json" json:"json"`
+	UpdatedAt			int64				`db:"updatedat" json:"updatedAt"`
+	UpdatedBy			string				`db:"updatedby" json:"updatedBy"`
+	Deleted				bool				`db:"deleted" json:"deleted"`
+	NameHash			string				`db:"namehash" json:"nameHash"`
+}
+
+// Policy, a set of rules granted to users through their roles
+type Policy struct {
+	ID					string						`json:"id"`
+	Name				string						`json:"name"`
+	FullyQualifiedName	string						`json:"fullyQualifiedName"`
+
+	DisplayName			string						`json:"displayName"`
+	Description			string						`json:"description"`
+
+	Rules				[]*Rule						`json:"rules"`
+
+	Version				float64						`json:"version"`
+	UpdatedAt			int64						`json:"updatedAt"`
+	UpdatedBy			string						`json:"updatedBy"`
+	ChangeDescription	*typeModels.ChangeDescription	`json:"changeDescription,omitempty"`
+
+	Deleted				bool						`json:"deleted"`
+}
+
+func (s Policy) Value() (driver.Value, error) {
+	return json.Marshal(s)
+}
+
+func (s *Policy) Scan(value interface{}) error {
+	val, ok := value.([]byte)
+
+	if !ok {
+		return errors.New("type assertion to []byte failed")
+	}
+
+	return json.Unmarshal(val, &s)
+}
+
+func (s *Policy) ToEntityReference() *typeModels.EntityReference {
+	entityRef := &typeModels.EntityReference{
+		ID: s.ID,
+		Type: "policy",
+		Name: s.Name,
+		FullyQualifiedName: s.FullyQualifiedName,
+		DisplayName: s.DisplayName,
+		Description: s.Description,
+		Deleted: s.Deleted,
+	}
+
+	return entityRef
+}
+
+// Rule allows or denies operations on resources, when its condition holds. An empty condition always holds
+type Rule struct {
+	Name				string			`json:"name"`
+	Description			string			`json:"description,omitempty"`
+	Resources			[]string		`json:"resources"`
+	Operations			[]string		`json:"operations"`
+	Effect				string			`json:"effect"`
+	Condition			string			`json:"condition,omitempty"`
+}
+
+// Rule effects, a matching deny rule wins over any allow rule
+const (
+	AllowEffect = "allow"
+	DenyEffect = "deny"
+)
+
+// Resources, "all" matches every resource
+const (
+	AllResources = "all"
+	DBServiceResource = "databaseService"
+	DatabaseResource = "database"
+	DatabaseSchemaResource = "databaseSchema"
+	TableResource = "table"
+	StoredProcedureResource = "storedProcedure"
+	WorkflowResource = "workflow"
+	TestConnectionDefinitionResource = "testConnectionDefinition"
+)
+
+var Resources = []string{
+	DBServiceResource,
+	DatabaseResource,
+	DatabaseSchemaResource,
+	TableResource,
+	StoredProcedureResource,
+	WorkflowResource,
+	TestConnectionDefinitionResource,
+}
+
+// Operations, "All" matches every operation, "EditAll" every Edit operation and "ViewAll" ViewBasic
+const (
+	AllOperations = "All"
+	CreateOperation = "Create"
+	DeleteOperation = "Delete"
+	ViewAllOperation = "ViewAll"
+	ViewBasicOperation = "ViewBasic"
+	EditAllOperation = "EditAll"
+	EditDescriptionOperation = "EditDescription"
+	EditDisplayNameOperation = "EditDisplayName"
+	EditTagsOperation = "EditTags"
+	EditOwnersOperation = "EditOwners"
+	EditCustomFieldsOperation = "EditCustomFields"
+	TriggerOperation = "Trigger"
+	ViewSecretsOperation = "ViewSecrets"
+	EditLineageOperation = "EditLineage"
+)
+
+var Operations = []string{
+	CreateOperation,
+	DeleteOperation,
+	ViewAllOperation,
+	ViewBasicOperation,
+	EditAllOperation,
+	EditDescriptionOperation,
+	EditDisplayNameOperation,
+	EditTagsOperation,
+	EditOwnersOperation,
+	EditCustomFieldsOperation,
+	TriggerOperation,
+	ViewSecretsOperation,
+	EditLineageOperation,
+}
+
+func (r *Rule) MatchResource(resource string) bool {
+	return slices.Contains(r.Resources, AllResources) || slices.Contains(r.Resources, resource)
+}
+
+func (r *Rule) MatchOperation(operation string) bool {
+	for _, o := range r.Operations {
+		if o == AllOperations || o == operation {
+			return true
+		}
+
+		if o == EditAllOperation && IsEditOperation(operation) {
+			return true
+		}
+
+		if o == ViewAllOperation && operation == ViewBasicOperation {
+			return true
+		}
+	}
+
+	return false
+}
+
+func IsEditOperation(operation string) bool {
+	return len(operation) > 4 && operation[:4] == "Edit"
+}
+
+func ValidateRule(rule *Rule) error {
+	if rule.Name == "" {
+		return errors.New("rule name is required")
+	}
+
+	if rule.Effect != AllowEffect && rule.Effect != DenyEffect {
+		return fmt.Errorf("rule %v: invalid effect %v", rule.Name, rule.Effect)
+	}
+
+	if len(rule.Resources) == 0 || len(rule.Operations) == 0 {
+		return fmt.Errorf("rule %v: resources and operations are required", rule.Name)
+	}
+
+	for _, resource := range rule.Resources {
+		if resource != AllResources && !slices.Contains(Resources, resource) {
+			return fmt.Errorf("rule %v: invalid resource %v", rule.Name, resource)
+		}
+	}
+
+	for _, operation := range rule.Operations {
+		if operation != AllOperations && !slices.Contains(Operations, operation) {
+			return fmt.Errorf("rule %v: invalid operation %v", rule.Name, operation)
+		}
+	}
+
+	if _, err := ParseCondition(rule.Condition); err != nil {
+		return fmt.Errorf("rule %v: %v", rule.Name, err)
+	}
+
+	return nil
+}
+
+// Default policies, created on start
+const (
+	DataConsumerPolicy = "DataConsumerPolicy"
+	DataStewardPolicy = "DataStewardPolicy"
+	IngestionBotPolicy = "IngestionBotPolicy"
+)
+
+var DefaultPolicies = []*CreatePolicyPayload{
+	{
+		Name: DataConsumerPolicy,
+		Description: "Everyone can view every asset, owners can do anything with theirs",
+		Rules: []*Rule{
+			{ Name: "DataConsumerPolicy-ViewAll", Resources: []string{AllResources}, Operations: []string{ViewAllOperation}, Effect: AllowEffect },
+			{ Name: "DataConsumerPolicy-Owner", Resources: []string{AllResources}, Operations: []string{AllOperations}, Effect: AllowEffect, Condition: IsOwnerCondition },
+		},
+	},
+	{
+		Name: DataStewardPolicy,
+		Description: "Data stewards curate the description, display name and tags of every asset",
+		Rules: []*Rule{
+			{
+				Name: "DataStewardPolicy-Curate",
+				Resources: []string{AllResources},
+				Operations: []string{ViewAllOperation, EditDescriptionOperation, EditDisplayNameOperation, EditTagsOperation},
+				Effect: AllowEffect,
+			},
+		},
+	},
+	{
+		Name: IngestionBotPolicy,
+		Description: "Ingestion bots create and update the assets of the services they ingest",
+		Rules: []*Rule{
+			{
+				Name: "IngestionBotPolicy-Ingest",
+				Resources: []string{AllResources},
+				Operations: []string{ViewAllOperation, CreateOperation, EditAllOperation, TriggerOperation, ViewSecretsOperation},
+				Effect: AllowEffect,
+			},
+		},
+	},
+}
+
+// APIs
+type GetPolicyEntitiesQuery struct {
+	Limit int	`form:"limit"`
+	Before string	`form:"before"`
+	After string	`form:"after"`
+	Include string	`form:"include"`
+}
+
+type GetPolicyEntityByIdParam struct {
+	ID string	`uri:"id" binding:"required"`
+}
+
+type GetPolicyEntityByNameParam struct {
+	Name string	`uri:"name" binding:"required"`
+}
+
+type CreatePolicyPayload struct {
+	Name				string		`json:"name" binding:"required"`
+	DisplayName			string		`json:"displayName"`
+	Description			string		`json:"description"`
+	Rules				[]*Rule		`json:"rules" binding:"required"`
+}
+
+func ValidateCreatePolicyPayload(payload *CreatePolicyPayload) error {
+	if len(payload.Rules) == 0 {
+		return errors.New("a policy needs at least one rule")
+	}
+
+	names := map[string]bool{}
+
+	for _, rule := range payload.Rules {
+		if rule == nil {
+			return errors.New("invalid rule")
+		}
+
+		if err := ValidateRule(rule); err != nil {
+			return err
+		}
+
+		if names[rule.Name] {
+			return fmt.Errorf("duplicate rule %v", rule.Name)
+		}
+
+		names[rule.Name] = true
+	}
+
+	return nil
+}
@@ -0,0 +1,92 @@
+package models
+
+import "testing"
+
+func TestMatchOperation(t *testing.T) {
+	tests := []struct {
+		name string
+		operations []string
+		operation string
+		expected bool
+	}{
+		{ name: "same operation", operations: []string{CreateOperation}, operation: CreateOperation, expected: true },
+		{ name: "other operation", operations: []string{CreateOperation}, operation: DeleteOperation, expected: false },
+		{ name: "all", operations: []string{AllOperations}, operation: ViewSecretsOperation, expected: true },
+		{ name: "edit all covers an edit", operations: []string{EditAllOperation}, operation: EditOwnersOperation, expected: true },
+		{ name: "edit all covers edit lineage", operations: []string{EditAllOperation}, operation: EditLineageOperation, expected: true },
+		{ name: "edit all does not cover delete", operations: []string{EditAllOperation}, operation: DeleteOperation, expected: false },
+		{ name: "view all covers view basic", operations: []string{ViewAllOperation}, operation: ViewBasicOperation, expected: true },
+		{ name: "view basic does not cover view all", operations: []string{ViewBasicOperation}, operation: ViewAllOperation, expected: false },
+		{ name: "view all does not cover view secrets", operations: []string{ViewAllOperation}, operation: ViewSecretsOperation, expected: false },
+		{ name: "any of the operations", operations: []string{DeleteOperation, TriggerOperation}, operation: TriggerOperation, expected: true },
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rule := &Rule{ Operations: test.operations }
+
+			if matched := rule.MatchOperation(test.operation); matched != test.expected {
+				t.Errorf("MatchOperation(%v) = %v, expected %v", test.operation, matched, test.expected)
+			}
+		})
+	}
+}
+
+func TestMatchResource(t *testing.T) {
+	tests := []struct {
+		name string
+		resources []string
+		resource string
+		expected bool
+	}{
+		{ name: "same resource", resources: []string{TableResource}, resource: TableResource, expected: true },
+		{ name: "other resource", resources: []string{TableResource}, resource: DatabaseResource, expected: false },
+		{ name: "all", resources: []string{AllResources}, resource: WorkflowResource, expected: true },
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rule := &Rule{ Resources: test.resources }
+
+			if matched := rule.MatchResource(test.resource); matched != test.expected {
+				t.Errorf("MatchResource(%v) = %v, expected %v", test.resource, matched, test.expected)
+			}
+		})
+	}
+}
+
+func TestValidateCreatePolicyPayload(t *testing.T) {
+	rule := func(name string, effect string, operation string, condition string) *Rule {
+		return &Rule{ Name: name, Resources: []string{TableResource}, Operations: []string{operation}, Effect: effect, Condition: condition }
+	}
+
+	tests := []struct {
+		name string
+		rules []*Rule
+		expectedErr bool
+	}{
+		{ name: "valid", rules: []*Rule{ rule("owner", AllowEffect, EditAllOperation, "isOwner()"), rule("view", AllowEffect, ViewAllOperation, "") } },
+		{ name: "no rule", rules: []*Rule{}, expectedErr: true },
+		{ name: "nil rule", rules: []*Rule{nil}, expectedErr: true },
+		{ name: "no rule name", rules: []*Rule{ rule("", AllowEffect, EditAllOperation, "") }, expectedErr: true },
+		{ name: "invalid effect", rules: []*Rule{ rule("owner", "maybe", EditAllOperation, "") }, expectedErr: true },
+		{ name: "invalid operation", rules: []*Rule{ rule("owner", AllowEffect, "Rename", "") }, expectedErr: true },
+		{ name: "invalid condition", rules: []*Rule{ rule("owner", AllowEffect, EditAllOperation, "isOwner(") }, expectedErr: true },
+		{ name: "duplicate rule", rules: []*Rule{ rule("owner", AllowEffect, EditAllOperation, ""), rule("owner", DenyEffect, DeleteOperation, "") }, expectedErr: true },
+		{
+			name: "invalid resource",
+			rules: []*Rule{ { Name: "owner", Resources: []string{"dashboard"}, Operations: []string{CreateOperation}, Effect: AllowEffect } },
+			expectedErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			payload := &CreatePolicyPayload{ Name: "policy", Rules: test.rules }
+
+			if err := ValidateCreatePolicyPayload(payload); (err != nil) != test.expectedErr {
+				t.Errorf("ValidateCreatePolicyPayload() err = %v, expected an error: %v", err, test.expectedErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,346 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"strings"
+
+	securityModels "github.com/nambuitechx/go-metadata/models/security"
+	typeModels "github.com/nambuitechx/go-metadata/models/type"
+)
+
+// Search index, the entity type of an index and the table its entities are stored in
+var SearchIndex = map[string]string {
+	"databaseService": "dbservice_entity",
+	"database": "database_entity",
+	"databaseSchema": "database_schema_entity",
+	"table": "table_entity",
+	"storedProcedure": "stored_procedure_entity",
+}
+
+// Indexes searched when none is given, in the order of SearchIndex
+var DefaultSearchIndexes = []string{ "databaseService", "database", "databaseSchema", "table", "storedProcedure" }
+
+// Facets counted over every hit of a query
+var SearchFacets = []string{ "entityType", "service", "serviceType", "tableType" }
+
+const (
+	DefaultSearchSize = 10
+	MaxSearchSize = 100
+)
+
+// Search indexer
+const (
+	PostgresSearchIndexer = "postgres"
+	MemorySearchIndexer = "memory"
+)
+
+var ErrReindexRunning = errors.New("a reindex is already running")
+
+// Reindex
+const (
+	DefaultReindexBatchSize = 100
+	MaxReindexBatchSize = 1000
+)
+
+// Reindex status
+const (
+	ReindexRunning = "running"
+	ReindexCompleted = "completed"
+	ReindexFailed = "failed"
+)
+
+// Search source, the json of the entity
+type SearchSource map[string]interface{}
+
+func (s SearchSource) Value() (driver.Value, error) {
+	return json.Marshal(s)
+}
+
+func (s *SearchSource) Scan(value interface{}) error {
+	val, ok := value.([]byte)
+
+	if !ok {
+		return errors.New("type assertion to []byte failed")
+	}
+
+	return json.Unmarshal(val, &s)
+}
+
+// Search highlights, the fragments of each matched field with the matched words wrapped in <em></em>.
+// Fields are name, displayName, description, columns.name and columns.description
+type SearchHighlights map[string][]string
+
+func (s SearchHighlights) Value() (driver.Value, error) {
+	return json.Marshal(s)
+}
+
+func (s *SearchHighlights) Scan(value interface{}) error {
+	val, ok := value.([]byte)
+
+	if !ok {
+		return errors.New("type assertion to []byte failed")
+	}
+
+	return json.Unmarshal(val, &s)
+}
+
+// Search document, the entity as it is written to a search index
+type SearchDocument struct {
+	EntityType			string				`json:"entityType"`
+	ID					string				`json:"id"`
+	FullyQualifiedName	string				`json:"fullyQualifiedName"`
+	Deleted				bool				`json:"deleted"`
+	Source				SearchSource		`json:"source"`
+}
+
+// NewSearchDocument builds the document of an entity from its json, without the secrets of its connection
+func NewSearchDocument(entityType string, id string, entity interface{}) (*SearchDocument, error) {
+	bytes, err := json.Marshal(entity)
+
+	if err != nil {
+		return nil, err
+	}
+
+	source := SearchSource{}
+
+	if err := json.Unmarshal(bytes, &source); err != nil {
+		return nil, err
+	}
+
+	securityModels.MaskJsonSecrets(map[string]interface{}(source))
+
+	document := &SearchDocument{ EntityType: entityType, ID: id, Source: source }
+	document.FullyQualifiedName, _ = source["fullyQualifiedName"].(string)
+	document.Deleted, _ = source["deleted"].(bool)
+
+	return document, nil
+}
+
+// Search entity row, an entity read from its table to be reindexed
+type SearchEntityRow struct {
+	ID					string				`db:"id"`
+	Json				SearchSource		`db:"json"`
+}
+
+type SearchHit struct {
+	EntityType			string				`db:"entitytype" json:"entityType"`
+	ID					string				`db:"id" json:"id"`
+	Score				float64				`db:"score" json:"score"`
+	Source				SearchSource		`db:"json" json:"source"`
+	Highlights			SearchHighlights	`db:"highlights" json:"highlights"`
+}
+
+type SearchFacetCount struct {
+	Facet				string				`db:"facet" json:"-"`
+	Value				string				`db:"value" json:"value"`
+	Count				int					`db:"count" json:"count"`
+}
+
+type SearchResult struct {
+	Query				string									`json:"query"`
+	Hits				[]*SearchHit							`json:"hits"`
+	Facets				map[string][]*SearchFacetCount			`json:"facets"`
+	From				int										`json:"from"`
+	Size				int										`json:"size"`
+	Total				int										`json:"total"`
+}
+
+func NewSearchResult(q string, from int, size int) *SearchResult {
+	result := &SearchResult{
+		Query: q,
+		Hits: []*SearchHit{},
+		Facets: map[string][]*SearchFacetCount{},
+		From: from,
+		Size: size,
+	}
+
+	for _, facet := range SearchFacets {
+		result.Facets[facet] = []*SearchFacetCount{}
+	}
+
+	return result
+}
+
+// AddFacetCounts groups the counts by facet, the total is the sum of the entity type counts since every hit has
+// exactly one entity type
+func (s *SearchResult) AddFacetCounts(facetCounts []*SearchFacetCount) {
+	for _, facetCount := range facetCounts {
+		s.Facets[facetCount.Facet] = append(s.Facets[facetCount.Facet], facetCount)
+
+		if facetCount.Facet == "entityType" {
+			s.Total += facetCount.Count
+		}
+	}
+}
+
+// FilterHits keeps the hits allowed by position, the total and the facets still count every matching entity
+func (s *SearchResult) FilterHits(allowed []bool) {
+	hits := []*SearchHit{}
+
+	for i, hit := range s.Hits {
+		if allowed[i] {
+			hits = append(hits, hit)
+		}
+	}
+
+	s.Hits = hits
+}
+
+// Search suggestion, an entity reference ranked by how closely its name or fullyQualifiedName matches the input.
+// Columns are scanned into the reference by the lower cased names of its fields
+type SearchSuggestion struct {
+	typeModels.EntityReference
+	Score				float64				`db:"score" json:"score"`
+}
+
+// FilterSuggestions keeps the suggestions allowed by position
+func FilterSuggestions(suggestions []*SearchSuggestion, allowed []bool) []*SearchSuggestion {
+	filtered := []*SearchSuggestion{}
+
+	for i, suggestion := range suggestions {
+		if allowed[i] {
+			filtered = append(filtered, suggestion)
+		}
+	}
+
+	return filtered
+}
+
+// Reindex progress of an entity type
+type ReindexProgress struct {
+	Total				int					`json:"total"`
+	Indexed				int					`json:"indexed"`
+}
+
+type ReindexStatus struct {
+	ID					string							`json:"id"`
+	Status				string							`json:"status"`
+	EntityTypes			[]string						`json:"entityTypes"`
+	BatchSize			int								`json:"batchSize"`
+	Progress			map[string]*ReindexProgress		`json:"progress"`
+	Total				int								`json:"total"`
+	Indexed				int								`json:"indexed"`
+	StartedAt			int64							`json:"startedAt"`
+	CompletedAt			int64							`json:"completedAt,omitempty"`
+	Failure				string							`json:"failure,omitempty"`
+}
+
+// Copy returns a copy of the status that is safe to read while the reindex goes on
+func (s *ReindexStatus) Copy() *ReindexStatus {
+	status := *s
+	status.EntityTypes = append([]string{}, s.EntityTypes...)
+	status.Progress = map[string]*ReindexProgress{}
+
+	for entityType, progress := range s.Progress {
+		p := *progress
+		status.Progress[entityType] = &p
+	}
+
+	return &status
+}
+
+// APIs
+type SearchQuery struct {
+	Q					string		`form:"q" binding:"required"`
+	Index				string		`form:"index"`
+	From				int			`form:"from"`
+	Size				int			`form:"size"`
+}
+
+// ValidateSearchQuery applies the defaults and returns the searched indexes
+func ValidateSearchQuery(query *SearchQuery) ([]string, error) {
+	if strings.TrimSpace(query.Q) == "" {
+		return nil, errors.New("q cannot be empty")
+	}
+
+	if query.From < 0 {
+		return nil, errors.New("from cannot be negative")
+	}
+
+	if query.Size == 0 {
+		query.Size = DefaultSearchSize
+	}
+
+	if query.Size < 0 || query.Size > MaxSearchSize {
+		return nil, errors.New("size must be between 1 and 100")
+	}
+
+	return parseSearchIndexes(query.Index)
+}
+
+type SuggestQuery struct {
+	Q					string		`form:"q" binding:"required"`
+	Type				string		`form:"type"`
+	Size				int			`form:"size"`
+}
+
+// ValidateSuggestQuery applies the defaults and returns the suggested entity types
+func ValidateSuggestQuery(query *SuggestQuery) ([]string, error) {
+	query.Q = strings.ToLower(strings.TrimSpace(query.Q))
+
+	if query.Q == "" {
+		return nil, errors.New("q cannot be empty")
+	}
+
+	if query.Size == 0 {
+		query.Size = DefaultSearchSize
+	}
+
+	if query.Size < 0 || query.Size > MaxSearchSize {
+		return nil, errors.New("size must be between 1 and 100")
+	}
+
+	return parseSearchIndexes(query.Type)
+}
+
+// parseSearchIndexes reads a comma separated list of indexes, all of them when it is empty
+func parseSearchIndexes(value string) ([]string, error) {
+	if value == "" {
+		return DefaultSearchIndexes, nil
+	}
+
+	indexes := []string{}
+	seen := map[string]bool{}
+
+	for _, index := range strings.Split(value, ",") {
+		index = strings.TrimSpace(index)
+
+		if _, ok := SearchIndex[index]; !ok {
+			return nil, errors.New("invalid index " + index)
+		}
+
+		if !seen[index] {
+			seen[index] = true
+			indexes = append(indexes, index)
+		}
+	}
+
+	return indexes, nil
+}
+
+type ReindexPayload struct {
+	EntityTypes			[]string	`json:"entityTypes"`
+	BatchSize			int			`json:"batchSize"`
+}
+
+// ValidateReindexPayload applies the defaults, all entity types in batches of 100
+func ValidateReindexPayload(payload *ReindexPayload) error {
+	if payload.BatchSize == 0 {
+		payload.BatchSize = DefaultReindexBatchSize
+	}
+
+	if payload.BatchSize < 0 || payload.BatchSize > MaxReindexBatchSize {
+		return errors.New("batchSize must be between 1 and 1000")
+	}
+
+	entityTypes, err := parseSearchIndexes(strings.Join(payload.EntityTypes, ","))
+
+	if err != nil {
+		return err
+	}
+
+	payload.EntityTypes = entityTypes
+	return nil
+}
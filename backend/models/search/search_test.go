@@ -0,0 +1,230 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+
+	securityModels "github.com/nambuitechx/go-metadata/models/security"
+	typeModels "github.com/nambuitechx/go-metadata/models/type"
+)
+
+func TestValidateSearchQuery(t *testing.T) {
+	tests := []struct {
+		name string
+		query *SearchQuery
+		expected []string
+		expectedSize int
+		expectedErr bool
+	}{
+		{ name: "defaults", query: &SearchQuery{ Q: "orders" }, expected: DefaultSearchIndexes, expectedSize: DefaultSearchSize },
+		{ name: "blank query", query: &SearchQuery{ Q: "  " }, expectedErr: true },
+		{ name: "negative from", query: &SearchQuery{ Q: "orders", From: -1 }, expectedErr: true },
+		{ name: "size too large", query: &SearchQuery{ Q: "orders", Size: MaxSearchSize + 1 }, expectedErr: true },
+		{ name: "indexes deduplicated", query: &SearchQuery{ Q: "orders", Index: "table, database,table", Size: 5 }, expected: []string{"table", "database"}, expectedSize: 5 },
+		{ name: "unknown index", query: &SearchQuery{ Q: "orders", Index: "table,pipeline" }, expectedErr: true },
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			indexes, err := ValidateSearchQuery(test.query)
+
+			if (err != nil) != test.expectedErr {
+				t.Fatalf("ValidateSearchQuery() err = %v, expected an error: %v", err, test.expectedErr)
+			}
+
+			if err != nil {
+				return
+			}
+
+			if !reflect.DeepEqual(indexes, test.expected) || test.query.Size != test.expectedSize {
+				t.Errorf("ValidateSearchQuery() = %v with size %v, expected %v with size %v", indexes, test.query.Size, test.expected, test.expectedSize)
+			}
+		})
+	}
+}
+
+func TestAddFacetCounts(t *testing.T) {
+	result := NewSearchResult("orders", 0, 10)
+	result.AddFacetCounts([]*SearchFacetCount{
+		{ Facet: "entityType", Value: "table", Count: 3 },
+		{ Facet: "entityType", Value: "storedProcedure", Count: 1 },
+		{ Facet: "service", Value: "pg", Count: 4 },
+		{ Facet: "tableType", Value: "Regular", Count: 2 },
+	})
+
+	tests := []struct {
+		facet string
+		expected []string
+	}{
+		{ facet: "entityType", expected: []string{"table", "storedProcedure"} },
+		{ facet: "service", expected: []string{"pg"} },
+		{ facet: "serviceType", expected: []string{} },
+		{ facet: "tableType", expected: []string{"Regular"} },
+	}
+
+	for _, test := range tests {
+		values := []string{}
+
+		for _, facetCount := range result.Facets[test.facet] {
+			values = append(values, facetCount.Value)
+		}
+
+		if !reflect.DeepEqual(values, test.expected) {
+			t.Errorf("facet %v = %v, expected %v", test.facet, values, test.expected)
+		}
+	}
+
+	if result.Total != 4 {
+		t.Errorf("total = %v, expected the sum of the entity type counts 4", result.Total)
+	}
+}
+
+func TestValidateSuggestQuery(t *testing.T) {
+	tests := []struct {
+		name string
+		query *SuggestQuery
+		expectedQ string
+		expected []string
+		expectedErr bool
+	}{
+		{ name: "input is trimmed and lower cased", query: &SuggestQuery{ Q: " Ord " }, expectedQ: "ord", expected: DefaultSearchIndexes },
+		{ name: "blank input", query: &SuggestQuery{ Q: " " }, expectedErr: true },
+		{ name: "negative size", query: &SuggestQuery{ Q: "ord", Size: -1 }, expectedErr: true },
+		{ name: "types", query: &SuggestQuery{ Q: "ord", Type: "table,storedProcedure" }, expectedQ: "ord", expected: []string{"table", "storedProcedure"} },
+		{ name: "unknown type", query: &SuggestQuery{ Q: "ord", Type: "dashboard" }, expectedErr: true },
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			types, err := ValidateSuggestQuery(test.query)
+
+			if (err != nil) != test.expectedErr {
+				t.Fatalf("ValidateSuggestQuery() err = %v, expected an error: %v", err, test.expectedErr)
+			}
+
+			if err != nil {
+				return
+			}
+
+			if test.query.Q != test.expectedQ || !reflect.DeepEqual(types, test.expected) || test.query.Size != DefaultSearchSize {
+				t.Errorf("ValidateSuggestQuery() = %q %v size %v, expected %q %v", test.query.Q, types, test.query.Size, test.expectedQ, test.expected)
+			}
+		})
+	}
+}
+
+func TestValidateReindexPayload(t *testing.T) {
+	tests := []struct {
+		name string
+		payload *ReindexPayload
+		expected *ReindexPayload
+		expectedErr bool
+	}{
+		{ name: "defaults", payload: &ReindexPayload{}, expected: &ReindexPayload{ EntityTypes: DefaultSearchIndexes, BatchSize: DefaultReindexBatchSize } },
+		{
+			name: "entity types",
+			payload: &ReindexPayload{ EntityTypes: []string{"table"}, BatchSize: 10 },
+			expected: &ReindexPayload{ EntityTypes: []string{"table"}, BatchSize: 10 },
+		},
+		{ name: "negative batch size", payload: &ReindexPayload{ BatchSize: -1 }, expectedErr: true },
+		{ name: "batch size too large", payload: &ReindexPayload{ BatchSize: MaxReindexBatchSize + 1 }, expectedErr: true },
+		{ name: "unknown entity type", payload: &ReindexPayload{ EntityTypes: []string{"dashboard"} }, expectedErr: true },
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := ValidateReindexPayload(test.payload)
+
+			if (err != nil) != test.expectedErr {
+				t.Fatalf("ValidateReindexPayload() err = %v, expected an error: %v", err, test.expectedErr)
+			}
+
+			if err == nil && !reflect.DeepEqual(test.payload, test.expected) {
+				t.Errorf("ValidateReindexPayload() = %+v, expected %+v", test.payload, test.expected)
+			}
+		})
+	}
+}
+
+func TestFilterHits(t *testing.T) {
+	tests := []struct {
+		name string
+		allowed []bool
+		expected []string
+	}{
+		{ name: "all allowed", allowed: []bool{true, true, true}, expected: []string{"1", "2", "3"} },
+		{ name: "by position", allowed: []bool{false, true, false}, expected: []string{"2"} },
+		{ name: "none allowed", allowed: []bool{false, false, false}, expected: []string{} },
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result := NewSearchResult("orders", 0, 10)
+			result.Total = 3
+			suggestions := []*SearchSuggestion{}
+
+			for _, id := range []string{"1", "2", "3"} {
+				result.Hits = append(result.Hits, &SearchHit{ ID: id })
+				suggestions = append(suggestions, &SearchSuggestion{ EntityReference: typeModels.EntityReference{ ID: id } })
+			}
+
+			result.FilterHits(test.allowed)
+			hits := []string{}
+
+			for _, hit := range result.Hits {
+				hits = append(hits, hit.ID)
+			}
+
+			if !reflect.DeepEqual(hits, test.expected) || result.Total != 3 {
+				t.Errorf("FilterHits() = %v total %v, expected %v total 3", hits, result.Total, test.expected)
+			}
+
+			filtered := []string{}
+
+			for _, suggestion := range FilterSuggestions(suggestions, test.allowed) {
+				filtered = append(filtered, suggestion.ID)
+			}
+
+			if !reflect.DeepEqual(filtered, test.expected) {
+				t.Errorf("FilterSuggestions() = %v, expected %v", filtered, test.expected)
+			}
+		})
+	}
+}
+
+func TestNewSearchDocument(t *testing.T) {
+	entity := map[string]interface{}{
+		"name": "pg",
+		"fullyQualifiedName": "pg",
+		"deleted": true,
+		"connection": map[string]interface{}{ "config": map[string]interface{}{ "hostPort": "localhost", "password": "encrypted:abc" } },
+	}
+
+	document, err := NewSearchDocument("databaseService", "1", entity)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		value interface{}
+		expected interface{}
+	}{
+		{ name: "fullyQualifiedName", value: document.FullyQualifiedName, expected: "pg" },
+		{ name: "deleted", value: document.Deleted, expected: true },
+		{
+			name: "connection",
+			value: document.Source["connection"],
+			expected: map[string]interface{}{ "config": map[string]interface{}{ "hostPort": "localhost", "password": securityModels.SecretMask } },
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if !reflect.DeepEqual(test.value, test.expected) {
+				t.Errorf("%v = %v, expected %v", test.name, test.value, test.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+// Secrets managers, the local one encrypts the secrets with AES-GCM and stores the ciphertext in the connection,
+// the file one keeps the secrets in a file and stores a reference to them
+const (
+	LocalSecretsManager = "local"
+	FileSecretsManager = "file"
+)
+
+// Prefixes of the values written by the secrets managers, values without them are plain secrets
+const (
+	EncryptedSecretPrefix = "encrypted:"
+	SecretReferencePrefix = "secret:"
+)
+
+// Responses show this value in place of the secrets, a request sending it back keeps the stored secret
+const SecretMask = "*********"
+
+// Fields of a connection config holding secrets, at any depth. Ex: authType.password
+var SecretFields = map[string]bool {
+	"password": true,
+	"secret": true,
+	"secretKey": true,
+	"token": true,
+	"accessToken": true,
+	"apiKey": true,
+	"privateKey": true,
+}
+
+var (
+	ErrSecretDecryption = errors.New("failed to decrypt secret")
+	ErrSecretNotFound = errors.New("secret not found")
+	ErrSecretMasked = errors.New("masked secret without a stored secret")
+)
+
+// Query of the reads which may return the plain secrets
+type SecretsQuery struct {
+	IncludeSecrets		bool		`form:"includeSecrets"`
+}
+
+// MaskSecrets returns a json copy of value with the secrets replaced by the mask
+func MaskSecrets(value interface{}) (interface{}, error) {
+	var masked interface{}
+	err := CopyMasked(value, &masked)
+	return masked, err
+}
+
+// CopyMasked decodes the json of value into target with the secrets replaced by the mask
+func CopyMasked(value interface{}, target interface{}) error {
+	bytes, err := json.Marshal(value)
+
+	if err != nil {
+		return err
+	}
+
+	var decoded interface{}
+
+	if err := json.Unmarshal(bytes, &decoded); err != nil {
+		return err
+	}
+
+	MaskJsonSecrets(decoded)
+
+	if bytes, err = json.Marshal(decoded); err != nil {
+		return err
+	}
+
+	return json.Unmarshal(bytes, target)
+}
+
+// MaskJsonSecrets replaces the secrets of a decoded json value with the mask in place, at any depth
+func MaskJsonSecrets(value interface{}) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, field := range v {
+			if secret, ok := field.(string); ok && SecretFields[key] && secret != "" {
+				v[key] = SecretMask
+				continue
+			}
+
+			MaskJsonSecrets(field)
+		}
+	case []interface{}:
+		for _, item := range v {
+			MaskJsonSecrets(item)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMaskSecrets(t *testing.T) {
+	type connection struct {
+		HostPort string `json:"hostPort"`
+		Password string `json:"password"`
+	}
+
+	tests := []struct {
+		name string
+		value interface{}
+		expected interface{}
+	}{
+		{
+			name: "nested secrets",
+			value: map[string]interface{}{ "hostPort": "localhost", "authType": map[string]interface{}{ "password": "password", "apiKey": "key" } },
+			expected: map[string]interface{}{ "hostPort": "localhost", "authType": map[string]interface{}{ "password": SecretMask, "apiKey": SecretMask } },
+		},
+		{
+			name: "secrets in lists",
+			value: []interface{}{ map[string]interface{}{ "token": "token" } },
+			expected: []interface{}{ map[string]interface{}{ "token": SecretMask } },
+		},
+		{
+			name: "structs",
+			value: &connection{ HostPort: "localhost", Password: "password" },
+			expected: map[string]interface{}{ "hostPort": "localhost", "password": SecretMask },
+		},
+		{
+			name: "empty secrets are left empty",
+			value: map[string]interface{}{ "password": "" },
+			expected: map[string]interface{}{ "password": "" },
+		},
+		{
+			name: "only string secrets are masked",
+			value: map[string]interface{}{ "secret": map[string]interface{}{ "name": "value" } },
+			expected: map[string]interface{}{ "secret": map[string]interface{}{ "name": "value" } },
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			masked, err := MaskSecrets(test.value)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(masked, test.expected) {
+				t.Errorf("MaskSecrets() = %v, expected %v", masked, test.expected)
+			}
+		})
+	}
+}
+
+func TestCopyMasked(t *testing.T) {
+	type connection struct {
+		HostPort string `json:"hostPort"`
+		Password string `json:"password"`
+	}
+
+	value := &connection{ HostPort: "localhost", Password: "password" }
+	masked := &connection{}
+
+	if err := CopyMasked(value, masked); err != nil {
+		t.Fatal(err)
+	}
+
+	if masked.Password != SecretMask || masked.HostPort != "localhost" || value.Password != "password" {
+		t.Errorf("CopyMasked() = %+v from %+v, expected a masked copy", masked, value)
+	}
+}
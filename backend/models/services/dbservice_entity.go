@@ -37,6 +37,13 @@ type DBService struct {
 
 	TestConnectionResult	*TestConnectionResult	`json:"testConnectionResult"`
 
+	Owners					[]*typeModels.EntityReference	`json:"owners,omitempty"`
+
+	Version					float64					`json:"version"`
+	UpdatedAt				int64					`json:"updatedAt"`
+	UpdatedBy				string					`json:"updatedBy"`
+	ChangeDescription		*typeModels.ChangeDescription	`json:"changeDescription,omitempty"`
+
 	Deleted					bool					`json:"deleted"`
 }
 
@@ -68,6 +75,17 @@ func (s *DBService) ToEntityReference() *typeModels.EntityReference {
 	return entityRef
 }
 
+func (s *DBService) GetVersion() float64 {
+	return s.Version
+}
+
+func (s *DBService) SetVersion(version float64, changeDescription *typeModels.ChangeDescription, updatedAt int64, updatedBy string) {
+	s.Version = version
+	s.ChangeDescription = changeDescription
+	s.UpdatedAt = updatedAt
+	s.UpdatedBy = updatedBy
+}
+
 // Service type
 var ServiceType = map[string]int {"Postgres": 0, "MySQL": 1}
 
@@ -369,7 +387,9 @@ type TestConnectionStepResult struct {
 // APIs
 type GetDBServiceEntitiesQuery struct {
 	Limit int	`form:"limit"`
-	Offset int	`form:"offset"`
+	Before string	`form:"before"`
+	After string	`form:"after"`
+	Include string	`form:"include"`
 }
 
 type GetDBServiceEntityByIdParam struct {
@@ -387,19 +407,29 @@ type CreateDBServiceEntityPayload struct {
 
 	ServiceType		string				`json:"serviceType" binding:"required"`
 	Connection		*DatabaseConnection	`json:"connection" binding:"required"`
+
+	Owners		[]*typeModels.EntityReference	`json:"owners"`
 }
 
 func ValidateCreateDBServiceEntityPayload(payload *CreateDBServiceEntityPayload) error {
-	idx, serviceTypeErr := ValidateServiceType(payload.ServiceType)
+	return ValidateDBServiceConnection(payload.ServiceType, payload.Connection)
+}
+
+func ValidateDBServiceConnection(serviceType string, connection *DatabaseConnection) error {
+	idx, serviceTypeErr := ValidateServiceType(serviceType)
 
 	if serviceTypeErr != nil {
 		return serviceTypeErr
 	}
 
+	if connection == nil {
+		return errors.New("invalid connection")
+	}
+
 	if idx == 0 {
-		return ValidatePostgresConnection(payload.Connection)
+		return ValidatePostgresConnection(connection)
 	} else if idx == 1 {
-		return ValidateMysqlConnection(payload.Connection)
+		return ValidateMysqlConnection(connection)
 	} else {
 		return errors.New("unsuported service type")
 	}
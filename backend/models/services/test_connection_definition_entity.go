@@ -59,7 +59,9 @@ type TestConnectionStep struct {
 // APIs
 type GetTestConnectionDefinitionEntitiesQuery struct {
 	Limit int	`form:"limit"`
-	Offset int	`form:"offset"`
+	Before string	`form:"before"`
+	After string	`form:"after"`
+	Include string	`form:"include"`
 }
 
 type GetTestConnectionDefinitionEntityByIdParam struct {
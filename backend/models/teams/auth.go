@@ -0,0 +1,35 @@
+package models
+
+import "errors"
+
+// JWT claims of an access token, signed with HS256
+type JWTClaims struct {
+	ID					string		`json:"jti,omitempty"`
+	Issuer				string		`json:"iss"`
+	Subject				string		`json:"sub"`
+	Email				string		`json:"email"`
+	IsBot				bool		`json:"isBot"`
+	TokenType			string		`json:"tokenType"`
+	IssuedAt			int64		`json:"iat"`
+	ExpiresAt			int64		`json:"exp"`
+}
+
+const JWTIssuer = "go-metadata"
+const BearerTokenType = "Bearer"
+
+var ErrInvalidToken = errors.New("invalid or expired token")
+
+// Principal is the authenticated user of a request
+type Principal struct {
+	ID					string
+	Name				string
+	Email				string
+	IsAdmin				bool
+	IsBot				bool
+	Roles				[]string
+	// Ids of the teams of the user and of their ancestors
+	Teams				[]string
+}
+
+// The principal is kept in the gin context under this key
+const PrincipalKey = "principal"
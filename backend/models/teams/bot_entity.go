@@ -0,0 +1,100 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+
+	typeModels "github.com/nambuitechx/go-metadata/models/type"
+)
+
+// Bot entity
+type BotEntity struct {
+	ID					string				`db:"id" json:"id"`
+	Name				string				`db:"name" json:"name"`
+	Json				*Bot				`db:"json" json:"json"`
+	UpdatedAt			int64				`db:"updatedat" json:"updatedAt"`
+	UpdatedBy			string				`db:"updatedby" json:"updatedBy"`
+	Deleted				bool				`db:"deleted" json:"deleted"`
+	NameHash			string				`db:"namehash" json:"nameHash"`
+}
+
+// Bot, a service identity backed by a bot user of the same name
+type Bot struct {
+	ID					string						`json:"id"`
+	Name				string						`json:"name"`
+	FullyQualifiedName	string						`json:"fullyQualifiedName"`
+
+	DisplayName			string						`json:"displayName"`
+	Description			string						`json:"description"`
+
+	BotUser				*typeModels.EntityReference	`json:"botUser"`
+
+	Version				float64						`json:"version"`
+	UpdatedAt			int64						`json:"updatedAt"`
+	UpdatedBy			string						`json:"updatedBy"`
+	ChangeDescription	*typeModels.ChangeDescription	`json:"changeDescription,omitempty"`
+
+	Deleted				bool						`json:"deleted"`
+}
+
+func (s Bot) Value() (driver.Value, error) {
+	return json.Marshal(s)
+}
+
+func (s *Bot) Scan(value interface{}) error {
+	val, ok := value.([]byte)
+
+	if !ok {
+		return errors.New("type assertion to []byte failed")
+	}
+
+	return json.Unmarshal(val, &s)
+}
+
+func (s *Bot) ToEntityReference() *typeModels.EntityReference {
+	entityRef := &typeModels.EntityReference{
+		ID: s.ID,
+		Type: "bot",
+		Name: s.Name,
+		FullyQualifiedName: s.FullyQualifiedName,
+		DisplayName: s.DisplayName,
+		Description: s.Description,
+		Deleted: s.Deleted,
+	}
+
+	return entityRef
+}
+
+// Bot users get an address of their own, emails are unique
+const BotEmailDomain = "bots.go-metadata.org"
+
+// APIs
+type GetBotEntitiesQuery struct {
+	Limit int	`form:"limit"`
+	Before string	`form:"before"`
+	After string	`form:"after"`
+	Include string	`form:"include"`
+}
+
+type GetBotEntityByIdParam struct {
+	ID string	`uri:"id" binding:"required"`
+}
+
+type GetBotEntityByNameParam struct {
+	Name string	`uri:"name" binding:"required"`
+}
+
+type CreateBotPayload struct {
+	Name				string		`json:"name" binding:"required"`
+	DisplayName			string		`json:"displayName"`
+	Description			string		`json:"description"`
+}
+
+func ValidateCreateBotPayload(payload *CreateBotPayload) error {
+	if !userNamePattern.MatchString(payload.Name) {
+		return errors.New("invalid name")
+	}
+
+	return nil
+}
@@ -0,0 +1,31 @@
+package models
+
+import (
+	"errors"
+
+	typeModels "github.com/nambuitechx/go-metadata/models/type"
+)
+
+// Owner entity, an OWNS row of entity_relationship. Level is 1 for the entity itself and grows up the hierarchy,
+// schema then database then service
+type OwnerEntity struct {
+	Level				int							`db:"level" json:"level"`
+	Json				*typeModels.EntityReference	`db:"json" json:"json"`
+}
+
+// Owner types, owners are users or teams
+const (
+	UserOwner = "user"
+	TeamOwner = "team"
+)
+
+var ErrOwnerNotFound = errors.New("owner not found")
+
+// Tables of the entities with owners, by entity type
+var OwnedEntityTables = map[string]string{
+	"databaseService": "dbservice_entity",
+	"database": "database_entity",
+	"databaseSchema": "database_schema_entity",
+	"table": "table_entity",
+	"storedProcedure": "stored_procedure_entity",
+}
@@ -0,0 +1,116 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+
+	typeModels "github.com/nambuitechx/go-metadata/models/type"
+)
+
+// Role entity
+type RoleEntity struct {
+	ID					string				`db:"id" json:"id"`
+	Name				string				`db:"name" json:"name"`
+	Json				*Role				`db:"json" json:"json"`
+	UpdatedAt			int64				`db:"updatedat" json:"updatedAt"`
+	UpdatedBy			string				`db:"updatedby" json:"updatedBy"`
+	Deleted				bool				`db:"deleted" json:"deleted"`
+	NameHash			string				`db:"namehash" json:"nameHash"`
+}
+
+// Role, a named set of policies assigned to users
+type Role struct {
+	ID					string						`json:"id"`
+	Name				string						`json:"name"`
+	FullyQualifiedName	string						`json:"fullyQualifiedName"`
+
+	DisplayName			string						`json:"displayName"`
+	Description			string						`json:"description"`
+
+	Policies			[]*typeModels.EntityReference	`json:"policies"`
+
+	Version				float64						`json:"version"`
+	UpdatedAt			int64						`json:"updatedAt"`
+	UpdatedBy			string						`json:"updatedBy"`
+	ChangeDescription	*typeModels.ChangeDescription	`json:"changeDescription,omitempty"`
+
+	Deleted				bool						`json:"deleted"`
+}
+
+func (s Role) Value() (driver.Value, error) {
+	return json.Marshal(s)
+}
+
+func (s *Role) Scan(value interface{}) error {
+	val, ok := value.([]byte)
+
+	if !ok {
+		return errors.New("type assertion to []byte failed")
+	}
+
+	return json.Unmarshal(val, &s)
+}
+
+func (s *Role) ToEntityReference() *typeModels.EntityReference {
+	entityRef := &typeModels.EntityReference{
+		ID: s.ID,
+		Type: "role",
+		Name: s.Name,
+		FullyQualifiedName: s.FullyQualifiedName,
+		DisplayName: s.DisplayName,
+		Description: s.Description,
+		Deleted: s.Deleted,
+	}
+
+	return entityRef
+}
+
+// Default roles, created on start. Every user has the default role on top of its own roles
+const (
+	DataConsumerRole = "DataConsumer"
+	DataStewardRole = "DataSteward"
+	IngestionBotRole = "IngestionBotRole"
+	DefaultRole = DataConsumerRole
+)
+
+var DefaultRoles = []*CreateRolePayload{
+	{ Name: DataConsumerRole, Description: "Users who use the data", Policies: []string{"DataConsumerPolicy"} },
+	{ Name: DataStewardRole, Description: "Users who curate the data", Policies: []string{"DataStewardPolicy", "DataConsumerPolicy"} },
+	{ Name: IngestionBotRole, Description: "Bots which ingest the metadata", Policies: []string{"IngestionBotPolicy"} },
+}
+
+// APIs
+type GetRoleEntitiesQuery struct {
+	Limit int	`form:"limit"`
+	Before string	`form:"before"`
+	After string	`form:"after"`
+	Include string	`form:"include"`
+}
+
+type GetRoleEntityByIdParam struct {
+	ID string	`uri:"id" binding:"required"`
+}
+
+type GetRoleEntityByNameParam struct {
+	Name string	`uri:"name" binding:"required"`
+}
+
+type CreateRolePayload struct {
+	Name				string		`json:"name" binding:"required"`
+	DisplayName			string		`json:"displayName"`
+	Description			string		`json:"description"`
+	Policies			[]string	`json:"policies" binding:"required"`
+}
+
+func ValidateCreateRolePayload(payload *CreateRolePayload) error {
+	if len(payload.Policies) == 0 {
+		return errors.New("a role needs at least one policy")
+	}
+
+	return nil
+}
+
+type SetUserRolesPayload struct {
+	Roles				[]string	`json:"roles"`
+}
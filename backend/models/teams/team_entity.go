@@ -0,0 +1,146 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"slices"
+
+	typeModels "github.com/nambuitechx/go-metadata/models/type"
+)
+
+// Team entity
+type TeamEntity struct {
+	ID					string				`db:"id" json:"id"`
+	Name				string				`db:"name" json:"name"`
+	Json				*Team				`db:"json" json:"json"`
+	UpdatedAt			int64				`db:"updatedat" json:"updatedAt"`
+	UpdatedBy			string				`db:"updatedby" json:"updatedBy"`
+	Deleted				bool				`db:"deleted" json:"deleted"`
+	NameHash			string				`db:"namehash" json:"nameHash"`
+}
+
+// Team, a node of the organization → department → group hierarchy. The parent and the users are mirrored as
+// PARENT_OF and HAS rows of entity_relationship, the children are only filled when a single team is read
+type Team struct {
+	ID					string						`json:"id"`
+	Name				string						`json:"name"`
+	FullyQualifiedName	string						`json:"fullyQualifiedName"`
+
+	DisplayName			string						`json:"displayName"`
+	Description			string						`json:"description"`
+
+	TeamType			string						`json:"teamType"`
+	Parent				*typeModels.EntityReference	`json:"parent,omitempty"`
+	Children			[]*typeModels.EntityReference	`json:"children,omitempty"`
+	Users				[]*typeModels.EntityReference	`json:"users,omitempty"`
+
+	Version				float64						`json:"version"`
+	UpdatedAt			int64						`json:"updatedAt"`
+	UpdatedBy			string						`json:"updatedBy"`
+	ChangeDescription	*typeModels.ChangeDescription	`json:"changeDescription,omitempty"`
+
+	Deleted				bool						`json:"deleted"`
+}
+
+func (s Team) Value() (driver.Value, error) {
+	return json.Marshal(s)
+}
+
+func (s *Team) Scan(value interface{}) error {
+	val, ok := value.([]byte)
+
+	if !ok {
+		return errors.New("type assertion to []byte failed")
+	}
+
+	return json.Unmarshal(val, &s)
+}
+
+func (s *Team) ToEntityReference() *typeModels.EntityReference {
+	entityRef := &typeModels.EntityReference{
+		ID: s.ID,
+		Type: "team",
+		Name: s.Name,
+		FullyQualifiedName: s.FullyQualifiedName,
+		DisplayName: s.DisplayName,
+		Description: s.Description,
+		Deleted: s.Deleted,
+	}
+
+	return entityRef
+}
+
+// Team types. There is a single organization, created on start, departments nest under the organization or another
+// department and groups, the only teams with users, are the leaves
+const (
+	OrganizationTeam = "Organization"
+	DepartmentTeam = "Department"
+	GroupTeam = "Group"
+)
+
+const OrganizationName = "Organization"
+
+var (
+	ErrOrganizationTeam = errors.New("the organization cannot be created or deleted")
+	ErrTeamParent = errors.New("the parent of a team must be the organization or a department")
+	ErrTeamUsers = errors.New("only groups can have users")
+	ErrTeamImmutable = errors.New("the team type and the parent of a team cannot be changed")
+	ErrTeamHasChildren = errors.New("the team still has child teams")
+	ErrTeamOwnsEntities = errors.New("the team still owns entities")
+)
+
+// ValidateTeamParent checks that a team of the given type can be a child of the parent
+func ValidateTeamParent(parent *Team) error {
+	if !slices.Contains([]string{OrganizationTeam, DepartmentTeam}, parent.TeamType) {
+		return ErrTeamParent
+	}
+
+	return nil
+}
+
+// APIs
+type GetTeamEntitiesQuery struct {
+	Limit int	`form:"limit"`
+	Before string	`form:"before"`
+	After string	`form:"after"`
+	Include string	`form:"include"`
+}
+
+type GetTeamEntityByIdParam struct {
+	ID string	`uri:"id" binding:"required"`
+}
+
+type GetTeamEntityByNameParam struct {
+	Name string	`uri:"name" binding:"required"`
+}
+
+type CreateTeamPayload struct {
+	Name				string		`json:"name" binding:"required"`
+	DisplayName			string		`json:"displayName"`
+	Description			string		`json:"description"`
+	TeamType			string		`json:"teamType" binding:"required"`
+	// Name of the parent team, the organization when empty
+	Parent				string		`json:"parent"`
+	Users				[]string	`json:"users"`
+}
+
+func ValidateCreateTeamPayload(payload *CreateTeamPayload) error {
+	if !userNamePattern.MatchString(payload.Name) {
+		return errors.New("invalid name")
+	}
+
+	if payload.TeamType == OrganizationTeam {
+		return ErrOrganizationTeam
+	}
+
+	if !slices.Contains([]string{DepartmentTeam, GroupTeam}, payload.TeamType) {
+		return errors.New("invalid teamType")
+	}
+
+	if payload.TeamType != GroupTeam && len(payload.Users) > 0 {
+		return ErrTeamUsers
+	}
+
+	return nil
+}
@@ -0,0 +1,57 @@
+package models
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestValidateCreateTeamPayload(t *testing.T) {
+	tests := []struct {
+		name string
+		payload *CreateTeamPayload
+		expectedErr bool
+		// the specific error, when there is one
+		expected error
+	}{
+		{ name: "department", payload: &CreateTeamPayload{ Name: "engineering", TeamType: DepartmentTeam } },
+		{ name: "group with users", payload: &CreateTeamPayload{ Name: "data", TeamType: GroupTeam, Users: []string{"alice"} } },
+		{ name: "invalid name", payload: &CreateTeamPayload{ Name: "data team", TeamType: GroupTeam }, expectedErr: true },
+		{ name: "invalid team type", payload: &CreateTeamPayload{ Name: "data", TeamType: "Division" }, expectedErr: true },
+		{ name: "organization", payload: &CreateTeamPayload{ Name: "company", TeamType: OrganizationTeam }, expectedErr: true, expected: ErrOrganizationTeam },
+		{
+			name: "department with users",
+			payload: &CreateTeamPayload{ Name: "engineering", TeamType: DepartmentTeam, Users: []string{"alice"} },
+			expectedErr: true,
+			expected: ErrTeamUsers,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := ValidateCreateTeamPayload(test.payload)
+
+			if (err != nil) != test.expectedErr || (test.expected != nil && !errors.Is(err, test.expected)) {
+				t.Errorf("ValidateCreateTeamPayload() = %v, expected an error: %v %v", err, test.expectedErr, test.expected)
+			}
+		})
+	}
+}
+
+func TestValidateTeamParent(t *testing.T) {
+	tests := []struct {
+		teamType string
+		expected error
+	}{
+		{ teamType: OrganizationTeam, expected: nil },
+		{ teamType: DepartmentTeam, expected: nil },
+		{ teamType: GroupTeam, expected: ErrTeamParent },
+	}
+
+	for _, test := range tests {
+		t.Run(test.teamType, func(t *testing.T) {
+			if err := ValidateTeamParent(&Team{ TeamType: test.teamType }); !errors.Is(err, test.expected) {
+				t.Errorf("ValidateTeamParent(%v) = %v, expected %v", test.teamType, err, test.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,140 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"net/mail"
+	"regexp"
+
+	typeModels "github.com/nambuitechx/go-metadata/models/type"
+)
+
+// User entity
+type UserEntity struct {
+	ID					string				`db:"id" json:"id"`
+	Name				string				`db:"name" json:"name"`
+	Email				string				`db:"email" json:"email"`
+	Deactivated			string				`db:"deactivated" json:"deactivated"`
+	Json				*User				`db:"json" json:"json"`
+	UpdatedAt			int64				`db:"updatedat" json:"updatedAt"`
+	UpdatedBy			string				`db:"updatedby" json:"updatedBy"`
+	Deleted				bool				`db:"deleted" json:"deleted"`
+	NameHash			string				`db:"namehash" json:"nameHash"`
+	IsBot				bool				`db:"isbot" json:"isBot"`
+	PasswordHash		string				`db:"passwordhash" json:"-"`
+}
+
+// User
+type User struct {
+	ID					string						`json:"id"`
+	Name				string						`json:"name"`
+	FullyQualifiedName	string						`json:"fullyQualifiedName"`
+
+	DisplayName			string						`json:"displayName"`
+	Description			string						`json:"description"`
+	Email				string						`json:"email"`
+
+	IsAdmin				bool						`json:"isAdmin"`
+	IsBot				bool						`json:"isBot"`
+	Deactivated			bool						`json:"deactivated"`
+
+	Roles				[]*typeModels.EntityReference	`json:"roles,omitempty"`
+
+	Version				float64						`json:"version"`
+	UpdatedAt			int64						`json:"updatedAt"`
+	UpdatedBy			string						`json:"updatedBy"`
+	ChangeDescription	*typeModels.ChangeDescription	`json:"changeDescription,omitempty"`
+
+	Deleted				bool						`json:"deleted"`
+}
+
+func (s User) Value() (driver.Value, error) {
+	return json.Marshal(s)
+}
+
+func (s *User) Scan(value interface{}) error {
+	val, ok := value.([]byte)
+
+	if !ok {
+		return errors.New("type assertion to []byte failed")
+	}
+
+	return json.Unmarshal(val, &s)
+}
+
+func (s *User) ToEntityReference() *typeModels.EntityReference {
+	entityRef := &typeModels.EntityReference{
+		ID: s.ID,
+		Type: "user",
+		Name: s.Name,
+		FullyQualifiedName: s.FullyQualifiedName,
+		DisplayName: s.DisplayName,
+		Description: s.Description,
+		Deleted: s.Deleted,
+	}
+
+	return entityRef
+}
+
+// The deactivated column keeps the flag as text
+const (
+	UserActive = "false"
+	UserDeactivated = "true"
+)
+
+var ErrInvalidCredentials = errors.New("invalid user name or password")
+var ErrUserDeactivated = errors.New("user is deactivated")
+
+// Names are used in fully qualified names and tokens
+var userNamePattern = regexp.MustCompile(`^[A-Za-z0-9_.@-]{1,64}$`)
+
+const MinPasswordLength = 8
+
+// APIs
+type GetUserEntitiesQuery struct {
+	Limit int	`form:"limit"`
+	Before string	`form:"before"`
+	After string	`form:"after"`
+	Include string	`form:"include"`
+}
+
+type GetUserEntityByIdParam struct {
+	ID string	`uri:"id" binding:"required"`
+}
+
+type GetUserEntityByNameParam struct {
+	Name string	`uri:"name" binding:"required"`
+}
+
+type CreateUserPayload struct {
+	Name				string		`json:"name" binding:"required"`
+	Email				string		`json:"email" binding:"required"`
+	DisplayName			string		`json:"displayName"`
+	Description			string		`json:"description"`
+	Password			string		`json:"password" binding:"required"`
+	IsAdmin				bool		`json:"isAdmin"`
+}
+
+func ValidateCreateUserPayload(payload *CreateUserPayload) error {
+	if !userNamePattern.MatchString(payload.Name) {
+		return errors.New("invalid name")
+	}
+
+	if _, err := mail.ParseAddress(payload.Email); err != nil {
+		return errors.New("invalid email")
+	}
+
+	if len(payload.Password) < MinPasswordLength {
+		return errors.New("password must have at least 8 characters")
+	}
+
+	return nil
+}
+
+// Login
+type LoginResponse struct {
+	AccessToken			string		`json:"accessToken"`
+	TokenType			string		`json:"tokenType"`
+	ExpiryDuration		int64		`json:"expiryDuration"`
+}
@@ -0,0 +1,24 @@
+package models
+
+import "testing"
+
+func TestValidateCreateUserPayload(t *testing.T) {
+	tests := []struct {
+		name string
+		payload *CreateUserPayload
+		expectedErr bool
+	}{
+		{ name: "valid", payload: &CreateUserPayload{ Name: "alice.smith", Email: "alice@example.com", Password: "password" } },
+		{ name: "invalid name", payload: &CreateUserPayload{ Name: "alice smith", Email: "alice@example.com", Password: "password" }, expectedErr: true },
+		{ name: "invalid email", payload: &CreateUserPayload{ Name: "alice", Email: "alice", Password: "password" }, expectedErr: true },
+		{ name: "short password", payload: &CreateUserPayload{ Name: "alice", Email: "alice@example.com", Password: "pass" }, expectedErr: true },
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := ValidateCreateUserPayload(test.payload); (err != nil) != test.expectedErr {
+				t.Errorf("ValidateCreateUserPayload() err = %v, expected an error: %v", err, test.expectedErr)
+			}
+		})
+	}
+}
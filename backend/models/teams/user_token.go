@@ -0,0 +1,88 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+)
+
+// User token entity, the record of a long-lived token. The token itself is only returned when it is issued, a
+// token whose record is deleted is revoked
+type UserTokenEntity struct {
+	ID					string				`db:"id" json:"id"`
+	UserID				string				`db:"userid" json:"userId"`
+	TokenType			string				`db:"tokentype" json:"tokenType"`
+	ExpiresAt			int64				`db:"expiresat" json:"expiresAt"`
+	Json				*UserToken			`db:"json" json:"json"`
+}
+
+type UserToken struct {
+	ID					string		`json:"id"`
+	Name				string		`json:"name"`
+	UserID				string		`json:"userId"`
+	UserName			string		`json:"userName"`
+	TokenType			string		`json:"tokenType"`
+	ExpiresAt			int64		`json:"expiresAt"`
+	CreatedAt			int64		`json:"createdAt"`
+	CreatedBy			string		`json:"createdBy"`
+}
+
+func (s UserToken) Value() (driver.Value, error) {
+	return json.Marshal(s)
+}
+
+func (s *UserToken) Scan(value interface{}) error {
+	val, ok := value.([]byte)
+
+	if !ok {
+		return errors.New("type assertion to []byte failed")
+	}
+
+	return json.Unmarshal(val, &s)
+}
+
+// Token types, session tokens are issued by the login and are not stored
+const (
+	SessionToken = "SESSION"
+	BotToken = "BOT"
+	PersonalAccessToken = "PERSONAL_ACCESS"
+)
+
+const (
+	DefaultTokenExpiryDays = 30
+	MaxTokenExpiryDays = 365
+)
+
+var ErrBotPersonalAccessToken = errors.New("bots cannot have personal access tokens")
+
+// APIs
+type GetUserTokenParam struct {
+	ID string	`uri:"id" binding:"required"`
+}
+
+type CreatePersonalAccessTokenPayload struct {
+	Name				string		`json:"name" binding:"required"`
+	ExpiryDays			int			`json:"expiryDays"`
+}
+
+type GenerateBotTokenPayload struct {
+	ExpiryDays			int			`json:"expiryDays"`
+}
+
+func ValidateTokenExpiryDays(expiryDays int) (int, error) {
+	if expiryDays == 0 {
+		return DefaultTokenExpiryDays, nil
+	}
+
+	if expiryDays < 0 || expiryDays > MaxTokenExpiryDays {
+		return 0, errors.New("expiryDays must be between 1 and 365")
+	}
+
+	return expiryDays, nil
+}
+
+// UserTokenResponse carries the token the only time it is returned
+type UserTokenResponse struct {
+	*UserToken
+	AccessToken			string		`json:"accessToken"`
+}
@@ -0,0 +1,52 @@
+package models
+
+import "testing"
+
+func TestValidateTokenExpiryDays(t *testing.T) {
+	tests := []struct {
+		name string
+		expiryDays int
+		expected int
+		expectedErr bool
+	}{
+		{ name: "default", expiryDays: 0, expected: DefaultTokenExpiryDays },
+		{ name: "one day", expiryDays: 1, expected: 1 },
+		{ name: "max", expiryDays: MaxTokenExpiryDays, expected: MaxTokenExpiryDays },
+		{ name: "negative", expiryDays: -1, expectedErr: true },
+		{ name: "too long", expiryDays: MaxTokenExpiryDays + 1, expectedErr: true },
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			expiryDays, err := ValidateTokenExpiryDays(test.expiryDays)
+
+			if (err != nil) != test.expectedErr {
+				t.Fatalf("ValidateTokenExpiryDays() err = %v, expected an error: %v", err, test.expectedErr)
+			}
+
+			if expiryDays != test.expected {
+				t.Errorf("ValidateTokenExpiryDays(%v) = %v, expected %v", test.expiryDays, expiryDays, test.expected)
+			}
+		})
+	}
+}
+
+func TestValidateCreateBotPayload(t *testing.T) {
+	tests := []struct {
+		name string
+		payload *CreateBotPayload
+		expectedErr bool
+	}{
+		{ name: "valid", payload: &CreateBotPayload{ Name: "ingestion-bot" } },
+		{ name: "invalid name", payload: &CreateBotPayload{ Name: "ingestion bot" }, expectedErr: true },
+		{ name: "blank name", payload: &CreateBotPayload{}, expectedErr: true },
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := ValidateCreateBotPayload(test.payload); (err != nil) != test.expectedErr {
+				t.Errorf("ValidateCreateBotPayload() err = %v, expected an error: %v", err, test.expectedErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,9 @@
+package models
+
+// Cascade deletion, a child soft deleted along with its parent and its version once deleted. Restoring the parent
+// restores the children still at that version
+type CascadeDeletion struct {
+	ID					string				`db:"id" json:"id"`
+	ParentID			string				`db:"parentid" json:"parentId"`
+	Version				float64				`db:"version" json:"version"`
+}
@@ -0,0 +1,63 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+// Version
+// Every entity starts at 0.1, minor changes add 0.1 and breaking changes move to the next major version
+const InitialVersion = 0.1
+
+func VersionExtension(entityType string, version float64) string {
+	return fmt.Sprintf("%v.version.%v", entityType, strconv.FormatFloat(version, 'f', 1, 64))
+}
+
+// Change description
+type FieldChange struct {
+	Name				string			`json:"name"`
+	OldValue			interface{}		`json:"oldValue,omitempty"`
+	NewValue			interface{}		`json:"newValue,omitempty"`
+}
+
+type ChangeDescription struct {
+	FieldsAdded			[]FieldChange	`json:"fieldsAdded"`
+	FieldsUpdated		[]FieldChange	`json:"fieldsUpdated"`
+	FieldsDeleted		[]FieldChange	`json:"fieldsDeleted"`
+	PreviousVersion		float64			`json:"previousVersion"`
+}
+
+func (c *ChangeDescription) IsEmpty() bool {
+	return len(c.FieldsAdded) == 0 && len(c.FieldsUpdated) == 0 && len(c.FieldsDeleted) == 0
+}
+
+// Entity extension
+// Previous versions of an entity, stored under the extension <entityType>.version.<version>
+type EntityExtension struct {
+	ID					string				`db:"id" json:"id"`
+	Extension			string				`db:"extension" json:"extension"`
+	JsonSchema			string				`db:"jsonschema" json:"jsonSchema"`
+	Json				json.RawMessage		`db:"json" json:"json"`
+}
+
+// APIs
+type EntityHistory struct {
+	EntityType			string				`json:"entityType"`
+	Versions			[]interface{}		`json:"versions"`
+}
+
+type GetEntityVersionParam struct {
+	ID					string				`uri:"id" binding:"required"`
+	Version				string				`uri:"version" binding:"required"`
+}
+
+func ValidateVersion(version string) (float64, error) {
+	v, err := strconv.ParseFloat(version, 64)
+
+	if err != nil || v <= 0 {
+		return -1, fmt.Errorf("invalid version %v", version)
+	}
+
+	return v, nil
+}
@@ -1,5 +1,11 @@
 package models
 
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+)
+
 type EntityReference struct {
 	ID					string		`json:"id"`
 	Type 				string		`json:"type"`
@@ -10,4 +16,20 @@ type EntityReference struct {
 	Description			string		`json:"description"`
 
 	Deleted				bool		`json:"deleted"`
+	// Set on owners which come from a parent entity, they are never stored on the entity itself
+	Inherited			bool		`json:"inherited,omitempty"`
+}
+
+func (s EntityReference) Value() (driver.Value, error) {
+	return json.Marshal(s)
+}
+
+func (s *EntityReference) Scan(value interface{}) error {
+	val, ok := value.([]byte)
+
+	if !ok {
+		return errors.New("type assertion to []byte failed")
+	}
+
+	return json.Unmarshal(val, &s)
 }
@@ -0,0 +1,22 @@
+package models
+
+// Relationship, stored as its ordinal in the relation column of entity_relationship
+var Relationship = map[string]int {
+	"CONTAINS": 0,
+	"CREATED": 1,
+	"REPLIED_TO": 2,
+	"IS_ABOUT": 3,
+	"ADDRESSED_TO": 4,
+	"MENTIONED_IN": 5,
+	"TESTED_BY": 6,
+	"USES": 7,
+	"OWNS": 8,
+	"PARENT_OF": 9,
+	"HAS": 10,
+	"FOLLOWS": 11,
+	"JOINED_WITH": 12,
+	"UPSTREAM": 13,
+	"APPLIED_TO": 14,
+	"RELATED_TO": 15,
+	"REVIEWS": 16,
+}
@@ -2,50 +2,48 @@ package repositories
 
 import (
 	"github.com/jmoiron/sqlx"
+	baseRepositories "github.com/nambuitechx/go-metadata/repositories/base"
 	baseModels "github.com/nambuitechx/go-metadata/models/base"
 	automationsModels "github.com/nambuitechx/go-metadata/models/automations"
 )
 
 type WorkflowEntityRepository struct {
-	DB *sqlx.DB
+	DB baseRepositories.DBTX
 }
 
 func NewWorkflowEntityRepository(db *sqlx.DB) *WorkflowEntityRepository {
 	return &WorkflowEntityRepository{ DB: db }
 }
 
-func (r *WorkflowEntityRepository) SelectWorkflowEntities(limit int, offset int) ([]automationsModels.WorkflowEntity, error) {
-	workflowEntities := []automationsModels.WorkflowEntity{}
-	var err error
-	
-	if limit < 0 {
-		statement := "SELECT * FROM automations_workflow"
-		err = r.DB.Select(&workflowEntities, statement)
-	} else {
-		statement := "SELECT * FROM automations_workflow LIMIT $1 OFFSET $2"
-		err = r.DB.Select(&workflowEntities, statement, limit, offset)
-	}
+func (r *WorkflowEntityRepository) WithTx(tx *sqlx.Tx) *WorkflowEntityRepository {
+	return &WorkflowEntityRepository{ DB: tx }
+}
 
+func (r *WorkflowEntityRepository) SelectWorkflowEntities(include string, limit int, before *baseModels.Cursor, after *baseModels.Cursor) ([]automationsModels.WorkflowEntity, error) {
+	workflowEntities := []automationsModels.WorkflowEntity{}
+	statement := "SELECT * FROM automations_workflow WHERE " + baseRepositories.DeletedCondition(include)
+	statement, args := baseRepositories.Paginate(statement, []interface{}{}, limit, before, after)
+	err := r.DB.Select(&workflowEntities, statement, args...)
 	return workflowEntities, err
 }
 
-func (r *WorkflowEntityRepository) SelectCountWorkflowEntities() (*baseModels.EntityTotal, error) {
+func (r *WorkflowEntityRepository) SelectCountWorkflowEntities(include string) (*baseModels.EntityTotal, error) {
 	entityTotal := &baseModels.EntityTotal{}
-	statement := "SELECT COUNT(id) as total FROM automations_workflow"
+	statement := "SELECT COUNT(id) as total FROM automations_workflow WHERE " + baseRepositories.DeletedCondition(include)
 	err := r.DB.Get(entityTotal, statement)
 	return entityTotal, err
 }
 
-func (r *WorkflowEntityRepository) SelectWorkflowEntityById(id string) (*automationsModels.WorkflowEntity, error) {
+func (r *WorkflowEntityRepository) SelectWorkflowEntityById(id string, include string) (*automationsModels.WorkflowEntity, error) {
 	workflowEntity := &automationsModels.WorkflowEntity{}
-	statement := "SELECT * FROM automations_workflow WHERE id = $1"
+	statement := "SELECT * FROM automations_workflow WHERE id = $1 AND " + baseRepositories.DeletedCondition(include)
 	err := r.DB.Get(workflowEntity, statement, id)
 	return workflowEntity, err
 }
 
-func (r *WorkflowEntityRepository) SelectWorkflowEntityByFqn(fqn string) (*automationsModels.WorkflowEntity, error) {
+func (r *WorkflowEntityRepository) SelectWorkflowEntityByFqn(fqn string, include string) (*automationsModels.WorkflowEntity, error) {
 	workflowEntity := &automationsModels.WorkflowEntity{}
-	statement := "SELECT * FROM automations_workflow WHERE json->>'fullyQualifiedName' = $1"
+	statement := "SELECT * FROM automations_workflow WHERE json->>'fullyQualifiedName' = $1 AND " + baseRepositories.DeletedCondition(include)
 	err := r.DB.Get(workflowEntity, statement, fqn)
 	return workflowEntity, err
 }
@@ -95,14 +93,34 @@ func (r *WorkflowEntityRepository) UpdateWorkflowEntity(payload *automationsMode
 	return &workflowEntity, err
 }
 
+// UpdateWorkflowStatus stores the status of the workflow, and its response unless response is nil, without changing
+// the rest of the workflow
+func (r *WorkflowEntityRepository) UpdateWorkflowStatus(id string, status string, response []byte, updatedAt int64, updatedBy string) (*automationsModels.WorkflowEntity, error) {
+	var workflowEntity = automationsModels.WorkflowEntity{}
+	statement := `
+		UPDATE automations_workflow
+		SET status = $2, updatedat = $4, updatedby = $5, json = jsonb_set(json, '{status}', to_jsonb($2::varchar)) ||
+			CASE WHEN $3::jsonb IS NULL THEN '{}'::jsonb ELSE jsonb_build_object('response', $3::jsonb) END
+		WHERE id = $1 RETURNING *
+	`
+	err := r.DB.Get(&workflowEntity, statement, id, status, response, updatedAt, updatedBy)
+	return &workflowEntity, err
+}
+
 func (r *WorkflowEntityRepository) DeleteWorkflowEntityById(id string) error {
-	statement := "DELETE FROM automations_workflow WHERE id = $1"
+	statement := `
+		WITH deleted AS (DELETE FROM automations_workflow WHERE id = $1 RETURNING id)
+		DELETE FROM workflow_run WHERE workflowid IN (SELECT id FROM deleted)
+	`
 	_, err := r.DB.Exec(statement, id)
 	return err
 }
 
 func (r *WorkflowEntityRepository) DeleteWorkflowEntityByFqn(fqn string) error {
-	statement := "DELETE FROM automations_workflow WHERE json->>'fullyQualifiedName' = $1"
+	statement := `
+		WITH deleted AS (DELETE FROM automations_workflow WHERE json->>'fullyQualifiedName' = $1 RETURNING id)
+		DELETE FROM workflow_run WHERE workflowid IN (SELECT id FROM deleted)
+	`
 	_, err := r.DB.Exec(statement, fqn)
 	return err
 }
@@ -0,0 +1,122 @@
+package repositories
+
+import (
+	"github.com/jmoiron/sqlx"
+	baseRepositories "github.com/nambuitechx/go-metadata/repositories/base"
+	automationsModels "github.com/nambuitechx/go-metadata/models/automations"
+)
+
+type WorkflowRunRepository struct {
+	DB baseRepositories.DBTX
+}
+
+func NewWorkflowRunRepository(db *sqlx.DB) *WorkflowRunRepository {
+	return &WorkflowRunRepository{ DB: db }
+}
+
+func (r *WorkflowRunRepository) WithTx(tx *sqlx.Tx) *WorkflowRunRepository {
+	return &WorkflowRunRepository{ DB: tx }
+}
+
+// SelectWorkflowRuns returns the latest runs of a workflow first
+func (r *WorkflowRunRepository) SelectWorkflowRuns(workflowId string, limit int) ([]automationsModels.WorkflowRunEntity, error) {
+	workflowRunEntities := []automationsModels.WorkflowRunEntity{}
+	statement := "SELECT * FROM workflow_run WHERE workflowid = $1 ORDER BY createdat DESC, id LIMIT $2"
+	err := r.DB.Select(&workflowRunEntities, statement, workflowId, limit)
+	return workflowRunEntities, err
+}
+
+// InsertWorkflowRun returns sql.ErrNoRows when the workflow already has a queued or running run
+func (r *WorkflowRunRepository) InsertWorkflowRun(payload *automationsModels.WorkflowRunEntity) (*automationsModels.WorkflowRunEntity, error) {
+	var workflowRunEntity = automationsModels.WorkflowRunEntity{}
+	statement := `
+		INSERT INTO workflow_run(id, workflowid, status, attempt, maxattempts, timeout, runafter, createdat, createdby)
+		VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT (workflowid) WHERE status IN ('Queued', 'Running') DO NOTHING
+		RETURNING *
+	`
+	err := r.DB.Get(
+		&workflowRunEntity,
+		statement,
+		payload.ID,
+		payload.WorkflowID,
+		payload.Status,
+		payload.Attempt,
+		payload.MaxAttempts,
+		payload.Timeout,
+		payload.RunAfter,
+		payload.CreatedAt,
+		payload.CreatedBy,
+	)
+	return &workflowRunEntity, err
+}
+
+// ClaimWorkflowRun moves the oldest due queued run to Running, the runs claimed by other workers are skipped.
+// Returns sql.ErrNoRows when no run is due
+func (r *WorkflowRunRepository) ClaimWorkflowRun(now int64) (*automationsModels.WorkflowRunEntity, error) {
+	var workflowRunEntity = automationsModels.WorkflowRunEntity{}
+	statement := `
+		UPDATE workflow_run SET status = 'Running', attempt = attempt + 1, startedat = $1, heartbeatat = $1
+		WHERE id = (
+			SELECT id FROM workflow_run WHERE status = 'Queued' AND runafter <= $1
+			ORDER BY runafter, id LIMIT 1 FOR UPDATE SKIP LOCKED
+		)
+		RETURNING *
+	`
+	err := r.DB.Get(&workflowRunEntity, statement, now)
+	return &workflowRunEntity, err
+}
+
+// HeartbeatWorkflowRun marks a running run as alive and tells whether its cancellation was requested
+func (r *WorkflowRunRepository) HeartbeatWorkflowRun(id string, now int64) (bool, error) {
+	var cancelRequested bool
+	statement := "UPDATE workflow_run SET heartbeatat = $2 WHERE id = $1 AND status = 'Running' RETURNING cancelrequested"
+	err := r.DB.Get(&cancelRequested, statement, id, now)
+	return cancelRequested, err
+}
+
+func (r *WorkflowRunRepository) FinishWorkflowRun(id string, status string, message string, now int64) error {
+	statement := "UPDATE workflow_run SET status = $2, error = $3, finishedat = $4 WHERE id = $1 AND status = 'Running'"
+	_, err := r.DB.Exec(statement, id, status, message, now)
+	return err
+}
+
+// RequeueWorkflowRun queues a failed attempt again, to be claimed after runAfter
+func (r *WorkflowRunRepository) RequeueWorkflowRun(id string, message string, runAfter int64) error {
+	statement := "UPDATE workflow_run SET status = 'Queued', error = $2, runafter = $3 WHERE id = $1 AND status = 'Running'"
+	_, err := r.DB.Exec(statement, id, message, runAfter)
+	return err
+}
+
+// CancelWorkflowRun requests the cancellation of the active run of a workflow. A queued run is cancelled right away,
+// a running one by its worker. Returns sql.ErrNoRows when the workflow has no active run
+func (r *WorkflowRunRepository) CancelWorkflowRun(workflowId string, now int64) (*automationsModels.WorkflowRunEntity, error) {
+	var workflowRunEntity = automationsModels.WorkflowRunEntity{}
+	statement := `
+		UPDATE workflow_run SET
+			cancelrequested = TRUE,
+			status = CASE WHEN status = 'Queued' THEN 'Cancelled' ELSE status END,
+			finishedat = CASE WHEN status = 'Queued' THEN $2 ELSE finishedat END
+		WHERE workflowid = $1 AND status IN ('Queued', 'Running')
+		RETURNING *
+	`
+	err := r.DB.Get(&workflowRunEntity, statement, workflowId, now)
+	return &workflowRunEntity, err
+}
+
+// RecoverWorkflowRuns releases the running runs whose worker stopped sending heartbeats before staleBefore, after a
+// crash. They are queued again while attempts remain, failed otherwise, and cancelled when it was requested
+func (r *WorkflowRunRepository) RecoverWorkflowRuns(staleBefore int64, now int64) ([]automationsModels.WorkflowRunEntity, error) {
+	workflowRunEntities := []automationsModels.WorkflowRunEntity{}
+	statement := `
+		UPDATE workflow_run SET
+			status = CASE WHEN cancelrequested THEN 'Cancelled' WHEN attempt < maxattempts THEN 'Queued' ELSE 'Failed' END,
+			finishedat = CASE WHEN cancelrequested OR attempt >= maxattempts THEN $2 ELSE finishedat END,
+			runafter = $2,
+			error = 'Interrupted while running'
+		WHERE status = 'Running' AND heartbeatat < $1
+		RETURNING *
+	`
+	err := r.DB.Select(&workflowRunEntities, statement, staleBefore, now)
+	return workflowRunEntities, err
+}
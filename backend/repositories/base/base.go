@@ -0,0 +1,78 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+	baseModels "github.com/nambuitechx/go-metadata/models/base"
+)
+
+// DBTX is implemented by both *sqlx.DB and *sqlx.Tx, so a repository can run its statements
+// either directly on the connection pool or inside a transaction
+type DBTX interface {
+	Get(dest interface{}, query string, args ...interface{}) error
+	Select(dest interface{}, query string, args ...interface{}) error
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+type Transactor struct {
+	DB *sqlx.DB
+}
+
+func NewTransactor(db *sqlx.DB) *Transactor {
+	return &Transactor{ DB: db }
+}
+
+// RunInTx commits when fn returns nil and rolls back otherwise
+func (t *Transactor) RunInTx(fn func(tx *sqlx.Tx) error) error {
+	tx, err := t.DB.Beginx()
+
+	if err != nil {
+		return err
+	}
+
+	if err := fn(tx); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// DeletedCondition turns an include value (all | deleted | non-deleted) into a condition on the deleted column
+func DeletedCondition(include string) string {
+	switch include {
+	case "all":
+		return "TRUE"
+	case "deleted":
+		return "deleted = TRUE"
+	default:
+		return "deleted = FALSE"
+	}
+}
+
+// Paginate appends a keyset condition and an ordering on (fullyQualifiedName, id) to a select statement.
+// Rows before a cursor are read in descending order and one extra row is fetched, baseModels.NewPaging
+// turns the result back into a page
+func Paginate(statement string, args []interface{}, limit int, before *baseModels.Cursor, after *baseModels.Cursor) (string, []interface{}) {
+	order := "ASC"
+
+	if after != nil {
+		args = append(args, after.Name, after.ID)
+		statement += fmt.Sprintf(" AND (json->>'fullyQualifiedName', id) > ($%v, $%v)", len(args) - 1, len(args))
+	} else if before != nil {
+		args = append(args, before.Name, before.ID)
+		statement += fmt.Sprintf(" AND (json->>'fullyQualifiedName', id) < ($%v, $%v)", len(args) - 1, len(args))
+		order = "DESC"
+	}
+
+	statement += fmt.Sprintf(" ORDER BY json->>'fullyQualifiedName' %v, id %v", order, order)
+
+	if limit >= 0 {
+		args = append(args, limit + 1)
+		statement += fmt.Sprintf(" LIMIT $%v", len(args))
+	}
+
+	return statement, args
+}
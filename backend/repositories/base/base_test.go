@@ -0,0 +1,83 @@
+package repositories
+
+import (
+	"reflect"
+	"testing"
+
+	baseModels "github.com/nambuitechx/go-metadata/models/base"
+)
+
+func TestDeletedCondition(t *testing.T) {
+	tests := []struct {
+		include string
+		expected string
+	}{
+		{ include: "all", expected: "TRUE" },
+		{ include: "deleted", expected: "deleted = TRUE" },
+		{ include: "non-deleted", expected: "deleted = FALSE" },
+		{ include: "", expected: "deleted = FALSE" },
+	}
+
+	for _, test := range tests {
+		t.Run(test.include, func(t *testing.T) {
+			if condition := DeletedCondition(test.include); condition != test.expected {
+				t.Errorf("DeletedCondition(%q) = %q, expected %q", test.include, condition, test.expected)
+			}
+		})
+	}
+}
+
+func TestPaginate(t *testing.T) {
+	statement := "SELECT * FROM table_entity WHERE deleted = FALSE"
+	cursor := &baseModels.Cursor{ Name: "pg.shop", ID: "1" }
+
+	tests := []struct {
+		name string
+		limit int
+		before *baseModels.Cursor
+		after *baseModels.Cursor
+		expected string
+		expectedArgs []interface{}
+	}{
+		{
+			name: "first page",
+			limit: 10,
+			expected: statement + " ORDER BY json->>'fullyQualifiedName' ASC, id ASC LIMIT $2",
+			expectedArgs: []interface{}{"service", 11},
+		},
+		{
+			name: "after a cursor",
+			limit: 10,
+			after: cursor,
+			expected: statement + " AND (json->>'fullyQualifiedName', id) > ($2, $3) ORDER BY json->>'fullyQualifiedName' ASC, id ASC LIMIT $4",
+			expectedArgs: []interface{}{"service", "pg.shop", "1", 11},
+		},
+		{
+			name: "before a cursor",
+			limit: 10,
+			before: cursor,
+			expected: statement + " AND (json->>'fullyQualifiedName', id) < ($2, $3) ORDER BY json->>'fullyQualifiedName' DESC, id DESC LIMIT $4",
+			expectedArgs: []interface{}{"service", "pg.shop", "1", 11},
+		},
+		{
+			name: "no limit",
+			limit: -1,
+			expected: statement + " ORDER BY json->>'fullyQualifiedName' ASC, id ASC",
+			expectedArgs: []interface{}{"service"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			paginated, args := Paginate(statement, []interface{}{"service"}, test.limit, test.before, test.after)
+
+			if paginated != test.expected {
+				t.Errorf("Paginate() = %q, expected %q", paginated, test.expected)
+			}
+
+			if !reflect.DeepEqual(args, test.expectedArgs) {
+				t.Errorf("Paginate() args = %v, expected %v", args, test.expectedArgs)
+			}
+		})
+	}
+}
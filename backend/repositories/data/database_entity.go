@@ -2,50 +2,48 @@ package repositories
 
 import (
 	"github.com/jmoiron/sqlx"
+	baseRepositories "github.com/nambuitechx/go-metadata/repositories/base"
 	baseModels "github.com/nambuitechx/go-metadata/models/base"
 	dataModels "github.com/nambuitechx/go-metadata/models/data"
 )
 
 type DatabaseEntityRepository struct {
-	DB *sqlx.DB
+	DB baseRepositories.DBTX
 }
 
 func NewDatabaseEntityRepository(db *sqlx.DB) *DatabaseEntityRepository {
 	return &DatabaseEntityRepository{ DB: db }
 }
 
-func (r *DatabaseEntityRepository) SelectDatabaseEntities(service string, limit int, offset int) ([]dataModels.DatabaseEntity, error) {
-	databaseEntities := []dataModels.DatabaseEntity{}
-	var err error
-	
-	if limit < 0 {
-		statement := "SELECT * FROM database_entity WHERE json->>'fullyQualifiedName' LIKE ($1 || '.%')"
-		err = r.DB.Select(&databaseEntities, statement, service)
-	} else {
-		statement := "SELECT * FROM database_entity WHERE json->>'fullyQualifiedName' LIKE ($1 || '.%') LIMIT $2 OFFSET $3"
-		err = r.DB.Select(&databaseEntities, statement, service, limit, offset)
-	}
+func (r *DatabaseEntityRepository) WithTx(tx *sqlx.Tx) *DatabaseEntityRepository {
+	return &DatabaseEntityRepository{ DB: tx }
+}
 
+func (r *DatabaseEntityRepository) SelectDatabaseEntities(service string, include string, limit int, before *baseModels.Cursor, after *baseModels.Cursor) ([]dataModels.DatabaseEntity, error) {
+	databaseEntities := []dataModels.DatabaseEntity{}
+	statement := "SELECT * FROM database_entity WHERE starts_with(json->>'fullyQualifiedName', $1 || '.') AND " + baseRepositories.DeletedCondition(include)
+	statement, args := baseRepositories.Paginate(statement, []interface{}{service}, limit, before, after)
+	err := r.DB.Select(&databaseEntities, statement, args...)
 	return databaseEntities, err
 }
 
-func (r *DatabaseEntityRepository) SelectCountDatabaseEntities(service string) (*baseModels.EntityTotal, error) {
+func (r *DatabaseEntityRepository) SelectCountDatabaseEntities(service string, include string) (*baseModels.EntityTotal, error) {
 	entityTotal := &baseModels.EntityTotal{}
-	statement := "SELECT COUNT(id) as total FROM database_entity WHERE json->>'fullyQualifiedName' LIKE ($1 || '.%')"
+	statement := "SELECT COUNT(id) as total FROM database_entity WHERE starts_with(json->>'fullyQualifiedName', $1 || '.') AND " + baseRepositories.DeletedCondition(include)
 	err := r.DB.Get(entityTotal, statement, service)
 	return entityTotal, err
 }
 
-func (r *DatabaseEntityRepository) SelectDatabaseEntityById(id string) (*dataModels.DatabaseEntity, error) {
+func (r *DatabaseEntityRepository) SelectDatabaseEntityById(id string, include string) (*dataModels.DatabaseEntity, error) {
 	databaseEntity := &dataModels.DatabaseEntity{}
-	statement := "SELECT * FROM database_entity WHERE id = $1"
+	statement := "SELECT * FROM database_entity WHERE id = $1 AND " + baseRepositories.DeletedCondition(include)
 	err := r.DB.Get(databaseEntity, statement, id)
 	return databaseEntity, err
 }
 
-func (r *DatabaseEntityRepository) SelectDatabaseEntityByFqn(fqn string) (*dataModels.DatabaseEntity, error) {
+func (r *DatabaseEntityRepository) SelectDatabaseEntityByFqn(fqn string, include string) (*dataModels.DatabaseEntity, error) {
 	databaseEntity := &dataModels.DatabaseEntity{}
-	statement := "SELECT * FROM database_entity WHERE json->>'fullyQualifiedName' = $1"
+	statement := "SELECT * FROM database_entity WHERE json->>'fullyQualifiedName' = $1 AND " + baseRepositories.DeletedCondition(include)
 	err := r.DB.Get(databaseEntity, statement, fqn)
 	return databaseEntity, err
 }
@@ -70,12 +68,14 @@ func (r *DatabaseEntityRepository) InsertDatabaseEntity(payload *dataModels.Data
 	return &databaseEntity, err
 }
 
-func (r *DatabaseEntityRepository) UpdateDatabaseEntity(payload *dataModels.DatabaseEntity) (*dataModels.DatabaseEntity, error) {
+// UpdateDatabaseEntity only succeeds while the stored entity is still at version, so concurrent writers cannot
+// overwrite each other
+func (r *DatabaseEntityRepository) UpdateDatabaseEntity(payload *dataModels.DatabaseEntity, version float64) (*dataModels.DatabaseEntity, error) {
 	var databaseEntity = dataModels.DatabaseEntity{}
 	statement := `
 		UPDATE database_entity
 		SET name = $2, json = $3, updatedat = $4, updatedby = $5, deleted = $6, fqnhash = $7
-		WHERE id = $1 RETURNING *
+		WHERE id = $1 AND COALESCE((json->>'version')::float8, 0) = $8 RETURNING *
 	`
 	err := r.DB.Get(
 		&databaseEntity,
@@ -87,18 +87,46 @@ func (r *DatabaseEntityRepository) UpdateDatabaseEntity(payload *dataModels.Data
 		payload.UpdatedBy,
 		payload.Deleted,
 		payload.FqnHash,
+		version,
 	)
 	return &databaseEntity, err
 }
 
 func (r *DatabaseEntityRepository) DeleteDatabaseEntityById(id string) error {
-	statement := "DELETE FROM database_entity WHERE id = $1"
+	statement := `
+		WITH deleted AS (DELETE FROM database_entity WHERE id = $1 RETURNING id),
+		relationships AS (DELETE FROM entity_relationship WHERE fromid IN (SELECT id FROM deleted) OR toid IN (SELECT id FROM deleted))
+		DELETE FROM entity_extension WHERE id IN (SELECT id FROM deleted)
+	`
 	_, err := r.DB.Exec(statement, id)
 	return err
 }
 
 func (r *DatabaseEntityRepository) DeleteDatabaseEntityByFqn(fqn string) error {
-	statement := "DELETE FROM database_entity WHERE json->>'fullyQualifiedName' = $1"
+	statement := `
+		WITH deleted AS (DELETE FROM database_entity WHERE json->>'fullyQualifiedName' = $1 RETURNING id),
+		relationships AS (DELETE FROM entity_relationship WHERE fromid IN (SELECT id FROM deleted) OR toid IN (SELECT id FROM deleted))
+		DELETE FROM entity_extension WHERE id IN (SELECT id FROM deleted)
+	`
 	_, err := r.DB.Exec(statement, fqn)
 	return err
 }
+
+// SelectDatabaseEntitiesByPrefix returns the entities whose fullyQualifiedName starts with prefix followed by a dot,
+// deleted or not, locked until the end of the transaction
+func (r *DatabaseEntityRepository) SelectDatabaseEntitiesByPrefix(prefix string) ([]dataModels.DatabaseEntity, error) {
+	databaseEntities := []dataModels.DatabaseEntity{}
+	statement := "SELECT * FROM database_entity WHERE starts_with(json->>'fullyQualifiedName', $1 || '.') ORDER BY json->>'fullyQualifiedName' FOR UPDATE"
+	err := r.DB.Select(&databaseEntities, statement, prefix)
+	return databaseEntities, err
+}
+
+func (r *DatabaseEntityRepository) DeleteDatabaseEntitiesByPrefix(prefix string) error {
+	statement := `
+		WITH deleted AS (DELETE FROM database_entity WHERE starts_with(json->>'fullyQualifiedName', $1 || '.') RETURNING id),
+		relationships AS (DELETE FROM entity_relationship WHERE fromid IN (SELECT id FROM deleted) OR toid IN (SELECT id FROM deleted))
+		DELETE FROM entity_extension WHERE id IN (SELECT id FROM deleted)
+	`
+	_, err := r.DB.Exec(statement, prefix)
+	return err
+}
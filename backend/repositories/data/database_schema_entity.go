@@ -2,50 +2,48 @@ package repositories
 
 import (
 	"github.com/jmoiron/sqlx"
+	baseRepositories "github.com/nambuitechx/go-metadata/repositories/base"
 	baseModels "github.com/nambuitechx/go-metadata/models/base"
 	dataModels "github.com/nambuitechx/go-metadata/models/data"
 )
 
 type DatabaseSchemaEntityRepository struct {
-	DB *sqlx.DB
+	DB baseRepositories.DBTX
 }
 
 func NewDatabaseSchemaEntityRepository(db *sqlx.DB) *DatabaseSchemaEntityRepository {
 	return &DatabaseSchemaEntityRepository{ DB: db }
 }
 
-func (r *DatabaseSchemaEntityRepository) SelectDatabaseSchemaEntities(database string, limit int, offset int) ([]dataModels.DatabaseSchemaEntity, error) {
-	databaseSchemaEntities := []dataModels.DatabaseSchemaEntity{}
-	var err error
-	
-	if limit < 0 {
-		statement := "SELECT * FROM database_schema_entity WHERE json->>'fullyQualifiedName' LIKE ($1 || '.%')"
-		err = r.DB.Select(&databaseSchemaEntities, statement, database)
-	} else {
-		statement := "SELECT * FROM database_schema_entity WHERE json->>'fullyQualifiedName' LIKE ($1 || '.%') LIMIT $2 OFFSET $3"
-		err = r.DB.Select(&databaseSchemaEntities, statement, database, limit, offset)
-	}
+func (r *DatabaseSchemaEntityRepository) WithTx(tx *sqlx.Tx) *DatabaseSchemaEntityRepository {
+	return &DatabaseSchemaEntityRepository{ DB: tx }
+}
 
+func (r *DatabaseSchemaEntityRepository) SelectDatabaseSchemaEntities(database string, include string, limit int, before *baseModels.Cursor, after *baseModels.Cursor) ([]dataModels.DatabaseSchemaEntity, error) {
+	databaseSchemaEntities := []dataModels.DatabaseSchemaEntity{}
+	statement := "SELECT * FROM database_schema_entity WHERE starts_with(json->>'fullyQualifiedName', $1 || '.') AND " + baseRepositories.DeletedCondition(include)
+	statement, args := baseRepositories.Paginate(statement, []interface{}{database}, limit, before, after)
+	err := r.DB.Select(&databaseSchemaEntities, statement, args...)
 	return databaseSchemaEntities, err
 }
 
-func (r *DatabaseSchemaEntityRepository) SelectCountDatabaseSchemaEntities(database string) (*baseModels.EntityTotal, error) {
+func (r *DatabaseSchemaEntityRepository) SelectCountDatabaseSchemaEntities(database string, include string) (*baseModels.EntityTotal, error) {
 	entityTotal := &baseModels.EntityTotal{}
-	statement := "SELECT COUNT(id) as total FROM database_schema_entity WHERE json->>'fullyQualifiedName' LIKE ($1 || '.%')"
+	statement := "SELECT COUNT(id) as total FROM database_schema_entity WHERE starts_with(json->>'fullyQualifiedName', $1 || '.') AND " + baseRepositories.DeletedCondition(include)
 	err := r.DB.Get(entityTotal, statement, database)
 	return entityTotal, err
 }
 
-func (r *DatabaseSchemaEntityRepository) SelectDatabaseSchemaEntityById(id string) (*dataModels.DatabaseSchemaEntity, error) {
+func (r *DatabaseSchemaEntityRepository) SelectDatabaseSchemaEntityById(id string, include string) (*dataModels.DatabaseSchemaEntity, error) {
 	databaseSchemaEntity := &dataModels.DatabaseSchemaEntity{}
-	statement := "SELECT * FROM database_schema_entity WHERE id = $1"
+	statement := "SELECT * FROM database_schema_entity WHERE id = $1 AND " + baseRepositories.DeletedCondition(include)
 	err := r.DB.Get(databaseSchemaEntity, statement, id)
 	return databaseSchemaEntity, err
 }
 
-func (r *DatabaseSchemaEntityRepository) SelectDatabaseSchemaEntityByFqn(fqn string) (*dataModels.DatabaseSchemaEntity, error) {
+func (r *DatabaseSchemaEntityRepository) SelectDatabaseSchemaEntityByFqn(fqn string, include string) (*dataModels.DatabaseSchemaEntity, error) {
 	databaseSchemaEntity := &dataModels.DatabaseSchemaEntity{}
-	statement := "SELECT * FROM database_schema_entity WHERE json->>'fullyQualifiedName' = $1"
+	statement := "SELECT * FROM database_schema_entity WHERE json->>'fullyQualifiedName' = $1 AND " + baseRepositories.DeletedCondition(include)
 	err := r.DB.Get(databaseSchemaEntity, statement, fqn)
 	return databaseSchemaEntity, err
 }
@@ -70,12 +68,14 @@ func (r *DatabaseSchemaEntityRepository) InsertDatabaseSchemaEntity(payload *dat
 	return &databaseSchemaEntity, err
 }
 
-func (r *DatabaseSchemaEntityRepository) UpdateDatabaseSchemaEntity(payload *dataModels.DatabaseSchemaEntity) (*dataModels.DatabaseSchemaEntity, error) {
+// UpdateDatabaseSchemaEntity only succeeds while the stored entity is still at version, so concurrent writers cannot
+// overwrite each other
+func (r *DatabaseSchemaEntityRepository) UpdateDatabaseSchemaEntity(payload *dataModels.DatabaseSchemaEntity, version float64) (*dataModels.DatabaseSchemaEntity, error) {
 	var databaseSchemaEntity = dataModels.DatabaseSchemaEntity{}
 	statement := `
 		UPDATE database_schema_entity
 		SET name = $2, json = $3, updatedat = $4, updatedby = $5, deleted = $6, fqnhash = $7
-		WHERE id = $1 RETURNING *
+		WHERE id = $1 AND COALESCE((json->>'version')::float8, 0) = $8 RETURNING *
 	`
 	err := r.DB.Get(
 		&databaseSchemaEntity,
@@ -87,18 +87,46 @@ func (r *DatabaseSchemaEntityRepository) UpdateDatabaseSchemaEntity(payload *dat
 		payload.UpdatedBy,
 		payload.Deleted,
 		payload.FqnHash,
+		version,
 	)
 	return &databaseSchemaEntity, err
 }
 
 func (r *DatabaseSchemaEntityRepository) DeleteDatabaseSchemaEntityById(id string) error {
-	statement := "DELETE FROM database_schema_entity WHERE id = $1"
+	statement := `
+		WITH deleted AS (DELETE FROM database_schema_entity WHERE id = $1 RETURNING id),
+		relationships AS (DELETE FROM entity_relationship WHERE fromid IN (SELECT id FROM deleted) OR toid IN (SELECT id FROM deleted))
+		DELETE FROM entity_extension WHERE id IN (SELECT id FROM deleted)
+	`
 	_, err := r.DB.Exec(statement, id)
 	return err
 }
 
 func (r *DatabaseSchemaEntityRepository) DeleteDatabaseSchemaEntityByFqn(fqn string) error {
-	statement := "DELETE FROM database_schema_entity WHERE json->>'fullyQualifiedName' = $1"
+	statement := `
+		WITH deleted AS (DELETE FROM database_schema_entity WHERE json->>'fullyQualifiedName' = $1 RETURNING id),
+		relationships AS (DELETE FROM entity_relationship WHERE fromid IN (SELECT id FROM deleted) OR toid IN (SELECT id FROM deleted))
+		DELETE FROM entity_extension WHERE id IN (SELECT id FROM deleted)
+	`
 	_, err := r.DB.Exec(statement, fqn)
 	return err
 }
+
+// SelectDatabaseSchemaEntitiesByPrefix returns the entities whose fullyQualifiedName starts with prefix followed by a dot,
+// deleted or not, locked until the end of the transaction
+func (r *DatabaseSchemaEntityRepository) SelectDatabaseSchemaEntitiesByPrefix(prefix string) ([]dataModels.DatabaseSchemaEntity, error) {
+	databaseSchemaEntities := []dataModels.DatabaseSchemaEntity{}
+	statement := "SELECT * FROM database_schema_entity WHERE starts_with(json->>'fullyQualifiedName', $1 || '.') ORDER BY json->>'fullyQualifiedName' FOR UPDATE"
+	err := r.DB.Select(&databaseSchemaEntities, statement, prefix)
+	return databaseSchemaEntities, err
+}
+
+func (r *DatabaseSchemaEntityRepository) DeleteDatabaseSchemaEntitiesByPrefix(prefix string) error {
+	statement := `
+		WITH deleted AS (DELETE FROM database_schema_entity WHERE starts_with(json->>'fullyQualifiedName', $1 || '.') RETURNING id),
+		relationships AS (DELETE FROM entity_relationship WHERE fromid IN (SELECT id FROM deleted) OR toid IN (SELECT id FROM deleted))
+		DELETE FROM entity_extension WHERE id IN (SELECT id FROM deleted)
+	`
+	_, err := r.DB.Exec(statement, prefix)
+	return err
+}
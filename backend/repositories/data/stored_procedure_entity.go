@@ -2,50 +2,48 @@ package repositories
 
 import (
 	"github.com/jmoiron/sqlx"
+	baseRepositories "github.com/nambuitechx/go-metadata/repositories/base"
 	baseModels "github.com/nambuitechx/go-metadata/models/base"
 	dataModels "github.com/nambuitechx/go-metadata/models/data"
 )
 
 type StoredProcedureEntityRepository struct {
-	DB *sqlx.DB
+	DB baseRepositories.DBTX
 }
 
 func NewStoredProcedureEntityRepository(db *sqlx.DB) *StoredProcedureEntityRepository {
 	return &StoredProcedureEntityRepository{ DB: db }
 }
 
-func (r *StoredProcedureEntityRepository) SelectStoredProcedureEntities(databaseSchema string, limit int, offset int) ([]dataModels.StoredProcedureEntity, error) {
-	storedProcedureEntities := []dataModels.StoredProcedureEntity{}
-	var err error
-	
-	if limit < 0 {
-		statement := "SELECT * FROM stored_procedure_entity WHERE json->>'fullyQualifiedName' LIKE ($1 || '.%')"
-		err = r.DB.Select(&storedProcedureEntities, statement, databaseSchema)
-	} else {
-		statement := "SELECT * FROM stored_procedure_entity WHERE json->>'fullyQualifiedName' LIKE ($1 || '.%') LIMIT $2 OFFSET $3"
-		err = r.DB.Select(&storedProcedureEntities, statement, databaseSchema, limit, offset)
-	}
+func (r *StoredProcedureEntityRepository) WithTx(tx *sqlx.Tx) *StoredProcedureEntityRepository {
+	return &StoredProcedureEntityRepository{ DB: tx }
+}
 
+func (r *StoredProcedureEntityRepository) SelectStoredProcedureEntities(databaseSchema string, include string, limit int, before *baseModels.Cursor, after *baseModels.Cursor) ([]dataModels.StoredProcedureEntity, error) {
+	storedProcedureEntities := []dataModels.StoredProcedureEntity{}
+	statement := "SELECT * FROM stored_procedure_entity WHERE starts_with(json->>'fullyQualifiedName', $1 || '.') AND " + baseRepositories.DeletedCondition(include)
+	statement, args := baseRepositories.Paginate(statement, []interface{}{databaseSchema}, limit, before, after)
+	err := r.DB.Select(&storedProcedureEntities, statement, args...)
 	return storedProcedureEntities, err
 }
 
-func (r *StoredProcedureEntityRepository) SelectCountStoredProcedureEntities(databaseSchema string) (*baseModels.EntityTotal, error) {
+func (r *StoredProcedureEntityRepository) SelectCountStoredProcedureEntities(databaseSchema string, include string) (*baseModels.EntityTotal, error) {
 	entityTotal := &baseModels.EntityTotal{}
-	statement := "SELECT COUNT(id) as total FROM stored_procedure_entity WHERE json->>'fullyQualifiedName' LIKE ($1 || '.%')"
+	statement := "SELECT COUNT(id) as total FROM stored_procedure_entity WHERE starts_with(json->>'fullyQualifiedName', $1 || '.') AND " + baseRepositories.DeletedCondition(include)
 	err := r.DB.Get(entityTotal, statement, databaseSchema)
 	return entityTotal, err
 }
 
-func (r *StoredProcedureEntityRepository) SelectStoredProcedureEntityById(id string) (*dataModels.StoredProcedureEntity, error) {
+func (r *StoredProcedureEntityRepository) SelectStoredProcedureEntityById(id string, include string) (*dataModels.StoredProcedureEntity, error) {
 	storedProcedureEntity := &dataModels.StoredProcedureEntity{}
-	statement := "SELECT * FROM stored_procedure_entity WHERE id = $1"
+	statement := "SELECT * FROM stored_procedure_entity WHERE id = $1 AND " + baseRepositories.DeletedCondition(include)
 	err := r.DB.Get(storedProcedureEntity, statement, id)
 	return storedProcedureEntity, err
 }
 
-func (r *StoredProcedureEntityRepository) SelectStoredProcedureEntityByFqn(fqn string) (*dataModels.StoredProcedureEntity, error) {
+func (r *StoredProcedureEntityRepository) SelectStoredProcedureEntityByFqn(fqn string, include string) (*dataModels.StoredProcedureEntity, error) {
 	storedProcedureEntity := &dataModels.StoredProcedureEntity{}
-	statement := "SELECT * FROM stored_procedure_entity WHERE json->>'fullyQualifiedName' = $1"
+	statement := "SELECT * FROM stored_procedure_entity WHERE json->>'fullyQualifiedName' = $1 AND " + baseRepositories.DeletedCondition(include)
 	err := r.DB.Get(storedProcedureEntity, statement, fqn)
 	return storedProcedureEntity, err
 }
@@ -70,12 +68,14 @@ func (r *StoredProcedureEntityRepository) InsertStoredProcedureEntity(payload *d
 	return &storedProcedureEntity, err
 }
 
-func (r *StoredProcedureEntityRepository) UpdateStoredProcedureEntity(payload *dataModels.StoredProcedureEntity) (*dataModels.StoredProcedureEntity, error) {
+// UpdateStoredProcedureEntity only succeeds while the stored entity is still at version, so concurrent writers cannot
+// overwrite each other
+func (r *StoredProcedureEntityRepository) UpdateStoredProcedureEntity(payload *dataModels.StoredProcedureEntity, version float64) (*dataModels.StoredProcedureEntity, error) {
 	var storedProcedureEntity = dataModels.StoredProcedureEntity{}
 	statement := `
 		UPDATE stored_procedure_entity
 		SET name = $2, json = $3, updatedat = $4, updatedby = $5, deleted = $6, fqnhash = $7
-		WHERE id = $1 RETURNING *
+		WHERE id = $1 AND COALESCE((json->>'version')::float8, 0) = $8 RETURNING *
 	`
 	err := r.DB.Get(
 		&storedProcedureEntity,
@@ -87,18 +87,46 @@ func (r *StoredProcedureEntityRepository) UpdateStoredProcedureEntity(payload *d
 		payload.UpdatedBy,
 		payload.Deleted,
 		payload.FqnHash,
+		version,
 	)
 	return &storedProcedureEntity, err
 }
 
 func (r *StoredProcedureEntityRepository) DeleteStoredProcedureEntityById(id string) error {
-	statement := "DELETE FROM stored_procedure_entity WHERE id = $1"
+	statement := `
+		WITH deleted AS (DELETE FROM stored_procedure_entity WHERE id = $1 RETURNING id),
+		relationships AS (DELETE FROM entity_relationship WHERE fromid IN (SELECT id FROM deleted) OR toid IN (SELECT id FROM deleted))
+		DELETE FROM entity_extension WHERE id IN (SELECT id FROM deleted)
+	`
 	_, err := r.DB.Exec(statement, id)
 	return err
 }
 
 func (r *StoredProcedureEntityRepository) DeleteStoredProcedureEntityByFqn(fqn string) error {
-	statement := "DELETE FROM stored_procedure_entity WHERE json->>'fullyQualifiedName' = $1"
+	statement := `
+		WITH deleted AS (DELETE FROM stored_procedure_entity WHERE json->>'fullyQualifiedName' = $1 RETURNING id),
+		relationships AS (DELETE FROM entity_relationship WHERE fromid IN (SELECT id FROM deleted) OR toid IN (SELECT id FROM deleted))
+		DELETE FROM entity_extension WHERE id IN (SELECT id FROM deleted)
+	`
 	_, err := r.DB.Exec(statement, fqn)
 	return err
 }
+
+// SelectStoredProcedureEntitiesByPrefix returns the entities whose fullyQualifiedName starts with prefix followed by a dot,
+// deleted or not, locked until the end of the transaction
+func (r *StoredProcedureEntityRepository) SelectStoredProcedureEntitiesByPrefix(prefix string) ([]dataModels.StoredProcedureEntity, error) {
+	storedProcedureEntities := []dataModels.StoredProcedureEntity{}
+	statement := "SELECT * FROM stored_procedure_entity WHERE starts_with(json->>'fullyQualifiedName', $1 || '.') ORDER BY json->>'fullyQualifiedName' FOR UPDATE"
+	err := r.DB.Select(&storedProcedureEntities, statement, prefix)
+	return storedProcedureEntities, err
+}
+
+func (r *StoredProcedureEntityRepository) DeleteStoredProcedureEntitiesByPrefix(prefix string) error {
+	statement := `
+		WITH deleted AS (DELETE FROM stored_procedure_entity WHERE starts_with(json->>'fullyQualifiedName', $1 || '.') RETURNING id),
+		relationships AS (DELETE FROM entity_relationship WHERE fromid IN (SELECT id FROM deleted) OR toid IN (SELECT id FROM deleted))
+		DELETE FROM entity_extension WHERE id IN (SELECT id FROM deleted)
+	`
+	_, err := r.DB.Exec(statement, prefix)
+	return err
+}
@@ -1,55 +1,79 @@
 package repositories
 
 import (
+	"fmt"
+
 	"github.com/jmoiron/sqlx"
+	baseRepositories "github.com/nambuitechx/go-metadata/repositories/base"
 	baseModels "github.com/nambuitechx/go-metadata/models/base"
 	dataModels "github.com/nambuitechx/go-metadata/models/data"
+	teamsRepositories "github.com/nambuitechx/go-metadata/repositories/teams"
 )
 
 type TableEntityRepository struct {
-	DB *sqlx.DB
+	DB baseRepositories.DBTX
 }
 
 func NewTableEntityRepository(db *sqlx.DB) *TableEntityRepository {
 	return &TableEntityRepository{ DB: db }
 }
 
-func (r *TableEntityRepository) SelectTableEntities(databaseSchema string, limit int, offset int) ([]dataModels.TableEntity, error) {
-	tableEntities := []dataModels.TableEntity{}
-	var err error
-	
-	if limit < 0 {
-		statement := "SELECT * FROM table_entity WHERE json->>'fullyQualifiedName' LIKE ($1 || '.%')"
-		err = r.DB.Select(&tableEntities, statement, databaseSchema)
-	} else {
-		statement := "SELECT * FROM table_entity WHERE json->>'fullyQualifiedName' LIKE ($1 || '.%') LIMIT $2 OFFSET $3"
-		err = r.DB.Select(&tableEntities, statement, databaseSchema, limit, offset)
+func (r *TableEntityRepository) WithTx(tx *sqlx.Tx) *TableEntityRepository {
+	return &TableEntityRepository{ DB: tx }
+}
+
+// tablesCondition filters on the database schema and, when ownerId is set, on the effective owners
+func tablesCondition(databaseSchema string, ownerId string, include string) (string, []interface{}) {
+	condition := "($1 = '' OR starts_with(json->>'fullyQualifiedName', $1 || '.')) AND " + baseRepositories.DeletedCondition(include)
+	args := []interface{}{databaseSchema}
+
+	if ownerId != "" {
+		args = append(args, ownerId)
+		condition += " AND " + teamsRepositories.OwnerCondition("table_entity", fmt.Sprintf("$%v", len(args)))
 	}
 
+	return condition, args
+}
+
+func (r *TableEntityRepository) SelectTableEntities(databaseSchema string, ownerId string, include string, limit int, before *baseModels.Cursor, after *baseModels.Cursor) ([]dataModels.TableEntity, error) {
+	tableEntities := []dataModels.TableEntity{}
+	condition, args := tablesCondition(databaseSchema, ownerId, include)
+	statement := "SELECT * FROM table_entity WHERE " + condition
+	statement, args = baseRepositories.Paginate(statement, args, limit, before, after)
+	err := r.DB.Select(&tableEntities, statement, args...)
 	return tableEntities, err
 }
 
-func (r *TableEntityRepository) SelectCountTableEntities(databaseSchema string) (*baseModels.EntityTotal, error) {
+func (r *TableEntityRepository) SelectCountTableEntities(databaseSchema string, ownerId string, include string) (*baseModels.EntityTotal, error) {
 	entityTotal := &baseModels.EntityTotal{}
-	statement := "SELECT COUNT(id) as total FROM table_entity WHERE json->>'fullyQualifiedName' LIKE ($1 || '.%')"
-	err := r.DB.Get(entityTotal, statement, databaseSchema)
+	condition, args := tablesCondition(databaseSchema, ownerId, include)
+	statement := "SELECT COUNT(id) as total FROM table_entity WHERE " + condition
+	err := r.DB.Get(entityTotal, statement, args...)
 	return entityTotal, err
 }
 
-func (r *TableEntityRepository) SelectTableEntityById(id string) (*dataModels.TableEntity, error) {
+func (r *TableEntityRepository) SelectTableEntityById(id string, include string) (*dataModels.TableEntity, error) {
 	tableEntity := &dataModels.TableEntity{}
-	statement := "SELECT * FROM table_entity WHERE id = $1"
+	statement := "SELECT * FROM table_entity WHERE id = $1 AND " + baseRepositories.DeletedCondition(include)
 	err := r.DB.Get(tableEntity, statement, id)
 	return tableEntity, err
 }
 
-func (r *TableEntityRepository) SelectTableEntityByFqn(fqn string) (*dataModels.TableEntity, error) {
+func (r *TableEntityRepository) SelectTableEntityByFqn(fqn string, include string) (*dataModels.TableEntity, error) {
 	tableEntity := &dataModels.TableEntity{}
-	statement := "SELECT * FROM table_entity WHERE json->>'fullyQualifiedName' = $1"
+	statement := "SELECT * FROM table_entity WHERE json->>'fullyQualifiedName' = $1 AND " + baseRepositories.DeletedCondition(include)
 	err := r.DB.Get(tableEntity, statement, fqn)
 	return tableEntity, err
 }
 
+// SelectTableEntitiesByFqnIgnoreCase returns the tables whose fullyQualifiedName equals fqn in any case
+func (r *TableEntityRepository) SelectTableEntitiesByFqnIgnoreCase(fqn string, include string) ([]dataModels.TableEntity, error) {
+	tableEntities := []dataModels.TableEntity{}
+	statement := "SELECT * FROM table_entity WHERE lower(json->>'fullyQualifiedName') = lower($1) AND " + baseRepositories.DeletedCondition(include)
+	err := r.DB.Select(&tableEntities, statement, fqn)
+	return tableEntities, err
+}
+
 func (r *TableEntityRepository) InsertTableEntity(payload *dataModels.TableEntity) (*dataModels.TableEntity, error) {
 	var tableEntity = dataModels.TableEntity{}
 	statement := `
@@ -70,12 +94,14 @@ func (r *TableEntityRepository) InsertTableEntity(payload *dataModels.TableEntit
 	return &tableEntity, err
 }
 
-func (r *TableEntityRepository) UpdateTableEntity(payload *dataModels.TableEntity) (*dataModels.TableEntity, error) {
+// UpdateTableEntity only succeeds while the stored entity is still at version, so concurrent writers cannot
+// overwrite each other
+func (r *TableEntityRepository) UpdateTableEntity(payload *dataModels.TableEntity, version float64) (*dataModels.TableEntity, error) {
 	var tableEntity = dataModels.TableEntity{}
 	statement := `
 		UPDATE table_entity
 		SET name = $2, json = $3, updatedat = $4, updatedby = $5, deleted = $6, fqnhash = $7
-		WHERE id = $1 RETURNING *
+		WHERE id = $1 AND COALESCE((json->>'version')::float8, 0) = $8 RETURNING *
 	`
 	err := r.DB.Get(
 		&tableEntity,
@@ -87,18 +113,46 @@ func (r *TableEntityRepository) UpdateTableEntity(payload *dataModels.TableEntit
 		payload.UpdatedBy,
 		payload.Deleted,
 		payload.FqnHash,
+		version,
 	)
 	return &tableEntity, err
 }
 
 func (r *TableEntityRepository) DeleteTableEntityById(id string) error {
-	statement := "DELETE FROM table_entity WHERE id = $1"
+	statement := `
+		WITH deleted AS (DELETE FROM table_entity WHERE id = $1 RETURNING id),
+		relationships AS (DELETE FROM entity_relationship WHERE fromid IN (SELECT id FROM deleted) OR toid IN (SELECT id FROM deleted))
+		DELETE FROM entity_extension WHERE id IN (SELECT id FROM deleted)
+	`
 	_, err := r.DB.Exec(statement, id)
 	return err
 }
 
 func (r *TableEntityRepository) DeleteTableEntityByFqn(fqn string) error {
-	statement := "DELETE FROM table_entity WHERE json->>'fullyQualifiedName' = $1"
+	statement := `
+		WITH deleted AS (DELETE FROM table_entity WHERE json->>'fullyQualifiedName' = $1 RETURNING id),
+		relationships AS (DELETE FROM entity_relationship WHERE fromid IN (SELECT id FROM deleted) OR toid IN (SELECT id FROM deleted))
+		DELETE FROM entity_extension WHERE id IN (SELECT id FROM deleted)
+	`
 	_, err := r.DB.Exec(statement, fqn)
 	return err
 }
+
+// SelectTableEntitiesByPrefix returns the entities whose fullyQualifiedName starts with prefix followed by a dot,
+// deleted or not, locked until the end of the transaction
+func (r *TableEntityRepository) SelectTableEntitiesByPrefix(prefix string) ([]dataModels.TableEntity, error) {
+	tableEntities := []dataModels.TableEntity{}
+	statement := "SELECT * FROM table_entity WHERE starts_with(json->>'fullyQualifiedName', $1 || '.') ORDER BY json->>'fullyQualifiedName' FOR UPDATE"
+	err := r.DB.Select(&tableEntities, statement, prefix)
+	return tableEntities, err
+}
+
+func (r *TableEntityRepository) DeleteTableEntitiesByPrefix(prefix string) error {
+	statement := `
+		WITH deleted AS (DELETE FROM table_entity WHERE starts_with(json->>'fullyQualifiedName', $1 || '.') RETURNING id),
+		relationships AS (DELETE FROM entity_relationship WHERE fromid IN (SELECT id FROM deleted) OR toid IN (SELECT id FROM deleted))
+		DELETE FROM entity_extension WHERE id IN (SELECT id FROM deleted)
+	`
+	_, err := r.DB.Exec(statement, prefix)
+	return err
+}
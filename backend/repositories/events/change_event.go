@@ -0,0 +1,151 @@
+package repositories
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+	baseRepositories "github.com/nambuitechx/go-metadata/repositories/base"
+	eventsModels "github.com/nambuitechx/go-metadata/models/events"
+)
+
+type ChangeEventRepository struct {
+	DB baseRepositories.DBTX
+}
+
+func NewChangeEventRepository(db *sqlx.DB) *ChangeEventRepository {
+	return &ChangeEventRepository{ DB: db }
+}
+
+func (r *ChangeEventRepository) WithTx(tx *sqlx.Tx) *ChangeEventRepository {
+	return &ChangeEventRepository{ DB: tx }
+}
+
+// SelectChangeEvents returns the events since timestamp whose entity type is listed under their event type,
+// the entity type "*" matches every entity
+func (r *ChangeEventRepository) SelectChangeEvents(filters map[string][]string, timestamp int64) ([]eventsModels.ChangeEventEntity, error) {
+	changeEventEntities := []eventsModels.ChangeEventEntity{}
+	args := []interface{}{ timestamp }
+	conditions := []string{}
+	eventTypes := []string{}
+
+	for eventType := range filters {
+		eventTypes = append(eventTypes, eventType)
+	}
+
+	sort.Strings(eventTypes)
+
+	for _, eventType := range eventTypes {
+		args = append(args, eventType)
+		condition := fmt.Sprintf("eventtype = $%v", len(args))
+		placeholders := []string{}
+		matchAll := false
+
+		for _, entityType := range filters[eventType] {
+			if entityType == "*" {
+				matchAll = true
+				break
+			}
+
+			args = append(args, entityType)
+			placeholders = append(placeholders, fmt.Sprintf("$%v", len(args)))
+		}
+
+		if !matchAll {
+			condition += " AND entitytype IN (" + strings.Join(placeholders, ", ") + ")"
+		}
+
+		conditions = append(conditions, "(" + condition + ")")
+	}
+
+	if len(conditions) == 0 {
+		return changeEventEntities, nil
+	}
+
+	statement := `SELECT * FROM change_event WHERE "offset" IS NOT NULL AND eventtime >= $1 AND (` + strings.Join(conditions, " OR ") + `) ORDER BY "offset"`
+	err := r.DB.Select(&changeEventEntities, statement, args...)
+	return changeEventEntities, err
+}
+
+// InsertChangeEvent records an event without an offset, PublishChangeEvents assigns it once the event is committed
+func (r *ChangeEventRepository) InsertChangeEvent(payload *eventsModels.ChangeEventEntity) (*eventsModels.ChangeEventEntity, error) {
+	var changeEventEntity = eventsModels.ChangeEventEntity{}
+	statement := `
+		INSERT INTO change_event(eventtype, entitytype, username, eventtime, json)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, eventtype, entitytype, username, eventtime, json
+	`
+	err := r.DB.Get(
+		&changeEventEntity,
+		statement,
+		payload.EventType,
+		payload.EntityType,
+		payload.UserName,
+		payload.EventTime,
+		payload.Json,
+	)
+	return &changeEventEntity, err
+}
+
+// changeEventPublisherLock is only taken by the publishers, the transactions recording events never wait on it
+const changeEventPublisherLock = 7301
+
+// PublishChangeEvents gives the committed events without an offset the next offsets. The publishers run one at a time
+// and their offsets become visible all at once on commit, so a reader resuming after an offset never misses an event
+// published later with a lower one. It returns the number of published events, none when another publisher is running
+func (r *ChangeEventRepository) PublishChangeEvents(limit int) (int64, error) {
+	statement := fmt.Sprintf(`
+		WITH publisher AS (SELECT pg_try_advisory_xact_lock(%d) AS locked),
+		pending AS (
+			SELECT change_event.id FROM change_event, publisher
+			WHERE publisher.locked AND change_event."offset" IS NULL
+			ORDER BY change_event.id LIMIT $1
+			FOR UPDATE OF change_event
+		),
+		numbered AS (
+			SELECT ordered.id, nextval('change_event_offset_seq') AS "offset"
+			FROM (SELECT id FROM pending ORDER BY id) ordered
+		)
+		UPDATE change_event SET "offset" = numbered."offset" FROM numbered WHERE change_event.id = numbered.id
+	`, changeEventPublisherLock)
+	result, err := r.DB.Exec(statement, limit)
+
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+func (r *ChangeEventRepository) SelectChangeEventsAfterOffset(offset int64, limit int) ([]eventsModels.ChangeEventEntity, error) {
+	changeEventEntities := []eventsModels.ChangeEventEntity{}
+	statement := `SELECT * FROM change_event WHERE "offset" > $1 ORDER BY "offset" LIMIT $2`
+	err := r.DB.Select(&changeEventEntities, statement, offset, limit)
+	return changeEventEntities, err
+}
+
+// SelectChangeEventsByEntityType returns the events of an entity type, published or not, the unpublished ones without
+// their offset
+func (r *ChangeEventRepository) SelectChangeEventsByEntityType(entityType string) ([]eventsModels.ChangeEventEntity, error) {
+	changeEventEntities := []eventsModels.ChangeEventEntity{}
+	statement := `
+		SELECT id, COALESCE("offset", 0) AS "offset", eventtype, entitytype, username, eventtime, json
+		FROM change_event WHERE entitytype = $1 ORDER BY id
+	`
+	err := r.DB.Select(&changeEventEntities, statement, entityType)
+	return changeEventEntities, err
+}
+
+func (r *ChangeEventRepository) UpdateChangeEventJson(id int64, json *eventsModels.ChangeEvent) error {
+	statement := `UPDATE change_event SET json = $2 WHERE id = $1`
+	_, err := r.DB.Exec(statement, id, json)
+	return err
+}
+
+func (r *ChangeEventRepository) SelectLatestOffset() (int64, error) {
+	var offset int64
+	statement := `SELECT COALESCE(MAX("offset"), 0) FROM change_event`
+	err := r.DB.Get(&offset, statement)
+	return offset, err
+}
@@ -0,0 +1,159 @@
+package repositories
+
+import (
+	"github.com/jmoiron/sqlx"
+	baseRepositories "github.com/nambuitechx/go-metadata/repositories/base"
+	baseModels "github.com/nambuitechx/go-metadata/models/base"
+	eventsModels "github.com/nambuitechx/go-metadata/models/events"
+)
+
+type EventSubscriptionRepository struct {
+	DB baseRepositories.DBTX
+}
+
+func NewEventSubscriptionRepository(db *sqlx.DB) *EventSubscriptionRepository {
+	return &EventSubscriptionRepository{ DB: db }
+}
+
+func (r *EventSubscriptionRepository) WithTx(tx *sqlx.Tx) *EventSubscriptionRepository {
+	return &EventSubscriptionRepository{ DB: tx }
+}
+
+func (r *EventSubscriptionRepository) SelectEventSubscriptionEntities(include string, limit int, before *baseModels.Cursor, after *baseModels.Cursor) ([]eventsModels.EventSubscriptionEntity, error) {
+	eventSubscriptionEntities := []eventsModels.EventSubscriptionEntity{}
+	statement := "SELECT * FROM event_subscription_entity WHERE " + baseRepositories.DeletedCondition(include)
+	statement, args := baseRepositories.Paginate(statement, []interface{}{}, limit, before, after)
+	err := r.DB.Select(&eventSubscriptionEntities, statement, args...)
+	return eventSubscriptionEntities, err
+}
+
+// ClaimEventSubscriptions claims the enabled subscriptions which are not claimed or whose claim went stale before
+// staleBefore, the subscriptions being claimed by other dispatchers are skipped
+func (r *EventSubscriptionRepository) ClaimEventSubscriptions(claimId string, now int64, staleBefore int64) ([]eventsModels.EventSubscriptionEntity, error) {
+	eventSubscriptionEntities := []eventsModels.EventSubscriptionEntity{}
+	statement := `
+		UPDATE event_subscription_entity SET claimid = $1, heartbeatat = $2
+		WHERE id IN (
+			SELECT id FROM event_subscription_entity
+			WHERE deleted = FALSE AND (json->>'enabled')::boolean = TRUE AND (claimid = '' OR heartbeatat < $3)
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING *
+	`
+	err := r.DB.Select(&eventSubscriptionEntities, statement, claimId, now, staleBefore)
+	return eventSubscriptionEntities, err
+}
+
+// HeartbeatEventSubscription keeps a claim alive, it returns sql.ErrNoRows when the claim was taken over
+func (r *EventSubscriptionRepository) HeartbeatEventSubscription(id string, claimId string, now int64) error {
+	var heartbeatAt int64
+	statement := "UPDATE event_subscription_entity SET heartbeatat = $3 WHERE id = $1 AND claimid = $2 RETURNING heartbeatat"
+	return r.DB.Get(&heartbeatAt, statement, id, claimId, now)
+}
+
+func (r *EventSubscriptionRepository) ReleaseEventSubscription(id string, claimId string) error {
+	statement := "UPDATE event_subscription_entity SET claimid = '', heartbeatat = 0 WHERE id = $1 AND claimid = $2"
+	_, err := r.DB.Exec(statement, id, claimId)
+	return err
+}
+
+func (r *EventSubscriptionRepository) SelectCountEventSubscriptionEntities(include string) (*baseModels.EntityTotal, error) {
+	entityTotal := &baseModels.EntityTotal{}
+	statement := "SELECT COUNT(id) as total FROM event_subscription_entity WHERE " + baseRepositories.DeletedCondition(include)
+	err := r.DB.Get(entityTotal, statement)
+	return entityTotal, err
+}
+
+func (r *EventSubscriptionRepository) SelectEventSubscriptionEntityById(id string, include string) (*eventsModels.EventSubscriptionEntity, error) {
+	eventSubscriptionEntity := &eventsModels.EventSubscriptionEntity{}
+	statement := "SELECT * FROM event_subscription_entity WHERE id = $1 AND " + baseRepositories.DeletedCondition(include)
+	err := r.DB.Get(eventSubscriptionEntity, statement, id)
+	return eventSubscriptionEntity, err
+}
+
+func (r *EventSubscriptionRepository) SelectEventSubscriptionEntityByFqn(fqn string, include string) (*eventsModels.EventSubscriptionEntity, error) {
+	eventSubscriptionEntity := &eventsModels.EventSubscriptionEntity{}
+	statement := "SELECT * FROM event_subscription_entity WHERE json->>'fullyQualifiedName' = $1 AND " + baseRepositories.DeletedCondition(include)
+	err := r.DB.Get(eventSubscriptionEntity, statement, fqn)
+	return eventSubscriptionEntity, err
+}
+
+func (r *EventSubscriptionRepository) InsertEventSubscriptionEntity(payload *eventsModels.EventSubscriptionEntity) (*eventsModels.EventSubscriptionEntity, error) {
+	var eventSubscriptionEntity = eventsModels.EventSubscriptionEntity{}
+	statement := `
+		INSERT INTO event_subscription_entity(id, name, json, updatedat, updatedby, deleted, namehash)
+		VALUES($1, $2, $3, $4, $5, $6, $7) RETURNING *
+	`
+	err := r.DB.Get(
+		&eventSubscriptionEntity,
+		statement,
+		payload.ID,
+		payload.Name,
+		payload.Json,
+		payload.UpdatedAt,
+		payload.UpdatedBy,
+		payload.Deleted,
+		payload.NameHash,
+	)
+	return &eventSubscriptionEntity, err
+}
+
+// UpdateEventSubscriptionEntity keeps the dispatcher owned offset and status of the stored row
+func (r *EventSubscriptionRepository) UpdateEventSubscriptionEntity(payload *eventsModels.EventSubscriptionEntity) (*eventsModels.EventSubscriptionEntity, error) {
+	var eventSubscriptionEntity = eventsModels.EventSubscriptionEntity{}
+	statement := `
+		UPDATE event_subscription_entity
+		SET name = $2,
+			json = $3::jsonb || jsonb_build_object('offset', json->'offset', 'status', json->'status'),
+			updatedat = $4, updatedby = $5, deleted = $6, namehash = $7
+		WHERE id = $1 RETURNING *
+	`
+	err := r.DB.Get(
+		&eventSubscriptionEntity,
+		statement,
+		payload.ID,
+		payload.Name,
+		payload.Json,
+		payload.UpdatedAt,
+		payload.UpdatedBy,
+		payload.Deleted,
+		payload.NameHash,
+	)
+	return &eventSubscriptionEntity, err
+}
+
+// UpdateEventSubscriptionDelivery moves the offset of a subscription, only while the dispatcher still holds its claim
+func (r *EventSubscriptionRepository) UpdateEventSubscriptionDelivery(id string, claimId string, offset int64, status *eventsModels.EventSubscriptionStatus) error {
+	statement := `
+		UPDATE event_subscription_entity
+		SET json = json || jsonb_build_object('offset', $3::bigint, 'status', $4::jsonb)
+		WHERE id = $1 AND claimid = $2
+	`
+	_, err := r.DB.Exec(statement, id, claimId, offset, status)
+	return err
+}
+
+func (r *EventSubscriptionRepository) DeleteEventSubscriptionEntityById(id string) error {
+	statement := `
+		WITH deleted AS (DELETE FROM event_subscription_entity WHERE id = $1 RETURNING id)
+		DELETE FROM event_subscription_dead_letter WHERE subscriptionid IN (SELECT id FROM deleted)
+	`
+	_, err := r.DB.Exec(statement, id)
+	return err
+}
+
+func (r *EventSubscriptionRepository) SelectDeadLetters(subscriptionID string, limit int) ([]eventsModels.DeadLetterEntity, error) {
+	deadLetterEntities := []eventsModels.DeadLetterEntity{}
+	statement := `SELECT * FROM event_subscription_dead_letter WHERE subscriptionid = $1 ORDER BY failedat DESC, "offset" DESC LIMIT $2`
+	err := r.DB.Select(&deadLetterEntities, statement, subscriptionID, limit)
+	return deadLetterEntities, err
+}
+
+func (r *EventSubscriptionRepository) InsertDeadLetter(payload *eventsModels.DeadLetterEntity) error {
+	statement := `
+		INSERT INTO event_subscription_dead_letter(id, subscriptionid, "offset", failedat, json)
+		VALUES($1, $2, $3, $4, $5)
+	`
+	_, err := r.DB.Exec(statement, payload.ID, payload.SubscriptionID, payload.Offset, payload.FailedAt, payload.Json)
+	return err
+}
@@ -0,0 +1,163 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+	baseRepositories "github.com/nambuitechx/go-metadata/repositories/base"
+	dataModels "github.com/nambuitechx/go-metadata/models/data"
+	lineageModels "github.com/nambuitechx/go-metadata/models/lineage"
+	typeModels "github.com/nambuitechx/go-metadata/models/type"
+)
+
+// upstreamEdgesStatement returns the upstream edges of $1 up to the depth placeholder, following relationships
+// from toid to fromid. The walk stops at deleted tables, so the tables beyond them are not reached either
+func upstreamEdgesStatement(depth string) string {
+	return fmt.Sprintf(`
+	upstream(fromid, toid, json, depth) AS (
+		SELECT r.fromid, r.toid, r.json, 1 FROM entity_relationship r
+		JOIN table_entity f ON f.id = r.fromid AND f.deleted = FALSE
+		WHERE r.toid = $1 AND r.relation = $2 AND r.deleted = FALSE AND %[1]v > 0
+		UNION
+		SELECT r.fromid, r.toid, r.json, u.depth + 1 FROM entity_relationship r
+		JOIN upstream u ON r.toid = u.fromid
+		JOIN table_entity f ON f.id = r.fromid AND f.deleted = FALSE
+		WHERE r.relation = $2 AND r.deleted = FALSE AND u.depth < %[1]v
+	),
+	upstream_edges AS (
+		SELECT DISTINCT ON (u.fromid, u.toid) u.fromid, u.toid, u.json FROM upstream u
+		ORDER BY u.fromid, u.toid
+	)
+	`, depth)
+}
+
+// downstreamEdgesStatement returns the downstream edges of $1 up to the depth placeholder, following relationships
+// from fromid to toid
+func downstreamEdgesStatement(depth string) string {
+	return fmt.Sprintf(`
+	downstream(fromid, toid, json, depth) AS (
+		SELECT r.fromid, r.toid, r.json, 1 FROM entity_relationship r
+		JOIN table_entity t ON t.id = r.toid AND t.deleted = FALSE
+		WHERE r.fromid = $1 AND r.relation = $2 AND r.deleted = FALSE AND %[1]v > 0
+		UNION
+		SELECT r.fromid, r.toid, r.json, d.depth + 1 FROM entity_relationship r
+		JOIN downstream d ON r.fromid = d.toid
+		JOIN table_entity t ON t.id = r.toid AND t.deleted = FALSE
+		WHERE r.relation = $2 AND r.deleted = FALSE AND d.depth < %[1]v
+	),
+	downstream_edges AS (
+		SELECT DISTINCT ON (d.fromid, d.toid) d.fromid, d.toid, d.json FROM downstream d
+		ORDER BY d.fromid, d.toid
+	)
+	`, depth)
+}
+
+type LineageRepository struct {
+	DB baseRepositories.DBTX
+}
+
+func NewLineageRepository(db *sqlx.DB) *LineageRepository {
+	return &LineageRepository{ DB: db }
+}
+
+func (r *LineageRepository) WithTx(tx *sqlx.Tx) *LineageRepository {
+	return &LineageRepository{ DB: tx }
+}
+
+func (r *LineageRepository) SelectLineageEdge(fromId string, toId string) (*lineageModels.LineageEdgeEntity, error) {
+	lineageEdgeEntity := &lineageModels.LineageEdgeEntity{}
+	statement := `
+		SELECT fromid, toid, json FROM entity_relationship
+		WHERE fromid = $1 AND toid = $2 AND relation = $3 AND deleted = FALSE
+	`
+	err := r.DB.Get(lineageEdgeEntity, statement, fromId, toId, typeModels.Relationship["UPSTREAM"])
+	return lineageEdgeEntity, err
+}
+
+func (r *LineageRepository) SelectUpstreamEdges(id string, depth int) ([]lineageModels.LineageEdgeEntity, error) {
+	lineageEdgeEntities := []lineageModels.LineageEdgeEntity{}
+	statement := "WITH RECURSIVE " + upstreamEdgesStatement("$3") + " SELECT * FROM upstream_edges"
+	err := r.DB.Select(&lineageEdgeEntities, statement, id, typeModels.Relationship["UPSTREAM"], depth)
+	return lineageEdgeEntities, err
+}
+
+func (r *LineageRepository) SelectDownstreamEdges(id string, depth int) ([]lineageModels.LineageEdgeEntity, error) {
+	lineageEdgeEntities := []lineageModels.LineageEdgeEntity{}
+	statement := "WITH RECURSIVE " + downstreamEdgesStatement("$3") + " SELECT * FROM downstream_edges"
+	err := r.DB.Select(&lineageEdgeEntities, statement, id, typeModels.Relationship["UPSTREAM"], depth)
+	return lineageEdgeEntities, err
+}
+
+// SelectLineageNodes returns the tables reached by the upstream and downstream edges, without the table itself
+func (r *LineageRepository) SelectLineageNodes(id string, upstreamDepth int, downstreamDepth int) ([]dataModels.TableEntity, error) {
+	tableEntities := []dataModels.TableEntity{}
+	statement := "WITH RECURSIVE " + upstreamEdgesStatement("$3") + "," + downstreamEdgesStatement("$4") + `
+		SELECT * FROM table_entity WHERE id <> $1 AND id IN (
+			SELECT fromid FROM upstream_edges UNION SELECT toid FROM upstream_edges
+			UNION SELECT fromid FROM downstream_edges UNION SELECT toid FROM downstream_edges
+		)
+		ORDER BY json->>'fullyQualifiedName'
+	`
+	err := r.DB.Select(&tableEntities, statement, id, typeModels.Relationship["UPSTREAM"], upstreamDepth, downstreamDepth)
+	return tableEntities, err
+}
+
+func (r *LineageRepository) InsertLineageEdge(fromId string, toId string, details *lineageModels.LineageDetails) (*lineageModels.LineageEdgeEntity, error) {
+	lineageEdgeEntity := &lineageModels.LineageEdgeEntity{}
+	statement := `
+		INSERT INTO entity_relationship(fromid, toid, fromentity, toentity, relation, jsonschema, json, deleted)
+		VALUES($1, $2, $3, $3, $4, 'lineageDetails', $5, FALSE)
+		ON CONFLICT (fromid, toid, relation) DO UPDATE SET json = EXCLUDED.json, deleted = FALSE
+		RETURNING fromid, toid, json
+	`
+	err := r.DB.Get(
+		lineageEdgeEntity,
+		statement,
+		fromId,
+		toId,
+		lineageModels.LineageEntityType,
+		typeModels.Relationship["UPSTREAM"],
+		details,
+	)
+	return lineageEdgeEntity, err
+}
+
+func (r *LineageRepository) DeleteLineageEdge(fromId string, toId string) error {
+	statement := "DELETE FROM entity_relationship WHERE fromid = $1 AND toid = $2 AND relation = $3"
+	result, err := r.DB.Exec(statement, fromId, toId, typeModels.Relationship["UPSTREAM"])
+
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+
+	if err == nil && rows == 0 {
+		return sql.ErrNoRows
+	}
+
+	return err
+}
+
+// DeleteUpstreamEdgesBySource removes the edges into an entity from the given source, so they can be recorded again
+// after the entity changed
+func (r *LineageRepository) DeleteUpstreamEdgesBySource(toId string, source string) error {
+	statement := `
+		DELETE FROM entity_relationship
+		WHERE relation = $1 AND toid = $2 AND json->>'source' = $3
+	`
+	_, err := r.DB.Exec(statement, typeModels.Relationship["UPSTREAM"], toId, source)
+	return err
+}
+
+// DeleteLineageEdgesByPipeline removes the edges a pipeline produced from the given source, so they can be
+// recorded again after the pipeline changed
+func (r *LineageRepository) DeleteLineageEdgesByPipeline(pipelineId string, source string) error {
+	statement := `
+		DELETE FROM entity_relationship
+		WHERE relation = $1 AND json->'pipeline'->>'id' = $2 AND json->>'source' = $3
+	`
+	_, err := r.DB.Exec(statement, typeModels.Relationship["UPSTREAM"], pipelineId, source)
+	return err
+}
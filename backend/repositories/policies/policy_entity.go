@@ -0,0 +1,119 @@
+package repositories
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+	baseRepositories "github.com/nambuitechx/go-metadata/repositories/base"
+	baseModels "github.com/nambuitechx/go-metadata/models/base"
+	policiesModels "github.com/nambuitechx/go-metadata/models/policies"
+)
+
+type PolicyEntityRepository struct {
+	DB baseRepositories.DBTX
+}
+
+func NewPolicyEntityRepository(db *sqlx.DB) *PolicyEntityRepository {
+	return &PolicyEntityRepository{ DB: db }
+}
+
+func (r *PolicyEntityRepository) WithTx(tx *sqlx.Tx) *PolicyEntityRepository {
+	return &PolicyEntityRepository{ DB: tx }
+}
+
+func (r *PolicyEntityRepository) SelectPolicyEntities(include string, limit int, before *baseModels.Cursor, after *baseModels.Cursor) ([]policiesModels.PolicyEntity, error) {
+	policyEntities := []policiesModels.PolicyEntity{}
+	statement := "SELECT * FROM policy_entity WHERE " + baseRepositories.DeletedCondition(include)
+	statement, args := baseRepositories.Paginate(statement, []interface{}{}, limit, before, after)
+	err := r.DB.Select(&policyEntities, statement, args...)
+	return policyEntities, err
+}
+
+func (r *PolicyEntityRepository) SelectCountPolicyEntities(include string) (*baseModels.EntityTotal, error) {
+	entityTotal := &baseModels.EntityTotal{}
+	statement := "SELECT COUNT(id) as total FROM policy_entity WHERE " + baseRepositories.DeletedCondition(include)
+	err := r.DB.Get(entityTotal, statement)
+	return entityTotal, err
+}
+
+func (r *PolicyEntityRepository) SelectPolicyEntityById(id string, include string) (*policiesModels.PolicyEntity, error) {
+	policyEntity := &policiesModels.PolicyEntity{}
+	statement := "SELECT * FROM policy_entity WHERE id = $1 AND " + baseRepositories.DeletedCondition(include)
+	err := r.DB.Get(policyEntity, statement, id)
+	return policyEntity, err
+}
+
+func (r *PolicyEntityRepository) SelectPolicyEntityByName(name string, include string) (*policiesModels.PolicyEntity, error) {
+	policyEntity := &policiesModels.PolicyEntity{}
+	statement := "SELECT * FROM policy_entity WHERE name = $1 AND " + baseRepositories.DeletedCondition(include)
+	err := r.DB.Get(policyEntity, statement, name)
+	return policyEntity, err
+}
+
+// SelectPolicyEntitiesByNames skips the names without a policy
+func (r *PolicyEntityRepository) SelectPolicyEntitiesByNames(names []string) ([]policiesModels.PolicyEntity, error) {
+	policyEntities := []policiesModels.PolicyEntity{}
+
+	if len(names) == 0 {
+		return policyEntities, nil
+	}
+
+	args := []interface{}{}
+	placeholders := []string{}
+
+	for _, name := range names {
+		args = append(args, name)
+		placeholders = append(placeholders, fmt.Sprintf("$%v", len(args)))
+	}
+
+	statement := "SELECT * FROM policy_entity WHERE name IN (" + strings.Join(placeholders, ", ") + ") AND deleted = false ORDER BY name"
+	err := r.DB.Select(&policyEntities, statement, args...)
+	return policyEntities, err
+}
+
+func (r *PolicyEntityRepository) InsertPolicyEntity(payload *policiesModels.PolicyEntity) (*policiesModels.PolicyEntity, error) {
+	var policyEntity = policiesModels.PolicyEntity{}
+	statement := `
+		INSERT INTO policy_entity(id, name, json, updatedat, updatedby, deleted, namehash)
+		VALUES($1, $2, $3, $4, $5, $6, $7) RETURNING *
+	`
+	err := r.DB.Get(
+		&policyEntity,
+		statement,
+		payload.ID,
+		payload.Name,
+		payload.Json,
+		payload.UpdatedAt,
+		payload.UpdatedBy,
+		payload.Deleted,
+		payload.NameHash,
+	)
+	return &policyEntity, err
+}
+
+func (r *PolicyEntityRepository) UpdatePolicyEntity(payload *policiesModels.PolicyEntity, previousVersion float64) (*policiesModels.PolicyEntity, error) {
+	var policyEntity = policiesModels.PolicyEntity{}
+	statement := `
+		UPDATE policy_entity
+		SET json = $2, updatedat = $3, updatedby = $4, deleted = $5
+		WHERE id = $1 AND COALESCE((json->>'version')::float8, 0) = $6 RETURNING *
+	`
+	err := r.DB.Get(
+		&policyEntity,
+		statement,
+		payload.ID,
+		payload.Json,
+		payload.UpdatedAt,
+		payload.UpdatedBy,
+		payload.Deleted,
+		previousVersion,
+	)
+	return &policyEntity, err
+}
+
+func (r *PolicyEntityRepository) DeletePolicyEntityById(id string) error {
+	statement := "DELETE FROM policy_entity WHERE id = $1"
+	_, err := r.DB.Exec(statement, id)
+	return err
+}
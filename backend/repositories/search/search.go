@@ -0,0 +1,169 @@
+package repositories
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+	baseModels "github.com/nambuitechx/go-metadata/models/base"
+	baseRepositories "github.com/nambuitechx/go-metadata/repositories/base"
+	searchModels "github.com/nambuitechx/go-metadata/models/search"
+)
+
+// hitsStatement returns the non-deleted entities of the indexes matching the query in $1. Index names come from
+// searchModels.SearchIndex, so they are safe to put in the statement
+func hitsStatement(indexes []string) string {
+	selects := []string{}
+
+	for _, index := range indexes {
+		selects = append(selects, fmt.Sprintf(`
+		SELECT s.entitytype, e.id, e.json, s.searchvector FROM search_index s
+		JOIN %v e ON e.id = s.id AND e.deleted = FALSE
+		WHERE s.entitytype = '%v' AND s.searchvector @@ websearch_to_tsquery('english', $1)
+		`, searchModels.SearchIndex[index], index))
+	}
+
+	return "hits AS (" + strings.Join(selects, " UNION ALL ") + ")"
+}
+
+type SearchRepository struct {
+	DB baseRepositories.DBTX
+}
+
+func NewSearchRepository(db *sqlx.DB) *SearchRepository {
+	return &SearchRepository{ DB: db }
+}
+
+func (r *SearchRepository) WithTx(tx *sqlx.Tx) *SearchRepository {
+	return &SearchRepository{ DB: tx }
+}
+
+// InsertSearchDocuments indexes the documents in one statement, they are passed as a json array since the
+// driver cannot bind slices
+func (r *SearchRepository) InsertSearchDocuments(documents []*searchModels.SearchDocument) error {
+	if len(documents) == 0 {
+		return nil
+	}
+
+	bytes, err := json.Marshal(documents)
+
+	if err != nil {
+		return err
+	}
+
+	statement := `
+		INSERT INTO search_index(entitytype, id, fullyqualifiedname, searchvector)
+		SELECT d->>'entityType', d->>'id', d->>'fullyQualifiedName', entity_search_vector(d->'source')
+		FROM jsonb_array_elements($1::jsonb) d
+		ON CONFLICT (entitytype, id) DO UPDATE
+		SET fullyqualifiedname = EXCLUDED.fullyqualifiedname, searchvector = EXCLUDED.searchvector
+	`
+	_, err = r.DB.Exec(statement, string(bytes))
+	return err
+}
+
+func (r *SearchRepository) DeleteSearchDocument(entityType string, id string) error {
+	statement := "DELETE FROM search_index WHERE entitytype = $1 AND id = $2"
+	_, err := r.DB.Exec(statement, entityType, id)
+	return err
+}
+
+func (r *SearchRepository) DeleteSearchDocumentsByPrefix(prefix string) error {
+	statement := "DELETE FROM search_index WHERE starts_with(fullyqualifiedname, $1 || '.')"
+	_, err := r.DB.Exec(statement, prefix)
+	return err
+}
+
+func (r *SearchRepository) DeleteSearchDocumentsByEntityType(entityType string) error {
+	statement := "DELETE FROM search_index WHERE entitytype = $1"
+	_, err := r.DB.Exec(statement, entityType)
+	return err
+}
+
+// SelectSearchEntityRows reads the entities of an index in batches ordered by id, deleted ones included
+func (r *SearchRepository) SelectSearchEntityRows(entityType string, afterId string, limit int) ([]*searchModels.SearchEntityRow, error) {
+	searchEntityRows := []*searchModels.SearchEntityRow{}
+	statement := fmt.Sprintf("SELECT id, json FROM %v WHERE id > $1 ORDER BY id LIMIT $2", searchModels.SearchIndex[entityType])
+	err := r.DB.Select(&searchEntityRows, statement, afterId, limit)
+	return searchEntityRows, err
+}
+
+func (r *SearchRepository) SelectCountSearchEntities(entityType string) (*baseModels.EntityTotal, error) {
+	entityTotal := &baseModels.EntityTotal{}
+	statement := fmt.Sprintf("SELECT COUNT(*) AS total FROM %v", searchModels.SearchIndex[entityType])
+	err := r.DB.Get(entityTotal, statement)
+	return entityTotal, err
+}
+
+// SelectSearchHits returns a page of hits ordered by rank, ties are broken by fullyQualifiedName so pages are stable
+func (r *SearchRepository) SelectSearchHits(q string, indexes []string, from int, size int) ([]*searchModels.SearchHit, error) {
+	searchHits := []*searchModels.SearchHit{}
+	statement := `
+		WITH ` + hitsStatement(indexes) + `
+		SELECT
+			entitytype,
+			id,
+			json,
+			ts_rank_cd(searchvector, websearch_to_tsquery('english', $1))::float8 AS score,
+			entity_search_highlights(json, websearch_to_tsquery('english', $1)) AS highlights
+		FROM hits
+		ORDER BY score DESC, json->>'fullyQualifiedName', id
+		LIMIT $2 OFFSET $3
+	`
+	err := r.DB.Select(&searchHits, statement, q, size, from)
+	return searchHits, err
+}
+
+// SelectSearchFacets counts the hits by entity type, service name, service type and table type. Hits without
+// a value for a facet are not counted in it
+func (r *SearchRepository) SelectSearchFacets(q string, indexes []string) ([]*searchModels.SearchFacetCount, error) {
+	searchFacetCounts := []*searchModels.SearchFacetCount{}
+	statement := `
+		WITH ` + hitsStatement(indexes) + `
+		SELECT 'entityType' AS facet, entitytype AS value, COUNT(*) AS count FROM hits GROUP BY entitytype
+		UNION ALL
+		SELECT 'service', json->'service'->>'name', COUNT(*) FROM hits WHERE json->'service'->>'name' IS NOT NULL GROUP BY json->'service'->>'name'
+		UNION ALL
+		SELECT 'serviceType', json->>'serviceType', COUNT(*) FROM hits WHERE json->>'serviceType' IS NOT NULL GROUP BY json->>'serviceType'
+		UNION ALL
+		SELECT 'tableType', json->>'tableType', COUNT(*) FROM hits WHERE json->>'tableType' IS NOT NULL GROUP BY json->>'tableType'
+		ORDER BY facet, count DESC, value
+	`
+	err := r.DB.Select(&searchFacetCounts, statement, q)
+	return searchFacetCounts, err
+}
+
+// SelectSearchSuggestions ranks entities by the trigram word similarity of $1 to their name and fullyQualifiedName,
+// names starting with the input come first. Each entity type is limited on its own so the trigram indexes are used
+func (r *SearchRepository) SelectSearchSuggestions(q string, types []string, size int) ([]*searchModels.SearchSuggestion, error) {
+	searchSuggestions := []*searchModels.SearchSuggestion{}
+	selects := []string{}
+
+	for _, entityType := range types {
+		selects = append(selects, fmt.Sprintf(`(
+			SELECT
+				id,
+				'%[2]v' AS type,
+				json->>'name' AS name,
+				json->>'fullyQualifiedName' AS fullyqualifiedname,
+				COALESCE(json->>'displayName', '') AS displayname,
+				COALESCE(json->>'description', '') AS description,
+				deleted,
+				(GREATEST(word_similarity($1, lower(json->>'name')), word_similarity($1, lower(json->>'fullyQualifiedName')) * 0.9)
+					+ CASE WHEN lower(json->>'name') LIKE $2 THEN 1 ELSE 0 END)::float8 AS score
+			FROM %[1]v
+			WHERE deleted = FALSE AND ($1 <%% lower(json->>'name') OR $1 <%% lower(json->>'fullyQualifiedName') OR lower(json->>'name') LIKE $2)
+			ORDER BY score DESC
+			LIMIT $3
+		)`, searchModels.SearchIndex[entityType], entityType))
+	}
+
+	statement := strings.Join(selects, " UNION ALL ") + " ORDER BY score DESC, fullyqualifiedname LIMIT $3"
+	err := r.DB.Select(&searchSuggestions, statement, q, escapeLike(q) + "%", size)
+	return searchSuggestions, err
+}
+
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(value)
+}
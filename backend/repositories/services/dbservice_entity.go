@@ -2,50 +2,48 @@ package repositories
 
 import (
 	"github.com/jmoiron/sqlx"
+	baseRepositories "github.com/nambuitechx/go-metadata/repositories/base"
 	baseModels "github.com/nambuitechx/go-metadata/models/base"
 	servicesModels "github.com/nambuitechx/go-metadata/models/services"
 )
 
 type DBServiceEntityRepository struct {
-	DB *sqlx.DB
+	DB baseRepositories.DBTX
 }
 
 func NewDBServiceEntityRepository(db *sqlx.DB) *DBServiceEntityRepository {
 	return &DBServiceEntityRepository{ DB: db }
 }
 
-func (r *DBServiceEntityRepository) SelectDBServiceEntities(limit int, offset int) ([]servicesModels.DBServiceEntity, error) {
-	dbserviceEntities := []servicesModels.DBServiceEntity{}
-	var err error
-	
-	if limit < 0 {
-		statement := "SELECT * FROM dbservice_entity"
-		err = r.DB.Select(&dbserviceEntities, statement)
-	} else {
-		statement := "SELECT * FROM dbservice_entity LIMIT $1 OFFSET $2"
-		err = r.DB.Select(&dbserviceEntities, statement, limit, offset)
-	}
+func (r *DBServiceEntityRepository) WithTx(tx *sqlx.Tx) *DBServiceEntityRepository {
+	return &DBServiceEntityRepository{ DB: tx }
+}
 
+func (r *DBServiceEntityRepository) SelectDBServiceEntities(include string, limit int, before *baseModels.Cursor, after *baseModels.Cursor) ([]servicesModels.DBServiceEntity, error) {
+	dbserviceEntities := []servicesModels.DBServiceEntity{}
+	statement := "SELECT * FROM dbservice_entity WHERE " + baseRepositories.DeletedCondition(include)
+	statement, args := baseRepositories.Paginate(statement, []interface{}{}, limit, before, after)
+	err := r.DB.Select(&dbserviceEntities, statement, args...)
 	return dbserviceEntities, err
 }
 
-func (r *DBServiceEntityRepository) SelectCountDBServiceEntities() (*baseModels.EntityTotal, error) {
+func (r *DBServiceEntityRepository) SelectCountDBServiceEntities(include string) (*baseModels.EntityTotal, error) {
 	entityTotal := &baseModels.EntityTotal{}
-	statement := "SELECT COUNT(id) as total FROM dbservice_entity"
+	statement := "SELECT COUNT(id) as total FROM dbservice_entity WHERE " + baseRepositories.DeletedCondition(include)
 	err := r.DB.Get(entityTotal, statement)
 	return entityTotal, err
 }
 
-func (r *DBServiceEntityRepository) SelectDBServiceEntityById(id string) (*servicesModels.DBServiceEntity, error) {
+func (r *DBServiceEntityRepository) SelectDBServiceEntityById(id string, include string) (*servicesModels.DBServiceEntity, error) {
 	dbserviceEntity := &servicesModels.DBServiceEntity{}
-	statement := "SELECT * FROM dbservice_entity WHERE id = $1"
+	statement := "SELECT * FROM dbservice_entity WHERE id = $1 AND " + baseRepositories.DeletedCondition(include)
 	err := r.DB.Get(dbserviceEntity, statement, id)
 	return dbserviceEntity, err
 }
 
-func (r *DBServiceEntityRepository) SelectDBServiceEntityByFqn(fqn string) (*servicesModels.DBServiceEntity, error) {
+func (r *DBServiceEntityRepository) SelectDBServiceEntityByFqn(fqn string, include string) (*servicesModels.DBServiceEntity, error) {
 	dbserviceEntity := &servicesModels.DBServiceEntity{}
-	statement := "SELECT * FROM dbservice_entity WHERE json->>'fullyQualifiedName' = $1"
+	statement := "SELECT * FROM dbservice_entity WHERE json->>'fullyQualifiedName' = $1 AND " + baseRepositories.DeletedCondition(include)
 	err := r.DB.Get(dbserviceEntity, statement, fqn)
 	return dbserviceEntity, err
 }
@@ -71,12 +69,14 @@ func (r *DBServiceEntityRepository) InsertDBServiceEntity(payload *servicesModel
 	return &dbserviceEntity, err
 }
 
-func (r *DBServiceEntityRepository) UpdateDBServiceEntity(payload *servicesModels.DBServiceEntity) (*servicesModels.DBServiceEntity, error) {
+// UpdateDBServiceEntity only succeeds while the stored entity is still at version, so concurrent writers cannot
+// overwrite each other
+func (r *DBServiceEntityRepository) UpdateDBServiceEntity(payload *servicesModels.DBServiceEntity, version float64) (*servicesModels.DBServiceEntity, error) {
 	var dbserviceEntity = servicesModels.DBServiceEntity{}
 	statement := `
 		UPDATE dbservice_entity
 		SET name = $2, servicetype = $3, json = $4, updatedat = $5, updatedby = $6, deleted = $7, namehash = $8
-		WHERE id = $1 RETURNING *
+		WHERE id = $1 AND COALESCE((json->>'version')::float8, 0) = $9 RETURNING *
 	`
 	err := r.DB.Get(
 		&dbserviceEntity,
@@ -89,18 +89,27 @@ func (r *DBServiceEntityRepository) UpdateDBServiceEntity(payload *servicesModel
 		payload.UpdatedBy,
 		payload.Deleted,
 		payload.NameHash,
+		version,
 	)
 	return &dbserviceEntity, err
 }
 
 func (r *DBServiceEntityRepository) DeleteDBServiceEntityById(id string) error {
-	statement := "DELETE FROM dbservice_entity WHERE id = $1"
+	statement := `
+		WITH deleted AS (DELETE FROM dbservice_entity WHERE id = $1 RETURNING id),
+		relationships AS (DELETE FROM entity_relationship WHERE fromid IN (SELECT id FROM deleted) OR toid IN (SELECT id FROM deleted))
+		DELETE FROM entity_extension WHERE id IN (SELECT id FROM deleted)
+	`
 	_, err := r.DB.Exec(statement, id)
 	return err
 }
 
 func (r *DBServiceEntityRepository) DeleteDBServiceEntityByFqn(fqn string) error {
-	statement := "DELETE FROM dbservice_entity WHERE json->>'fullyQualifiedName' = $1"
+	statement := `
+		WITH deleted AS (DELETE FROM dbservice_entity WHERE json->>'fullyQualifiedName' = $1 RETURNING id),
+		relationships AS (DELETE FROM entity_relationship WHERE fromid IN (SELECT id FROM deleted) OR toid IN (SELECT id FROM deleted))
+		DELETE FROM entity_extension WHERE id IN (SELECT id FROM deleted)
+	`
 	_, err := r.DB.Exec(statement, fqn)
 	return err
 }
@@ -2,6 +2,7 @@ package repositories
 
 import (
 	"github.com/jmoiron/sqlx"
+	baseRepositories "github.com/nambuitechx/go-metadata/repositories/base"
 	baseModels "github.com/nambuitechx/go-metadata/models/base"
 	servicesModels "github.com/nambuitechx/go-metadata/models/services"
 )
@@ -14,38 +15,31 @@ func NewTestConnectionDefinitionEntityRepository(db *sqlx.DB) *TestConnectionDef
 	return &TestConnectionDefinitionEntityRepository{ DB: db }
 }
 
-func (r *TestConnectionDefinitionEntityRepository) SelectTestConnectionDefinitionEntities(limit int, offset int) ([]servicesModels.TestConnectionDefinitionEntity, error) {
+func (r *TestConnectionDefinitionEntityRepository) SelectTestConnectionDefinitionEntities(include string, limit int, before *baseModels.Cursor, after *baseModels.Cursor) ([]servicesModels.TestConnectionDefinitionEntity, error) {
 	testConnectionDefinitionEntities := []servicesModels.TestConnectionDefinitionEntity{}
-	var err error
-	
-	if limit < 0 {
-		statement := "SELECT * FROM test_connection_definition"
-		err = r.DB.Select(&testConnectionDefinitionEntities, statement)
-	} else {
-		statement := "SELECT * FROM test_connection_definition LIMIT $1 OFFSET $2"
-		err = r.DB.Select(&testConnectionDefinitionEntities, statement, limit, offset)
-	}
-
+	statement := "SELECT * FROM test_connection_definition WHERE " + baseRepositories.DeletedCondition(include)
+	statement, args := baseRepositories.Paginate(statement, []interface{}{}, limit, before, after)
+	err := r.DB.Select(&testConnectionDefinitionEntities, statement, args...)
 	return testConnectionDefinitionEntities, err
 }
 
-func (r *TestConnectionDefinitionEntityRepository) SelectCountTestConnectionDefinitionEntities() (*baseModels.EntityTotal, error) {
+func (r *TestConnectionDefinitionEntityRepository) SelectCountTestConnectionDefinitionEntities(include string) (*baseModels.EntityTotal, error) {
 	entityTotal := &baseModels.EntityTotal{}
-	statement := "SELECT COUNT(id) as total FROM test_connection_definition"
+	statement := "SELECT COUNT(id) as total FROM test_connection_definition WHERE " + baseRepositories.DeletedCondition(include)
 	err := r.DB.Get(entityTotal, statement)
 	return entityTotal, err
 }
 
-func (r *TestConnectionDefinitionEntityRepository) SelectTestConnectionDefinitionEntityById(id string) (*servicesModels.TestConnectionDefinitionEntity, error) {
+func (r *TestConnectionDefinitionEntityRepository) SelectTestConnectionDefinitionEntityById(id string, include string) (*servicesModels.TestConnectionDefinitionEntity, error) {
 	testConnectionDefinitionEntity := &servicesModels.TestConnectionDefinitionEntity{}
-	statement := "SELECT * FROM test_connection_definition WHERE id = $1"
+	statement := "SELECT * FROM test_connection_definition WHERE id = $1 AND " + baseRepositories.DeletedCondition(include)
 	err := r.DB.Get(testConnectionDefinitionEntity, statement, id)
 	return testConnectionDefinitionEntity, err
 }
 
-func (r *TestConnectionDefinitionEntityRepository) SelectTestConnectionDefinitionEntityByFqn(fqn string) (*servicesModels.TestConnectionDefinitionEntity, error) {
+func (r *TestConnectionDefinitionEntityRepository) SelectTestConnectionDefinitionEntityByFqn(fqn string, include string) (*servicesModels.TestConnectionDefinitionEntity, error) {
 	testConnectionDefinitionEntity := &servicesModels.TestConnectionDefinitionEntity{}
-	statement := "SELECT * FROM test_connection_definition WHERE json->>'fullyQualifiedName' = $1"
+	statement := "SELECT * FROM test_connection_definition WHERE json->>'fullyQualifiedName' = $1 AND " + baseRepositories.DeletedCondition(include)
 	err := r.DB.Get(testConnectionDefinitionEntity, statement, fqn)
 	return testConnectionDefinitionEntity, err
 }
@@ -0,0 +1,75 @@
+package repositories
+
+import (
+	"github.com/jmoiron/sqlx"
+	baseRepositories "github.com/nambuitechx/go-metadata/repositories/base"
+	baseModels "github.com/nambuitechx/go-metadata/models/base"
+	teamsModels "github.com/nambuitechx/go-metadata/models/teams"
+)
+
+type BotEntityRepository struct {
+	DB baseRepositories.DBTX
+}
+
+func NewBotEntityRepository(db *sqlx.DB) *BotEntityRepository {
+	return &BotEntityRepository{ DB: db }
+}
+
+func (r *BotEntityRepository) WithTx(tx *sqlx.Tx) *BotEntityRepository {
+	return &BotEntityRepository{ DB: tx }
+}
+
+func (r *BotEntityRepository) SelectBotEntities(include string, limit int, before *baseModels.Cursor, after *baseModels.Cursor) ([]teamsModels.BotEntity, error) {
+	botEntities := []teamsModels.BotEntity{}
+	statement := "SELECT * FROM bot_entity WHERE " + baseRepositories.DeletedCondition(include)
+	statement, args := baseRepositories.Paginate(statement, []interface{}{}, limit, before, after)
+	err := r.DB.Select(&botEntities, statement, args...)
+	return botEntities, err
+}
+
+func (r *BotEntityRepository) SelectCountBotEntities(include string) (*baseModels.EntityTotal, error) {
+	entityTotal := &baseModels.EntityTotal{}
+	statement := "SELECT COUNT(id) as total FROM bot_entity WHERE " + baseRepositories.DeletedCondition(include)
+	err := r.DB.Get(entityTotal, statement)
+	return entityTotal, err
+}
+
+func (r *BotEntityRepository) SelectBotEntityById(id string, include string) (*teamsModels.BotEntity, error) {
+	botEntity := &teamsModels.BotEntity{}
+	statement := "SELECT * FROM bot_entity WHERE id = $1 AND " + baseRepositories.DeletedCondition(include)
+	err := r.DB.Get(botEntity, statement, id)
+	return botEntity, err
+}
+
+func (r *BotEntityRepository) SelectBotEntityByName(name string, include string) (*teamsModels.BotEntity, error) {
+	botEntity := &teamsModels.BotEntity{}
+	statement := "SELECT * FROM bot_entity WHERE name = $1 AND " + baseRepositories.DeletedCondition(include)
+	err := r.DB.Get(botEntity, statement, name)
+	return botEntity, err
+}
+
+func (r *BotEntityRepository) InsertBotEntity(payload *teamsModels.BotEntity) (*teamsModels.BotEntity, error) {
+	var botEntity = teamsModels.BotEntity{}
+	statement := `
+		INSERT INTO bot_entity(id, name, json, updatedat, updatedby, deleted, namehash)
+		VALUES($1, $2, $3, $4, $5, $6, $7) RETURNING *
+	`
+	err := r.DB.Get(
+		&botEntity,
+		statement,
+		payload.ID,
+		payload.Name,
+		payload.Json,
+		payload.UpdatedAt,
+		payload.UpdatedBy,
+		payload.Deleted,
+		payload.NameHash,
+	)
+	return &botEntity, err
+}
+
+func (r *BotEntityRepository) DeleteBotEntityById(id string) error {
+	statement := "DELETE FROM bot_entity WHERE id = $1"
+	_, err := r.DB.Exec(statement, id)
+	return err
+}
@@ -0,0 +1,96 @@
+package repositories
+
+import (
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+	baseRepositories "github.com/nambuitechx/go-metadata/repositories/base"
+	teamsModels "github.com/nambuitechx/go-metadata/models/teams"
+	typeModels "github.com/nambuitechx/go-metadata/models/type"
+)
+
+type OwnerRepository struct {
+	DB baseRepositories.DBTX
+}
+
+func NewOwnerRepository(db *sqlx.DB) *OwnerRepository {
+	return &OwnerRepository{ DB: db }
+}
+
+func (r *OwnerRepository) WithTx(tx *sqlx.Tx) *OwnerRepository {
+	return &OwnerRepository{ DB: tx }
+}
+
+// ownerHolders lists the entity and its parents with their level, the parents come from the references of the json
+func ownerHolders(table string) string {
+	return fmt.Sprintf(`(VALUES
+		(1, %[1]v.id::text),
+		(2, %[1]v.json->'databaseSchema'->>'id'),
+		(3, %[1]v.json->'database'->>'id'),
+		(4, %[1]v.json->'service'->>'id')
+	)`, table)
+}
+
+// OwnerCondition matches the rows of table whose effective owners, their own ones or else the ones of the nearest
+// parent with owners, contain the owner id at the given placeholder
+func OwnerCondition(table string, placeholder string) string {
+	return fmt.Sprintf(`%[1]v IN (
+		SELECT r.fromid FROM %[2]v AS h(level, id)
+		JOIN entity_relationship r ON r.toid = h.id AND r.relation = %[3]v AND r.deleted = FALSE
+		WHERE h.level = (
+			SELECT MIN(p.level) FROM %[2]v AS p(level, id)
+			JOIN entity_relationship o ON o.toid = p.id AND o.relation = %[3]v AND o.deleted = FALSE
+		)
+	)`, placeholder, ownerHolders(table), typeModels.Relationship["OWNS"])
+}
+
+// SelectEffectiveOwners returns the owners of the entity, or of its nearest parent with owners
+func (r *OwnerRepository) SelectEffectiveOwners(table string, id string) ([]teamsModels.OwnerEntity, error) {
+	ownerEntities := []teamsModels.OwnerEntity{}
+	statement := fmt.Sprintf(`
+		WITH owners AS (
+			SELECT h.level, r.json FROM %[1]v e
+			CROSS JOIN LATERAL %[2]v AS h(level, id)
+			JOIN entity_relationship r ON r.toid = h.id AND r.relation = $2 AND r.deleted = FALSE
+			WHERE e.id = $1
+		)
+		SELECT level, json FROM owners WHERE level = (SELECT MIN(level) FROM owners)
+		ORDER BY json->>'type', json->>'name'
+	`, table, ownerHolders("e"))
+	err := r.DB.Select(&ownerEntities, statement, id, typeModels.Relationship["OWNS"])
+	return ownerEntities, err
+}
+
+func (r *OwnerRepository) SelectCountOwnedEntities(ownerId string) (int, error) {
+	var total int
+	statement := "SELECT COUNT(*) FROM entity_relationship WHERE fromid = $1 AND relation = $2"
+	err := r.DB.Get(&total, statement, ownerId, typeModels.Relationship["OWNS"])
+	return total, err
+}
+
+// ReplaceOwners replaces the OWNS rows of an entity with the given owners
+func (r *OwnerRepository) ReplaceOwners(id string, entityType string, owners []*typeModels.EntityReference) error {
+	if err := r.DeleteOwners(id); err != nil {
+		return err
+	}
+
+	statement := `
+		INSERT INTO entity_relationship(fromid, toid, fromentity, toentity, relation, jsonschema, json, deleted)
+		VALUES($1, $2, $3, $4, $5, 'entityReference', $6, FALSE)
+		ON CONFLICT (fromid, toid, relation) DO UPDATE SET json = EXCLUDED.json, deleted = FALSE
+	`
+
+	for _, owner := range owners {
+		if _, err := r.DB.Exec(statement, owner.ID, id, owner.Type, entityType, typeModels.Relationship["OWNS"], owner); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *OwnerRepository) DeleteOwners(id string) error {
+	statement := "DELETE FROM entity_relationship WHERE toid = $1 AND relation = $2"
+	_, err := r.DB.Exec(statement, id, typeModels.Relationship["OWNS"])
+	return err
+}
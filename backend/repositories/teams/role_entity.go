@@ -0,0 +1,119 @@
+package repositories
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+	baseRepositories "github.com/nambuitechx/go-metadata/repositories/base"
+	baseModels "github.com/nambuitechx/go-metadata/models/base"
+	teamsModels "github.com/nambuitechx/go-metadata/models/teams"
+)
+
+type RoleEntityRepository struct {
+	DB baseRepositories.DBTX
+}
+
+func NewRoleEntityRepository(db *sqlx.DB) *RoleEntityRepository {
+	return &RoleEntityRepository{ DB: db }
+}
+
+func (r *RoleEntityRepository) WithTx(tx *sqlx.Tx) *RoleEntityRepository {
+	return &RoleEntityRepository{ DB: tx }
+}
+
+func (r *RoleEntityRepository) SelectRoleEntities(include string, limit int, before *baseModels.Cursor, after *baseModels.Cursor) ([]teamsModels.RoleEntity, error) {
+	roleEntities := []teamsModels.RoleEntity{}
+	statement := "SELECT * FROM role_entity WHERE " + baseRepositories.DeletedCondition(include)
+	statement, args := baseRepositories.Paginate(statement, []interface{}{}, limit, before, after)
+	err := r.DB.Select(&roleEntities, statement, args...)
+	return roleEntities, err
+}
+
+func (r *RoleEntityRepository) SelectCountRoleEntities(include string) (*baseModels.EntityTotal, error) {
+	entityTotal := &baseModels.EntityTotal{}
+	statement := "SELECT COUNT(id) as total FROM role_entity WHERE " + baseRepositories.DeletedCondition(include)
+	err := r.DB.Get(entityTotal, statement)
+	return entityTotal, err
+}
+
+func (r *RoleEntityRepository) SelectRoleEntityById(id string, include string) (*teamsModels.RoleEntity, error) {
+	roleEntity := &teamsModels.RoleEntity{}
+	statement := "SELECT * FROM role_entity WHERE id = $1 AND " + baseRepositories.DeletedCondition(include)
+	err := r.DB.Get(roleEntity, statement, id)
+	return roleEntity, err
+}
+
+func (r *RoleEntityRepository) SelectRoleEntityByName(name string, include string) (*teamsModels.RoleEntity, error) {
+	roleEntity := &teamsModels.RoleEntity{}
+	statement := "SELECT * FROM role_entity WHERE name = $1 AND " + baseRepositories.DeletedCondition(include)
+	err := r.DB.Get(roleEntity, statement, name)
+	return roleEntity, err
+}
+
+// SelectRoleEntitiesByNames skips the names without a role
+func (r *RoleEntityRepository) SelectRoleEntitiesByNames(names []string) ([]teamsModels.RoleEntity, error) {
+	roleEntities := []teamsModels.RoleEntity{}
+
+	if len(names) == 0 {
+		return roleEntities, nil
+	}
+
+	args := []interface{}{}
+	placeholders := []string{}
+
+	for _, name := range names {
+		args = append(args, name)
+		placeholders = append(placeholders, fmt.Sprintf("$%v", len(args)))
+	}
+
+	statement := "SELECT * FROM role_entity WHERE name IN (" + strings.Join(placeholders, ", ") + ") AND deleted = false ORDER BY name"
+	err := r.DB.Select(&roleEntities, statement, args...)
+	return roleEntities, err
+}
+
+func (r *RoleEntityRepository) InsertRoleEntity(payload *teamsModels.RoleEntity) (*teamsModels.RoleEntity, error) {
+	var roleEntity = teamsModels.RoleEntity{}
+	statement := `
+		INSERT INTO role_entity(id, name, json, updatedat, updatedby, deleted, namehash)
+		VALUES($1, $2, $3, $4, $5, $6, $7) RETURNING *
+	`
+	err := r.DB.Get(
+		&roleEntity,
+		statement,
+		payload.ID,
+		payload.Name,
+		payload.Json,
+		payload.UpdatedAt,
+		payload.UpdatedBy,
+		payload.Deleted,
+		payload.NameHash,
+	)
+	return &roleEntity, err
+}
+
+func (r *RoleEntityRepository) UpdateRoleEntity(payload *teamsModels.RoleEntity, previousVersion float64) (*teamsModels.RoleEntity, error) {
+	var roleEntity = teamsModels.RoleEntity{}
+	statement := `
+		UPDATE role_entity
+		SET json = $2, updatedat = $3, updatedby = $4, deleted = $5
+		WHERE id = $1 AND COALESCE((json->>'version')::float8, 0) = $6 RETURNING *
+	`
+	err := r.DB.Get(
+		&roleEntity,
+		statement,
+		payload.ID,
+		payload.Json,
+		payload.UpdatedAt,
+		payload.UpdatedBy,
+		payload.Deleted,
+		previousVersion,
+	)
+	return &roleEntity, err
+}
+
+func (r *RoleEntityRepository) DeleteRoleEntityById(id string) error {
+	statement := "DELETE FROM role_entity WHERE id = $1"
+	_, err := r.DB.Exec(statement, id)
+	return err
+}
@@ -0,0 +1,167 @@
+package repositories
+
+import (
+
+	"github.com/jmoiron/sqlx"
+	baseRepositories "github.com/nambuitechx/go-metadata/repositories/base"
+	baseModels "github.com/nambuitechx/go-metadata/models/base"
+	teamsModels "github.com/nambuitechx/go-metadata/models/teams"
+	typeModels "github.com/nambuitechx/go-metadata/models/type"
+)
+
+type TeamEntityRepository struct {
+	DB baseRepositories.DBTX
+}
+
+func NewTeamEntityRepository(db *sqlx.DB) *TeamEntityRepository {
+	return &TeamEntityRepository{ DB: db }
+}
+
+func (r *TeamEntityRepository) WithTx(tx *sqlx.Tx) *TeamEntityRepository {
+	return &TeamEntityRepository{ DB: tx }
+}
+
+func (r *TeamEntityRepository) SelectTeamEntities(include string, limit int, before *baseModels.Cursor, after *baseModels.Cursor) ([]teamsModels.TeamEntity, error) {
+	teamEntities := []teamsModels.TeamEntity{}
+	statement := "SELECT * FROM team_entity WHERE " + baseRepositories.DeletedCondition(include)
+	statement, args := baseRepositories.Paginate(statement, []interface{}{}, limit, before, after)
+	err := r.DB.Select(&teamEntities, statement, args...)
+	return teamEntities, err
+}
+
+func (r *TeamEntityRepository) SelectCountTeamEntities(include string) (*baseModels.EntityTotal, error) {
+	entityTotal := &baseModels.EntityTotal{}
+	statement := "SELECT COUNT(id) as total FROM team_entity WHERE " + baseRepositories.DeletedCondition(include)
+	err := r.DB.Get(entityTotal, statement)
+	return entityTotal, err
+}
+
+func (r *TeamEntityRepository) SelectTeamEntityById(id string, include string) (*teamsModels.TeamEntity, error) {
+	teamEntity := &teamsModels.TeamEntity{}
+	statement := "SELECT * FROM team_entity WHERE id = $1 AND " + baseRepositories.DeletedCondition(include)
+	err := r.DB.Get(teamEntity, statement, id)
+	return teamEntity, err
+}
+
+func (r *TeamEntityRepository) SelectTeamEntityByName(name string, include string) (*teamsModels.TeamEntity, error) {
+	teamEntity := &teamsModels.TeamEntity{}
+	statement := "SELECT * FROM team_entity WHERE name = $1 AND " + baseRepositories.DeletedCondition(include)
+	err := r.DB.Get(teamEntity, statement, name)
+	return teamEntity, err
+}
+
+func (r *TeamEntityRepository) InsertTeamEntity(payload *teamsModels.TeamEntity) (*teamsModels.TeamEntity, error) {
+	var teamEntity = teamsModels.TeamEntity{}
+	statement := `
+		INSERT INTO team_entity(id, name, json, updatedat, updatedby, deleted, namehash)
+		VALUES($1, $2, $3, $4, $5, $6, $7) RETURNING *
+	`
+	err := r.DB.Get(
+		&teamEntity,
+		statement,
+		payload.ID,
+		payload.Name,
+		payload.Json,
+		payload.UpdatedAt,
+		payload.UpdatedBy,
+		payload.Deleted,
+		payload.NameHash,
+	)
+	return &teamEntity, err
+}
+
+func (r *TeamEntityRepository) UpdateTeamEntity(payload *teamsModels.TeamEntity, previousVersion float64) (*teamsModels.TeamEntity, error) {
+	var teamEntity = teamsModels.TeamEntity{}
+	statement := `
+		UPDATE team_entity
+		SET json = $2, updatedat = $3, updatedby = $4, deleted = $5
+		WHERE id = $1 AND COALESCE((json->>'version')::float8, 0) = $6 RETURNING *
+	`
+	err := r.DB.Get(
+		&teamEntity,
+		statement,
+		payload.ID,
+		payload.Json,
+		payload.UpdatedAt,
+		payload.UpdatedBy,
+		payload.Deleted,
+		previousVersion,
+	)
+	return &teamEntity, err
+}
+
+func (r *TeamEntityRepository) DeleteTeamEntityById(id string) error {
+	statement := "DELETE FROM team_entity WHERE id = $1"
+	_, err := r.DB.Exec(statement, id)
+	return err
+}
+
+func (r *TeamEntityRepository) SelectChildTeamEntities(id string) ([]teamsModels.TeamEntity, error) {
+	teamEntities := []teamsModels.TeamEntity{}
+	statement := `
+		SELECT t.* FROM team_entity t
+		JOIN entity_relationship r ON r.toid = t.id
+		WHERE r.fromid = $1 AND r.relation = $2 AND r.fromentity = 'team' AND t.deleted = FALSE
+		ORDER BY t.name
+	`
+	err := r.DB.Select(&teamEntities, statement, id, typeModels.Relationship["PARENT_OF"])
+	return teamEntities, err
+}
+
+// SelectTeamIdsByUserId returns the teams of a user together with all their ancestors
+func (r *TeamEntityRepository) SelectTeamIdsByUserId(userId string) ([]string, error) {
+	ids := []string{}
+	statement := `
+		WITH RECURSIVE teams(id) AS (
+			SELECT fromid FROM entity_relationship
+			WHERE toid = $1 AND relation = $2 AND fromentity = 'team' AND toentity = 'user'
+			UNION
+			SELECT r.fromid FROM entity_relationship r
+			JOIN teams t ON r.toid = t.id
+			WHERE r.relation = $3 AND r.fromentity = 'team'
+		)
+		SELECT id FROM teams
+	`
+	err := r.DB.Select(&ids, statement, userId, typeModels.Relationship["HAS"], typeModels.Relationship["PARENT_OF"])
+	return ids, err
+}
+
+func (r *TeamEntityRepository) InsertTeamParent(parentId string, childId string) error {
+	statement := `
+		INSERT INTO entity_relationship(fromid, toid, fromentity, toentity, relation, deleted)
+		VALUES($1, $2, 'team', 'team', $3, FALSE)
+		ON CONFLICT (fromid, toid, relation) DO NOTHING
+	`
+	_, err := r.DB.Exec(statement, parentId, childId, typeModels.Relationship["PARENT_OF"])
+	return err
+}
+
+// ReplaceTeamUsers replaces the HAS rows of a team with the given users
+func (r *TeamEntityRepository) ReplaceTeamUsers(id string, users []*typeModels.EntityReference) error {
+	statement := "DELETE FROM entity_relationship WHERE fromid = $1 AND relation = $2 AND fromentity = 'team' AND toentity = 'user'"
+
+	if _, err := r.DB.Exec(statement, id, typeModels.Relationship["HAS"]); err != nil {
+		return err
+	}
+
+	statement = `
+		INSERT INTO entity_relationship(fromid, toid, fromentity, toentity, relation, deleted)
+		VALUES($1, $2, 'team', 'user', $3, FALSE)
+		ON CONFLICT (fromid, toid, relation) DO NOTHING
+	`
+
+	for _, user := range users {
+		if _, err := r.DB.Exec(statement, id, user.ID, typeModels.Relationship["HAS"]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// DeleteTeamRelationships deletes the parent, children and users rows of a team
+func (r *TeamEntityRepository) DeleteTeamRelationships(id string) error {
+	statement := "DELETE FROM entity_relationship WHERE (fromid = $1 OR toid = $1) AND relation IN ($2, $3) AND fromentity = 'team'"
+	_, err := r.DB.Exec(statement, id, typeModels.Relationship["PARENT_OF"], typeModels.Relationship["HAS"])
+	return err
+}
@@ -0,0 +1,98 @@
+package repositories
+
+import (
+	"github.com/jmoiron/sqlx"
+	baseRepositories "github.com/nambuitechx/go-metadata/repositories/base"
+	baseModels "github.com/nambuitechx/go-metadata/models/base"
+	teamsModels "github.com/nambuitechx/go-metadata/models/teams"
+)
+
+type UserEntityRepository struct {
+	DB baseRepositories.DBTX
+}
+
+func NewUserEntityRepository(db *sqlx.DB) *UserEntityRepository {
+	return &UserEntityRepository{ DB: db }
+}
+
+func (r *UserEntityRepository) WithTx(tx *sqlx.Tx) *UserEntityRepository {
+	return &UserEntityRepository{ DB: tx }
+}
+
+func (r *UserEntityRepository) SelectUserEntities(include string, limit int, before *baseModels.Cursor, after *baseModels.Cursor) ([]teamsModels.UserEntity, error) {
+	userEntities := []teamsModels.UserEntity{}
+	statement := "SELECT * FROM user_entity WHERE " + baseRepositories.DeletedCondition(include)
+	statement, args := baseRepositories.Paginate(statement, []interface{}{}, limit, before, after)
+	err := r.DB.Select(&userEntities, statement, args...)
+	return userEntities, err
+}
+
+func (r *UserEntityRepository) SelectCountUserEntities(include string) (*baseModels.EntityTotal, error) {
+	entityTotal := &baseModels.EntityTotal{}
+	statement := "SELECT COUNT(id) as total FROM user_entity WHERE " + baseRepositories.DeletedCondition(include)
+	err := r.DB.Get(entityTotal, statement)
+	return entityTotal, err
+}
+
+func (r *UserEntityRepository) SelectUserEntityById(id string, include string) (*teamsModels.UserEntity, error) {
+	userEntity := &teamsModels.UserEntity{}
+	statement := "SELECT * FROM user_entity WHERE id = $1 AND " + baseRepositories.DeletedCondition(include)
+	err := r.DB.Get(userEntity, statement, id)
+	return userEntity, err
+}
+
+func (r *UserEntityRepository) SelectUserEntityByName(name string, include string) (*teamsModels.UserEntity, error) {
+	userEntity := &teamsModels.UserEntity{}
+	statement := "SELECT * FROM user_entity WHERE name = $1 AND " + baseRepositories.DeletedCondition(include)
+	err := r.DB.Get(userEntity, statement, name)
+	return userEntity, err
+}
+
+func (r *UserEntityRepository) InsertUserEntity(payload *teamsModels.UserEntity) (*teamsModels.UserEntity, error) {
+	var userEntity = teamsModels.UserEntity{}
+	statement := `
+		INSERT INTO user_entity(id, name, email, deactivated, json, updatedat, updatedby, deleted, namehash, isbot, passwordhash)
+		VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11) RETURNING *
+	`
+	err := r.DB.Get(
+		&userEntity,
+		statement,
+		payload.ID,
+		payload.Name,
+		payload.Email,
+		payload.Deactivated,
+		payload.Json,
+		payload.UpdatedAt,
+		payload.UpdatedBy,
+		payload.Deleted,
+		payload.NameHash,
+		payload.IsBot,
+		payload.PasswordHash,
+	)
+	return &userEntity, err
+}
+
+// UpdateUserEntity only writes when the stored version is still previousVersion, sql.ErrNoRows otherwise
+func (r *UserEntityRepository) UpdateUserEntity(payload *teamsModels.UserEntity, previousVersion float64) (*teamsModels.UserEntity, error) {
+	var userEntity = teamsModels.UserEntity{}
+	statement := `
+		UPDATE user_entity
+		SET email = $2, deactivated = $3, json = $4, updatedat = $5, updatedby = $6, deleted = $7, isbot = $8, passwordhash = $9
+		WHERE id = $1 AND COALESCE((json->>'version')::float8, 0) = $10 RETURNING *
+	`
+	err := r.DB.Get(
+		&userEntity,
+		statement,
+		payload.ID,
+		payload.Email,
+		payload.Deactivated,
+		payload.Json,
+		payload.UpdatedAt,
+		payload.UpdatedBy,
+		payload.Deleted,
+		payload.IsBot,
+		payload.PasswordHash,
+		previousVersion,
+	)
+	return &userEntity, err
+}
@@ -0,0 +1,63 @@
+package repositories
+
+import (
+	"github.com/jmoiron/sqlx"
+	baseRepositories "github.com/nambuitechx/go-metadata/repositories/base"
+	teamsModels "github.com/nambuitechx/go-metadata/models/teams"
+)
+
+type UserTokenRepository struct {
+	DB baseRepositories.DBTX
+}
+
+func NewUserTokenRepository(db *sqlx.DB) *UserTokenRepository {
+	return &UserTokenRepository{ DB: db }
+}
+
+func (r *UserTokenRepository) WithTx(tx *sqlx.Tx) *UserTokenRepository {
+	return &UserTokenRepository{ DB: tx }
+}
+
+func (r *UserTokenRepository) SelectUserTokens(userId string, tokenType string) ([]teamsModels.UserTokenEntity, error) {
+	userTokenEntities := []teamsModels.UserTokenEntity{}
+	statement := "SELECT * FROM user_token WHERE userid = $1 AND tokentype = $2 ORDER BY (json->>'createdAt')::bigint, id"
+	err := r.DB.Select(&userTokenEntities, statement, userId, tokenType)
+	return userTokenEntities, err
+}
+
+func (r *UserTokenRepository) SelectUserTokenById(id string) (*teamsModels.UserTokenEntity, error) {
+	userTokenEntity := &teamsModels.UserTokenEntity{}
+	statement := "SELECT * FROM user_token WHERE id = $1"
+	err := r.DB.Get(userTokenEntity, statement, id)
+	return userTokenEntity, err
+}
+
+func (r *UserTokenRepository) InsertUserToken(payload *teamsModels.UserTokenEntity) (*teamsModels.UserTokenEntity, error) {
+	var userTokenEntity = teamsModels.UserTokenEntity{}
+	statement := `
+		INSERT INTO user_token(id, userid, tokentype, expiresat, json)
+		VALUES($1, $2, $3, $4, $5) RETURNING *
+	`
+	err := r.DB.Get(
+		&userTokenEntity,
+		statement,
+		payload.ID,
+		payload.UserID,
+		payload.TokenType,
+		payload.ExpiresAt,
+		payload.Json,
+	)
+	return &userTokenEntity, err
+}
+
+func (r *UserTokenRepository) DeleteUserTokenById(id string) error {
+	statement := "DELETE FROM user_token WHERE id = $1"
+	_, err := r.DB.Exec(statement, id)
+	return err
+}
+
+func (r *UserTokenRepository) DeleteUserTokens(userId string, tokenType string) error {
+	statement := "DELETE FROM user_token WHERE userid = $1 AND tokentype = $2"
+	_, err := r.DB.Exec(statement, userId, tokenType)
+	return err
+}
@@ -0,0 +1,43 @@
+package repositories
+
+import (
+	"github.com/jmoiron/sqlx"
+	baseRepositories "github.com/nambuitechx/go-metadata/repositories/base"
+	typeModels "github.com/nambuitechx/go-metadata/models/type"
+)
+
+type CascadeDeletionRepository struct {
+	DB baseRepositories.DBTX
+}
+
+func NewCascadeDeletionRepository(db *sqlx.DB) *CascadeDeletionRepository {
+	return &CascadeDeletionRepository{ DB: db }
+}
+
+func (r *CascadeDeletionRepository) WithTx(tx *sqlx.Tx) *CascadeDeletionRepository {
+	return &CascadeDeletionRepository{ DB: tx }
+}
+
+func (r *CascadeDeletionRepository) SelectCascadeDeletions(parentId string) ([]typeModels.CascadeDeletion, error) {
+	cascadeDeletions := []typeModels.CascadeDeletion{}
+	statement := "SELECT * FROM cascade_deletion WHERE parentid = $1"
+	err := r.DB.Select(&cascadeDeletions, statement, parentId)
+	return cascadeDeletions, err
+}
+
+// InsertCascadeDeletion records a child deleted along with its parent, a child is only recorded for its latest cascade
+func (r *CascadeDeletionRepository) InsertCascadeDeletion(id string, parentId string, version float64) error {
+	statement := `
+		INSERT INTO cascade_deletion(id, parentid, version)
+		VALUES($1, $2, $3)
+		ON CONFLICT (id) DO UPDATE SET parentid = EXCLUDED.parentid, version = EXCLUDED.version
+	`
+	_, err := r.DB.Exec(statement, id, parentId, version)
+	return err
+}
+
+func (r *CascadeDeletionRepository) DeleteCascadeDeletions(parentId string) error {
+	statement := "DELETE FROM cascade_deletion WHERE parentid = $1"
+	_, err := r.DB.Exec(statement, parentId)
+	return err
+}
@@ -0,0 +1,62 @@
+package repositories
+
+import (
+	"github.com/jmoiron/sqlx"
+	baseRepositories "github.com/nambuitechx/go-metadata/repositories/base"
+	typeModels "github.com/nambuitechx/go-metadata/models/type"
+)
+
+type EntityExtensionRepository struct {
+	DB baseRepositories.DBTX
+}
+
+func NewEntityExtensionRepository(db *sqlx.DB) *EntityExtensionRepository {
+	return &EntityExtensionRepository{ DB: db }
+}
+
+func (r *EntityExtensionRepository) WithTx(tx *sqlx.Tx) *EntityExtensionRepository {
+	return &EntityExtensionRepository{ DB: tx }
+}
+
+// SelectEntityVersions returns the previous versions of an entity, latest first
+func (r *EntityExtensionRepository) SelectEntityVersions(id string, entityType string) ([]typeModels.EntityExtension, error) {
+	entityExtensions := []typeModels.EntityExtension{}
+	statement := `
+		SELECT * FROM entity_extension
+		WHERE id = $1 AND extension LIKE ($2 || '.version.%')
+		ORDER BY (json->>'version')::numeric DESC
+	`
+	err := r.DB.Select(&entityExtensions, statement, id, entityType)
+	return entityExtensions, err
+}
+
+func (r *EntityExtensionRepository) SelectEntityExtension(id string, extension string) (*typeModels.EntityExtension, error) {
+	entityExtension := &typeModels.EntityExtension{}
+	statement := "SELECT * FROM entity_extension WHERE id = $1 AND extension = $2"
+	err := r.DB.Get(entityExtension, statement, id, extension)
+	return entityExtension, err
+}
+
+func (r *EntityExtensionRepository) InsertEntityExtension(id string, extension string, jsonSchema string, json interface{}) error {
+	statement := `
+		INSERT INTO entity_extension(id, extension, jsonschema, json)
+		VALUES($1, $2, $3, $4)
+		ON CONFLICT (id, extension) DO UPDATE SET json = EXCLUDED.json
+	`
+	_, err := r.DB.Exec(statement, id, extension, jsonSchema, json)
+	return err
+}
+
+// SelectEntityExtensionsByJsonSchema returns every stored version of the entities of a type
+func (r *EntityExtensionRepository) SelectEntityExtensionsByJsonSchema(jsonSchema string) ([]typeModels.EntityExtension, error) {
+	entityExtensions := []typeModels.EntityExtension{}
+	statement := "SELECT * FROM entity_extension WHERE jsonschema = $1"
+	err := r.DB.Select(&entityExtensions, statement, jsonSchema)
+	return entityExtensions, err
+}
+
+func (r *EntityExtensionRepository) UpdateEntityExtensionJson(id string, extension string, json string) error {
+	statement := "UPDATE entity_extension SET json = $3 WHERE id = $1 AND extension = $2"
+	_, err := r.DB.Exec(statement, id, extension, json)
+	return err
+}
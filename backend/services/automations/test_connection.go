@@ -0,0 +1,378 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	_ "github.com/jackc/pgx/stdlib"
+	automationsModels "github.com/nambuitechx/go-metadata/models/automations"
+	servicesModels "github.com/nambuitechx/go-metadata/models/services"
+	servicesRepositories "github.com/nambuitechx/go-metadata/repositories/services"
+	securityServices "github.com/nambuitechx/go-metadata/services/security"
+	servicesServices "github.com/nambuitechx/go-metadata/services/services"
+)
+
+// A step failing to answer in time fails, the connection itself gives up earlier
+const (
+	testConnectionStepTimeout = 30 * time.Second
+	testConnectionConnectTimeout = 10 * time.Second
+)
+
+// TestConnectionRunner tests the connection of a TEST_CONNECTION workflow with the steps of the test connection
+// definition of its connection type, without the ingestion sidecar
+type TestConnectionRunner struct {
+	WorkflowEntityService *WorkflowEntityService
+	TestConnectionDefinitionEntityRepository *servicesRepositories.TestConnectionDefinitionEntityRepository
+	DBServiceEntityService *servicesServices.DBServiceEntityService
+}
+
+func NewTestConnectionRunner(
+	workflowEntityService *WorkflowEntityService,
+	testConnectionDefinitionEntityRepository *servicesRepositories.TestConnectionDefinitionEntityRepository,
+	dbserviceEntityService *servicesServices.DBServiceEntityService,
+) *TestConnectionRunner {
+	return &TestConnectionRunner{
+		WorkflowEntityService: workflowEntityService,
+		TestConnectionDefinitionEntityRepository: testConnectionDefinitionEntityRepository,
+		DBServiceEntityService: dbserviceEntityService,
+	}
+}
+
+// ValidateWorkflow checks the workflow can be run before it is queued
+func (r *TestConnectionRunner) ValidateWorkflow(exist *automationsModels.WorkflowEntity) error {
+	_, _, err := getTestConnector(exist)
+	return err
+}
+
+// RunWorkflow moves the workflow to Running, runs its test connection and stores the result in its response, then
+// moves it to Successful when every mandatory step passed and to Failed otherwise, or to Cancelled when ctx was
+// cancelled. The result is also stored in the testConnectionResult of the database service named in the request when
+// it exists
+func (r *TestConnectionRunner) RunWorkflow(ctx context.Context, exist *automationsModels.WorkflowEntity, userName string) (*automationsModels.WorkflowEntity, error) {
+	definitionName, connector, err := getTestConnector(exist)
+
+	if err != nil {
+		return nil, err
+	}
+
+	request := exist.Json.Request
+	fullyQualifiedName := fmt.Sprintf("%v.%v", definitionName, servicesModels.TestConnectionDefinitionString)
+	definitionEntity, err := r.TestConnectionDefinitionEntityRepository.SelectTestConnectionDefinitionEntityByFqn(fullyQualifiedName, "non-deleted")
+
+	if err != nil {
+		return nil, fmt.Errorf("test connection definition %v not found: %w", fullyQualifiedName, err)
+	}
+
+	config, err := r.decryptConfig(request.Connection.Config)
+
+	if err != nil {
+		return nil, err
+	}
+
+	running := &servicesModels.TestConnectionResult{ Status: automationsModels.RunningStatus, Steps: []*servicesModels.TestConnectionStepResult{} }
+	workflowEntity, err := r.saveResult(exist, automationsModels.RunningStatus, running, userName)
+
+	if err != nil {
+		return nil, err
+	}
+
+	result := runTestConnection(ctx, connector, config, definitionEntity.Json.Steps)
+	status := result.Status
+
+	// The server which recovered the run stores its result
+	if errors.Is(context.Cause(ctx), automationsModels.ErrWorkflowRunLost) {
+		return nil, automationsModels.ErrWorkflowRunLost
+	}
+
+	if errors.Is(ctx.Err(), context.Canceled) {
+		status = automationsModels.CancelledStatus
+	}
+
+	workflowEntity, err = r.saveResult(workflowEntity, status, result, userName)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.updateDBServiceResult(request.ServiceName, result, userName); err != nil {
+		log.Printf("Failed to store the test connection result of %v: %v\n", request.ServiceName, err)
+	}
+
+	return workflowEntity, nil
+}
+
+// getTestConnector returns the test connection definition name and the connector of the workflow connection type
+func getTestConnector(exist *automationsModels.WorkflowEntity) (string, *testConnector, error) {
+	if exist.WorkflowType != automationsModels.TestConnectionWorkflow {
+		return "", nil, automationsModels.ErrNotTestConnection
+	}
+
+	request := exist.Json.Request
+
+	if request == nil || request.Connection == nil {
+		return "", nil, errors.New("workflow request has no connection")
+	}
+
+	definitionName, ok := automationsModels.TestConnectionDefinitionNames[request.ConnectionType]
+	connector, connectorOk := testConnectors[definitionName]
+
+	if !ok || !connectorOk {
+		return "", nil, fmt.Errorf("%v: %w", request.ConnectionType, automationsModels.ErrUnsupportedConnection)
+	}
+
+	return definitionName, connector, nil
+}
+
+// decryptConfig returns a plain copy of the stored connection config, the workflow keeps the encrypted one
+func (r *TestConnectionRunner) decryptConfig(config map[string]interface{}) (map[string]interface{}, error) {
+	bytes, err := json.Marshal(config)
+
+	if err != nil {
+		return nil, err
+	}
+
+	plain := map[string]interface{}{}
+
+	if err := json.Unmarshal(bytes, &plain); err != nil {
+		return nil, err
+	}
+
+	if err := securityServices.DecryptConfig(r.WorkflowEntityService.SecretsManager, plain); err != nil {
+		return nil, err
+	}
+
+	return plain, nil
+}
+
+func (r *TestConnectionRunner) saveResult(exist *automationsModels.WorkflowEntity, status string, result *servicesModels.TestConnectionResult, userName string) (*automationsModels.WorkflowEntity, error) {
+	return r.WorkflowEntityService.UpdateWorkflowStatus(exist, status, result, userName)
+}
+
+// updateDBServiceResult skips the services which do not exist yet, they are tested before being created
+func (r *TestConnectionRunner) updateDBServiceResult(serviceName string, result *servicesModels.TestConnectionResult, userName string) error {
+	if serviceName == "" {
+		return nil
+	}
+
+	dbserviceEntity, err := r.DBServiceEntityService.GetDBServiceEntityByFqn(serviceName, "non-deleted")
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	_, err = r.DBServiceEntityService.UpdateDBServiceTestConnectionResult(dbserviceEntity, result, userName)
+	return err
+}
+
+// runTestConnection runs the steps in order. A failed step with shortCircuit skips the next ones, as does the end of
+// ctx, the result fails when a mandatory step did not pass
+func runTestConnection(ctx context.Context, connector *testConnector, config map[string]interface{}, steps []*servicesModels.TestConnectionStep) *servicesModels.TestConnectionResult {
+	result := &servicesModels.TestConnectionResult{
+		Status: automationsModels.SuccessfulStatus,
+		Steps: []*servicesModels.TestConnectionStepResult{},
+	}
+
+	conn, openErr := connector.open(config)
+
+	if openErr == nil {
+		defer conn.db.Close()
+	}
+
+	shortCircuited := ""
+
+	for _, step := range steps {
+		stepResult := &servicesModels.TestConnectionStepResult{ Name: step.Name, Mandatory: step.Mandatory }
+
+		if shortCircuited != "" {
+			stepResult.Message = fmt.Sprintf("Skipped since %v failed", shortCircuited)
+		} else if ctx.Err() != nil {
+			stepResult.Message = "Skipped since the run was stopped"
+			stepResult.ErrorLog = ctx.Err().Error()
+		} else if err := runTestConnectionStep(ctx, connector, conn, openErr, step.Name); err != nil {
+			stepResult.Message = step.ErrorMessage
+			stepResult.ErrorLog = err.Error()
+
+			if step.ShortCircuit {
+				shortCircuited = step.Name
+			}
+		} else {
+			stepResult.Passed = true
+			stepResult.Message = "Passed"
+		}
+
+		if step.Mandatory && !stepResult.Passed {
+			result.Status = automationsModels.FailedStatus
+		}
+
+		result.Steps = append(result.Steps, stepResult)
+	}
+
+	result.LastUpdatedAt = int(time.Now().Unix())
+	return result
+}
+
+func runTestConnectionStep(ctx context.Context, connector *testConnector, conn *testConnection, openErr error, name string) error {
+	if openErr != nil {
+		return openErr
+	}
+
+	step, ok := connector.steps[name]
+
+	if !ok {
+		return fmt.Errorf("step %v is not supported", name)
+	}
+
+	stepCtx, cancel := context.WithTimeout(ctx, testConnectionStepTimeout)
+	defer cancel()
+
+	return step(stepCtx, conn)
+}
+
+// testConnection is the database under test, with the schema its steps look into when the connection names one
+type testConnection struct {
+	db *sql.DB
+	schema string
+}
+
+// testConnectionStep checks one capability of the connection, it passes without error
+type testConnectionStep func(ctx context.Context, conn *testConnection) error
+
+// testConnector opens a connection from its config and knows how to run the steps of its definition
+type testConnector struct {
+	open func(config map[string]interface{}) (*testConnection, error)
+	steps map[string]testConnectionStep
+}
+
+// Connectors by test connection definition name
+var testConnectors = map[string]*testConnector {
+	"Postgres": {
+		open: openPostgresConnection,
+		steps: map[string]testConnectionStep {
+			"CheckAccess": pingStep,
+			"GetDatabases": queryStep("SELECT datname FROM pg_database WHERE NOT datistemplate LIMIT 1", false),
+			"GetSchemas": queryStep("SELECT schema_name FROM information_schema.schemata LIMIT 1", false),
+			"GetTables": queryStep("SELECT table_name FROM information_schema.tables WHERE table_type = 'BASE TABLE' AND table_schema NOT IN ('pg_catalog', 'information_schema') LIMIT 1", false),
+			"GetViews": queryStep("SELECT table_name FROM information_schema.views WHERE table_schema NOT IN ('pg_catalog', 'information_schema') LIMIT 1", false),
+			"GetTags": queryStep("SELECT p.polname FROM pg_policy p JOIN pg_class c ON c.oid = p.polrelid JOIN pg_namespace n ON n.oid = c.relnamespace LIMIT 1", false),
+			"GetQueries": queryStep("SELECT query FROM pg_stat_statements LIMIT 1", false),
+		},
+	},
+	"Mysql": {
+		open: openMysqlConnection,
+		steps: map[string]testConnectionStep {
+			"CheckAccess": pingStep,
+			"GetDatabases": queryStep("SELECT schema_name FROM information_schema.schemata LIMIT 1", false),
+			"GetSchemas": queryStep("SELECT schema_name FROM information_schema.schemata LIMIT 1", false),
+			"GetTables": queryStep("SELECT table_name FROM information_schema.tables WHERE table_schema = ? AND table_type = 'BASE TABLE' LIMIT 1", true),
+			"GetViews": queryStep("SELECT table_name FROM information_schema.views WHERE table_schema = ? LIMIT 1", true),
+			"GetQueries": queryStep("SELECT argument FROM mysql.general_log LIMIT 1", false),
+		},
+	},
+}
+
+func pingStep(ctx context.Context, conn *testConnection) error {
+	return conn.db.PingContext(ctx)
+}
+
+// queryStep passes when the statement runs, the schema of the connection is its argument when withSchema is set
+func queryStep(statement string, withSchema bool) testConnectionStep {
+	return func(ctx context.Context, conn *testConnection) error {
+		args := []interface{}{}
+
+		if withSchema {
+			args = append(args, conn.schema)
+		}
+
+		rows, err := conn.db.QueryContext(ctx, statement, args...)
+
+		if err != nil {
+			return err
+		}
+
+		defer rows.Close()
+
+		for rows.Next() {
+		}
+
+		return rows.Err()
+	}
+}
+
+func openPostgresConnection(config map[string]interface{}) (*testConnection, error) {
+	c := &servicesModels.PostgresConnection{}
+
+	if err := decodeConnection(config, c); err != nil {
+		return nil, err
+	}
+
+	password, _ := c.AuthType["password"].(string)
+	query := url.Values{}
+	query.Set("connect_timeout", fmt.Sprint(int(testConnectionConnectTimeout.Seconds())))
+
+	if c.SSLMode != nil && *c.SSLMode != "" {
+		query.Set("sslmode", *c.SSLMode)
+	}
+
+	dataSourceName := &url.URL{
+		Scheme: "postgres",
+		User: url.UserPassword(c.Username, password),
+		Host: c.HostPort,
+		Path: "/" + c.Database,
+		RawQuery: query.Encode(),
+	}
+
+	db, err := sql.Open("pgx", dataSourceName.String())
+
+	if err != nil {
+		return nil, err
+	}
+
+	db.SetMaxOpenConns(1)
+	return &testConnection{ db: db }, nil
+}
+
+func openMysqlConnection(config map[string]interface{}) (*testConnection, error) {
+	c := &servicesModels.MysqlConnection{}
+
+	if err := decodeConnection(config, c); err != nil {
+		return nil, err
+	}
+
+	mysqlConfig := mysql.NewConfig()
+	mysqlConfig.User = c.Username
+	mysqlConfig.Passwd, _ = c.AuthType["password"].(string)
+	mysqlConfig.Net = "tcp"
+	mysqlConfig.Addr = c.HostPort
+	mysqlConfig.DBName = c.DatabaseName
+	mysqlConfig.Timeout = testConnectionConnectTimeout
+
+	db, err := sql.Open("mysql", mysqlConfig.FormatDSN())
+
+	if err != nil {
+		return nil, err
+	}
+
+	db.SetMaxOpenConns(1)
+	return &testConnection{ db: db, schema: c.DatabaseSchema }, nil
+}
+
+func decodeConnection(config map[string]interface{}, connection interface{}) error {
+	bytes, err := json.Marshal(config)
+
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(bytes, connection)
+}
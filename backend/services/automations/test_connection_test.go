@@ -0,0 +1,170 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"reflect"
+	"testing"
+
+	automationsModels "github.com/nambuitechx/go-metadata/models/automations"
+	servicesModels "github.com/nambuitechx/go-metadata/models/services"
+)
+
+func newTestConnector(openErr error) *testConnector {
+	pass := func(ctx context.Context, conn *testConnection) error { return nil }
+	fail := func(ctx context.Context, conn *testConnection) error { return errors.New("permission denied") }
+
+	return &testConnector{
+		open: func(config map[string]interface{}) (*testConnection, error) {
+			if openErr != nil {
+				return nil, openErr
+			}
+
+			// Opening does not connect, the steps never use the database
+			db, err := sql.Open("pgx", "postgres://localhost/test")
+			return &testConnection{ db: db }, err
+		},
+		steps: map[string]testConnectionStep{
+			"CheckAccess": pass,
+			"GetTables": pass,
+			"GetQueries": fail,
+			"GetTags": fail,
+		},
+	}
+}
+
+func TestRunTestConnection(t *testing.T) {
+	step := func(name string, mandatory bool, shortCircuit bool) *servicesModels.TestConnectionStep {
+		return &servicesModels.TestConnectionStep{ Name: name, ErrorMessage: name + " failed", Mandatory: mandatory, ShortCircuit: shortCircuit }
+	}
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name string
+		ctx context.Context
+		openErr error
+		steps []*servicesModels.TestConnectionStep
+		expectedStatus string
+		expectedPassed []bool
+		expectedMessages []string
+	}{
+		{
+			name: "every step passes",
+			steps: []*servicesModels.TestConnectionStep{ step("CheckAccess", true, true), step("GetTables", true, false) },
+			expectedStatus: automationsModels.SuccessfulStatus,
+			expectedPassed: []bool{true, true},
+			expectedMessages: []string{"Passed", "Passed"},
+		},
+		{
+			name: "optional step fails",
+			steps: []*servicesModels.TestConnectionStep{ step("CheckAccess", true, true), step("GetQueries", false, false) },
+			expectedStatus: automationsModels.SuccessfulStatus,
+			expectedPassed: []bool{true, false},
+			expectedMessages: []string{"Passed", "GetQueries failed"},
+		},
+		{
+			name: "mandatory step fails",
+			steps: []*servicesModels.TestConnectionStep{ step("GetTags", true, false), step("GetTables", true, false) },
+			expectedStatus: automationsModels.FailedStatus,
+			expectedPassed: []bool{false, true},
+			expectedMessages: []string{"GetTags failed", "Passed"},
+		},
+		{
+			name: "short circuit skips the next steps",
+			steps: []*servicesModels.TestConnectionStep{ step("GetQueries", false, true), step("GetTables", true, false) },
+			expectedStatus: automationsModels.FailedStatus,
+			expectedPassed: []bool{false, false},
+			expectedMessages: []string{"GetQueries failed", "Skipped since GetQueries failed"},
+		},
+		{
+			name: "unsupported step",
+			steps: []*servicesModels.TestConnectionStep{ step("GetDashboards", false, false) },
+			expectedStatus: automationsModels.SuccessfulStatus,
+			expectedPassed: []bool{false},
+			expectedMessages: []string{"GetDashboards failed"},
+		},
+		{
+			name: "connection fails to open",
+			openErr: errors.New("invalid port"),
+			steps: []*servicesModels.TestConnectionStep{ step("CheckAccess", true, false), step("GetTables", false, false) },
+			expectedStatus: automationsModels.FailedStatus,
+			expectedPassed: []bool{false, false},
+			expectedMessages: []string{"CheckAccess failed", "GetTables failed"},
+		},
+		{
+			name: "stopped run",
+			ctx: cancelled,
+			steps: []*servicesModels.TestConnectionStep{ step("CheckAccess", true, false) },
+			expectedStatus: automationsModels.FailedStatus,
+			expectedPassed: []bool{false},
+			expectedMessages: []string{"Skipped since the run was stopped"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := test.ctx
+
+			if ctx == nil {
+				ctx = context.Background()
+			}
+
+			result := runTestConnection(ctx, newTestConnector(test.openErr), map[string]interface{}{}, test.steps)
+
+			if result.Status != test.expectedStatus {
+				t.Errorf("status = %v, expected %v", result.Status, test.expectedStatus)
+			}
+
+			passed := []bool{}
+			messages := []string{}
+
+			for _, stepResult := range result.Steps {
+				passed = append(passed, stepResult.Passed)
+				messages = append(messages, stepResult.Message)
+			}
+
+			if !reflect.DeepEqual(passed, test.expectedPassed) || !reflect.DeepEqual(messages, test.expectedMessages) {
+				t.Errorf("steps = %v %q, expected %v %q", passed, messages, test.expectedPassed, test.expectedMessages)
+			}
+		})
+	}
+}
+
+func TestGetTestConnector(t *testing.T) {
+	workflow := func(workflowType string, connectionType string, connection *servicesModels.DatabaseConnection) *automationsModels.WorkflowEntity {
+		request := &automationsModels.TestServiceConnection{ ConnectionType: connectionType, Connection: connection }
+		return &automationsModels.WorkflowEntity{ WorkflowType: workflowType, Json: &automationsModels.Workflow{ Request: request } }
+	}
+
+	connection := &servicesModels.DatabaseConnection{}
+
+	tests := []struct {
+		name string
+		workflow *automationsModels.WorkflowEntity
+		expected string
+		expectedErr bool
+	}{
+		{ name: "postgres", workflow: workflow(automationsModels.TestConnectionWorkflow, "Postgres", connection), expected: "Postgres" },
+		{ name: "mysql", workflow: workflow(automationsModels.TestConnectionWorkflow, "MySQL", connection), expected: "Mysql" },
+		{ name: "other workflow type", workflow: workflow("REVERSE_INGESTION", "Postgres", connection), expectedErr: true },
+		{ name: "no connection", workflow: workflow(automationsModels.TestConnectionWorkflow, "Postgres", nil), expectedErr: true },
+		{ name: "unsupported connection", workflow: workflow(automationsModels.TestConnectionWorkflow, "Snowflake", connection), expectedErr: true },
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			definitionName, connector, err := getTestConnector(test.workflow)
+
+			if (err != nil) != test.expectedErr {
+				t.Fatalf("getTestConnector() err = %v, expected an error: %v", err, test.expectedErr)
+			}
+
+			if err == nil && (definitionName != test.expected || connector != testConnectors[test.expected]) {
+				t.Errorf("getTestConnector() = %v, expected %v", definitionName, test.expected)
+			}
+		})
+	}
+}
@@ -5,45 +5,76 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
 	jsonpatch "github.com/evanphx/json-patch"
 	baseModels "github.com/nambuitechx/go-metadata/models/base"
 	automationsModels "github.com/nambuitechx/go-metadata/models/automations"
+	servicesModels "github.com/nambuitechx/go-metadata/models/services"
+	eventsModels "github.com/nambuitechx/go-metadata/models/events"
 	automationsRepositories "github.com/nambuitechx/go-metadata/repositories/automations"
+	baseRepositories "github.com/nambuitechx/go-metadata/repositories/base"
+	eventsRepositories "github.com/nambuitechx/go-metadata/repositories/events"
+	eventsServices "github.com/nambuitechx/go-metadata/services/events"
+	securityServices "github.com/nambuitechx/go-metadata/services/security"
+	typeModels "github.com/nambuitechx/go-metadata/models/type"
+	baseUtils "github.com/nambuitechx/go-metadata/utils"
 )
 
 type WorkflowEntityService struct {
+	Transactor *baseRepositories.Transactor
 	WorkflowEntityRepository *automationsRepositories.WorkflowEntityRepository
+	ChangeEventRepository *eventsRepositories.ChangeEventRepository
+	SecretsManager securityServices.SecretsManager
 }
 
-func NewWorkflowEntityService(workflowEntityRepository *automationsRepositories.WorkflowEntityRepository) *WorkflowEntityService {
-	return &WorkflowEntityService{ WorkflowEntityRepository: workflowEntityRepository }
+func NewWorkflowEntityService(
+	transactor *baseRepositories.Transactor,
+	workflowEntityRepository *automationsRepositories.WorkflowEntityRepository,
+	changeEventRepository *eventsRepositories.ChangeEventRepository,
+	secretsManager securityServices.SecretsManager,
+) *WorkflowEntityService {
+	return &WorkflowEntityService{
+		Transactor: transactor,
+		WorkflowEntityRepository: workflowEntityRepository,
+		ChangeEventRepository: changeEventRepository,
+		SecretsManager: secretsManager,
+	}
 }
 
 func (s *WorkflowEntityService) Health() string {
 	return "Workflow service is available"
 }
 
-func (s *WorkflowEntityService) GetAllWorkflowEntities(limit int, offset int) ([]automationsModels.WorkflowEntity, error) {
-	workflowEntity, err := s.WorkflowEntityRepository.SelectWorkflowEntities(limit, offset)
-	return workflowEntity, err
+func (s *WorkflowEntityService) GetAllWorkflowEntities(include string, limit int, before *baseModels.Cursor, after *baseModels.Cursor) ([]automationsModels.WorkflowEntity, *baseModels.Paging, error) {
+	workflowEntity, err := s.WorkflowEntityRepository.SelectWorkflowEntities(include, limit, before, after)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	workflowEntity, paging := baseModels.NewPaging(workflowEntity, limit, before, after, func(e automationsModels.WorkflowEntity) *baseModels.Cursor {
+		return &baseModels.Cursor{ Name: e.Json.FullyQualifiedName, ID: e.ID }
+	})
+
+	return workflowEntity, paging, nil
 }
 
-func (s *WorkflowEntityService) GetCountTableEntities() (*baseModels.EntityTotal, error) {
-	entityTotal, err := s.WorkflowEntityRepository.SelectCountWorkflowEntities()
+func (s *WorkflowEntityService) GetCountTableEntities(include string) (*baseModels.EntityTotal, error) {
+	entityTotal, err := s.WorkflowEntityRepository.SelectCountWorkflowEntities(include)
 	return entityTotal, err
 }
 
-func (s *WorkflowEntityService) GetWorkflowEntityById(id string) (*automationsModels.WorkflowEntity, error) {
-	workflowEntity, err := s.WorkflowEntityRepository.SelectWorkflowEntityById(id)
+func (s *WorkflowEntityService) GetWorkflowEntityById(id string, include string) (*automationsModels.WorkflowEntity, error) {
+	workflowEntity, err := s.WorkflowEntityRepository.SelectWorkflowEntityById(id, include)
 	return workflowEntity, err
 }
 
-func (s *WorkflowEntityService) GetWorkflowEntityByFqn(fqn string) (*automationsModels.WorkflowEntity, error) {
-	workflowEntity, err := s.WorkflowEntityRepository.SelectWorkflowEntityByFqn(fqn)
+func (s *WorkflowEntityService) GetWorkflowEntityByFqn(fqn string, include string) (*automationsModels.WorkflowEntity, error) {
+	workflowEntity, err := s.WorkflowEntityRepository.SelectWorkflowEntityByFqn(fqn, include)
 	return workflowEntity, err
 }
 
-func (s *WorkflowEntityService) CreateWorkflowEntity(payload *automationsModels.CreateWorkflowRequest) (*automationsModels.WorkflowEntity, error) {
+func (s *WorkflowEntityService) CreateWorkflowEntity(payload *automationsModels.CreateWorkflowRequest, userName string) (*automationsModels.WorkflowEntity, error) {
 	id := uuid.NewString()
 	now := time.Now().Unix()
 
@@ -67,18 +98,34 @@ func (s *WorkflowEntityService) CreateWorkflowEntity(payload *automationsModels.
 		Status: payload.Status,
 		Json: workflow,
 		UpdatedAt: now,
+		UpdatedBy: userName,
 		Deleted: false,
 	}
 
-	workflowEntity, err := s.WorkflowEntityRepository.InsertWorkflowEntity(entity)
+	workflowEntity, err := s.insertWorkflowEntity(entity)
 	return workflowEntity, err
 }
 
-func (s *WorkflowEntityService) CreateOrUpdateWorkflowEntity(payload *automationsModels.CreateWorkflowRequest) (*automationsModels.WorkflowEntity, error) {
-	exist, err := s.WorkflowEntityRepository.SelectWorkflowEntityByFqn(payload.Name)
+func (s *WorkflowEntityService) CreateOrUpdateWorkflowEntity(payload *automationsModels.CreateWorkflowRequest, userName string) (*automationsModels.WorkflowEntity, error) {
+	exist, err := s.WorkflowEntityRepository.SelectWorkflowEntityByFqn(payload.Name, "all")
 
 	if err == nil {
-		updated, err := s.WorkflowEntityRepository.UpdateWorkflowEntity(exist)
+		if exist.Deleted {
+			return nil, baseModels.ErrEntityDeleted
+		}
+
+		exist.WorkflowType = payload.WorkflowType
+		exist.Status = payload.Status
+		exist.Json.DisplayName = baseUtils.MergeString(exist.Json.DisplayName, payload.DisplayName)
+		exist.Json.Description = baseUtils.MergeString(exist.Json.Description, payload.Description)
+		exist.Json.WorkflowType = payload.WorkflowType
+		exist.Json.Status = payload.Status
+		exist.Json.Request = payload.Request
+		exist.Json.Response = payload.Response
+		exist.UpdatedAt = time.Now().Unix()
+		exist.UpdatedBy = userName
+
+		updated, err := s.updateWorkflowEntity(exist)
 		return updated, err
 	}
 
@@ -105,19 +152,37 @@ func (s *WorkflowEntityService) CreateOrUpdateWorkflowEntity(payload *automation
 		Status: payload.Status,
 		Json: workflow,
 		UpdatedAt: now,
+		UpdatedBy: userName,
 		Deleted: false,
 	}
 
-	workflowEntity, err := s.WorkflowEntityRepository.InsertWorkflowEntity(entity)
+	workflowEntity, err := s.insertWorkflowEntity(entity)
 	return workflowEntity, err
 }
 
 func (s *WorkflowEntityService) UpdateWorkflowEntity(exist *automationsModels.WorkflowEntity) (*automationsModels.WorkflowEntity, error) {
-	entity, err := s.WorkflowEntityRepository.UpdateWorkflowEntity(exist)
+	entity, err := s.updateWorkflowEntity(exist)
 	return entity, err
 }
 
-func (s *WorkflowEntityService) PatchWorkflowEntity(exist *automationsModels.WorkflowEntity, payload []baseModels.JsonPatchOperation) (*automationsModels.WorkflowEntity, error) {
+// UpdateWorkflowStatus stores the run state of the workflow, its status and the response unless it is nil. The run
+// state is not a change of the workflow, so no change event is recorded
+func (s *WorkflowEntityService) UpdateWorkflowStatus(exist *automationsModels.WorkflowEntity, status string, response *servicesModels.TestConnectionResult, userName string) (*automationsModels.WorkflowEntity, error) {
+	var responseJson []byte
+
+	if response != nil {
+		var err error
+		responseJson, err = json.Marshal(response)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return s.WorkflowEntityRepository.UpdateWorkflowStatus(exist.ID, status, responseJson, time.Now().Unix(), userName)
+}
+
+func (s *WorkflowEntityService) PatchWorkflowEntity(exist *automationsModels.WorkflowEntity, payload []baseModels.JsonPatchOperation, userName string) (*automationsModels.WorkflowEntity, error) {
 	// Prepare patch
 	jsonPatch, jsonPatchErr := json.Marshal(payload)
 
@@ -156,8 +221,10 @@ func (s *WorkflowEntityService) PatchWorkflowEntity(exist *automationsModels.Wor
 	exist.WorkflowType = modifiedWorkflow.WorkflowType
 	exist.Status = modifiedWorkflow.Status
 	exist.Json = &modifiedWorkflow
+	exist.UpdatedAt = time.Now().Unix()
+	exist.UpdatedBy = userName
 
-	updatedWorkflowEntity, updatedWorkflowEntityErr := s.WorkflowEntityRepository.UpdateWorkflowEntity(exist)
+	updatedWorkflowEntity, updatedWorkflowEntityErr := s.updateWorkflowEntity(exist)
 
 	if updatedWorkflowEntityErr != nil {
 		return nil, updatedWorkflowEntityErr
@@ -166,138 +233,179 @@ func (s *WorkflowEntityService) PatchWorkflowEntity(exist *automationsModels.Wor
 	return updatedWorkflowEntity, nil
 }
 
-func (s *WorkflowEntityService) DeleteWorkflowEntityById(id string) error {
-	err := s.WorkflowEntityRepository.DeleteWorkflowEntityById(id)
-	return err
+func (s *WorkflowEntityService) DeleteWorkflowEntityById(id string, hardDelete bool, userName string) error {
+	exist, err := s.WorkflowEntityRepository.SelectWorkflowEntityById(id, "all")
+
+	if err != nil {
+		return err
+	}
+
+	return s.deleteWorkflowEntity(exist, hardDelete, userName)
 }
 
-func (s *WorkflowEntityService) DeleteWorkflowEntityByFqn(fqn string) error {
-	err := s.WorkflowEntityRepository.DeleteWorkflowEntityByFqn(fqn)
-	return err
+func (s *WorkflowEntityService) DeleteWorkflowEntityByFqn(fqn string, hardDelete bool, userName string) error {
+	exist, err := s.WorkflowEntityRepository.SelectWorkflowEntityByFqn(fqn, "all")
+
+	if err != nil {
+		return err
+	}
+
+	return s.deleteWorkflowEntity(exist, hardDelete, userName)
+}
+
+func (s *WorkflowEntityService) deleteWorkflowEntity(exist *automationsModels.WorkflowEntity, hardDelete bool, userName string) error {
+	if hardDelete {
+		return s.Transactor.RunInTx(func(tx *sqlx.Tx) error {
+			if err := s.WorkflowEntityRepository.WithTx(tx).DeleteWorkflowEntityById(exist.ID); err != nil {
+				return err
+			}
+
+			exist.UpdatedAt = time.Now().Unix()
+			exist.UpdatedBy = userName
+			return s.recordChangeEvent(tx, eventsModels.EntityDeleted, exist, nil)
+		})
+	}
+
+	exist.Deleted = true
+	exist.Json.Deleted = true
+	exist.UpdatedAt = time.Now().Unix()
+	exist.UpdatedBy = userName
+
+	return s.Transactor.RunInTx(func(tx *sqlx.Tx) error {
+		workflowEntity, err := s.WorkflowEntityRepository.WithTx(tx).UpdateWorkflowEntity(exist)
+
+		if err != nil {
+			return err
+		}
+
+		return s.recordChangeEvent(tx, eventsModels.EntitySoftDeleted, workflowEntity, nil)
+	})
+}
+
+func (s *WorkflowEntityService) insertWorkflowEntity(entity *automationsModels.WorkflowEntity) (*automationsModels.WorkflowEntity, error) {
+	if err := s.encryptConnection(entity.Json, nil); err != nil {
+		return nil, err
+	}
+
+	var workflowEntity *automationsModels.WorkflowEntity
+
+	err := s.Transactor.RunInTx(func(tx *sqlx.Tx) error {
+		var err error
+		workflowEntity, err = s.WorkflowEntityRepository.WithTx(tx).InsertWorkflowEntity(entity)
+
+		if err != nil {
+			return err
+		}
+
+		return s.recordChangeEvent(tx, eventsModels.EntityCreated, workflowEntity, nil)
+	})
+
+	return workflowEntity, err
+}
+
+// updateWorkflowEntity saves the workflow when it differs from the stored one and records the changed fields
+func (s *WorkflowEntityService) updateWorkflowEntity(exist *automationsModels.WorkflowEntity) (*automationsModels.WorkflowEntity, error) {
+	var workflowEntity *automationsModels.WorkflowEntity
+
+	err := s.Transactor.RunInTx(func(tx *sqlx.Tx) error {
+		previous, err := s.WorkflowEntityRepository.WithTx(tx).SelectWorkflowEntityById(exist.ID, "all")
+
+		if err != nil {
+			return err
+		}
+
+		if err := s.encryptConnection(exist.Json, previous.Json); err != nil {
+			return err
+		}
+
+		changeDescription, err := baseUtils.CompareEntities(previous.Json, exist.Json)
+
+		if err != nil {
+			return err
+		}
+
+		if changeDescription.IsEmpty() {
+			workflowEntity = previous
+			return nil
+		}
+
+		exist.Json.ChangeDescription = changeDescription
+		workflowEntity, err = s.WorkflowEntityRepository.WithTx(tx).UpdateWorkflowEntity(exist)
+
+		if err != nil {
+			return err
+		}
+
+		return s.recordChangeEvent(tx, eventsModels.EntityUpdated, workflowEntity, changeDescription)
+	})
+
+	return workflowEntity, err
+}
+
+// DecryptWorkflowConnection replaces the stored secrets of the request connection with the plain ones, the result
+// must only be returned to the ingestion bots
+func (s *WorkflowEntityService) DecryptWorkflowConnection(workflow *automationsModels.Workflow) error {
+	if workflow.Request == nil || workflow.Request.Connection == nil {
+		return nil
+	}
+
+	return securityServices.DecryptConfig(s.SecretsManager, workflow.Request.Connection.Config)
 }
 
+// EncryptStoredSecrets encrypts the secrets of the workflows stored before the secrets manager was in place, and masks
+// the ones of their change events
+func (s *WorkflowEntityService) EncryptStoredSecrets() error {
+	workflowEntities, err := s.WorkflowEntityRepository.SelectWorkflowEntities("all", -1, nil, nil)
 
-/*
- *
- *	Test connection section
- *
- */ 
-// func TestConnection(testServiceConnection *automationsModels.TestServiceConnection) bool {
-// 	connection := testServiceConnection.Connection
-
-// 	idx, serviceTypeErr := servicesModels.ValidateServiceType(testServiceConnection.ConnectionType)
-
-// 	if serviceTypeErr != nil {
-// 		return false
-// 	}
-
-// 	if idx == 0 {
-// 		return TestPostgresConnection(connection.Config)
-// 	} else if idx == 1 {
-// 		return TestMysqlConnection(connection.Config)
-// 	} else {
-// 		return false
-// 	}
-// }
-
-// func TestPostgresConnection(conn interface{}) bool {
-// 	bytes, err := json.Marshal(conn)
-
-// 	if err != nil {
-// 		return false
-// 	}
-
-// 	var c *servicesModels.PostgresConnection = &servicesModels.PostgresConnection{}
-
-// 	if err := json.Unmarshal(bytes, c); err != nil {
-// 		return false
-// 	}
-
-// 	hostPort := strings.Split(c.HostPort, ":")
-
-// 	if len(hostPort) < 2 {
-// 		return false
-// 	}
-
-// 	host := hostPort[0]
-// 	port := hostPort[1]
-// 	user := c.Username
-// 	password := c.AuthType["password"]
-// 	dbName := c.Database
-
-// 	db, err := sql.Open(
-// 		"pgx",
-// 		fmt.Sprintf(
-// 			"postgres://%v:%v@%v:%v/%v",
-// 			user,
-// 			password,
-// 			host,
-// 			port,
-// 			dbName,
-// 		),
-// 	)
-
-//     if err != nil {
-//         return false
-//     }
-
-// 	pingErr := db.Ping()
-
-// 	if pingErr != nil {
-//         return false
-//     }
-
-// 	closeErr := db.Close()
-// 	return closeErr == nil
-// }
-
-// func TestMysqlConnection(conn interface{}) bool {
-// 	bytes, err := json.Marshal(conn)
-	
-// 	if err != nil {
-// 		return false
-// 	}
-
-// 	var c *servicesModels.MysqlConnection = &servicesModels.MysqlConnection{}
-
-// 	if err := json.Unmarshal(bytes, c); err != nil {
-// 		return false
-// 	}
-
-// 	hostPort := strings.Split(c.HostPort, ":")
-
-// 	if len(hostPort) < 2 {
-// 		return false
-// 	}
-
-// 	host := hostPort[0]
-// 	port := hostPort[1]
-// 	user := c.Username
-// 	password := c.AuthType["password"]
-// 	dbName := c.DatabaseName
-
-// 	db, err := sql.Open(
-// 		"mysql",
-// 		fmt.Sprintf(
-// 			"mysql://%v:%v@%v:%v/%v",
-// 			user,
-// 			password,
-// 			host,
-// 			port,
-// 			dbName,
-// 		),
-// 	)
-
-//     if err != nil {
-//         return false
-//     }
-
-// 	pingErr := db.Ping()
-
-// 	if pingErr != nil {
-//         return false
-//     } 
-
-// 	closeErr := db.Close()
-// 	return closeErr == nil
-// }
+	if err != nil {
+		return err
+	}
+
+	for _, e := range workflowEntities {
+		if e.Json.Request == nil || e.Json.Request.Connection == nil || !securityServices.HasPlainSecrets(s.SecretsManager, e.Json.Request.Connection.Config) {
+			continue
+		}
+
+		if err := s.encryptConnection(e.Json, nil); err != nil {
+			return err
+		}
+
+		if _, err := s.WorkflowEntityRepository.UpdateWorkflowEntity(&e); err != nil {
+			return err
+		}
+	}
+
+	return eventsServices.ScrubChangeEventSecrets(s.ChangeEventRepository, "workflow")
+}
+
+// encryptConnection encrypts the secrets of the request connection before it is stored, the secrets of previous are
+// reused when they did not change
+func (s *WorkflowEntityService) encryptConnection(workflow *automationsModels.Workflow, previous *automationsModels.Workflow) error {
+	if workflow.Request == nil || workflow.Request.Connection == nil {
+		return nil
+	}
+
+	var previousConfig map[string]interface{}
+
+	if previous != nil && previous.Request != nil && previous.Request.Connection != nil {
+		previousConfig = previous.Request.Connection.Config
+	}
+
+	return securityServices.EncryptConfig(s.SecretsManager, workflow.Request.Connection.Config, previousConfig)
+}
+
+// Workflows are not versioned, their events always carry version 0
+func (s *WorkflowEntityService) recordChangeEvent(tx *sqlx.Tx, eventType string, workflowEntity *automationsModels.WorkflowEntity, changeDescription *typeModels.ChangeDescription) error {
+	changeEvent := eventsModels.NewChangeEventEntity(
+		eventType,
+		workflowEntity.Json.ToEntityReference(),
+		0,
+		changeDescription,
+		workflowEntity.UpdatedBy,
+		workflowEntity.UpdatedAt,
+		workflowEntity.Json,
+	)
+
+	_, err := s.ChangeEventRepository.WithTx(tx).InsertChangeEvent(changeEvent)
+	return err
+}
@@ -0,0 +1,324 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	automationsModels "github.com/nambuitechx/go-metadata/models/automations"
+	automationsRepositories "github.com/nambuitechx/go-metadata/repositories/automations"
+)
+
+// A worker polls the queue when idle. A running run sends a heartbeat, a run without one for staleRunAfter is
+// considered lost with its server and recovered
+const (
+	pollInterval = time.Second
+	heartbeatInterval = 5 * time.Second
+	staleRunAfter = 3 * heartbeatInterval
+	recoveryInterval = 30 * time.Second
+	runRetryBaseDelay = time.Second
+	runRetryMaxDelay = time.Minute
+)
+
+// WorkflowExecutor runs the triggered workflows from the workflow_run queue with a pool of workers. A run is claimed
+// by one worker of one server, a failed or timed out attempt is queued again until its attempts are exhausted
+type WorkflowExecutor struct {
+	WorkflowRunRepository *automationsRepositories.WorkflowRunRepository
+	WorkflowEntityService *WorkflowEntityService
+	TestConnectionRunner *TestConnectionRunner
+	Workers int
+	Timeout int64
+	MaxAttempts int
+
+	mu sync.Mutex
+	cancels map[string]context.CancelCauseFunc
+}
+
+func NewWorkflowExecutor(
+	workflowRunRepository *automationsRepositories.WorkflowRunRepository,
+	workflowEntityService *WorkflowEntityService,
+	testConnectionRunner *TestConnectionRunner,
+	workers int,
+	timeout int64,
+	maxAttempts int,
+) *WorkflowExecutor {
+	return &WorkflowExecutor{
+		WorkflowRunRepository: workflowRunRepository,
+		WorkflowEntityService: workflowEntityService,
+		TestConnectionRunner: testConnectionRunner,
+		Workers: workers,
+		Timeout: timeout,
+		MaxAttempts: maxAttempts,
+		cancels: map[string]context.CancelCauseFunc{},
+	}
+}
+
+func (e *WorkflowExecutor) GetWorkflowRuns(workflowId string, limit int) ([]automationsModels.WorkflowRunEntity, error) {
+	return e.WorkflowRunRepository.SelectWorkflowRuns(workflowId, limit)
+}
+
+// EnqueueWorkflow queues a run of the workflow and moves it to Pending, timeout is in seconds and the executor
+// timeout is used when it is 0
+func (e *WorkflowExecutor) EnqueueWorkflow(exist *automationsModels.WorkflowEntity, timeout int64, userName string) (*automationsModels.WorkflowRunEntity, error) {
+	if err := e.TestConnectionRunner.ValidateWorkflow(exist); err != nil {
+		return nil, err
+	}
+
+	if timeout <= 0 {
+		timeout = e.Timeout
+	}
+
+	now := time.Now().Unix()
+	workflowRunEntity, err := e.WorkflowRunRepository.InsertWorkflowRun(&automationsModels.WorkflowRunEntity{
+		ID: uuid.NewString(),
+		WorkflowID: exist.ID,
+		Status: automationsModels.QueuedRun,
+		MaxAttempts: e.MaxAttempts,
+		Timeout: timeout,
+		RunAfter: now,
+		CreatedAt: now,
+		CreatedBy: userName,
+	})
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, automationsModels.ErrWorkflowRunning
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := e.updateWorkflowStatus(exist, automationsModels.PendingStatus, userName); err != nil {
+		log.Printf("Failed to update the status of workflow %v: %v\n", exist.ID, err)
+	}
+
+	return workflowRunEntity, nil
+}
+
+// CancelWorkflow cancels the queued run of the workflow, or asks the worker of its running run to stop it
+func (e *WorkflowExecutor) CancelWorkflow(exist *automationsModels.WorkflowEntity, userName string) (*automationsModels.WorkflowRunEntity, error) {
+	workflowRunEntity, err := e.WorkflowRunRepository.CancelWorkflowRun(exist.ID, time.Now().Unix())
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, automationsModels.ErrWorkflowNotRunning
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if workflowRunEntity.Status == automationsModels.CancelledRun {
+		if _, err := e.updateWorkflowStatus(exist, automationsModels.CancelledStatus, userName); err != nil {
+			log.Printf("Failed to update the status of workflow %v: %v\n", exist.ID, err)
+		}
+
+		return workflowRunEntity, nil
+	}
+
+	// A run of another server is cancelled by its next heartbeat
+	e.mu.Lock()
+	cancel, ok := e.cancels[workflowRunEntity.ID]
+	e.mu.Unlock()
+
+	if ok {
+		cancel(context.Canceled)
+	}
+
+	return workflowRunEntity, nil
+}
+
+func (e *WorkflowExecutor) Start() {
+	e.recoverRuns()
+
+	for i := 0; i < e.Workers; i++ {
+		go e.work()
+	}
+
+	go func() {
+		ticker := time.NewTicker(recoveryInterval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			e.recoverRuns()
+		}
+	}()
+}
+
+func (e *WorkflowExecutor) work() {
+	for {
+		workflowRunEntity, err := e.WorkflowRunRepository.ClaimWorkflowRun(time.Now().Unix())
+
+		if err != nil {
+			if !errors.Is(err, sql.ErrNoRows) {
+				log.Println("Failed to claim workflow run:", err)
+			}
+
+			time.Sleep(pollInterval)
+			continue
+		}
+
+		e.execute(workflowRunEntity)
+	}
+}
+
+func (e *WorkflowExecutor) execute(workflowRunEntity *automationsModels.WorkflowRunEntity) {
+	ctx, cancel := context.WithCancelCause(context.Background())
+	defer cancel(nil)
+
+	ctx, cancelTimeout := context.WithTimeout(ctx, time.Duration(workflowRunEntity.Timeout) * time.Second)
+	defer cancelTimeout()
+
+	e.mu.Lock()
+	e.cancels[workflowRunEntity.ID] = cancel
+	e.mu.Unlock()
+
+	defer func() {
+		e.mu.Lock()
+		delete(e.cancels, workflowRunEntity.ID)
+		e.mu.Unlock()
+	}()
+
+	done := make(chan struct{})
+	defer close(done)
+	go e.heartbeat(workflowRunEntity.ID, cancel, done)
+
+	workflowEntity, err := e.WorkflowEntityService.GetWorkflowEntityById(workflowRunEntity.WorkflowID, "non-deleted")
+
+	if err != nil {
+		e.finish(workflowRunEntity, nil, automationsModels.FailedRun, err)
+		return
+	}
+
+	result, err := e.TestConnectionRunner.RunWorkflow(ctx, workflowEntity, workflowRunEntity.CreatedBy)
+
+	if err == nil || errors.Is(context.Cause(ctx), automationsModels.ErrWorkflowRunLost) {
+		err = context.Cause(ctx)
+	}
+
+	switch {
+	case errors.Is(err, automationsModels.ErrWorkflowRunLost):
+		log.Printf("Workflow run %v was recovered by another server, stopped it\n", workflowRunEntity.ID)
+	case errors.Is(err, context.Canceled):
+		if result == nil {
+			result = workflowEntity
+		}
+
+		e.finish(workflowRunEntity, result, automationsModels.CancelledRun, err)
+	case err != nil && workflowRunEntity.Attempt < workflowRunEntity.MaxAttempts:
+		e.retry(workflowRunEntity, workflowEntity, err)
+	case err != nil:
+		e.finish(workflowRunEntity, workflowEntity, automationsModels.FailedRun, err)
+	default:
+		e.finish(workflowRunEntity, result, result.Status, nil)
+	}
+}
+
+// heartbeat keeps the run claimed until done and cancels it when its cancellation was requested, or when the run was
+// recovered by another server after missing its heartbeats
+func (e *WorkflowExecutor) heartbeat(id string, cancel context.CancelCauseFunc, done <-chan struct{}) {
+	ticker := time.NewTicker(heartbeatInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			cancelRequested, err := e.WorkflowRunRepository.HeartbeatWorkflowRun(id, time.Now().Unix())
+
+			if errors.Is(err, sql.ErrNoRows) {
+				cancel(automationsModels.ErrWorkflowRunLost)
+				return
+			}
+
+			if err != nil {
+				log.Printf("Failed to send heartbeat of workflow run %v: %v\n", id, err)
+				continue
+			}
+
+			if cancelRequested {
+				cancel(context.Canceled)
+			}
+		}
+	}
+}
+
+// finish stores the final status of the run, and of the workflow unless it already holds the result of the run
+func (e *WorkflowExecutor) finish(workflowRunEntity *automationsModels.WorkflowRunEntity, workflowEntity *automationsModels.WorkflowEntity, status string, runErr error) {
+	message := ""
+
+	if runErr != nil {
+		message = runErr.Error()
+	}
+
+	if err := e.WorkflowRunRepository.FinishWorkflowRun(workflowRunEntity.ID, status, message, time.Now().Unix()); err != nil {
+		log.Printf("Failed to finish workflow run %v: %v\n", workflowRunEntity.ID, err)
+	}
+
+	if workflowEntity == nil || workflowEntity.Status == status {
+		return
+	}
+
+	if _, err := e.updateWorkflowStatus(workflowEntity, status, workflowRunEntity.CreatedBy); err != nil {
+		log.Printf("Failed to update the status of workflow %v: %v\n", workflowEntity.ID, err)
+	}
+}
+
+func (e *WorkflowExecutor) retry(workflowRunEntity *automationsModels.WorkflowRunEntity, workflowEntity *automationsModels.WorkflowEntity, runErr error) {
+	runAfter := time.Now().Add(runRetryDelay(workflowRunEntity.Attempt)).Unix()
+
+	if err := e.WorkflowRunRepository.RequeueWorkflowRun(workflowRunEntity.ID, runErr.Error(), runAfter); err != nil {
+		log.Printf("Failed to requeue workflow run %v: %v\n", workflowRunEntity.ID, err)
+	}
+
+	if _, err := e.updateWorkflowStatus(workflowEntity, automationsModels.PendingStatus, workflowRunEntity.CreatedBy); err != nil {
+		log.Printf("Failed to update the status of workflow %v: %v\n", workflowEntity.ID, err)
+	}
+}
+
+// recoverRuns releases the runs left Running by a server that stopped, and moves their workflows to the recovered status
+func (e *WorkflowExecutor) recoverRuns() {
+	now := time.Now()
+	workflowRunEntities, err := e.WorkflowRunRepository.RecoverWorkflowRuns(now.Add(-staleRunAfter).Unix(), now.Unix())
+
+	if err != nil {
+		log.Println("Failed to recover workflow runs:", err)
+		return
+	}
+
+	for _, workflowRunEntity := range workflowRunEntities {
+		status := workflowRunEntity.Status
+
+		if status == automationsModels.QueuedRun {
+			status = automationsModels.PendingStatus
+		}
+
+		workflowEntity, err := e.WorkflowEntityService.GetWorkflowEntityById(workflowRunEntity.WorkflowID, "non-deleted")
+
+		if err != nil {
+			continue
+		}
+
+		if _, err := e.updateWorkflowStatus(workflowEntity, status, workflowRunEntity.CreatedBy); err != nil {
+			log.Printf("Failed to update the status of workflow %v: %v\n", workflowEntity.ID, err)
+		}
+	}
+}
+
+func (e *WorkflowExecutor) updateWorkflowStatus(exist *automationsModels.WorkflowEntity, status string, userName string) (*automationsModels.WorkflowEntity, error) {
+	return e.WorkflowEntityService.UpdateWorkflowStatus(exist, status, nil, userName)
+}
+
+func runRetryDelay(attempt int) time.Duration {
+	delay := runRetryBaseDelay << (attempt - 1)
+
+	if delay <= 0 || delay > runRetryMaxDelay {
+		return runRetryMaxDelay
+	}
+
+	return delay
+}
@@ -0,0 +1,30 @@
+package services
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestRunRetryDelay(t *testing.T) {
+	tests := []struct {
+		attempt int
+		expected time.Duration
+	}{
+		{ attempt: 1, expected: time.Second },
+		{ attempt: 2, expected: 2 * time.Second },
+		{ attempt: 3, expected: 4 * time.Second },
+		{ attempt: 6, expected: 32 * time.Second },
+		{ attempt: 7, expected: time.Minute },
+		{ attempt: 64, expected: time.Minute },
+		{ attempt: 100, expected: time.Minute },
+	}
+
+	for _, test := range tests {
+		t.Run(strconv.Itoa(test.attempt), func(t *testing.T) {
+			if delay := runRetryDelay(test.attempt); delay != test.expected {
+				t.Errorf("runRetryDelay(%v) = %v, expected %v", test.attempt, delay, test.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,381 @@
+package services
+
+import (
+	"github.com/jmoiron/sqlx"
+	dataModels "github.com/nambuitechx/go-metadata/models/data"
+	dataRepositories "github.com/nambuitechx/go-metadata/repositories/data"
+	typeModels "github.com/nambuitechx/go-metadata/models/type"
+	typeRepositories "github.com/nambuitechx/go-metadata/repositories/type"
+	eventsModels "github.com/nambuitechx/go-metadata/models/events"
+	eventsRepositories "github.com/nambuitechx/go-metadata/repositories/events"
+	searchServices "github.com/nambuitechx/go-metadata/services/search"
+)
+
+// Cascade follows the deletion and the restoration of a dbservice, database or database schema to its children.
+// Every child is updated on its own like the entity itself: its previous version is stored, its version is bumped
+// and a change event is recorded. The children deleted along with a parent are recorded, restoring the parent
+// restores them only
+type Cascade struct {
+	DatabaseEntityRepository *dataRepositories.DatabaseEntityRepository
+	DatabaseSchemaEntityRepository *dataRepositories.DatabaseSchemaEntityRepository
+	TableEntityRepository *dataRepositories.TableEntityRepository
+	StoredProcedureEntityRepository *dataRepositories.StoredProcedureEntityRepository
+	CascadeDeletionRepository *typeRepositories.CascadeDeletionRepository
+	Versioning *Versioning
+}
+
+func NewCascade(
+	databaseEntityRepository *dataRepositories.DatabaseEntityRepository,
+	databaseSchemaEntityRepository *dataRepositories.DatabaseSchemaEntityRepository,
+	tableEntityRepository *dataRepositories.TableEntityRepository,
+	storedProcedureEntityRepository *dataRepositories.StoredProcedureEntityRepository,
+	entityExtensionRepository *typeRepositories.EntityExtensionRepository,
+	cascadeDeletionRepository *typeRepositories.CascadeDeletionRepository,
+	changeEventRepository *eventsRepositories.ChangeEventRepository,
+) *Cascade {
+	return &Cascade{
+		DatabaseEntityRepository: databaseEntityRepository,
+		DatabaseSchemaEntityRepository: databaseSchemaEntityRepository,
+		TableEntityRepository: tableEntityRepository,
+		StoredProcedureEntityRepository: storedProcedureEntityRepository,
+		CascadeDeletionRepository: cascadeDeletionRepository,
+		Versioning: NewVersioning(entityExtensionRepository, changeEventRepository),
+	}
+}
+
+// CascadedEntity is a child changed by a cascade, it is indexed once the transaction is committed
+type CascadedEntity struct {
+	EntityType string
+	ID string
+	Version float64
+	Json interface{}
+}
+
+// IndexCascadedEntities writes the children changed by a cascade to the index
+func IndexCascadedEntities(indexer searchServices.SearchIndexer, cascadedEntities []*CascadedEntity) {
+	for _, e := range cascadedEntities {
+		searchServices.IndexEntity(indexer, e.EntityType, e.ID, e.Json)
+	}
+}
+
+// SoftDelete soft deletes the children of prefix which are not deleted yet and records them as deleted along with
+// the parent
+func (c *Cascade) SoftDelete(tx *sqlx.Tx, parentId string, prefix string, now int64, userName string) ([]*CascadedEntity, error) {
+	cascadedEntities, err := c.setDeleted(tx, prefix, true, notDeleted, now, userName)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, e := range cascadedEntities {
+		if err := c.CascadeDeletionRepository.WithTx(tx).InsertCascadeDeletion(e.ID, parentId, e.Version); err != nil {
+			return nil, err
+		}
+	}
+
+	return cascadedEntities, nil
+}
+
+// Restore restores the children recorded as deleted along with the parent and not changed since. Children deleted on
+// their own stay deleted
+func (c *Cascade) Restore(tx *sqlx.Tx, parentId string, prefix string, now int64, userName string) ([]*CascadedEntity, error) {
+	cascadeDeletions, err := c.CascadeDeletionRepository.WithTx(tx).SelectCascadeDeletions(parentId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	cascadedEntities, err := c.setDeleted(tx, prefix, false, deletedWith(cascadeDeletions), now, userName)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.CascadeDeletionRepository.WithTx(tx).DeleteCascadeDeletions(parentId); err != nil {
+		return nil, err
+	}
+
+	return cascadedEntities, nil
+}
+
+// RecordHardDelete records the deletion of every child of prefix, before the children are deleted
+func (c *Cascade) RecordHardDelete(tx *sqlx.Tx, parentId string, prefix string, now int64, userName string) error {
+	for _, accessor := range c.accessors() {
+		children, err := accessor.selectByPrefix(tx, prefix)
+
+		if err != nil {
+			return err
+		}
+
+		for _, child := range children {
+			if err := c.Versioning.RecordChangeEvent(tx, eventsModels.EntityDeleted, child.entity, nil, userName, now); err != nil {
+				return err
+			}
+		}
+	}
+
+	return c.CascadeDeletionRepository.WithTx(tx).DeleteCascadeDeletions(parentId)
+}
+
+// cascadeChild is a child read for a cascade, whatever its entity type
+type cascadeChild struct {
+	id string
+	deleted bool
+	version float64
+	entity VersionedEntity
+	// setDeleted changes the deleted state of the entity, it returns a copy of the entity as it was
+	setDeleted func(deleted bool, now int64, userName string) VersionedEntity
+	// store writes the entity with its new version, it returns the stored entity
+	store func(tx *sqlx.Tx, previousVersion float64) (*cascadeChild, error)
+}
+
+// cascadeAccessor reads the children of one entity type
+type cascadeAccessor struct {
+	entityType string
+	selectByPrefix func(tx *sqlx.Tx, prefix string) ([]*cascadeChild, error)
+}
+
+// accessors lists the entity types of the children, parents first
+func (c *Cascade) accessors() []*cascadeAccessor {
+	return []*cascadeAccessor{
+		{ entityType: "database", selectByPrefix: c.selectDatabaseChildren },
+		{ entityType: "databaseSchema", selectByPrefix: c.selectDatabaseSchemaChildren },
+		{ entityType: "table", selectByPrefix: c.selectTableChildren },
+		{ entityType: "storedProcedure", selectByPrefix: c.selectStoredProcedureChildren },
+	}
+}
+
+// cascadeMatch tells whether a child is changed by the cascade
+type cascadeMatch func(child *cascadeChild) bool
+
+func notDeleted(child *cascadeChild) bool {
+	return !child.deleted
+}
+
+// deletedWith matches the deleted children recorded with the parent, as long as they kept the recorded version
+func deletedWith(cascadeDeletions []typeModels.CascadeDeletion) cascadeMatch {
+	versions := map[string]float64{}
+
+	for _, e := range cascadeDeletions {
+		versions[e.ID] = e.Version
+	}
+
+	return func(child *cascadeChild) bool {
+		version, ok := versions[child.id]
+		return child.deleted && ok && child.version == version
+	}
+}
+
+// setDeleted sets the deleted state of the matching children of prefix
+func (c *Cascade) setDeleted(tx *sqlx.Tx, prefix string, deleted bool, match cascadeMatch, now int64, userName string) ([]*CascadedEntity, error) {
+	eventType := eventsModels.EntitySoftDeleted
+
+	if !deleted {
+		eventType = eventsModels.EntityUpdated
+	}
+
+	cascadedEntities := []*CascadedEntity{}
+
+	for _, accessor := range c.accessors() {
+		children, err := accessor.selectByPrefix(tx, prefix)
+
+		if err != nil {
+			return nil, err
+		}
+
+		for _, child := range children {
+			if !match(child) {
+				continue
+			}
+
+			previous := child.setDeleted(deleted, now, userName)
+			changeDescription, err := c.Versioning.NextVersion(tx, accessor.entityType, child.id, previous, child.entity, now, userName)
+
+			if err != nil {
+				return nil, err
+			}
+
+			stored, err := child.store(tx, child.version)
+
+			if err != nil {
+				return nil, err
+			}
+
+			if err := c.Versioning.RecordChangeEvent(tx, eventType, stored.entity, changeDescription, userName, now); err != nil {
+				return nil, err
+			}
+
+			cascadedEntities = append(cascadedEntities, &CascadedEntity{ EntityType: accessor.entityType, ID: stored.id, Version: stored.version, Json: stored.entity })
+		}
+	}
+
+	return cascadedEntities, nil
+}
+
+func (c *Cascade) selectDatabaseChildren(tx *sqlx.Tx, prefix string) ([]*cascadeChild, error) {
+	databaseEntities, err := c.DatabaseEntityRepository.WithTx(tx).SelectDatabaseEntitiesByPrefix(prefix)
+
+	if err != nil {
+		return nil, err
+	}
+
+	children := []*cascadeChild{}
+
+	for i := range databaseEntities {
+		children = append(children, c.databaseChild(&databaseEntities[i]))
+	}
+
+	return children, nil
+}
+
+func (c *Cascade) databaseChild(e *dataModels.DatabaseEntity) *cascadeChild {
+	return &cascadeChild{
+		id: e.ID,
+		deleted: e.Deleted,
+		version: e.Json.Version,
+		entity: e.Json,
+		setDeleted: func(deleted bool, now int64, userName string) VersionedEntity {
+			previous := *e.Json
+			e.Deleted = deleted
+			e.Json.Deleted = deleted
+			e.UpdatedAt = now
+			e.UpdatedBy = userName
+			return &previous
+		},
+		store: func(tx *sqlx.Tx, previousVersion float64) (*cascadeChild, error) {
+			databaseEntity, err := c.DatabaseEntityRepository.WithTx(tx).UpdateDatabaseEntity(e, previousVersion)
+
+			if err != nil {
+				return nil, err
+			}
+
+			return c.databaseChild(databaseEntity), nil
+		},
+	}
+}
+
+func (c *Cascade) selectDatabaseSchemaChildren(tx *sqlx.Tx, prefix string) ([]*cascadeChild, error) {
+	databaseSchemaEntities, err := c.DatabaseSchemaEntityRepository.WithTx(tx).SelectDatabaseSchemaEntitiesByPrefix(prefix)
+
+	if err != nil {
+		return nil, err
+	}
+
+	children := []*cascadeChild{}
+
+	for i := range databaseSchemaEntities {
+		children = append(children, c.databaseSchemaChild(&databaseSchemaEntities[i]))
+	}
+
+	return children, nil
+}
+
+func (c *Cascade) databaseSchemaChild(e *dataModels.DatabaseSchemaEntity) *cascadeChild {
+	return &cascadeChild{
+		id: e.ID,
+		deleted: e.Deleted,
+		version: e.Json.Version,
+		entity: e.Json,
+		setDeleted: func(deleted bool, now int64, userName string) VersionedEntity {
+			previous := *e.Json
+			e.Deleted = deleted
+			e.Json.Deleted = deleted
+			e.UpdatedAt = now
+			e.UpdatedBy = userName
+			return &previous
+		},
+		store: func(tx *sqlx.Tx, previousVersion float64) (*cascadeChild, error) {
+			databaseSchemaEntity, err := c.DatabaseSchemaEntityRepository.WithTx(tx).UpdateDatabaseSchemaEntity(e, previousVersion)
+
+			if err != nil {
+				return nil, err
+			}
+
+			return c.databaseSchemaChild(databaseSchemaEntity), nil
+		},
+	}
+}
+
+func (c *Cascade) selectTableChildren(tx *sqlx.Tx, prefix string) ([]*cascadeChild, error) {
+	tableEntities, err := c.TableEntityRepository.WithTx(tx).SelectTableEntitiesByPrefix(prefix)
+
+	if err != nil {
+		return nil, err
+	}
+
+	children := []*cascadeChild{}
+
+	for i := range tableEntities {
+		children = append(children, c.tableChild(&tableEntities[i]))
+	}
+
+	return children, nil
+}
+
+func (c *Cascade) tableChild(e *dataModels.TableEntity) *cascadeChild {
+	return &cascadeChild{
+		id: e.ID,
+		deleted: e.Deleted,
+		version: e.Json.Version,
+		entity: e.Json,
+		setDeleted: func(deleted bool, now int64, userName string) VersionedEntity {
+			previous := *e.Json
+			e.Deleted = deleted
+			e.Json.Deleted = deleted
+			e.UpdatedAt = now
+			e.UpdatedBy = userName
+			return &previous
+		},
+		store: func(tx *sqlx.Tx, previousVersion float64) (*cascadeChild, error) {
+			tableEntity, err := c.TableEntityRepository.WithTx(tx).UpdateTableEntity(e, previousVersion)
+
+			if err != nil {
+				return nil, err
+			}
+
+			return c.tableChild(tableEntity), nil
+		},
+	}
+}
+
+func (c *Cascade) selectStoredProcedureChildren(tx *sqlx.Tx, prefix string) ([]*cascadeChild, error) {
+	storedProcedureEntities, err := c.StoredProcedureEntityRepository.WithTx(tx).SelectStoredProcedureEntitiesByPrefix(prefix)
+
+	if err != nil {
+		return nil, err
+	}
+
+	children := []*cascadeChild{}
+
+	for i := range storedProcedureEntities {
+		children = append(children, c.storedProcedureChild(&storedProcedureEntities[i]))
+	}
+
+	return children, nil
+}
+
+func (c *Cascade) storedProcedureChild(e *dataModels.StoredProcedureEntity) *cascadeChild {
+	return &cascadeChild{
+		id: e.ID,
+		deleted: e.Deleted,
+		version: e.Json.Version,
+		entity: e.Json,
+		setDeleted: func(deleted bool, now int64, userName string) VersionedEntity {
+			previous := *e.Json
+			e.Deleted = deleted
+			e.Json.Deleted = deleted
+			e.UpdatedAt = now
+			e.UpdatedBy = userName
+			return &previous
+		},
+		store: func(tx *sqlx.Tx, previousVersion float64) (*cascadeChild, error) {
+			storedProcedureEntity, err := c.StoredProcedureEntityRepository.WithTx(tx).UpdateStoredProcedureEntity(e, previousVersion)
+
+			if err != nil {
+				return nil, err
+			}
+
+			return c.storedProcedureChild(storedProcedureEntity), nil
+		},
+	}
+}
@@ -0,0 +1,66 @@
+package services
+
+import (
+	"testing"
+
+	typeModels "github.com/nambuitechx/go-metadata/models/type"
+)
+
+func TestCascadeMatch(t *testing.T) {
+	cascadeDeletions := []typeModels.CascadeDeletion{
+		{ ID: "orders", ParentID: "shop", Version: 0.2 },
+		{ ID: "customers", ParentID: "shop", Version: 0.3 },
+	}
+
+	tests := []struct {
+		name string
+		child *cascadeChild
+		softDelete bool
+		restore bool
+	}{
+		{
+			name: "deleted with the parent",
+			child: &cascadeChild{ id: "orders", deleted: true, version: 0.2 },
+			softDelete: false,
+			restore: true,
+		},
+		{
+			name: "changed after the deletion",
+			child: &cascadeChild{ id: "customers", deleted: true, version: 0.4 },
+			softDelete: false,
+			restore: false,
+		},
+		{
+			name: "deleted on its own",
+			child: &cascadeChild{ id: "payments", deleted: true, version: 0.2 },
+			softDelete: false,
+			restore: false,
+		},
+		{
+			name: "restored on its own",
+			child: &cascadeChild{ id: "orders", deleted: false, version: 0.2 },
+			softDelete: true,
+			restore: false,
+		},
+		{
+			name: "not deleted",
+			child: &cascadeChild{ id: "payments", deleted: false, version: 0.1 },
+			softDelete: true,
+			restore: false,
+		},
+	}
+
+	restore := deletedWith(cascadeDeletions)
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if matched := notDeleted(test.child); matched != test.softDelete {
+				t.Errorf("soft delete match = %v, expected %v", matched, test.softDelete)
+			}
+
+			if matched := restore(test.child); matched != test.restore {
+				t.Errorf("restore match = %v, expected %v", matched, test.restore)
+			}
+		})
+	}
+}
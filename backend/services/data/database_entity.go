@@ -1,28 +1,68 @@
 package services
 
 import (
+	"database/sql"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
 	baseModels "github.com/nambuitechx/go-metadata/models/base"
 	dataModels "github.com/nambuitechx/go-metadata/models/data"
 	servicesRepositories "github.com/nambuitechx/go-metadata/repositories/services"
 	dataRepositories "github.com/nambuitechx/go-metadata/repositories/data"
+	baseRepositories "github.com/nambuitechx/go-metadata/repositories/base"
+	typeModels "github.com/nambuitechx/go-metadata/models/type"
+	typeRepositories "github.com/nambuitechx/go-metadata/repositories/type"
+	eventsModels "github.com/nambuitechx/go-metadata/models/events"
+	eventsRepositories "github.com/nambuitechx/go-metadata/repositories/events"
+	searchServices "github.com/nambuitechx/go-metadata/services/search"
+	teamsServices "github.com/nambuitechx/go-metadata/services/teams"
+	baseUtils "github.com/nambuitechx/go-metadata/utils"
 )
 
 type DatabaseEntityService struct {
+	Transactor *baseRepositories.Transactor
 	DBServiceEntityRepository *servicesRepositories.DBServiceEntityRepository
 	DatabaseEntityRepository *dataRepositories.DatabaseEntityRepository
+	DatabaseSchemaEntityRepository *dataRepositories.DatabaseSchemaEntityRepository
+	TableEntityRepository *dataRepositories.TableEntityRepository
+	StoredProcedureEntityRepository *dataRepositories.StoredProcedureEntityRepository
+	EntityExtensionRepository *typeRepositories.EntityExtensionRepository
+	ChangeEventRepository *eventsRepositories.ChangeEventRepository
+	Versioning *Versioning
+	SearchIndexer searchServices.SearchIndexer
+	OwnershipService *teamsServices.OwnershipService
+	Cascade *Cascade
 }
 
 func NewDatabaseEntityService(
+	transactor *baseRepositories.Transactor,
 	dbserviceEntityRepository *servicesRepositories.DBServiceEntityRepository,
 	databaseEntityRepository *dataRepositories.DatabaseEntityRepository,
+	databaseSchemaEntityRepository *dataRepositories.DatabaseSchemaEntityRepository,
+	tableEntityRepository *dataRepositories.TableEntityRepository,
+	storedProcedureEntityRepository *dataRepositories.StoredProcedureEntityRepository,
+	entityExtensionRepository *typeRepositories.EntityExtensionRepository,
+	cascadeDeletionRepository *typeRepositories.CascadeDeletionRepository,
+	changeEventRepository *eventsRepositories.ChangeEventRepository,
+	searchIndexer searchServices.SearchIndexer,
+	ownershipService *teamsServices.OwnershipService,
 ) *DatabaseEntityService {
 	return &DatabaseEntityService{
+		Transactor: transactor,
 		DBServiceEntityRepository: dbserviceEntityRepository,
 		DatabaseEntityRepository: databaseEntityRepository,
+		DatabaseSchemaEntityRepository: databaseSchemaEntityRepository,
+		TableEntityRepository: tableEntityRepository,
+		StoredProcedureEntityRepository: storedProcedureEntityRepository,
+		EntityExtensionRepository: entityExtensionRepository,
+		ChangeEventRepository: changeEventRepository,
+		Versioning: NewVersioning(entityExtensionRepository, changeEventRepository),
+		SearchIndexer: searchIndexer,
+		OwnershipService: ownershipService,
+		Cascade: NewCascade(databaseEntityRepository, databaseSchemaEntityRepository, tableEntityRepository, storedProcedureEntityRepository, entityExtensionRepository, cascadeDeletionRepository, changeEventRepository),
 	}
 }
 
@@ -30,32 +70,63 @@ func (s *DatabaseEntityService) Health() string {
 	return "Database service is available"
 }
 
-func (s *DatabaseEntityService) GetAllDatabaseEntities(service string, limit int, offset int) ([]dataModels.DatabaseEntity, error) {
-	databaseEntity, err := s.DatabaseEntityRepository.SelectDatabaseEntities(service, limit, offset)
-	return databaseEntity, err
+func (s *DatabaseEntityService) GetAllDatabaseEntities(service string, include string, limit int, before *baseModels.Cursor, after *baseModels.Cursor) ([]dataModels.DatabaseEntity, *baseModels.Paging, error) {
+	databaseEntity, err := s.DatabaseEntityRepository.SelectDatabaseEntities(service, include, limit, before, after)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for i := range databaseEntity {
+		if err := s.inheritOwners(&databaseEntity[i]); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	databaseEntity, paging := baseModels.NewPaging(databaseEntity, limit, before, after, func(e dataModels.DatabaseEntity) *baseModels.Cursor {
+		return &baseModels.Cursor{ Name: e.Json.FullyQualifiedName, ID: e.ID }
+	})
+
+	return databaseEntity, paging, nil
 }
 
-func (s *DatabaseEntityService) GetCountDatabaseEntities(service string) (*baseModels.EntityTotal, error) {
-	entityTotal, err := s.DatabaseEntityRepository.SelectCountDatabaseEntities(service)
+func (s *DatabaseEntityService) GetCountDatabaseEntities(service string, include string) (*baseModels.EntityTotal, error) {
+	entityTotal, err := s.DatabaseEntityRepository.SelectCountDatabaseEntities(service, include)
 	return entityTotal, err
 }
 
-func (s *DatabaseEntityService) GetDatabaseEntityById(id string) (*dataModels.DatabaseEntity, error) {
-	databaseEntity, err := s.DatabaseEntityRepository.SelectDatabaseEntityById(id)
-	return databaseEntity, err
+func (s *DatabaseEntityService) GetDatabaseEntityById(id string, include string) (*dataModels.DatabaseEntity, error) {
+	databaseEntity, err := s.DatabaseEntityRepository.SelectDatabaseEntityById(id, include)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return databaseEntity, s.inheritOwners(databaseEntity)
 }
 
-func (s *DatabaseEntityService) GetDatabaseEntityByFqn(fqn string) (*dataModels.DatabaseEntity, error) {
-	databaseEntity, err := s.DatabaseEntityRepository.SelectDatabaseEntityByFqn(fqn)
-	return databaseEntity, err
+func (s *DatabaseEntityService) GetDatabaseEntityByFqn(fqn string, include string) (*dataModels.DatabaseEntity, error) {
+	databaseEntity, err := s.DatabaseEntityRepository.SelectDatabaseEntityByFqn(fqn, include)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return databaseEntity, s.inheritOwners(databaseEntity)
 }
 
-func (s *DatabaseEntityService) CreateDatabaseEntity(payload *dataModels.CreateDatabaseEntityPayload) (*dataModels.DatabaseEntity, error) {
+func (s *DatabaseEntityService) CreateDatabaseEntity(payload *dataModels.CreateDatabaseEntityPayload, userName string) (*dataModels.DatabaseEntity, error) {
+	owners, err := s.OwnershipService.ResolveOwners(payload.Owners)
+
+	if err != nil {
+		return nil, err
+	}
+
 	id := uuid.NewString()
 	now := time.Now().Unix()
 
 	// Get dbservice
-	dbservice, err := s.DBServiceEntityRepository.SelectDBServiceEntityByFqn(payload.Service)
+	dbservice, err := s.DBServiceEntityRepository.SelectDBServiceEntityByFqn(payload.Service, "non-deleted")
 
 	if err != nil {
 		return nil, err
@@ -72,6 +143,10 @@ func (s *DatabaseEntityService) CreateDatabaseEntity(payload *dataModels.CreateD
 		Description: payload.Description,
 		ServiceType: dbservice.ServiceType,
 		Service: dbserviceEntityRef,
+		Owners: owners,
+		Version: typeModels.InitialVersion,
+		UpdatedAt: now,
+		UpdatedBy: userName,
 		Deleted: false,
 	}
 
@@ -80,18 +155,54 @@ func (s *DatabaseEntityService) CreateDatabaseEntity(payload *dataModels.CreateD
 		Name: payload.Name,
 		Json: database,
 		UpdatedAt: now,
+		UpdatedBy: userName,
 		Deleted: false,
 	}
 
-	databaseEntity, err := s.DatabaseEntityRepository.InsertDatabaseEntity(entity)
+	databaseEntity, err := s.insertDatabaseEntity(entity)
 	return databaseEntity, err
 }
 
-func (s *DatabaseEntityService) CreateOrUpdateDatabaseEntity(payload *dataModels.CreateDatabaseEntityPayload) (*dataModels.DatabaseEntity, error) {
-	exist, err := s.DatabaseEntityRepository.SelectDatabaseEntityByFqn(fmt.Sprintf("%v.%v", payload.Service, payload.Name))
+func (s *DatabaseEntityService) CreateOrUpdateDatabaseEntity(payload *dataModels.CreateDatabaseEntityPayload, ifMatch string, userName string) (*dataModels.DatabaseEntity, error) {
+	owners, err := s.OwnershipService.ResolveOwners(payload.Owners)
+
+	if err != nil {
+		return nil, err
+	}
+
+	exist, err := s.DatabaseEntityRepository.SelectDatabaseEntityByFqn(fmt.Sprintf("%v.%v", payload.Service, payload.Name), "all")
 
 	if err == nil {
-		updated, err := s.DatabaseEntityRepository.UpdateDatabaseEntity(exist)
+		if exist.Deleted {
+			return nil, baseModels.ErrEntityDeleted
+		}
+
+		if err := baseModels.CheckIfMatch(ifMatch, exist.Json.Version); err != nil {
+			return nil, err
+		}
+
+		exist.Json.DisplayName = baseUtils.MergeString(exist.Json.DisplayName, payload.DisplayName)
+		exist.Json.Description = baseUtils.MergeString(exist.Json.Description, payload.Description)
+
+		if payload.Owners != nil {
+			exist.Json.Owners = owners
+		}
+
+		exist.UpdatedAt = time.Now().Unix()
+		exist.UpdatedBy = userName
+
+		var updated *dataModels.DatabaseEntity
+
+		err = s.Transactor.RunInTx(func(tx *sqlx.Tx) error {
+			var err error
+			updated, err = s.updateDatabaseEntity(tx, exist, eventsModels.EntityUpdated)
+			return err
+		})
+
+		if err == nil {
+			searchServices.IndexEntity(s.SearchIndexer, "database", updated.ID, updated.Json)
+		}
+
 		return updated, err
 	}
 
@@ -99,7 +210,7 @@ func (s *DatabaseEntityService) CreateOrUpdateDatabaseEntity(payload *dataModels
 	now := time.Now().Unix()
 
 	// Get dbservice
-	dbservice, err := s.DBServiceEntityRepository.SelectDBServiceEntityByFqn(payload.Service)
+	dbservice, err := s.DBServiceEntityRepository.SelectDBServiceEntityByFqn(payload.Service, "non-deleted")
 
 	if err != nil {
 		return nil, err
@@ -116,6 +227,10 @@ func (s *DatabaseEntityService) CreateOrUpdateDatabaseEntity(payload *dataModels
 		Description: payload.Description,
 		ServiceType: dbservice.ServiceType,
 		Service: dbserviceEntityRef,
+		Owners: owners,
+		Version: typeModels.InitialVersion,
+		UpdatedAt: now,
+		UpdatedBy: userName,
 		Deleted: false,
 	}
 
@@ -124,19 +239,321 @@ func (s *DatabaseEntityService) CreateOrUpdateDatabaseEntity(payload *dataModels
 		Name: payload.Name,
 		Json: database,
 		UpdatedAt: now,
+		UpdatedBy: userName,
 		Deleted: false,
 	}
 
-	databaseEntity, err := s.DatabaseEntityRepository.InsertDatabaseEntity(entity)
+	databaseEntity, err := s.insertDatabaseEntity(entity)
 	return databaseEntity, err
 }
 
-func (s *DatabaseEntityService) DeleteDatabaseEntityById(id string) error {
-	err := s.DatabaseEntityRepository.DeleteDatabaseEntityById(id)
-	return err
+func (s *DatabaseEntityService) DeleteDatabaseEntityById(id string, hardDelete bool, recursive bool, userName string) error {
+	exist, err := s.DatabaseEntityRepository.SelectDatabaseEntityById(id, "all")
+
+	if err != nil {
+		return err
+	}
+
+	return s.deleteDatabaseEntity(exist, hardDelete, recursive, userName)
+}
+
+func (s *DatabaseEntityService) DeleteDatabaseEntityByFqn(fqn string, hardDelete bool, recursive bool, userName string) error {
+	exist, err := s.DatabaseEntityRepository.SelectDatabaseEntityByFqn(fqn, "all")
+
+	if err != nil {
+		return err
+	}
+
+	return s.deleteDatabaseEntity(exist, hardDelete, recursive, userName)
+}
+
+func (s *DatabaseEntityService) deleteDatabaseEntity(exist *dataModels.DatabaseEntity, hardDelete bool, recursive bool, userName string) error {
+	fqn := exist.Json.FullyQualifiedName
+
+	if !recursive {
+		databaseSchemaTotal, err := s.DatabaseSchemaEntityRepository.SelectCountDatabaseSchemaEntities(fqn, "all")
+
+		if err != nil {
+			return err
+		}
+
+		if databaseSchemaTotal.Total > 0 {
+			return baseModels.ErrEntityHasChildren
+		}
+	}
+
+	now := time.Now().Unix()
+
+	var cascadedEntities []*CascadedEntity
+
+	err := s.Transactor.RunInTx(func(tx *sqlx.Tx) error {
+		if hardDelete {
+			if err := s.Cascade.RecordHardDelete(tx, exist.ID, fqn, now, userName); err != nil {
+				return err
+			}
+
+			if err := s.StoredProcedureEntityRepository.WithTx(tx).DeleteStoredProcedureEntitiesByPrefix(fqn); err != nil {
+				return err
+			}
+
+			if err := s.TableEntityRepository.WithTx(tx).DeleteTableEntitiesByPrefix(fqn); err != nil {
+				return err
+			}
+
+			if err := s.DatabaseSchemaEntityRepository.WithTx(tx).DeleteDatabaseSchemaEntitiesByPrefix(fqn); err != nil {
+				return err
+			}
+
+			if err := s.DatabaseEntityRepository.WithTx(tx).DeleteDatabaseEntityById(exist.ID); err != nil {
+				return err
+			}
+
+			exist.UpdatedAt = now
+			exist.UpdatedBy = userName
+			return s.Versioning.RecordChangeEvent(tx, eventsModels.EntityDeleted, exist.Json, nil, exist.UpdatedBy, exist.UpdatedAt)
+		}
+
+		var err error
+		cascadedEntities, err = s.Cascade.SoftDelete(tx, exist.ID, fqn, now, userName)
+
+		if err != nil {
+			return err
+		}
+
+		exist.Deleted = true
+		exist.Json.Deleted = true
+		exist.UpdatedAt = now
+		exist.UpdatedBy = userName
+
+		_, err = s.updateDatabaseEntity(tx, exist, eventsModels.EntitySoftDeleted)
+		return err
+	})
+
+	if err != nil {
+		return err
+	}
+
+	if hardDelete {
+		searchServices.DeleteEntity(s.SearchIndexer, "database", exist.ID, fqn)
+	} else {
+		IndexCascadedEntities(s.SearchIndexer, cascadedEntities)
+		searchServices.IndexEntity(s.SearchIndexer, "database", exist.ID, exist.Json)
+	}
+
+	return nil
+}
+
+func (s *DatabaseEntityService) RestoreDatabaseEntity(id string, userName string) (*dataModels.DatabaseEntity, error) {
+	exist, err := s.DatabaseEntityRepository.SelectDatabaseEntityById(id, "all")
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !exist.Deleted {
+		return nil, baseModels.ErrEntityNotDeleted
+	}
+
+	// A database cannot be restored under a deleted dbservice
+	dbservice, err := s.DBServiceEntityRepository.SelectDBServiceEntityById(exist.Json.Service.ID, "all")
+
+	if err != nil {
+		return nil, err
+	}
+
+	if dbservice.Deleted {
+		return nil, baseModels.ErrParentEntityDeleted
+	}
+
+	fqn := exist.Json.FullyQualifiedName
+	now := time.Now().Unix()
+
+	var databaseEntity *dataModels.DatabaseEntity
+	var cascadedEntities []*CascadedEntity
+
+	err = s.Transactor.RunInTx(func(tx *sqlx.Tx) error {
+		var err error
+		cascadedEntities, err = s.Cascade.Restore(tx, exist.ID, fqn, now, userName)
+
+		if err != nil {
+			return err
+		}
+
+		exist.Deleted = false
+		exist.Json.Deleted = false
+		exist.UpdatedAt = now
+		exist.UpdatedBy = userName
+
+		databaseEntity, err = s.updateDatabaseEntity(tx, exist, eventsModels.EntityUpdated)
+		return err
+	})
+
+	if err == nil {
+		IndexCascadedEntities(s.SearchIndexer, cascadedEntities)
+		searchServices.IndexEntity(s.SearchIndexer, "database", databaseEntity.ID, databaseEntity.Json)
+	}
+
+	return databaseEntity, err
+}
+
+// PatchDatabaseEntity applies a JSON patch to the entity, immutable fields cannot be patched
+func (s *DatabaseEntityService) PatchDatabaseEntity(exist *dataModels.DatabaseEntity, payload []baseModels.JsonPatchOperation, ifMatch string, userName string) (*dataModels.DatabaseEntity, error) {
+	if err := baseModels.CheckIfMatch(ifMatch, exist.Json.Version); err != nil {
+		return nil, err
+	}
+
+	if err := baseModels.ValidateJsonPatch(payload, baseModels.ImmutableFields); err != nil {
+		return nil, err
+	}
+
+	var database dataModels.Database
+
+	if err := baseUtils.ApplyJsonPatch(exist.Json, payload, &database); err != nil {
+		return nil, err
+	}
+
+	owners, err := s.OwnershipService.ResolveOwners(database.Owners)
+
+	if err != nil {
+		return nil, err
+	}
+
+	database.Owners = owners
+
+	exist.Json = &database
+	exist.UpdatedAt = time.Now().Unix()
+	exist.UpdatedBy = userName
+
+	var databaseEntity *dataModels.DatabaseEntity
+
+	err = s.Transactor.RunInTx(func(tx *sqlx.Tx) error {
+		var err error
+		databaseEntity, err = s.updateDatabaseEntity(tx, exist, eventsModels.EntityUpdated)
+		return err
+	})
+
+	if err == nil {
+		searchServices.IndexEntity(s.SearchIndexer, "database", databaseEntity.ID, databaseEntity.Json)
+	}
+
+	return databaseEntity, err
+}
+
+func (s *DatabaseEntityService) GetDatabaseEntityVersions(id string) (*typeModels.EntityHistory, error) {
+	databaseEntity, err := s.DatabaseEntityRepository.SelectDatabaseEntityById(id, "all")
+
+	if err != nil {
+		return nil, err
+	}
+
+	entityExtensions, err := s.EntityExtensionRepository.SelectEntityVersions(id, "database")
+
+	if err != nil {
+		return nil, err
+	}
+
+	entityHistory := &typeModels.EntityHistory{ EntityType: "database", Versions: []interface{}{ databaseEntity.Json } }
+
+	for _, e := range entityExtensions {
+		entityHistory.Versions = append(entityHistory.Versions, e.Json)
+	}
+
+	return entityHistory, nil
+}
+
+func (s *DatabaseEntityService) GetDatabaseEntityVersion(id string, version float64) (interface{}, error) {
+	databaseEntity, err := s.DatabaseEntityRepository.SelectDatabaseEntityById(id, "all")
+
+	if err != nil {
+		return nil, err
+	}
+
+	if databaseEntity.Json.Version == version {
+		return databaseEntity.Json, nil
+	}
+
+	entityExtension, err := s.EntityExtensionRepository.SelectEntityExtension(id, typeModels.VersionExtension("database", version))
+
+	if err != nil {
+		return nil, err
+	}
+
+	return entityExtension.Json, nil
+}
+
+// updateDatabaseEntity writes a changed database and its owners
+func (s *DatabaseEntityService) updateDatabaseEntity(tx *sqlx.Tx, exist *dataModels.DatabaseEntity, eventType string) (*dataModels.DatabaseEntity, error) {
+	previous, err := s.DatabaseEntityRepository.WithTx(tx).SelectDatabaseEntityById(exist.ID, "all")
+
+	if err != nil {
+		return nil, err
+	}
+
+	// exist was read before a concurrent update changed the stored version
+	if previous.Json.Version != exist.Json.Version {
+		return nil, baseModels.ErrPreconditionFailed
+	}
+
+	changeDescription, err := s.Versioning.NextVersion(tx, "database", previous.ID, previous.Json, exist.Json, exist.UpdatedAt, exist.UpdatedBy)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if changeDescription == nil {
+		return previous, nil
+	}
+
+	databaseEntity, err := s.DatabaseEntityRepository.WithTx(tx).UpdateDatabaseEntity(exist, previous.Json.Version)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, baseModels.ErrPreconditionFailed
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.OwnershipService.SetOwners(tx, databaseEntity.ID, "database", databaseEntity.Json.Owners); err != nil {
+		return nil, err
+	}
+
+	if err := s.Versioning.RecordChangeEvent(tx, eventType, databaseEntity.Json, changeDescription, databaseEntity.UpdatedBy, databaseEntity.UpdatedAt); err != nil {
+		return nil, err
+	}
+
+	return databaseEntity, nil
+}
+
+// insertDatabaseEntity creates the database and records an entityCreated event in the same transaction
+func (s *DatabaseEntityService) insertDatabaseEntity(entity *dataModels.DatabaseEntity) (*dataModels.DatabaseEntity, error) {
+	var databaseEntity *dataModels.DatabaseEntity
+
+	err := s.Transactor.RunInTx(func(tx *sqlx.Tx) error {
+		var err error
+		databaseEntity, err = s.DatabaseEntityRepository.WithTx(tx).InsertDatabaseEntity(entity)
+
+		if err != nil {
+			return err
+		}
+
+		if err := s.OwnershipService.SetOwners(tx, databaseEntity.ID, "database", databaseEntity.Json.Owners); err != nil {
+			return err
+		}
+
+		return s.Versioning.RecordChangeEvent(tx, eventsModels.EntityCreated, databaseEntity.Json, nil, databaseEntity.UpdatedBy, databaseEntity.UpdatedAt)
+	})
+
+	if err == nil {
+		searchServices.IndexEntity(s.SearchIndexer, "database", databaseEntity.ID, databaseEntity.Json)
+	}
+
+	return databaseEntity, err
 }
 
-func (s *DatabaseEntityService) DeleteDatabaseEntityByFqn(fqn string) error {
-	err := s.DatabaseEntityRepository.DeleteDatabaseEntityByFqn(fqn)
+// inheritOwners fills the owners of a database without owners with the ones of its service
+func (s *DatabaseEntityService) inheritOwners(databaseEntity *dataModels.DatabaseEntity) error {
+	owners, err := s.OwnershipService.InheritOwners("database", databaseEntity.ID, databaseEntity.Json.Owners)
+	databaseEntity.Json.Owners = owners
 	return err
 }
@@ -1,33 +1,69 @@
 package services
 
 import (
+	"database/sql"
 	"errors"
 	"fmt"
 	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
 	baseModels "github.com/nambuitechx/go-metadata/models/base"
 	dataModels "github.com/nambuitechx/go-metadata/models/data"
 	servicesRepositories "github.com/nambuitechx/go-metadata/repositories/services"
 	dataRepositories "github.com/nambuitechx/go-metadata/repositories/data"
+	baseRepositories "github.com/nambuitechx/go-metadata/repositories/base"
+	typeModels "github.com/nambuitechx/go-metadata/models/type"
+	typeRepositories "github.com/nambuitechx/go-metadata/repositories/type"
+	eventsModels "github.com/nambuitechx/go-metadata/models/events"
+	eventsRepositories "github.com/nambuitechx/go-metadata/repositories/events"
+	searchServices "github.com/nambuitechx/go-metadata/services/search"
+	teamsServices "github.com/nambuitechx/go-metadata/services/teams"
+	baseUtils "github.com/nambuitechx/go-metadata/utils"
 )
 
 type DatabaseSchemaEntityService struct {
+	Transactor *baseRepositories.Transactor
 	DBServiceEntityRepository *servicesRepositories.DBServiceEntityRepository
 	DatabaseEntityRepository *dataRepositories.DatabaseEntityRepository
 	DatabaseSchemaEntityRepository *dataRepositories.DatabaseSchemaEntityRepository
+	TableEntityRepository *dataRepositories.TableEntityRepository
+	StoredProcedureEntityRepository *dataRepositories.StoredProcedureEntityRepository
+	EntityExtensionRepository *typeRepositories.EntityExtensionRepository
+	ChangeEventRepository *eventsRepositories.ChangeEventRepository
+	Versioning *Versioning
+	SearchIndexer searchServices.SearchIndexer
+	OwnershipService *teamsServices.OwnershipService
+	Cascade *Cascade
 }
 
 func NewDatabaseSchemaEntityService(
+	transactor *baseRepositories.Transactor,
 	dbserviceEntityRepository *servicesRepositories.DBServiceEntityRepository,
 	databaseEntityRepository *dataRepositories.DatabaseEntityRepository,
 	databaseSchemaEntityRepository *dataRepositories.DatabaseSchemaEntityRepository,
+	tableEntityRepository *dataRepositories.TableEntityRepository,
+	storedProcedureEntityRepository *dataRepositories.StoredProcedureEntityRepository,
+	entityExtensionRepository *typeRepositories.EntityExtensionRepository,
+	cascadeDeletionRepository *typeRepositories.CascadeDeletionRepository,
+	changeEventRepository *eventsRepositories.ChangeEventRepository,
+	searchIndexer searchServices.SearchIndexer,
+	ownershipService *teamsServices.OwnershipService,
 ) *DatabaseSchemaEntityService {
 	return &DatabaseSchemaEntityService{
+		Transactor: transactor,
 		DBServiceEntityRepository: dbserviceEntityRepository,
 		DatabaseEntityRepository: databaseEntityRepository,
 		DatabaseSchemaEntityRepository: databaseSchemaEntityRepository,
+		TableEntityRepository: tableEntityRepository,
+		StoredProcedureEntityRepository: storedProcedureEntityRepository,
+		EntityExtensionRepository: entityExtensionRepository,
+		ChangeEventRepository: changeEventRepository,
+		Versioning: NewVersioning(entityExtensionRepository, changeEventRepository),
+		SearchIndexer: searchIndexer,
+		OwnershipService: ownershipService,
+		Cascade: NewCascade(databaseEntityRepository, databaseSchemaEntityRepository, tableEntityRepository, storedProcedureEntityRepository, entityExtensionRepository, cascadeDeletionRepository, changeEventRepository),
 	}
 }
 
@@ -35,27 +71,58 @@ func (s *DatabaseSchemaEntityService) Health() string {
 	return "Database schema service is available"
 }
 
-func (s *DatabaseSchemaEntityService) GetAllDatabaseSchemaEntities(database string, limit int, offset int) ([]dataModels.DatabaseSchemaEntity, error) {
-	databaseSchemaEntity, err := s.DatabaseSchemaEntityRepository.SelectDatabaseSchemaEntities(database, limit, offset)
-	return databaseSchemaEntity, err
+func (s *DatabaseSchemaEntityService) GetAllDatabaseSchemaEntities(database string, include string, limit int, before *baseModels.Cursor, after *baseModels.Cursor) ([]dataModels.DatabaseSchemaEntity, *baseModels.Paging, error) {
+	databaseSchemaEntity, err := s.DatabaseSchemaEntityRepository.SelectDatabaseSchemaEntities(database, include, limit, before, after)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for i := range databaseSchemaEntity {
+		if err := s.inheritOwners(&databaseSchemaEntity[i]); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	databaseSchemaEntity, paging := baseModels.NewPaging(databaseSchemaEntity, limit, before, after, func(e dataModels.DatabaseSchemaEntity) *baseModels.Cursor {
+		return &baseModels.Cursor{ Name: e.Json.FullyQualifiedName, ID: e.ID }
+	})
+
+	return databaseSchemaEntity, paging, nil
 }
 
-func (s *DatabaseSchemaEntityService) GetCountDatabaseSchemaEntities(database string) (*baseModels.EntityTotal, error) {
-	entityTotal, err := s.DatabaseSchemaEntityRepository.SelectCountDatabaseSchemaEntities(database)
+func (s *DatabaseSchemaEntityService) GetCountDatabaseSchemaEntities(database string, include string) (*baseModels.EntityTotal, error) {
+	entityTotal, err := s.DatabaseSchemaEntityRepository.SelectCountDatabaseSchemaEntities(database, include)
 	return entityTotal, err
 }
 
-func (s *DatabaseSchemaEntityService) GetDatabaseSchemaEntityById(id string) (*dataModels.DatabaseSchemaEntity, error) {
-	databaseSchemaEntity, err := s.DatabaseSchemaEntityRepository.SelectDatabaseSchemaEntityById(id)
-	return databaseSchemaEntity, err
+func (s *DatabaseSchemaEntityService) GetDatabaseSchemaEntityById(id string, include string) (*dataModels.DatabaseSchemaEntity, error) {
+	databaseSchemaEntity, err := s.DatabaseSchemaEntityRepository.SelectDatabaseSchemaEntityById(id, include)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return databaseSchemaEntity, s.inheritOwners(databaseSchemaEntity)
 }
 
-func (s *DatabaseSchemaEntityService) GetDatabaseSchemaEntityByFqn(fqn string) (*dataModels.DatabaseSchemaEntity, error) {
-	databaseSchemaEntity, err := s.DatabaseSchemaEntityRepository.SelectDatabaseSchemaEntityByFqn(fqn)
-	return databaseSchemaEntity, err
+func (s *DatabaseSchemaEntityService) GetDatabaseSchemaEntityByFqn(fqn string, include string) (*dataModels.DatabaseSchemaEntity, error) {
+	databaseSchemaEntity, err := s.DatabaseSchemaEntityRepository.SelectDatabaseSchemaEntityByFqn(fqn, include)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return databaseSchemaEntity, s.inheritOwners(databaseSchemaEntity)
 }
 
-func (s *DatabaseSchemaEntityService) CreateDatabaseSchemaEntity(payload *dataModels.CreateDatabaseSchemaEntityPayload) (*dataModels.DatabaseSchemaEntity, error) {
+func (s *DatabaseSchemaEntityService) CreateDatabaseSchemaEntity(payload *dataModels.CreateDatabaseSchemaEntityPayload, userName string) (*dataModels.DatabaseSchemaEntity, error) {
+	owners, err := s.OwnershipService.ResolveOwners(payload.Owners)
+
+	if err != nil {
+		return nil, err
+	}
+
 	id := uuid.NewString()
 	now := time.Now().Unix()
 
@@ -67,7 +134,7 @@ func (s *DatabaseSchemaEntityService) CreateDatabaseSchemaEntity(payload *dataMo
 	}
 
 	// Get dbservice
-	dbservice, err := s.DBServiceEntityRepository.SelectDBServiceEntityByFqn(arr[0])
+	dbservice, err := s.DBServiceEntityRepository.SelectDBServiceEntityByFqn(arr[0], "non-deleted")
 
 	if err != nil {
 		return nil, err
@@ -76,7 +143,7 @@ func (s *DatabaseSchemaEntityService) CreateDatabaseSchemaEntity(payload *dataMo
 	dbserviceEntityRef := dbservice.Json.ToEntityReference()
 
 	// Get database
-	database, err := s.DatabaseEntityRepository.SelectDatabaseEntityByFqn(fmt.Sprintf("%v.%v", arr[0], arr[1]))
+	database, err := s.DatabaseEntityRepository.SelectDatabaseEntityByFqn(fmt.Sprintf("%v.%v", arr[0], arr[1]), "non-deleted")
 
 	if err != nil {
 		return nil, err
@@ -94,6 +161,10 @@ func (s *DatabaseSchemaEntityService) CreateDatabaseSchemaEntity(payload *dataMo
 		ServiceType: dbservice.ServiceType,
 		Service: dbserviceEntityRef,
 		Database: databaseEntityRef,
+		Owners: owners,
+		Version: typeModels.InitialVersion,
+		UpdatedAt: now,
+		UpdatedBy: userName,
 		Deleted: false,
 	}
 
@@ -102,18 +173,54 @@ func (s *DatabaseSchemaEntityService) CreateDatabaseSchemaEntity(payload *dataMo
 		Name: payload.Name,
 		Json: databaseSchema,
 		UpdatedAt: now,
+		UpdatedBy: userName,
 		Deleted: false,
 	}
 
-	databaseSchemaEntity, err := s.DatabaseSchemaEntityRepository.InsertDatabaseSchemaEntity(entity)
+	databaseSchemaEntity, err := s.insertDatabaseSchemaEntity(entity)
 	return databaseSchemaEntity, err
 }
 
-func (s *DatabaseSchemaEntityService) CreateOrUpdateDatabaseSchemaEntity(payload *dataModels.CreateDatabaseSchemaEntityPayload) (*dataModels.DatabaseSchemaEntity, error) {
-	exist, err := s.DatabaseSchemaEntityRepository.SelectDatabaseSchemaEntityByFqn(fmt.Sprintf("%v.%v", payload.Database, payload.Name))
+func (s *DatabaseSchemaEntityService) CreateOrUpdateDatabaseSchemaEntity(payload *dataModels.CreateDatabaseSchemaEntityPayload, ifMatch string, userName string) (*dataModels.DatabaseSchemaEntity, error) {
+	owners, err := s.OwnershipService.ResolveOwners(payload.Owners)
+
+	if err != nil {
+		return nil, err
+	}
+
+	exist, err := s.DatabaseSchemaEntityRepository.SelectDatabaseSchemaEntityByFqn(fmt.Sprintf("%v.%v", payload.Database, payload.Name), "all")
 
 	if err == nil {
-		updated, err := s.DatabaseSchemaEntityRepository.UpdateDatabaseSchemaEntity(exist)
+		if exist.Deleted {
+			return nil, baseModels.ErrEntityDeleted
+		}
+
+		if err := baseModels.CheckIfMatch(ifMatch, exist.Json.Version); err != nil {
+			return nil, err
+		}
+
+		exist.Json.DisplayName = baseUtils.MergeString(exist.Json.DisplayName, payload.DisplayName)
+		exist.Json.Description = baseUtils.MergeString(exist.Json.Description, payload.Description)
+
+		if payload.Owners != nil {
+			exist.Json.Owners = owners
+		}
+
+		exist.UpdatedAt = time.Now().Unix()
+		exist.UpdatedBy = userName
+
+		var updated *dataModels.DatabaseSchemaEntity
+
+		err = s.Transactor.RunInTx(func(tx *sqlx.Tx) error {
+			var err error
+			updated, err = s.updateDatabaseSchemaEntity(tx, exist, eventsModels.EntityUpdated)
+			return err
+		})
+
+		if err == nil {
+			searchServices.IndexEntity(s.SearchIndexer, "databaseSchema", updated.ID, updated.Json)
+		}
+
 		return updated, err
 	}
 
@@ -128,7 +235,7 @@ func (s *DatabaseSchemaEntityService) CreateOrUpdateDatabaseSchemaEntity(payload
 	}
 
 	// Get dbservice
-	dbservice, err := s.DBServiceEntityRepository.SelectDBServiceEntityByFqn(arr[0])
+	dbservice, err := s.DBServiceEntityRepository.SelectDBServiceEntityByFqn(arr[0], "non-deleted")
 
 	if err != nil {
 		return nil, err
@@ -137,7 +244,7 @@ func (s *DatabaseSchemaEntityService) CreateOrUpdateDatabaseSchemaEntity(payload
 	dbserviceEntityRef := dbservice.Json.ToEntityReference()
 
 	// Get database
-	database, err := s.DatabaseEntityRepository.SelectDatabaseEntityByFqn(fmt.Sprintf("%v.%v", arr[0], arr[1]))
+	database, err := s.DatabaseEntityRepository.SelectDatabaseEntityByFqn(fmt.Sprintf("%v.%v", arr[0], arr[1]), "non-deleted")
 
 	if err != nil {
 		return nil, err
@@ -155,6 +262,10 @@ func (s *DatabaseSchemaEntityService) CreateOrUpdateDatabaseSchemaEntity(payload
 		ServiceType: dbservice.ServiceType,
 		Service: dbserviceEntityRef,
 		Database: databaseEntityRef,
+		Owners: owners,
+		Version: typeModels.InitialVersion,
+		UpdatedAt: now,
+		UpdatedBy: userName,
 		Deleted: false,
 	}
 
@@ -163,19 +274,333 @@ func (s *DatabaseSchemaEntityService) CreateOrUpdateDatabaseSchemaEntity(payload
 		Name: payload.Name,
 		Json: databaseSchema,
 		UpdatedAt: now,
+		UpdatedBy: userName,
 		Deleted: false,
 	}
 
-	databaseSchemaEntity, err := s.DatabaseSchemaEntityRepository.InsertDatabaseSchemaEntity(entity)
+	databaseSchemaEntity, err := s.insertDatabaseSchemaEntity(entity)
 	return databaseSchemaEntity, err
 }
 
-func (s *DatabaseSchemaEntityService) DeleteDatabaseSchemaEntityById(id string) error {
-	err := s.DatabaseSchemaEntityRepository.DeleteDatabaseSchemaEntityById(id)
-	return err
+func (s *DatabaseSchemaEntityService) DeleteDatabaseSchemaEntityById(id string, hardDelete bool, recursive bool, userName string) error {
+	exist, err := s.DatabaseSchemaEntityRepository.SelectDatabaseSchemaEntityById(id, "all")
+
+	if err != nil {
+		return err
+	}
+
+	return s.deleteDatabaseSchemaEntity(exist, hardDelete, recursive, userName)
+}
+
+func (s *DatabaseSchemaEntityService) DeleteDatabaseSchemaEntityByFqn(fqn string, hardDelete bool, recursive bool, userName string) error {
+	exist, err := s.DatabaseSchemaEntityRepository.SelectDatabaseSchemaEntityByFqn(fqn, "all")
+
+	if err != nil {
+		return err
+	}
+
+	return s.deleteDatabaseSchemaEntity(exist, hardDelete, recursive, userName)
+}
+
+func (s *DatabaseSchemaEntityService) deleteDatabaseSchemaEntity(exist *dataModels.DatabaseSchemaEntity, hardDelete bool, recursive bool, userName string) error {
+	fqn := exist.Json.FullyQualifiedName
+
+	if !recursive {
+		hasChildren, err := s.hasChildren(fqn)
+
+		if err != nil {
+			return err
+		}
+
+		if hasChildren {
+			return baseModels.ErrEntityHasChildren
+		}
+	}
+
+	now := time.Now().Unix()
+
+	var cascadedEntities []*CascadedEntity
+
+	err := s.Transactor.RunInTx(func(tx *sqlx.Tx) error {
+		if hardDelete {
+			if err := s.Cascade.RecordHardDelete(tx, exist.ID, fqn, now, userName); err != nil {
+				return err
+			}
+
+			if err := s.StoredProcedureEntityRepository.WithTx(tx).DeleteStoredProcedureEntitiesByPrefix(fqn); err != nil {
+				return err
+			}
+
+			if err := s.TableEntityRepository.WithTx(tx).DeleteTableEntitiesByPrefix(fqn); err != nil {
+				return err
+			}
+
+			if err := s.DatabaseSchemaEntityRepository.WithTx(tx).DeleteDatabaseSchemaEntityById(exist.ID); err != nil {
+				return err
+			}
+
+			exist.UpdatedAt = now
+			exist.UpdatedBy = userName
+			return s.Versioning.RecordChangeEvent(tx, eventsModels.EntityDeleted, exist.Json, nil, exist.UpdatedBy, exist.UpdatedAt)
+		}
+
+		var err error
+		cascadedEntities, err = s.Cascade.SoftDelete(tx, exist.ID, fqn, now, userName)
+
+		if err != nil {
+			return err
+		}
+
+		exist.Deleted = true
+		exist.Json.Deleted = true
+		exist.UpdatedAt = now
+		exist.UpdatedBy = userName
+
+		_, err = s.updateDatabaseSchemaEntity(tx, exist, eventsModels.EntitySoftDeleted)
+		return err
+	})
+
+	if err != nil {
+		return err
+	}
+
+	if hardDelete {
+		searchServices.DeleteEntity(s.SearchIndexer, "databaseSchema", exist.ID, fqn)
+	} else {
+		IndexCascadedEntities(s.SearchIndexer, cascadedEntities)
+		searchServices.IndexEntity(s.SearchIndexer, "databaseSchema", exist.ID, exist.Json)
+	}
+
+	return nil
+}
+
+func (s *DatabaseSchemaEntityService) hasChildren(fqn string) (bool, error) {
+	tableTotal, err := s.TableEntityRepository.SelectCountTableEntities(fqn, "", "all")
+
+	if err != nil {
+		return false, err
+	}
+
+	storedProcedureTotal, err := s.StoredProcedureEntityRepository.SelectCountStoredProcedureEntities(fqn, "all")
+
+	if err != nil {
+		return false, err
+	}
+
+	return tableTotal.Total > 0 || storedProcedureTotal.Total > 0, nil
+}
+
+func (s *DatabaseSchemaEntityService) RestoreDatabaseSchemaEntity(id string, userName string) (*dataModels.DatabaseSchemaEntity, error) {
+	exist, err := s.DatabaseSchemaEntityRepository.SelectDatabaseSchemaEntityById(id, "all")
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !exist.Deleted {
+		return nil, baseModels.ErrEntityNotDeleted
+	}
+
+	// A database schema cannot be restored under a deleted database
+	database, err := s.DatabaseEntityRepository.SelectDatabaseEntityById(exist.Json.Database.ID, "all")
+
+	if err != nil {
+		return nil, err
+	}
+
+	if database.Deleted {
+		return nil, baseModels.ErrParentEntityDeleted
+	}
+
+	fqn := exist.Json.FullyQualifiedName
+	now := time.Now().Unix()
+
+	var databaseSchemaEntity *dataModels.DatabaseSchemaEntity
+	var cascadedEntities []*CascadedEntity
+
+	err = s.Transactor.RunInTx(func(tx *sqlx.Tx) error {
+		var err error
+		cascadedEntities, err = s.Cascade.Restore(tx, exist.ID, fqn, now, userName)
+
+		if err != nil {
+			return err
+		}
+
+		exist.Deleted = false
+		exist.Json.Deleted = false
+		exist.UpdatedAt = now
+		exist.UpdatedBy = userName
+
+		databaseSchemaEntity, err = s.updateDatabaseSchemaEntity(tx, exist, eventsModels.EntityUpdated)
+		return err
+	})
+
+	if err == nil {
+		IndexCascadedEntities(s.SearchIndexer, cascadedEntities)
+		searchServices.IndexEntity(s.SearchIndexer, "databaseSchema", databaseSchemaEntity.ID, databaseSchemaEntity.Json)
+	}
+
+	return databaseSchemaEntity, err
+}
+
+// PatchDatabaseSchemaEntity applies a JSON patch to the entity, immutable fields cannot be patched
+func (s *DatabaseSchemaEntityService) PatchDatabaseSchemaEntity(exist *dataModels.DatabaseSchemaEntity, payload []baseModels.JsonPatchOperation, ifMatch string, userName string) (*dataModels.DatabaseSchemaEntity, error) {
+	if err := baseModels.CheckIfMatch(ifMatch, exist.Json.Version); err != nil {
+		return nil, err
+	}
+
+	if err := baseModels.ValidateJsonPatch(payload, baseModels.ImmutableFields); err != nil {
+		return nil, err
+	}
+
+	var databaseSchema dataModels.DatabaseSchema
+
+	if err := baseUtils.ApplyJsonPatch(exist.Json, payload, &databaseSchema); err != nil {
+		return nil, err
+	}
+
+	owners, err := s.OwnershipService.ResolveOwners(databaseSchema.Owners)
+
+	if err != nil {
+		return nil, err
+	}
+
+	databaseSchema.Owners = owners
+
+	exist.Json = &databaseSchema
+	exist.UpdatedAt = time.Now().Unix()
+	exist.UpdatedBy = userName
+
+	var databaseSchemaEntity *dataModels.DatabaseSchemaEntity
+
+	err = s.Transactor.RunInTx(func(tx *sqlx.Tx) error {
+		var err error
+		databaseSchemaEntity, err = s.updateDatabaseSchemaEntity(tx, exist, eventsModels.EntityUpdated)
+		return err
+	})
+
+	if err == nil {
+		searchServices.IndexEntity(s.SearchIndexer, "databaseSchema", databaseSchemaEntity.ID, databaseSchemaEntity.Json)
+	}
+
+	return databaseSchemaEntity, err
+}
+
+func (s *DatabaseSchemaEntityService) GetDatabaseSchemaEntityVersions(id string) (*typeModels.EntityHistory, error) {
+	databaseSchemaEntity, err := s.DatabaseSchemaEntityRepository.SelectDatabaseSchemaEntityById(id, "all")
+
+	if err != nil {
+		return nil, err
+	}
+
+	entityExtensions, err := s.EntityExtensionRepository.SelectEntityVersions(id, "databaseSchema")
+
+	if err != nil {
+		return nil, err
+	}
+
+	entityHistory := &typeModels.EntityHistory{ EntityType: "databaseSchema", Versions: []interface{}{ databaseSchemaEntity.Json } }
+
+	for _, e := range entityExtensions {
+		entityHistory.Versions = append(entityHistory.Versions, e.Json)
+	}
+
+	return entityHistory, nil
+}
+
+func (s *DatabaseSchemaEntityService) GetDatabaseSchemaEntityVersion(id string, version float64) (interface{}, error) {
+	databaseSchemaEntity, err := s.DatabaseSchemaEntityRepository.SelectDatabaseSchemaEntityById(id, "all")
+
+	if err != nil {
+		return nil, err
+	}
+
+	if databaseSchemaEntity.Json.Version == version {
+		return databaseSchemaEntity.Json, nil
+	}
+
+	entityExtension, err := s.EntityExtensionRepository.SelectEntityExtension(id, typeModels.VersionExtension("databaseSchema", version))
+
+	if err != nil {
+		return nil, err
+	}
+
+	return entityExtension.Json, nil
+}
+
+// updateDatabaseSchemaEntity writes a changed schema and its owners
+func (s *DatabaseSchemaEntityService) updateDatabaseSchemaEntity(tx *sqlx.Tx, exist *dataModels.DatabaseSchemaEntity, eventType string) (*dataModels.DatabaseSchemaEntity, error) {
+	previous, err := s.DatabaseSchemaEntityRepository.WithTx(tx).SelectDatabaseSchemaEntityById(exist.ID, "all")
+
+	if err != nil {
+		return nil, err
+	}
+
+	// exist was read before a concurrent update changed the stored version
+	if previous.Json.Version != exist.Json.Version {
+		return nil, baseModels.ErrPreconditionFailed
+	}
+
+	changeDescription, err := s.Versioning.NextVersion(tx, "databaseSchema", previous.ID, previous.Json, exist.Json, exist.UpdatedAt, exist.UpdatedBy)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if changeDescription == nil {
+		return previous, nil
+	}
+
+	databaseSchemaEntity, err := s.DatabaseSchemaEntityRepository.WithTx(tx).UpdateDatabaseSchemaEntity(exist, previous.Json.Version)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, baseModels.ErrPreconditionFailed
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.OwnershipService.SetOwners(tx, databaseSchemaEntity.ID, "databaseSchema", databaseSchemaEntity.Json.Owners); err != nil {
+		return nil, err
+	}
+
+	if err := s.Versioning.RecordChangeEvent(tx, eventType, databaseSchemaEntity.Json, changeDescription, databaseSchemaEntity.UpdatedBy, databaseSchemaEntity.UpdatedAt); err != nil {
+		return nil, err
+	}
+
+	return databaseSchemaEntity, nil
+}
+
+// insertDatabaseSchemaEntity creates the database schema and records an entityCreated event in the same transaction
+func (s *DatabaseSchemaEntityService) insertDatabaseSchemaEntity(entity *dataModels.DatabaseSchemaEntity) (*dataModels.DatabaseSchemaEntity, error) {
+	var databaseSchemaEntity *dataModels.DatabaseSchemaEntity
+
+	err := s.Transactor.RunInTx(func(tx *sqlx.Tx) error {
+		var err error
+		databaseSchemaEntity, err = s.DatabaseSchemaEntityRepository.WithTx(tx).InsertDatabaseSchemaEntity(entity)
+
+		if err != nil {
+			return err
+		}
+
+		if err := s.OwnershipService.SetOwners(tx, databaseSchemaEntity.ID, "databaseSchema", databaseSchemaEntity.Json.Owners); err != nil {
+			return err
+		}
+
+		return s.Versioning.RecordChangeEvent(tx, eventsModels.EntityCreated, databaseSchemaEntity.Json, nil, databaseSchemaEntity.UpdatedBy, databaseSchemaEntity.UpdatedAt)
+	})
+
+	if err == nil {
+		searchServices.IndexEntity(s.SearchIndexer, "databaseSchema", databaseSchemaEntity.ID, databaseSchemaEntity.Json)
+	}
+
+	return databaseSchemaEntity, err
 }
 
-func (s *DatabaseSchemaEntityService) DeleteDatabaseSchemaEntityByFqn(fqn string) error {
-	err := s.DatabaseSchemaEntityRepository.DeleteDatabaseSchemaEntityByFqn(fqn)
+// inheritOwners fills the owners of a schema without owners with the ones of its database or service
+func (s *DatabaseSchemaEntityService) inheritOwners(databaseSchemaEntity *dataModels.DatabaseSchemaEntity) error {
+	owners, err := s.OwnershipService.InheritOwners("databaseSchema", databaseSchemaEntity.ID, databaseSchemaEntity.Json.Owners)
+	databaseSchemaEntity.Json.Owners = owners
 	return err
 }
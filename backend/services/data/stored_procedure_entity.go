@@ -1,36 +1,71 @@
 package services
 
 import (
+	"database/sql"
 	"errors"
 	"fmt"
 	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
 	baseModels "github.com/nambuitechx/go-metadata/models/base"
 	dataModels "github.com/nambuitechx/go-metadata/models/data"
 	servicesRepositories "github.com/nambuitechx/go-metadata/repositories/services"
 	dataRepositories "github.com/nambuitechx/go-metadata/repositories/data"
+	typeModels "github.com/nambuitechx/go-metadata/models/type"
+	typeRepositories "github.com/nambuitechx/go-metadata/repositories/type"
+	eventsModels "github.com/nambuitechx/go-metadata/models/events"
+	lineageModels "github.com/nambuitechx/go-metadata/models/lineage"
+	eventsRepositories "github.com/nambuitechx/go-metadata/repositories/events"
+	searchServices "github.com/nambuitechx/go-metadata/services/search"
+	teamsServices "github.com/nambuitechx/go-metadata/services/teams"
+	baseRepositories "github.com/nambuitechx/go-metadata/repositories/base"
+	lineageRepositories "github.com/nambuitechx/go-metadata/repositories/lineage"
+	baseUtils "github.com/nambuitechx/go-metadata/utils"
 )
 
 type StoredProcedureEntityService struct {
+	Transactor *baseRepositories.Transactor
 	DBServiceEntityRepository *servicesRepositories.DBServiceEntityRepository
 	DatabaseEntityRepository *dataRepositories.DatabaseEntityRepository
 	DatabaseSchemaEntityRepository *dataRepositories.DatabaseSchemaEntityRepository
 	StoredProcedureEntityRepository *dataRepositories.StoredProcedureEntityRepository
+	TableEntityRepository *dataRepositories.TableEntityRepository
+	EntityExtensionRepository *typeRepositories.EntityExtensionRepository
+	ChangeEventRepository *eventsRepositories.ChangeEventRepository
+	Versioning *Versioning
+	LineageRepository *lineageRepositories.LineageRepository
+	SearchIndexer searchServices.SearchIndexer
+	OwnershipService *teamsServices.OwnershipService
 }
 
 func NewStoredProcedureEntityService(
+	transactor *baseRepositories.Transactor,
 	dbserviceEntityRepository *servicesRepositories.DBServiceEntityRepository,
 	databaseEntityRepository *dataRepositories.DatabaseEntityRepository,
 	databaseSchemaEntityRepository *dataRepositories.DatabaseSchemaEntityRepository,
 	storedProcedureEntityRepository *dataRepositories.StoredProcedureEntityRepository,
+	tableEntityRepository *dataRepositories.TableEntityRepository,
+	entityExtensionRepository *typeRepositories.EntityExtensionRepository,
+	changeEventRepository *eventsRepositories.ChangeEventRepository,
+	lineageRepository *lineageRepositories.LineageRepository,
+	searchIndexer searchServices.SearchIndexer,
+	ownershipService *teamsServices.OwnershipService,
 ) *StoredProcedureEntityService {
 	return &StoredProcedureEntityService{
+		Transactor: transactor,
 		DBServiceEntityRepository: dbserviceEntityRepository,
 		DatabaseEntityRepository: databaseEntityRepository,
 		DatabaseSchemaEntityRepository: databaseSchemaEntityRepository,
 		StoredProcedureEntityRepository: storedProcedureEntityRepository,
+		TableEntityRepository: tableEntityRepository,
+		EntityExtensionRepository: entityExtensionRepository,
+		ChangeEventRepository: changeEventRepository,
+		Versioning: NewVersioning(entityExtensionRepository, changeEventRepository),
+		LineageRepository: lineageRepository,
+		SearchIndexer: searchIndexer,
+		OwnershipService: ownershipService,
 	}
 }
 
@@ -38,27 +73,58 @@ func (s *StoredProcedureEntityService) Health() string {
 	return "Stored procedure service is available"
 }
 
-func (s *StoredProcedureEntityService) GetAllStoredProcedureEntities(databaseSchema string, limit int, offset int) ([]dataModels.StoredProcedureEntity, error) {
-	storedProcedureEntity, err := s.StoredProcedureEntityRepository.SelectStoredProcedureEntities(databaseSchema, limit, offset)
-	return storedProcedureEntity, err
+func (s *StoredProcedureEntityService) GetAllStoredProcedureEntities(databaseSchema string, include string, limit int, before *baseModels.Cursor, after *baseModels.Cursor) ([]dataModels.StoredProcedureEntity, *baseModels.Paging, error) {
+	storedProcedureEntity, err := s.StoredProcedureEntityRepository.SelectStoredProcedureEntities(databaseSchema, include, limit, before, after)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for i := range storedProcedureEntity {
+		if err := s.inheritOwners(&storedProcedureEntity[i]); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	storedProcedureEntity, paging := baseModels.NewPaging(storedProcedureEntity, limit, before, after, func(e dataModels.StoredProcedureEntity) *baseModels.Cursor {
+		return &baseModels.Cursor{ Name: e.Json.FullyQualifiedName, ID: e.ID }
+	})
+
+	return storedProcedureEntity, paging, nil
 }
 
-func (s *StoredProcedureEntityService) GetCountStoredProcedureEntities(databaseSchema string) (*baseModels.EntityTotal, error) {
-	entityTotal, err := s.StoredProcedureEntityRepository.SelectCountStoredProcedureEntities(databaseSchema)
+func (s *StoredProcedureEntityService) GetCountStoredProcedureEntities(databaseSchema string, include string) (*baseModels.EntityTotal, error) {
+	entityTotal, err := s.StoredProcedureEntityRepository.SelectCountStoredProcedureEntities(databaseSchema, include)
 	return entityTotal, err
 }
 
-func (s *StoredProcedureEntityService) GetStoredProcedureEntityById(id string) (*dataModels.StoredProcedureEntity, error) {
-	storedProcedureEntity, err := s.StoredProcedureEntityRepository.SelectStoredProcedureEntityById(id)
-	return storedProcedureEntity, err
+func (s *StoredProcedureEntityService) GetStoredProcedureEntityById(id string, include string) (*dataModels.StoredProcedureEntity, error) {
+	storedProcedureEntity, err := s.StoredProcedureEntityRepository.SelectStoredProcedureEntityById(id, include)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return storedProcedureEntity, s.inheritOwners(storedProcedureEntity)
 }
 
-func (s *StoredProcedureEntityService) GetStoredProcedureEntityByFqn(fqn string) (*dataModels.StoredProcedureEntity, error) {
-	storedProcedureEntity, err := s.StoredProcedureEntityRepository.SelectStoredProcedureEntityByFqn(fqn)
-	return storedProcedureEntity, err
+func (s *StoredProcedureEntityService) GetStoredProcedureEntityByFqn(fqn string, include string) (*dataModels.StoredProcedureEntity, error) {
+	storedProcedureEntity, err := s.StoredProcedureEntityRepository.SelectStoredProcedureEntityByFqn(fqn, include)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return storedProcedureEntity, s.inheritOwners(storedProcedureEntity)
 }
 
-func (s *StoredProcedureEntityService) CreateStoredProcedureEntity(payload *dataModels.CreateStoredProcedureEntityPayload) (*dataModels.StoredProcedureEntity, error) {
+func (s *StoredProcedureEntityService) CreateStoredProcedureEntity(payload *dataModels.CreateStoredProcedureEntityPayload, userName string) (*dataModels.StoredProcedureEntity, error) {
+	owners, err := s.OwnershipService.ResolveOwners(payload.Owners)
+
+	if err != nil {
+		return nil, err
+	}
+
 	id := uuid.NewString()
 	now := time.Now().Unix()
 
@@ -70,7 +136,7 @@ func (s *StoredProcedureEntityService) CreateStoredProcedureEntity(payload *data
 	}
 
 	// Get dbservice
-	dbservice, err := s.DBServiceEntityRepository.SelectDBServiceEntityByFqn(arr[0])
+	dbservice, err := s.DBServiceEntityRepository.SelectDBServiceEntityByFqn(arr[0], "non-deleted")
 
 	if err != nil {
 		return nil, err
@@ -79,7 +145,7 @@ func (s *StoredProcedureEntityService) CreateStoredProcedureEntity(payload *data
 	dbserviceEntityRef := dbservice.Json.ToEntityReference()
 
 	// Get database
-	database, err := s.DatabaseEntityRepository.SelectDatabaseEntityByFqn(fmt.Sprintf("%v.%v", arr[0], arr[1]))
+	database, err := s.DatabaseEntityRepository.SelectDatabaseEntityByFqn(fmt.Sprintf("%v.%v", arr[0], arr[1]), "non-deleted")
 
 	if err != nil {
 		return nil, err
@@ -88,7 +154,7 @@ func (s *StoredProcedureEntityService) CreateStoredProcedureEntity(payload *data
 	databaseEntityRef := database.Json.ToEntityReference()
 
 	// Get database schema
-	databaseSchema, err := s.DatabaseSchemaEntityRepository.SelectDatabaseSchemaEntityByFqn(fmt.Sprintf("%v.%v.%v", arr[0], arr[1], arr[2]))
+	databaseSchema, err := s.DatabaseSchemaEntityRepository.SelectDatabaseSchemaEntityByFqn(fmt.Sprintf("%v.%v.%v", arr[0], arr[1], arr[2]), "non-deleted")
 
 	if err != nil {
 		return nil, err
@@ -109,6 +175,10 @@ func (s *StoredProcedureEntityService) CreateStoredProcedureEntity(payload *data
 		Service: dbserviceEntityRef,
 		Database: databaseEntityRef,
 		DatabaseSchema: databaseSchemaEntityRef,
+		Owners: owners,
+		Version: typeModels.InitialVersion,
+		UpdatedAt: now,
+		UpdatedBy: userName,
 		Deleted: false,
 	}
 
@@ -117,18 +187,45 @@ func (s *StoredProcedureEntityService) CreateStoredProcedureEntity(payload *data
 		Name: payload.Name,
 		Json: storedProcedure,
 		UpdatedAt: now,
+		UpdatedBy: userName,
 		Deleted: false,
 	}
 
-	storedProcedureEntity, err := s.StoredProcedureEntityRepository.InsertStoredProcedureEntity(entity)
+	storedProcedureEntity, err := s.insertStoredProcedureEntity(entity)
 	return storedProcedureEntity, err
 }
 
-func (s *StoredProcedureEntityService) CreateOrUpdateStoredProcedureEntity(payload *dataModels.CreateStoredProcedureEntityPayload) (*dataModels.StoredProcedureEntity, error) {
-	exist, err := s.StoredProcedureEntityRepository.SelectStoredProcedureEntityByFqn(fmt.Sprintf("%v.%v", payload.DatabaseSchema, payload.Name))
+func (s *StoredProcedureEntityService) CreateOrUpdateStoredProcedureEntity(payload *dataModels.CreateStoredProcedureEntityPayload, ifMatch string, userName string) (*dataModels.StoredProcedureEntity, error) {
+	owners, err := s.OwnershipService.ResolveOwners(payload.Owners)
+
+	if err != nil {
+		return nil, err
+	}
+
+	exist, err := s.StoredProcedureEntityRepository.SelectStoredProcedureEntityByFqn(fmt.Sprintf("%v.%v", payload.DatabaseSchema, payload.Name), "all")
 
 	if err == nil {
-		updated, err := s.StoredProcedureEntityRepository.UpdateStoredProcedureEntity(exist)
+		if exist.Deleted {
+			return nil, baseModels.ErrEntityDeleted
+		}
+
+		if err := baseModels.CheckIfMatch(ifMatch, exist.Json.Version); err != nil {
+			return nil, err
+		}
+
+		exist.Json.DisplayName = baseUtils.MergeString(exist.Json.DisplayName, payload.DisplayName)
+		exist.Json.Description = baseUtils.MergeString(exist.Json.Description, payload.Description)
+		exist.Json.StoredProcedureCode = payload.StoredProcedureCode
+		exist.Json.StoredProcedureType = payload.StoredProcedureType
+
+		if payload.Owners != nil {
+			exist.Json.Owners = owners
+		}
+
+		exist.UpdatedAt = time.Now().Unix()
+		exist.UpdatedBy = userName
+
+		updated, err := s.updateStoredProcedureEntity(exist, eventsModels.EntityUpdated)
 		return updated, err
 	}
 
@@ -143,7 +240,7 @@ func (s *StoredProcedureEntityService) CreateOrUpdateStoredProcedureEntity(paylo
 	}
 
 	// Get dbservice
-	dbservice, err := s.DBServiceEntityRepository.SelectDBServiceEntityByFqn(arr[0])
+	dbservice, err := s.DBServiceEntityRepository.SelectDBServiceEntityByFqn(arr[0], "non-deleted")
 
 	if err != nil {
 		return nil, err
@@ -152,7 +249,7 @@ func (s *StoredProcedureEntityService) CreateOrUpdateStoredProcedureEntity(paylo
 	dbserviceEntityRef := dbservice.Json.ToEntityReference()
 
 	// Get database
-	database, err := s.DatabaseEntityRepository.SelectDatabaseEntityByFqn(fmt.Sprintf("%v.%v", arr[0], arr[1]))
+	database, err := s.DatabaseEntityRepository.SelectDatabaseEntityByFqn(fmt.Sprintf("%v.%v", arr[0], arr[1]), "non-deleted")
 
 	if err != nil {
 		return nil, err
@@ -161,7 +258,7 @@ func (s *StoredProcedureEntityService) CreateOrUpdateStoredProcedureEntity(paylo
 	databaseEntityRef := database.Json.ToEntityReference()
 
 	// Get database schema
-	databaseSchema, err := s.DatabaseSchemaEntityRepository.SelectDatabaseSchemaEntityByFqn(fmt.Sprintf("%v.%v.%v", arr[0], arr[1], arr[2]))
+	databaseSchema, err := s.DatabaseSchemaEntityRepository.SelectDatabaseSchemaEntityByFqn(fmt.Sprintf("%v.%v.%v", arr[0], arr[1], arr[2]), "non-deleted")
 
 	if err != nil {
 		return nil, err
@@ -182,6 +279,10 @@ func (s *StoredProcedureEntityService) CreateOrUpdateStoredProcedureEntity(paylo
 		Service: dbserviceEntityRef,
 		Database: databaseEntityRef,
 		DatabaseSchema: databaseSchemaEntityRef,
+		Owners: owners,
+		Version: typeModels.InitialVersion,
+		UpdatedAt: now,
+		UpdatedBy: userName,
 		Deleted: false,
 	}
 
@@ -190,19 +291,259 @@ func (s *StoredProcedureEntityService) CreateOrUpdateStoredProcedureEntity(paylo
 		Name: payload.Name,
 		Json: storedProcedure,
 		UpdatedAt: now,
+		UpdatedBy: userName,
 		Deleted: false,
 	}
 
-	storedProcedureEntity, err := s.StoredProcedureEntityRepository.InsertStoredProcedureEntity(entity)
+	storedProcedureEntity, err := s.insertStoredProcedureEntity(entity)
 	return storedProcedureEntity, err
 }
 
-func (s *StoredProcedureEntityService) DeleteStoredProcedureEntityById(id string) error {
-	err := s.StoredProcedureEntityRepository.DeleteStoredProcedureEntityById(id)
+func (s *StoredProcedureEntityService) DeleteStoredProcedureEntityById(id string, hardDelete bool, userName string) error {
+	exist, err := s.StoredProcedureEntityRepository.SelectStoredProcedureEntityById(id, "all")
+
+	if err != nil {
+		return err
+	}
+
+	return s.deleteStoredProcedureEntity(exist, hardDelete, userName)
+}
+
+func (s *StoredProcedureEntityService) DeleteStoredProcedureEntityByFqn(fqn string, hardDelete bool, userName string) error {
+	exist, err := s.StoredProcedureEntityRepository.SelectStoredProcedureEntityByFqn(fqn, "all")
+
+	if err != nil {
+		return err
+	}
+
+	return s.deleteStoredProcedureEntity(exist, hardDelete, userName)
+}
+
+func (s *StoredProcedureEntityService) deleteStoredProcedureEntity(exist *dataModels.StoredProcedureEntity, hardDelete bool, userName string) error {
+	if hardDelete {
+		err := s.Transactor.RunInTx(func(tx *sqlx.Tx) error {
+			if err := s.StoredProcedureEntityRepository.WithTx(tx).DeleteStoredProcedureEntityById(exist.ID); err != nil {
+				return err
+			}
+
+			if err := s.LineageRepository.WithTx(tx).DeleteLineageEdgesByPipeline(exist.ID, lineageModels.QueryLineage); err != nil {
+				return err
+			}
+
+			exist.UpdatedAt = time.Now().Unix()
+			exist.UpdatedBy = userName
+			return s.Versioning.RecordChangeEvent(tx, eventsModels.EntityDeleted, exist.Json, nil, exist.UpdatedBy, exist.UpdatedAt)
+		})
+
+		if err == nil {
+			searchServices.DeleteEntity(s.SearchIndexer, "storedProcedure", exist.ID, exist.Json.FullyQualifiedName)
+		}
+
+		return err
+	}
+
+	exist.Deleted = true
+	exist.Json.Deleted = true
+	exist.UpdatedAt = time.Now().Unix()
+	exist.UpdatedBy = userName
+
+	_, err := s.updateStoredProcedureEntity(exist, eventsModels.EntitySoftDeleted)
 	return err
 }
 
-func (s *StoredProcedureEntityService) DeleteStoredProcedureEntityByFqn(fqn string) error {
-	err := s.StoredProcedureEntityRepository.DeleteStoredProcedureEntityByFqn(fqn)
+func (s *StoredProcedureEntityService) RestoreStoredProcedureEntity(id string, userName string) (*dataModels.StoredProcedureEntity, error) {
+	exist, err := s.StoredProcedureEntityRepository.SelectStoredProcedureEntityById(id, "all")
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !exist.Deleted {
+		return nil, baseModels.ErrEntityNotDeleted
+	}
+
+	// A stored procedure cannot be restored under a deleted database schema
+	databaseSchema, err := s.DatabaseSchemaEntityRepository.SelectDatabaseSchemaEntityById(exist.Json.DatabaseSchema.ID, "all")
+
+	if err != nil {
+		return nil, err
+	}
+
+	if databaseSchema.Deleted {
+		return nil, baseModels.ErrParentEntityDeleted
+	}
+
+	exist.Deleted = false
+	exist.Json.Deleted = false
+	exist.UpdatedAt = time.Now().Unix()
+	exist.UpdatedBy = userName
+
+	storedProcedureEntity, err := s.updateStoredProcedureEntity(exist, eventsModels.EntityUpdated)
+	return storedProcedureEntity, err
+}
+
+// PatchStoredProcedureEntity applies a JSON patch to the entity, immutable fields cannot be patched
+func (s *StoredProcedureEntityService) PatchStoredProcedureEntity(exist *dataModels.StoredProcedureEntity, payload []baseModels.JsonPatchOperation, ifMatch string, userName string) (*dataModels.StoredProcedureEntity, error) {
+	if err := baseModels.CheckIfMatch(ifMatch, exist.Json.Version); err != nil {
+		return nil, err
+	}
+
+	if err := baseModels.ValidateJsonPatch(payload, baseModels.ImmutableFields); err != nil {
+		return nil, err
+	}
+
+	var storedProcedure dataModels.StoredProcedure
+
+	if err := baseUtils.ApplyJsonPatch(exist.Json, payload, &storedProcedure); err != nil {
+		return nil, err
+	}
+
+	owners, err := s.OwnershipService.ResolveOwners(storedProcedure.Owners)
+
+	if err != nil {
+		return nil, err
+	}
+
+	storedProcedure.Owners = owners
+
+	exist.Json = &storedProcedure
+	exist.UpdatedAt = time.Now().Unix()
+	exist.UpdatedBy = userName
+
+	storedProcedureEntity, err := s.updateStoredProcedureEntity(exist, eventsModels.EntityUpdated)
+	return storedProcedureEntity, err
+}
+
+func (s *StoredProcedureEntityService) GetStoredProcedureEntityVersions(id string) (*typeModels.EntityHistory, error) {
+	storedProcedureEntity, err := s.StoredProcedureEntityRepository.SelectStoredProcedureEntityById(id, "all")
+
+	if err != nil {
+		return nil, err
+	}
+
+	entityExtensions, err := s.EntityExtensionRepository.SelectEntityVersions(id, "storedProcedure")
+
+	if err != nil {
+		return nil, err
+	}
+
+	entityHistory := &typeModels.EntityHistory{ EntityType: "storedProcedure", Versions: []interface{}{ storedProcedureEntity.Json } }
+
+	for _, e := range entityExtensions {
+		entityHistory.Versions = append(entityHistory.Versions, e.Json)
+	}
+
+	return entityHistory, nil
+}
+
+func (s *StoredProcedureEntityService) GetStoredProcedureEntityVersion(id string, version float64) (interface{}, error) {
+	storedProcedureEntity, err := s.StoredProcedureEntityRepository.SelectStoredProcedureEntityById(id, "all")
+
+	if err != nil {
+		return nil, err
+	}
+
+	if storedProcedureEntity.Json.Version == version {
+		return storedProcedureEntity.Json, nil
+	}
+
+	entityExtension, err := s.EntityExtensionRepository.SelectEntityExtension(id, typeModels.VersionExtension("storedProcedure", version))
+
+	if err != nil {
+		return nil, err
+	}
+
+	return entityExtension.Json, nil
+}
+
+// updateStoredProcedureEntity writes a changed stored procedure and replaces the lineage read from its code
+func (s *StoredProcedureEntityService) updateStoredProcedureEntity(exist *dataModels.StoredProcedureEntity, eventType string) (*dataModels.StoredProcedureEntity, error) {
+	var storedProcedureEntity *dataModels.StoredProcedureEntity
+
+	err := s.Transactor.RunInTx(func(tx *sqlx.Tx) error {
+		previous, err := s.StoredProcedureEntityRepository.WithTx(tx).SelectStoredProcedureEntityById(exist.ID, "all")
+
+		if err != nil {
+			return err
+		}
+
+		// exist was read before a concurrent update changed the stored version
+		if previous.Json.Version != exist.Json.Version {
+			return baseModels.ErrPreconditionFailed
+		}
+
+		changeDescription, err := s.Versioning.NextVersion(tx, "storedProcedure", previous.ID, previous.Json, exist.Json, exist.UpdatedAt, exist.UpdatedBy)
+
+		if err != nil {
+			return err
+		}
+
+		if changeDescription == nil {
+			storedProcedureEntity = previous
+			return nil
+		}
+
+		storedProcedureEntity, err = s.StoredProcedureEntityRepository.WithTx(tx).UpdateStoredProcedureEntity(exist, previous.Json.Version)
+
+		if errors.Is(err, sql.ErrNoRows) {
+			return baseModels.ErrPreconditionFailed
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if err := s.OwnershipService.SetOwners(tx, storedProcedureEntity.ID, "storedProcedure", storedProcedureEntity.Json.Owners); err != nil {
+			return err
+		}
+
+		if err := s.recordStoredProcedureLineage(tx, storedProcedureEntity); err != nil {
+			return err
+		}
+
+		return s.Versioning.RecordChangeEvent(tx, eventType, storedProcedureEntity.Json, changeDescription, storedProcedureEntity.UpdatedBy, storedProcedureEntity.UpdatedAt)
+	})
+
+	if err == nil {
+		searchServices.IndexEntity(s.SearchIndexer, "storedProcedure", storedProcedureEntity.ID, storedProcedureEntity.Json)
+	}
+
+	return storedProcedureEntity, err
+}
+
+// insertStoredProcedureEntity creates the stored procedure and records an entityCreated event in the same transaction
+func (s *StoredProcedureEntityService) insertStoredProcedureEntity(entity *dataModels.StoredProcedureEntity) (*dataModels.StoredProcedureEntity, error) {
+	var storedProcedureEntity *dataModels.StoredProcedureEntity
+
+	err := s.Transactor.RunInTx(func(tx *sqlx.Tx) error {
+		var err error
+		storedProcedureEntity, err = s.StoredProcedureEntityRepository.WithTx(tx).InsertStoredProcedureEntity(entity)
+
+		if err != nil {
+			return err
+		}
+
+		if err := s.OwnershipService.SetOwners(tx, storedProcedureEntity.ID, "storedProcedure", storedProcedureEntity.Json.Owners); err != nil {
+			return err
+		}
+
+		if err := s.recordStoredProcedureLineage(tx, storedProcedureEntity); err != nil {
+			return err
+		}
+
+		return s.Versioning.RecordChangeEvent(tx, eventsModels.EntityCreated, storedProcedureEntity.Json, nil, storedProcedureEntity.UpdatedBy, storedProcedureEntity.UpdatedAt)
+	})
+
+	if err == nil {
+		searchServices.IndexEntity(s.SearchIndexer, "storedProcedure", storedProcedureEntity.ID, storedProcedureEntity.Json)
+	}
+
+	return storedProcedureEntity, err
+}
+
+// inheritOwners fills the owners of a stored procedure without owners with the ones of its schema, database or service
+func (s *StoredProcedureEntityService) inheritOwners(storedProcedureEntity *dataModels.StoredProcedureEntity) error {
+	owners, err := s.OwnershipService.InheritOwners("storedProcedure", storedProcedureEntity.ID, storedProcedureEntity.Json.Owners)
+	storedProcedureEntity.Json.Owners = owners
 	return err
 }
@@ -0,0 +1,126 @@
+package services
+
+import (
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+	dataModels "github.com/nambuitechx/go-metadata/models/data"
+	lineageModels "github.com/nambuitechx/go-metadata/models/lineage"
+	typeModels "github.com/nambuitechx/go-metadata/models/type"
+	dataRepositories "github.com/nambuitechx/go-metadata/repositories/data"
+	"github.com/nambuitechx/go-metadata/utils/sqllineage"
+)
+
+// recordStoredProcedureLineage replaces the lineage edges derived from the code of the stored procedure. Tables are
+// resolved within the service, database and schema of the procedure, names that do not match a table are skipped
+func (s *StoredProcedureEntityService) recordStoredProcedureLineage(tx *sqlx.Tx, storedProcedureEntity *dataModels.StoredProcedureEntity) error {
+	lineageRepository := s.LineageRepository.WithTx(tx)
+
+	if err := lineageRepository.DeleteLineageEdgesByPipeline(storedProcedureEntity.ID, lineageModels.QueryLineage); err != nil {
+		return err
+	}
+
+	storedProcedure := storedProcedureEntity.Json
+
+	if storedProcedure.Deleted || storedProcedure.StoredProcedureCode == nil || storedProcedure.StoredProcedureCode.Code == "" {
+		return nil
+	}
+
+	lineages := sqllineage.Parse(storedProcedure.StoredProcedureCode.Code, sqllineage.DialectFromServiceType(storedProcedure.ServiceType))
+
+	for _, lineage := range lineages {
+		target, err := resolveTableEntity(s.TableEntityRepository.WithTx(tx), storedProcedure.Service, storedProcedure.Database, storedProcedure.DatabaseSchema, lineage.Target)
+
+		if err != nil {
+			return err
+		}
+
+		if target == nil {
+			continue
+		}
+
+		for _, sourceName := range lineage.Sources {
+			source, err := resolveTableEntity(s.TableEntityRepository.WithTx(tx), storedProcedure.Service, storedProcedure.Database, storedProcedure.DatabaseSchema, sourceName)
+
+			if err != nil {
+				return err
+			}
+
+			if source == nil || source.ID == target.ID {
+				continue
+			}
+
+			lineageDetails := &lineageModels.LineageDetails{
+				Pipeline: storedProcedure.ToEntityReference(),
+				SqlQuery: lineage.Query,
+				Source: lineageModels.QueryLineage,
+				CreatedAt: storedProcedureEntity.UpdatedAt,
+				CreatedBy: storedProcedureEntity.UpdatedBy,
+			}
+
+			if _, err := lineageRepository.InsertLineageEdge(source.ID, target.ID, lineageDetails); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// resolveTableEntity completes a table name of one, two or three parts with the schema, database or service it is
+// read from. Unquoted parts match in any case and an exact match wins, a nil table is returned when there is no such
+// table or the name matches several
+func resolveTableEntity(
+	tableEntityRepository *dataRepositories.TableEntityRepository,
+	service *typeModels.EntityReference,
+	database *typeModels.EntityReference,
+	databaseSchema *typeModels.EntityReference,
+	name sqllineage.TableName,
+) (*dataModels.TableEntity, error) {
+	var prefix string
+
+	switch len(name) {
+	case 1:
+		prefix = databaseSchema.FullyQualifiedName
+	case 2:
+		prefix = database.FullyQualifiedName
+	case 3:
+		prefix = service.FullyQualifiedName
+	default:
+		return nil, nil
+	}
+
+	tableEntities, err := tableEntityRepository.SelectTableEntitiesByFqnIgnoreCase(prefix + "." + name.String(), "non-deleted")
+
+	if err != nil {
+		return nil, err
+	}
+
+	return matchTableEntity(tableEntities, prefix, name), nil
+}
+
+// matchTableEntity picks the table named name under prefix among tables whose names differ in case only
+func matchTableEntity(tableEntities []dataModels.TableEntity, prefix string, name sqllineage.TableName) *dataModels.TableEntity {
+	fqn := prefix + "." + name.String()
+	var match *dataModels.TableEntity
+	matches := 0
+
+	for i := range tableEntities {
+		stored := tableEntities[i].Json.FullyQualifiedName
+
+		if stored == fqn {
+			return &tableEntities[i]
+		}
+
+		if strings.HasPrefix(stored, prefix + ".") && name.Matches(stored[len(prefix) + 1:]) {
+			match = &tableEntities[i]
+			matches++
+		}
+	}
+
+	if matches != 1 {
+		return nil
+	}
+
+	return match
+}
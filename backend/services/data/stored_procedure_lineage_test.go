@@ -0,0 +1,82 @@
+package services
+
+import (
+	"testing"
+
+	dataModels "github.com/nambuitechx/go-metadata/models/data"
+	"github.com/nambuitechx/go-metadata/utils/sqllineage"
+)
+
+func TestMatchTableEntity(t *testing.T) {
+	tableEntity := func(fqn string) dataModels.TableEntity {
+		return dataModels.TableEntity{ ID: fqn, Json: &dataModels.Table{ FullyQualifiedName: fqn } }
+	}
+
+	unquoted := sqllineage.TableName{ { Value: "sales" }, { Value: "orders" } }
+	quoted := sqllineage.TableName{ { Value: "Sales", Quoted: true }, { Value: "Orders", Quoted: true } }
+
+	tests := []struct {
+		name string
+		tables []string
+		tableName sqllineage.TableName
+		expected string
+	}{
+		{
+			name: "unquoted name in another case",
+			tables: []string{"pg.shop.Sales.Orders"},
+			tableName: unquoted,
+			expected: "pg.shop.Sales.Orders",
+		},
+		{
+			name: "exact match wins",
+			tables: []string{"pg.shop.Sales.Orders", "pg.shop.sales.orders"},
+			tableName: unquoted,
+			expected: "pg.shop.sales.orders",
+		},
+		{
+			name: "ambiguous name",
+			tables: []string{"pg.shop.Sales.Orders", "pg.shop.SALES.ORDERS"},
+			tableName: unquoted,
+			expected: "",
+		},
+		{
+			name: "quoted name matches exactly",
+			tables: []string{"pg.shop.sales.orders"},
+			tableName: quoted,
+			expected: "",
+		},
+		{
+			name: "quoted name",
+			tables: []string{"pg.shop.sales.orders", "pg.shop.Sales.Orders"},
+			tableName: quoted,
+			expected: "pg.shop.Sales.Orders",
+		},
+		{
+			name: "prefix in another case",
+			tables: []string{"PG.shop.sales.orders"},
+			tableName: unquoted,
+			expected: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tableEntities := []dataModels.TableEntity{}
+
+			for _, fqn := range tt.tables {
+				tableEntities = append(tableEntities, tableEntity(fqn))
+			}
+
+			match := matchTableEntity(tableEntities, "pg.shop", tt.tableName)
+			id := ""
+
+			if match != nil {
+				id = match.ID
+			}
+
+			if id != tt.expected {
+				t.Errorf("matchTableEntity(%v, %v) = %q, expected %q", tt.tables, tt.tableName, id, tt.expected)
+			}
+		})
+	}
+}
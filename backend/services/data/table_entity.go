@@ -1,36 +1,67 @@
 package services
 
 import (
+	"database/sql"
 	"errors"
 	"fmt"
 	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
 	baseModels "github.com/nambuitechx/go-metadata/models/base"
 	dataModels "github.com/nambuitechx/go-metadata/models/data"
 	servicesRepositories "github.com/nambuitechx/go-metadata/repositories/services"
 	dataRepositories "github.com/nambuitechx/go-metadata/repositories/data"
+	typeModels "github.com/nambuitechx/go-metadata/models/type"
+	typeRepositories "github.com/nambuitechx/go-metadata/repositories/type"
+	eventsModels "github.com/nambuitechx/go-metadata/models/events"
+	eventsRepositories "github.com/nambuitechx/go-metadata/repositories/events"
+	lineageRepositories "github.com/nambuitechx/go-metadata/repositories/lineage"
+	searchServices "github.com/nambuitechx/go-metadata/services/search"
+	teamsServices "github.com/nambuitechx/go-metadata/services/teams"
+	baseRepositories "github.com/nambuitechx/go-metadata/repositories/base"
+	baseUtils "github.com/nambuitechx/go-metadata/utils"
 )
 
 type TableEntityService struct {
+	Transactor *baseRepositories.Transactor
 	DBServiceEntityRepository *servicesRepositories.DBServiceEntityRepository
 	DatabaseEntityRepository *dataRepositories.DatabaseEntityRepository
 	DatabaseSchemaEntityRepository *dataRepositories.DatabaseSchemaEntityRepository
 	TableEntityRepository *dataRepositories.TableEntityRepository
+	EntityExtensionRepository *typeRepositories.EntityExtensionRepository
+	ChangeEventRepository *eventsRepositories.ChangeEventRepository
+	Versioning *Versioning
+	LineageRepository *lineageRepositories.LineageRepository
+	SearchIndexer searchServices.SearchIndexer
+	OwnershipService *teamsServices.OwnershipService
 }
 
 func NewTableEntityService(
+	transactor *baseRepositories.Transactor,
 	dbserviceEntityRepository *servicesRepositories.DBServiceEntityRepository,
 	databaseEntityRepository *dataRepositories.DatabaseEntityRepository,
 	databaseSchemaEntityRepository *dataRepositories.DatabaseSchemaEntityRepository,
 	tableEntityRepository *dataRepositories.TableEntityRepository,
+	entityExtensionRepository *typeRepositories.EntityExtensionRepository,
+	changeEventRepository *eventsRepositories.ChangeEventRepository,
+	lineageRepository *lineageRepositories.LineageRepository,
+	searchIndexer searchServices.SearchIndexer,
+	ownershipService *teamsServices.OwnershipService,
 ) *TableEntityService {
 	return &TableEntityService{
+		Transactor: transactor,
 		DBServiceEntityRepository: dbserviceEntityRepository,
 		DatabaseEntityRepository: databaseEntityRepository,
 		DatabaseSchemaEntityRepository: databaseSchemaEntityRepository,
 		TableEntityRepository: tableEntityRepository,
+		EntityExtensionRepository: entityExtensionRepository,
+		ChangeEventRepository: changeEventRepository,
+		Versioning: NewVersioning(entityExtensionRepository, changeEventRepository),
+		LineageRepository: lineageRepository,
+		SearchIndexer: searchIndexer,
+		OwnershipService: ownershipService,
 	}
 }
 
@@ -38,27 +69,80 @@ func (s *TableEntityService) Health() string {
 	return "Table service is available"
 }
 
-func (s *TableEntityService) GetAllTableEntities(databaseSchema string, limit int, offset int) ([]dataModels.TableEntity, error) {
-	tableEntity, err := s.TableEntityRepository.SelectTableEntities(databaseSchema, limit, offset)
-	return tableEntity, err
+// GetAllTableEntities lists the tables of a schema, all of them when databaseSchema is empty. owner is the name of a
+// user or team, it matches the tables it owns directly or through their schema, database or service
+func (s *TableEntityService) GetAllTableEntities(databaseSchema string, owner string, include string, limit int, before *baseModels.Cursor, after *baseModels.Cursor) ([]dataModels.TableEntity, *baseModels.Paging, error) {
+	ownerId, err := s.getOwnerId(owner)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tableEntity, err := s.TableEntityRepository.SelectTableEntities(databaseSchema, ownerId, include, limit, before, after)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for i := range tableEntity {
+		if err := s.inheritOwners(&tableEntity[i]); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	tableEntity, paging := baseModels.NewPaging(tableEntity, limit, before, after, func(e dataModels.TableEntity) *baseModels.Cursor {
+		return &baseModels.Cursor{ Name: e.Json.FullyQualifiedName, ID: e.ID }
+	})
+
+	return tableEntity, paging, nil
 }
 
-func (s *TableEntityService) GetCountTableEntities(databaseSchema string) (*baseModels.EntityTotal, error) {
-	entityTotal, err := s.TableEntityRepository.SelectCountTableEntities(databaseSchema)
+func (s *TableEntityService) GetCountTableEntities(databaseSchema string, owner string, include string) (*baseModels.EntityTotal, error) {
+	ownerId, err := s.getOwnerId(owner)
+
+	if err != nil {
+		return nil, err
+	}
+
+	entityTotal, err := s.TableEntityRepository.SelectCountTableEntities(databaseSchema, ownerId, include)
 	return entityTotal, err
 }
 
-func (s *TableEntityService) GetTableEntityById(id string) (*dataModels.TableEntity, error) {
-	tableEntity, err := s.TableEntityRepository.SelectTableEntityById(id)
-	return tableEntity, err
+func (s *TableEntityService) getOwnerId(owner string) (string, error) {
+	if owner == "" {
+		return "", nil
+	}
+
+	return s.OwnershipService.GetOwnerId(owner)
 }
 
-func (s *TableEntityService) GetTableEntityByFqn(fqn string) (*dataModels.TableEntity, error) {
-	tableEntity, err := s.TableEntityRepository.SelectTableEntityByFqn(fqn)
-	return tableEntity, err
+func (s *TableEntityService) GetTableEntityById(id string, include string) (*dataModels.TableEntity, error) {
+	tableEntity, err := s.TableEntityRepository.SelectTableEntityById(id, include)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return tableEntity, s.inheritOwners(tableEntity)
+}
+
+func (s *TableEntityService) GetTableEntityByFqn(fqn string, include string) (*dataModels.TableEntity, error) {
+	tableEntity, err := s.TableEntityRepository.SelectTableEntityByFqn(fqn, include)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return tableEntity, s.inheritOwners(tableEntity)
 }
 
-func (s *TableEntityService) CreateTableEntity(payload *dataModels.CreateTableEntityPayload) (*dataModels.TableEntity, error) {
+func (s *TableEntityService) CreateTableEntity(payload *dataModels.CreateTableEntityPayload, userName string) (*dataModels.TableEntity, error) {
+	owners, err := s.OwnershipService.ResolveOwners(payload.Owners)
+
+	if err != nil {
+		return nil, err
+	}
+
 	id := uuid.NewString()
 	now := time.Now().Unix()
 
@@ -70,7 +154,7 @@ func (s *TableEntityService) CreateTableEntity(payload *dataModels.CreateTableEn
 	}
 
 	// Get dbservice
-	dbservice, err := s.DBServiceEntityRepository.SelectDBServiceEntityByFqn(arr[0])
+	dbservice, err := s.DBServiceEntityRepository.SelectDBServiceEntityByFqn(arr[0], "non-deleted")
 
 	if err != nil {
 		return nil, err
@@ -79,7 +163,7 @@ func (s *TableEntityService) CreateTableEntity(payload *dataModels.CreateTableEn
 	dbserviceEntityRef := dbservice.Json.ToEntityReference()
 
 	// Get database
-	database, err := s.DatabaseEntityRepository.SelectDatabaseEntityByFqn(fmt.Sprintf("%v.%v", arr[0], arr[1]))
+	database, err := s.DatabaseEntityRepository.SelectDatabaseEntityByFqn(fmt.Sprintf("%v.%v", arr[0], arr[1]), "non-deleted")
 
 	if err != nil {
 		return nil, err
@@ -88,7 +172,7 @@ func (s *TableEntityService) CreateTableEntity(payload *dataModels.CreateTableEn
 	databaseEntityRef := database.Json.ToEntityReference()
 
 	// Get database schema
-	databaseSchema, err := s.DatabaseSchemaEntityRepository.SelectDatabaseSchemaEntityByFqn(fmt.Sprintf("%v.%v.%v", arr[0], arr[1], arr[2]))
+	databaseSchema, err := s.DatabaseSchemaEntityRepository.SelectDatabaseSchemaEntityByFqn(fmt.Sprintf("%v.%v.%v", arr[0], arr[1], arr[2]), "non-deleted")
 
 	if err != nil {
 		return nil, err
@@ -109,7 +193,12 @@ func (s *TableEntityService) CreateTableEntity(payload *dataModels.CreateTableEn
 		DatabaseSchema: databaseSchemaEntityRef,
 		TableType: payload.TableType,
 		TableConstraints: payload.TableConstraints,
+		ViewDefinition: payload.ViewDefinition,
 		Columns: payload.Columns,
+		Owners: owners,
+		Version: typeModels.InitialVersion,
+		UpdatedAt: now,
+		UpdatedBy: userName,
 		Deleted: false,
 	}
 
@@ -118,18 +207,47 @@ func (s *TableEntityService) CreateTableEntity(payload *dataModels.CreateTableEn
 		Name: payload.Name,
 		Json: table,
 		UpdatedAt: now,
+		UpdatedBy: userName,
 		Deleted: false,
 	}
 
-	tableEntity, err := s.TableEntityRepository.InsertTableEntity(entity)
+	tableEntity, err := s.insertTableEntity(entity)
 	return tableEntity, err
 }
 
-func (s *TableEntityService) CreateOrUpdateTableEntity(payload *dataModels.CreateTableEntityPayload) (*dataModels.TableEntity, error) {
-	exist, err := s.TableEntityRepository.SelectTableEntityByFqn(fmt.Sprintf("%v.%v", payload.DatabaseSchema, payload.Name))
+func (s *TableEntityService) CreateOrUpdateTableEntity(payload *dataModels.CreateTableEntityPayload, ifMatch string, userName string) (*dataModels.TableEntity, error) {
+	owners, err := s.OwnershipService.ResolveOwners(payload.Owners)
+
+	if err != nil {
+		return nil, err
+	}
+
+	exist, err := s.TableEntityRepository.SelectTableEntityByFqn(fmt.Sprintf("%v.%v", payload.DatabaseSchema, payload.Name), "all")
 
 	if err == nil {
-		updated, err := s.TableEntityRepository.UpdateTableEntity(exist)
+		if exist.Deleted {
+			return nil, baseModels.ErrEntityDeleted
+		}
+
+		if err := baseModels.CheckIfMatch(ifMatch, exist.Json.Version); err != nil {
+			return nil, err
+		}
+
+		exist.Json.DisplayName = baseUtils.MergeString(exist.Json.DisplayName, payload.DisplayName)
+		exist.Json.Description = baseUtils.MergeString(exist.Json.Description, payload.Description)
+		exist.Json.TableType = payload.TableType
+		exist.Json.TableConstraints = payload.TableConstraints
+		exist.Json.ViewDefinition = baseUtils.MergeString(exist.Json.ViewDefinition, payload.ViewDefinition)
+		exist.Json.Columns = dataModels.MergeColumns(exist.Json.Columns, payload.Columns)
+
+		if payload.Owners != nil {
+			exist.Json.Owners = owners
+		}
+
+		exist.UpdatedAt = time.Now().Unix()
+		exist.UpdatedBy = userName
+
+		updated, err := s.updateTableEntity(exist, eventsModels.EntityUpdated)
 		return updated, err
 	}
 
@@ -144,7 +262,7 @@ func (s *TableEntityService) CreateOrUpdateTableEntity(payload *dataModels.Creat
 	}
 
 	// Get dbservice
-	dbservice, err := s.DBServiceEntityRepository.SelectDBServiceEntityByFqn(arr[0])
+	dbservice, err := s.DBServiceEntityRepository.SelectDBServiceEntityByFqn(arr[0], "non-deleted")
 
 	if err != nil {
 		return nil, err
@@ -153,7 +271,7 @@ func (s *TableEntityService) CreateOrUpdateTableEntity(payload *dataModels.Creat
 	dbserviceEntityRef := dbservice.Json.ToEntityReference()
 
 	// Get database
-	database, err := s.DatabaseEntityRepository.SelectDatabaseEntityByFqn(fmt.Sprintf("%v.%v", arr[0], arr[1]))
+	database, err := s.DatabaseEntityRepository.SelectDatabaseEntityByFqn(fmt.Sprintf("%v.%v", arr[0], arr[1]), "non-deleted")
 
 	if err != nil {
 		return nil, err
@@ -162,7 +280,7 @@ func (s *TableEntityService) CreateOrUpdateTableEntity(payload *dataModels.Creat
 	databaseEntityRef := database.Json.ToEntityReference()
 
 	// Get database schema
-	databaseSchema, err := s.DatabaseSchemaEntityRepository.SelectDatabaseSchemaEntityByFqn(fmt.Sprintf("%v.%v.%v", arr[0], arr[1], arr[2]))
+	databaseSchema, err := s.DatabaseSchemaEntityRepository.SelectDatabaseSchemaEntityByFqn(fmt.Sprintf("%v.%v.%v", arr[0], arr[1], arr[2]), "non-deleted")
 
 	if err != nil {
 		return nil, err
@@ -183,7 +301,12 @@ func (s *TableEntityService) CreateOrUpdateTableEntity(payload *dataModels.Creat
 		DatabaseSchema: databaseSchemaEntityRef,
 		TableType: payload.TableType,
 		TableConstraints: payload.TableConstraints,
+		ViewDefinition: payload.ViewDefinition,
 		Columns: payload.Columns,
+		Owners: owners,
+		Version: typeModels.InitialVersion,
+		UpdatedAt: now,
+		UpdatedBy: userName,
 		Deleted: false,
 	}
 
@@ -192,19 +315,259 @@ func (s *TableEntityService) CreateOrUpdateTableEntity(payload *dataModels.Creat
 		Name: payload.Name,
 		Json: table,
 		UpdatedAt: now,
+		UpdatedBy: userName,
 		Deleted: false,
 	}
 
-	tableEntity, err := s.TableEntityRepository.InsertTableEntity(entity)
+	tableEntity, err := s.insertTableEntity(entity)
 	return tableEntity, err
 }
 
-func (s *TableEntityService) DeleteTableEntityById(id string) error {
-	err := s.TableEntityRepository.DeleteTableEntityById(id)
+func (s *TableEntityService) DeleteTableEntityById(id string, hardDelete bool, userName string) error {
+	exist, err := s.TableEntityRepository.SelectTableEntityById(id, "all")
+
+	if err != nil {
+		return err
+	}
+
+	return s.deleteTableEntity(exist, hardDelete, userName)
+}
+
+func (s *TableEntityService) DeleteTableEntityByFqn(fqn string, hardDelete bool, userName string) error {
+	exist, err := s.TableEntityRepository.SelectTableEntityByFqn(fqn, "all")
+
+	if err != nil {
+		return err
+	}
+
+	return s.deleteTableEntity(exist, hardDelete, userName)
+}
+
+func (s *TableEntityService) deleteTableEntity(exist *dataModels.TableEntity, hardDelete bool, userName string) error {
+	if hardDelete {
+		err := s.Transactor.RunInTx(func(tx *sqlx.Tx) error {
+			if err := s.TableEntityRepository.WithTx(tx).DeleteTableEntityById(exist.ID); err != nil {
+				return err
+			}
+
+			exist.UpdatedAt = time.Now().Unix()
+			exist.UpdatedBy = userName
+			return s.Versioning.RecordChangeEvent(tx, eventsModels.EntityDeleted, exist.Json, nil, exist.UpdatedBy, exist.UpdatedAt)
+		})
+
+		if err == nil {
+			searchServices.DeleteEntity(s.SearchIndexer, "table", exist.ID, exist.Json.FullyQualifiedName)
+		}
+
+		return err
+	}
+
+	exist.Deleted = true
+	exist.Json.Deleted = true
+	exist.UpdatedAt = time.Now().Unix()
+	exist.UpdatedBy = userName
+
+	_, err := s.updateTableEntity(exist, eventsModels.EntitySoftDeleted)
 	return err
 }
 
-func (s *TableEntityService) DeleteTableEntityByFqn(fqn string) error {
-	err := s.TableEntityRepository.DeleteTableEntityByFqn(fqn)
+func (s *TableEntityService) RestoreTableEntity(id string, userName string) (*dataModels.TableEntity, error) {
+	exist, err := s.TableEntityRepository.SelectTableEntityById(id, "all")
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !exist.Deleted {
+		return nil, baseModels.ErrEntityNotDeleted
+	}
+
+	// A table cannot be restored under a deleted database schema
+	databaseSchema, err := s.DatabaseSchemaEntityRepository.SelectDatabaseSchemaEntityById(exist.Json.DatabaseSchema.ID, "all")
+
+	if err != nil {
+		return nil, err
+	}
+
+	if databaseSchema.Deleted {
+		return nil, baseModels.ErrParentEntityDeleted
+	}
+
+	exist.Deleted = false
+	exist.Json.Deleted = false
+	exist.UpdatedAt = time.Now().Unix()
+	exist.UpdatedBy = userName
+
+	tableEntity, err := s.updateTableEntity(exist, eventsModels.EntityUpdated)
+	return tableEntity, err
+}
+
+// PatchTableEntity applies a JSON patch to the entity, immutable fields cannot be patched
+func (s *TableEntityService) PatchTableEntity(exist *dataModels.TableEntity, payload []baseModels.JsonPatchOperation, ifMatch string, userName string) (*dataModels.TableEntity, error) {
+	if err := baseModels.CheckIfMatch(ifMatch, exist.Json.Version); err != nil {
+		return nil, err
+	}
+
+	if err := baseModels.ValidateJsonPatch(payload, baseModels.ImmutableFields); err != nil {
+		return nil, err
+	}
+
+	var table dataModels.Table
+
+	if err := baseUtils.ApplyJsonPatch(exist.Json, payload, &table); err != nil {
+		return nil, err
+	}
+
+	if err := dataModels.ValidateTable(&table); err != nil {
+		return nil, err
+	}
+
+	owners, err := s.OwnershipService.ResolveOwners(table.Owners)
+
+	if err != nil {
+		return nil, err
+	}
+
+	table.Owners = owners
+
+	exist.Json = &table
+	exist.UpdatedAt = time.Now().Unix()
+	exist.UpdatedBy = userName
+
+	tableEntity, err := s.updateTableEntity(exist, eventsModels.EntityUpdated)
+	return tableEntity, err
+}
+
+func (s *TableEntityService) GetTableEntityVersions(id string) (*typeModels.EntityHistory, error) {
+	tableEntity, err := s.TableEntityRepository.SelectTableEntityById(id, "all")
+
+	if err != nil {
+		return nil, err
+	}
+
+	entityExtensions, err := s.EntityExtensionRepository.SelectEntityVersions(id, "table")
+
+	if err != nil {
+		return nil, err
+	}
+
+	entityHistory := &typeModels.EntityHistory{ EntityType: "table", Versions: []interface{}{ tableEntity.Json } }
+
+	for _, e := range entityExtensions {
+		entityHistory.Versions = append(entityHistory.Versions, e.Json)
+	}
+
+	return entityHistory, nil
+}
+
+func (s *TableEntityService) GetTableEntityVersion(id string, version float64) (interface{}, error) {
+	tableEntity, err := s.TableEntityRepository.SelectTableEntityById(id, "all")
+
+	if err != nil {
+		return nil, err
+	}
+
+	if tableEntity.Json.Version == version {
+		return tableEntity.Json, nil
+	}
+
+	entityExtension, err := s.EntityExtensionRepository.SelectEntityExtension(id, typeModels.VersionExtension("table", version))
+
+	if err != nil {
+		return nil, err
+	}
+
+	return entityExtension.Json, nil
+}
+
+// updateTableEntity writes a changed table and replaces the lineage read from its view definition
+func (s *TableEntityService) updateTableEntity(exist *dataModels.TableEntity, eventType string) (*dataModels.TableEntity, error) {
+	var tableEntity *dataModels.TableEntity
+
+	err := s.Transactor.RunInTx(func(tx *sqlx.Tx) error {
+		previous, err := s.TableEntityRepository.WithTx(tx).SelectTableEntityById(exist.ID, "all")
+
+		if err != nil {
+			return err
+		}
+
+		// exist was read before a concurrent update changed the stored version
+		if previous.Json.Version != exist.Json.Version {
+			return baseModels.ErrPreconditionFailed
+		}
+
+		changeDescription, err := s.Versioning.NextVersion(tx, "table", previous.ID, previous.Json, exist.Json, exist.UpdatedAt, exist.UpdatedBy)
+
+		if err != nil {
+			return err
+		}
+
+		if changeDescription == nil {
+			tableEntity = previous
+			return nil
+		}
+
+		tableEntity, err = s.TableEntityRepository.WithTx(tx).UpdateTableEntity(exist, previous.Json.Version)
+
+		if errors.Is(err, sql.ErrNoRows) {
+			return baseModels.ErrPreconditionFailed
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if err := s.OwnershipService.SetOwners(tx, tableEntity.ID, "table", tableEntity.Json.Owners); err != nil {
+			return err
+		}
+
+		if err := s.recordViewLineage(tx, tableEntity); err != nil {
+			return err
+		}
+
+		return s.Versioning.RecordChangeEvent(tx, eventType, tableEntity.Json, changeDescription, tableEntity.UpdatedBy, tableEntity.UpdatedAt)
+	})
+
+	if err == nil {
+		searchServices.IndexEntity(s.SearchIndexer, "table", tableEntity.ID, tableEntity.Json)
+	}
+
+	return tableEntity, err
+}
+
+// insertTableEntity creates the table and records an entityCreated event in the same transaction
+func (s *TableEntityService) insertTableEntity(entity *dataModels.TableEntity) (*dataModels.TableEntity, error) {
+	var tableEntity *dataModels.TableEntity
+
+	err := s.Transactor.RunInTx(func(tx *sqlx.Tx) error {
+		var err error
+		tableEntity, err = s.TableEntityRepository.WithTx(tx).InsertTableEntity(entity)
+
+		if err != nil {
+			return err
+		}
+
+		if err := s.OwnershipService.SetOwners(tx, tableEntity.ID, "table", tableEntity.Json.Owners); err != nil {
+			return err
+		}
+
+		if err := s.recordViewLineage(tx, tableEntity); err != nil {
+			return err
+		}
+
+		return s.Versioning.RecordChangeEvent(tx, eventsModels.EntityCreated, tableEntity.Json, nil, tableEntity.UpdatedBy, tableEntity.UpdatedAt)
+	})
+
+	if err == nil {
+		searchServices.IndexEntity(s.SearchIndexer, "table", tableEntity.ID, tableEntity.Json)
+	}
+
+	return tableEntity, err
+}
+
+// inheritOwners fills the owners of a table without owners with the ones of its schema, database or service
+func (s *TableEntityService) inheritOwners(tableEntity *dataModels.TableEntity) error {
+	owners, err := s.OwnershipService.InheritOwners("table", tableEntity.ID, tableEntity.Json.Owners)
+	tableEntity.Json.Owners = owners
 	return err
 }
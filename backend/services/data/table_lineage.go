@@ -0,0 +1,54 @@
+package services
+
+import (
+	"github.com/jmoiron/sqlx"
+	dataModels "github.com/nambuitechx/go-metadata/models/data"
+	lineageModels "github.com/nambuitechx/go-metadata/models/lineage"
+	"github.com/nambuitechx/go-metadata/utils/sqllineage"
+)
+
+// recordViewLineage replaces the lineage edges from the tables a view reads, parsed from its definition. Tables are
+// resolved within the service, database and schema of the view, names that do not match a table are skipped. The
+// edges of a deleted view are kept, so that restoring it restores its lineage
+func (s *TableEntityService) recordViewLineage(tx *sqlx.Tx, tableEntity *dataModels.TableEntity) error {
+	table := tableEntity.Json
+
+	if table.Deleted {
+		return nil
+	}
+
+	lineageRepository := s.LineageRepository.WithTx(tx)
+
+	if err := lineageRepository.DeleteUpstreamEdgesBySource(tableEntity.ID, lineageModels.ViewLineage); err != nil {
+		return err
+	}
+
+	if table.ViewDefinition == "" {
+		return nil
+	}
+
+	for _, sourceName := range sqllineage.ParseView(table.ViewDefinition, sqllineage.DialectFromServiceType(table.ServiceType)) {
+		source, err := resolveTableEntity(s.TableEntityRepository.WithTx(tx), table.Service, table.Database, table.DatabaseSchema, sourceName)
+
+		if err != nil {
+			return err
+		}
+
+		if source == nil || source.ID == tableEntity.ID {
+			continue
+		}
+
+		lineageDetails := &lineageModels.LineageDetails{
+			SqlQuery: table.ViewDefinition,
+			Source: lineageModels.ViewLineage,
+			CreatedAt: tableEntity.UpdatedAt,
+			CreatedBy: tableEntity.UpdatedBy,
+		}
+
+		if _, err := lineageRepository.InsertLineageEdge(source.ID, tableEntity.ID, lineageDetails); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,79 @@
+package services
+
+import (
+	"github.com/jmoiron/sqlx"
+	typeModels "github.com/nambuitechx/go-metadata/models/type"
+	typeRepositories "github.com/nambuitechx/go-metadata/repositories/type"
+	eventsModels "github.com/nambuitechx/go-metadata/models/events"
+	eventsRepositories "github.com/nambuitechx/go-metadata/repositories/events"
+	baseUtils "github.com/nambuitechx/go-metadata/utils"
+)
+
+// VersionedEntity is the json of an entity whose versions are kept
+type VersionedEntity interface {
+	ToEntityReference() *typeModels.EntityReference
+	GetVersion() float64
+	SetVersion(version float64, changeDescription *typeModels.ChangeDescription, updatedAt int64, updatedBy string)
+}
+
+// Versioning keeps the previous versions of the entities and records their change events
+type Versioning struct {
+	EntityExtensionRepository *typeRepositories.EntityExtensionRepository
+	ChangeEventRepository *eventsRepositories.ChangeEventRepository
+}
+
+func NewVersioning(
+	entityExtensionRepository *typeRepositories.EntityExtensionRepository,
+	changeEventRepository *eventsRepositories.ChangeEventRepository,
+) *Versioning {
+	return &Versioning{
+		EntityExtensionRepository: entityExtensionRepository,
+		ChangeEventRepository: changeEventRepository,
+	}
+}
+
+// NextVersion stores previous in entity_extension and moves updated to the next version, a columns change is a major
+// one. The change description is nil and nothing is stored when updated does not differ from previous
+func (v *Versioning) NextVersion(
+	tx *sqlx.Tx,
+	entityType string,
+	id string,
+	previous VersionedEntity,
+	updated VersionedEntity,
+	updatedAt int64,
+	updatedBy string,
+) (*typeModels.ChangeDescription, error) {
+	changeDescription, err := baseUtils.CompareEntities(previous, updated)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if changeDescription.IsEmpty() {
+		return nil, nil
+	}
+
+	version := previous.GetVersion()
+	extension := typeModels.VersionExtension(entityType, version)
+
+	if err := v.EntityExtensionRepository.WithTx(tx).InsertEntityExtension(id, extension, entityType, previous); err != nil {
+		return nil, err
+	}
+
+	changeDescription.PreviousVersion = version
+	updated.SetVersion(baseUtils.NextVersion(version, baseUtils.IsMajorChange(changeDescription)), changeDescription, updatedAt, updatedBy)
+	return changeDescription, nil
+}
+
+func (v *Versioning) RecordChangeEvent(
+	tx *sqlx.Tx,
+	eventType string,
+	entity VersionedEntity,
+	changeDescription *typeModels.ChangeDescription,
+	userName string,
+	updatedAt int64,
+) error {
+	changeEvent := eventsModels.NewChangeEventEntity(eventType, entity.ToEntityReference(), entity.GetVersion(), changeDescription, userName, updatedAt, entity)
+	_, err := v.ChangeEventRepository.WithTx(tx).InsertChangeEvent(changeEvent)
+	return err
+}
@@ -0,0 +1,70 @@
+package services
+
+import (
+	eventsModels "github.com/nambuitechx/go-metadata/models/events"
+	eventsRepositories "github.com/nambuitechx/go-metadata/repositories/events"
+)
+
+type ChangeEventService struct {
+	ChangeEventRepository *eventsRepositories.ChangeEventRepository
+	ChangeEventNotifier *ChangeEventNotifier
+}
+
+func NewChangeEventService(changeEventRepository *eventsRepositories.ChangeEventRepository, changeEventNotifier *ChangeEventNotifier) *ChangeEventService {
+	return &ChangeEventService{ ChangeEventRepository: changeEventRepository, ChangeEventNotifier: changeEventNotifier }
+}
+
+func (s *ChangeEventService) Health() string {
+	return "Change event service is available"
+}
+
+func (s *ChangeEventService) GetChangeEvents(filters map[string][]string, timestamp int64) ([]eventsModels.ChangeEventEntity, error) {
+	changeEvents, err := s.ChangeEventRepository.SelectChangeEvents(filters, timestamp)
+	return changeEvents, err
+}
+
+func (s *ChangeEventService) GetChangeEventsAfterOffset(offset int64, limit int) ([]eventsModels.ChangeEventEntity, error) {
+	changeEvents, err := s.ChangeEventRepository.SelectChangeEventsAfterOffset(offset, limit)
+	return changeEvents, err
+}
+
+func (s *ChangeEventService) GetLatestOffset() (int64, error) {
+	offset, err := s.ChangeEventRepository.SelectLatestOffset()
+	return offset, err
+}
+
+func (s *ChangeEventService) ListenChangeEvents() (<-chan struct{}, func()) {
+	return s.ChangeEventNotifier.Listen()
+}
+
+// ScrubChangeEventSecrets masks the secrets of the stored change events of an entity type, the events recorded before
+// they were masked on creation may carry them
+func ScrubChangeEventSecrets(changeEventRepository *eventsRepositories.ChangeEventRepository, entityType string) error {
+	changeEventEntities, err := changeEventRepository.SelectChangeEventsByEntityType(entityType)
+
+	if err != nil {
+		return err
+	}
+
+	for _, e := range changeEventEntities {
+		if e.Json == nil {
+			continue
+		}
+
+		masked, changed, err := eventsModels.MaskChangeEventSecrets(e.Json)
+
+		if err != nil {
+			return err
+		}
+
+		if !changed {
+			continue
+		}
+
+		if err := changeEventRepository.UpdateChangeEventJson(e.ID, masked); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,101 @@
+package services
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	eventsRepositories "github.com/nambuitechx/go-metadata/repositories/events"
+)
+
+const (
+	notifyInterval = time.Second
+	publishBatchSize = 1000
+)
+
+// ChangeEventNotifier publishes the committed change events, watches the latest change event offset and wakes up
+// every listener when new events are published, listeners then read the events they have not seen yet by offset
+type ChangeEventNotifier struct {
+	ChangeEventRepository *eventsRepositories.ChangeEventRepository
+
+	mu sync.Mutex
+	latestOffset int64
+	listeners map[chan struct{}]bool
+}
+
+func NewChangeEventNotifier(changeEventRepository *eventsRepositories.ChangeEventRepository) *ChangeEventNotifier {
+	return &ChangeEventNotifier{
+		ChangeEventRepository: changeEventRepository,
+		listeners: map[chan struct{}]bool{},
+	}
+}
+
+func (n *ChangeEventNotifier) Start() {
+	go func() {
+		ticker := time.NewTicker(notifyInterval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			n.poll()
+		}
+	}()
+}
+
+func (n *ChangeEventNotifier) poll() {
+	n.publish()
+
+	offset, err := n.ChangeEventRepository.SelectLatestOffset()
+
+	if err != nil {
+		log.Println("Failed to get latest change event offset:", err)
+		return
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if offset <= n.latestOffset {
+		return
+	}
+
+	n.latestOffset = offset
+
+	for listener := range n.listeners {
+		// A listener that has not consumed the previous signal reads everything on its next wake up
+		select {
+		case listener <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Listen returns a channel signaled on new change events and a function to stop listening
+func (n *ChangeEventNotifier) Listen() (<-chan struct{}, func()) {
+	listener := make(chan struct{}, 1)
+
+	n.mu.Lock()
+	n.listeners[listener] = true
+	n.mu.Unlock()
+
+	return listener, func() {
+		n.mu.Lock()
+		delete(n.listeners, listener)
+		n.mu.Unlock()
+	}
+}
+
+// publish gives offsets to the recorded events, the events of every server are published by whichever gets there first
+func (n *ChangeEventNotifier) publish() {
+	for {
+		published, err := n.ChangeEventRepository.PublishChangeEvents(publishBatchSize)
+
+		if err != nil {
+			log.Println("Failed to publish change events:", err)
+			return
+		}
+
+		if published < publishBatchSize {
+			return
+		}
+	}
+}
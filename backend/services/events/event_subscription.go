@@ -0,0 +1,286 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	"github.com/google/uuid"
+	baseModels "github.com/nambuitechx/go-metadata/models/base"
+	eventsModels "github.com/nambuitechx/go-metadata/models/events"
+	eventsRepositories "github.com/nambuitechx/go-metadata/repositories/events"
+	securityModels "github.com/nambuitechx/go-metadata/models/security"
+	securityServices "github.com/nambuitechx/go-metadata/services/security"
+)
+
+type EventSubscriptionService struct {
+	EventSubscriptionRepository *eventsRepositories.EventSubscriptionRepository
+	ChangeEventRepository *eventsRepositories.ChangeEventRepository
+	SecretsManager securityServices.SecretsManager
+}
+
+func NewEventSubscriptionService(
+	eventSubscriptionRepository *eventsRepositories.EventSubscriptionRepository,
+	changeEventRepository *eventsRepositories.ChangeEventRepository,
+	secretsManager securityServices.SecretsManager,
+) *EventSubscriptionService {
+	return &EventSubscriptionService{
+		EventSubscriptionRepository: eventSubscriptionRepository,
+		ChangeEventRepository: changeEventRepository,
+		SecretsManager: secretsManager,
+	}
+}
+
+func (s *EventSubscriptionService) Health() string {
+	return "Event subscription service is available"
+}
+
+func (s *EventSubscriptionService) GetAllEventSubscriptionEntities(include string, limit int, before *baseModels.Cursor, after *baseModels.Cursor) ([]eventsModels.EventSubscriptionEntity, *baseModels.Paging, error) {
+	eventSubscriptionEntities, err := s.EventSubscriptionRepository.SelectEventSubscriptionEntities(include, limit, before, after)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	eventSubscriptionEntities, paging := baseModels.NewPaging(eventSubscriptionEntities, limit, before, after, func(e eventsModels.EventSubscriptionEntity) *baseModels.Cursor {
+		return &baseModels.Cursor{ Name: e.Json.FullyQualifiedName, ID: e.ID }
+	})
+
+	return eventSubscriptionEntities, paging, nil
+}
+
+func (s *EventSubscriptionService) GetCountEventSubscriptionEntities(include string) (*baseModels.EntityTotal, error) {
+	entityTotal, err := s.EventSubscriptionRepository.SelectCountEventSubscriptionEntities(include)
+	return entityTotal, err
+}
+
+func (s *EventSubscriptionService) GetEventSubscriptionEntityById(id string, include string) (*eventsModels.EventSubscriptionEntity, error) {
+	eventSubscriptionEntity, err := s.EventSubscriptionRepository.SelectEventSubscriptionEntityById(id, include)
+	return eventSubscriptionEntity, err
+}
+
+func (s *EventSubscriptionService) GetEventSubscriptionEntityByFqn(fqn string, include string) (*eventsModels.EventSubscriptionEntity, error) {
+	eventSubscriptionEntity, err := s.EventSubscriptionRepository.SelectEventSubscriptionEntityByFqn(fqn, include)
+	return eventSubscriptionEntity, err
+}
+
+func (s *EventSubscriptionService) CreateEventSubscriptionEntity(payload *eventsModels.CreateEventSubscriptionPayload, userName string) (*eventsModels.EventSubscriptionEntity, error) {
+	// A new subscription only receives the events that happen after it is created
+	offset, err := s.ChangeEventRepository.SelectLatestOffset()
+
+	if err != nil {
+		return nil, err
+	}
+
+	secret := payload.Secret
+
+	if secret == "" {
+		secret, err = generateSecret()
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	secret, err = s.SecretsManager.Encrypt(secret)
+
+	if err != nil {
+		return nil, err
+	}
+
+	id := uuid.NewString()
+	now := time.Now().Unix()
+
+	eventSubscription := &eventsModels.EventSubscription{
+		ID: id,
+		Name: payload.Name,
+		FullyQualifiedName: payload.Name,
+		DisplayName: payload.DisplayName,
+		Description: payload.Description,
+		Endpoint: payload.Endpoint,
+		Secret: secret,
+		Filters: payload.Filters,
+		Enabled: *payload.Enabled,
+		MaxRetries: *payload.MaxRetries,
+		Timeout: *payload.Timeout,
+		Offset: offset,
+		Status: &eventsModels.EventSubscriptionStatus{ Status: eventsModels.SubscriptionActive },
+		UpdatedAt: now,
+		UpdatedBy: userName,
+		Deleted: false,
+	}
+
+	entity := &eventsModels.EventSubscriptionEntity{
+		ID: id,
+		Name: payload.Name,
+		Json: eventSubscription,
+		UpdatedAt: now,
+		UpdatedBy: userName,
+		Deleted: false,
+	}
+
+	eventSubscriptionEntity, err := s.EventSubscriptionRepository.InsertEventSubscriptionEntity(entity)
+	return eventSubscriptionEntity, err
+}
+
+func (s *EventSubscriptionService) CreateOrUpdateEventSubscriptionEntity(payload *eventsModels.CreateEventSubscriptionPayload, userName string) (*eventsModels.EventSubscriptionEntity, error) {
+	exist, err := s.EventSubscriptionRepository.SelectEventSubscriptionEntityByFqn(payload.Name, "all")
+
+	if err != nil {
+		return s.CreateEventSubscriptionEntity(payload, userName)
+	}
+
+	if exist.Deleted {
+		return nil, baseModels.ErrEntityDeleted
+	}
+
+	exist.Json.DisplayName = payload.DisplayName
+	exist.Json.Description = payload.Description
+	exist.Json.Endpoint = payload.Endpoint
+	exist.Json.Filters = payload.Filters
+	exist.Json.Enabled = *payload.Enabled
+	exist.Json.MaxRetries = *payload.MaxRetries
+	exist.Json.Timeout = *payload.Timeout
+
+	// The secret sent back masked is kept
+	if payload.Secret != "" && payload.Secret != securityModels.SecretMask {
+		secret, err := s.SecretsManager.Encrypt(payload.Secret)
+
+		if err != nil {
+			return nil, err
+		}
+
+		exist.Json.Secret = secret
+	}
+
+	exist.UpdatedAt = time.Now().Unix()
+	exist.UpdatedBy = userName
+	exist.Json.UpdatedAt = exist.UpdatedAt
+	exist.Json.UpdatedBy = exist.UpdatedBy
+
+	updated, err := s.EventSubscriptionRepository.UpdateEventSubscriptionEntity(exist)
+	return updated, err
+}
+
+func (s *EventSubscriptionService) DeleteEventSubscriptionEntityById(id string, hardDelete bool, userName string) error {
+	exist, err := s.EventSubscriptionRepository.SelectEventSubscriptionEntityById(id, "all")
+
+	if err != nil {
+		return err
+	}
+
+	return s.deleteEventSubscriptionEntity(exist, hardDelete, userName)
+}
+
+func (s *EventSubscriptionService) DeleteEventSubscriptionEntityByFqn(fqn string, hardDelete bool, userName string) error {
+	exist, err := s.EventSubscriptionRepository.SelectEventSubscriptionEntityByFqn(fqn, "all")
+
+	if err != nil {
+		return err
+	}
+
+	return s.deleteEventSubscriptionEntity(exist, hardDelete, userName)
+}
+
+func (s *EventSubscriptionService) deleteEventSubscriptionEntity(exist *eventsModels.EventSubscriptionEntity, hardDelete bool, userName string) error {
+	if hardDelete {
+		return s.EventSubscriptionRepository.DeleteEventSubscriptionEntityById(exist.ID)
+	}
+
+	exist.Deleted = true
+	exist.Json.Deleted = true
+	exist.UpdatedAt = time.Now().Unix()
+	exist.UpdatedBy = userName
+	exist.Json.UpdatedAt = exist.UpdatedAt
+	exist.Json.UpdatedBy = exist.UpdatedBy
+
+	_, err := s.EventSubscriptionRepository.UpdateEventSubscriptionEntity(exist)
+	return err
+}
+
+func (s *EventSubscriptionService) RestoreEventSubscriptionEntity(id string, userName string) (*eventsModels.EventSubscriptionEntity, error) {
+	exist, err := s.EventSubscriptionRepository.SelectEventSubscriptionEntityById(id, "all")
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !exist.Deleted {
+		return nil, baseModels.ErrEntityNotDeleted
+	}
+
+	exist.Deleted = false
+	exist.Json.Deleted = false
+	exist.UpdatedAt = time.Now().Unix()
+	exist.UpdatedBy = userName
+	exist.Json.UpdatedAt = exist.UpdatedAt
+	exist.Json.UpdatedBy = exist.UpdatedBy
+
+	eventSubscriptionEntity, err := s.EventSubscriptionRepository.UpdateEventSubscriptionEntity(exist)
+	return eventSubscriptionEntity, err
+}
+
+func (s *EventSubscriptionService) GetDeadLetters(subscriptionID string, limit int) ([]eventsModels.DeadLetterEntity, error) {
+	deadLetterEntities, err := s.EventSubscriptionRepository.SelectDeadLetters(subscriptionID, limit)
+	return deadLetterEntities, err
+}
+
+// DecryptEventSubscriptionSecret replaces the stored secret with the plain one, the secrets stored before the secrets
+// manager was in place are already plain
+func (s *EventSubscriptionService) DecryptEventSubscriptionSecret(eventSubscription *eventsModels.EventSubscription) error {
+	secret, err := decryptSecret(s.SecretsManager, eventSubscription.Secret)
+
+	if err != nil {
+		return err
+	}
+
+	eventSubscription.Secret = secret
+	return nil
+}
+
+// EncryptStoredSecrets encrypts the secrets of the subscriptions stored before the secrets manager was in place
+func (s *EventSubscriptionService) EncryptStoredSecrets() error {
+	eventSubscriptionEntities, err := s.EventSubscriptionRepository.SelectEventSubscriptionEntities("all", -1, nil, nil)
+
+	if err != nil {
+		return err
+	}
+
+	for _, e := range eventSubscriptionEntities {
+		if e.Json.Secret == "" || s.SecretsManager.IsEncrypted(e.Json.Secret) {
+			continue
+		}
+
+		secret, err := s.SecretsManager.Encrypt(e.Json.Secret)
+
+		if err != nil {
+			return err
+		}
+
+		e.Json.Secret = secret
+
+		if _, err := s.EventSubscriptionRepository.UpdateEventSubscriptionEntity(&e); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func decryptSecret(manager securityServices.SecretsManager, secret string) (string, error) {
+	if !manager.IsEncrypted(secret) {
+		return secret, nil
+	}
+
+	return manager.Decrypt(secret)
+}
+
+func generateSecret() (string, error) {
+	bytes := make([]byte, 32)
+
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(bytes), nil
+}
@@ -0,0 +1,285 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	eventsModels "github.com/nambuitechx/go-metadata/models/events"
+	eventsRepositories "github.com/nambuitechx/go-metadata/repositories/events"
+	securityServices "github.com/nambuitechx/go-metadata/services/security"
+	baseUtils "github.com/nambuitechx/go-metadata/utils"
+)
+
+const (
+	dispatchInterval = 5 * time.Second
+	dispatchBatchSize = 100
+	retryBaseDelay = time.Second
+	retryMaxDelay = time.Minute
+	claimHeartbeatInterval = 5 * time.Second
+	staleClaimAfter = 3 * claimHeartbeatInterval
+)
+
+// Headers sent with every delivery, the signature is "sha256=" followed by the hex HMAC of the body
+const (
+	SignatureHeader = "X-Metadata-Signature"
+	EventIdHeader = "X-Metadata-Event-Id"
+	DeliveryAttemptHeader = "X-Metadata-Delivery-Attempt"
+)
+
+// EventSubscriptionDispatcher delivers change events to the webhook of every enabled subscription.
+// Each subscription keeps the offset of the last change event it handled, an event that still fails
+// after all retries is moved to the dead letter list and the dispatcher continues with the next one.
+// A subscription is claimed in the database by one dispatcher at a time, across servers, and the claim is kept alive
+// with heartbeats while its retries outlast the interval.
+type EventSubscriptionDispatcher struct {
+	EventSubscriptionRepository *eventsRepositories.EventSubscriptionRepository
+	ChangeEventRepository *eventsRepositories.ChangeEventRepository
+	SecretsManager securityServices.SecretsManager
+	Client *http.Client
+}
+
+func NewEventSubscriptionDispatcher(
+	eventSubscriptionRepository *eventsRepositories.EventSubscriptionRepository,
+	changeEventRepository *eventsRepositories.ChangeEventRepository,
+	secretsManager securityServices.SecretsManager,
+) *EventSubscriptionDispatcher {
+	return &EventSubscriptionDispatcher{
+		EventSubscriptionRepository: eventSubscriptionRepository,
+		ChangeEventRepository: changeEventRepository,
+		SecretsManager: secretsManager,
+		Client: &http.Client{},
+	}
+}
+
+func (d *EventSubscriptionDispatcher) Start() {
+	go func() {
+		ticker := time.NewTicker(dispatchInterval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			d.dispatch()
+		}
+	}()
+}
+
+func (d *EventSubscriptionDispatcher) dispatch() {
+	claimId := uuid.NewString()
+	now := time.Now()
+	eventSubscriptionEntities, err := d.EventSubscriptionRepository.ClaimEventSubscriptions(claimId, now.Unix(), now.Add(-staleClaimAfter).Unix())
+
+	if err != nil {
+		log.Println("Failed to claim event subscriptions:", err)
+		return
+	}
+
+	for i := range eventSubscriptionEntities {
+		eventSubscriptionEntity := &eventSubscriptionEntities[i]
+
+		go func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			done := make(chan struct{})
+			defer close(done)
+			go d.heartbeat(eventSubscriptionEntity.ID, claimId, cancel, done)
+
+			d.dispatchSubscription(ctx, eventSubscriptionEntity, claimId)
+
+			if err := d.EventSubscriptionRepository.ReleaseEventSubscription(eventSubscriptionEntity.ID, claimId); err != nil {
+				log.Printf("Failed to release subscription %v: %v\n", eventSubscriptionEntity.Name, err)
+			}
+		}()
+	}
+}
+
+// heartbeat keeps the claim of the subscription alive until done, and cancels the dispatch when another dispatcher
+// took the claim over after missed heartbeats
+func (d *EventSubscriptionDispatcher) heartbeat(id string, claimId string, cancel context.CancelFunc, done <-chan struct{}) {
+	ticker := time.NewTicker(claimHeartbeatInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			err := d.EventSubscriptionRepository.HeartbeatEventSubscription(id, claimId, time.Now().Unix())
+
+			if errors.Is(err, sql.ErrNoRows) {
+				cancel()
+				return
+			}
+
+			if err != nil {
+				log.Printf("Failed to send heartbeat of subscription %v: %v\n", id, err)
+			}
+		}
+	}
+}
+
+func (d *EventSubscriptionDispatcher) dispatchSubscription(ctx context.Context, eventSubscriptionEntity *eventsModels.EventSubscriptionEntity, claimId string) {
+	subscription := eventSubscriptionEntity.Json
+	changeEventEntities, err := d.ChangeEventRepository.SelectChangeEventsAfterOffset(subscription.Offset, dispatchBatchSize)
+
+	if err != nil {
+		log.Printf("Failed to get change events for subscription %v: %v\n", subscription.Name, err)
+		return
+	}
+
+	if len(changeEventEntities) == 0 {
+		return
+	}
+
+	status := subscription.Status
+
+	if status == nil {
+		status = &eventsModels.EventSubscriptionStatus{ Status: eventsModels.SubscriptionActive }
+	}
+
+	for _, changeEventEntity := range changeEventEntities {
+		// The claim was lost, the dispatcher now holding it delivers the rest
+		if ctx.Err() != nil {
+			return
+		}
+
+		if !subscription.Filters.Match(changeEventEntity.Json) {
+			continue
+		}
+
+		attempts, statusCode, err := d.deliver(subscription, changeEventEntity.Json)
+		now := time.Now().Unix()
+
+		if err != nil {
+			deadLetter := &eventsModels.DeadLetter{
+				ID: uuid.NewString(),
+				SubscriptionID: subscription.ID,
+				Offset: changeEventEntity.Offset,
+				Attempts: attempts,
+				StatusCode: statusCode,
+				Reason: err.Error(),
+				FailedAt: now,
+				ChangeEvent: changeEventEntity.Json,
+			}
+
+			deadLetterEntity := &eventsModels.DeadLetterEntity{
+				ID: deadLetter.ID,
+				SubscriptionID: deadLetter.SubscriptionID,
+				Offset: deadLetter.Offset,
+				FailedAt: deadLetter.FailedAt,
+				Json: deadLetter,
+			}
+
+			// Stop here so the event is dispatched again instead of being lost
+			if err := d.EventSubscriptionRepository.InsertDeadLetter(deadLetterEntity); err != nil {
+				log.Printf("Failed to save dead letter for subscription %v: %v\n", subscription.Name, err)
+				return
+			}
+
+			status.Status = eventsModels.SubscriptionFailed
+			status.LastFailedAt = now
+			status.LastFailedStatusCode = statusCode
+			status.LastFailedReason = err.Error()
+			status.FailedEvents++
+		} else {
+			status.Status = eventsModels.SubscriptionActive
+			status.LastSuccessfulAt = now
+		}
+
+		if err := d.EventSubscriptionRepository.UpdateEventSubscriptionDelivery(subscription.ID, claimId, changeEventEntity.Offset, status); err != nil {
+			log.Printf("Failed to update subscription %v: %v\n", subscription.Name, err)
+			return
+		}
+	}
+
+	// Move past the events that did not match the filters
+	lastOffset := changeEventEntities[len(changeEventEntities) - 1].Offset
+
+	if err := d.EventSubscriptionRepository.UpdateEventSubscriptionDelivery(subscription.ID, claimId, lastOffset, status); err != nil {
+		log.Printf("Failed to update subscription %v: %v\n", subscription.Name, err)
+	}
+}
+
+// deliver posts the change event until it is accepted or maxRetries is exhausted, waiting twice as long
+// before each new attempt
+func (d *EventSubscriptionDispatcher) deliver(subscription *eventsModels.EventSubscription, changeEvent *eventsModels.ChangeEvent) (int, int, error) {
+	body, err := json.Marshal(changeEvent)
+
+	if err != nil {
+		return 0, 0, err
+	}
+
+	attempts := 0
+	statusCode := 0
+
+	for attempts <= subscription.MaxRetries {
+		if attempts > 0 {
+			time.Sleep(retryDelay(attempts))
+		}
+
+		attempts++
+		statusCode, err = d.post(subscription, changeEvent.ID, body, attempts)
+
+		if err == nil {
+			return attempts, statusCode, nil
+		}
+	}
+
+	return attempts, statusCode, err
+}
+
+func (d *EventSubscriptionDispatcher) post(subscription *eventsModels.EventSubscription, eventId string, body []byte, attempt int) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(subscription.Timeout) * time.Second)
+	defer cancel()
+
+	secret, err := decryptSecret(d.SecretsManager, subscription.Secret)
+
+	if err != nil {
+		return 0, err
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, subscription.Endpoint, bytes.NewReader(body))
+
+	if err != nil {
+		return 0, err
+	}
+
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set(SignatureHeader, "sha256=" + baseUtils.SignPayload(secret, body))
+	request.Header.Set(EventIdHeader, eventId)
+	request.Header.Set(DeliveryAttemptHeader, strconv.Itoa(attempt))
+
+	response, err := d.Client.Do(request)
+
+	if err != nil {
+		return 0, err
+	}
+
+	defer response.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(response.Body, 64 << 10))
+
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return response.StatusCode, fmt.Errorf("webhook responded with status %v", response.StatusCode)
+	}
+
+	return response.StatusCode, nil
+}
+
+func retryDelay(attempt int) time.Duration {
+	delay := retryBaseDelay << (attempt - 1)
+
+	if delay <= 0 || delay > retryMaxDelay {
+		return retryMaxDelay
+	}
+
+	return delay
+}
@@ -0,0 +1,140 @@
+package services
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	eventsModels "github.com/nambuitechx/go-metadata/models/events"
+	securityServices "github.com/nambuitechx/go-metadata/services/security"
+	baseUtils "github.com/nambuitechx/go-metadata/utils"
+)
+
+func TestRetryDelay(t *testing.T) {
+	tests := []struct {
+		attempt int
+		expected time.Duration
+	}{
+		{ attempt: 1, expected: time.Second },
+		{ attempt: 2, expected: 2 * time.Second },
+		{ attempt: 4, expected: 8 * time.Second },
+		{ attempt: 6, expected: 32 * time.Second },
+		{ attempt: 7, expected: time.Minute },
+		{ attempt: 64, expected: time.Minute },
+	}
+
+	for _, test := range tests {
+		t.Run(strconv.Itoa(test.attempt), func(t *testing.T) {
+			if delay := retryDelay(test.attempt); delay != test.expected {
+				t.Errorf("retryDelay(%v) = %v, expected %v", test.attempt, delay, test.expected)
+			}
+		})
+	}
+}
+
+func TestDeliver(t *testing.T) {
+	secretsManager, err := securityServices.NewLocalSecretsManager("test")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	secret, err := secretsManager.Encrypt("secret")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		// statuses answered to the successive attempts, the last one is repeated
+		statuses []int
+		maxRetries int
+		expectedAttempts int
+		expectedStatusCode int
+		expectedErr bool
+	}{
+		{
+			name: "accepted at once",
+			statuses: []int{http.StatusOK},
+			maxRetries: 2,
+			expectedAttempts: 1,
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name: "accepted after a retry",
+			statuses: []int{http.StatusInternalServerError, http.StatusNoContent},
+			maxRetries: 1,
+			expectedAttempts: 2,
+			expectedStatusCode: http.StatusNoContent,
+		},
+		{
+			name: "retries exhausted",
+			statuses: []int{http.StatusBadGateway},
+			maxRetries: 1,
+			expectedAttempts: 2,
+			expectedStatusCode: http.StatusBadGateway,
+			expectedErr: true,
+		},
+		{
+			name: "no retries",
+			statuses: []int{http.StatusNotFound},
+			maxRetries: 0,
+			expectedAttempts: 1,
+			expectedStatusCode: http.StatusNotFound,
+			expectedErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var requests atomic.Int32
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				attempt := int(requests.Add(1))
+				body, _ := io.ReadAll(r.Body)
+
+				if signature := r.Header.Get(SignatureHeader); signature != "sha256=" + baseUtils.SignPayload("secret", body) {
+					t.Errorf("unexpected signature %v", signature)
+				}
+
+				if header := r.Header.Get(DeliveryAttemptHeader); header != strconv.Itoa(attempt) {
+					t.Errorf("attempt header = %v, expected %v", header, attempt)
+				}
+
+				if header := r.Header.Get(EventIdHeader); header != "event" {
+					t.Errorf("event id header = %v, expected event", header)
+				}
+
+				w.WriteHeader(test.statuses[min(attempt, len(test.statuses)) - 1])
+			}))
+			defer server.Close()
+
+			dispatcher := NewEventSubscriptionDispatcher(nil, nil, secretsManager)
+			subscription := &eventsModels.EventSubscription{
+				Endpoint: server.URL,
+				Secret: secret,
+				MaxRetries: test.maxRetries,
+				Timeout: 5,
+			}
+
+			attempts, statusCode, err := dispatcher.deliver(subscription, &eventsModels.ChangeEvent{ ID: "event" })
+
+			if attempts != test.expectedAttempts || int(requests.Load()) != test.expectedAttempts {
+				t.Errorf("attempts = %v with %v requests, expected %v", attempts, requests.Load(), test.expectedAttempts)
+			}
+
+			if statusCode != test.expectedStatusCode {
+				t.Errorf("status code = %v, expected %v", statusCode, test.expectedStatusCode)
+			}
+
+			if (err != nil) != test.expectedErr {
+				t.Errorf("err = %v, expected an error: %v", err, test.expectedErr)
+			}
+		})
+	}
+}
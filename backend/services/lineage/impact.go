@@ -0,0 +1,270 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+
+	dataModels "github.com/nambuitechx/go-metadata/models/data"
+	lineageModels "github.com/nambuitechx/go-metadata/models/lineage"
+	typeModels "github.com/nambuitechx/go-metadata/models/type"
+)
+
+// AnalyzeTableImpact walks the downstream lineage of a table and reports the tables, columns and stored procedures
+// that depend on the changed columns. Columns are followed through column lineage, an edge without column lineage
+// impacts the whole downstream table. Views take part through the edges parsed from their definition
+func (s *LineageService) AnalyzeTableImpact(fqn string, changes []*lineageModels.ColumnChange) (*lineageModels.ImpactReport, error) {
+	tableEntity, err := s.TableEntityRepository.SelectTableEntityByFqn(fqn, "non-deleted")
+
+	if err != nil {
+		return nil, err
+	}
+
+	// Cause of every impacted column and table, as the fullyQualifiedName of the changed columns
+	columnCauses := map[string]map[string]bool{}
+	tableCauses := map[string]map[string]bool{}
+
+	for _, change := range changes {
+		column := tableEntity.Json.FindColumn(change.Column)
+
+		if column == nil {
+			return nil, fmt.Errorf("%w: %v", lineageModels.ErrColumnNotFound, change.Column)
+		}
+
+		if change.ChangeType == "rename" && tableEntity.Json.FindColumn(change.NewName) != nil {
+			return nil, fmt.Errorf("column %v already exists", change.NewName)
+		}
+
+		change.Column = *column.FullyQualifiedName
+		columnCauses[change.Column] = map[string]bool{ change.Column: true }
+	}
+
+	downstreamEdges, err := s.LineageRepository.SelectDownstreamEdges(tableEntity.ID, lineageModels.MaxLineageDepth)
+
+	if err != nil {
+		return nil, err
+	}
+
+	upstreamEdges, err := s.LineageRepository.SelectUpstreamEdges(tableEntity.ID, 1)
+
+	if err != nil {
+		return nil, err
+	}
+
+	nodes, err := s.LineageRepository.SelectLineageNodes(tableEntity.ID, 0, lineageModels.MaxLineageDepth)
+
+	if err != nil {
+		return nil, err
+	}
+
+	tables := map[string]*dataModels.Table{ tableEntity.ID: tableEntity.Json }
+
+	for i := range nodes {
+		tables[nodes[i].ID] = nodes[i].Json
+	}
+
+	depths := map[string]int{ tableEntity.ID: 0 }
+	impactedColumns := map[string]*lineageModels.ImpactedColumn{}
+	storedProcedures := map[string]*lineageModels.ImpactedStoredProcedure{}
+
+	// Causes only grow, so the walk stops once an iteration adds nothing
+	for changed := true; changed; {
+		changed = false
+
+		for _, edge := range downstreamEdges {
+			from, to := tables[edge.FromID], tables[edge.ToID]
+			depth, reached := depths[edge.FromID]
+
+			if from == nil || to == nil || !reached || edge.Json == nil {
+				continue
+			}
+
+			impacted := false
+
+			if len(edge.Json.ColumnsLineage) == 0 {
+				causes := copyCauses(tableCauses[edge.FromID])
+
+				for _, column := range from.Columns {
+					if column.FullyQualifiedName != nil {
+						addCauses(causes, columnCauses[*column.FullyQualifiedName])
+					}
+				}
+
+				if len(causes) > 0 {
+					impacted = true
+
+					if tableCauses[edge.ToID] == nil {
+						tableCauses[edge.ToID] = map[string]bool{}
+					}
+
+					changed = addCauses(tableCauses[edge.ToID], causes) || changed
+				}
+			}
+
+			for _, columnLineage := range edge.Json.ColumnsLineage {
+				causes := copyCauses(tableCauses[edge.FromID])
+
+				for _, fromColumn := range columnLineage.FromColumns {
+					addCauses(causes, columnCauses[fromColumn])
+				}
+
+				if len(causes) == 0 {
+					continue
+				}
+
+				impacted = true
+
+				if columnCauses[columnLineage.ToColumn] == nil {
+					columnCauses[columnLineage.ToColumn] = map[string]bool{}
+					impactedColumns[columnLineage.ToColumn] = &lineageModels.ImpactedColumn{
+						FullyQualifiedName: columnLineage.ToColumn,
+						FromColumns: columnLineage.FromColumns,
+						Function: columnLineage.Function,
+					}
+				}
+
+				changed = addCauses(columnCauses[columnLineage.ToColumn], causes) || changed
+			}
+
+			if !impacted {
+				continue
+			}
+
+			if d, ok := depths[edge.ToID]; !ok || depth + 1 < d {
+				depths[edge.ToID] = depth + 1
+				changed = true
+			}
+
+			if edge.Json.Pipeline != nil {
+				addImpactedStoredProcedure(storedProcedures, edge.Json.Pipeline, lineageModels.ReadsTable, from.FullyQualifiedName)
+			}
+		}
+	}
+
+	// Procedures loading the table write to the changed columns
+	for _, edge := range upstreamEdges {
+		if edge.Json != nil && edge.Json.Pipeline != nil {
+			addImpactedStoredProcedure(storedProcedures, edge.Json.Pipeline, lineageModels.WritesTable, tableEntity.Json.FullyQualifiedName)
+		}
+	}
+
+	report := &lineageModels.ImpactReport{
+		Table: tableEntity.Json.ToEntityReference(),
+		Changes: changes,
+		ImpactedTables: []*lineageModels.ImpactedTable{},
+		ImpactedStoredProcedures: []*lineageModels.ImpactedStoredProcedure{},
+	}
+
+	for id, depth := range depths {
+		if id == tableEntity.ID {
+			continue
+		}
+
+		table := tables[id]
+		owners, err := s.OwnershipService.GetOwners("table", id)
+
+		if err != nil {
+			return nil, err
+		}
+
+		impactedTable := &lineageModels.ImpactedTable{
+			Table: table.ToEntityReference(),
+			TableType: table.TableType,
+			Depth: depth,
+			Columns: []*lineageModels.ImpactedColumn{},
+			ImpactedBy: sortedCauses(tableCauses[id]),
+			Owners: owners,
+		}
+
+		for _, column := range table.Columns {
+			if column.FullyQualifiedName == nil {
+				continue
+			}
+
+			if impactedColumn, ok := impactedColumns[*column.FullyQualifiedName]; ok {
+				impactedColumn.ImpactedBy = sortedCauses(columnCauses[*column.FullyQualifiedName])
+				impactedTable.Columns = append(impactedTable.Columns, impactedColumn)
+			}
+		}
+
+		report.ImpactedTables = append(report.ImpactedTables, impactedTable)
+	}
+
+	sort.Slice(report.ImpactedTables, func(i, j int) bool {
+		a, b := report.ImpactedTables[i], report.ImpactedTables[j]
+
+		if a.Depth != b.Depth {
+			return a.Depth < b.Depth
+		}
+
+		return a.Table.FullyQualifiedName < b.Table.FullyQualifiedName
+	})
+
+	for _, storedProcedure := range storedProcedures {
+		owners, err := s.OwnershipService.GetOwners("storedProcedure", storedProcedure.StoredProcedure.ID)
+
+		if err != nil {
+			return nil, err
+		}
+
+		storedProcedure.Owners = owners
+		report.ImpactedStoredProcedures = append(report.ImpactedStoredProcedures, storedProcedure)
+	}
+
+	sort.Slice(report.ImpactedStoredProcedures, func(i, j int) bool {
+		a, b := report.ImpactedStoredProcedures[i], report.ImpactedStoredProcedures[j]
+
+		if a.StoredProcedure.FullyQualifiedName != b.StoredProcedure.FullyQualifiedName {
+			return a.StoredProcedure.FullyQualifiedName < b.StoredProcedure.FullyQualifiedName
+		}
+
+		return a.Table < b.Table
+	})
+
+	return report, nil
+}
+
+func addImpactedStoredProcedure(storedProcedures map[string]*lineageModels.ImpactedStoredProcedure, pipeline *typeModels.EntityReference, reason string, table string) {
+	key := pipeline.ID + "/" + reason + "/" + table
+
+	if _, ok := storedProcedures[key]; ok {
+		return
+	}
+
+	storedProcedures[key] = &lineageModels.ImpactedStoredProcedure{
+		StoredProcedure: pipeline,
+		Reason: reason,
+		Table: table,
+		Owners: []*typeModels.EntityReference{},
+	}
+}
+
+// addCauses adds src to dst and reports whether dst grew
+func addCauses(dst map[string]bool, src map[string]bool) bool {
+	grew := false
+
+	for cause := range src {
+		if !dst[cause] {
+			dst[cause] = true
+			grew = true
+		}
+	}
+
+	return grew
+}
+
+func copyCauses(causes map[string]bool) map[string]bool {
+	copied := map[string]bool{}
+	addCauses(copied, causes)
+	return copied
+}
+
+func sortedCauses(causes map[string]bool) []string {
+	sorted := []string{}
+
+	for cause := range causes {
+		sorted = append(sorted, cause)
+	}
+
+	sort.Strings(sorted)
+	return sorted
+}
@@ -0,0 +1,66 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+
+	lineageModels "github.com/nambuitechx/go-metadata/models/lineage"
+	typeModels "github.com/nambuitechx/go-metadata/models/type"
+)
+
+func TestAddCauses(t *testing.T) {
+	tests := []struct {
+		name string
+		dst map[string]bool
+		src map[string]bool
+		expected []string
+		expectedGrew bool
+	}{
+		{ name: "new cause", dst: map[string]bool{ "total": true }, src: map[string]bool{ "id": true }, expected: []string{"id", "total"}, expectedGrew: true },
+		{ name: "known cause", dst: map[string]bool{ "total": true }, src: map[string]bool{ "total": true }, expected: []string{"total"}, expectedGrew: false },
+		{ name: "no cause", dst: map[string]bool{}, src: map[string]bool{}, expected: []string{}, expectedGrew: false },
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			grew := addCauses(test.dst, test.src)
+
+			if grew != test.expectedGrew {
+				t.Errorf("addCauses() = %v, expected %v", grew, test.expectedGrew)
+			}
+
+			if causes := sortedCauses(test.dst); !reflect.DeepEqual(causes, test.expected) {
+				t.Errorf("causes = %v, expected %v", causes, test.expected)
+			}
+		})
+	}
+}
+
+func TestAddImpactedStoredProcedure(t *testing.T) {
+	load := &typeModels.EntityReference{ ID: "1", Type: "storedProcedure" }
+	report := &typeModels.EntityReference{ ID: "2", Type: "storedProcedure" }
+
+	tests := []struct {
+		name string
+		pipeline *typeModels.EntityReference
+		reason string
+		table string
+		expected int
+	}{
+		{ name: "first", pipeline: load, reason: lineageModels.WritesTable, table: "orders", expected: 1 },
+		{ name: "same impact", pipeline: load, reason: lineageModels.WritesTable, table: "orders", expected: 1 },
+		{ name: "other reason", pipeline: load, reason: lineageModels.ReadsTable, table: "orders", expected: 2 },
+		{ name: "other table", pipeline: load, reason: lineageModels.ReadsTable, table: "report", expected: 3 },
+		{ name: "other procedure", pipeline: report, reason: lineageModels.ReadsTable, table: "report", expected: 4 },
+	}
+
+	storedProcedures := map[string]*lineageModels.ImpactedStoredProcedure{}
+
+	for _, test := range tests {
+		addImpactedStoredProcedure(storedProcedures, test.pipeline, test.reason, test.table)
+
+		if len(storedProcedures) != test.expected {
+			t.Errorf("%v: %v impacted stored procedures, expected %v", test.name, len(storedProcedures), test.expected)
+		}
+	}
+}
@@ -0,0 +1,283 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	dataModels "github.com/nambuitechx/go-metadata/models/data"
+	lineageModels "github.com/nambuitechx/go-metadata/models/lineage"
+	typeModels "github.com/nambuitechx/go-metadata/models/type"
+	dataRepositories "github.com/nambuitechx/go-metadata/repositories/data"
+	lineageRepositories "github.com/nambuitechx/go-metadata/repositories/lineage"
+	teamsServices "github.com/nambuitechx/go-metadata/services/teams"
+)
+
+type LineageService struct {
+	LineageRepository *lineageRepositories.LineageRepository
+	TableEntityRepository *dataRepositories.TableEntityRepository
+	StoredProcedureEntityRepository *dataRepositories.StoredProcedureEntityRepository
+	OwnershipService *teamsServices.OwnershipService
+}
+
+func NewLineageService(
+	lineageRepository *lineageRepositories.LineageRepository,
+	tableEntityRepository *dataRepositories.TableEntityRepository,
+	storedProcedureEntityRepository *dataRepositories.StoredProcedureEntityRepository,
+	ownershipService *teamsServices.OwnershipService,
+) *LineageService {
+	return &LineageService{
+		LineageRepository: lineageRepository,
+		TableEntityRepository: tableEntityRepository,
+		StoredProcedureEntityRepository: storedProcedureEntityRepository,
+		OwnershipService: ownershipService,
+	}
+}
+
+func (s *LineageService) Health() string {
+	return "Lineage service is available"
+}
+
+// GetTableLineageByFqn returns the lineage of a table, when column is set only the edges and nodes that column
+// is traced through are kept
+func (s *LineageService) GetTableLineageByFqn(fqn string, column string, upstreamDepth int, downstreamDepth int) (*lineageModels.EntityLineage, error) {
+	tableEntity, err := s.TableEntityRepository.SelectTableEntityByFqn(fqn, "non-deleted")
+
+	if err != nil {
+		return nil, err
+	}
+
+	if column != "" && !tableEntity.Json.HasColumn(column) {
+		return nil, lineageModels.ErrColumnNotFound
+	}
+
+	upstreamEdges, err := s.LineageRepository.SelectUpstreamEdges(tableEntity.ID, upstreamDepth)
+
+	if err != nil {
+		return nil, err
+	}
+
+	downstreamEdges, err := s.LineageRepository.SelectDownstreamEdges(tableEntity.ID, downstreamDepth)
+
+	if err != nil {
+		return nil, err
+	}
+
+	nodes, err := s.LineageRepository.SelectLineageNodes(tableEntity.ID, upstreamDepth, downstreamDepth)
+
+	if err != nil {
+		return nil, err
+	}
+
+	entityLineage := &lineageModels.EntityLineage{
+		Entity: tableEntity.Json.ToEntityReference(),
+		Nodes: []*typeModels.EntityReference{},
+		UpstreamEdges: toEdges(upstreamEdges),
+		DownstreamEdges: toEdges(downstreamEdges),
+	}
+
+	if column != "" {
+		entityLineage.UpstreamEdges = traceColumn(entityLineage.UpstreamEdges, column, true)
+		entityLineage.DownstreamEdges = traceColumn(entityLineage.DownstreamEdges, column, false)
+	}
+
+	reached := map[string]bool{}
+
+	for _, edge := range append(entityLineage.UpstreamEdges, entityLineage.DownstreamEdges...) {
+		reached[edge.FromEntity] = true
+		reached[edge.ToEntity] = true
+	}
+
+	for _, node := range nodes {
+		if reached[node.ID] {
+			entityLineage.Nodes = append(entityLineage.Nodes, node.Json.ToEntityReference())
+		}
+	}
+
+	return entityLineage, nil
+}
+
+func (s *LineageService) AddLineage(payload *lineageModels.AddLineagePayload, userName string) (*lineageModels.Edge, error) {
+	fromEntity, err := s.getTableEntity(payload.Edge.FromEntity)
+
+	if err != nil {
+		return nil, err
+	}
+
+	toEntity, err := s.getTableEntity(payload.Edge.ToEntity)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if fromEntity.ID == toEntity.ID {
+		return nil, errors.New("a table cannot be upstream of itself")
+	}
+
+	lineageDetails := payload.Edge.LineageDetails
+
+	for _, columnLineage := range lineageDetails.ColumnsLineage {
+		for _, fromColumn := range columnLineage.FromColumns {
+			if !fromEntity.Json.HasColumn(fromColumn) {
+				return nil, fmt.Errorf("column %v does not belong to table %v", fromColumn, fromEntity.Json.FullyQualifiedName)
+			}
+		}
+
+		if !toEntity.Json.HasColumn(columnLineage.ToColumn) {
+			return nil, fmt.Errorf("column %v does not belong to table %v", columnLineage.ToColumn, toEntity.Json.FullyQualifiedName)
+		}
+	}
+
+	if lineageDetails.Pipeline != nil {
+		storedProcedureEntity, err := s.getStoredProcedureEntity(lineageDetails.Pipeline)
+
+		if err != nil {
+			return nil, err
+		}
+
+		lineageDetails.Pipeline = storedProcedureEntity.Json.ToEntityReference()
+	}
+
+	if lineageDetails.Source == "" {
+		lineageDetails.Source = lineageModels.ManualLineage
+	}
+
+	lineageDetails.CreatedAt = time.Now().Unix()
+	lineageDetails.CreatedBy = userName
+
+	lineageEdgeEntity, err := s.LineageRepository.InsertLineageEdge(fromEntity.ID, toEntity.ID, lineageDetails)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return toEdge(lineageEdgeEntity), nil
+}
+
+// GetTableId returns the id of the table of a lineage entity reference, which may only name it
+func (s *LineageService) GetTableId(entityRef *typeModels.EntityReference) (string, error) {
+	tableEntity, err := s.getTableEntity(entityRef)
+
+	if err != nil {
+		return "", err
+	}
+
+	return tableEntity.ID, nil
+}
+
+func (s *LineageService) DeleteLineage(fromId string, toId string) error {
+	return s.LineageRepository.DeleteLineageEdge(fromId, toId)
+}
+
+func (s *LineageService) getTableEntity(entityRef *typeModels.EntityReference) (*dataModels.TableEntity, error) {
+	if entityRef.ID != "" {
+		return s.TableEntityRepository.SelectTableEntityById(entityRef.ID, "non-deleted")
+	}
+
+	return s.TableEntityRepository.SelectTableEntityByFqn(entityRef.FullyQualifiedName, "non-deleted")
+}
+
+func (s *LineageService) getStoredProcedureEntity(entityRef *typeModels.EntityReference) (*dataModels.StoredProcedureEntity, error) {
+	if entityRef.ID != "" {
+		return s.StoredProcedureEntityRepository.SelectStoredProcedureEntityById(entityRef.ID, "non-deleted")
+	}
+
+	return s.StoredProcedureEntityRepository.SelectStoredProcedureEntityByFqn(entityRef.FullyQualifiedName, "non-deleted")
+}
+
+func toEdge(lineageEdgeEntity *lineageModels.LineageEdgeEntity) *lineageModels.Edge {
+	return &lineageModels.Edge{
+		FromEntity: lineageEdgeEntity.FromID,
+		ToEntity: lineageEdgeEntity.ToID,
+		LineageDetails: lineageEdgeEntity.Json,
+	}
+}
+
+func toEdges(lineageEdgeEntities []lineageModels.LineageEdgeEntity) []*lineageModels.Edge {
+	edges := []*lineageModels.Edge{}
+
+	for i := range lineageEdgeEntities {
+		edges = append(edges, toEdge(&lineageEdgeEntities[i]))
+	}
+
+	return edges
+}
+
+// traceColumn keeps the edges a column flows through, with only the column lineage of the traced columns.
+// Upstream the trace follows toColumn back to fromColumns, downstream it follows fromColumns to toColumn
+func traceColumn(edges []*lineageModels.Edge, column string, upstream bool) []*lineageModels.Edge {
+	traced := map[string]bool{ column: true }
+	kept := map[*lineageModels.ColumnLineage]bool{}
+
+	for changed := true; changed; {
+		changed = false
+
+		for _, edge := range edges {
+			if edge.LineageDetails == nil {
+				continue
+			}
+
+			for _, columnLineage := range edge.LineageDetails.ColumnsLineage {
+				if kept[columnLineage] || !isTraced(traced, columnLineage, upstream) {
+					continue
+				}
+
+				kept[columnLineage] = true
+				changed = true
+
+				if upstream {
+					for _, fromColumn := range columnLineage.FromColumns {
+						traced[fromColumn] = true
+					}
+				} else {
+					traced[columnLineage.ToColumn] = true
+				}
+			}
+		}
+	}
+
+	tracedEdges := []*lineageModels.Edge{}
+
+	for _, edge := range edges {
+		if edge.LineageDetails == nil {
+			continue
+		}
+
+		columnsLineage := []*lineageModels.ColumnLineage{}
+
+		for _, columnLineage := range edge.LineageDetails.ColumnsLineage {
+			if kept[columnLineage] {
+				columnsLineage = append(columnsLineage, columnLineage)
+			}
+		}
+
+		if len(columnsLineage) == 0 {
+			continue
+		}
+
+		lineageDetails := *edge.LineageDetails
+		lineageDetails.ColumnsLineage = columnsLineage
+
+		tracedEdges = append(tracedEdges, &lineageModels.Edge{
+			FromEntity: edge.FromEntity,
+			ToEntity: edge.ToEntity,
+			LineageDetails: &lineageDetails,
+		})
+	}
+
+	return tracedEdges
+}
+
+func isTraced(traced map[string]bool, columnLineage *lineageModels.ColumnLineage, upstream bool) bool {
+	if upstream {
+		return traced[columnLineage.ToColumn]
+	}
+
+	for _, fromColumn := range columnLineage.FromColumns {
+		if traced[fromColumn] {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,72 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+
+	lineageModels "github.com/nambuitechx/go-metadata/models/lineage"
+)
+
+func TestTraceColumn(t *testing.T) {
+	columnLineage := func(toColumn string, fromColumns ...string) *lineageModels.ColumnLineage {
+		return &lineageModels.ColumnLineage{ FromColumns: fromColumns, ToColumn: toColumn }
+	}
+
+	edge := func(from string, to string, columnsLineage ...*lineageModels.ColumnLineage) *lineageModels.Edge {
+		return &lineageModels.Edge{ FromEntity: from, ToEntity: to, LineageDetails: &lineageModels.LineageDetails{ ColumnsLineage: columnsLineage } }
+	}
+
+	// raw.id, raw.amount -> orders.id, orders.total -> report.total
+	edges := []*lineageModels.Edge{
+		edge("raw", "orders", columnLineage("orders.id", "raw.id"), columnLineage("orders.total", "raw.amount", "raw.tax")),
+		edge("orders", "report", columnLineage("report.total", "orders.total")),
+		edge("customers", "report"),
+		{ FromEntity: "legacy", ToEntity: "report" },
+	}
+
+	tests := []struct {
+		name string
+		column string
+		upstream bool
+		expected []*lineageModels.Edge
+	}{
+		{
+			name: "upstream through two edges",
+			column: "report.total",
+			upstream: true,
+			expected: []*lineageModels.Edge{
+				edge("raw", "orders", columnLineage("orders.total", "raw.amount", "raw.tax")),
+				edge("orders", "report", columnLineage("report.total", "orders.total")),
+			},
+		},
+		{
+			name: "downstream through two edges",
+			column: "raw.amount",
+			upstream: false,
+			expected: []*lineageModels.Edge{
+				edge("raw", "orders", columnLineage("orders.total", "raw.amount", "raw.tax")),
+				edge("orders", "report", columnLineage("report.total", "orders.total")),
+			},
+		},
+		{
+			name: "column without lineage",
+			column: "report.customer",
+			upstream: true,
+			expected: []*lineageModels.Edge{},
+		},
+		{
+			name: "downstream of a leaf column",
+			column: "raw.id",
+			upstream: false,
+			expected: []*lineageModels.Edge{ edge("raw", "orders", columnLineage("orders.id", "raw.id")) },
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if traced := traceColumn(edges, test.column, test.upstream); !reflect.DeepEqual(traced, test.expected) {
+				t.Errorf("traceColumn(%v) = %v, expected %v", test.column, traced, test.expected)
+			}
+		})
+	}
+}
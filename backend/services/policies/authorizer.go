@@ -0,0 +1,276 @@
+package services
+
+import (
+	"slices"
+
+	policiesModels "github.com/nambuitechx/go-metadata/models/policies"
+	teamsModels "github.com/nambuitechx/go-metadata/models/teams"
+	typeModels "github.com/nambuitechx/go-metadata/models/type"
+	policiesRepositories "github.com/nambuitechx/go-metadata/repositories/policies"
+	teamsRepositories "github.com/nambuitechx/go-metadata/repositories/teams"
+)
+
+// OwnersResolver returns the owners of an entity, for the ownership conditions of the rules
+type OwnersResolver interface {
+	GetOwners(resource string, entityId string) ([]*typeModels.EntityReference, error)
+}
+
+// Authorizer evaluates the rules of the policies of the roles of a user. Admins may do anything, otherwise an
+// operation needs a matching allow rule and no matching deny rule
+type Authorizer struct {
+	RoleEntityRepository *teamsRepositories.RoleEntityRepository
+	PolicyEntityRepository *policiesRepositories.PolicyEntityRepository
+	OwnersResolver OwnersResolver
+}
+
+func NewAuthorizer(
+	roleEntityRepository *teamsRepositories.RoleEntityRepository,
+	policyEntityRepository *policiesRepositories.PolicyEntityRepository,
+	ownersResolver OwnersResolver,
+) *Authorizer {
+	return &Authorizer{
+		RoleEntityRepository: roleEntityRepository,
+		PolicyEntityRepository: policyEntityRepository,
+		OwnersResolver: ownersResolver,
+	}
+}
+
+// Authorize returns policiesModels.ErrPermissionDenied unless the principal may do all the operations
+func (a *Authorizer) Authorize(principal *teamsModels.Principal, resourceContext *policiesModels.ResourceContext, operations ...string) error {
+	if principal == nil {
+		return policiesModels.ErrPermissionDenied
+	}
+
+	if principal.IsAdmin {
+		return nil
+	}
+
+	rules, err := a.getRules(principal)
+
+	if err != nil {
+		return err
+	}
+
+	e := &evaluation{ authorizer: a, principal: principal, resourceContext: resourceContext }
+
+	for _, operation := range operations {
+		permission, err := e.check(operation, rules)
+
+		if err != nil {
+			return err
+		}
+
+		if permission.Access != policiesModels.AllowAccess {
+			return policiesModels.ErrPermissionDenied
+		}
+	}
+
+	return nil
+}
+
+// AuthorizeEach tells for every resource context whether the principal may do the operation, the rules are read once
+func (a *Authorizer) AuthorizeEach(principal *teamsModels.Principal, resourceContexts []*policiesModels.ResourceContext, operation string) ([]bool, error) {
+	allowed := make([]bool, len(resourceContexts))
+
+	if principal == nil {
+		return allowed, nil
+	}
+
+	if principal.IsAdmin {
+		for i := range allowed {
+			allowed[i] = true
+		}
+
+		return allowed, nil
+	}
+
+	rules, err := a.getRules(principal)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for i, resourceContext := range resourceContexts {
+		e := &evaluation{ authorizer: a, principal: principal, resourceContext: resourceContext }
+		permission, err := e.check(operation, rules)
+
+		if err != nil {
+			return nil, err
+		}
+
+		allowed[i] = permission.Access == policiesModels.AllowAccess
+	}
+
+	return allowed, nil
+}
+
+// GetPermissions returns the access of the principal to every operation on the resource
+func (a *Authorizer) GetPermissions(principal *teamsModels.Principal, resourceContext *policiesModels.ResourceContext) (*policiesModels.ResourcePermission, error) {
+	resourcePermission := &policiesModels.ResourcePermission{
+		Resource: resourceContext.Resource,
+		EntityID: resourceContext.EntityID,
+		Permissions: []*policiesModels.Permission{},
+	}
+
+	if principal == nil {
+		return nil, policiesModels.ErrPermissionDenied
+	}
+
+	if principal.IsAdmin {
+		for _, operation := range policiesModels.Operations {
+			resourcePermission.Permissions = append(resourcePermission.Permissions, &policiesModels.Permission{ Operation: operation, Access: policiesModels.AllowAccess })
+		}
+
+		return resourcePermission, nil
+	}
+
+	rules, err := a.getRules(principal)
+
+	if err != nil {
+		return nil, err
+	}
+
+	e := &evaluation{ authorizer: a, principal: principal, resourceContext: resourceContext }
+
+	for _, operation := range policiesModels.Operations {
+		permission, err := e.check(operation, rules)
+
+		if err != nil {
+			return nil, err
+		}
+
+		resourcePermission.Permissions = append(resourcePermission.Permissions, permission)
+	}
+
+	return resourcePermission, nil
+}
+
+type policyRule struct {
+	policy string
+	rule *policiesModels.Rule
+	condition policiesModels.Condition
+}
+
+// getRules reads the roles of the principal, with the default role, and the rules of their policies. Deleted roles
+// and policies are skipped
+func (a *Authorizer) getRules(principal *teamsModels.Principal) ([]*policyRule, error) {
+	roleNames := []string{teamsModels.DefaultRole}
+
+	for _, role := range principal.Roles {
+		if role != teamsModels.DefaultRole {
+			roleNames = append(roleNames, role)
+		}
+	}
+
+	roleEntities, err := a.RoleEntityRepository.SelectRoleEntitiesByNames(roleNames)
+
+	if err != nil {
+		return nil, err
+	}
+
+	policyNames := []string{}
+	seen := map[string]bool{}
+
+	for _, e := range roleEntities {
+		for _, policy := range e.Json.Policies {
+			if !seen[policy.Name] {
+				seen[policy.Name] = true
+				policyNames = append(policyNames, policy.Name)
+			}
+		}
+	}
+
+	policyEntities, err := a.PolicyEntityRepository.SelectPolicyEntitiesByNames(policyNames)
+
+	if err != nil {
+		return nil, err
+	}
+
+	rules := []*policyRule{}
+
+	for _, e := range policyEntities {
+		for _, rule := range e.Json.Rules {
+			condition, err := policiesModels.ParseCondition(rule.Condition)
+
+			if err != nil {
+				return nil, err
+			}
+
+			rules = append(rules, &policyRule{ policy: e.Name, rule: rule, condition: condition })
+		}
+	}
+
+	return rules, nil
+}
+
+// evaluation resolves the owners of the entity at most once, and only for a rule with a condition
+type evaluation struct {
+	authorizer *Authorizer
+	principal *teamsModels.Principal
+	resourceContext *policiesModels.ResourceContext
+	owners []*typeModels.EntityReference
+	ownersResolved bool
+}
+
+func (e *evaluation) check(operation string, rules []*policyRule) (*policiesModels.Permission, error) {
+	permission := &policiesModels.Permission{ Operation: operation, Access: policiesModels.NotAllowAccess }
+
+	for _, r := range rules {
+		if !r.rule.MatchResource(e.resourceContext.Resource) || !r.rule.MatchOperation(operation) {
+			continue
+		}
+
+		if r.condition != nil {
+			ok, err := r.condition.Evaluate(e.call)
+
+			if err != nil {
+				return nil, err
+			}
+
+			if !ok {
+				continue
+			}
+		}
+
+		if r.rule.Effect == policiesModels.DenyEffect {
+			return &policiesModels.Permission{ Operation: operation, Access: policiesModels.DenyAccess, Policy: r.policy, Rule: r.rule.Name }, nil
+		}
+
+		if permission.Access != policiesModels.AllowAccess {
+			permission = &policiesModels.Permission{ Operation: operation, Access: policiesModels.AllowAccess, Policy: r.policy, Rule: r.rule.Name }
+		}
+	}
+
+	return permission, nil
+}
+
+func (e *evaluation) call(function string) (bool, error) {
+	if !e.ownersResolved {
+		e.ownersResolved = true
+
+		if e.authorizer.OwnersResolver != nil && e.resourceContext.EntityID != "" {
+			owners, err := e.authorizer.OwnersResolver.GetOwners(e.resourceContext.Resource, e.resourceContext.EntityID)
+
+			if err != nil {
+				return false, err
+			}
+
+			e.owners = owners
+		}
+	}
+
+	switch function {
+	case "isOwner":
+		for _, owner := range e.owners {
+			if owner.ID == e.principal.ID || slices.Contains(e.principal.Teams, owner.ID) {
+				return true, nil
+			}
+		}
+
+		return false, nil
+	case "noOwner":
+		return len(e.owners) == 0, nil
+	}
+
+	return false, nil
+}
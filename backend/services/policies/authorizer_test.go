@@ -0,0 +1,171 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	policiesModels "github.com/nambuitechx/go-metadata/models/policies"
+	teamsModels "github.com/nambuitechx/go-metadata/models/teams"
+	typeModels "github.com/nambuitechx/go-metadata/models/type"
+)
+
+type testOwnersResolver struct {
+	owners []*typeModels.EntityReference
+	calls int
+}
+
+func (r *testOwnersResolver) GetOwners(resource string, entityId string) ([]*typeModels.EntityReference, error) {
+	r.calls++
+	return r.owners, nil
+}
+
+func newTestPolicyRule(t *testing.T, name string, effect string, operation string, condition string) *policyRule {
+	rule := &policiesModels.Rule{
+		Name: name,
+		Resources: []string{policiesModels.TableResource},
+		Operations: []string{operation},
+		Effect: effect,
+		Condition: condition,
+	}
+
+	c, err := policiesModels.ParseCondition(condition)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &policyRule{ policy: "policy", rule: rule, condition: c }
+}
+
+func TestEvaluationCheck(t *testing.T) {
+	viewAll := newTestPolicyRule(t, "viewAll", policiesModels.AllowEffect, policiesModels.ViewAllOperation, "")
+	ownerEdit := newTestPolicyRule(t, "ownerEdit", policiesModels.AllowEffect, policiesModels.EditAllOperation, policiesModels.IsOwnerCondition)
+	noOwnerEdit := newTestPolicyRule(t, "noOwnerEdit", policiesModels.AllowEffect, policiesModels.EditAllOperation, policiesModels.NoOwnerCondition)
+	denyDelete := newTestPolicyRule(t, "denyDelete", policiesModels.DenyEffect, policiesModels.DeleteOperation, "")
+	allowDelete := newTestPolicyRule(t, "allowDelete", policiesModels.AllowEffect, policiesModels.DeleteOperation, "")
+
+	alice := &typeModels.EntityReference{ ID: "alice", Type: "user" }
+	bob := &typeModels.EntityReference{ ID: "bob", Type: "user" }
+
+	tests := []struct {
+		name string
+		rules []*policyRule
+		owners []*typeModels.EntityReference
+		operation string
+		expectedAccess string
+		expectedRule string
+		expectedCalls int
+	}{
+		{
+			name: "no matching rule",
+			rules: []*policyRule{viewAll},
+			operation: policiesModels.CreateOperation,
+			expectedAccess: policiesModels.NotAllowAccess,
+		},
+		{
+			name: "view all allows view basic",
+			rules: []*policyRule{viewAll},
+			operation: policiesModels.ViewBasicOperation,
+			expectedAccess: policiesModels.AllowAccess,
+			expectedRule: "viewAll",
+		},
+		{
+			name: "owner",
+			rules: []*policyRule{ownerEdit},
+			owners: []*typeModels.EntityReference{alice},
+			operation: policiesModels.EditDescriptionOperation,
+			expectedAccess: policiesModels.AllowAccess,
+			expectedRule: "ownerEdit",
+			expectedCalls: 1,
+		},
+		{
+			name: "owner through a team",
+			rules: []*policyRule{ownerEdit},
+			owners: []*typeModels.EntityReference{ { ID: "data", Type: "team", Inherited: true } },
+			operation: policiesModels.EditDescriptionOperation,
+			expectedAccess: policiesModels.AllowAccess,
+			expectedRule: "ownerEdit",
+			expectedCalls: 1,
+		},
+		{
+			name: "not the owner",
+			rules: []*policyRule{ownerEdit},
+			owners: []*typeModels.EntityReference{bob},
+			operation: policiesModels.EditDescriptionOperation,
+			expectedAccess: policiesModels.NotAllowAccess,
+			expectedCalls: 1,
+		},
+		{
+			name: "no owner",
+			rules: []*policyRule{ownerEdit, noOwnerEdit},
+			operation: policiesModels.EditTagsOperation,
+			expectedAccess: policiesModels.AllowAccess,
+			expectedRule: "noOwnerEdit",
+			expectedCalls: 1,
+		},
+		{
+			name: "deny wins",
+			rules: []*policyRule{allowDelete, denyDelete},
+			operation: policiesModels.DeleteOperation,
+			expectedAccess: policiesModels.DenyAccess,
+			expectedRule: "denyDelete",
+		},
+		{
+			name: "owners are not resolved without a condition",
+			rules: []*policyRule{viewAll, allowDelete},
+			owners: []*typeModels.EntityReference{alice},
+			operation: policiesModels.DeleteOperation,
+			expectedAccess: policiesModels.AllowAccess,
+			expectedRule: "allowDelete",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ownersResolver := &testOwnersResolver{ owners: test.owners }
+
+			e := &evaluation{
+				authorizer: NewAuthorizer(nil, nil, ownersResolver),
+				principal: &teamsModels.Principal{ ID: "alice", Name: "alice", Teams: []string{"engineering", "data"} },
+				resourceContext: policiesModels.NewResourceContext(policiesModels.TableResource, "1"),
+			}
+
+			permission, err := e.check(test.operation, test.rules)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if permission.Access != test.expectedAccess || permission.Rule != test.expectedRule {
+				t.Errorf("check(%v) = %v by %q, expected %v by %q", test.operation, permission.Access, permission.Rule, test.expectedAccess, test.expectedRule)
+			}
+
+			if ownersResolver.calls != test.expectedCalls {
+				t.Errorf("owners resolved %v times, expected %v", ownersResolver.calls, test.expectedCalls)
+			}
+		})
+	}
+}
+
+// Admins and anonymous requests are decided before any role is read
+func TestAuthorizePrincipal(t *testing.T) {
+	authorizer := NewAuthorizer(nil, nil, nil)
+	resourceContext := policiesModels.NewResourceContext(policiesModels.TableResource, "")
+
+	tests := []struct {
+		name string
+		principal *teamsModels.Principal
+		expected error
+	}{
+		{ name: "anonymous", principal: nil, expected: policiesModels.ErrPermissionDenied },
+		{ name: "admin", principal: &teamsModels.Principal{ Name: "admin", IsAdmin: true }, expected: nil },
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := authorizer.Authorize(test.principal, resourceContext, policiesModels.DeleteOperation); !errors.Is(err, test.expected) {
+				t.Errorf("Authorize() = %v, expected %v", err, test.expected)
+			}
+		})
+	}
+}
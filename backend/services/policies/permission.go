@@ -0,0 +1,154 @@
+package services
+
+import (
+	"slices"
+
+	policiesModels "github.com/nambuitechx/go-metadata/models/policies"
+	teamsModels "github.com/nambuitechx/go-metadata/models/teams"
+	automationsRepositories "github.com/nambuitechx/go-metadata/repositories/automations"
+	dataRepositories "github.com/nambuitechx/go-metadata/repositories/data"
+	servicesRepositories "github.com/nambuitechx/go-metadata/repositories/services"
+)
+
+type PermissionService struct {
+	Authorizer *Authorizer
+	DBServiceEntityRepository *servicesRepositories.DBServiceEntityRepository
+	TestConnectionDefinitionEntityRepository *servicesRepositories.TestConnectionDefinitionEntityRepository
+	DatabaseEntityRepository *dataRepositories.DatabaseEntityRepository
+	DatabaseSchemaEntityRepository *dataRepositories.DatabaseSchemaEntityRepository
+	TableEntityRepository *dataRepositories.TableEntityRepository
+	StoredProcedureEntityRepository *dataRepositories.StoredProcedureEntityRepository
+	WorkflowEntityRepository *automationsRepositories.WorkflowEntityRepository
+}
+
+func NewPermissionService(
+	authorizer *Authorizer,
+	dbserviceEntityRepository *servicesRepositories.DBServiceEntityRepository,
+	testConnectionDefinitionEntityRepository *servicesRepositories.TestConnectionDefinitionEntityRepository,
+	databaseEntityRepository *dataRepositories.DatabaseEntityRepository,
+	databaseSchemaEntityRepository *dataRepositories.DatabaseSchemaEntityRepository,
+	tableEntityRepository *dataRepositories.TableEntityRepository,
+	storedProcedureEntityRepository *dataRepositories.StoredProcedureEntityRepository,
+	workflowEntityRepository *automationsRepositories.WorkflowEntityRepository,
+) *PermissionService {
+	return &PermissionService{
+		Authorizer: authorizer,
+		DBServiceEntityRepository: dbserviceEntityRepository,
+		TestConnectionDefinitionEntityRepository: testConnectionDefinitionEntityRepository,
+		DatabaseEntityRepository: databaseEntityRepository,
+		DatabaseSchemaEntityRepository: databaseSchemaEntityRepository,
+		TableEntityRepository: tableEntityRepository,
+		StoredProcedureEntityRepository: storedProcedureEntityRepository,
+		WorkflowEntityRepository: workflowEntityRepository,
+	}
+}
+
+func (s *PermissionService) Health() string {
+	return "Permission service is available"
+}
+
+// GetAllResourcePermissions returns the permissions of the principal on every resource, without an entity
+func (s *PermissionService) GetAllResourcePermissions(principal *teamsModels.Principal) ([]*policiesModels.ResourcePermission, error) {
+	resourcePermissions := []*policiesModels.ResourcePermission{}
+
+	for _, resource := range policiesModels.Resources {
+		resourcePermission, err := s.Authorizer.GetPermissions(principal, policiesModels.NewResourceContext(resource, ""))
+
+		if err != nil {
+			return nil, err
+		}
+
+		resourcePermissions = append(resourcePermissions, resourcePermission)
+	}
+
+	return resourcePermissions, nil
+}
+
+func (s *PermissionService) GetResourcePermission(principal *teamsModels.Principal, resource string) (*policiesModels.ResourcePermission, error) {
+	if !slices.Contains(policiesModels.Resources, resource) {
+		return nil, policiesModels.ErrUnknownResource
+	}
+
+	return s.Authorizer.GetPermissions(principal, policiesModels.NewResourceContext(resource, ""))
+}
+
+func (s *PermissionService) GetEntityPermissionById(principal *teamsModels.Principal, resource string, id string) (*policiesModels.ResourcePermission, error) {
+	if !slices.Contains(policiesModels.Resources, resource) {
+		return nil, policiesModels.ErrUnknownResource
+	}
+
+	return s.Authorizer.GetPermissions(principal, policiesModels.NewResourceContext(resource, id))
+}
+
+func (s *PermissionService) GetEntityPermissionByFqn(principal *teamsModels.Principal, resource string, fqn string) (*policiesModels.ResourcePermission, error) {
+	id, err := s.getEntityIdByFqn(resource, fqn)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return s.Authorizer.GetPermissions(principal, policiesModels.NewResourceContext(resource, id))
+}
+
+func (s *PermissionService) getEntityIdByFqn(resource string, fqn string) (string, error) {
+	switch resource {
+	case policiesModels.DBServiceResource:
+		e, err := s.DBServiceEntityRepository.SelectDBServiceEntityByFqn(fqn, "non-deleted")
+
+		if err != nil {
+			return "", err
+		}
+
+		return e.ID, nil
+	case policiesModels.TestConnectionDefinitionResource:
+		e, err := s.TestConnectionDefinitionEntityRepository.SelectTestConnectionDefinitionEntityByFqn(fqn, "non-deleted")
+
+		if err != nil {
+			return "", err
+		}
+
+		return e.ID, nil
+	case policiesModels.DatabaseResource:
+		e, err := s.DatabaseEntityRepository.SelectDatabaseEntityByFqn(fqn, "non-deleted")
+
+		if err != nil {
+			return "", err
+		}
+
+		return e.ID, nil
+	case policiesModels.DatabaseSchemaResource:
+		e, err := s.DatabaseSchemaEntityRepository.SelectDatabaseSchemaEntityByFqn(fqn, "non-deleted")
+
+		if err != nil {
+			return "", err
+		}
+
+		return e.ID, nil
+	case policiesModels.TableResource:
+		e, err := s.TableEntityRepository.SelectTableEntityByFqn(fqn, "non-deleted")
+
+		if err != nil {
+			return "", err
+		}
+
+		return e.ID, nil
+	case policiesModels.StoredProcedureResource:
+		e, err := s.StoredProcedureEntityRepository.SelectStoredProcedureEntityByFqn(fqn, "non-deleted")
+
+		if err != nil {
+			return "", err
+		}
+
+		return e.ID, nil
+	case policiesModels.WorkflowResource:
+		e, err := s.WorkflowEntityRepository.SelectWorkflowEntityByFqn(fqn, "non-deleted")
+
+		if err != nil {
+			return "", err
+		}
+
+		return e.ID, nil
+	}
+
+	return "", policiesModels.ErrUnknownResource
+}
@@ -0,0 +1,224 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	baseModels "github.com/nambuitechx/go-metadata/models/base"
+	eventsModels "github.com/nambuitechx/go-metadata/models/events"
+	policiesModels "github.com/nambuitechx/go-metadata/models/policies"
+	typeModels "github.com/nambuitechx/go-metadata/models/type"
+	baseRepositories "github.com/nambuitechx/go-metadata/repositories/base"
+	eventsRepositories "github.com/nambuitechx/go-metadata/repositories/events"
+	policiesRepositories "github.com/nambuitechx/go-metadata/repositories/policies"
+	baseUtils "github.com/nambuitechx/go-metadata/utils"
+)
+
+type PolicyEntityService struct {
+	Transactor *baseRepositories.Transactor
+	PolicyEntityRepository *policiesRepositories.PolicyEntityRepository
+	ChangeEventRepository *eventsRepositories.ChangeEventRepository
+}
+
+func NewPolicyEntityService(
+	transactor *baseRepositories.Transactor,
+	policyEntityRepository *policiesRepositories.PolicyEntityRepository,
+	changeEventRepository *eventsRepositories.ChangeEventRepository,
+) *PolicyEntityService {
+	return &PolicyEntityService{
+		Transactor: transactor,
+		PolicyEntityRepository: policyEntityRepository,
+		ChangeEventRepository: changeEventRepository,
+	}
+}
+
+func (s *PolicyEntityService) Health() string {
+	return "Policy service is available"
+}
+
+func (s *PolicyEntityService) GetAllPolicyEntities(include string, limit int, before *baseModels.Cursor, after *baseModels.Cursor) ([]policiesModels.PolicyEntity, *baseModels.Paging, error) {
+	policyEntities, err := s.PolicyEntityRepository.SelectPolicyEntities(include, limit, before, after)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	policyEntities, paging := baseModels.NewPaging(policyEntities, limit, before, after, func(e policiesModels.PolicyEntity) *baseModels.Cursor {
+		return &baseModels.Cursor{ Name: e.Json.FullyQualifiedName, ID: e.ID }
+	})
+
+	return policyEntities, paging, nil
+}
+
+func (s *PolicyEntityService) GetCountPolicyEntities(include string) (*baseModels.EntityTotal, error) {
+	entityTotal, err := s.PolicyEntityRepository.SelectCountPolicyEntities(include)
+	return entityTotal, err
+}
+
+func (s *PolicyEntityService) GetPolicyEntityById(id string, include string) (*policiesModels.PolicyEntity, error) {
+	policyEntity, err := s.PolicyEntityRepository.SelectPolicyEntityById(id, include)
+	return policyEntity, err
+}
+
+func (s *PolicyEntityService) GetPolicyEntityByName(name string, include string) (*policiesModels.PolicyEntity, error) {
+	policyEntity, err := s.PolicyEntityRepository.SelectPolicyEntityByName(name, include)
+	return policyEntity, err
+}
+
+func (s *PolicyEntityService) CreatePolicyEntity(payload *policiesModels.CreatePolicyPayload, userName string) (*policiesModels.PolicyEntity, error) {
+	id := uuid.NewString()
+	now := time.Now().Unix()
+
+	policy := &policiesModels.Policy{
+		ID: id,
+		Name: payload.Name,
+		FullyQualifiedName: payload.Name,
+		DisplayName: payload.DisplayName,
+		Description: payload.Description,
+		Rules: payload.Rules,
+		Version: typeModels.InitialVersion,
+		UpdatedAt: now,
+		UpdatedBy: userName,
+		Deleted: false,
+	}
+
+	entity := &policiesModels.PolicyEntity{
+		ID: id,
+		Name: payload.Name,
+		Json: policy,
+		UpdatedAt: now,
+		UpdatedBy: userName,
+		Deleted: false,
+	}
+
+	var policyEntity *policiesModels.PolicyEntity
+
+	err := s.Transactor.RunInTx(func(tx *sqlx.Tx) error {
+		var err error
+		policyEntity, err = s.PolicyEntityRepository.WithTx(tx).InsertPolicyEntity(entity)
+
+		if err != nil {
+			return err
+		}
+
+		return s.recordChangeEvent(tx, eventsModels.EntityCreated, policyEntity, nil)
+	})
+
+	return policyEntity, err
+}
+
+func (s *PolicyEntityService) CreateOrUpdatePolicyEntity(payload *policiesModels.CreatePolicyPayload, userName string) (*policiesModels.PolicyEntity, error) {
+	exist, err := s.PolicyEntityRepository.SelectPolicyEntityByName(payload.Name, "all")
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return s.CreatePolicyEntity(payload, userName)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if exist.Deleted {
+		return nil, baseModels.ErrEntityDeleted
+	}
+
+	exist.Json.DisplayName = payload.DisplayName
+	exist.Json.Description = payload.Description
+	exist.Json.Rules = payload.Rules
+	exist.UpdatedAt = time.Now().Unix()
+	exist.UpdatedBy = userName
+
+	var policyEntity *policiesModels.PolicyEntity
+
+	err = s.Transactor.RunInTx(func(tx *sqlx.Tx) error {
+		previous, err := s.PolicyEntityRepository.WithTx(tx).SelectPolicyEntityById(exist.ID, "all")
+
+		if err != nil {
+			return err
+		}
+
+		changeDescription, err := baseUtils.CompareEntities(previous.Json, exist.Json)
+
+		if err != nil {
+			return err
+		}
+
+		if changeDescription.IsEmpty() {
+			policyEntity = previous
+			return nil
+		}
+
+		changeDescription.PreviousVersion = previous.Json.Version
+		exist.Json.Version = baseUtils.NextVersion(previous.Json.Version, baseUtils.IsMajorChange(changeDescription))
+		exist.Json.ChangeDescription = changeDescription
+		exist.Json.UpdatedAt = exist.UpdatedAt
+		exist.Json.UpdatedBy = exist.UpdatedBy
+
+		policyEntity, err = s.PolicyEntityRepository.WithTx(tx).UpdatePolicyEntity(exist, previous.Json.Version)
+
+		if errors.Is(err, sql.ErrNoRows) {
+			return baseModels.ErrPreconditionFailed
+		}
+
+		if err != nil {
+			return err
+		}
+
+		return s.recordChangeEvent(tx, eventsModels.EntityUpdated, policyEntity, changeDescription)
+	})
+
+	return policyEntity, err
+}
+
+// DeletePolicyEntity deletes the policy, the roles which reference it skip it when they are evaluated
+func (s *PolicyEntityService) DeletePolicyEntity(exist *policiesModels.PolicyEntity, userName string) error {
+	exist.UpdatedAt = time.Now().Unix()
+	exist.UpdatedBy = userName
+
+	return s.Transactor.RunInTx(func(tx *sqlx.Tx) error {
+		if err := s.PolicyEntityRepository.WithTx(tx).DeletePolicyEntityById(exist.ID); err != nil {
+			return err
+		}
+
+		return s.recordChangeEvent(tx, eventsModels.EntityDeleted, exist, nil)
+	})
+}
+
+// EnsureDefaultPolicies creates the default policies which do not exist yet, existing ones are left untouched
+func (s *PolicyEntityService) EnsureDefaultPolicies(userName string) error {
+	for _, payload := range policiesModels.DefaultPolicies {
+		_, err := s.PolicyEntityRepository.SelectPolicyEntityByName(payload.Name, "all")
+
+		if err == nil {
+			continue
+		}
+
+		if !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+
+		if _, err := s.CreatePolicyEntity(payload, userName); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *PolicyEntityService) recordChangeEvent(tx *sqlx.Tx, eventType string, policyEntity *policiesModels.PolicyEntity, changeDescription *typeModels.ChangeDescription) error {
+	changeEvent := eventsModels.NewChangeEventEntity(
+		eventType,
+		policyEntity.Json.ToEntityReference(),
+		policyEntity.Json.Version,
+		changeDescription,
+		policyEntity.UpdatedBy,
+		policyEntity.UpdatedAt,
+		policyEntity.Json,
+	)
+
+	_, err := s.ChangeEventRepository.WithTx(tx).InsertChangeEvent(changeEvent)
+	return err
+}
@@ -0,0 +1,108 @@
+package services
+
+import (
+	"log"
+
+	searchModels "github.com/nambuitechx/go-metadata/models/search"
+	searchRepositories "github.com/nambuitechx/go-metadata/repositories/search"
+)
+
+// SearchIndexer is the search backend. Entity services write to it once their transaction is committed and the
+// search endpoints read from it, so another engine only needs another implementation
+type SearchIndexer interface {
+	// IndexEntities adds the documents or replaces the ones with the same entity type and id
+	IndexEntities(documents []*searchModels.SearchDocument) error
+	DeleteEntity(entityType string, id string) error
+	// DeleteEntitiesByPrefix follows the children of a deleted entity, the entities whose fullyQualifiedName starts
+	// with prefix followed by a dot
+	DeleteEntitiesByPrefix(prefix string) error
+	// DeleteIndex removes every document of an entity type before it is reindexed
+	DeleteIndex(entityType string) error
+
+	Search(q string, indexes []string, from int, size int) (*searchModels.SearchResult, error)
+	Suggest(q string, types []string, size int) ([]*searchModels.SearchSuggestion, error)
+}
+
+// NewSearchIndexer returns the indexer named in the settings, Postgres when the name is unknown
+func NewSearchIndexer(name string, searchRepository *searchRepositories.SearchRepository) SearchIndexer {
+	if name == searchModels.MemorySearchIndexer {
+		return NewMemorySearchIndexer()
+	}
+
+	return NewPostgresSearchIndexer(searchRepository)
+}
+
+// IndexEntity writes an entity to the index. The entity is already stored, so a failure is only logged and the
+// next reindex repairs the index
+func IndexEntity(indexer SearchIndexer, entityType string, id string, entity interface{}) {
+	document, err := searchModels.NewSearchDocument(entityType, id, entity)
+
+	if err == nil {
+		err = indexer.IndexEntities([]*searchModels.SearchDocument{ document })
+	}
+
+	if err != nil {
+		log.Printf("Failed to index %v %v: %v\n", entityType, id, err)
+	}
+}
+
+// DeleteEntity removes an entity and its children from the index, failures are logged like in IndexEntity
+func DeleteEntity(indexer SearchIndexer, entityType string, id string, fqn string) {
+	if err := indexer.DeleteEntitiesByPrefix(fqn); err != nil {
+		log.Printf("Failed to remove the children of %v %v from the index: %v\n", entityType, fqn, err)
+	}
+
+	if err := indexer.DeleteEntity(entityType, id); err != nil {
+		log.Printf("Failed to remove %v %v from the index: %v\n", entityType, fqn, err)
+	}
+}
+
+// PostgresSearchIndexer keeps the search vectors in the search_index table, hits are joined back to the entity
+// tables so their deleted state is always current
+type PostgresSearchIndexer struct {
+	SearchRepository *searchRepositories.SearchRepository
+}
+
+func NewPostgresSearchIndexer(searchRepository *searchRepositories.SearchRepository) *PostgresSearchIndexer {
+	return &PostgresSearchIndexer{ SearchRepository: searchRepository }
+}
+
+func (i *PostgresSearchIndexer) IndexEntities(documents []*searchModels.SearchDocument) error {
+	return i.SearchRepository.InsertSearchDocuments(documents)
+}
+
+func (i *PostgresSearchIndexer) DeleteEntity(entityType string, id string) error {
+	return i.SearchRepository.DeleteSearchDocument(entityType, id)
+}
+
+func (i *PostgresSearchIndexer) DeleteEntitiesByPrefix(prefix string) error {
+	return i.SearchRepository.DeleteSearchDocumentsByPrefix(prefix)
+}
+
+func (i *PostgresSearchIndexer) DeleteIndex(entityType string) error {
+	return i.SearchRepository.DeleteSearchDocumentsByEntityType(entityType)
+}
+
+func (i *PostgresSearchIndexer) Search(q string, indexes []string, from int, size int) (*searchModels.SearchResult, error) {
+	searchHits, err := i.SearchRepository.SelectSearchHits(q, indexes, from, size)
+
+	if err != nil {
+		return nil, err
+	}
+
+	searchFacetCounts, err := i.SearchRepository.SelectSearchFacets(q, indexes)
+
+	if err != nil {
+		return nil, err
+	}
+
+	result := searchModels.NewSearchResult(q, from, size)
+	result.Hits = searchHits
+	result.AddFacetCounts(searchFacetCounts)
+
+	return result, nil
+}
+
+func (i *PostgresSearchIndexer) Suggest(q string, types []string, size int) ([]*searchModels.SearchSuggestion, error) {
+	return i.SearchRepository.SelectSearchSuggestions(q, types, size)
+}
@@ -0,0 +1,539 @@
+package services
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+
+	searchModels "github.com/nambuitechx/go-metadata/models/search"
+	typeModels "github.com/nambuitechx/go-metadata/models/type"
+)
+
+// Weights of the searched fields, in the same order as the weights of entity_search_vector
+var memoryFieldWeights = map[string]float64 {
+	"name": 1.0,
+	"displayName": 1.0,
+	"description": 0.4,
+	"columns.name": 0.2,
+	"columns.description": 0.1,
+}
+
+// Words left out of the index and of queries, like the english text search configuration does
+var memoryStopWords = map[string]bool {
+	"a": true, "an": true, "and": true, "as": true, "at": true, "by": true, "for": true, "from": true, "in": true,
+	"is": true, "of": true, "on": true, "or": true, "the": true, "to": true, "with": true,
+}
+
+// Trigram word similarity above which a suggestion is kept when the name does not start with the input
+const memorySuggestThreshold = 0.5
+
+type memoryDocument struct {
+	document *searchModels.SearchDocument
+	// Values of the searched fields, a column field has one value per column
+	fields map[string][]string
+	// Weight of every term of the document
+	terms map[string]float64
+}
+
+// MemorySearchIndexer is an inverted index held in process, for tests and small deployments. It is empty on
+// start, a reindex fills it from the entity tables
+type MemorySearchIndexer struct {
+	mutex sync.RWMutex
+	documents map[string]*memoryDocument
+	postings map[string]map[string]bool
+}
+
+func NewMemorySearchIndexer() *MemorySearchIndexer {
+	return &MemorySearchIndexer{
+		documents: map[string]*memoryDocument{},
+		postings: map[string]map[string]bool{},
+	}
+}
+
+func memoryKey(entityType string, id string) string {
+	return entityType + "/" + id
+}
+
+func (i *MemorySearchIndexer) IndexEntities(documents []*searchModels.SearchDocument) error {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+
+	for _, document := range documents {
+		key := memoryKey(document.EntityType, document.ID)
+		i.remove(key)
+
+		indexed := &memoryDocument{ document: document, fields: searchFields(document.Source), terms: map[string]float64{} }
+
+		for field, values := range indexed.fields {
+			for _, value := range values {
+				for _, term := range tokenize(value) {
+					indexed.terms[term] += memoryFieldWeights[field]
+				}
+			}
+		}
+
+		for term := range indexed.terms {
+			if i.postings[term] == nil {
+				i.postings[term] = map[string]bool{}
+			}
+
+			i.postings[term][key] = true
+		}
+
+		i.documents[key] = indexed
+	}
+
+	return nil
+}
+
+func (i *MemorySearchIndexer) DeleteEntity(entityType string, id string) error {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+
+	i.remove(memoryKey(entityType, id))
+	return nil
+}
+
+func (i *MemorySearchIndexer) DeleteEntitiesByPrefix(prefix string) error {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+
+	for key, indexed := range i.documents {
+		if strings.HasPrefix(indexed.document.FullyQualifiedName, prefix + ".") {
+			i.remove(key)
+		}
+	}
+
+	return nil
+}
+
+func (i *MemorySearchIndexer) DeleteIndex(entityType string) error {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+
+	for key, indexed := range i.documents {
+		if indexed.document.EntityType == entityType {
+			i.remove(key)
+		}
+	}
+
+	return nil
+}
+
+// remove drops a document and its postings, the caller holds the lock
+func (i *MemorySearchIndexer) remove(key string) {
+	indexed, ok := i.documents[key]
+
+	if !ok {
+		return
+	}
+
+	for term := range indexed.terms {
+		delete(i.postings[term], key)
+
+		if len(i.postings[term]) == 0 {
+			delete(i.postings, term)
+		}
+	}
+
+	delete(i.documents, key)
+}
+
+// Search supports the same syntax as websearch_to_tsquery: words must all match, OR between two words matches
+// either and a leading - excludes a word. Quoted phrases are matched as their words
+func (i *MemorySearchIndexer) Search(q string, indexes []string, from int, size int) (*searchModels.SearchResult, error) {
+	i.mutex.RLock()
+	defer i.mutex.RUnlock()
+
+	groups, excluded := parseMemoryQuery(q)
+	result := searchModels.NewSearchResult(q, from, size)
+
+	if len(groups) == 0 {
+		return result, nil
+	}
+
+	searched := map[string]bool{}
+
+	for _, index := range indexes {
+		searched[index] = true
+	}
+
+	// Candidates contain one of the terms of the first group
+	candidates := map[string]bool{}
+
+	for _, term := range groups[0] {
+		for key := range i.postings[term] {
+			candidates[key] = true
+		}
+	}
+
+	terms := map[string]bool{}
+
+	for _, group := range groups {
+		for _, term := range group {
+			terms[term] = true
+		}
+	}
+
+	hits := []*searchModels.SearchHit{}
+	facets := map[string]map[string]int{}
+
+	for _, facet := range searchModels.SearchFacets {
+		facets[facet] = map[string]int{}
+	}
+
+	for key := range candidates {
+		indexed := i.documents[key]
+		document := indexed.document
+
+		if document.Deleted || !searched[document.EntityType] || !matchesMemoryQuery(indexed, groups, excluded) {
+			continue
+		}
+
+		score := 0.0
+
+		for term := range terms {
+			score += indexed.terms[term]
+		}
+
+		hits = append(hits, &searchModels.SearchHit{
+			EntityType: document.EntityType,
+			ID: document.ID,
+			Score: score,
+			Source: document.Source,
+			Highlights: highlightFields(indexed.fields, terms),
+		})
+
+		facets["entityType"][document.EntityType]++
+
+		if service, ok := document.Source["service"].(map[string]interface{}); ok {
+			if name, ok := service["name"].(string); ok {
+				facets["service"][name]++
+			}
+		}
+
+		for _, facet := range []string{ "serviceType", "tableType" } {
+			if value, ok := document.Source[facet].(string); ok {
+				facets[facet][value]++
+			}
+		}
+	}
+
+	sort.Slice(hits, func(a, b int) bool {
+		if hits[a].Score != hits[b].Score {
+			return hits[a].Score > hits[b].Score
+		}
+
+		fqnA, _ := hits[a].Source["fullyQualifiedName"].(string)
+		fqnB, _ := hits[b].Source["fullyQualifiedName"].(string)
+
+		if fqnA != fqnB {
+			return fqnA < fqnB
+		}
+
+		return hits[a].ID < hits[b].ID
+	})
+
+	if from < len(hits) {
+		result.Hits = hits[from:min(from + size, len(hits))]
+	}
+
+	facetCounts := []*searchModels.SearchFacetCount{}
+
+	for _, facet := range searchModels.SearchFacets {
+		counts := []*searchModels.SearchFacetCount{}
+
+		for value, count := range facets[facet] {
+			counts = append(counts, &searchModels.SearchFacetCount{ Facet: facet, Value: value, Count: count })
+		}
+
+		sort.Slice(counts, func(a, b int) bool {
+			if counts[a].Count != counts[b].Count {
+				return counts[a].Count > counts[b].Count
+			}
+
+			return counts[a].Value < counts[b].Value
+		})
+
+		facetCounts = append(facetCounts, counts...)
+	}
+
+	result.AddFacetCounts(facetCounts)
+	return result, nil
+}
+
+// Suggest ranks names and fullyQualifiedNames by trigram similarity like the pg_trgm suggestions, names starting
+// with the input come first
+func (i *MemorySearchIndexer) Suggest(q string, types []string, size int) ([]*searchModels.SearchSuggestion, error) {
+	i.mutex.RLock()
+	defer i.mutex.RUnlock()
+
+	suggested := map[string]bool{}
+
+	for _, entityType := range types {
+		suggested[entityType] = true
+	}
+
+	suggestions := []*searchModels.SearchSuggestion{}
+
+	for _, indexed := range i.documents {
+		document := indexed.document
+
+		if document.Deleted || !suggested[document.EntityType] {
+			continue
+		}
+
+		name, _ := document.Source["name"].(string)
+		name = strings.ToLower(name)
+		score := max(wordSimilarity(q, name), wordSimilarity(q, strings.ToLower(document.FullyQualifiedName)) * 0.9)
+		prefix := strings.HasPrefix(name, q)
+
+		if !prefix && score < memorySuggestThreshold {
+			continue
+		}
+
+		if prefix {
+			score += 1
+		}
+
+		suggestion := &searchModels.SearchSuggestion{ Score: score }
+		suggestion.EntityReference = typeModels.EntityReference{
+			ID: document.ID,
+			Type: document.EntityType,
+			FullyQualifiedName: document.FullyQualifiedName,
+		}
+		suggestion.Name, _ = document.Source["name"].(string)
+		suggestion.DisplayName, _ = document.Source["displayName"].(string)
+		suggestion.Description, _ = document.Source["description"].(string)
+
+		suggestions = append(suggestions, suggestion)
+	}
+
+	sort.Slice(suggestions, func(a, b int) bool {
+		if suggestions[a].Score != suggestions[b].Score {
+			return suggestions[a].Score > suggestions[b].Score
+		}
+
+		return suggestions[a].FullyQualifiedName < suggestions[b].FullyQualifiedName
+	})
+
+	if len(suggestions) > size {
+		suggestions = suggestions[:size]
+	}
+
+	return suggestions, nil
+}
+
+// searchFields reads the searched fields from the source of a document
+func searchFields(source searchModels.SearchSource) map[string][]string {
+	fields := map[string][]string{}
+
+	for _, field := range []string{ "name", "displayName", "description" } {
+		if value, ok := source[field].(string); ok && value != "" {
+			fields[field] = []string{ value }
+		}
+	}
+
+	columns, _ := source["columns"].([]interface{})
+
+	for _, c := range columns {
+		column, ok := c.(map[string]interface{})
+
+		if !ok {
+			continue
+		}
+
+		for field, key := range map[string]string{ "columns.name": "name", "columns.description": "description" } {
+			if value, ok := column[key].(string); ok && value != "" {
+				fields[field] = append(fields[field], value)
+			}
+		}
+	}
+
+	return fields
+}
+
+// parseMemoryQuery returns groups of terms of which the document must match one term each, and the excluded terms
+func parseMemoryQuery(q string) ([][]string, []string) {
+	groups := [][]string{}
+	excluded := []string{}
+	or := false
+
+	for _, word := range strings.Fields(strings.ReplaceAll(q, `"`, " ")) {
+		if strings.EqualFold(word, "or") {
+			or = len(groups) > 0
+			continue
+		}
+
+		if strings.HasPrefix(word, "-") {
+			excluded = append(excluded, tokenize(word[1:])...)
+			continue
+		}
+
+		terms := tokenize(word)
+
+		if len(terms) == 0 {
+			continue
+		}
+
+		if or {
+			groups[len(groups) - 1] = append(groups[len(groups) - 1], terms...)
+		} else {
+			for _, term := range terms {
+				groups = append(groups, []string{ term })
+			}
+		}
+
+		or = false
+	}
+
+	return groups, excluded
+}
+
+func matchesMemoryQuery(indexed *memoryDocument, groups [][]string, excluded []string) bool {
+	for _, term := range excluded {
+		if _, ok := indexed.terms[term]; ok {
+			return false
+		}
+	}
+
+	for _, group := range groups {
+		matched := false
+
+		for _, term := range group {
+			if _, ok := indexed.terms[term]; ok {
+				matched = true
+				break
+			}
+		}
+
+		if !matched {
+			return false
+		}
+	}
+
+	return true
+}
+
+// highlightFields wraps the words matching a term in <em></em>, only the values with a match are kept
+func highlightFields(fields map[string][]string, terms map[string]bool) searchModels.SearchHighlights {
+	highlights := searchModels.SearchHighlights{}
+
+	for field, values := range fields {
+		for _, value := range values {
+			if fragment, ok := highlight(value, terms); ok {
+				highlights[field] = append(highlights[field], fragment)
+			}
+		}
+	}
+
+	return highlights
+}
+
+func highlight(value string, terms map[string]bool) (string, bool) {
+	builder := strings.Builder{}
+	matched := false
+
+	for _, word := range splitWords(value) {
+		if word.isWord && terms[normalizeTerm(word.text)] {
+			builder.WriteString("<em>" + word.text + "</em>")
+			matched = true
+		} else {
+			builder.WriteString(word.text)
+		}
+	}
+
+	return builder.String(), matched
+}
+
+type textPart struct {
+	text string
+	isWord bool
+}
+
+// splitWords splits text into words of letters and digits and the text between them
+func splitWords(text string) []textPart {
+	parts := []textPart{}
+	runes := []rune(text)
+	start := 0
+
+	for j := 1; j <= len(runes); j++ {
+		if j == len(runes) || isWordRune(runes[j]) != isWordRune(runes[start]) {
+			parts = append(parts, textPart{ text: string(runes[start:j]), isWord: isWordRune(runes[start]) })
+			start = j
+		}
+	}
+
+	return parts
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// tokenize returns the normalized terms of text, stop words left out
+func tokenize(text string) []string {
+	terms := []string{}
+
+	for _, word := range strings.FieldsFunc(text, func(r rune) bool { return !isWordRune(r) }) {
+		if term := normalizeTerm(word); term != "" {
+			terms = append(terms, term)
+		}
+	}
+
+	return terms
+}
+
+// normalizeTerm lower cases a word and strips the plural, an empty term is a stop word
+func normalizeTerm(word string) string {
+	word = strings.ToLower(word)
+
+	if memoryStopWords[word] {
+		return ""
+	}
+
+	switch {
+	case len(word) > 4 && strings.HasSuffix(word, "ies"):
+		return word[:len(word) - 3] + "y"
+	case len(word) > 3 && strings.HasSuffix(word, "s") && !strings.HasSuffix(word, "ss"):
+		return word[:len(word) - 1]
+	}
+
+	return word
+}
+
+// wordSimilarity is the share of the trigrams of q found in text, trigrams are built like pg_trgm does from each
+// word padded with two spaces in front and one behind
+func wordSimilarity(q string, text string) float64 {
+	queryTrigrams := trigrams(q)
+
+	if len(queryTrigrams) == 0 {
+		return 0
+	}
+
+	textTrigrams := trigrams(text)
+	shared := 0
+
+	for trigram := range queryTrigrams {
+		if textTrigrams[trigram] {
+			shared++
+		}
+	}
+
+	return float64(shared) / float64(len(queryTrigrams))
+}
+
+func trigrams(text string) map[string]bool {
+	set := map[string]bool{}
+
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool { return !isWordRune(r) }) {
+		runes := []rune("  " + word + " ")
+
+		for j := 0; j + 3 <= len(runes); j++ {
+			set[string(runes[j:j + 3])] = true
+		}
+	}
+
+	return set
+}
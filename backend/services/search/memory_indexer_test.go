@@ -0,0 +1,213 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+
+	searchModels "github.com/nambuitechx/go-metadata/models/search"
+)
+
+func newTestMemoryIndexer(t *testing.T) *MemorySearchIndexer {
+	indexer := NewMemorySearchIndexer()
+	documents := []*searchModels.SearchDocument{}
+
+	for _, e := range []struct {
+		entityType string
+		id string
+		source map[string]interface{}
+	}{
+		{ "table", "orders", map[string]interface{}{ "name": "orders", "fullyQualifiedName": "pg.shop.sales.orders", "description": "Customer orders" } },
+		{ "table", "customers", map[string]interface{}{ "name": "customers", "fullyQualifiedName": "pg.shop.sales.customers", "description": "Buyers of the orders" } },
+		{ "table", "payments", map[string]interface{}{
+			"name": "payments",
+			"fullyQualifiedName": "pg.shop.billing.payments",
+			"columns": []interface{}{ map[string]interface{}{ "name": "order_id", "description": "Paid order" } },
+		} },
+		{ "storedProcedure", "load_orders", map[string]interface{}{ "name": "load_orders", "fullyQualifiedName": "pg.shop.sales.load_orders" } },
+		{ "table", "archived", map[string]interface{}{ "name": "orders_archive", "fullyQualifiedName": "pg.shop.sales.orders_archive", "deleted": true } },
+	} {
+		document, err := searchModels.NewSearchDocument(e.entityType, e.id, e.source)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		documents = append(documents, document)
+	}
+
+	if err := indexer.IndexEntities(documents); err != nil {
+		t.Fatal(err)
+	}
+
+	return indexer
+}
+
+func TestMemorySearch(t *testing.T) {
+	indexer := newTestMemoryIndexer(t)
+
+	tests := []struct {
+		name string
+		q string
+		indexes []string
+		expected []string
+	}{
+		{ name: "name ranks above description", q: "orders", indexes: []string{"table"}, expected: []string{"orders", "customers", "payments"} },
+		{ name: "every word must match", q: "buyers orders", indexes: []string{"table"}, expected: []string{"customers"} },
+		{ name: "or", q: "buyers or paid", indexes: []string{"table"}, expected: []string{"customers", "payments"} },
+		{ name: "excluded word", q: "orders -buyers", indexes: []string{"table"}, expected: []string{"orders", "payments"} },
+		{ name: "stop words are ignored", q: "the orders", indexes: []string{"table"}, expected: []string{"orders", "customers", "payments"} },
+		{ name: "searched indexes only", q: "load", indexes: []string{"table"}, expected: []string{} },
+		{ name: "other index", q: "load", indexes: []string{"storedProcedure"}, expected: []string{"load_orders"} },
+		{ name: "no term", q: "the", indexes: []string{"table"}, expected: []string{} },
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result, err := indexer.Search(test.q, test.indexes, 0, 10)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			ids := []string{}
+
+			for _, hit := range result.Hits {
+				ids = append(ids, hit.ID)
+			}
+
+			if !reflect.DeepEqual(ids, test.expected) {
+				t.Errorf("Search(%q) = %v, expected %v", test.q, ids, test.expected)
+			}
+
+			if result.Total != len(test.expected) {
+				t.Errorf("Search(%q) total = %v, expected %v", test.q, result.Total, len(test.expected))
+			}
+		})
+	}
+}
+
+func TestMemorySearchHighlights(t *testing.T) {
+	indexer := newTestMemoryIndexer(t)
+	result, err := indexer.Search("paid", []string{"table"}, 0, 10)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(result.Hits) != 1 {
+		t.Fatalf("expected one hit, got %v", len(result.Hits))
+	}
+
+	expected := searchModels.SearchHighlights{ "columns.description": {"<em>Paid</em> order"} }
+
+	if highlights := result.Hits[0].Highlights; !reflect.DeepEqual(highlights, expected) {
+		t.Errorf("highlights = %v, expected %v", highlights, expected)
+	}
+}
+
+func TestMemorySuggest(t *testing.T) {
+	indexer := newTestMemoryIndexer(t)
+
+	tests := []struct {
+		name string
+		q string
+		types []string
+		size int
+		expected []string
+	}{
+		{ name: "prefix first", q: "ord", types: []string{"table", "storedProcedure"}, size: 10, expected: []string{"orders", "load_orders"} },
+		{ name: "typo", q: "custmers", types: []string{"table"}, size: 10, expected: []string{"customers"} },
+		{ name: "size", q: "ord", types: []string{"table", "storedProcedure"}, size: 1, expected: []string{"orders"} },
+		{ name: "suggested types only", q: "load", types: []string{"table"}, size: 10, expected: []string{} },
+		{ name: "no match", q: "zzz", types: []string{"table"}, size: 10, expected: []string{} },
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			suggestions, err := indexer.Suggest(test.q, test.types, test.size)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			ids := []string{}
+
+			for _, suggestion := range suggestions {
+				ids = append(ids, suggestion.ID)
+			}
+
+			if !reflect.DeepEqual(ids, test.expected) {
+				t.Errorf("Suggest(%q) = %v, expected %v", test.q, ids, test.expected)
+			}
+		})
+	}
+}
+
+func TestMemoryDelete(t *testing.T) {
+	tests := []struct {
+		name string
+		delete func(indexer *MemorySearchIndexer) error
+		expected []string
+	}{
+		{
+			name: "entity",
+			delete: func(indexer *MemorySearchIndexer) error { return indexer.DeleteEntity("table", "orders") },
+			expected: []string{"customers", "payments"},
+		},
+		{
+			name: "entities by prefix",
+			delete: func(indexer *MemorySearchIndexer) error { return indexer.DeleteEntitiesByPrefix("pg.shop.sales") },
+			expected: []string{"payments"},
+		},
+		{
+			name: "index",
+			delete: func(indexer *MemorySearchIndexer) error { return indexer.DeleteIndex("table") },
+			expected: []string{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			indexer := newTestMemoryIndexer(t)
+
+			if err := test.delete(indexer); err != nil {
+				t.Fatal(err)
+			}
+
+			result, err := indexer.Search("orders", []string{"table"}, 0, 10)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			ids := []string{}
+
+			for _, hit := range result.Hits {
+				ids = append(ids, hit.ID)
+			}
+
+			if !reflect.DeepEqual(ids, test.expected) {
+				t.Errorf("hits = %v, expected %v", ids, test.expected)
+			}
+		})
+	}
+}
+
+func TestNormalizeTerm(t *testing.T) {
+	tests := []struct {
+		word string
+		expected string
+	}{
+		{ word: "Orders", expected: "order" },
+		{ word: "categories", expected: "category" },
+		{ word: "address", expected: "address" },
+		{ word: "bus", expected: "bus" },
+		{ word: "The", expected: "" },
+	}
+
+	for _, test := range tests {
+		if term := normalizeTerm(test.word); term != test.expected {
+			t.Errorf("normalizeTerm(%q) = %q, expected %q", test.word, term, test.expected)
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package services
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	searchModels "github.com/nambuitechx/go-metadata/models/search"
+	securityModels "github.com/nambuitechx/go-metadata/models/security"
+	searchRepositories "github.com/nambuitechx/go-metadata/repositories/search"
+)
+
+type SearchService struct {
+	SearchIndexer SearchIndexer
+	SearchRepository *searchRepositories.SearchRepository
+
+	mutex sync.Mutex
+	reindexStatus *searchModels.ReindexStatus
+}
+
+func NewSearchService(searchIndexer SearchIndexer, searchRepository *searchRepositories.SearchRepository) *SearchService {
+	return &SearchService{ SearchIndexer: searchIndexer, SearchRepository: searchRepository }
+}
+
+func (s *SearchService) Health() string {
+	return "Search service is available"
+}
+
+// Search returns a page of the entities matching q in the given indexes, with the facet counts of all of them.
+// The secrets of the hits are masked
+func (s *SearchService) Search(q string, indexes []string, from int, size int) (*searchModels.SearchResult, error) {
+	result, err := s.SearchIndexer.Search(q, indexes, from, size)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, hit := range result.Hits {
+		securityModels.MaskJsonSecrets(map[string]interface{}(hit.Source))
+	}
+
+	return result, nil
+}
+
+// Suggest returns the entities whose name or fullyQualifiedName best matches q, tolerating typos and prefixes
+func (s *SearchService) Suggest(q string, types []string, size int) ([]*searchModels.SearchSuggestion, error) {
+	return s.SearchIndexer.Suggest(q, types, size)
+}
+
+// StartReindex rebuilds the index of the entity types from the entity tables in the background, one reindex
+// runs at a time
+func (s *SearchService) StartReindex(entityTypes []string, batchSize int) (*searchModels.ReindexStatus, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.reindexStatus != nil && s.reindexStatus.Status == searchModels.ReindexRunning {
+		return nil, searchModels.ErrReindexRunning
+	}
+
+	s.reindexStatus = &searchModels.ReindexStatus{
+		ID: uuid.NewString(),
+		Status: searchModels.ReindexRunning,
+		EntityTypes: entityTypes,
+		BatchSize: batchSize,
+		Progress: map[string]*searchModels.ReindexProgress{},
+		StartedAt: time.Now().Unix(),
+	}
+
+	for _, entityType := range entityTypes {
+		s.reindexStatus.Progress[entityType] = &searchModels.ReindexProgress{}
+	}
+
+	go s.reindex(entityTypes, batchSize)
+
+	return s.reindexStatus.Copy(), nil
+}
+
+// GetReindexStatus returns the status of the last reindex, nil when none was started
+func (s *SearchService) GetReindexStatus() *searchModels.ReindexStatus {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.reindexStatus == nil {
+		return nil
+	}
+
+	return s.reindexStatus.Copy()
+}
+
+func (s *SearchService) reindex(entityTypes []string, batchSize int) {
+	err := s.reindexEntityTypes(entityTypes, batchSize)
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.reindexStatus.CompletedAt = time.Now().Unix()
+	s.reindexStatus.Status = searchModels.ReindexCompleted
+
+	if err != nil {
+		log.Println("Failed to reindex:", err)
+		s.reindexStatus.Status = searchModels.ReindexFailed
+		s.reindexStatus.Failure = err.Error()
+	}
+}
+
+func (s *SearchService) reindexEntityTypes(entityTypes []string, batchSize int) error {
+	for _, entityType := range entityTypes {
+		total, err := s.SearchRepository.SelectCountSearchEntities(entityType)
+
+		if err != nil {
+			return err
+		}
+
+		s.updateReindexProgress(entityType, total.Total, 0)
+
+		if err := s.SearchIndexer.DeleteIndex(entityType); err != nil {
+			return err
+		}
+
+		indexed := 0
+		afterId := ""
+
+		for {
+			rows, err := s.SearchRepository.SelectSearchEntityRows(entityType, afterId, batchSize)
+
+			if err != nil {
+				return err
+			}
+
+			if len(rows) == 0 {
+				break
+			}
+
+			documents := []*searchModels.SearchDocument{}
+
+			for _, row := range rows {
+				document, err := searchModels.NewSearchDocument(entityType, row.ID, row.Json)
+
+				if err != nil {
+					return err
+				}
+
+				documents = append(documents, document)
+			}
+
+			if err := s.SearchIndexer.IndexEntities(documents); err != nil {
+				return err
+			}
+
+			indexed += len(rows)
+			afterId = rows[len(rows) - 1].ID
+			s.updateReindexProgress(entityType, max(total.Total, indexed), indexed)
+		}
+	}
+
+	return nil
+}
+
+func (s *SearchService) updateReindexProgress(entityType string, total int, indexed int) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	progress := s.reindexStatus.Progress[entityType]
+	progress.Total = total
+	progress.Indexed = indexed
+
+	s.reindexStatus.Total = 0
+	s.reindexStatus.Indexed = 0
+
+	for _, p := range s.reindexStatus.Progress {
+		s.reindexStatus.Total += p.Total
+		s.reindexStatus.Indexed += p.Indexed
+	}
+}
@@ -0,0 +1,100 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/google/uuid"
+	securityModels "github.com/nambuitechx/go-metadata/models/security"
+)
+
+// FileSecretsManager keeps the secrets in a JSON file readable by the server user only, the stored value is a
+// reference to the secret. The file is rewritten as a whole on every new secret
+type FileSecretsManager struct {
+	mu sync.RWMutex
+	path string
+	secrets map[string]string
+}
+
+func NewFileSecretsManager(path string) (*FileSecretsManager, error) {
+	m := &FileSecretsManager{ path: path, secrets: map[string]string{} }
+	bytes, err := os.ReadFile(path)
+
+	if errors.Is(err, os.ErrNotExist) {
+		return m, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(bytes, &m.secrets); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+func (m *FileSecretsManager) Encrypt(value string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	id := uuid.NewString()
+	m.secrets[id] = value
+
+	if err := m.save(); err != nil {
+		delete(m.secrets, id)
+		return "", err
+	}
+
+	return securityModels.SecretReferencePrefix + id, nil
+}
+
+func (m *FileSecretsManager) Decrypt(value string) (string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	secret, ok := m.secrets[strings.TrimPrefix(value, securityModels.SecretReferencePrefix)]
+
+	if !ok || !m.IsEncrypted(value) {
+		return "", securityModels.ErrSecretNotFound
+	}
+
+	return secret, nil
+}
+
+func (m *FileSecretsManager) IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, securityModels.SecretReferencePrefix)
+}
+
+// save writes a temporary file next to the secrets file and renames it, a crash leaves the previous file intact
+func (m *FileSecretsManager) save() error {
+	bytes, err := json.Marshal(m.secrets)
+
+	if err != nil {
+		return err
+	}
+
+	temp, err := os.CreateTemp(filepath.Dir(m.path), filepath.Base(m.path) + ".*")
+
+	if err != nil {
+		return err
+	}
+
+	defer os.Remove(temp.Name())
+
+	if _, err := temp.Write(bytes); err != nil {
+		temp.Close()
+		return err
+	}
+
+	if err := temp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(temp.Name(), m.path)
+}
@@ -0,0 +1,76 @@
+package services
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"strings"
+
+	securityModels "github.com/nambuitechx/go-metadata/models/security"
+)
+
+// LocalSecretsManager encrypts the secrets with AES-256-GCM under a key derived from the configured one, the stored
+// value is the prefix followed by the base64 of the nonce and the ciphertext
+type LocalSecretsManager struct {
+	aead cipher.AEAD
+}
+
+func NewLocalSecretsManager(key string) (*LocalSecretsManager, error) {
+	if key == "" {
+		return nil, errors.New("the secrets key is empty")
+	}
+
+	derived := sha256.Sum256([]byte(key))
+	block, err := aes.NewCipher(derived[:])
+
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := cipher.NewGCM(block)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &LocalSecretsManager{ aead: aead }, nil
+}
+
+func (m *LocalSecretsManager) Encrypt(value string) (string, error) {
+	nonce := make([]byte, m.aead.NonceSize())
+
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	sealed := m.aead.Seal(nonce, nonce, []byte(value), nil)
+	return securityModels.EncryptedSecretPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+func (m *LocalSecretsManager) Decrypt(value string) (string, error) {
+	if !m.IsEncrypted(value) {
+		return "", securityModels.ErrSecretDecryption
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, securityModels.EncryptedSecretPrefix))
+
+	if err != nil || len(sealed) < m.aead.NonceSize() {
+		return "", securityModels.ErrSecretDecryption
+	}
+
+	nonce, ciphertext := sealed[:m.aead.NonceSize()], sealed[m.aead.NonceSize():]
+	plain, err := m.aead.Open(nil, nonce, ciphertext, nil)
+
+	if err != nil {
+		return "", securityModels.ErrSecretDecryption
+	}
+
+	return string(plain), nil
+}
+
+func (m *LocalSecretsManager) IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, securityModels.EncryptedSecretPrefix)
+}
@@ -0,0 +1,121 @@
+package services
+
+import (
+	"fmt"
+
+	securityModels "github.com/nambuitechx/go-metadata/models/security"
+)
+
+// SecretsManager protects the secrets of the connection configs before they are stored
+type SecretsManager interface {
+	// Encrypt returns the value to store in place of a plain secret
+	Encrypt(value string) (string, error)
+	// Decrypt returns the plain secret of a stored value
+	Decrypt(value string) (string, error)
+	// IsEncrypted tells whether a value was written by the manager
+	IsEncrypted(value string) bool
+}
+
+// NewSecretsManager returns the secrets manager named in the settings, the local one when the name is unknown
+func NewSecretsManager(name string, key string, file string) (SecretsManager, error) {
+	if name == securityModels.FileSecretsManager {
+		return NewFileSecretsManager(file)
+	}
+
+	return NewLocalSecretsManager(key)
+}
+
+// EncryptConfig encrypts the plain secrets of a connection config in place. A secret equal to the one of the
+// previous config, or sent back masked, keeps its stored value, so that saving an unchanged connection changes nothing
+func EncryptConfig(manager SecretsManager, config map[string]interface{}, previous map[string]interface{}) error {
+	for key, value := range config {
+		switch v := value.(type) {
+		case map[string]interface{}:
+			previousValue, _ := previous[key].(map[string]interface{})
+
+			if err := EncryptConfig(manager, v, previousValue); err != nil {
+				return err
+			}
+		case string:
+			if !securityModels.SecretFields[key] || v == "" || manager.IsEncrypted(v) {
+				continue
+			}
+
+			if v == securityModels.SecretMask {
+				previousValue, ok := previous[key].(string)
+
+				if !ok || previousValue == "" {
+					return fmt.Errorf("%v: %w", key, securityModels.ErrSecretMasked)
+				}
+
+				if manager.IsEncrypted(previousValue) {
+					config[key] = previousValue
+					continue
+				}
+
+				v = previousValue
+			}
+
+			if previousValue, ok := previous[key].(string); ok && manager.IsEncrypted(previousValue) {
+				if plain, err := manager.Decrypt(previousValue); err == nil && plain == v {
+					config[key] = previousValue
+					continue
+				}
+			}
+
+			encrypted, err := manager.Encrypt(v)
+
+			if err != nil {
+				return err
+			}
+
+			config[key] = encrypted
+		}
+	}
+
+	return nil
+}
+
+// DecryptConfig decrypts the secrets of a connection config in place
+func DecryptConfig(manager SecretsManager, config map[string]interface{}) error {
+	for key, value := range config {
+		switch v := value.(type) {
+		case map[string]interface{}:
+			if err := DecryptConfig(manager, v); err != nil {
+				return err
+			}
+		case string:
+			if !securityModels.SecretFields[key] || !manager.IsEncrypted(v) {
+				continue
+			}
+
+			plain, err := manager.Decrypt(v)
+
+			if err != nil {
+				return err
+			}
+
+			config[key] = plain
+		}
+	}
+
+	return nil
+}
+
+// HasPlainSecrets tells whether a connection config still holds secrets which are not encrypted
+func HasPlainSecrets(manager SecretsManager, config map[string]interface{}) bool {
+	for key, value := range config {
+		switch v := value.(type) {
+		case map[string]interface{}:
+			if HasPlainSecrets(manager, v) {
+				return true
+			}
+		case string:
+			if securityModels.SecretFields[key] && v != "" && !manager.IsEncrypted(v) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,170 @@
+package services
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	securityModels "github.com/nambuitechx/go-metadata/models/security"
+)
+
+func TestSecretsManagers(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secrets.json")
+
+	tests := []struct {
+		name string
+		new func() (SecretsManager, error)
+	}{
+		{ name: "local", new: func() (SecretsManager, error) { return NewSecretsManager(securityModels.LocalSecretsManager, "key", "") } },
+		{ name: "file", new: func() (SecretsManager, error) { return NewSecretsManager(securityModels.FileSecretsManager, "", path) } },
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			manager, err := test.new()
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			encrypted, err := manager.Encrypt("password")
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if encrypted == "password" || !manager.IsEncrypted(encrypted) || manager.IsEncrypted("password") {
+				t.Errorf("Encrypt() = %v, expected an encrypted value", encrypted)
+			}
+
+			// A new manager reads what the previous one wrote
+			reopened, err := test.new()
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if plain, err := reopened.Decrypt(encrypted); err != nil || plain != "password" {
+				t.Errorf("Decrypt() = %v, %v, expected password", plain, err)
+			}
+
+			if _, err := manager.Decrypt("password"); err == nil {
+				t.Errorf("Decrypt() of a plain value, expected an error")
+			}
+		})
+	}
+}
+
+func TestLocalSecretsManagerDecrypt(t *testing.T) {
+	manager, err := NewLocalSecretsManager("key")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	other, err := NewLocalSecretsManager("other")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	encrypted, err := manager.Encrypt("password")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tampered := []byte(encrypted)
+	tampered[len(tampered) - 2] ^= 1
+
+	tests := []struct {
+		name string
+		manager *LocalSecretsManager
+		value string
+	}{
+		{ name: "other key", manager: other, value: encrypted },
+		{ name: "tampered", manager: manager, value: string(tampered) },
+		{ name: "not base64", manager: manager, value: securityModels.EncryptedSecretPrefix + "!" },
+		{ name: "too short", manager: manager, value: securityModels.EncryptedSecretPrefix + "AAAA" },
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := test.manager.Decrypt(test.value); !errors.Is(err, securityModels.ErrSecretDecryption) {
+				t.Errorf("Decrypt() err = %v, expected %v", err, securityModels.ErrSecretDecryption)
+			}
+		})
+	}
+
+	if _, err := NewLocalSecretsManager(""); err == nil {
+		t.Errorf("NewLocalSecretsManager() with an empty key, expected an error")
+	}
+}
+
+func TestEncryptConfig(t *testing.T) {
+	manager, err := NewLocalSecretsManager("key")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stored, err := manager.Encrypt("password")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		config map[string]interface{}
+		previous map[string]interface{}
+		// the stored value is kept
+		expectedKept bool
+		expected string
+		expectedErr error
+	}{
+		{ name: "new secret", config: map[string]interface{}{ "password": "password" }, expected: "password" },
+		{ name: "unchanged secret", config: map[string]interface{}{ "password": "password" }, previous: map[string]interface{}{ "password": stored }, expectedKept: true, expected: "password" },
+		{ name: "changed secret", config: map[string]interface{}{ "password": "changed" }, previous: map[string]interface{}{ "password": stored }, expected: "changed" },
+		{ name: "masked secret", config: map[string]interface{}{ "password": securityModels.SecretMask }, previous: map[string]interface{}{ "password": stored }, expectedKept: true, expected: "password" },
+		{ name: "masked plain secret", config: map[string]interface{}{ "password": securityModels.SecretMask }, previous: map[string]interface{}{ "password": "password" }, expected: "password" },
+		{ name: "masked secret without a stored one", config: map[string]interface{}{ "password": securityModels.SecretMask }, expectedErr: securityModels.ErrSecretMasked },
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config := map[string]interface{}{ "hostPort": "localhost:5432", "authType": test.config }
+			previous := map[string]interface{}{ "authType": test.previous }
+			err := EncryptConfig(manager, config, previous)
+
+			if !errors.Is(err, test.expectedErr) {
+				t.Fatalf("EncryptConfig() err = %v, expected %v", err, test.expectedErr)
+			}
+
+			if err != nil {
+				return
+			}
+
+			encrypted := test.config["password"].(string)
+
+			if kept := encrypted == stored; kept != test.expectedKept {
+				t.Errorf("EncryptConfig() kept the stored secret: %v, expected %v", kept, test.expectedKept)
+			}
+
+			if HasPlainSecrets(manager, config) || config["hostPort"] != "localhost:5432" {
+				t.Errorf("EncryptConfig() = %v, expected encrypted secrets only", config)
+			}
+
+			if err := DecryptConfig(manager, config); err != nil {
+				t.Fatal(err)
+			}
+
+			expected := map[string]interface{}{ "hostPort": "localhost:5432", "authType": map[string]interface{}{ "password": test.expected } }
+
+			if !reflect.DeepEqual(config, expected) {
+				t.Errorf("DecryptConfig() = %v, expected %v", config, expected)
+			}
+		})
+	}
+}
@@ -1,47 +1,117 @@
 package services
 
 import (
+	"database/sql"
+	"encoding/json"
+	"errors"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
 	baseModels "github.com/nambuitechx/go-metadata/models/base"
 	servicesModels "github.com/nambuitechx/go-metadata/models/services"
+	baseRepositories "github.com/nambuitechx/go-metadata/repositories/base"
+	typeModels "github.com/nambuitechx/go-metadata/models/type"
+	typeRepositories "github.com/nambuitechx/go-metadata/repositories/type"
+	eventsModels "github.com/nambuitechx/go-metadata/models/events"
+	eventsRepositories "github.com/nambuitechx/go-metadata/repositories/events"
+	eventsServices "github.com/nambuitechx/go-metadata/services/events"
+	dataServices "github.com/nambuitechx/go-metadata/services/data"
+	searchServices "github.com/nambuitechx/go-metadata/services/search"
+	securityServices "github.com/nambuitechx/go-metadata/services/security"
+	teamsServices "github.com/nambuitechx/go-metadata/services/teams"
+	baseUtils "github.com/nambuitechx/go-metadata/utils"
+	dataRepositories "github.com/nambuitechx/go-metadata/repositories/data"
 	servicesRepositories "github.com/nambuitechx/go-metadata/repositories/services"
 )
 
 type DBServiceEntityService struct {
+	Transactor *baseRepositories.Transactor
 	DBServiceEntityRepository *servicesRepositories.DBServiceEntityRepository
+	DatabaseEntityRepository *dataRepositories.DatabaseEntityRepository
+	DatabaseSchemaEntityRepository *dataRepositories.DatabaseSchemaEntityRepository
+	TableEntityRepository *dataRepositories.TableEntityRepository
+	StoredProcedureEntityRepository *dataRepositories.StoredProcedureEntityRepository
+	EntityExtensionRepository *typeRepositories.EntityExtensionRepository
+	ChangeEventRepository *eventsRepositories.ChangeEventRepository
+	Versioning *dataServices.Versioning
+	SearchIndexer searchServices.SearchIndexer
+	OwnershipService *teamsServices.OwnershipService
+	SecretsManager securityServices.SecretsManager
+	Cascade *dataServices.Cascade
 }
 
-func NewDBServiceEntityService(dbserviceEntityRepository *servicesRepositories.DBServiceEntityRepository) *DBServiceEntityService {
-	return &DBServiceEntityService{ DBServiceEntityRepository: dbserviceEntityRepository }
+func NewDBServiceEntityService(
+	transactor *baseRepositories.Transactor,
+	dbserviceEntityRepository *servicesRepositories.DBServiceEntityRepository,
+	databaseEntityRepository *dataRepositories.DatabaseEntityRepository,
+	databaseSchemaEntityRepository *dataRepositories.DatabaseSchemaEntityRepository,
+	tableEntityRepository *dataRepositories.TableEntityRepository,
+	storedProcedureEntityRepository *dataRepositories.StoredProcedureEntityRepository,
+	entityExtensionRepository *typeRepositories.EntityExtensionRepository,
+	cascadeDeletionRepository *typeRepositories.CascadeDeletionRepository,
+	changeEventRepository *eventsRepositories.ChangeEventRepository,
+	searchIndexer searchServices.SearchIndexer,
+	ownershipService *teamsServices.OwnershipService,
+	secretsManager securityServices.SecretsManager,
+) *DBServiceEntityService {
+	return &DBServiceEntityService{
+		Transactor: transactor,
+		DBServiceEntityRepository: dbserviceEntityRepository,
+		DatabaseEntityRepository: databaseEntityRepository,
+		DatabaseSchemaEntityRepository: databaseSchemaEntityRepository,
+		TableEntityRepository: tableEntityRepository,
+		StoredProcedureEntityRepository: storedProcedureEntityRepository,
+		EntityExtensionRepository: entityExtensionRepository,
+		ChangeEventRepository: changeEventRepository,
+		Versioning: dataServices.NewVersioning(entityExtensionRepository, changeEventRepository),
+		SearchIndexer: searchIndexer,
+		OwnershipService: ownershipService,
+		SecretsManager: secretsManager,
+		Cascade: dataServices.NewCascade(databaseEntityRepository, databaseSchemaEntityRepository, tableEntityRepository, storedProcedureEntityRepository, entityExtensionRepository, cascadeDeletionRepository, changeEventRepository),
+	}
 }
 
 func (s *DBServiceEntityService) Health() string {
 	return "DBService service is available"
 }
 
-func (s *DBServiceEntityService) GetAllDBServiceEntities(limit int, offset int) ([]servicesModels.DBServiceEntity, error) {
-	dbserviceEntity, err := s.DBServiceEntityRepository.SelectDBServiceEntities(limit, offset)
-	return dbserviceEntity, err
+func (s *DBServiceEntityService) GetAllDBServiceEntities(include string, limit int, before *baseModels.Cursor, after *baseModels.Cursor) ([]servicesModels.DBServiceEntity, *baseModels.Paging, error) {
+	dbserviceEntity, err := s.DBServiceEntityRepository.SelectDBServiceEntities(include, limit, before, after)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	dbserviceEntity, paging := baseModels.NewPaging(dbserviceEntity, limit, before, after, func(e servicesModels.DBServiceEntity) *baseModels.Cursor {
+		return &baseModels.Cursor{ Name: e.Json.FullyQualifiedName, ID: e.ID }
+	})
+
+	return dbserviceEntity, paging, nil
 }
 
-func (s *DBServiceEntityService) GetCountDBServiceEntities() (*baseModels.EntityTotal, error) {
-	entityTotal, err := s.DBServiceEntityRepository.SelectCountDBServiceEntities()
+func (s *DBServiceEntityService) GetCountDBServiceEntities(include string) (*baseModels.EntityTotal, error) {
+	entityTotal, err := s.DBServiceEntityRepository.SelectCountDBServiceEntities(include)
 	return entityTotal, err
 }
 
-func (s *DBServiceEntityService) GetDBServiceEntityById(id string) (*servicesModels.DBServiceEntity, error) {
-	dbserviceEntity, err := s.DBServiceEntityRepository.SelectDBServiceEntityById(id)
+func (s *DBServiceEntityService) GetDBServiceEntityById(id string, include string) (*servicesModels.DBServiceEntity, error) {
+	dbserviceEntity, err := s.DBServiceEntityRepository.SelectDBServiceEntityById(id, include)
 	return dbserviceEntity, err
 }
 
-func (s *DBServiceEntityService) GetDBServiceEntityByFqn(fqn string) (*servicesModels.DBServiceEntity, error) {
-	dbserviceEntity, err := s.DBServiceEntityRepository.SelectDBServiceEntityByFqn(fqn)
+func (s *DBServiceEntityService) GetDBServiceEntityByFqn(fqn string, include string) (*servicesModels.DBServiceEntity, error) {
+	dbserviceEntity, err := s.DBServiceEntityRepository.SelectDBServiceEntityByFqn(fqn, include)
 	return dbserviceEntity, err
 }
 
-func (s *DBServiceEntityService) CreateDBServiceEntity(payload *servicesModels.CreateDBServiceEntityPayload) (*servicesModels.DBServiceEntity, error) {
+func (s *DBServiceEntityService) CreateDBServiceEntity(payload *servicesModels.CreateDBServiceEntityPayload, userName string) (*servicesModels.DBServiceEntity, error) {
+	owners, err := s.OwnershipService.ResolveOwners(payload.Owners)
+
+	if err != nil {
+		return nil, err
+	}
+
 	id := uuid.NewString()
 	now := time.Now().Unix()
 
@@ -53,6 +123,10 @@ func (s *DBServiceEntityService) CreateDBServiceEntity(payload *servicesModels.C
 		Description: payload.Description,
 		ServiceType: payload.ServiceType,
 		Connection: payload.Connection,
+		Owners: owners,
+		Version: typeModels.InitialVersion,
+		UpdatedAt: now,
+		UpdatedBy: userName,
 		Deleted: false,
 	}
 
@@ -62,18 +136,59 @@ func (s *DBServiceEntityService) CreateDBServiceEntity(payload *servicesModels.C
 		ServiceType: payload.ServiceType,
 		Json: dbservice,
 		UpdatedAt: now,
+		UpdatedBy: userName,
 		Deleted: false,
 	}
 
-	dbserviceEntity, err := s.DBServiceEntityRepository.InsertDBServiceEntity(entity)
+	dbserviceEntity, err := s.insertDBServiceEntity(entity)
 	return dbserviceEntity, err
 }
 
-func (s *DBServiceEntityService) CreateOrUpdateDBServiceEntity(payload *servicesModels.CreateDBServiceEntityPayload) (*servicesModels.DBServiceEntity, error) {
-	exist, err := s.DBServiceEntityRepository.SelectDBServiceEntityByFqn(payload.Name)
+func (s *DBServiceEntityService) CreateOrUpdateDBServiceEntity(payload *servicesModels.CreateDBServiceEntityPayload, ifMatch string, userName string) (*servicesModels.DBServiceEntity, error) {
+	owners, err := s.OwnershipService.ResolveOwners(payload.Owners)
+
+	if err != nil {
+		return nil, err
+	}
+
+	exist, err := s.DBServiceEntityRepository.SelectDBServiceEntityByFqn(payload.Name, "all")
 
 	if err == nil {
-		updated, err := s.DBServiceEntityRepository.UpdateDBServiceEntity(exist)
+		if exist.Deleted {
+			return nil, baseModels.ErrEntityDeleted
+		}
+
+		if err := baseModels.CheckIfMatch(ifMatch, exist.Json.Version); err != nil {
+			return nil, err
+		}
+
+		if exist.ServiceType != payload.ServiceType {
+			return nil, errors.New("service type cannot be changed")
+		}
+
+		exist.Json.DisplayName = baseUtils.MergeString(exist.Json.DisplayName, payload.DisplayName)
+		exist.Json.Description = baseUtils.MergeString(exist.Json.Description, payload.Description)
+		exist.Json.Connection = payload.Connection
+
+		if payload.Owners != nil {
+			exist.Json.Owners = owners
+		}
+
+		exist.UpdatedAt = time.Now().Unix()
+		exist.UpdatedBy = userName
+
+		var updated *servicesModels.DBServiceEntity
+
+		err = s.Transactor.RunInTx(func(tx *sqlx.Tx) error {
+			var err error
+			updated, err = s.updateDBServiceEntity(tx, exist, eventsModels.EntityUpdated)
+			return err
+		})
+
+		if err == nil {
+			searchServices.IndexEntity(s.SearchIndexer, "databaseService", updated.ID, updated.Json)
+		}
+
 		return updated, err
 	}
 
@@ -88,6 +203,10 @@ func (s *DBServiceEntityService) CreateOrUpdateDBServiceEntity(payload *services
 		Description: payload.Description,
 		ServiceType: payload.ServiceType,
 		Connection: payload.Connection,
+		Owners: owners,
+		Version: typeModels.InitialVersion,
+		UpdatedAt: now,
+		UpdatedBy: userName,
 		Deleted: false,
 	}
 
@@ -97,19 +216,460 @@ func (s *DBServiceEntityService) CreateOrUpdateDBServiceEntity(payload *services
 		ServiceType: payload.ServiceType,
 		Json: dbservice,
 		UpdatedAt: now,
+		UpdatedBy: userName,
 		Deleted: false,
 	}
 
-	dbserviceEntity, err := s.DBServiceEntityRepository.InsertDBServiceEntity(entity)
+	dbserviceEntity, err := s.insertDBServiceEntity(entity)
+	return dbserviceEntity, err
+}
+
+func (s *DBServiceEntityService) DeleteDBServiceEntityById(id string, hardDelete bool, recursive bool, userName string) error {
+	exist, err := s.DBServiceEntityRepository.SelectDBServiceEntityById(id, "all")
+
+	if err != nil {
+		return err
+	}
+
+	return s.deleteDBServiceEntity(exist, hardDelete, recursive, userName)
+}
+
+func (s *DBServiceEntityService) DeleteDBServiceEntityByFqn(fqn string, hardDelete bool, recursive bool, userName string) error {
+	exist, err := s.DBServiceEntityRepository.SelectDBServiceEntityByFqn(fqn, "all")
+
+	if err != nil {
+		return err
+	}
+
+	return s.deleteDBServiceEntity(exist, hardDelete, recursive, userName)
+}
+
+func (s *DBServiceEntityService) deleteDBServiceEntity(exist *servicesModels.DBServiceEntity, hardDelete bool, recursive bool, userName string) error {
+	fqn := exist.Json.FullyQualifiedName
+
+	if !recursive {
+		databaseTotal, err := s.DatabaseEntityRepository.SelectCountDatabaseEntities(fqn, "all")
+
+		if err != nil {
+			return err
+		}
+
+		if databaseTotal.Total > 0 {
+			return baseModels.ErrEntityHasChildren
+		}
+	}
+
+	now := time.Now().Unix()
+
+	var cascadedEntities []*dataServices.CascadedEntity
+
+	err := s.Transactor.RunInTx(func(tx *sqlx.Tx) error {
+		if hardDelete {
+			if err := s.Cascade.RecordHardDelete(tx, exist.ID, fqn, now, userName); err != nil {
+				return err
+			}
+
+			if err := s.StoredProcedureEntityRepository.WithTx(tx).DeleteStoredProcedureEntitiesByPrefix(fqn); err != nil {
+				return err
+			}
+
+			if err := s.TableEntityRepository.WithTx(tx).DeleteTableEntitiesByPrefix(fqn); err != nil {
+				return err
+			}
+
+			if err := s.DatabaseSchemaEntityRepository.WithTx(tx).DeleteDatabaseSchemaEntitiesByPrefix(fqn); err != nil {
+				return err
+			}
+
+			if err := s.DatabaseEntityRepository.WithTx(tx).DeleteDatabaseEntitiesByPrefix(fqn); err != nil {
+				return err
+			}
+
+			if err := s.DBServiceEntityRepository.WithTx(tx).DeleteDBServiceEntityById(exist.ID); err != nil {
+				return err
+			}
+
+			exist.UpdatedAt = now
+			exist.UpdatedBy = userName
+			return s.Versioning.RecordChangeEvent(tx, eventsModels.EntityDeleted, exist.Json, nil, exist.UpdatedBy, exist.UpdatedAt)
+		}
+
+		var err error
+		cascadedEntities, err = s.Cascade.SoftDelete(tx, exist.ID, fqn, now, userName)
+
+		if err != nil {
+			return err
+		}
+
+		exist.Deleted = true
+		exist.Json.Deleted = true
+		exist.UpdatedAt = now
+		exist.UpdatedBy = userName
+
+		_, err = s.updateDBServiceEntity(tx, exist, eventsModels.EntitySoftDeleted)
+		return err
+	})
+
+	if err != nil {
+		return err
+	}
+
+	if hardDelete {
+		searchServices.DeleteEntity(s.SearchIndexer, "databaseService", exist.ID, fqn)
+	} else {
+		dataServices.IndexCascadedEntities(s.SearchIndexer, cascadedEntities)
+		searchServices.IndexEntity(s.SearchIndexer, "databaseService", exist.ID, exist.Json)
+	}
+
+	return nil
+}
+
+func (s *DBServiceEntityService) RestoreDBServiceEntity(id string, userName string) (*servicesModels.DBServiceEntity, error) {
+	exist, err := s.DBServiceEntityRepository.SelectDBServiceEntityById(id, "all")
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !exist.Deleted {
+		return nil, baseModels.ErrEntityNotDeleted
+	}
+
+	fqn := exist.Json.FullyQualifiedName
+	now := time.Now().Unix()
+
+	var dbserviceEntity *servicesModels.DBServiceEntity
+	var cascadedEntities []*dataServices.CascadedEntity
+
+	err = s.Transactor.RunInTx(func(tx *sqlx.Tx) error {
+		var err error
+		cascadedEntities, err = s.Cascade.Restore(tx, exist.ID, fqn, now, userName)
+
+		if err != nil {
+			return err
+		}
+
+		exist.Deleted = false
+		exist.Json.Deleted = false
+		exist.UpdatedAt = now
+		exist.UpdatedBy = userName
+
+		dbserviceEntity, err = s.updateDBServiceEntity(tx, exist, eventsModels.EntityUpdated)
+		return err
+	})
+
+	if err == nil {
+		dataServices.IndexCascadedEntities(s.SearchIndexer, cascadedEntities)
+		searchServices.IndexEntity(s.SearchIndexer, "databaseService", dbserviceEntity.ID, dbserviceEntity.Json)
+	}
+
 	return dbserviceEntity, err
 }
 
-func (s *DBServiceEntityService) DeleteDBServiceEntityById(id string) error {
-	err := s.DBServiceEntityRepository.DeleteDBServiceEntityById(id)
-	return err
+// The test connection result is runtime state rather than a definition change, so no new version is created
+func (s *DBServiceEntityService) UpdateDBServiceTestConnectionResult(exist *servicesModels.DBServiceEntity, testConnectionResult *servicesModels.TestConnectionResult, userName string) (*servicesModels.DBServiceEntity, error) {
+	changeDescription := &typeModels.ChangeDescription{
+		FieldsAdded: []typeModels.FieldChange{},
+		FieldsUpdated: []typeModels.FieldChange{
+			{ Name: "testConnectionResult", OldValue: exist.Json.TestConnectionResult, NewValue: testConnectionResult },
+		},
+		FieldsDeleted: []typeModels.FieldChange{},
+		PreviousVersion: exist.Json.Version,
+	}
+
+	exist.Json.TestConnectionResult = testConnectionResult
+	exist.UpdatedAt = time.Now().Unix()
+	exist.UpdatedBy = userName
+
+	var dbserviceEntity *servicesModels.DBServiceEntity
+
+	err := s.Transactor.RunInTx(func(tx *sqlx.Tx) error {
+		var err error
+		dbserviceEntity, err = s.DBServiceEntityRepository.WithTx(tx).UpdateDBServiceEntity(exist, exist.Json.Version)
+
+		if errors.Is(err, sql.ErrNoRows) {
+			return baseModels.ErrPreconditionFailed
+		}
+
+		if err != nil {
+			return err
+		}
+
+		return s.Versioning.RecordChangeEvent(tx, eventsModels.EntityUpdated, dbserviceEntity.Json, changeDescription, dbserviceEntity.UpdatedBy, dbserviceEntity.UpdatedAt)
+	})
+
+	if err == nil {
+		searchServices.IndexEntity(s.SearchIndexer, "databaseService", dbserviceEntity.ID, dbserviceEntity.Json)
+	}
+
+	return dbserviceEntity, err
 }
 
-func (s *DBServiceEntityService) DeleteDBServiceEntityByFqn(fqn string) error {
-	err := s.DBServiceEntityRepository.DeleteDBServiceEntityByFqn(fqn)
-	return err
+// PatchDBServiceEntity applies a JSON patch to the entity, immutable fields cannot be patched
+func (s *DBServiceEntityService) PatchDBServiceEntity(exist *servicesModels.DBServiceEntity, payload []baseModels.JsonPatchOperation, ifMatch string, userName string) (*servicesModels.DBServiceEntity, error) {
+	if err := baseModels.CheckIfMatch(ifMatch, exist.Json.Version); err != nil {
+		return nil, err
+	}
+
+	if err := baseModels.ValidateJsonPatch(payload, baseModels.ImmutableFields); err != nil {
+		return nil, err
+	}
+
+	var dbservice servicesModels.DBService
+
+	if err := baseUtils.ApplyJsonPatch(exist.Json, payload, &dbservice); err != nil {
+		return nil, err
+	}
+
+	if err := servicesModels.ValidateDBServiceConnection(dbservice.ServiceType, dbservice.Connection); err != nil {
+		return nil, err
+	}
+
+	owners, err := s.OwnershipService.ResolveOwners(dbservice.Owners)
+
+	if err != nil {
+		return nil, err
+	}
+
+	dbservice.Owners = owners
+
+	exist.Json = &dbservice
+	exist.UpdatedAt = time.Now().Unix()
+	exist.UpdatedBy = userName
+
+	var dbserviceEntity *servicesModels.DBServiceEntity
+
+	err = s.Transactor.RunInTx(func(tx *sqlx.Tx) error {
+		var err error
+		dbserviceEntity, err = s.updateDBServiceEntity(tx, exist, eventsModels.EntityUpdated)
+		return err
+	})
+
+	if err == nil {
+		searchServices.IndexEntity(s.SearchIndexer, "databaseService", dbserviceEntity.ID, dbserviceEntity.Json)
+	}
+
+	return dbserviceEntity, err
+}
+
+func (s *DBServiceEntityService) GetDBServiceEntityVersions(id string) (*typeModels.EntityHistory, error) {
+	dbserviceEntity, err := s.DBServiceEntityRepository.SelectDBServiceEntityById(id, "all")
+
+	if err != nil {
+		return nil, err
+	}
+
+	entityExtensions, err := s.EntityExtensionRepository.SelectEntityVersions(id, "databaseService")
+
+	if err != nil {
+		return nil, err
+	}
+
+	entityHistory := &typeModels.EntityHistory{ EntityType: "databaseService", Versions: []interface{}{ dbserviceEntity.Json } }
+
+	for _, e := range entityExtensions {
+		entityHistory.Versions = append(entityHistory.Versions, e.Json)
+	}
+
+	return entityHistory, nil
+}
+
+func (s *DBServiceEntityService) GetDBServiceEntityVersion(id string, version float64) (interface{}, error) {
+	dbserviceEntity, err := s.DBServiceEntityRepository.SelectDBServiceEntityById(id, "all")
+
+	if err != nil {
+		return nil, err
+	}
+
+	if dbserviceEntity.Json.Version == version {
+		return dbserviceEntity.Json, nil
+	}
+
+	entityExtension, err := s.EntityExtensionRepository.SelectEntityExtension(id, typeModels.VersionExtension("databaseService", version))
+
+	if err != nil {
+		return nil, err
+	}
+
+	return entityExtension.Json, nil
+}
+
+// updateDBServiceEntity encrypts the connection of the dbservice before writing it when it changed
+func (s *DBServiceEntityService) updateDBServiceEntity(tx *sqlx.Tx, exist *servicesModels.DBServiceEntity, eventType string) (*servicesModels.DBServiceEntity, error) {
+	previous, err := s.DBServiceEntityRepository.WithTx(tx).SelectDBServiceEntityById(exist.ID, "all")
+
+	if err != nil {
+		return nil, err
+	}
+
+	// exist was read before a concurrent update changed the stored version
+	if previous.Json.Version != exist.Json.Version {
+		return nil, baseModels.ErrPreconditionFailed
+	}
+
+	if err := s.encryptConnection(exist.Json, previous.Json); err != nil {
+		return nil, err
+	}
+
+	changeDescription, err := s.Versioning.NextVersion(tx, "databaseService", previous.ID, previous.Json, exist.Json, exist.UpdatedAt, exist.UpdatedBy)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if changeDescription == nil {
+		return previous, nil
+	}
+
+	dbserviceEntity, err := s.DBServiceEntityRepository.WithTx(tx).UpdateDBServiceEntity(exist, previous.Json.Version)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, baseModels.ErrPreconditionFailed
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.OwnershipService.SetOwners(tx, dbserviceEntity.ID, "databaseService", dbserviceEntity.Json.Owners); err != nil {
+		return nil, err
+	}
+
+	if err := s.Versioning.RecordChangeEvent(tx, eventType, dbserviceEntity.Json, changeDescription, dbserviceEntity.UpdatedBy, dbserviceEntity.UpdatedAt); err != nil {
+		return nil, err
+	}
+
+	return dbserviceEntity, nil
+}
+
+// insertDBServiceEntity creates the dbservice and records an entityCreated event in the same transaction
+func (s *DBServiceEntityService) insertDBServiceEntity(entity *servicesModels.DBServiceEntity) (*servicesModels.DBServiceEntity, error) {
+	if err := s.encryptConnection(entity.Json, nil); err != nil {
+		return nil, err
+	}
+
+	var dbserviceEntity *servicesModels.DBServiceEntity
+
+	err := s.Transactor.RunInTx(func(tx *sqlx.Tx) error {
+		var err error
+		dbserviceEntity, err = s.DBServiceEntityRepository.WithTx(tx).InsertDBServiceEntity(entity)
+
+		if err != nil {
+			return err
+		}
+
+		if err := s.OwnershipService.SetOwners(tx, dbserviceEntity.ID, "databaseService", dbserviceEntity.Json.Owners); err != nil {
+			return err
+		}
+
+		return s.Versioning.RecordChangeEvent(tx, eventsModels.EntityCreated, dbserviceEntity.Json, nil, dbserviceEntity.UpdatedBy, dbserviceEntity.UpdatedAt)
+	})
+
+	if err == nil {
+		searchServices.IndexEntity(s.SearchIndexer, "databaseService", dbserviceEntity.ID, dbserviceEntity.Json)
+	}
+
+	return dbserviceEntity, err
+}
+
+// DecryptDBServiceConnection replaces the stored secrets of the connection with the plain ones, the result must only
+// be returned to the ingestion bots
+func (s *DBServiceEntityService) DecryptDBServiceConnection(dbservice *servicesModels.DBService) error {
+	if dbservice.Connection == nil {
+		return nil
+	}
+
+	return securityServices.DecryptConfig(s.SecretsManager, dbservice.Connection.Config)
+}
+
+// EncryptStoredSecrets encrypts the secrets of the dbservices stored before the secrets manager was in place, the
+// version is kept since the connection does not change. The secrets of their previous versions are encrypted as
+// well, and the ones of their change events are masked
+func (s *DBServiceEntityService) EncryptStoredSecrets() error {
+	dbserviceEntities, err := s.DBServiceEntityRepository.SelectDBServiceEntities("all", -1, nil, nil)
+
+	if err != nil {
+		return err
+	}
+
+	for _, e := range dbserviceEntities {
+		if e.Json.Connection == nil || !securityServices.HasPlainSecrets(s.SecretsManager, e.Json.Connection.Config) {
+			continue
+		}
+
+		if err := s.encryptConnection(e.Json, nil); err != nil {
+			return err
+		}
+
+		dbserviceEntity, err := s.DBServiceEntityRepository.UpdateDBServiceEntity(&e, e.Json.Version)
+
+		if err != nil {
+			return err
+		}
+
+		searchServices.IndexEntity(s.SearchIndexer, "databaseService", dbserviceEntity.ID, dbserviceEntity.Json)
+	}
+
+	if err := s.encryptVersionSecrets(); err != nil {
+		return err
+	}
+
+	return eventsServices.ScrubChangeEventSecrets(s.ChangeEventRepository, "databaseService")
+}
+
+// encryptVersionSecrets encrypts the secrets of the previous versions kept in entity_extension. The versions are
+// decoded as plain json so that they are written back unchanged apart from their secrets
+func (s *DBServiceEntityService) encryptVersionSecrets() error {
+	entityExtensions, err := s.EntityExtensionRepository.SelectEntityExtensionsByJsonSchema("databaseService")
+
+	if err != nil {
+		return err
+	}
+
+	for _, e := range entityExtensions {
+		version := map[string]interface{}{}
+
+		if err := json.Unmarshal(e.Json, &version); err != nil {
+			return err
+		}
+
+		connection, _ := version["connection"].(map[string]interface{})
+		config, _ := connection["config"].(map[string]interface{})
+
+		if config == nil || !securityServices.HasPlainSecrets(s.SecretsManager, config) {
+			continue
+		}
+
+		if err := securityServices.EncryptConfig(s.SecretsManager, config, nil); err != nil {
+			return err
+		}
+
+		bytes, err := json.Marshal(version)
+
+		if err != nil {
+			return err
+		}
+
+		if err := s.EntityExtensionRepository.UpdateEntityExtensionJson(e.ID, e.Extension, string(bytes)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// encryptConnection encrypts the secrets of the connection before it is stored, the secrets of previous are reused
+// when they did not change
+func (s *DBServiceEntityService) encryptConnection(dbservice *servicesModels.DBService, previous *servicesModels.DBService) error {
+	if dbservice.Connection == nil {
+		return nil
+	}
+
+	var previousConfig map[string]interface{}
+
+	if previous != nil && previous.Connection != nil {
+		previousConfig = previous.Connection.Config
+	}
+
+	return securityServices.EncryptConfig(s.SecretsManager, dbservice.Connection.Config, previousConfig)
 }
@@ -28,23 +28,32 @@ func (s *TestConnectionDefinitionEntityService) Health() string {
 	return "Test connection definition service is available"
 }
 
-func (s *TestConnectionDefinitionEntityService) GetAllTestConnectionDefinitionEntities(limit int, offset int) ([]servicesModels.TestConnectionDefinitionEntity, error) {
-	testConnectionDefinitionEntity, err := s.TestConnectionDefinitionEntityRepository.SelectTestConnectionDefinitionEntities(limit, offset)
-	return testConnectionDefinitionEntity, err
+func (s *TestConnectionDefinitionEntityService) GetAllTestConnectionDefinitionEntities(include string, limit int, before *baseModels.Cursor, after *baseModels.Cursor) ([]servicesModels.TestConnectionDefinitionEntity, *baseModels.Paging, error) {
+	testConnectionDefinitionEntity, err := s.TestConnectionDefinitionEntityRepository.SelectTestConnectionDefinitionEntities(include, limit, before, after)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	testConnectionDefinitionEntity, paging := baseModels.NewPaging(testConnectionDefinitionEntity, limit, before, after, func(e servicesModels.TestConnectionDefinitionEntity) *baseModels.Cursor {
+		return &baseModels.Cursor{ Name: e.Json.FullyQualifiedName, ID: e.ID }
+	})
+
+	return testConnectionDefinitionEntity, paging, nil
 }
 
-func (s *TestConnectionDefinitionEntityService) GetCountTestConnectionDefinitionEntities() (*baseModels.EntityTotal, error) {
-	entityTotal, err := s.TestConnectionDefinitionEntityRepository.SelectCountTestConnectionDefinitionEntities()
+func (s *TestConnectionDefinitionEntityService) GetCountTestConnectionDefinitionEntities(include string) (*baseModels.EntityTotal, error) {
+	entityTotal, err := s.TestConnectionDefinitionEntityRepository.SelectCountTestConnectionDefinitionEntities(include)
 	return entityTotal, err
 }
 
-func (s *TestConnectionDefinitionEntityService) GetTestConnectionDefinitionEntityById(id string) (*servicesModels.TestConnectionDefinitionEntity, error) {
-	testConnectionDefinitionEntity, err := s.TestConnectionDefinitionEntityRepository.SelectTestConnectionDefinitionEntityById(id)
+func (s *TestConnectionDefinitionEntityService) GetTestConnectionDefinitionEntityById(id string, include string) (*servicesModels.TestConnectionDefinitionEntity, error) {
+	testConnectionDefinitionEntity, err := s.TestConnectionDefinitionEntityRepository.SelectTestConnectionDefinitionEntityById(id, include)
 	return testConnectionDefinitionEntity, err
 }
 
-func (s *TestConnectionDefinitionEntityService) GetTestConnectionDefinitionEntityByFqn(fqn string) (*servicesModels.TestConnectionDefinitionEntity, error) {
-	testConnectionDefinitionEntity, err := s.TestConnectionDefinitionEntityRepository.SelectTestConnectionDefinitionEntityByFqn(fqn)
+func (s *TestConnectionDefinitionEntityService) GetTestConnectionDefinitionEntityByFqn(fqn string, include string) (*servicesModels.TestConnectionDefinitionEntity, error) {
+	testConnectionDefinitionEntity, err := s.TestConnectionDefinitionEntityRepository.SelectTestConnectionDefinitionEntityByFqn(fqn, include)
 	return testConnectionDefinitionEntity, err
 }
 
@@ -81,7 +90,7 @@ func (s *TestConnectionDefinitionEntityService) InitTestConnectionDefinitions()
 		fullyQualifiedName := fmt.Sprintf("%v.%v", data.Name, servicesModels.TestConnectionDefinitionString)
 		now := time.Now().Unix()
 
-		_, err := s.TestConnectionDefinitionEntityRepository.SelectTestConnectionDefinitionEntityByFqn(fullyQualifiedName)
+		_, err := s.TestConnectionDefinitionEntityRepository.SelectTestConnectionDefinitionEntityByFqn(fullyQualifiedName, "all")
 
 		if err != nil {
 			log.Printf("========== Init test connection for %v", data.Name)
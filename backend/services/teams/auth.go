@@ -0,0 +1,255 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"golang.org/x/crypto/bcrypt"
+	teamsModels "github.com/nambuitechx/go-metadata/models/teams"
+	baseRepositories "github.com/nambuitechx/go-metadata/repositories/base"
+	teamsRepositories "github.com/nambuitechx/go-metadata/repositories/teams"
+	baseUtils "github.com/nambuitechx/go-metadata/utils"
+)
+
+type AuthService struct {
+	Transactor *baseRepositories.Transactor
+	UserEntityRepository *teamsRepositories.UserEntityRepository
+	UserTokenRepository *teamsRepositories.UserTokenRepository
+	TeamEntityRepository *teamsRepositories.TeamEntityRepository
+	JWTSecret string
+	JWTExpiry int64
+}
+
+func NewAuthService(
+	transactor *baseRepositories.Transactor,
+	userEntityRepository *teamsRepositories.UserEntityRepository,
+	userTokenRepository *teamsRepositories.UserTokenRepository,
+	teamEntityRepository *teamsRepositories.TeamEntityRepository,
+	jwtSecret string,
+	jwtExpiry int64,
+) *AuthService {
+	return &AuthService{
+		Transactor: transactor,
+		UserEntityRepository: userEntityRepository,
+		UserTokenRepository: userTokenRepository,
+		TeamEntityRepository: teamEntityRepository,
+		JWTSecret: jwtSecret,
+		JWTExpiry: jwtExpiry,
+	}
+}
+
+// Login checks the password of a user against its bcrypt hash and issues an access token. Unknown users and wrong
+// passwords fail the same way so that user names cannot be probed
+func (s *AuthService) Login(name string, password string) (*teamsModels.LoginResponse, error) {
+	userEntity, err := s.UserEntityRepository.SelectUserEntityByName(name, "non-deleted")
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, teamsModels.ErrInvalidCredentials
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if userEntity.IsBot || userEntity.PasswordHash == "" {
+		return nil, teamsModels.ErrInvalidCredentials
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(userEntity.PasswordHash), []byte(password)); err != nil {
+		return nil, teamsModels.ErrInvalidCredentials
+	}
+
+	if userEntity.Deactivated == teamsModels.UserDeactivated {
+		return nil, teamsModels.ErrUserDeactivated
+	}
+
+	now := time.Now().Unix()
+
+	claims := &teamsModels.JWTClaims{
+		Issuer: teamsModels.JWTIssuer,
+		Subject: userEntity.Name,
+		Email: userEntity.Email,
+		IsBot: userEntity.IsBot,
+		TokenType: teamsModels.SessionToken,
+		IssuedAt: now,
+		ExpiresAt: now + s.JWTExpiry,
+	}
+
+	token, err := baseUtils.SignJWT(s.JWTSecret, claims)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &teamsModels.LoginResponse{ AccessToken: token, TokenType: teamsModels.BearerTokenType, ExpiryDuration: s.JWTExpiry }, nil
+}
+
+// Authenticate validates an access token and returns the user it was issued to. The user is read again so that
+// deactivated and deleted users lose access before their tokens expire, and so is the record of a long-lived token
+// so that revoked tokens are rejected
+func (s *AuthService) Authenticate(token string) (*teamsModels.Principal, error) {
+	claims := &teamsModels.JWTClaims{}
+
+	if err := baseUtils.VerifyJWT(s.JWTSecret, token, claims); err != nil {
+		return nil, teamsModels.ErrInvalidToken
+	}
+
+	now := time.Now().Unix()
+
+	if claims.Issuer != teamsModels.JWTIssuer || claims.Subject == "" || claims.ExpiresAt <= now {
+		return nil, teamsModels.ErrInvalidToken
+	}
+
+	var userToken *teamsModels.UserTokenEntity
+
+	if claims.TokenType != teamsModels.SessionToken {
+		var err error
+		userToken, err = s.UserTokenRepository.SelectUserTokenById(claims.ID)
+
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, teamsModels.ErrInvalidToken
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		if userToken.TokenType != claims.TokenType || userToken.ExpiresAt <= now {
+			return nil, teamsModels.ErrInvalidToken
+		}
+	}
+
+	userEntity, err := s.UserEntityRepository.SelectUserEntityByName(claims.Subject, "non-deleted")
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, teamsModels.ErrInvalidToken
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if userEntity.Deactivated == teamsModels.UserDeactivated {
+		return nil, teamsModels.ErrUserDeactivated
+	}
+
+	if userToken != nil && userToken.UserID != userEntity.ID {
+		return nil, teamsModels.ErrInvalidToken
+	}
+
+	// Bots only authenticate with their bot token
+	if userEntity.IsBot && claims.TokenType != teamsModels.BotToken {
+		return nil, teamsModels.ErrInvalidToken
+	}
+
+	principal := &teamsModels.Principal{
+		ID: userEntity.ID,
+		Name: userEntity.Name,
+		Email: userEntity.Email,
+		IsAdmin: userEntity.Json.IsAdmin,
+		IsBot: userEntity.IsBot,
+		Roles: []string{},
+	}
+
+	for _, role := range userEntity.Json.Roles {
+		principal.Roles = append(principal.Roles, role.Name)
+	}
+
+	principal.Teams, err = s.TeamEntityRepository.SelectTeamIdsByUserId(userEntity.ID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return principal, nil
+}
+
+// IssueUserToken issues a long-lived token to a user and keeps its record, without the token itself. A bot has a
+// single token, issuing a new one revokes the previous one
+func (s *AuthService) IssueUserToken(userEntity *teamsModels.UserEntity, tokenType string, name string, expiryDays int, userName string) (*teamsModels.UserTokenResponse, error) {
+	now := time.Now().Unix()
+	id := uuid.NewString()
+
+	userToken := &teamsModels.UserToken{
+		ID: id,
+		Name: name,
+		UserID: userEntity.ID,
+		UserName: userEntity.Name,
+		TokenType: tokenType,
+		ExpiresAt: now + int64(expiryDays) * 24 * 60 * 60,
+		CreatedAt: now,
+		CreatedBy: userName,
+	}
+
+	claims := &teamsModels.JWTClaims{
+		ID: id,
+		Issuer: teamsModels.JWTIssuer,
+		Subject: userEntity.Name,
+		Email: userEntity.Email,
+		IsBot: userEntity.IsBot,
+		TokenType: tokenType,
+		IssuedAt: now,
+		ExpiresAt: userToken.ExpiresAt,
+	}
+
+	token, err := baseUtils.SignJWT(s.JWTSecret, claims)
+
+	if err != nil {
+		return nil, err
+	}
+
+	entity := &teamsModels.UserTokenEntity{
+		ID: id,
+		UserID: userEntity.ID,
+		TokenType: tokenType,
+		ExpiresAt: userToken.ExpiresAt,
+		Json: userToken,
+	}
+
+	var userTokenEntity *teamsModels.UserTokenEntity
+
+	err = s.Transactor.RunInTx(func(tx *sqlx.Tx) error {
+		if tokenType == teamsModels.BotToken {
+			if err := s.UserTokenRepository.WithTx(tx).DeleteUserTokens(userEntity.ID, teamsModels.BotToken); err != nil {
+				return err
+			}
+		}
+
+		var err error
+		userTokenEntity, err = s.UserTokenRepository.WithTx(tx).InsertUserToken(entity)
+		return err
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &teamsModels.UserTokenResponse{ UserToken: userTokenEntity.Json, AccessToken: token }, nil
+}
+
+func (s *AuthService) GetUserTokens(userId string, tokenType string) ([]teamsModels.UserTokenEntity, error) {
+	userTokenEntities, err := s.UserTokenRepository.SelectUserTokens(userId, tokenType)
+	return userTokenEntities, err
+}
+
+// RevokeUserToken deletes a token of a user, sql.ErrNoRows when the user has no such token
+func (s *AuthService) RevokeUserToken(userId string, tokenType string, id string) error {
+	userTokenEntity, err := s.UserTokenRepository.SelectUserTokenById(id)
+
+	if err != nil {
+		return err
+	}
+
+	if userTokenEntity.UserID != userId || userTokenEntity.TokenType != tokenType {
+		return sql.ErrNoRows
+	}
+
+	return s.UserTokenRepository.DeleteUserTokenById(id)
+}
+
+func (s *AuthService) RevokeUserTokens(userId string, tokenType string) error {
+	return s.UserTokenRepository.DeleteUserTokens(userId, tokenType)
+}
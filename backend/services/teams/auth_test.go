@@ -0,0 +1,67 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	teamsModels "github.com/nambuitechx/go-metadata/models/teams"
+	baseUtils "github.com/nambuitechx/go-metadata/utils"
+)
+
+// The claims are rejected before the user is read, so the service needs no repository
+func TestAuthenticateRejectsClaims(t *testing.T) {
+	authService := NewAuthService(nil, nil, nil, nil, "secret", 3600)
+	now := time.Now().Unix()
+
+	tests := []struct {
+		name string
+		secret string
+		claims *teamsModels.JWTClaims
+	}{
+		{
+			name: "other secret",
+			secret: "other",
+			claims: &teamsModels.JWTClaims{ Issuer: teamsModels.JWTIssuer, Subject: "alice", TokenType: teamsModels.SessionToken, ExpiresAt: now + 60 },
+		},
+		{
+			name: "other issuer",
+			secret: "secret",
+			claims: &teamsModels.JWTClaims{ Issuer: "other", Subject: "alice", TokenType: teamsModels.SessionToken, ExpiresAt: now + 60 },
+		},
+		{
+			name: "no subject",
+			secret: "secret",
+			claims: &teamsModels.JWTClaims{ Issuer: teamsModels.JWTIssuer, TokenType: teamsModels.SessionToken, ExpiresAt: now + 60 },
+		},
+		{
+			name: "expired",
+			secret: "secret",
+			claims: &teamsModels.JWTClaims{ Issuer: teamsModels.JWTIssuer, Subject: "alice", TokenType: teamsModels.SessionToken, ExpiresAt: now },
+		},
+		{
+			name: "personal access token signed with another secret",
+			secret: "other",
+			claims: &teamsModels.JWTClaims{ ID: "1", Issuer: teamsModels.JWTIssuer, Subject: "alice", TokenType: teamsModels.PersonalAccessToken, ExpiresAt: now + 60 },
+		},
+		{
+			name: "expired bot token",
+			secret: "secret",
+			claims: &teamsModels.JWTClaims{ ID: "1", Issuer: teamsModels.JWTIssuer, Subject: "bot", IsBot: true, TokenType: teamsModels.BotToken, ExpiresAt: now - 60 },
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			token, err := baseUtils.SignJWT(test.secret, test.claims)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := authService.Authenticate(token); !errors.Is(err, teamsModels.ErrInvalidToken) {
+				t.Errorf("Authenticate() err = %v, expected %v", err, teamsModels.ErrInvalidToken)
+			}
+		})
+	}
+}
@@ -0,0 +1,237 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	baseModels "github.com/nambuitechx/go-metadata/models/base"
+	eventsModels "github.com/nambuitechx/go-metadata/models/events"
+	teamsModels "github.com/nambuitechx/go-metadata/models/teams"
+	typeModels "github.com/nambuitechx/go-metadata/models/type"
+	baseRepositories "github.com/nambuitechx/go-metadata/repositories/base"
+	eventsRepositories "github.com/nambuitechx/go-metadata/repositories/events"
+	teamsRepositories "github.com/nambuitechx/go-metadata/repositories/teams"
+)
+
+type BotEntityService struct {
+	Transactor *baseRepositories.Transactor
+	BotEntityRepository *teamsRepositories.BotEntityRepository
+	UserEntityRepository *teamsRepositories.UserEntityRepository
+	UserTokenRepository *teamsRepositories.UserTokenRepository
+	ChangeEventRepository *eventsRepositories.ChangeEventRepository
+	AuthService *AuthService
+}
+
+func NewBotEntityService(
+	transactor *baseRepositories.Transactor,
+	botEntityRepository *teamsRepositories.BotEntityRepository,
+	userEntityRepository *teamsRepositories.UserEntityRepository,
+	userTokenRepository *teamsRepositories.UserTokenRepository,
+	changeEventRepository *eventsRepositories.ChangeEventRepository,
+	authService *AuthService,
+) *BotEntityService {
+	return &BotEntityService{
+		Transactor: transactor,
+		BotEntityRepository: botEntityRepository,
+		UserEntityRepository: userEntityRepository,
+		UserTokenRepository: userTokenRepository,
+		ChangeEventRepository: changeEventRepository,
+		AuthService: authService,
+	}
+}
+
+func (s *BotEntityService) Health() string {
+	return "Bot service is available"
+}
+
+func (s *BotEntityService) GetAllBotEntities(include string, limit int, before *baseModels.Cursor, after *baseModels.Cursor) ([]teamsModels.BotEntity, *baseModels.Paging, error) {
+	botEntities, err := s.BotEntityRepository.SelectBotEntities(include, limit, before, after)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	botEntities, paging := baseModels.NewPaging(botEntities, limit, before, after, func(e teamsModels.BotEntity) *baseModels.Cursor {
+		return &baseModels.Cursor{ Name: e.Json.FullyQualifiedName, ID: e.ID }
+	})
+
+	return botEntities, paging, nil
+}
+
+func (s *BotEntityService) GetCountBotEntities(include string) (*baseModels.EntityTotal, error) {
+	entityTotal, err := s.BotEntityRepository.SelectCountBotEntities(include)
+	return entityTotal, err
+}
+
+func (s *BotEntityService) GetBotEntityById(id string, include string) (*teamsModels.BotEntity, error) {
+	botEntity, err := s.BotEntityRepository.SelectBotEntityById(id, include)
+	return botEntity, err
+}
+
+func (s *BotEntityService) GetBotEntityByName(name string, include string) (*teamsModels.BotEntity, error) {
+	botEntity, err := s.BotEntityRepository.SelectBotEntityByName(name, include)
+	return botEntity, err
+}
+
+// CreateBotEntity creates the bot and its bot user together, the bot user has no password and only authenticates
+// with the bot token
+func (s *BotEntityService) CreateBotEntity(payload *teamsModels.CreateBotPayload, userName string) (*teamsModels.BotEntity, error) {
+	now := time.Now().Unix()
+	userId := uuid.NewString()
+	email := fmt.Sprintf("%v@%v", payload.Name, teamsModels.BotEmailDomain)
+
+	user := &teamsModels.User{
+		ID: userId,
+		Name: payload.Name,
+		FullyQualifiedName: payload.Name,
+		DisplayName: payload.DisplayName,
+		Description: payload.Description,
+		Email: email,
+		IsAdmin: false,
+		IsBot: true,
+		Deactivated: false,
+		Version: typeModels.InitialVersion,
+		UpdatedAt: now,
+		UpdatedBy: userName,
+		Deleted: false,
+	}
+
+	userEntity := &teamsModels.UserEntity{
+		ID: userId,
+		Name: payload.Name,
+		Email: email,
+		Deactivated: teamsModels.UserActive,
+		Json: user,
+		UpdatedAt: now,
+		UpdatedBy: userName,
+		Deleted: false,
+		IsBot: true,
+	}
+
+	id := uuid.NewString()
+
+	bot := &teamsModels.Bot{
+		ID: id,
+		Name: payload.Name,
+		FullyQualifiedName: payload.Name,
+		DisplayName: payload.DisplayName,
+		Description: payload.Description,
+		BotUser: user.ToEntityReference(),
+		Version: typeModels.InitialVersion,
+		UpdatedAt: now,
+		UpdatedBy: userName,
+		Deleted: false,
+	}
+
+	entity := &teamsModels.BotEntity{
+		ID: id,
+		Name: payload.Name,
+		Json: bot,
+		UpdatedAt: now,
+		UpdatedBy: userName,
+		Deleted: false,
+	}
+
+	var botEntity *teamsModels.BotEntity
+
+	err := s.Transactor.RunInTx(func(tx *sqlx.Tx) error {
+		botUserEntity, err := s.UserEntityRepository.WithTx(tx).InsertUserEntity(userEntity)
+
+		if err != nil {
+			return err
+		}
+
+		if err := s.recordChangeEvent(tx, eventsModels.EntityCreated, botUserEntity.Json.ToEntityReference(), botUserEntity.Json.Version, userName, now, botUserEntity.Json); err != nil {
+			return err
+		}
+
+		botEntity, err = s.BotEntityRepository.WithTx(tx).InsertBotEntity(entity)
+
+		if err != nil {
+			return err
+		}
+
+		return s.recordChangeEvent(tx, eventsModels.EntityCreated, botEntity.Json.ToEntityReference(), botEntity.Json.Version, userName, now, botEntity.Json)
+	})
+
+	return botEntity, err
+}
+
+// DeleteBotEntity deletes the bot and revokes its token. The bot user is soft deleted so that its name, recorded in
+// the updatedBy of its changes, is not reused
+func (s *BotEntityService) DeleteBotEntity(exist *teamsModels.BotEntity, userName string) error {
+	now := time.Now().Unix()
+
+	return s.Transactor.RunInTx(func(tx *sqlx.Tx) error {
+		if err := s.BotEntityRepository.WithTx(tx).DeleteBotEntityById(exist.ID); err != nil {
+			return err
+		}
+
+		if err := s.UserTokenRepository.WithTx(tx).DeleteUserTokens(exist.Json.BotUser.ID, teamsModels.BotToken); err != nil {
+			return err
+		}
+
+		botUserEntity, err := s.UserEntityRepository.WithTx(tx).SelectUserEntityById(exist.Json.BotUser.ID, "all")
+
+		if err == nil {
+			botUserEntity.Deleted = true
+			botUserEntity.Json.Deleted = true
+			botUserEntity.UpdatedAt = now
+			botUserEntity.UpdatedBy = userName
+			botUserEntity.Json.UpdatedAt = now
+			botUserEntity.Json.UpdatedBy = userName
+
+			if _, err := s.UserEntityRepository.WithTx(tx).UpdateUserEntity(botUserEntity, botUserEntity.Json.Version); err != nil {
+				return err
+			}
+
+			if err := s.recordChangeEvent(tx, eventsModels.EntitySoftDeleted, botUserEntity.Json.ToEntityReference(), botUserEntity.Json.Version, userName, now, botUserEntity.Json); err != nil {
+				return err
+			}
+		}
+
+		return s.recordChangeEvent(tx, eventsModels.EntityDeleted, exist.Json.ToEntityReference(), exist.Json.Version, userName, now, exist.Json)
+	})
+}
+
+// GenerateBotToken issues a new token to the bot user, the previous token is revoked
+func (s *BotEntityService) GenerateBotToken(exist *teamsModels.BotEntity, expiryDays int, userName string) (*teamsModels.UserTokenResponse, error) {
+	botUserEntity, err := s.UserEntityRepository.SelectUserEntityById(exist.Json.BotUser.ID, "non-deleted")
+
+	if err != nil {
+		return nil, err
+	}
+
+	return s.AuthService.IssueUserToken(botUserEntity, teamsModels.BotToken, exist.Name, expiryDays, userName)
+}
+
+// GetBotToken returns the record of the current token of the bot, nil when it has none
+func (s *BotEntityService) GetBotToken(exist *teamsModels.BotEntity) (*teamsModels.UserToken, error) {
+	userTokenEntities, err := s.AuthService.GetUserTokens(exist.Json.BotUser.ID, teamsModels.BotToken)
+
+	if err != nil || len(userTokenEntities) == 0 {
+		return nil, err
+	}
+
+	return userTokenEntities[len(userTokenEntities) - 1].Json, nil
+}
+
+func (s *BotEntityService) RevokeBotToken(exist *teamsModels.BotEntity) error {
+	return s.AuthService.RevokeUserTokens(exist.Json.BotUser.ID, teamsModels.BotToken)
+}
+
+func (s *BotEntityService) recordChangeEvent(
+	tx *sqlx.Tx,
+	eventType string,
+	entityRef *typeModels.EntityReference,
+	version float64,
+	userName string,
+	timestamp int64,
+	entity interface{},
+) error {
+	changeEvent := eventsModels.NewChangeEventEntity(eventType, entityRef, version, nil, userName, timestamp, entity)
+	_, err := s.ChangeEventRepository.WithTx(tx).InsertChangeEvent(changeEvent)
+	return err
+}
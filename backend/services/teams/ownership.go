@@ -0,0 +1,169 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+	teamsModels "github.com/nambuitechx/go-metadata/models/teams"
+	typeModels "github.com/nambuitechx/go-metadata/models/type"
+	teamsRepositories "github.com/nambuitechx/go-metadata/repositories/teams"
+)
+
+// OwnershipService resolves the owners of the entities. The owners set on an entity are kept in its json and mirrored
+// as OWNS rows, an entity without owners inherits the ones of its schema, database or service when it is read
+type OwnershipService struct {
+	UserEntityRepository *teamsRepositories.UserEntityRepository
+	TeamEntityRepository *teamsRepositories.TeamEntityRepository
+	OwnerRepository *teamsRepositories.OwnerRepository
+}
+
+func NewOwnershipService(
+	userEntityRepository *teamsRepositories.UserEntityRepository,
+	teamEntityRepository *teamsRepositories.TeamEntityRepository,
+	ownerRepository *teamsRepositories.OwnerRepository,
+) *OwnershipService {
+	return &OwnershipService{
+		UserEntityRepository: userEntityRepository,
+		TeamEntityRepository: teamEntityRepository,
+		OwnerRepository: ownerRepository,
+	}
+}
+
+// ResolveOwners looks up the users and teams referenced by id, deleted users included, or name. Inherited owners
+// are dropped so that an entity read back and written again does not store the owners of its parents
+func (s *OwnershipService) ResolveOwners(owners []*typeModels.EntityReference) ([]*typeModels.EntityReference, error) {
+	resolved := []*typeModels.EntityReference{}
+	found := map[string]bool{}
+
+	for _, owner := range owners {
+		if owner == nil || owner.Inherited {
+			continue
+		}
+
+		ownerRef, err := s.resolveOwner(owner)
+
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%w: %v %v%v", teamsModels.ErrOwnerNotFound, owner.Type, owner.Name, owner.ID)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		if !found[ownerRef.ID] {
+			resolved = append(resolved, ownerRef)
+			found[ownerRef.ID] = true
+		}
+	}
+
+	return resolved, nil
+}
+
+func (s *OwnershipService) resolveOwner(owner *typeModels.EntityReference) (*typeModels.EntityReference, error) {
+	switch owner.Type {
+	case teamsModels.UserOwner:
+		var userEntity *teamsModels.UserEntity
+		var err error
+
+		if owner.ID != "" {
+			userEntity, err = s.UserEntityRepository.SelectUserEntityById(owner.ID, "all")
+		} else {
+			userEntity, err = s.UserEntityRepository.SelectUserEntityByName(owner.Name, "non-deleted")
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		return userEntity.Json.ToEntityReference(), nil
+	case teamsModels.TeamOwner:
+		var teamEntity *teamsModels.TeamEntity
+		var err error
+
+		if owner.ID != "" {
+			teamEntity, err = s.TeamEntityRepository.SelectTeamEntityById(owner.ID, "all")
+		} else {
+			teamEntity, err = s.TeamEntityRepository.SelectTeamEntityByName(owner.Name, "non-deleted")
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		return teamEntity.Json.ToEntityReference(), nil
+	}
+
+	return nil, fmt.Errorf("invalid owner type %v, owners are users or teams", owner.Type)
+}
+
+// SetOwners mirrors the owners of an entity as OWNS rows, within the transaction writing the entity
+func (s *OwnershipService) SetOwners(tx *sqlx.Tx, id string, entityType string, owners []*typeModels.EntityReference) error {
+	return s.OwnerRepository.WithTx(tx).ReplaceOwners(id, entityType, owners)
+}
+
+// InheritOwners returns the owners of an entity, or the inherited ones when it has none
+func (s *OwnershipService) InheritOwners(entityType string, id string, owners []*typeModels.EntityReference) ([]*typeModels.EntityReference, error) {
+	if len(owners) > 0 {
+		return owners, nil
+	}
+
+	effectiveOwners, err := s.GetOwners(entityType, id)
+
+	if err != nil || len(effectiveOwners) == 0 {
+		return owners, err
+	}
+
+	return effectiveOwners, nil
+}
+
+// GetOwners returns the effective owners of an entity, the inherited ones are marked. The entity types match the
+// resources of the policies, the other resources have no owners
+func (s *OwnershipService) GetOwners(entityType string, id string) ([]*typeModels.EntityReference, error) {
+	table, ok := teamsModels.OwnedEntityTables[entityType]
+
+	if !ok {
+		return nil, nil
+	}
+
+	ownerEntities, err := s.OwnerRepository.SelectEffectiveOwners(table, id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	owners := []*typeModels.EntityReference{}
+
+	for _, e := range ownerEntities {
+		e.Json.Inherited = e.Level > 1
+		owners = append(owners, e.Json)
+	}
+
+	return owners, nil
+}
+
+// GetOwnerId returns the id of the user, or else the team, of the given name
+func (s *OwnershipService) GetOwnerId(name string) (string, error) {
+	userEntity, err := s.UserEntityRepository.SelectUserEntityByName(name, "non-deleted")
+
+	if err == nil {
+		return userEntity.ID, nil
+	}
+
+	if !errors.Is(err, sql.ErrNoRows) {
+		return "", err
+	}
+
+	teamEntity, err := s.TeamEntityRepository.SelectTeamEntityByName(name, "non-deleted")
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", fmt.Errorf("%w: %v", teamsModels.ErrOwnerNotFound, name)
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	return teamEntity.ID, nil
+}
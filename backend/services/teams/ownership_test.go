@@ -0,0 +1,38 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+
+	typeModels "github.com/nambuitechx/go-metadata/models/type"
+)
+
+// Entities with owners of their own and resources without owners are answered without reading the relationships
+func TestInheritOwners(t *testing.T) {
+	ownershipService := NewOwnershipService(nil, nil, nil)
+	alice := &typeModels.EntityReference{ ID: "alice", Type: "user" }
+
+	tests := []struct {
+		name string
+		entityType string
+		owners []*typeModels.EntityReference
+		expected []*typeModels.EntityReference
+	}{
+		{ name: "own owners", entityType: "table", owners: []*typeModels.EntityReference{alice}, expected: []*typeModels.EntityReference{alice} },
+		{ name: "resource without owners", entityType: "workflow", owners: nil, expected: nil },
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			owners, err := ownershipService.InheritOwners(test.entityType, "1", test.owners)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(owners, test.expected) {
+				t.Errorf("InheritOwners() = %v, expected %v", owners, test.expected)
+			}
+		})
+	}
+}
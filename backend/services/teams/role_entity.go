@@ -0,0 +1,266 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	baseModels "github.com/nambuitechx/go-metadata/models/base"
+	eventsModels "github.com/nambuitechx/go-metadata/models/events"
+	teamsModels "github.com/nambuitechx/go-metadata/models/teams"
+	typeModels "github.com/nambuitechx/go-metadata/models/type"
+	baseRepositories "github.com/nambuitechx/go-metadata/repositories/base"
+	eventsRepositories "github.com/nambuitechx/go-metadata/repositories/events"
+	policiesRepositories "github.com/nambuitechx/go-metadata/repositories/policies"
+	teamsRepositories "github.com/nambuitechx/go-metadata/repositories/teams"
+	baseUtils "github.com/nambuitechx/go-metadata/utils"
+)
+
+type RoleEntityService struct {
+	Transactor *baseRepositories.Transactor
+	RoleEntityRepository *teamsRepositories.RoleEntityRepository
+	PolicyEntityRepository *policiesRepositories.PolicyEntityRepository
+	ChangeEventRepository *eventsRepositories.ChangeEventRepository
+}
+
+func NewRoleEntityService(
+	transactor *baseRepositories.Transactor,
+	roleEntityRepository *teamsRepositories.RoleEntityRepository,
+	policyEntityRepository *policiesRepositories.PolicyEntityRepository,
+	changeEventRepository *eventsRepositories.ChangeEventRepository,
+) *RoleEntityService {
+	return &RoleEntityService{
+		Transactor: transactor,
+		RoleEntityRepository: roleEntityRepository,
+		PolicyEntityRepository: policyEntityRepository,
+		ChangeEventRepository: changeEventRepository,
+	}
+}
+
+func (s *RoleEntityService) Health() string {
+	return "Role service is available"
+}
+
+func (s *RoleEntityService) GetAllRoleEntities(include string, limit int, before *baseModels.Cursor, after *baseModels.Cursor) ([]teamsModels.RoleEntity, *baseModels.Paging, error) {
+	roleEntities, err := s.RoleEntityRepository.SelectRoleEntities(include, limit, before, after)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	roleEntities, paging := baseModels.NewPaging(roleEntities, limit, before, after, func(e teamsModels.RoleEntity) *baseModels.Cursor {
+		return &baseModels.Cursor{ Name: e.Json.FullyQualifiedName, ID: e.ID }
+	})
+
+	return roleEntities, paging, nil
+}
+
+func (s *RoleEntityService) GetCountRoleEntities(include string) (*baseModels.EntityTotal, error) {
+	entityTotal, err := s.RoleEntityRepository.SelectCountRoleEntities(include)
+	return entityTotal, err
+}
+
+func (s *RoleEntityService) GetRoleEntityById(id string, include string) (*teamsModels.RoleEntity, error) {
+	roleEntity, err := s.RoleEntityRepository.SelectRoleEntityById(id, include)
+	return roleEntity, err
+}
+
+func (s *RoleEntityService) GetRoleEntityByName(name string, include string) (*teamsModels.RoleEntity, error) {
+	roleEntity, err := s.RoleEntityRepository.SelectRoleEntityByName(name, include)
+	return roleEntity, err
+}
+
+func (s *RoleEntityService) CreateRoleEntity(payload *teamsModels.CreateRolePayload, userName string) (*teamsModels.RoleEntity, error) {
+	policies, err := s.getPolicyReferences(payload.Policies)
+
+	if err != nil {
+		return nil, err
+	}
+
+	id := uuid.NewString()
+	now := time.Now().Unix()
+
+	role := &teamsModels.Role{
+		ID: id,
+		Name: payload.Name,
+		FullyQualifiedName: payload.Name,
+		DisplayName: payload.DisplayName,
+		Description: payload.Description,
+		Policies: policies,
+		Version: typeModels.InitialVersion,
+		UpdatedAt: now,
+		UpdatedBy: userName,
+		Deleted: false,
+	}
+
+	entity := &teamsModels.RoleEntity{
+		ID: id,
+		Name: payload.Name,
+		Json: role,
+		UpdatedAt: now,
+		UpdatedBy: userName,
+		Deleted: false,
+	}
+
+	var roleEntity *teamsModels.RoleEntity
+
+	err = s.Transactor.RunInTx(func(tx *sqlx.Tx) error {
+		var err error
+		roleEntity, err = s.RoleEntityRepository.WithTx(tx).InsertRoleEntity(entity)
+
+		if err != nil {
+			return err
+		}
+
+		return s.recordChangeEvent(tx, eventsModels.EntityCreated, roleEntity, nil)
+	})
+
+	return roleEntity, err
+}
+
+func (s *RoleEntityService) CreateOrUpdateRoleEntity(payload *teamsModels.CreateRolePayload, userName string) (*teamsModels.RoleEntity, error) {
+	exist, err := s.RoleEntityRepository.SelectRoleEntityByName(payload.Name, "all")
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return s.CreateRoleEntity(payload, userName)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if exist.Deleted {
+		return nil, baseModels.ErrEntityDeleted
+	}
+
+	policies, err := s.getPolicyReferences(payload.Policies)
+
+	if err != nil {
+		return nil, err
+	}
+
+	exist.Json.DisplayName = payload.DisplayName
+	exist.Json.Description = payload.Description
+	exist.Json.Policies = policies
+	exist.UpdatedAt = time.Now().Unix()
+	exist.UpdatedBy = userName
+
+	var roleEntity *teamsModels.RoleEntity
+
+	err = s.Transactor.RunInTx(func(tx *sqlx.Tx) error {
+		previous, err := s.RoleEntityRepository.WithTx(tx).SelectRoleEntityById(exist.ID, "all")
+
+		if err != nil {
+			return err
+		}
+
+		changeDescription, err := baseUtils.CompareEntities(previous.Json, exist.Json)
+
+		if err != nil {
+			return err
+		}
+
+		if changeDescription.IsEmpty() {
+			roleEntity = previous
+			return nil
+		}
+
+		changeDescription.PreviousVersion = previous.Json.Version
+		exist.Json.Version = baseUtils.NextVersion(previous.Json.Version, baseUtils.IsMajorChange(changeDescription))
+		exist.Json.ChangeDescription = changeDescription
+		exist.Json.UpdatedAt = exist.UpdatedAt
+		exist.Json.UpdatedBy = exist.UpdatedBy
+
+		roleEntity, err = s.RoleEntityRepository.WithTx(tx).UpdateRoleEntity(exist, previous.Json.Version)
+
+		if errors.Is(err, sql.ErrNoRows) {
+			return baseModels.ErrPreconditionFailed
+		}
+
+		if err != nil {
+			return err
+		}
+
+		return s.recordChangeEvent(tx, eventsModels.EntityUpdated, roleEntity, changeDescription)
+	})
+
+	return roleEntity, err
+}
+
+// DeleteRoleEntity deletes the role, the users who have it lose its policies
+func (s *RoleEntityService) DeleteRoleEntity(exist *teamsModels.RoleEntity, userName string) error {
+	exist.UpdatedAt = time.Now().Unix()
+	exist.UpdatedBy = userName
+
+	return s.Transactor.RunInTx(func(tx *sqlx.Tx) error {
+		if err := s.RoleEntityRepository.WithTx(tx).DeleteRoleEntityById(exist.ID); err != nil {
+			return err
+		}
+
+		return s.recordChangeEvent(tx, eventsModels.EntityDeleted, exist, nil)
+	})
+}
+
+// EnsureDefaultRoles creates the default roles which do not exist yet, existing ones are left untouched
+func (s *RoleEntityService) EnsureDefaultRoles(userName string) error {
+	for _, payload := range teamsModels.DefaultRoles {
+		_, err := s.RoleEntityRepository.SelectRoleEntityByName(payload.Name, "all")
+
+		if err == nil {
+			continue
+		}
+
+		if !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+
+		if _, err := s.CreateRoleEntity(payload, userName); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// getPolicyReferences fails on an unknown policy name
+func (s *RoleEntityService) getPolicyReferences(names []string) ([]*typeModels.EntityReference, error) {
+	policyEntities, err := s.PolicyEntityRepository.SelectPolicyEntitiesByNames(names)
+
+	if err != nil {
+		return nil, err
+	}
+
+	policies := []*typeModels.EntityReference{}
+	found := map[string]bool{}
+
+	for _, e := range policyEntities {
+		policies = append(policies, e.Json.ToEntityReference())
+		found[e.Name] = true
+	}
+
+	for _, name := range names {
+		if !found[name] {
+			return nil, fmt.Errorf("policy %v not found", name)
+		}
+	}
+
+	return policies, nil
+}
+
+func (s *RoleEntityService) recordChangeEvent(tx *sqlx.Tx, eventType string, roleEntity *teamsModels.RoleEntity, changeDescription *typeModels.ChangeDescription) error {
+	changeEvent := eventsModels.NewChangeEventEntity(
+		eventType,
+		roleEntity.Json.ToEntityReference(),
+		roleEntity.Json.Version,
+		changeDescription,
+		roleEntity.UpdatedBy,
+		roleEntity.UpdatedAt,
+		roleEntity.Json,
+	)
+
+	_, err := s.ChangeEventRepository.WithTx(tx).InsertChangeEvent(changeEvent)
+	return err
+}
@@ -0,0 +1,374 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	baseModels "github.com/nambuitechx/go-metadata/models/base"
+	eventsModels "github.com/nambuitechx/go-metadata/models/events"
+	teamsModels "github.com/nambuitechx/go-metadata/models/teams"
+	typeModels "github.com/nambuitechx/go-metadata/models/type"
+	baseRepositories "github.com/nambuitechx/go-metadata/repositories/base"
+	eventsRepositories "github.com/nambuitechx/go-metadata/repositories/events"
+	teamsRepositories "github.com/nambuitechx/go-metadata/repositories/teams"
+	baseUtils "github.com/nambuitechx/go-metadata/utils"
+)
+
+type TeamEntityService struct {
+	Transactor *baseRepositories.Transactor
+	TeamEntityRepository *teamsRepositories.TeamEntityRepository
+	UserEntityRepository *teamsRepositories.UserEntityRepository
+	OwnerRepository *teamsRepositories.OwnerRepository
+	ChangeEventRepository *eventsRepositories.ChangeEventRepository
+}
+
+func NewTeamEntityService(
+	transactor *baseRepositories.Transactor,
+	teamEntityRepository *teamsRepositories.TeamEntityRepository,
+	userEntityRepository *teamsRepositories.UserEntityRepository,
+	ownerRepository *teamsRepositories.OwnerRepository,
+	changeEventRepository *eventsRepositories.ChangeEventRepository,
+) *TeamEntityService {
+	return &TeamEntityService{
+		Transactor: transactor,
+		TeamEntityRepository: teamEntityRepository,
+		UserEntityRepository: userEntityRepository,
+		OwnerRepository: ownerRepository,
+		ChangeEventRepository: changeEventRepository,
+	}
+}
+
+func (s *TeamEntityService) Health() string {
+	return "Team service is available"
+}
+
+func (s *TeamEntityService) GetAllTeamEntities(include string, limit int, before *baseModels.Cursor, after *baseModels.Cursor) ([]teamsModels.TeamEntity, *baseModels.Paging, error) {
+	teamEntities, err := s.TeamEntityRepository.SelectTeamEntities(include, limit, before, after)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	teamEntities, paging := baseModels.NewPaging(teamEntities, limit, before, after, func(e teamsModels.TeamEntity) *baseModels.Cursor {
+		return &baseModels.Cursor{ Name: e.Json.FullyQualifiedName, ID: e.ID }
+	})
+
+	return teamEntities, paging, nil
+}
+
+func (s *TeamEntityService) GetCountTeamEntities(include string) (*baseModels.EntityTotal, error) {
+	entityTotal, err := s.TeamEntityRepository.SelectCountTeamEntities(include)
+	return entityTotal, err
+}
+
+func (s *TeamEntityService) GetTeamEntityById(id string, include string) (*teamsModels.TeamEntity, error) {
+	teamEntity, err := s.TeamEntityRepository.SelectTeamEntityById(id, include)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return teamEntity, s.fillChildren(teamEntity)
+}
+
+func (s *TeamEntityService) GetTeamEntityByName(name string, include string) (*teamsModels.TeamEntity, error) {
+	teamEntity, err := s.TeamEntityRepository.SelectTeamEntityByName(name, include)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return teamEntity, s.fillChildren(teamEntity)
+}
+
+func (s *TeamEntityService) CreateTeamEntity(payload *teamsModels.CreateTeamPayload, userName string) (*teamsModels.TeamEntity, error) {
+	parentName := payload.Parent
+
+	if parentName == "" {
+		parentName = teamsModels.OrganizationName
+	}
+
+	parentEntity, err := s.TeamEntityRepository.SelectTeamEntityByName(parentName, "non-deleted")
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("parent team %v not found", parentName)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := teamsModels.ValidateTeamParent(parentEntity.Json); err != nil {
+		return nil, err
+	}
+
+	users, err := s.getUserReferences(payload.Users)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return s.insertTeamEntity(payload, payload.TeamType, parentEntity.Json.ToEntityReference(), users, userName)
+}
+
+func (s *TeamEntityService) CreateOrUpdateTeamEntity(payload *teamsModels.CreateTeamPayload, userName string) (*teamsModels.TeamEntity, error) {
+	exist, err := s.TeamEntityRepository.SelectTeamEntityByName(payload.Name, "all")
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return s.CreateTeamEntity(payload, userName)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if exist.Deleted {
+		return nil, baseModels.ErrEntityDeleted
+	}
+
+	parentName := payload.Parent
+
+	if parentName == "" {
+		parentName = teamsModels.OrganizationName
+	}
+
+	if exist.Json.TeamType != payload.TeamType || exist.Json.Parent == nil || exist.Json.Parent.Name != parentName {
+		return nil, teamsModels.ErrTeamImmutable
+	}
+
+	users, err := s.getUserReferences(payload.Users)
+
+	if err != nil {
+		return nil, err
+	}
+
+	exist.Json.DisplayName = payload.DisplayName
+	exist.Json.Description = payload.Description
+	exist.Json.Users = users
+	exist.UpdatedAt = time.Now().Unix()
+	exist.UpdatedBy = userName
+
+	var teamEntity *teamsModels.TeamEntity
+
+	err = s.Transactor.RunInTx(func(tx *sqlx.Tx) error {
+		previous, err := s.TeamEntityRepository.WithTx(tx).SelectTeamEntityById(exist.ID, "all")
+
+		if err != nil {
+			return err
+		}
+
+		changeDescription, err := baseUtils.CompareEntities(previous.Json, exist.Json)
+
+		if err != nil {
+			return err
+		}
+
+		if changeDescription.IsEmpty() {
+			teamEntity = previous
+			return nil
+		}
+
+		changeDescription.PreviousVersion = previous.Json.Version
+		exist.Json.Version = baseUtils.NextVersion(previous.Json.Version, baseUtils.IsMajorChange(changeDescription))
+		exist.Json.ChangeDescription = changeDescription
+		exist.Json.UpdatedAt = exist.UpdatedAt
+		exist.Json.UpdatedBy = exist.UpdatedBy
+
+		teamEntity, err = s.TeamEntityRepository.WithTx(tx).UpdateTeamEntity(exist, previous.Json.Version)
+
+		if errors.Is(err, sql.ErrNoRows) {
+			return baseModels.ErrPreconditionFailed
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if err := s.TeamEntityRepository.WithTx(tx).ReplaceTeamUsers(teamEntity.ID, teamEntity.Json.Users); err != nil {
+			return err
+		}
+
+		return s.recordChangeEvent(tx, eventsModels.EntityUpdated, teamEntity, changeDescription)
+	})
+
+	return teamEntity, err
+}
+
+// DeleteTeamEntity deletes a team without child teams nor owned entities, its users stay
+func (s *TeamEntityService) DeleteTeamEntity(exist *teamsModels.TeamEntity, userName string) error {
+	if exist.Json.TeamType == teamsModels.OrganizationTeam {
+		return teamsModels.ErrOrganizationTeam
+	}
+
+	children, err := s.TeamEntityRepository.SelectChildTeamEntities(exist.ID)
+
+	if err != nil {
+		return err
+	}
+
+	if len(children) > 0 {
+		return teamsModels.ErrTeamHasChildren
+	}
+
+	owned, err := s.OwnerRepository.SelectCountOwnedEntities(exist.ID)
+
+	if err != nil {
+		return err
+	}
+
+	if owned > 0 {
+		return teamsModels.ErrTeamOwnsEntities
+	}
+
+	exist.UpdatedAt = time.Now().Unix()
+	exist.UpdatedBy = userName
+
+	return s.Transactor.RunInTx(func(tx *sqlx.Tx) error {
+		if err := s.TeamEntityRepository.WithTx(tx).DeleteTeamRelationships(exist.ID); err != nil {
+			return err
+		}
+
+		if err := s.TeamEntityRepository.WithTx(tx).DeleteTeamEntityById(exist.ID); err != nil {
+			return err
+		}
+
+		return s.recordChangeEvent(tx, eventsModels.EntityDeleted, exist, nil)
+	})
+}
+
+// EnsureOrganization creates the root team of the hierarchy when it does not exist yet
+func (s *TeamEntityService) EnsureOrganization(userName string) error {
+	_, err := s.TeamEntityRepository.SelectTeamEntityByName(teamsModels.OrganizationName, "all")
+
+	if err == nil || !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+
+	payload := &teamsModels.CreateTeamPayload{
+		Name: teamsModels.OrganizationName,
+		Description: "The root of the teams",
+	}
+
+	_, err = s.insertTeamEntity(payload, teamsModels.OrganizationTeam, nil, nil, userName)
+	return err
+}
+
+func (s *TeamEntityService) insertTeamEntity(
+	payload *teamsModels.CreateTeamPayload,
+	teamType string,
+	parent *typeModels.EntityReference,
+	users []*typeModels.EntityReference,
+	userName string,
+) (*teamsModels.TeamEntity, error) {
+	id := uuid.NewString()
+	now := time.Now().Unix()
+
+	team := &teamsModels.Team{
+		ID: id,
+		Name: payload.Name,
+		FullyQualifiedName: payload.Name,
+		DisplayName: payload.DisplayName,
+		Description: payload.Description,
+		TeamType: teamType,
+		Parent: parent,
+		Users: users,
+		Version: typeModels.InitialVersion,
+		UpdatedAt: now,
+		UpdatedBy: userName,
+		Deleted: false,
+	}
+
+	entity := &teamsModels.TeamEntity{
+		ID: id,
+		Name: payload.Name,
+		Json: team,
+		UpdatedAt: now,
+		UpdatedBy: userName,
+		Deleted: false,
+	}
+
+	var teamEntity *teamsModels.TeamEntity
+
+	err := s.Transactor.RunInTx(func(tx *sqlx.Tx) error {
+		var err error
+		teamEntity, err = s.TeamEntityRepository.WithTx(tx).InsertTeamEntity(entity)
+
+		if err != nil {
+			return err
+		}
+
+		if parent != nil {
+			if err := s.TeamEntityRepository.WithTx(tx).InsertTeamParent(parent.ID, teamEntity.ID); err != nil {
+				return err
+			}
+		}
+
+		if err := s.TeamEntityRepository.WithTx(tx).ReplaceTeamUsers(teamEntity.ID, users); err != nil {
+			return err
+		}
+
+		return s.recordChangeEvent(tx, eventsModels.EntityCreated, teamEntity, nil)
+	})
+
+	return teamEntity, err
+}
+
+func (s *TeamEntityService) fillChildren(teamEntity *teamsModels.TeamEntity) error {
+	childEntities, err := s.TeamEntityRepository.SelectChildTeamEntities(teamEntity.ID)
+
+	if err != nil {
+		return err
+	}
+
+	for _, e := range childEntities {
+		teamEntity.Json.Children = append(teamEntity.Json.Children, e.Json.ToEntityReference())
+	}
+
+	return nil
+}
+
+// getUserReferences fails on an unknown user name
+func (s *TeamEntityService) getUserReferences(names []string) ([]*typeModels.EntityReference, error) {
+	users := []*typeModels.EntityReference{}
+	found := map[string]bool{}
+
+	for _, name := range names {
+		if found[name] {
+			continue
+		}
+
+		userEntity, err := s.UserEntityRepository.SelectUserEntityByName(name, "non-deleted")
+
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("user %v not found", name)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		users = append(users, userEntity.Json.ToEntityReference())
+		found[name] = true
+	}
+
+	return users, nil
+}
+
+func (s *TeamEntityService) recordChangeEvent(tx *sqlx.Tx, eventType string, teamEntity *teamsModels.TeamEntity, changeDescription *typeModels.ChangeDescription) error {
+	changeEvent := eventsModels.NewChangeEventEntity(
+		eventType,
+		teamEntity.Json.ToEntityReference(),
+		teamEntity.Json.Version,
+		changeDescription,
+		teamEntity.UpdatedBy,
+		teamEntity.UpdatedAt,
+		teamEntity.Json,
+	)
+
+	_, err := s.ChangeEventRepository.WithTx(tx).InsertChangeEvent(changeEvent)
+	return err
+}
@@ -0,0 +1,279 @@
+package services
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"golang.org/x/crypto/bcrypt"
+	baseModels "github.com/nambuitechx/go-metadata/models/base"
+	eventsModels "github.com/nambuitechx/go-metadata/models/events"
+	teamsModels "github.com/nambuitechx/go-metadata/models/teams"
+	typeModels "github.com/nambuitechx/go-metadata/models/type"
+	baseRepositories "github.com/nambuitechx/go-metadata/repositories/base"
+	eventsRepositories "github.com/nambuitechx/go-metadata/repositories/events"
+	teamsRepositories "github.com/nambuitechx/go-metadata/repositories/teams"
+	baseUtils "github.com/nambuitechx/go-metadata/utils"
+)
+
+type UserEntityService struct {
+	Transactor *baseRepositories.Transactor
+	UserEntityRepository *teamsRepositories.UserEntityRepository
+	RoleEntityRepository *teamsRepositories.RoleEntityRepository
+	ChangeEventRepository *eventsRepositories.ChangeEventRepository
+}
+
+func NewUserEntityService(
+	transactor *baseRepositories.Transactor,
+	userEntityRepository *teamsRepositories.UserEntityRepository,
+	roleEntityRepository *teamsRepositories.RoleEntityRepository,
+	changeEventRepository *eventsRepositories.ChangeEventRepository,
+) *UserEntityService {
+	return &UserEntityService{
+		Transactor: transactor,
+		UserEntityRepository: userEntityRepository,
+		RoleEntityRepository: roleEntityRepository,
+		ChangeEventRepository: changeEventRepository,
+	}
+}
+
+func (s *UserEntityService) Health() string {
+	return "User service is available"
+}
+
+func (s *UserEntityService) GetAllUserEntities(include string, limit int, before *baseModels.Cursor, after *baseModels.Cursor) ([]teamsModels.UserEntity, *baseModels.Paging, error) {
+	userEntities, err := s.UserEntityRepository.SelectUserEntities(include, limit, before, after)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	userEntities, paging := baseModels.NewPaging(userEntities, limit, before, after, func(e teamsModels.UserEntity) *baseModels.Cursor {
+		return &baseModels.Cursor{ Name: e.Json.FullyQualifiedName, ID: e.ID }
+	})
+
+	return userEntities, paging, nil
+}
+
+func (s *UserEntityService) GetCountUserEntities(include string) (*baseModels.EntityTotal, error) {
+	entityTotal, err := s.UserEntityRepository.SelectCountUserEntities(include)
+	return entityTotal, err
+}
+
+func (s *UserEntityService) GetUserEntityById(id string, include string) (*teamsModels.UserEntity, error) {
+	userEntity, err := s.UserEntityRepository.SelectUserEntityById(id, include)
+	return userEntity, err
+}
+
+func (s *UserEntityService) GetUserEntityByName(name string, include string) (*teamsModels.UserEntity, error) {
+	userEntity, err := s.UserEntityRepository.SelectUserEntityByName(name, include)
+	return userEntity, err
+}
+
+// CreateUserEntity stores the bcrypt hash of the password, never the password itself
+func (s *UserEntityService) CreateUserEntity(payload *teamsModels.CreateUserPayload, userName string) (*teamsModels.UserEntity, error) {
+	passwordHash, err := bcrypt.GenerateFromPassword([]byte(payload.Password), bcrypt.DefaultCost)
+
+	if err != nil {
+		return nil, err
+	}
+
+	id := uuid.NewString()
+	now := time.Now().Unix()
+
+	user := &teamsModels.User{
+		ID: id,
+		Name: payload.Name,
+		FullyQualifiedName: payload.Name,
+		DisplayName: payload.DisplayName,
+		Description: payload.Description,
+		Email: payload.Email,
+		IsAdmin: payload.IsAdmin,
+		IsBot: false,
+		Deactivated: false,
+		Version: typeModels.InitialVersion,
+		UpdatedAt: now,
+		UpdatedBy: userName,
+		Deleted: false,
+	}
+
+	entity := &teamsModels.UserEntity{
+		ID: id,
+		Name: payload.Name,
+		Email: payload.Email,
+		Deactivated: teamsModels.UserActive,
+		Json: user,
+		UpdatedAt: now,
+		UpdatedBy: userName,
+		Deleted: false,
+		IsBot: false,
+		PasswordHash: string(passwordHash),
+	}
+
+	var userEntity *teamsModels.UserEntity
+
+	err = s.Transactor.RunInTx(func(tx *sqlx.Tx) error {
+		var err error
+		userEntity, err = s.UserEntityRepository.WithTx(tx).InsertUserEntity(entity)
+
+		if err != nil {
+			return err
+		}
+
+		return s.recordChangeEvent(tx, eventsModels.EntityCreated, userEntity, nil)
+	})
+
+	return userEntity, err
+}
+
+// DeactivateUserEntity keeps the user for the history of its changes, its tokens are rejected from now on
+func (s *UserEntityService) DeactivateUserEntity(exist *teamsModels.UserEntity, userName string) (*teamsModels.UserEntity, error) {
+	exist.Deactivated = teamsModels.UserDeactivated
+	exist.Json.Deactivated = true
+	exist.UpdatedAt = time.Now().Unix()
+	exist.UpdatedBy = userName
+
+	return s.updateUserEntity(exist, eventsModels.EntityUpdated)
+}
+
+// SetUserRoles replaces the roles of a user, the default role applies on top of them
+func (s *UserEntityService) SetUserRoles(exist *teamsModels.UserEntity, roleNames []string, userName string) (*teamsModels.UserEntity, error) {
+	roleEntities, err := s.RoleEntityRepository.SelectRoleEntitiesByNames(roleNames)
+
+	if err != nil {
+		return nil, err
+	}
+
+	roles := []*typeModels.EntityReference{}
+	found := map[string]bool{}
+
+	for _, e := range roleEntities {
+		roles = append(roles, e.Json.ToEntityReference())
+		found[e.Name] = true
+	}
+
+	for _, name := range roleNames {
+		if !found[name] {
+			return nil, fmt.Errorf("role %v not found", name)
+		}
+	}
+
+	exist.Json.Roles = roles
+	exist.UpdatedAt = time.Now().Unix()
+	exist.UpdatedBy = userName
+
+	return s.updateUserEntity(exist, eventsModels.EntityUpdated)
+}
+
+// EnsureAdminUser creates the first admin on start so that someone can log in, an existing user is left untouched
+func (s *UserEntityService) EnsureAdminUser(name string, email string, password string) error {
+	_, err := s.UserEntityRepository.SelectUserEntityByName(name, "all")
+
+	if err == nil {
+		return nil
+	}
+
+	if !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+
+	// Without a configured password the admin gets a random one, shown only when the admin is created
+	generated := password == ""
+
+	if generated {
+		b := make([]byte, 16)
+
+		if _, err := rand.Read(b); err != nil {
+			return err
+		}
+
+		password = hex.EncodeToString(b)
+	}
+
+	payload := &teamsModels.CreateUserPayload{
+		Name: name,
+		Email: email,
+		DisplayName: name,
+		Password: password,
+		IsAdmin: true,
+	}
+
+	if _, err := s.CreateUserEntity(payload, name); err != nil {
+		return err
+	}
+
+	if generated {
+		log.Printf("Created the admin user %v with the generated password %v\n", name, password)
+	}
+
+	return nil
+}
+
+func (s *UserEntityService) updateUserEntity(exist *teamsModels.UserEntity, eventType string) (*teamsModels.UserEntity, error) {
+	var userEntity *teamsModels.UserEntity
+
+	err := s.Transactor.RunInTx(func(tx *sqlx.Tx) error {
+		previous, err := s.UserEntityRepository.WithTx(tx).SelectUserEntityById(exist.ID, "all")
+
+		if err != nil {
+			return err
+		}
+
+		// exist was read before a concurrent update changed the stored version
+		if previous.Json.Version != exist.Json.Version {
+			return baseModels.ErrPreconditionFailed
+		}
+
+		changeDescription, err := baseUtils.CompareEntities(previous.Json, exist.Json)
+
+		if err != nil {
+			return err
+		}
+
+		if changeDescription.IsEmpty() {
+			userEntity = previous
+			return nil
+		}
+
+		changeDescription.PreviousVersion = previous.Json.Version
+		exist.Json.Version = baseUtils.NextVersion(previous.Json.Version, baseUtils.IsMajorChange(changeDescription))
+		exist.Json.ChangeDescription = changeDescription
+		exist.Json.UpdatedAt = exist.UpdatedAt
+		exist.Json.UpdatedBy = exist.UpdatedBy
+
+		userEntity, err = s.UserEntityRepository.WithTx(tx).UpdateUserEntity(exist, previous.Json.Version)
+
+		if errors.Is(err, sql.ErrNoRows) {
+			return baseModels.ErrPreconditionFailed
+		}
+
+		if err != nil {
+			return err
+		}
+
+		return s.recordChangeEvent(tx, eventType, userEntity, changeDescription)
+	})
+
+	return userEntity, err
+}
+
+func (s *UserEntityService) recordChangeEvent(tx *sqlx.Tx, eventType string, userEntity *teamsModels.UserEntity, changeDescription *typeModels.ChangeDescription) error {
+	changeEvent := eventsModels.NewChangeEventEntity(
+		eventType,
+		userEntity.Json.ToEntityReference(),
+		userEntity.Json.Version,
+		changeDescription,
+		userEntity.UpdatedBy,
+		userEntity.UpdatedAt,
+		userEntity.Json,
+	)
+
+	_, err := s.ChangeEventRepository.WithTx(tx).InsertChangeEvent(changeEvent)
+	return err
+}
@@ -0,0 +1,204 @@
+package utils
+
+import (
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+
+	typeModels "github.com/nambuitechx/go-metadata/models/type"
+)
+
+// Fields that change on every write and are not part of the entity history
+var ignoredFields = map[string]bool {
+	"version": true,
+	"changeDescription": true,
+	"updatedAt": true,
+	"updatedBy": true,
+}
+
+// CompareEntities describes the fields added, updated and deleted between two versions of an entity.
+// Lists of named objects such as columns are compared element by element
+func CompareEntities(previous interface{}, updated interface{}) (*typeModels.ChangeDescription, error) {
+	previousMap, err := StructToMap(previous)
+
+	if err != nil {
+		return nil, err
+	}
+
+	updatedMap, err := StructToMap(updated)
+
+	if err != nil {
+		return nil, err
+	}
+
+	changeDescription := &typeModels.ChangeDescription{
+		FieldsAdded: []typeModels.FieldChange{},
+		FieldsUpdated: []typeModels.FieldChange{},
+		FieldsDeleted: []typeModels.FieldChange{},
+	}
+
+	compareFields("", previousMap, updatedMap, changeDescription)
+
+	return changeDescription, nil
+}
+
+func compareFields(prefix string, previous map[string]interface{}, updated map[string]interface{}, changeDescription *typeModels.ChangeDescription) {
+	keys := []string{}
+
+	for key := range previous {
+		keys = append(keys, key)
+	}
+
+	for key := range updated {
+		if _, ok := previous[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if prefix == "" && ignoredFields[key] {
+			continue
+		}
+
+		name := key
+
+		if prefix != "" {
+			name = prefix + "." + key
+		}
+
+		oldValue, newValue := previous[key], updated[key]
+
+		switch {
+		case isEmptyValue(oldValue) && isEmptyValue(newValue):
+			continue
+		case isEmptyValue(oldValue):
+			changeDescription.FieldsAdded = append(changeDescription.FieldsAdded, typeModels.FieldChange{ Name: name, NewValue: newValue })
+		case isEmptyValue(newValue):
+			changeDescription.FieldsDeleted = append(changeDescription.FieldsDeleted, typeModels.FieldChange{ Name: name, OldValue: oldValue })
+		case reflect.DeepEqual(oldValue, newValue):
+			continue
+		default:
+			oldList, oldOk := namedList(oldValue)
+			newList, newOk := namedList(newValue)
+
+			if oldOk && newOk {
+				compareNamedLists(name, oldList, newList, changeDescription)
+			} else {
+				changeDescription.FieldsUpdated = append(changeDescription.FieldsUpdated, typeModels.FieldChange{ Name: name, OldValue: oldValue, NewValue: newValue })
+			}
+		}
+	}
+}
+
+func compareNamedLists(name string, previous []map[string]interface{}, updated []map[string]interface{}, changeDescription *typeModels.ChangeDescription) {
+	previousByName := map[string]map[string]interface{}{}
+	updatedByName := map[string]map[string]interface{}{}
+
+	for _, e := range previous {
+		previousByName[e["name"].(string)] = e
+	}
+
+	for _, e := range updated {
+		updatedByName[e["name"].(string)] = e
+	}
+
+	added := []interface{}{}
+	deleted := []interface{}{}
+
+	for _, e := range previous {
+		if _, ok := updatedByName[e["name"].(string)]; !ok {
+			deleted = append(deleted, e)
+		}
+	}
+
+	for _, e := range updated {
+		elementName := e["name"].(string)
+		old, ok := previousByName[elementName]
+
+		if !ok {
+			added = append(added, e)
+			continue
+		}
+
+		compareFields(name + "." + elementName, old, e, changeDescription)
+	}
+
+	if len(added) > 0 {
+		changeDescription.FieldsAdded = append(changeDescription.FieldsAdded, typeModels.FieldChange{ Name: name, NewValue: added })
+	}
+
+	if len(deleted) > 0 {
+		changeDescription.FieldsDeleted = append(changeDescription.FieldsDeleted, typeModels.FieldChange{ Name: name, OldValue: deleted })
+	}
+}
+
+// namedList converts a value into a list of objects when every element has a name
+func namedList(value interface{}) ([]map[string]interface{}, bool) {
+	list, ok := value.([]interface{})
+
+	if !ok {
+		return nil, false
+	}
+
+	result := []map[string]interface{}{}
+
+	for _, e := range list {
+		m, ok := e.(map[string]interface{})
+
+		if !ok {
+			return nil, false
+		}
+
+		if _, ok := m["name"].(string); !ok {
+			return nil, false
+		}
+
+		result = append(result, m)
+	}
+
+	return result, true
+}
+
+func isEmptyValue(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case string:
+		return v == ""
+	case []interface{}:
+		return len(v) == 0
+	case map[string]interface{}:
+		return len(v) == 0
+	}
+
+	return false
+}
+
+// IsMajorChange tells whether a change breaks consumers of the entity: a column is dropped or the data
+// type of a column changes
+func IsMajorChange(changeDescription *typeModels.ChangeDescription) bool {
+	for _, field := range changeDescription.FieldsDeleted {
+		if field.Name == "columns" {
+			return true
+		}
+	}
+
+	for _, field := range changeDescription.FieldsUpdated {
+		if strings.HasPrefix(field.Name, "columns.") && strings.HasSuffix(field.Name, ".dataType") {
+			return true
+		}
+	}
+
+	return false
+}
+
+func NextVersion(version float64, major bool) float64 {
+	if major {
+		return math.Floor(version) + 1.0
+	}
+
+	return math.Round((version + 0.1) * 10) / 10
+}
@@ -0,0 +1,187 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+
+	typeModels "github.com/nambuitechx/go-metadata/models/type"
+)
+
+func fieldNames(fieldChanges []typeModels.FieldChange) []string {
+	names := []string{}
+
+	for _, fieldChange := range fieldChanges {
+		names = append(names, fieldChange.Name)
+	}
+
+	return names
+}
+
+func TestCompareEntities(t *testing.T) {
+	column := func(name string, dataType string) map[string]interface{} {
+		return map[string]interface{}{ "name": name, "dataType": dataType }
+	}
+
+	previous := map[string]interface{}{
+		"name": "orders",
+		"description": "Orders",
+		"version": 0.1,
+		"updatedAt": 1,
+		"columns": []interface{}{ column("id", "INT"), column("total", "INT") },
+	}
+
+	tests := []struct {
+		name string
+		updated map[string]interface{}
+		added []string
+		fieldsUpdated []string
+		deleted []string
+	}{
+		{
+			name: "bookkeeping fields are ignored",
+			updated: map[string]interface{}{
+				"name": "orders",
+				"description": "Orders",
+				"version": 0.2,
+				"updatedAt": 2,
+				"updatedBy": "admin",
+				"columns": []interface{}{ column("id", "INT"), column("total", "INT") },
+			},
+			added: []string{},
+			fieldsUpdated: []string{},
+			deleted: []string{},
+		},
+		{
+			name: "field updated, added and deleted",
+			updated: map[string]interface{}{
+				"name": "orders",
+				"displayName": "All orders",
+				"columns": []interface{}{ column("id", "INT"), column("total", "INT") },
+			},
+			added: []string{"displayName"},
+			fieldsUpdated: []string{},
+			deleted: []string{"description"},
+		},
+		{
+			name: "empty values are absent",
+			updated: map[string]interface{}{
+				"name": "orders",
+				"description": "Orders",
+				"displayName": "",
+				"tags": []interface{}{},
+				"columns": []interface{}{ column("id", "INT"), column("total", "INT") },
+			},
+			added: []string{},
+			fieldsUpdated: []string{},
+			deleted: []string{},
+		},
+		{
+			name: "columns are compared by name",
+			updated: map[string]interface{}{
+				"name": "orders",
+				"description": "Orders",
+				"columns": []interface{}{ column("total", "BIGINT"), column("id", "INT"), column("status", "TEXT") },
+			},
+			added: []string{"columns"},
+			fieldsUpdated: []string{"columns.total.dataType"},
+			deleted: []string{},
+		},
+		{
+			name: "column dropped",
+			updated: map[string]interface{}{
+				"name": "orders",
+				"description": "Updated",
+				"columns": []interface{}{ column("id", "INT") },
+			},
+			added: []string{},
+			fieldsUpdated: []string{"description"},
+			deleted: []string{"columns"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			changeDescription, err := CompareEntities(previous, test.updated)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if names := fieldNames(changeDescription.FieldsAdded); !reflect.DeepEqual(names, test.added) {
+				t.Errorf("fieldsAdded = %v, expected %v", names, test.added)
+			}
+
+			if names := fieldNames(changeDescription.FieldsUpdated); !reflect.DeepEqual(names, test.fieldsUpdated) {
+				t.Errorf("fieldsUpdated = %v, expected %v", names, test.fieldsUpdated)
+			}
+
+			if names := fieldNames(changeDescription.FieldsDeleted); !reflect.DeepEqual(names, test.deleted) {
+				t.Errorf("fieldsDeleted = %v, expected %v", names, test.deleted)
+			}
+		})
+	}
+}
+
+func TestIsMajorChange(t *testing.T) {
+	tests := []struct {
+		name string
+		changeDescription *typeModels.ChangeDescription
+		expected bool
+	}{
+		{
+			name: "description updated",
+			changeDescription: &typeModels.ChangeDescription{ FieldsUpdated: []typeModels.FieldChange{ { Name: "description" } } },
+			expected: false,
+		},
+		{
+			name: "column added",
+			changeDescription: &typeModels.ChangeDescription{ FieldsAdded: []typeModels.FieldChange{ { Name: "columns" } } },
+			expected: false,
+		},
+		{
+			name: "column dropped",
+			changeDescription: &typeModels.ChangeDescription{ FieldsDeleted: []typeModels.FieldChange{ { Name: "columns" } } },
+			expected: true,
+		},
+		{
+			name: "column description updated",
+			changeDescription: &typeModels.ChangeDescription{ FieldsUpdated: []typeModels.FieldChange{ { Name: "columns.total.description" } } },
+			expected: false,
+		},
+		{
+			name: "column data type updated",
+			changeDescription: &typeModels.ChangeDescription{ FieldsUpdated: []typeModels.FieldChange{ { Name: "columns.total.dataType" } } },
+			expected: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if major := IsMajorChange(test.changeDescription); major != test.expected {
+				t.Errorf("IsMajorChange() = %v, expected %v", major, test.expected)
+			}
+		})
+	}
+}
+
+func TestNextVersion(t *testing.T) {
+	tests := []struct {
+		version float64
+		major bool
+		expected float64
+	}{
+		{ version: 0.1, major: false, expected: 0.2 },
+		{ version: 0.7, major: false, expected: 0.8 },
+		{ version: 0.9, major: false, expected: 1.0 },
+		{ version: 1.2, major: false, expected: 1.3 },
+		{ version: 0.3, major: true, expected: 1.0 },
+		{ version: 1.0, major: true, expected: 2.0 },
+		{ version: 2.9, major: true, expected: 3.0 },
+	}
+
+	for _, test := range tests {
+		if version := NextVersion(test.version, test.major); version != test.expected {
+			t.Errorf("NextVersion(%v, %v) = %v, expected %v", test.version, test.major, version, test.expected)
+		}
+	}
+}
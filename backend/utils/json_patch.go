@@ -0,0 +1,37 @@
+package utils
+
+import (
+	"encoding/json"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	baseModels "github.com/nambuitechx/go-metadata/models/base"
+)
+
+// ApplyJsonPatch applies the RFC 6902 operations to document and decodes the patched document into result
+func ApplyJsonPatch(document interface{}, operations []baseModels.JsonPatchOperation, result interface{}) error {
+	jsonPatch, err := json.Marshal(operations)
+
+	if err != nil {
+		return err
+	}
+
+	patch, err := jsonpatch.DecodePatch(jsonPatch)
+
+	if err != nil {
+		return err
+	}
+
+	documentJson, err := json.Marshal(document)
+
+	if err != nil {
+		return err
+	}
+
+	modified, err := patch.Apply(documentJson)
+
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(modified, result)
+}
@@ -0,0 +1,61 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+
+	baseModels "github.com/nambuitechx/go-metadata/models/base"
+)
+
+func TestApplyJsonPatch(t *testing.T) {
+	document := map[string]interface{}{
+		"description": "Orders",
+		"tags": []interface{}{"pii"},
+	}
+
+	tests := []struct {
+		name string
+		operations []baseModels.JsonPatchOperation
+		expected map[string]interface{}
+		expectedErr bool
+	}{
+		{
+			name: "replace",
+			operations: []baseModels.JsonPatchOperation{ { Op: "replace", Path: "/description", Value: "All orders" } },
+			expected: map[string]interface{}{ "description": "All orders", "tags": []interface{}{"pii"} },
+		},
+		{
+			name: "append and remove",
+			operations: []baseModels.JsonPatchOperation{
+				{ Op: "add", Path: "/tags/-", Value: "sales" },
+				{ Op: "remove", Path: "/description" },
+			},
+			expected: map[string]interface{}{ "tags": []interface{}{"pii", "sales"} },
+		},
+		{
+			name: "failed test",
+			operations: []baseModels.JsonPatchOperation{ { Op: "test", Path: "/description", Value: "Customers" } },
+			expectedErr: true,
+		},
+		{
+			name: "missing path",
+			operations: []baseModels.JsonPatchOperation{ { Op: "remove", Path: "/displayName" } },
+			expectedErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result := map[string]interface{}{}
+			err := ApplyJsonPatch(document, test.operations, &result)
+
+			if (err != nil) != test.expectedErr {
+				t.Fatalf("ApplyJsonPatch() err = %v, expected an error: %v", err, test.expectedErr)
+			}
+
+			if err == nil && !reflect.DeepEqual(result, test.expected) {
+				t.Errorf("ApplyJsonPatch() = %v, expected %v", result, test.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+var jwtHeader = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+
+// SignJWT encodes claims as the payload of a JWT signed with HMAC-SHA256
+func SignJWT(secret string, claims interface{}) (string, error) {
+	payload, err := json.Marshal(claims)
+
+	if err != nil {
+		return "", err
+	}
+
+	unsigned := jwtHeader + "." + base64.RawURLEncoding.EncodeToString(payload)
+	return unsigned + "." + signJWT(secret, unsigned), nil
+}
+
+// VerifyJWT checks the algorithm and signature of token and decodes its payload into claims, the claims themselves
+// are left to the caller
+func VerifyJWT(secret string, token string, claims interface{}) error {
+	parts := strings.Split(token, ".")
+
+	if len(parts) != 3 {
+		return errors.New("malformed token")
+	}
+
+	header, err := base64.RawURLEncoding.DecodeString(parts[0])
+
+	if err != nil {
+		return errors.New("malformed token header")
+	}
+
+	var alg struct {
+		Alg string `json:"alg"`
+	}
+
+	if err := json.Unmarshal(header, &alg); err != nil || alg.Alg != "HS256" {
+		return errors.New("unsupported token algorithm")
+	}
+
+	expected := signJWT(secret, parts[0] + "." + parts[1])
+
+	if !hmac.Equal([]byte(expected), []byte(parts[2])) {
+		return errors.New("invalid token signature")
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+
+	if err != nil {
+		return errors.New("malformed token payload")
+	}
+
+	return json.Unmarshal(payload, claims)
+}
+
+func signJWT(secret string, unsigned string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(unsigned))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,50 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+)
+
+type testClaims struct {
+	Subject string `json:"sub"`
+}
+
+func TestVerifyJWT(t *testing.T) {
+	token, err := SignJWT("secret", &testClaims{ Subject: "alice" })
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parts := strings.Split(token, ".")
+	noneHeader := "eyJhbGciOiJub25lIiwidHlwIjoiSldUIn0"
+
+	tests := []struct {
+		name string
+		secret string
+		token string
+		expectedErr bool
+	}{
+		{ name: "valid", secret: "secret", token: token },
+		{ name: "other secret", secret: "other", token: token, expectedErr: true },
+		{ name: "tampered payload", secret: "secret", token: parts[0] + ".eyJzdWIiOiJib2IifQ." + parts[2], expectedErr: true },
+		{ name: "none algorithm", secret: "secret", token: noneHeader + "." + parts[1] + ".", expectedErr: true },
+		{ name: "malformed", secret: "secret", token: parts[0] + "." + parts[1], expectedErr: true },
+		{ name: "malformed header", secret: "secret", token: "!." + parts[1] + "." + parts[2], expectedErr: true },
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			claims := &testClaims{}
+			err := VerifyJWT(test.secret, test.token, claims)
+
+			if (err != nil) != test.expectedErr {
+				t.Fatalf("VerifyJWT() err = %v, expected an error: %v", err, test.expectedErr)
+			}
+
+			if err == nil && claims.Subject != "alice" {
+				t.Errorf("VerifyJWT() subject = %v, expected alice", claims.Subject)
+			}
+		})
+	}
+}
@@ -0,0 +1,11 @@
+package utils
+
+// MergeString keeps the stored value when the incoming one is empty, so that a PUT from ingestion
+// does not erase descriptions written by users
+func MergeString(stored string, incoming string) string {
+	if incoming == "" {
+		return stored
+	}
+
+	return incoming
+}
@@ -0,0 +1,22 @@
+package utils
+
+import "testing"
+
+func TestMergeString(t *testing.T) {
+	tests := []struct {
+		stored string
+		incoming string
+		expected string
+	}{
+		{ stored: "Written by a user", incoming: "", expected: "Written by a user" },
+		{ stored: "Written by a user", incoming: "Ingested", expected: "Ingested" },
+		{ stored: "", incoming: "Ingested", expected: "Ingested" },
+		{ stored: "", incoming: "", expected: "" },
+	}
+
+	for _, test := range tests {
+		if merged := MergeString(test.stored, test.incoming); merged != test.expected {
+			t.Errorf("MergeString(%q, %q) = %q, expected %q", test.stored, test.incoming, merged, test.expected)
+		}
+	}
+}
@@ -0,0 +1,474 @@
+package sqllineage
+
+import (
+	"strings"
+)
+
+// Identifier is a part of a table name, unquoted identifiers are not case sensitive
+type Identifier struct {
+	Value string
+	Quoted bool
+}
+
+// TableName is a possibly qualified table name, such as schema.table
+type TableName []Identifier
+
+func (n TableName) String() string {
+	values := []string{}
+
+	for _, identifier := range n {
+		values = append(values, identifier.Value)
+	}
+
+	return strings.Join(values, ".")
+}
+
+// Matches reports whether a dotted name, such as the end of a fully qualified name, is the table name. Quoted parts
+// match exactly and unquoted ones in any case
+func (n TableName) Matches(name string) bool {
+	for i, identifier := range n {
+		if i > 0 {
+			if !strings.HasPrefix(name, ".") {
+				return false
+			}
+
+			name = name[1:]
+		}
+
+		if len(name) < len(identifier.Value) {
+			return false
+		}
+
+		part := name[:len(identifier.Value)]
+
+		if (identifier.Quoted && part != identifier.Value) || (!identifier.Quoted && !strings.EqualFold(part, identifier.Value)) {
+			return false
+		}
+
+		name = name[len(identifier.Value):]
+	}
+
+	return name == ""
+}
+
+// Lineage is a statement writing to Target from the tables in Sources
+type Lineage struct {
+	Target TableName
+	Sources []TableName
+	Query string
+}
+
+// Words that end a table reference, so they are never read as its alias
+var clauseKeywords = map[string]bool{
+	"WHERE": true, "JOIN": true, "INNER": true, "LEFT": true, "RIGHT": true, "FULL": true, "CROSS": true,
+	"NATURAL": true, "OUTER": true, "STRAIGHT_JOIN": true, "ON": true, "USING": true, "GROUP": true,
+	"ORDER": true, "HAVING": true, "LIMIT": true, "OFFSET": true, "FETCH": true, "UNION": true,
+	"INTERSECT": true, "EXCEPT": true, "WINDOW": true, "SET": true, "WHEN": true, "THEN": true,
+	"ELSE": true, "END": true, "RETURNING": true, "FOR": true, "SELECT": true, "VALUES": true,
+	"AS": true, "INTO": true, "LATERAL": true, "WITH": true, "PARTITION": true, "TABLESAMPLE": true,
+	"DEFAULT": true, "LOCK": true,
+}
+
+// Functions whose arguments may contain FROM without it introducing a table
+var fromFunctions = map[string]bool{
+	"EXTRACT": true, "SUBSTRING": true, "SUBSTR": true, "TRIM": true, "OVERLAY": true, "POSITION": true,
+}
+
+// Parse returns the lineage of every INSERT INTO ... SELECT, CREATE TABLE ... AS, MERGE and UPDATE ... FROM
+// statement in sql, including the statements in the body of a procedure. Statements that do not read
+// from a table are left out
+func Parse(sql string, dialect Dialect) []Lineage {
+	lineages := []Lineage{}
+
+	for _, statement := range splitStatements(tokenize(sql, dialect)) {
+		lineage := parseStatement(sql, statement)
+
+		if lineage == nil || len(lineage.Sources) == 0 {
+			continue
+		}
+
+		lineages = append(lineages, *lineage)
+	}
+
+	return lineages
+}
+
+// ParseView returns the tables read by a view definition, which is either the query of the view or its CREATE VIEW
+// statement
+func ParseView(sql string, dialect Dialect) []TableName {
+	sources := []TableName{}
+	seen := map[string]bool{}
+
+	for _, statement := range splitStatements(tokenize(sql, dialect)) {
+		for _, source := range collectSources(statement) {
+			if !seen[source.String()] {
+				seen[source.String()] = true
+				sources = append(sources, source)
+			}
+		}
+	}
+
+	return sources
+}
+
+// splitStatements splits tokens on the semicolons outside parentheses
+func splitStatements(tokens []token) [][]token {
+	statements := [][]token{}
+	start := 0
+	depth := 0
+
+	for i, t := range tokens {
+		switch {
+		case t.isPunct("("):
+			depth++
+		case t.isPunct(")") && depth > 0:
+			depth--
+		case t.isPunct(";") && depth == 0:
+			if i > start {
+				statements = append(statements, tokens[start:i])
+			}
+
+			start = i + 1
+		}
+	}
+
+	if start < len(tokens) {
+		statements = append(statements, tokens[start:])
+	}
+
+	return statements
+}
+
+// parseStatement finds the write at the top level of a statement and reads its target. The sources are read
+// from the write onwards, or from the WITH clause in front of it so that common table expressions count
+func parseStatement(sql string, tokens []token) *Lineage {
+	depth := 0
+	withStart := -1
+
+	for i, t := range tokens {
+		if t.isPunct("(") {
+			depth++
+			continue
+		}
+
+		if t.isPunct(")") {
+			depth--
+			continue
+		}
+
+		if depth != 0 || t.kind != wordToken {
+			continue
+		}
+
+		if t.is("WITH") && withStart < 0 {
+			withStart = i
+			continue
+		}
+
+		if isNestedKeyword(tokens, i) {
+			continue
+		}
+
+		sourceStart := i
+
+		if withStart >= 0 {
+			sourceStart = withStart
+		}
+
+		var lineage *Lineage
+
+		switch strings.ToUpper(t.value) {
+		case "INSERT":
+			lineage = parseInsert(tokens, i, sourceStart)
+		case "REPLACE":
+			// REPLACE INTO in MySQL, not the string function
+			if i + 1 < len(tokens) && tokens[i + 1].is("INTO") {
+				lineage = parseInsert(tokens, i, sourceStart)
+			}
+		case "CREATE":
+			lineage = parseCreateTable(tokens, i, sourceStart)
+		case "MERGE":
+			lineage = parseMerge(tokens, i, sourceStart)
+		case "UPDATE":
+			lineage = parseUpdate(tokens, i, sourceStart)
+		}
+
+		if lineage != nil {
+			lineage.Query = strings.TrimSpace(sql[tokens[sourceStart].start:tokens[len(tokens) - 1].end])
+			return lineage
+		}
+	}
+
+	return nil
+}
+
+// isNestedKeyword tells apart INSERT and UPDATE starting a statement from their use in triggers, grants,
+// row locks and conflict clauses
+func isNestedKeyword(tokens []token, i int) bool {
+	if i > 0 {
+		previous := tokens[i - 1]
+
+		for _, keyword := range []string{"BEFORE", "AFTER", "OR", "FOR", "GRANT", "KEY", "DO", "OF"} {
+			if previous.is(keyword) {
+				return true
+			}
+		}
+
+		if previous.isPunct(",") {
+			return true
+		}
+	}
+
+	if i + 1 < len(tokens) {
+		next := tokens[i + 1]
+		return next.is("ON") || next.is("OR") || next.is("OF") || next.isPunct(",")
+	}
+
+	return true
+}
+
+func parseInsert(tokens []token, i int, sourceStart int) *Lineage {
+	j := skipKeywords(tokens, i + 1, "IGNORE", "LOW_PRIORITY", "DELAYED", "HIGH_PRIORITY", "INTO")
+	target, _ := parseTableName(tokens, j)
+
+	if target == nil {
+		return nil
+	}
+
+	return &Lineage{ Target: target, Sources: excludeTarget(collectSources(tokens[sourceStart:]), target) }
+}
+
+func parseCreateTable(tokens []token, i int, sourceStart int) *Lineage {
+	j := skipKeywords(tokens, i + 1, "OR", "REPLACE", "GLOBAL", "LOCAL", "TEMP", "TEMPORARY", "UNLOGGED")
+
+	if j >= len(tokens) || !tokens[j].is("TABLE") {
+		return nil
+	}
+
+	j = skipKeywords(tokens, j + 1, "IF", "NOT", "EXISTS")
+	target, j := parseTableName(tokens, j)
+
+	if target == nil {
+		return nil
+	}
+
+	if j < len(tokens) && tokens[j].isPunct("(") {
+		j = matchingParen(tokens, j) + 1
+	}
+
+	// CREATE TABLE ... AS SELECT, MySQL also accepts the SELECT without AS
+	if j >= len(tokens) || !(tokens[j].is("AS") || tokens[j].is("SELECT") || tokens[j].isPunct("(")) {
+		return nil
+	}
+
+	return &Lineage{ Target: target, Sources: excludeTarget(collectSources(tokens[sourceStart:]), target) }
+}
+
+func parseMerge(tokens []token, i int, sourceStart int) *Lineage {
+	j := skipKeywords(tokens, i + 1, "INTO")
+	target, j := parseTableName(tokens, j)
+
+	if target == nil {
+		return nil
+	}
+
+	sources := collectSources(tokens[sourceStart:])
+
+	for ; j < len(tokens); j++ {
+		if tokens[j].is("USING") {
+			if source, _ := parseTableName(tokens, skipKeywords(tokens, j + 1, "ONLY")); source != nil {
+				sources = append(sources, source)
+			}
+
+			break
+		}
+	}
+
+	return &Lineage{ Target: target, Sources: excludeTarget(sources, target) }
+}
+
+func parseUpdate(tokens []token, i int, sourceStart int) *Lineage {
+	j := skipKeywords(tokens, i + 1, "LOW_PRIORITY", "IGNORE", "ONLY")
+	target, _ := parseTableName(tokens, j)
+
+	if target == nil {
+		return nil
+	}
+
+	// UPDATE t1, t2 SET ... and UPDATE t1 JOIN t2 ON ... SET ... in MySQL
+	sources := collectTableList(tokens, j, true)
+	sources = append(sources, collectSources(tokens[sourceStart:])...)
+
+	return &Lineage{ Target: target, Sources: excludeTarget(sources, target) }
+}
+
+// collectSources returns the tables following FROM and JOIN anywhere in tokens, without the names of
+// common table expressions
+func collectSources(tokens []token) []TableName {
+	sources := []TableName{}
+	cteNames := map[string]bool{}
+	functionDepths := map[int]bool{}
+	depth := 0
+
+	for i, t := range tokens {
+		switch {
+		case t.isPunct("("):
+			depth++
+			functionDepths[depth] = i > 0 && tokens[i - 1].kind == wordToken && fromFunctions[strings.ToUpper(tokens[i - 1].value)]
+		case t.isPunct(")"):
+			delete(functionDepths, depth)
+			depth--
+		case t.is("AS") && i > 0 && tokens[i - 1].isIdentifier() && i + 1 < len(tokens) && tokens[i + 1].isPunct("("):
+			// name AS ( ... ) defines a common table expression
+			if i > 1 && (tokens[i - 2].is("WITH") || tokens[i - 2].is("RECURSIVE") || tokens[i - 2].isPunct(",")) {
+				cteNames[tokens[i - 1].value] = true
+			}
+		case t.is("FROM") && !functionDepths[depth] && !(i > 0 && tokens[i - 1].is("DISTINCT")):
+			sources = append(sources, collectTableList(tokens, i + 1, true)...)
+		case t.is("JOIN") || t.is("STRAIGHT_JOIN"):
+			sources = append(sources, collectTableList(tokens, i + 1, false)...)
+		}
+	}
+
+	tables := []TableName{}
+
+	for _, source := range sources {
+		if len(source) == 1 && cteNames[source[0].Value] {
+			continue
+		}
+
+		tables = append(tables, source)
+	}
+
+	return tables
+}
+
+// collectTableList reads a table reference at i, and the ones that follow it after commas when list is set.
+// Subqueries and table functions are skipped, their own FROM clauses are read by collectSources
+func collectTableList(tokens []token, i int, list bool) []TableName {
+	tables := []TableName{}
+
+	for i < len(tokens) {
+		i = skipKeywords(tokens, i, "ONLY", "LATERAL")
+
+		if i >= len(tokens) {
+			break
+		}
+
+		if tokens[i].isPunct("(") {
+			i = matchingParen(tokens, i) + 1
+		} else if tokens[i].isIdentifier() && !(tokens[i].kind == wordToken && clauseKeywords[strings.ToUpper(tokens[i].value)]) {
+			table, j := parseTableName(tokens, i)
+
+			if j < len(tokens) && tokens[j].isPunct("(") {
+				j = matchingParen(tokens, j) + 1
+			} else {
+				tables = append(tables, table)
+			}
+
+			i = j
+		} else {
+			break
+		}
+
+		i = skipAlias(tokens, i)
+
+		if !list || i >= len(tokens) || !tokens[i].isPunct(",") {
+			break
+		}
+
+		i++
+	}
+
+	return tables
+}
+
+// parseTableName reads identifiers separated by dots and returns the index after the name
+func parseTableName(tokens []token, i int) (TableName, int) {
+	if i >= len(tokens) || !tokens[i].isIdentifier() {
+		return nil, i
+	}
+
+	name := TableName{ tokens[i].identifier() }
+	i++
+
+	for i + 1 < len(tokens) && tokens[i].isPunct(".") && tokens[i + 1].isIdentifier() {
+		name = append(name, tokens[i + 1].identifier())
+		i += 2
+	}
+
+	return name, i
+}
+
+func skipAlias(tokens []token, i int) int {
+	if i < len(tokens) && tokens[i].is("AS") {
+		i++
+	}
+
+	if i < len(tokens) && (tokens[i].kind == quotedToken || (tokens[i].kind == wordToken && !clauseKeywords[strings.ToUpper(tokens[i].value)])) {
+		i++
+
+		// Column aliases, as in (VALUES ...) AS v(a, b)
+		if i < len(tokens) && tokens[i].isPunct("(") {
+			i = matchingParen(tokens, i) + 1
+		}
+	}
+
+	return i
+}
+
+func skipKeywords(tokens []token, i int, keywords ...string) int {
+	for i < len(tokens) {
+		skipped := false
+
+		for _, keyword := range keywords {
+			if tokens[i].is(keyword) {
+				skipped = true
+				break
+			}
+		}
+
+		if !skipped {
+			return i
+		}
+
+		i++
+	}
+
+	return i
+}
+
+// matchingParen returns the index of the parenthesis closing the one at i
+func matchingParen(tokens []token, i int) int {
+	depth := 0
+
+	for j := i; j < len(tokens); j++ {
+		if tokens[j].isPunct("(") {
+			depth++
+		} else if tokens[j].isPunct(")") {
+			depth--
+
+			if depth == 0 {
+				return j
+			}
+		}
+	}
+
+	return len(tokens) - 1
+}
+
+func excludeTarget(sources []TableName, target TableName) []TableName {
+	tables := []TableName{}
+	seen := map[string]bool{ target.String(): true }
+
+	for _, source := range sources {
+		if seen[source.String()] {
+			continue
+		}
+
+		seen[source.String()] = true
+		tables = append(tables, source)
+	}
+
+	return tables
+}
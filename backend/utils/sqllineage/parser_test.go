@@ -0,0 +1,247 @@
+package sqllineage
+
+import (
+	"reflect"
+	"testing"
+)
+
+type expectedLineage struct {
+	target string
+	sources []string
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name string
+		sql string
+		dialect Dialect
+		expected []expectedLineage
+	}{
+		{
+			name: "insert select",
+			sql: "INSERT INTO sales.daily SELECT o.id FROM sales.orders o JOIN sales.customers c ON o.customer_id = c.id",
+			dialect: Postgres,
+			expected: []expectedLineage{{ target: "sales.daily", sources: []string{"sales.orders", "sales.customers"} }},
+		},
+		{
+			name: "insert select from a list and a subquery",
+			sql: "INSERT INTO report (id) SELECT a.id FROM a, (SELECT id FROM b) sub WHERE a.id = sub.id",
+			dialect: Postgres,
+			expected: []expectedLineage{{ target: "report", sources: []string{"a", "b"} }},
+		},
+		{
+			name: "insert without a source",
+			sql: "INSERT INTO report (id) VALUES (1)",
+			dialect: Postgres,
+			expected: []expectedLineage{},
+		},
+		{
+			name: "create table as",
+			sql: "CREATE TABLE IF NOT EXISTS report AS SELECT id FROM orders WHERE total > 10",
+			dialect: Postgres,
+			expected: []expectedLineage{{ target: "report", sources: []string{"orders"} }},
+		},
+		{
+			name: "create table without a query",
+			sql: "CREATE TABLE report (id int, total numeric)",
+			dialect: Postgres,
+			expected: []expectedLineage{},
+		},
+		{
+			name: "merge",
+			sql: `MERGE INTO dim_customer d USING staging.customers s ON d.id = s.id
+				WHEN MATCHED THEN UPDATE SET name = s.name
+				WHEN NOT MATCHED THEN INSERT (id, name) VALUES (s.id, s.name)`,
+			dialect: Postgres,
+			expected: []expectedLineage{{ target: "dim_customer", sources: []string{"staging.customers"} }},
+		},
+		{
+			name: "update from",
+			sql: "UPDATE accounts a SET balance = t.total FROM totals t JOIN currencies c ON t.currency = c.code WHERE a.id = t.id",
+			dialect: Postgres,
+			expected: []expectedLineage{{ target: "accounts", sources: []string{"totals", "currencies"} }},
+		},
+		{
+			name: "update without a source",
+			sql: "UPDATE accounts SET balance = 0 WHERE id = 1",
+			dialect: Postgres,
+			expected: []expectedLineage{},
+		},
+		{
+			name: "common table expressions are not sources",
+			sql: `WITH recent AS (SELECT * FROM orders WHERE created_at > now()), big AS (SELECT * FROM recent WHERE total > 10)
+				INSERT INTO archive SELECT * FROM big JOIN customers ON big.customer_id = customers.id`,
+			dialect: Postgres,
+			expected: []expectedLineage{{ target: "archive", sources: []string{"orders", "customers"} }},
+		},
+		{
+			name: "from inside function arguments",
+			sql: "INSERT INTO stats SELECT EXTRACT(YEAR FROM created_at), SUBSTRING(name FROM 2), TRIM(BOTH ' ' FROM code) FROM events",
+			dialect: Postgres,
+			expected: []expectedLineage{{ target: "stats", sources: []string{"events"} }},
+		},
+		{
+			name: "dollar quoted procedure body",
+			sql: `CREATE OR REPLACE PROCEDURE load_sales() LANGUAGE plpgsql AS $body$
+				BEGIN
+					-- INSERT INTO ignored SELECT * FROM comment
+					INSERT INTO sales SELECT * FROM staging_sales;
+					UPDATE sales SET region = r.name FROM regions r WHERE sales.region_id = r.id;
+				END;
+				$body$`,
+			dialect: Postgres,
+			expected: []expectedLineage{
+				{ target: "sales", sources: []string{"staging_sales"} },
+				{ target: "sales", sources: []string{"regions"} },
+			},
+		},
+		{
+			name: "quoted identifiers keep their case",
+			sql: `INSERT INTO "Sales"."Daily Totals" SELECT * FROM Public.Orders JOIN "Public"."Customers" USING (id)`,
+			dialect: Postgres,
+			expected: []expectedLineage{{ target: "Sales.Daily Totals", sources: []string{"public.orders", "Public.Customers"} }},
+		},
+		{
+			name: "mysql quoted identifiers, strings and comments",
+			sql: "INSERT INTO `db`.`Target` SELECT \"FROM x\" FROM Orders o STRAIGHT_JOIN `db`.Customers c ON o.id = c.id # FROM comment",
+			dialect: MySQL,
+			expected: []expectedLineage{{ target: "db.Target", sources: []string{"Orders", "db.Customers"} }},
+		},
+		{
+			name: "mysql replace into and multiple table update",
+			sql: "REPLACE INTO summary SELECT * FROM totals; UPDATE orders o JOIN customers c ON o.customer_id = c.id SET o.name = c.name",
+			dialect: MySQL,
+			expected: []expectedLineage{
+				{ target: "summary", sources: []string{"totals"} },
+				{ target: "orders", sources: []string{"customers"} },
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lineages := []expectedLineage{}
+
+			for _, lineage := range Parse(tt.sql, tt.dialect) {
+				sources := []string{}
+
+				for _, source := range lineage.Sources {
+					sources = append(sources, source.String())
+				}
+
+				lineages = append(lineages, expectedLineage{ target: lineage.Target.String(), sources: sources })
+			}
+
+			if !reflect.DeepEqual(lineages, tt.expected) {
+				t.Errorf("Parse(%q) = %v, expected %v", tt.sql, lineages, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParseQuery(t *testing.T) {
+	sql := "CREATE FUNCTION f() RETURNS void AS $$ INSERT INTO a SELECT * FROM b; $$ LANGUAGE sql"
+	lineages := Parse(sql, Postgres)
+
+	if len(lineages) != 1 {
+		t.Fatalf("Parse(%q) returned %v lineages, expected 1", sql, len(lineages))
+	}
+
+	if lineages[0].Query != "INSERT INTO a SELECT * FROM b" {
+		t.Errorf("Query = %q, expected the statement of the body", lineages[0].Query)
+	}
+}
+
+func TestParseView(t *testing.T) {
+	tests := []struct {
+		name string
+		sql string
+		dialect Dialect
+		expected []string
+	}{
+		{
+			name: "query",
+			sql: "SELECT o.id, c.name FROM sales.orders o JOIN sales.customers c ON o.customer_id = c.id",
+			dialect: Postgres,
+			expected: []string{"sales.orders", "sales.customers"},
+		},
+		{
+			name: "create view statement",
+			sql: "CREATE OR REPLACE VIEW report AS SELECT * FROM orders WHERE total > 10",
+			dialect: Postgres,
+			expected: []string{"orders"},
+		},
+		{
+			name: "tables read twice and common table expressions",
+			sql: "WITH big AS (SELECT * FROM orders WHERE total > 10) SELECT * FROM big JOIN orders USING (id)",
+			dialect: Postgres,
+			expected: []string{"orders"},
+		},
+		{
+			name: "mysql quoted identifiers",
+			sql: "CREATE VIEW `report` AS SELECT * FROM `db`.`Orders`",
+			dialect: MySQL,
+			expected: []string{"db.Orders"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sources := []string{}
+
+			for _, source := range ParseView(tt.sql, tt.dialect) {
+				sources = append(sources, source.String())
+			}
+
+			if !reflect.DeepEqual(sources, tt.expected) {
+				t.Errorf("ParseView(%q) = %v, expected %v", tt.sql, sources, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParseIdentifiers(t *testing.T) {
+	sql := `INSERT INTO Sales."Daily" SELECT * FROM "Public".Orders`
+	lineages := Parse(sql, Postgres)
+
+	if len(lineages) != 1 {
+		t.Fatalf("Parse(%q) returned %v lineages, expected 1", sql, len(lineages))
+	}
+
+	expectedTarget := TableName{ { Value: "sales" }, { Value: "Daily", Quoted: true } }
+	expectedSources := []TableName{ { { Value: "Public", Quoted: true }, { Value: "orders" } } }
+
+	if !reflect.DeepEqual(lineages[0].Target, expectedTarget) {
+		t.Errorf("Target = %v, expected %v", lineages[0].Target, expectedTarget)
+	}
+
+	if !reflect.DeepEqual(lineages[0].Sources, expectedSources) {
+		t.Errorf("Sources = %v, expected %v", lineages[0].Sources, expectedSources)
+	}
+}
+
+func TestTableNameMatches(t *testing.T) {
+	tests := []struct {
+		name string
+		tableName TableName
+		value string
+		expected bool
+	}{
+		{ name: "same case", tableName: TableName{ { Value: "sales" }, { Value: "orders" } }, value: "sales.orders", expected: true },
+		{ name: "unquoted in another case", tableName: TableName{ { Value: "sales" }, { Value: "orders" } }, value: "Sales.Orders", expected: true },
+		{ name: "quoted in another case", tableName: TableName{ { Value: "Sales", Quoted: true }, { Value: "orders" } }, value: "sales.Orders", expected: false },
+		{ name: "quoted in the same case", tableName: TableName{ { Value: "Sales", Quoted: true }, { Value: "orders" } }, value: "Sales.ORDERS", expected: true },
+		{ name: "quoted part with a dot", tableName: TableName{ { Value: "a.b", Quoted: true } }, value: "a.b", expected: true },
+		{ name: "fewer parts", tableName: TableName{ { Value: "orders" } }, value: "sales.orders", expected: false },
+		{ name: "more parts", tableName: TableName{ { Value: "sales" }, { Value: "orders" } }, value: "orders", expected: false },
+		{ name: "longer name", tableName: TableName{ { Value: "orders" } }, value: "orders_archive", expected: false },
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if matches := tt.tableName.Matches(tt.value); matches != tt.expected {
+				t.Errorf("%v.Matches(%q) = %v, expected %v", tt.tableName, tt.value, matches, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,184 @@
+package sqllineage
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Dialect decides how identifiers are quoted and folded and which comments and string forms exist
+type Dialect int
+
+const (
+	Postgres Dialect = iota
+	MySQL
+)
+
+// DialectFromServiceType maps the service type of a database service to its SQL dialect
+func DialectFromServiceType(serviceType string) Dialect {
+	if serviceType == "MySQL" {
+		return MySQL
+	}
+
+	return Postgres
+}
+
+type tokenKind int
+
+const (
+	wordToken tokenKind = iota
+	quotedToken
+	stringToken
+	numberToken
+	punctToken
+)
+
+type token struct {
+	kind tokenKind
+	value string
+	start int
+	end int
+}
+
+// is reports whether the token is the unquoted keyword
+func (t token) is(keyword string) bool {
+	return t.kind == wordToken && strings.EqualFold(t.value, keyword)
+}
+
+func (t token) isPunct(punct string) bool {
+	return t.kind == punctToken && t.value == punct
+}
+
+func (t token) isIdentifier() bool {
+	return t.kind == wordToken || t.kind == quotedToken
+}
+
+func (t token) identifier() Identifier {
+	return Identifier{ Value: t.value, Quoted: t.kind == quotedToken }
+}
+
+// tokenize splits sql into tokens, dropping whitespace and comments. Dollar quotes of Postgres function
+// bodies are dropped as well so that the statements of the body are analyzed like any other statement.
+// Unquoted identifiers are folded to lower case in Postgres
+func tokenize(sql string, dialect Dialect) []token {
+	tokens := []token{}
+	runes := []rune(sql)
+	offsets := make([]int, len(runes) + 1)
+
+	for i, offset := 0, 0; i < len(runes); i++ {
+		offsets[i] = offset
+		offset += len(string(runes[i]))
+		offsets[i + 1] = offset
+	}
+
+	i := 0
+
+	for i < len(runes) {
+		r := runes[i]
+
+		switch {
+		case unicode.IsSpace(r):
+			i++
+
+		case r == '-' && i + 1 < len(runes) && runes[i + 1] == '-', dialect == MySQL && r == '#':
+			for i < len(runes) && runes[i] != '\n' {
+				i++
+			}
+
+		case r == '/' && i + 1 < len(runes) && runes[i + 1] == '*':
+			i += 2
+
+			for i < len(runes) && !(runes[i] == '*' && i + 1 < len(runes) && runes[i + 1] == '/') {
+				i++
+			}
+
+			i += 2
+
+		case dialect == Postgres && r == '$' && dollarTagEnd(runes, i) > 0:
+			i = dollarTagEnd(runes, i)
+
+		case r == '\'':
+			start := i
+			i = quotedEnd(runes, i, '\'', dialect == MySQL)
+			tokens = append(tokens, token{ kind: stringToken, value: string(runes[start:min(i, len(runes))]), start: offsets[start], end: offsets[min(i, len(runes))] })
+
+		case r == '"' && dialect == Postgres, r == '`' && dialect == MySQL:
+			start := i
+			i = quotedEnd(runes, i, r, false)
+			end := min(i, len(runes))
+			value := strings.ReplaceAll(string(runes[start + 1:max(start + 1, end - 1)]), string([]rune{r, r}), string(r))
+			tokens = append(tokens, token{ kind: quotedToken, value: value, start: offsets[start], end: offsets[end] })
+
+		case r == '"' && dialect == MySQL:
+			start := i
+			i = quotedEnd(runes, i, '"', true)
+			tokens = append(tokens, token{ kind: stringToken, value: string(runes[start:min(i, len(runes))]), start: offsets[start], end: offsets[min(i, len(runes))] })
+
+		case unicode.IsLetter(r) || r == '_':
+			start := i
+
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || runes[i] == '_' || runes[i] == '$') {
+				i++
+			}
+
+			value := string(runes[start:i])
+
+			if dialect == Postgres {
+				value = strings.ToLower(value)
+			}
+
+			tokens = append(tokens, token{ kind: wordToken, value: value, start: offsets[start], end: offsets[i] })
+
+		case unicode.IsDigit(r):
+			start := i
+
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.') {
+				i++
+			}
+
+			tokens = append(tokens, token{ kind: numberToken, value: string(runes[start:i]), start: offsets[start], end: offsets[i] })
+
+		default:
+			tokens = append(tokens, token{ kind: punctToken, value: string(r), start: offsets[i], end: offsets[i + 1] })
+			i++
+		}
+	}
+
+	return tokens
+}
+
+// quotedEnd returns the index after the closing quote, a doubled quote is part of the value and so is
+// a backslash escape when backslashEscapes is set
+func quotedEnd(runes []rune, i int, quote rune, backslashEscapes bool) int {
+	i++
+
+	for i < len(runes) {
+		switch {
+		case backslashEscapes && runes[i] == '\\':
+			i += 2
+		case runes[i] == quote && i + 1 < len(runes) && runes[i + 1] == quote:
+			i += 2
+		case runes[i] == quote:
+			return i + 1
+		default:
+			i++
+		}
+	}
+
+	return i
+}
+
+// dollarTagEnd returns the index after a dollar quote tag such as $$ or $body$ starting at i, or 0 when
+// there is none
+func dollarTagEnd(runes []rune, i int) int {
+	j := i + 1
+
+	for j < len(runes) && (unicode.IsLetter(runes[j]) || unicode.IsDigit(runes[j]) || runes[j] == '_') {
+		j++
+	}
+
+	if j < len(runes) && runes[j] == '$' && (j == i + 1 || !unicode.IsDigit(runes[i + 1])) {
+		return j + 1
+	}
+
+	return 0
+}
@@ -20,6 +20,8 @@ class Settings(BaseSettings):
     DATABASE_PASSWORD: str
     
     BACKEND_URL: str = "http://backend:8585/api"
+    # Token of a bot with the IngestionBotRole, generated with POST /v1/bots/name/{name}/token
+    BACKEND_JWT_TOKEN: str = ""
     
 
 settings = Settings(_env_file=".env")
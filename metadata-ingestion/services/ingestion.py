@@ -245,15 +245,21 @@ metadata.ingestion.connections.test_connections._test_connection_steps = (
 )
 
 
+def get_server_config():
+    # The backend rejects requests without a valid token, BACKEND_JWT_TOKEN is the token of the ingestion bot
+    if not settings.BACKEND_JWT_TOKEN:
+        logger.warning("========== BACKEND_JWT_TOKEN is not set, the backend will reject the requests")
+
+    return {
+        "hostPort": settings.BACKEND_URL,
+        "authProvider": "openmetadata",
+        "securityConfig": {"jwtToken": settings.BACKEND_JWT_TOKEN},
+    }
+
+
 def get_metadata_client():
     metadata = OpenMetadata(
-        config=OpenMetadataConnection.model_validate(
-            {
-                "hostPort": settings.BACKEND_URL,
-                "authProvider": "openmetadata",
-                "securityConfig": {"jwtToken": "token"},
-            }
-        )
+        config=OpenMetadataConnection.model_validate(get_server_config())
     )
     return metadata
 
@@ -345,11 +351,7 @@ def runWorkFlow(
                     "sink": {"type": "metadata-rest", "config": {}},
                     "workflowConfig": {
                         "loggerLevel": "INFO",
-                        "openMetadataServerConfig": {
-                            "hostPort": settings.BACKEND_URL,
-                            "authProvider": "openmetadata",
-                            "securityConfig": {"jwtToken": "token"},
-                        },
+                        "openMetadataServerConfig": get_server_config(),
                     },
                 }
             ),